@@ -0,0 +1,112 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/decred/slog"
+	flags "github.com/jessevdk/go-flags"
+
+	"github.com/decred/dcrpool/pool"
+)
+
+// checkDBCmd is the subcommand checking the integrity of the pool accounting
+// state.
+const checkDBCmd = "checkdb"
+
+// checkDBConfig defines the options of the checkdb subcommand.
+type checkDBConfig struct {
+	Backend string `long:"backend" description:"Database backend to check {bolt, postgres, sqlite}."`
+	JSON    bool   `long:"json" description:"Report the violations found as JSON."`
+	Repair  bool   `long:"repair" description:"Repair the violations which can be safely repaired."`
+
+	dbBackendConfig
+}
+
+// checkDB runs the integrity checks of the pool accounting state against the
+// database of the configured backend and reports the violations found.
+// Returns an error if violations remain unrepaired.
+func checkDB(args []string) error {
+	cfg := checkDBConfig{
+		Backend:         boltBackend,
+		dbBackendConfig: defaultDBBackendConfig(),
+	}
+	parser := flags.NewParser(&cfg, flags.HelpFlag)
+	parser.Usage = checkDBCmd + " [OPTIONS]"
+	_, err := parser.ParseArgs(args)
+	if err != nil {
+		var e *flags.Error
+		if errors.As(err, &e) && e.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stdout)
+			return nil
+		}
+		return err
+	}
+
+	err = validateBackend(cfg.Backend)
+	if err != nil {
+		return err
+	}
+	cfg.DBFile = cleanAndExpandPath(cfg.DBFile)
+	cfg.SQLiteFile = cleanAndExpandPath(cfg.SQLiteFile)
+
+	// Avoid creating an empty database file.
+	dbFile := backendFile(&cfg.dbBackendConfig, cfg.Backend)
+	if dbFile != "" {
+		_, err := os.Stat(dbFile)
+		if err != nil {
+			return fmt.Errorf("unable to open database: %w", err)
+		}
+	}
+
+	// The log rotator is not initialized for subcommands. Logging is
+	// limited to errors so the JSON report is the only output.
+	logger := slog.NewBackend(os.Stderr).Logger("POOL")
+	logger.SetLevel(slog.LevelError)
+	pool.UseLogger(logger)
+
+	db, err := openBackendDB(&cfg.dbBackendConfig, cfg.Backend, false)
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	defer db.Close()
+
+	report, err := pool.CheckDB(db, cfg.Repair)
+	if err != nil {
+		return err
+	}
+
+	if cfg.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(report)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, v := range report.Violations {
+			status := "unrepaired"
+			switch {
+			case v.Repaired:
+				status = "repaired"
+			case v.Repairable:
+				status = "repairable"
+			}
+			fmt.Printf("%-22s %-15s %s: %s (%s)\n", v.Check, v.Kind, v.ID,
+				v.Description, status)
+		}
+		fmt.Printf("Ran %d checks, found %d violations, %d unrepaired.\n",
+			len(report.Checks), len(report.Violations), report.Unrepaired())
+	}
+
+	if unrepaired := report.Unrepaired(); unrepaired > 0 {
+		return fmt.Errorf("%d violations unrepaired", unrepaired)
+	}
+	return nil
+}
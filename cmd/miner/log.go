@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/decred/dcrpool/internal/cpuminer"
 	"github.com/decred/slog"
 	"github.com/jrick/logrotate/rotator"
 )
@@ -46,6 +47,7 @@ var (
 
 // Initialize package-global logger variables.
 func init() {
+	cpuminer.UseLogger(log)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -10,6 +10,8 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+
+	"github.com/decred/dcrpool/internal/cpuminer"
 )
 
 func main() {
@@ -34,7 +36,14 @@ func main() {
 
 	// Initialize and run the client.
 	ctx, cancel := context.WithCancel(context.Background())
-	miner := NewMiner(cfg, cancel)
+	miner := cpuminer.NewMiner(&cpuminer.Config{
+		ActiveNet: cfg.net,
+		User:      cfg.User,
+		Address:   cfg.Address,
+		Pool:      cfg.Pool,
+		UserAgent: cfg.UserAgent,
+		Stall:     cfg.Stall,
+	}, cancel)
 
 	log.Infof("Version: %s", version())
 	log.Infof("Runtime: Go version %s", runtime.Version())
@@ -53,7 +62,7 @@ func main() {
 			err := http.ListenAndServe(listenAddr, nil)
 			if err != nil {
 				log.Criticalf(err.Error())
-				miner.cancel()
+				cancel()
 			}
 		}()
 	}
@@ -61,12 +70,12 @@ func main() {
 	go func() {
 		select {
 		case <-interrupt:
-			miner.cancel()
+			cancel()
 
 		case <-ctx.Done():
 			return
 		}
 	}()
 
-	miner.run(ctx)
+	miner.Run(ctx)
 }
@@ -35,15 +35,24 @@ const (
 	defaultLogDirname            = "log"
 	defaultLogFilename           = "dcrpool.log"
 	defaultDBFilename            = "dcrpool.kv"
+	defaultSQLiteFilename        = "dcrpool.sqlite"
 	defaultGUITLSCertFilename    = "dcrpool.cert"
 	defaultGUITLSKeyFilename     = "dcrpool.key"
 	defaultWalletTLSCertFilename = "wallet.cert"
 	defaultWalletTLSKeyFilename  = "wallet.key"
 	defaultDcrdRPCHost           = "127.0.0.1"
+	defaultNodeHealthInterval    = time.Second * 10
+	defaultNodeNotifyTimeout     = time.Minute * 2
+	defaultNodeMaxTipLag         = 2
+	defaultBlockRetryDelay       = time.Second * 5
+	defaultBlockMaxRetryDelay    = time.Minute * 10
 	defaultWalletGRPCHost        = "127.0.0.1"
+	defaultWalletCheckInterval   = time.Minute
+	defaultWalletMaxSyncLag      = 2
 	defaultMaxGenTime            = time.Second * 15
 	defaultPoolFee               = 0.01
 	defaultLastNPeriod           = time.Hour * 24
+	defaultShareSlice            = time.Second
 	defaultSoloPool              = false
 	defaultGUIPort               = "8080"
 	defaultGUIListen             = "0.0.0.0"
@@ -56,14 +65,25 @@ const (
 	defaultWalletAccount         = 0
 	defaultCoinbaseConfTimeout   = time.Minute * 5 // one block time
 	defaultUsePostgres           = false
+	defaultUseSQLite             = false
 	defaultPGHost                = "127.0.0.1"
 	defaultPGPort                = 5432
 	defaultPGUser                = "dcrpooluser"
 	defaultPGPass                = "12345"
 	defaultPGDBName              = "dcrpooldb"
+	defaultPGConnectTimeout      = time.Second * 10
 	defaultMonitorCycle          = time.Minute * 2
 	defaultMaxUpgradeTries       = 10
 	defaultNoGUITLS              = false
+	defaultBackupDirname         = "backups"
+	defaultPayoutDirname         = "payouts"
+	defaultBackupInterval        = time.Hour
+	defaultBackupKeepHourly      = 24
+	defaultBackupKeepDaily       = 7
+	defaultBackupCompress        = false
+	defaultWebhookMaxAttempts    = 10
+	defaultWorkerOfflineTimeout  = time.Minute * 10
+	defaultWorkerHashRateFrac    = 0.5
 )
 
 var (
@@ -73,6 +93,7 @@ var (
 	defaultConfigFile    = filepath.Join(dcrpoolHomeDir, defaultConfigFilename)
 	defaultDataDir       = filepath.Join(dcrpoolHomeDir, defaultDataDirname)
 	defaultDBFile        = filepath.Join(defaultDataDir, defaultDBFilename)
+	defaultSQLiteFile    = filepath.Join(defaultDataDir, defaultSQLiteFilename)
 	defaultLogDir        = filepath.Join(dcrpoolHomeDir, defaultLogDirname)
 
 	// This keypair is solely for enabling HTTPS connections to the pool's
@@ -102,7 +123,16 @@ type config struct {
 	DBFile                string        `long:"dbfile" ini-name:"dbfile" description:"Path to the database file."`
 	DcrdRPCHost           string        `long:"dcrdrpchost" ini-name:"dcrdrpchost" description:"The ip:port to establish an RPC connection for dcrd."`
 	DcrdRPCCert           string        `long:"dcrdrpccert" ini-name:"dcrdrpccert" description:"The dcrd RPC certificate."`
+	DcrdFailoverRPCHosts  []string      `long:"dcrdfailoverrpchost" ini-name:"dcrdfailoverrpchost" description:"The ip:port of a dcrd taking over when the dcrd at dcrdrpchost fails, authenticated with the same rpcuser, rpcpass and dcrdrpccert. May be provided multiple times, in order of preference."`
+	DcrdSubmitRPCHosts    []string      `long:"dcrdsubmitrpchost" ini-name:"dcrdsubmitrpchost" description:"The ip:port of an additional dcrd solved blocks are submitted to concurrently, authenticated with the same rpcuser, rpcpass and dcrdrpccert. May be provided multiple times."`
+	NodeHealthInterval    time.Duration `long:"nodehealthinterval" ini-name:"nodehealthinterval" description:"The interval between dcrd health checks when failover dcrd hosts are configured. Valid time units are {s,m}."`
+	NodeNotifyTimeout     time.Duration `long:"nodenotifytimeout" ini-name:"nodenotifytimeout" description:"The time without notifications from the active dcrd after which a failover dcrd ahead of it takes over. Valid time units are {s,m,h}."`
+	NodeMaxTipLag         int64         `long:"nodemaxtiplag" ini-name:"nodemaxtiplag" description:"The number of blocks the active dcrd may lag behind the best failover dcrd before it takes over."`
+	BlockRetryDelay       time.Duration `long:"blockretrydelay" ini-name:"blockretrydelay" description:"The delay before retrying a block notification which failed to process, doubling with every attempt. Valid time units are {s,m}."`
+	BlockMaxRetryDelay    time.Duration `long:"blockmaxretrydelay" ini-name:"blockmaxretrydelay" description:"The maximum delay before retrying a block notification which failed to process. Valid time units are {s,m,h}."`
 	WalletGRPCHost        string        `long:"walletgrpchost" ini-name:"walletgrpchost" description:"The ip:port to establish a GRPC connection for the wallet."`
+	WalletCheckInterval   time.Duration `long:"walletcheckinterval" ini-name:"walletcheckinterval" description:"The interval between wallet health checks of connectivity, sync height, spendable balance and unlock state. Valid time units are {s,m,h}."`
+	WalletMaxSyncLag      uint32        `long:"walletmaxsynclag" ini-name:"walletmaxsynclag" description:"The number of blocks the wallet may lag behind dcrd before it is reported as out of sync."`
 	WalletRPCCert         string        `long:"walletrpccert" ini-name:"walletrpccert" description:"The wallet RPC certificate."`
 	RPCUser               string        `long:"rpcuser" ini-name:"rpcuser" description:"Username for RPC connections."`
 	RPCPass               string        `long:"rpcpass" ini-name:"rpcpass" default-mask:"-" description:"Password for RPC connections."`
@@ -112,11 +142,13 @@ type config struct {
 	MaxGenTime            time.Duration `long:"maxgentime" ini-name:"maxgentime" description:"The share creation target time for the pool. Valid time units are {s,m,h}. Minimum 2 seconds. This currently should be below 30 seconds to increase the likelihood a work submission for clients between new work distributions by the pool."`
 	PaymentMethod         string        `long:"paymentmethod" ini-name:"paymentmethod" description:"The payment method of the pool. {pps, pplns}"`
 	LastNPeriod           time.Duration `long:"lastnperiod" ini-name:"lastnperiod" description:"The time period of interest when using PPLNS payment scheme. Valid time units are {s,m,h}. Minimum 60 seconds."`
+	ShareSlice            time.Duration `long:"shareslice" ini-name:"shareslice" description:"The time slice accepted shares of an account are aggregated over when using the pps payment method, pplns pools persist every share individually. Valid time units are {ms,s,m}. Set to 0 to persist every share individually."`
 	WalletPass            string        `long:"walletpass" ini-name:"walletpass" description:"The wallet passphrase to use when paying dividends to pool contributors."`
+	OfflineSigning        bool          `long:"offlinesigning" ini-name:"offlinesigning" description:"Export payout transactions for signing by an external wallet instead of signing them with the wallet passphrase. Signed payouts are uploaded through the admin panel."`
 	WalletAccount         uint32        `long:"walletaccount" ini-name:"walletaccount" description:"The wallet account that will receive mining rewards when not mining as a solo pool."`
 	MinPayment            float64       `long:"minpayment" ini-name:"minpayment" description:"DEPRECATED -- The minimum payment to process for an account."`
 	SoloPool              bool          `long:"solopool" ini-name:"solopool" description:"Solo pool mode. This disables payment processing when enabled."`
-	AdminPass             string        `long:"adminpass" ini-name:"adminpass" description:"The admin password."`
+	AdminPass             string        `long:"adminpass" ini-name:"adminpass" description:"The password of the default admin user, created when no admin users exist."`
 	GUIDir                string        `long:"guidir" ini-name:"guidir" description:"The path to the directory containing the pool's user interface assets (templates, css etc.)"`
 	Domain                string        `long:"domain" ini-name:"domain" description:"The domain of the mining pool, required for TLS."`
 	UseLEHTTPS            bool          `long:"uselehttps" ini-name:"uselehttps" description:"This enables HTTPS using a Letsencrypt certificate. By default the pool uses a self-signed certificate for HTTPS."`
@@ -136,10 +168,33 @@ type config struct {
 	PGUser                string        `long:"postgresuser" ini-name:"postgresuser" description:"Username for postgres authentication."`
 	PGPass                string        `long:"postgrespass" ini-name:"postgrespass" description:"Password for postgres authentication."`
 	PGDBName              string        `long:"postgresdbname" ini-name:"postgresdbname" description:"Postgres database name."`
-	PurgeDB               bool          `long:"purgedb" ini-name:"purgedb" description:"Wipes all existing data on startup for a postgres backend. This intended for simnet testing purposes only."`
+	PGDSN                 string        `long:"postgresdsn" ini-name:"postgresdsn" description:"Full postgres connection string, either a URL or key/value pairs. Overrides the postgres host, port, user, password and database name."`
+	PGSSLMode             string        `long:"postgressslmode" ini-name:"postgressslmode" description:"Postgres ssl mode {disable, require, verify-ca, verify-full}. Defaults to disable unless set by the postgres connection string."`
+	PGSSLRootCert         string        `long:"postgressslrootcert" ini-name:"postgressslrootcert" description:"Path to the certificate authorities verifying the postgres server certificate."`
+	PGSSLCert             string        `long:"postgressslcert" ini-name:"postgressslcert" description:"Path to the postgres client certificate."`
+	PGSSLKey              string        `long:"postgressslkey" ini-name:"postgressslkey" description:"Path to the postgres client certificate key."`
+	PGMaxOpenConns        int           `long:"postgresmaxopenconns" ini-name:"postgresmaxopenconns" description:"Maximum number of open postgres connections. Set to 0 for no limit."`
+	PGMaxIdleConns        int           `long:"postgresmaxidleconns" ini-name:"postgresmaxidleconns" description:"Maximum number of idle postgres connections. Set to 0 for the default of 2."`
+	PGConnMaxLifetime     time.Duration `long:"postgresconnmaxlifetime" ini-name:"postgresconnmaxlifetime" description:"Maximum time a postgres connection is reused. Valid time units are {s,m,h}. Set to 0 to reuse connections forever."`
+	PGStatementTimeout    time.Duration `long:"postgresstatementtimeout" ini-name:"postgresstatementtimeout" description:"Time after which postgres aborts a statement. Valid time units are {ms,s,m}. Set to 0 for no timeout."`
+	PGConnectTimeout      time.Duration `long:"postgresconnecttimeout" ini-name:"postgresconnecttimeout" description:"Time allowed to establish a postgres connection. Valid time units are {s,m}. Set to 0 for no timeout."`
+	UseSQLite             bool          `long:"sqlite" ini-name:"sqlite" description:"Use an embedded sqlite database instead of bolt."`
+	SQLiteFile            string        `long:"sqlitefile" ini-name:"sqlitefile" description:"Path to the sqlite database file."`
+	PurgeDB               bool          `long:"purgedb" ini-name:"purgedb" description:"Wipes all existing data on startup for a postgres or sqlite backend. This intended for simnet testing purposes only."`
 	MonitorCycle          time.Duration `long:"monitorcycle" ini-name:"monitorcycle" description:"Time spent monitoring a mining client for possible upgrades."`
 	MaxUpgradeTries       uint32        `long:"maxupgradetries" ini-name:"maxupgradetries" description:"Maximum consecuctive miner monitoring and upgrade tries."`
 	NoGUITLS              bool          `long:"noguitls" ini-name:"noguitls" description:"Disable TLS on GUI endpoint (eg. for reverse proxy with a dedicated webserver)."`
+	RestoreFile           string        `long:"restore" no-ini:"true" description:"Restore the database from the provided backup archive or bolt snapshot manifest and exit. The database must not have been used by a pool yet, a snapshot is only restored to a bolt database file that does not exist."`
+	BackupInterval        time.Duration `long:"backupinterval" ini-name:"backupinterval" description:"The interval between scheduled snapshots of a bolt database, written to the backups directory of the data directory. Valid time units are {m,h}. Set to 0 to disable scheduled snapshots."`
+	BackupKeepHourly      int           `long:"backupkeephourly" ini-name:"backupkeephourly" description:"The number of latest hours whose newest scheduled snapshot is retained."`
+	BackupKeepDaily       int           `long:"backupkeepdaily" ini-name:"backupkeepdaily" description:"The number of latest days whose newest scheduled snapshot is retained."`
+	BackupCompress        bool          `long:"backupcompress" ini-name:"backupcompress" description:"Compress scheduled snapshots with gzip."`
+	APIAllowedOrigins     []string      `long:"apiallowedorigins" ini-name:"apiallowedorigins" description:"Origins permitted to make cross-origin requests to the public JSON API. All origins are permitted when unset."`
+	WebhookURLs           []string      `long:"webhookurl" ini-name:"webhookurl" description:"An http or https URL notified of all pool events with signed JSON payloads. May be provided multiple times."`
+	WebhookSecret         string        `long:"webhooksecret" ini-name:"webhooksecret" default-mask:"-" description:"The secret signing the payloads delivered to the webhookurl URLs, required when webhookurl is set."`
+	WebhookMaxAttempts    uint32        `long:"webhookmaxattempts" ini-name:"webhookmaxattempts" description:"The number of attempts to deliver a webhook event before it is dropped."`
+	WorkerOfflineTimeout  time.Duration `long:"workerofflinetimeout" ini-name:"workerofflinetimeout" description:"The period a named worker may go without submitting shares before it is reported offline. Valid time units are {s,m,h}."`
+	WorkerHashRateFrac    float64       `long:"workerhashratefraction" ini-name:"workerhashratefraction" description:"The fraction of its baseline hashrate a named worker may drop to before its hashrate is reported low. Set to 0 to disable low hashrate detection."`
 	poolFeeAddrs          []dcrutil.Address
 	dcrdRPCCerts          []byte
 	net                   *params
@@ -176,28 +231,52 @@ func supportedSubsystems() []string {
 // the levels accordingly.  An appropriate error is returned if anything is
 // invalid.
 func parseAndSetDebugLevels(debugLevel string) error {
+	levels, err := parseDebugLevels(debugLevel)
+	if err != nil {
+		return err
+	}
+	for subsysID, logLevel := range levels {
+		setLogLevel(subsysID, logLevel)
+	}
+	return nil
+}
+
+// validateDebugLevels attempts to parse the specified debug level without
+// setting the levels.
+func validateDebugLevels(debugLevel string) error {
+	_, err := parseDebugLevels(debugLevel)
+	return err
+}
+
+// parseDebugLevels attempts to parse the specified debug level and returns the
+// levels of the subsystems it specifies.
+func parseDebugLevels(debugLevel string) (map[string]string, error) {
 	// When the specified string doesn't have any delimiters, treat it as
 	// the log level for all subsystems.
 	if !strings.Contains(debugLevel, ",") && !strings.Contains(debugLevel, "=") {
 		// Validate debug log level.
 		if !validLogLevel(debugLevel) {
 			str := "the specified debug level [%v] is invalid"
-			return fmt.Errorf(str, debugLevel)
+			return nil, fmt.Errorf(str, debugLevel)
 		}
 
-		// Change the logging level for all subsystems.
-		setLogLevels(debugLevel)
+		// Apply the logging level to all subsystems.
+		levels := make(map[string]string, len(subsystemLoggers))
+		for subsysID := range subsystemLoggers {
+			levels[subsysID] = debugLevel
+		}
 
-		return nil
+		return levels, nil
 	}
 
 	// Split the specified string into subsystem/level pairs while detecting
-	// issues and update the log levels accordingly.
+	// issues.
+	levels := make(map[string]string)
 	for _, logLevelPair := range strings.Split(debugLevel, ",") {
 		if !strings.Contains(logLevelPair, "=") {
 			str := "the specified debug level contains an invalid " +
 				"subsystem/level pair [%v]"
-			return fmt.Errorf(str, logLevelPair)
+			return nil, fmt.Errorf(str, logLevelPair)
 		}
 
 		// Extract the specified subsystem and log level.
@@ -208,19 +287,19 @@ func parseAndSetDebugLevels(debugLevel string) error {
 		if _, exists := subsystemLoggers[subsysID]; !exists {
 			str := "the specified subsystem [%v] is invalid -- " +
 				"supported subsytems %v"
-			return fmt.Errorf(str, subsysID, supportedSubsystems())
+			return nil, fmt.Errorf(str, subsysID, supportedSubsystems())
 		}
 
 		// Validate log level.
 		if !validLogLevel(logLevel) {
 			str := "the specified debug level [%v] is invalid"
-			return fmt.Errorf(str, logLevel)
+			return nil, fmt.Errorf(str, logLevel)
 		}
 
-		setLogLevel(subsysID, logLevel)
+		levels[subsysID] = logLevel
 	}
 
-	return nil
+	return levels, nil
 }
 
 // fileExists reports whether the named file or directory exists.
@@ -341,7 +420,13 @@ func normalizeAddress(addr, defaultPort string) string {
 // The above results in dcrpool functioning properly without any config settings
 // while still allowing the user to override settings with config files and
 // command line options.  Command line options always take precedence.
+//
+// When logging has already been initialized the config is being reloaded, in
+// which case the log rotator is kept and debug levels are only validated, it
+// is up to the caller to apply them.
 func loadConfig() (*config, []string, error) {
+	reload := logRotator != nil
+
 	// Default config.
 	cfg := config{
 		HomeDir:               dcrpoolHomeDir,
@@ -351,12 +436,20 @@ func loadConfig() (*config, []string, error) {
 		DebugLevel:            defaultLogLevel,
 		LogDir:                defaultLogDir,
 		DcrdRPCHost:           defaultDcrdRPCHost,
+		NodeHealthInterval:    defaultNodeHealthInterval,
+		NodeNotifyTimeout:     defaultNodeNotifyTimeout,
+		NodeMaxTipLag:         defaultNodeMaxTipLag,
+		BlockRetryDelay:       defaultBlockRetryDelay,
+		BlockMaxRetryDelay:    defaultBlockMaxRetryDelay,
 		WalletGRPCHost:        defaultWalletGRPCHost,
+		WalletCheckInterval:   defaultWalletCheckInterval,
+		WalletMaxSyncLag:      defaultWalletMaxSyncLag,
 		PoolFee:               defaultPoolFee,
 		MaxGenTime:            defaultMaxGenTime,
 		ActiveNet:             defaultActiveNet,
 		PaymentMethod:         defaultPaymentMethod,
 		LastNPeriod:           defaultLastNPeriod,
+		ShareSlice:            defaultShareSlice,
 		SoloPool:              defaultSoloPool,
 		GUIListen:             defaultGUIListen,
 		GUIDir:                defaultGUIDir,
@@ -371,14 +464,24 @@ func loadConfig() (*config, []string, error) {
 		WalletAccount:         defaultWalletAccount,
 		CoinbaseConfTimeout:   defaultCoinbaseConfTimeout,
 		UsePostgres:           defaultUsePostgres,
+		UseSQLite:             defaultUseSQLite,
+		SQLiteFile:            defaultSQLiteFile,
 		PGHost:                defaultPGHost,
 		PGPort:                defaultPGPort,
 		PGUser:                defaultPGUser,
 		PGPass:                defaultPGPass,
 		PGDBName:              defaultPGDBName,
+		PGConnectTimeout:      defaultPGConnectTimeout,
 		MonitorCycle:          defaultMonitorCycle,
 		MaxUpgradeTries:       defaultMaxUpgradeTries,
 		NoGUITLS:              defaultNoGUITLS,
+		BackupInterval:        defaultBackupInterval,
+		BackupKeepHourly:      defaultBackupKeepHourly,
+		BackupKeepDaily:       defaultBackupKeepDaily,
+		BackupCompress:        defaultBackupCompress,
+		WebhookMaxAttempts:    defaultWebhookMaxAttempts,
+		WorkerOfflineTimeout:  defaultWorkerOfflineTimeout,
+		WorkerHashRateFrac:    defaultWorkerHashRateFrac,
 	}
 
 	// Service options which are only added on Windows.
@@ -461,6 +564,11 @@ func loadConfig() (*config, []string, error) {
 		} else {
 			cfg.DBFile = preCfg.DBFile
 		}
+		if preCfg.SQLiteFile == defaultSQLiteFile {
+			cfg.SQLiteFile = filepath.Join(cfg.DataDir, defaultSQLiteFilename)
+		} else {
+			cfg.SQLiteFile = preCfg.SQLiteFile
+		}
 		if preCfg.GUITLSCert == defaultGUITLSCertFile {
 			cfg.GUITLSCert = filepath.Join(cfg.HomeDir, defaultGUITLSCertFilename)
 		} else {
@@ -567,18 +675,10 @@ func loadConfig() (*config, []string, error) {
 	cfg.DataDir = cleanAndExpandPath(filepath.Join(cfg.DataDir, cfg.net.Name))
 	cfg.LogDir = cleanAndExpandPath(filepath.Join(cfg.LogDir, cfg.net.Name))
 
-	logRotator = nil
-
 	// Initialize log rotation.  After log rotation has been initialized, the
 	// logger variables may be used.
-	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
-
-	// Ensure the admin password is set.
-	if cfg.AdminPass == "" {
-		err := fmt.Errorf("the adminpass option is not set")
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
+	if !reload {
+		initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
 	}
 
 	// Ensure the dcrd rpc username is set.
@@ -606,8 +706,17 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Assert postgres config details are valid if being used.
-	if cfg.UsePostgres {
+	if cfg.UsePostgres && cfg.UseSQLite {
+		err := fmt.Errorf("the postgres and sqlite options can not be " +
+			"used together")
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Assert postgres config details are valid if being used. The connection
+	// details are part of the connection string when provided.
+	if cfg.UsePostgres && cfg.PGDSN == "" {
 		if cfg.PGHost == "" {
 			err := fmt.Errorf("the postgreshost option is not set")
 			fmt.Fprintln(os.Stderr, err)
@@ -630,14 +739,41 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	if cfg.UsePostgres {
+		if cfg.PGMaxOpenConns < 0 || cfg.PGMaxIdleConns < 0 {
+			str := "the postgresmaxopenconns and postgresmaxidleconns " +
+				"options may not be negative -- parsed [%d, %d]"
+			err := fmt.Errorf(str, cfg.PGMaxOpenConns, cfg.PGMaxIdleConns)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		if cfg.PGConnMaxLifetime < 0 || cfg.PGStatementTimeout < 0 ||
+			cfg.PGConnectTimeout < 0 {
+			str := "the postgresconnmaxlifetime, postgresstatementtimeout " +
+				"and postgresconnecttimeout options may not be negative " +
+				"-- parsed [%v, %v, %v]"
+			err := fmt.Errorf(str, cfg.PGConnMaxLifetime,
+				cfg.PGStatementTimeout, cfg.PGConnectTimeout)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Special show command to list supported subsystems and exit.
-	if cfg.DebugLevel == "show" {
+	if cfg.DebugLevel == "show" && !reload {
 		fmt.Println("Supported subsystems", supportedSubsystems())
 		os.Exit(0)
 	}
 
 	// Parse, validate, and set debug log level(s).
-	if err := parseAndSetDebugLevels(cfg.DebugLevel); err != nil {
+	setDebugLevels := parseAndSetDebugLevels
+	if reload {
+		setDebugLevels = validateDebugLevels
+	}
+	if err := setDebugLevels(cfg.DebugLevel); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
 		return nil, nil, err
@@ -645,6 +781,48 @@ func loadConfig() (*config, []string, error) {
 
 	// Add default ports for the active network if there are no ports specified.
 	cfg.DcrdRPCHost = normalizeAddress(cfg.DcrdRPCHost, cfg.net.DcrdRPCServerPort)
+	for i, host := range cfg.DcrdFailoverRPCHosts {
+		cfg.DcrdFailoverRPCHosts[i] = normalizeAddress(host,
+			cfg.net.DcrdRPCServerPort)
+	}
+	for i, host := range cfg.DcrdSubmitRPCHosts {
+		cfg.DcrdSubmitRPCHosts[i] = normalizeAddress(host,
+			cfg.net.DcrdRPCServerPort)
+	}
+
+	// Ensure the dcrd health check options are valid.
+	if cfg.NodeHealthInterval <= 0 || cfg.NodeNotifyTimeout <= 0 ||
+		cfg.NodeMaxTipLag <= 0 {
+		str := "%s: the nodehealthinterval, nodenotifytimeout and " +
+			"nodemaxtiplag options must be positive -- parsed [%v, %v, %d]"
+		err := fmt.Errorf(str, funcName, cfg.NodeHealthInterval,
+			cfg.NodeNotifyTimeout, cfg.NodeMaxTipLag)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the wallet health check options are valid.
+	if cfg.WalletCheckInterval <= 0 || cfg.WalletMaxSyncLag == 0 {
+		str := "%s: the walletcheckinterval and walletmaxsynclag options " +
+			"must be positive -- parsed [%v, %d]"
+		err := fmt.Errorf(str, funcName, cfg.WalletCheckInterval,
+			cfg.WalletMaxSyncLag)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the block notification retry delays are valid.
+	if cfg.BlockRetryDelay <= 0 || cfg.BlockMaxRetryDelay < cfg.BlockRetryDelay {
+		str := "%s: the blockretrydelay option must be positive and not " +
+			"exceed the blockmaxretrydelay option -- parsed [%v, %v]"
+		err := fmt.Errorf(str, funcName, cfg.BlockRetryDelay,
+			cfg.BlockMaxRetryDelay)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
 	cfg.WalletGRPCHost = normalizeAddress(cfg.WalletGRPCHost, cfg.net.WalletGRPCServerPort)
 
 	cfg.MinerListen = normalizeAddress(cfg.MinerListen, defaultMinerPort)
@@ -670,8 +848,9 @@ func loadConfig() (*config, []string, error) {
 		}
 
 		// Ensure the passphrase to unlock the wallet is provided.
-		// Wallet passphrase is required to pay dividends to pool contributors.
-		if cfg.WalletPass == "" {
+		// Wallet passphrase is required to pay dividends to pool contributors
+		// unless payouts are signed by an external wallet.
+		if cfg.WalletPass == "" && !cfg.OfflineSigning {
 			err := fmt.Errorf("the walletpass option is not set")
 			fmt.Fprintln(os.Stderr, err)
 			fmt.Fprintln(os.Stderr, usageMessage)
@@ -725,6 +904,85 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Do not allow negative share slice durations.
+	if cfg.ShareSlice < 0 {
+		str := "the shareslice option may not be negative -- parsed [%v]"
+		err := fmt.Errorf(str, cfg.ShareSlice)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Scheduled snapshots are written at most every minute, snapshot file
+	// names have a resolution of a second.
+	if cfg.BackupInterval != 0 && cfg.BackupInterval < time.Minute {
+		str := "the backupinterval option may not be less than 1m " +
+			"unless 0 -- parsed [%v]"
+		err := fmt.Errorf(str, cfg.BackupInterval)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// At least one scheduled snapshot must be retained.
+	if cfg.BackupKeepHourly < 0 || cfg.BackupKeepDaily < 0 ||
+		(cfg.BackupInterval > 0 &&
+			cfg.BackupKeepHourly+cfg.BackupKeepDaily == 0) {
+		str := "the backupkeephourly and backupkeepdaily options may " +
+			"not be negative and must retain at least one snapshot -- " +
+			"parsed [%d, %d]"
+		err := fmt.Errorf(str, cfg.BackupKeepHourly, cfg.BackupKeepDaily)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the webhook options are valid. Payloads delivered to the
+	// webhook URLs are always signed.
+	for _, hookURL := range cfg.WebhookURLs {
+		err := pool.ValidateWebhookURL(hookURL)
+		if err != nil {
+			str := "%s: invalid webhookurl: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+	if len(cfg.WebhookURLs) > 0 && cfg.WebhookSecret == "" {
+		str := "%s: the webhooksecret option is required when webhookurl " +
+			"is set"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.WebhookMaxAttempts == 0 {
+		str := "%s: the webhookmaxattempts option must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the worker monitoring options are valid.
+	if cfg.WorkerOfflineTimeout < time.Minute {
+		str := "%s: the workerofflinetimeout option must be at least " +
+			"a minute"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.WorkerHashRateFrac < 0 || cfg.WorkerHashRateFrac >= 1 {
+		str := "%s: the workerhashratefraction option must be in the " +
+			"range [0, 1)"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Warn about missing config file only after all other configuration is
 	// done. This prevents the warning on help messages and invalid
 	// options. Note this should go directly before the return.
@@ -870,3 +1128,24 @@ func loadConfig() (*config, []string, error) {
 
 	return &cfg, remainingArgs, nil
 }
+
+// pgConfig returns the postgres connection details of the configuration.
+func (cfg *config) pgConfig() *pool.PostgresConfig {
+	return &pool.PostgresConfig{
+		Host:             cfg.PGHost,
+		Port:             cfg.PGPort,
+		User:             cfg.PGUser,
+		Pass:             cfg.PGPass,
+		DBName:           cfg.PGDBName,
+		DSN:              cfg.PGDSN,
+		SSLMode:          cfg.PGSSLMode,
+		SSLRootCert:      cleanAndExpandPath(cfg.PGSSLRootCert),
+		SSLCert:          cleanAndExpandPath(cfg.PGSSLCert),
+		SSLKey:           cleanAndExpandPath(cfg.PGSSLKey),
+		MaxOpenConns:     cfg.PGMaxOpenConns,
+		MaxIdleConns:     cfg.PGMaxIdleConns,
+		ConnMaxLifetime:  cfg.PGConnMaxLifetime,
+		StatementTimeout: cfg.PGStatementTimeout,
+		ConnectTimeout:   cfg.PGConnectTimeout,
+	}
+}
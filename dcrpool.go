@@ -13,7 +13,11 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/decred/dcrd/rpcclient/v6"
 	"github.com/decred/dcrpool/gui"
@@ -26,11 +30,13 @@ type miningPool struct {
 	cancel context.CancelFunc
 	hub    *pool.Hub
 	gui    *gui.GUI
+	cfg    *config
+	cfgMtx sync.Mutex
 }
 
 // newPool initializes the mining pool.
 func newPool(db pool.Database, cfg *config) (*miningPool, error) {
-	p := new(miningPool)
+	p := &miningPool{cfg: cfg}
 	dcrdRPCCfg := &rpcclient.ConnConfig{
 		Host:         cfg.DcrdRPCHost,
 		Endpoint:     "ws",
@@ -38,34 +44,77 @@ func newPool(db pool.Database, cfg *config) (*miningPool, error) {
 		Pass:         cfg.RPCPass,
 		Certificates: cfg.dcrdRPCCerts,
 	}
+	var failoverRPCCfgs []*rpcclient.ConnConfig
+	for _, host := range cfg.DcrdFailoverRPCHosts {
+		failoverRPCCfgs = append(failoverRPCCfgs, &rpcclient.ConnConfig{
+			Host:         host,
+			Endpoint:     "ws",
+			User:         cfg.RPCUser,
+			Pass:         cfg.RPCPass,
+			Certificates: cfg.dcrdRPCCerts,
+		})
+	}
+	var submitRPCCfgs []*rpcclient.ConnConfig
+	for _, host := range cfg.DcrdSubmitRPCHosts {
+		submitRPCCfgs = append(submitRPCCfgs, &rpcclient.ConnConfig{
+			Host:         host,
+			Endpoint:     "ws",
+			User:         cfg.RPCUser,
+			Pass:         cfg.RPCPass,
+			Certificates: cfg.dcrdRPCCerts,
+		})
+	}
 	p.ctx, p.cancel = context.WithCancel(context.Background())
 	powLimit := cfg.net.PowLimit
 	powLimitF, _ := new(big.Float).SetInt(powLimit).Float64()
 	iterations := math.Pow(2, 256-math.Floor(math.Log2(powLimitF)))
 
 	hcfg := &pool.HubConfig{
-		DB:                    db,
-		NodeRPCConfig:         dcrdRPCCfg,
-		WalletRPCCert:         cfg.WalletRPCCert,
-		WalletTLSCert:         cfg.WalletTLSCert,
-		WalletTLSKey:          cfg.WalletTLSKey,
-		WalletGRPCHost:        cfg.WalletGRPCHost,
-		ActiveNet:             cfg.net.Params,
-		PoolFee:               cfg.PoolFee,
-		MaxGenTime:            cfg.MaxGenTime,
-		PaymentMethod:         cfg.PaymentMethod,
-		LastNPeriod:           cfg.LastNPeriod,
-		WalletPass:            cfg.WalletPass,
-		PoolFeeAddrs:          cfg.poolFeeAddrs,
-		SoloPool:              cfg.SoloPool,
-		NonceIterations:       iterations,
-		MinerListen:           cfg.MinerListen,
-		MaxConnectionsPerHost: cfg.MaxConnectionsPerHost,
-		WalletAccount:         cfg.WalletAccount,
-		CoinbaseConfTimeout:   cfg.CoinbaseConfTimeout,
-		MonitorCycle:          cfg.MonitorCycle,
-		MaxUpgradeTries:       cfg.MaxUpgradeTries,
-		ClientTimeout:         cfg.clientTimeout,
+		DB:                      db,
+		NodeRPCConfig:           dcrdRPCCfg,
+		FailoverRPCConfigs:      failoverRPCCfgs,
+		SubmitRPCConfigs:        submitRPCCfgs,
+		NodeHealthInterval:      cfg.NodeHealthInterval,
+		NodeNotifyTimeout:       cfg.NodeNotifyTimeout,
+		NodeMaxTipLag:           cfg.NodeMaxTipLag,
+		BlockEventRetryDelay:    cfg.BlockRetryDelay,
+		BlockEventMaxRetryDelay: cfg.BlockMaxRetryDelay,
+		WalletRPCCert:           cfg.WalletRPCCert,
+		WalletTLSCert:           cfg.WalletTLSCert,
+		WalletTLSKey:            cfg.WalletTLSKey,
+		WalletGRPCHost:          cfg.WalletGRPCHost,
+		WalletCheckInterval:     cfg.WalletCheckInterval,
+		WalletMaxSyncLag:        cfg.WalletMaxSyncLag,
+		ActiveNet:               cfg.net.Params,
+		PoolFee:                 cfg.PoolFee,
+		MaxGenTime:              cfg.MaxGenTime,
+		PaymentMethod:           cfg.PaymentMethod,
+		LastNPeriod:             cfg.LastNPeriod,
+		ShareSlice:              cfg.ShareSlice,
+		WalletPass:              cfg.WalletPass,
+		PoolFeeAddrs:            cfg.poolFeeAddrs,
+		SoloPool:                cfg.SoloPool,
+		AdminPass:               cfg.AdminPass,
+		NonceIterations:         iterations,
+		MinerListen:             cfg.MinerListen,
+		MaxConnectionsPerHost:   cfg.MaxConnectionsPerHost,
+		WalletAccount:           cfg.WalletAccount,
+		CoinbaseConfTimeout:     cfg.CoinbaseConfTimeout,
+		OfflineSigning:          cfg.OfflineSigning,
+		PayoutDir:               filepath.Join(cfg.DataDir, defaultPayoutDirname),
+		MonitorCycle:            cfg.MonitorCycle,
+		MaxUpgradeTries:         cfg.MaxUpgradeTries,
+		ClientTimeout:           cfg.clientTimeout,
+		BackupInterval:          cfg.BackupInterval,
+		BackupDir:               filepath.Join(cfg.DataDir, defaultBackupDirname),
+		BackupKeepHourly:        cfg.BackupKeepHourly,
+		BackupKeepDaily:         cfg.BackupKeepDaily,
+		BackupCompress:          cfg.BackupCompress,
+		WebhookURLs:             cfg.WebhookURLs,
+		WebhookSecret:           cfg.WebhookSecret,
+		WebhookMaxAttempts:      cfg.WebhookMaxAttempts,
+		WorkerOfflineTimeout:    cfg.WorkerOfflineTimeout,
+		WorkerHashRateFraction:  cfg.WorkerHashRateFrac,
 	}
 
 	var err error
@@ -90,37 +139,75 @@ func newPool(db pool.Database, cfg *config) (*miningPool, error) {
 	}
 
 	gcfg := &gui.Config{
-		SoloPool:              cfg.SoloPool,
-		GUIDir:                cfg.GUIDir,
-		AdminPass:             cfg.AdminPass,
-		GUIListen:             cfg.GUIListen,
-		UseLEHTTPS:            cfg.UseLEHTTPS,
-		NoGUITLS:              cfg.NoGUITLS,
-		Domain:                cfg.Domain,
-		TLSCertFile:           cfg.GUITLSCert,
-		TLSKeyFile:            cfg.GUITLSKey,
-		ActiveNet:             cfg.net.Params,
-		PaymentMethod:         cfg.PaymentMethod,
-		Designation:           cfg.Designation,
-		PoolFee:               cfg.PoolFee,
-		CSRFSecret:            csrfSecret,
-		MinerListen:           cfg.MinerListen,
-		WithinLimit:           p.hub.WithinLimit,
-		FetchLastWorkHeight:   p.hub.FetchLastWorkHeight,
-		FetchLastPaymentInfo:  p.hub.FetchLastPaymentInfo,
-		FetchMinedWork:        p.hub.FetchMinedWork,
-		FetchWorkQuotas:       p.hub.FetchWorkQuotas,
-		FetchHashData:         p.hub.FetchHashData,
-		AccountExists:         p.hub.AccountExists,
-		FetchArchivedPayments: p.hub.FetchArchivedPayments,
-		FetchPendingPayments:  p.hub.FetchPendingPayments,
-		FetchCacheChannel:     p.hub.FetchCacheChannel,
-	}
-
-	if !cfg.UsePostgres {
-		gcfg.HTTPBackupDB = p.hub.HTTPBackupDB
+		SoloPool:               cfg.SoloPool,
+		GUIDir:                 cfg.GUIDir,
+		GUIListen:              cfg.GUIListen,
+		UseLEHTTPS:             cfg.UseLEHTTPS,
+		NoGUITLS:               cfg.NoGUITLS,
+		Domain:                 cfg.Domain,
+		TLSCertFile:            cfg.GUITLSCert,
+		TLSKeyFile:             cfg.GUITLSKey,
+		ActiveNet:              cfg.net.Params,
+		PaymentMethod:          cfg.PaymentMethod,
+		Designation:            cfg.Designation,
+		PoolFee:                cfg.PoolFee,
+		CSRFSecret:             csrfSecret,
+		MinerListen:            cfg.MinerListen,
+		WithinLimit:            p.hub.WithinLimit,
+		FetchLastWorkHeight:    p.hub.FetchLastWorkHeight,
+		FetchLastPaymentInfo:   p.hub.FetchLastPaymentInfo,
+		FetchMinedWork:         p.hub.FetchMinedWork,
+		FetchWorkQuotas:        p.hub.FetchWorkQuotas,
+		FetchHashData:          p.hub.FetchHashData,
+		AccountExists:          p.hub.AccountExists,
+		FetchArchivedPayments:  p.hub.FetchArchivedPayments,
+		FetchPendingPayments:   p.hub.FetchPendingPayments,
+		FetchCacheChannel:      p.hub.FetchCacheChannel,
+		MintAPIToken:           p.hub.MintAPIToken,
+		FetchAPITokens:         p.hub.FetchAPITokens,
+		RevokeAPIToken:         p.hub.RevokeAPIToken,
+		RegisterWebhook:        p.hub.RegisterWebhook,
+		FetchWebhooks:          p.hub.FetchWebhooks,
+		DeleteWebhook:          p.hub.DeleteWebhook,
+		FetchWorkers:           p.hub.FetchWorkers,
+		FetchWorkerEvents:      p.hub.FetchWorkerEvents,
+		ForgetWorker:           p.hub.ForgetWorker,
+		ResolveAPIToken:        p.hub.ResolveAPIToken,
+		AuthenticateAdmin:      p.hub.AuthenticateAdmin,
+		FetchAdminUser:         p.hub.FetchAdminUser,
+		FetchAdminUsers:        p.hub.FetchAdminUsers,
+		CreateAdminUser:        p.hub.CreateAdminUser,
+		DeleteAdminUser:        p.hub.DeleteAdminUser,
+		ChangeAdminPassword:    p.hub.ChangeAdminPassword,
+		GenerateAdminTOTP:      p.hub.GenerateAdminTOTP,
+		EnableAdminTOTP:        p.hub.EnableAdminTOTP,
+		DisableAdminTOTP:       p.hub.DisableAdminTOTP,
+		RecordAdminAction:      p.hub.RecordAdminAction,
+		FetchAuditEntries:      p.hub.FetchAuditEntries,
+		DisconnectClient:       p.hub.DisconnectClient,
+		ForceClientDifficulty:  p.hub.ForceClientDifficulty,
+		BanClient:              p.hub.BanClient,
+		UnbanClient:            p.hub.UnbanClient,
+		FetchBans:              p.hub.FetchBans,
+		AdjustBalance:          p.hub.AdjustBalance,
+		FetchLedgerEntries:     p.hub.FetchLedgerEntries,
+		FetchLedgerBalances:    p.hub.FetchLedgerBalances,
+		FetchAccountBalances:   p.hub.FetchAccountBalances,
+		FetchAccountBalance:    p.hub.FetchAccountBalance,
+		FetchPendingPayout:     p.hub.FetchPendingPayout,
+		ExportPayout:           p.hub.ExportPayout,
+		PublishPayout:          p.hub.PublishPayout,
+		DiscardPayout:          p.hub.DiscardPayout,
+		FetchBlockSubmissions:  p.hub.FetchBlockSubmissions,
+		FetchBlockEventStats:   p.hub.FetchBlockEventStats,
+		FetchWalletStatus:      p.hub.FetchWalletStatus,
+		FetchWebhookDeliveries: p.hub.FetchWebhookDeliveries,
+		ReloadConfig:           p.reloadConfig,
+		APIAllowedOrigins:      cfg.APIAllowedOrigins,
 	}
 
+	gcfg.HTTPBackupDB = p.hub.HTTPBackupDB
+
 	p.gui, err = gui.NewGUI(gcfg)
 	if err != nil {
 		return nil, err
@@ -128,11 +215,47 @@ func newPool(db pool.Database, cfg *config) (*miningPool, error) {
 	return p, nil
 }
 
+// restoreDB restores the provided database from the archive at the provided
+// path.
+func restoreDB(db pool.Database, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return pool.RestoreArchive(db, f)
+}
+
 func main() {
+	// Run the database migration subcommand instead of the pool if requested.
+	if len(os.Args) > 1 && os.Args[1] == migrateDBCmd {
+		err := migrateDB(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", migrateDBCmd, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Run the database integrity check subcommand instead of the pool if
+	// requested.
+	if len(os.Args) > 1 && os.Args[1] == checkDBCmd {
+		err := checkDB(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", checkDBCmd, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Listen for interrupt signals.
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
+	// Listen for hangup signals to reload the configuration.
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
 	// Load configuration and parse command line. This also initializes
 	// logging and configures it accordingly.
 	cfg, _, err := loadConfig()
@@ -145,11 +268,28 @@ func main() {
 		}
 	}()
 
+	// Restore a bolt snapshot from its manifest and exit if requested. The
+	// snapshot replaces the database file so it is restored before the
+	// database is opened.
+	if cfg.RestoreFile != "" && !cfg.UsePostgres && !cfg.UseSQLite &&
+		strings.HasSuffix(cfg.RestoreFile, pool.BackupManifestSuffix) {
+		manifest, err := pool.RestoreSnapshot(cfg.RestoreFile, cfg.DBFile)
+		if err != nil {
+			mpLog.Errorf("failed to restore snapshot: %v", err)
+			os.Exit(1)
+		}
+		mpLog.Infof("Restored database version %d from snapshot %s.",
+			manifest.DBVersion, manifest.File)
+		return
+	}
+
 	var db pool.Database
-	if cfg.UsePostgres {
-		db, err = pool.InitPostgresDB(cfg.PGHost, cfg.PGPort, cfg.PGUser,
-			cfg.PGPass, cfg.PGDBName, cfg.PurgeDB)
-	} else {
+	switch {
+	case cfg.UsePostgres:
+		db, err = pool.InitPostgresDB(cfg.pgConfig(), cfg.PurgeDB)
+	case cfg.UseSQLite:
+		db, err = pool.InitSQLiteDB(cfg.SQLiteFile, cfg.PurgeDB)
+	default:
 		db, err = pool.InitBoltDB(cfg.DBFile)
 	}
 
@@ -158,6 +298,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Restore the database from the provided archive and exit if requested.
+	if cfg.RestoreFile != "" {
+		err := restoreDB(db, cfg.RestoreFile)
+		db.Close()
+		if err != nil {
+			mpLog.Errorf("failed to restore database: %v", err)
+			os.Exit(1)
+		}
+		mpLog.Infof("Restored database from %s.", cfg.RestoreFile)
+		return
+	}
+
 	p, err := newPool(db, cfg)
 	if err != nil {
 		mpLog.Errorf("failed to initialize pool: %v", err)
@@ -187,25 +339,43 @@ func main() {
 	mpLog.Infof("Started dcrpool.")
 
 	go func() {
-		select {
-		case <-p.ctx.Done():
-			return
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
 
-		case <-interrupt:
-			p.cancel()
+			case <-hangup:
+				mpLog.Infof("Received SIGHUP, reloading config.")
+				_, _, err := p.reloadConfig()
+				if err != nil {
+					mpLog.Errorf("unable to reload config: %v", err)
+				}
+
+			case <-interrupt:
+				p.cancel()
+				return
+			}
 		}
 	}()
 	p.gui.Run(p.ctx)
 	p.hub.Run(p.ctx)
 
 	// hub.Run() blocks until the pool is fully shut down. When it returns,
-	// write a backup of the DB (if not using postgres), and then close the DB.
-	if !cfg.UsePostgres {
-		mpLog.Infof("Backing up database.")
-		err = db.Backup(pool.BoltBackupFile)
-		if err != nil {
-			mpLog.Errorf("failed to write database backup file: %v", err)
-		}
+	// write a backup of the DB and then close the DB. Bolt and sqlite backups
+	// are written alongside the database file, postgres backups to the data
+	// directory.
+	mpLog.Infof("Backing up database.")
+	backupFile := pool.BackupFile
+	switch {
+	case cfg.UsePostgres:
+		backupFile = filepath.Join(cfg.DataDir, pool.BackupFile)
+	case cfg.UseSQLite:
+		backupFile = filepath.Join(filepath.Dir(cfg.SQLiteFile),
+			pool.BackupFile)
+	}
+	err = db.Backup(backupFile)
+	if err != nil {
+		mpLog.Errorf("failed to write database backup file: %v", err)
 	}
 
 	db.Close()
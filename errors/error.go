@@ -140,6 +140,9 @@ const (
 
 	// CreateAmount indicates an amount creation error.
 	CreateAmount = ErrorKind("CreateAmount")
+
+	// Unauthorized indicates a failed authentication or ownership proof.
+	Unauthorized = ErrorKind("Unauthorized")
 )
 
 // Error satisfies the error interface and prints human-readable errors.
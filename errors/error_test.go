@@ -57,6 +57,7 @@ func TestErrorKindStringer(t *testing.T) {
 		{TxIn, "TxIn"},
 		{ContextCancelled, "ContextCancelled"},
 		{CreateAmount, "CreateAmount"},
+		{Unauthorized, "Unauthorized"},
 	}
 
 	for i, test := range tests {
@@ -5,10 +5,12 @@
 package gui
 
 import (
+	"math/big"
 	"net/http"
 
 	"github.com/decred/dcrpool/pool"
 	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
 )
 
 // accountPageData contains all of the necessary information to render the
@@ -21,11 +23,36 @@ type accountPageData struct {
 	PendingPaymentsTotal  string
 	PendingPayments       []*pendingPayment
 	ConnectedClients      []*client
+	Workers               []*trackedWorker
+	WorkerEvents          []*workerEvent
 	AccountID             string
 	Address               string
 	BlockExplorerURL      string
 }
 
+// trackedWorker represents a named worker of an account tracked by the pool.
+type trackedWorker struct {
+	Name     string
+	Miner    string
+	Status   string
+	HashRate string
+	Baseline string
+	LastSeen int64
+}
+
+// workerEvent represents a status change of a tracked worker.
+type workerEvent struct {
+	Worker    string
+	Kind      string
+	HashRate  string
+	CreatedOn int64
+}
+
+// floatHashString formats the provided hashrate per the best-fit unit.
+func floatHashString(hashRate float64) string {
+	return hashString(new(big.Rat).SetFloat64(hashRate))
+}
+
 // account is the handler for "GET /account". Renders the account template if
 // a valid address with associated account information is provided,
 // otherwise renders the index template with an appropriate error message.
@@ -45,6 +72,28 @@ func (ui *GUI) account(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ui.renderAccount(w, r, accountID, address)
+}
+
+// watcher is the handler for "GET /watch/{token}". Renders the account
+// template for the account referenced by a watcher token without revealing
+// the account's address, otherwise renders the index template with an
+// appropriate error message.
+func (ui *GUI) watcher(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	accountID, err := ui.cfg.ResolveAPIToken(token, pool.WatcherTokenKind)
+	if err != nil {
+		log.Tracef("unable to resolve watcher token: %v", err)
+		ui.renderIndex(w, r, "Invalid or revoked watcher link")
+		return
+	}
+
+	ui.renderAccount(w, r, accountID, "")
+}
+
+// renderAccount renders the account template for the provided account. The
+// address is only displayed if provided.
+func (ui *GUI) renderAccount(w http.ResponseWriter, r *http.Request, accountID string, address string) {
 	totalPending := ui.cache.getPendingPaymentsTotal(accountID)
 	totalArchived := ui.cache.getArchivedPaymentsTotal(accountID)
 
@@ -63,10 +112,41 @@ func (ui *GUI) account(w http.ResponseWriter, r *http.Request) {
 	// Get 10 of this accounts connected clients.
 	_, clients, _ := ui.cache.getClientsForAccount(0, 9, accountID)
 
+	tracked := ui.cfg.FetchWorkers(accountID)
+	workers := make([]*trackedWorker, 0, len(tracked))
+	for _, worker := range tracked {
+		workers = append(workers, &trackedWorker{
+			Name:     worker.Name,
+			Miner:    worker.Miner,
+			Status:   worker.Status,
+			HashRate: floatHashString(worker.HashRate),
+			Baseline: floatHashString(worker.Baseline),
+			LastSeen: worker.LastSeen,
+		})
+	}
+
+	// Get the 10 most recent worker events of this account.
+	events := make([]*workerEvent, 0)
+	recentEvents, err := ui.cfg.FetchWorkerEvents(accountID)
+	if err != nil {
+		log.Errorf("unable to fetch worker events: %v", err)
+	}
+	for _, event := range recentEvents {
+		if len(events) == 10 {
+			break
+		}
+		events = append(events, &workerEvent{
+			Worker:    event.Worker,
+			Kind:      event.Kind,
+			HashRate:  floatHashString(event.HashRate),
+			CreatedOn: event.CreatedOn,
+		})
+	}
+
 	data := &accountPageData{
 		HeaderData: headerData{
 			CSRF:        csrf.TemplateField(r),
-			Designation: ui.cfg.Designation,
+			Designation: ui.designation(),
 			ShowMenu:    true,
 		},
 		MinedWork:             recentWork,
@@ -75,6 +155,8 @@ func (ui *GUI) account(w http.ResponseWriter, r *http.Request) {
 		ArchivedPaymentsTotal: totalArchived,
 		ArchivedPayments:      archivedPmts,
 		ConnectedClients:      clients,
+		Workers:               workers,
+		WorkerEvents:          events,
 		AccountID:             accountID,
 		Address:               address,
 		BlockExplorerURL:      ui.cfg.BlockExplorerURL,
@@ -5,13 +5,36 @@
 package gui
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrpool/pool"
 	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
+
+	errs "github.com/decred/dcrpool/errors"
 )
 
+// auditLogPageSize is the number of audit log entries shown on the admin
+// page.
+const auditLogPageSize = 20
+
+// maxAuditLogLimit is the maximum number of audit log entries returned by
+// a single request.
+const maxAuditLogLimit = 500
+
+// ledgerPageSize is the number of ledger entries shown on the admin page.
+const ledgerPageSize = 20
+
+// maxLedgerLimit is the maximum number of ledger entries returned by a single
+// request.
+const maxLedgerLimit = 500
+
 // adminPageData contains all of the necessary information to render the admin
 // template.
 type adminPageData struct {
@@ -23,6 +46,61 @@ type adminPageData struct {
 	PendingPaymentsTotal  string
 	PendingPayments       []*pendingPayment
 	BackupAvailable       bool
+	AdminUser             *pool.AdminUser
+	CanViewAuditLog       bool
+	CanManageUsers        bool
+	CanManageClients      bool
+	CanViewLedger         bool
+	CanAdjustBalances     bool
+	Bans                  []*pool.Ban
+	LedgerEntries         []*pool.LedgerEntry
+	PendingPayout         *pool.PendingPayout
+	AdminUsers            []*pool.AdminUser
+	AuditEntries          []*pool.AuditEntry
+	Roles                 []string
+	TOTPSetupSecret       string
+	TOTPSetupURL          string
+	WalletProblems        []*pool.WalletProblem
+	ReloadApplied         string
+	ReloadRestart         string
+}
+
+// adminUser returns the admin user the current session is authenticated as,
+// provided the user still exists and is granted the permissions of the
+// required role.
+func (ui *GUI) adminUser(r *http.Request, required string) (*pool.AdminUser, bool) {
+	session := r.Context().Value(sessionKey).(*sessions.Session)
+
+	name, ok := session.Values["AdminUser"].(string)
+	if !ok || name == "" {
+		return nil, false
+	}
+
+	// The user is fetched on every request so that role changes and
+	// deletions take effect immediately.
+	user, err := ui.cfg.FetchAdminUser(name)
+	if err != nil {
+		if !errors.Is(err, errs.ValueNotFound) {
+			log.Errorf("unable to fetch admin user %s: %v", name, err)
+		}
+		return nil, false
+	}
+
+	if !pool.RoleAllows(user.Role, required) {
+		return nil, false
+	}
+
+	return user, true
+}
+
+// recordAdminAction persists an audit log entry of the provided admin action,
+// logging any error encountered.
+func (ui *GUI) recordAdminAction(r *http.Request, actor, action, details string) {
+	err := ui.cfg.RecordAdminAction(actor, action, details, remoteHost(r))
+	if err != nil {
+		log.Errorf("unable to record %s admin action by %s: %v", action,
+			actor, err)
+	}
 }
 
 // adminPage is the handler for "GET /admin". If the current session is
@@ -31,7 +109,8 @@ type adminPageData struct {
 func (ui *GUI) adminPage(w http.ResponseWriter, r *http.Request) {
 	session := r.Context().Value(sessionKey).(*sessions.Session)
 
-	if session.Values["IsAdmin"] != true {
+	user, ok := ui.adminUser(r, pool.RoleViewer)
+	if !ok {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
 	}
@@ -57,7 +136,7 @@ func (ui *GUI) adminPage(w http.ResponseWriter, r *http.Request) {
 	pageData := adminPageData{
 		HeaderData: headerData{
 			CSRF:        csrf.TemplateField(r),
-			Designation: ui.cfg.Designation,
+			Designation: ui.designation(),
 			ShowMenu:    false,
 		},
 		PoolStatsData: poolStatsData{
@@ -66,7 +145,7 @@ func (ui *GUI) adminPage(w http.ResponseWriter, r *http.Request) {
 			PoolHashRate:      ui.cache.getPoolHash(),
 			PaymentMethod:     ui.cfg.PaymentMethod,
 			Network:           ui.cfg.ActiveNet.Name,
-			PoolFee:           ui.cfg.PoolFee,
+			PoolFee:           ui.poolFee(),
 			SoloPool:          ui.cfg.SoloPool,
 		},
 		ConnectedClients:      clients,
@@ -74,7 +153,75 @@ func (ui *GUI) adminPage(w http.ResponseWriter, r *http.Request) {
 		PendingPayments:       pendingPmts,
 		ArchivedPaymentsTotal: totalArchived,
 		ArchivedPayments:      archivedPmts,
-		BackupAvailable:       ui.cfg.HTTPBackupDB != nil,
+		BackupAvailable: ui.cfg.HTTPBackupDB != nil &&
+			pool.RoleAllows(user.Role, pool.RoleOperator),
+		AdminUser:         user,
+		CanViewAuditLog:   pool.RoleAllows(user.Role, pool.RoleOperator),
+		CanManageUsers:    pool.RoleAllows(user.Role, pool.RoleTreasurer),
+		CanManageClients:  pool.RoleAllows(user.Role, pool.RoleOperator),
+		CanViewLedger:     pool.RoleAllows(user.Role, pool.RoleOperator),
+		CanAdjustBalances: pool.RoleAllows(user.Role, pool.RoleTreasurer),
+		Roles:             []string{pool.RoleViewer, pool.RoleOperator, pool.RoleTreasurer},
+	}
+
+	if status := ui.cfg.FetchWalletStatus(); status != nil {
+		pageData.WalletProblems = status.Problems
+	}
+
+	if pageData.CanViewAuditLog {
+		entries, err := ui.cfg.FetchAuditEntries(auditLogPageSize)
+		if err != nil {
+			log.Errorf("unable to fetch audit log: %v", err)
+		}
+		pageData.AuditEntries = entries
+	}
+
+	if pageData.CanManageClients {
+		pageData.Bans = ui.cfg.FetchBans()
+	}
+
+	if pageData.CanViewLedger {
+		entries, err := ui.cfg.FetchLedgerEntries("", ledgerPageSize)
+		if err != nil {
+			log.Errorf("unable to fetch ledger entries: %v", err)
+		}
+		pageData.LedgerEntries = entries
+	}
+
+	if pageData.CanAdjustBalances {
+		payout, err := ui.cfg.FetchPendingPayout()
+		if err != nil && !errors.Is(err, errs.ValueNotFound) {
+			log.Errorf("unable to fetch pending payout: %v", err)
+		}
+		pageData.PendingPayout = payout
+	}
+
+	if pageData.CanManageUsers {
+		users, err := ui.cfg.FetchAdminUsers()
+		if err != nil {
+			log.Errorf("unable to fetch admin users: %v", err)
+		}
+		pageData.AdminUsers = users
+	}
+
+	// A TOTP secret pending confirmation is kept in the session until it is
+	// enabled.
+	if secret, ok := session.Values["TOTPSecret"].(string); ok &&
+		user.TOTPSecret == "" {
+		pageData.TOTPSetupSecret = secret
+		pageData.TOTPSetupURL, _ = session.Values["TOTPURL"].(string)
+	}
+
+	// The outcome of a configuration reload is shown once.
+	if applied, ok := session.Values["ReloadApplied"].(string); ok {
+		pageData.ReloadApplied = applied
+		pageData.ReloadRestart, _ = session.Values["ReloadRestart"].(string)
+		delete(session.Values, "ReloadApplied")
+		delete(session.Values, "ReloadRestart")
+		err := session.Save(r, w)
+		if err != nil {
+			log.Errorf("unable to save session: %v", err)
+		}
 	}
 
 	ui.renderTemplate(w, "admin", pageData)
@@ -82,20 +229,37 @@ func (ui *GUI) adminPage(w http.ResponseWriter, r *http.Request) {
 
 // adminLogin is the handler for "POST /admin". If proper admin credentials are
 // supplied, the session is authenticated and a "200 OK" response is returned,
-// otherwise a "401 Unauthorized" response is returned.
+// otherwise a "401 Unauthorized" response is returned. Repeated failures
+// result in a "429 Too Many Requests" response.
 func (ui *GUI) adminLogin(w http.ResponseWriter, r *http.Request) {
 	session := r.Context().Value(sessionKey).(*sessions.Session)
 
+	name := r.FormValue("username")
+	if name == "" {
+		name = pool.DefaultAdminUser
+	}
 	pass := r.FormValue("password")
+	code := r.FormValue("otp")
 
-	if ui.cfg.AdminPass != pass {
-		log.Warn("Unauthorized access")
-		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+	user, err := ui.cfg.AuthenticateAdmin(name, pass, code, remoteHost(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.LimitExceeded):
+			log.Warnf("Admin login attempts exceeded for %s", name)
+			http.Error(w, "Too many failed attempts, try again later",
+				http.StatusTooManyRequests)
+		case errors.Is(err, errs.Unauthorized):
+			log.Warn("Unauthorized access")
+			http.Error(w, "Incorrect credentials", http.StatusUnauthorized)
+		default:
+			log.Errorf("unable to authenticate admin: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		return
 	}
 
-	session.Values["IsAdmin"] = true
-	err := session.Save(r, w)
+	session.Values["AdminUser"] = user.UUID
+	err = session.Save(r, w)
 	if err != nil {
 		log.Errorf("unable to save session: %v", err)
 		return
@@ -110,7 +274,13 @@ func (ui *GUI) adminLogin(w http.ResponseWriter, r *http.Request) {
 func (ui *GUI) adminLogout(w http.ResponseWriter, r *http.Request) {
 	session := r.Context().Value(sessionKey).(*sessions.Session)
 
-	session.Values["IsAdmin"] = false
+	if name, ok := session.Values["AdminUser"].(string); ok && name != "" {
+		ui.recordAdminAction(r, name, pool.AuditLogout, "")
+	}
+
+	delete(session.Values, "AdminUser")
+	delete(session.Values, "TOTPSecret")
+	delete(session.Values, "TOTPURL")
 	err := session.Save(r, w)
 	if err != nil {
 		log.Errorf("unable to save session: %v", err)
@@ -121,22 +291,566 @@ func (ui *GUI) adminLogout(w http.ResponseWriter, r *http.Request) {
 }
 
 // downloadDatabaseBackup is the handler for "POST /backup". If the current
-// session is authenticated as an admin, a binary representation of the whole
-// database is generated and returned to the client.
+// session is authenticated as an operator, a binary representation of the
+// whole database is generated and returned to the client.
 func (ui *GUI) downloadDatabaseBackup(w http.ResponseWriter, r *http.Request) {
-	session := r.Context().Value(sessionKey).(*sessions.Session)
-
-	if session.Values["IsAdmin"] != true {
+	user, ok := ui.adminUser(r, pool.RoleOperator)
+	if !ok {
 		http.Error(w, "Not authenticated", http.StatusUnauthorized)
 		return
 	}
 
+	ui.recordAdminAction(r, user.UUID, pool.AuditBackup, "")
+
 	err := ui.cfg.HTTPBackupDB(w)
 	if err != nil {
 		log.Errorf("error backing up database: %v", err)
-		// Don't write any http body here because Content-Length has already
-		// been set in BackupDB. Status is enough to indicate an error.
+		// The archive is streamed, part of it may have been written
+		// already. Status is enough to indicate an error otherwise.
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
+
+// sendAdminError writes the appropriate http error for the provided error
+// returned by an admin action.
+func sendAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errs.Unauthorized), errors.Is(err, errs.Parse),
+		errors.Is(err, errs.LowDifficulty), errors.Is(err, errs.Decode),
+		errors.Is(err, errs.TxIn), errors.Is(err, errs.TxOut),
+		errors.Is(err, errs.SignTx):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errs.ValueFound):
+		http.Error(w, "Already exists", http.StatusConflict)
+	case errors.Is(err, errs.ValueNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Errorf("admin action failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// createAdminUser is the handler for "POST /admin/users". If the current
+// session is authenticated as a treasurer, an admin user is created with the
+// provided username, password and role.
+func (ui *GUI) createAdminUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleTreasurer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.CreateAdminUser(user.UUID, remoteHost(r),
+		r.FormValue("username"), r.FormValue("password"), r.FormValue("role"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// deleteAdminUser is the handler for "POST /admin/users/{name}/delete". If
+// the current session is authenticated as a treasurer, the referenced admin
+// user is deleted.
+func (ui *GUI) deleteAdminUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleTreasurer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.DeleteAdminUser(user.UUID, remoteHost(r),
+		mux.Vars(r)["name"])
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// changeAdminPassword is the handler for "POST /admin/password". The password
+// of the admin user the current session is authenticated as is updated given
+// their current password.
+func (ui *GUI) changeAdminPassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleViewer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.ChangeAdminPassword(user.UUID, remoteHost(r),
+		r.FormValue("password"), r.FormValue("newpassword"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// setupAdminTOTP is the handler for "POST /admin/totp/setup". A new TOTP
+// secret is generated for the admin user the current session is authenticated
+// as and kept in the session until confirmed.
+func (ui *GUI) setupAdminTOTP(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(sessionKey).(*sessions.Session)
+
+	user, ok := ui.adminUser(r, pool.RoleViewer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	secret, url, err := ui.cfg.GenerateAdminTOTP(user.UUID,
+		"dcrpool "+ui.designation())
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	session.Values["TOTPSecret"] = secret
+	session.Values["TOTPURL"] = url
+	err = session.Save(r, w)
+	if err != nil {
+		log.Errorf("unable to save session: %v", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// enableAdminTOTP is the handler for "POST /admin/totp/enable". The TOTP
+// secret pending confirmation in the current session is enabled as a second
+// factor given a valid code generated from it.
+func (ui *GUI) enableAdminTOTP(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(sessionKey).(*sessions.Session)
+
+	user, ok := ui.adminUser(r, pool.RoleViewer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	secret, ok := session.Values["TOTPSecret"].(string)
+	if !ok {
+		http.Error(w, "No pending two-factor setup", http.StatusBadRequest)
+		return
+	}
+
+	err := ui.cfg.EnableAdminTOTP(user.UUID, remoteHost(r), secret,
+		r.FormValue("otp"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	delete(session.Values, "TOTPSecret")
+	delete(session.Values, "TOTPURL")
+	err = session.Save(r, w)
+	if err != nil {
+		log.Errorf("unable to save session: %v", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// disableAdminTOTP is the handler for "POST /admin/totp/disable". The TOTP
+// second factor of the admin user the current session is authenticated as is
+// removed given a valid code.
+func (ui *GUI) disableAdminTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleViewer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.DisableAdminTOTP(user.UUID, remoteHost(r), r.FormValue("otp"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// disconnectClient is the handler for "POST /admin/clients/disconnect". If the
+// current session is authenticated as an operator, the client with the
+// provided extraNonce1, or all clients connected from the provided IP
+// address, are disconnected.
+func (ui *GUI) disconnectClient(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleOperator)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.DisconnectClient(user.UUID, remoteHost(r),
+		r.FormValue("target"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// forceClientDifficulty is the handler for "POST /admin/clients/difficulty".
+// If the current session is authenticated as an operator, the difficulty of
+// the client with the provided extraNonce1 is overridden.
+func (ui *GUI) forceClientDifficulty(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleOperator)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	diff, err := strconv.ParseFloat(r.FormValue("difficulty"), 64)
+	if err != nil {
+		http.Error(w, "Invalid difficulty", http.StatusBadRequest)
+		return
+	}
+
+	err = ui.cfg.ForceClientDifficulty(user.UUID, remoteHost(r),
+		r.FormValue("extranonce1"), diff)
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// banClient is the handler for "POST /admin/bans". If the current session is
+// authenticated as an operator, the provided IP address, mining address or
+// account id is banned from the pool.
+func (ui *GUI) banClient(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleOperator)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.BanClient(user.UUID, remoteHost(r), r.FormValue("target"),
+		r.FormValue("reason"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// unbanClient is the handler for "POST /admin/bans/unban". If the current
+// session is authenticated as an operator, the ban of the provided IP
+// address, mining address or account id is lifted.
+func (ui *GUI) unbanClient(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleOperator)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.UnbanClient(user.UUID, remoteHost(r), r.FormValue("target"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// reloadConfig is the handler for "POST /admin/reload". If the current session
+// is authenticated as an operator, the pool configuration is reloaded and the
+// settings applied as well as the changed settings requiring a restart are
+// shown on the admin page. A "400 Bad Request" response is returned if the
+// configuration is invalid, in which case nothing is applied.
+func (ui *GUI) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(sessionKey).(*sessions.Session)
+
+	user, ok := ui.adminUser(r, pool.RoleOperator)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	applied, restart, err := ui.cfg.ReloadConfig()
+	if err != nil {
+		log.Errorf("unable to reload config: %v", err)
+		http.Error(w, "Invalid config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	details := fmt.Sprintf("applied: %s; restart required: %s",
+		strings.Join(applied, ","), strings.Join(restart, ","))
+	ui.recordAdminAction(r, user.UUID, pool.AuditReload, details)
+
+	appliedList := "none"
+	if len(applied) > 0 {
+		appliedList = strings.Join(applied, ", ")
+	}
+	session.Values["ReloadApplied"] = appliedList
+	session.Values["ReloadRestart"] = strings.Join(restart, ", ")
+	err = session.Save(r, w)
+	if err != nil {
+		log.Errorf("unable to save session: %v", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// adminAuditLog is the handler for "GET /admin/audit". If the current session
+// is authenticated as an operator, it returns a json payload of the most
+// recent admin audit log entries. The number of entries is set with the limit
+// parameter, up to maxAuditLogLimit.
+func (ui *GUI) adminAuditLog(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleOperator); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	limit := auditLogPageSize
+	if v := r.FormValue("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		limit = l
+		if limit > maxAuditLogLimit {
+			limit = maxAuditLogLimit
+		}
+	}
+
+	entries, err := ui.cfg.FetchAuditEntries(limit)
+	if err != nil {
+		log.Errorf("unable to fetch audit log: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(w, entries)
+}
+
+// adjustBalance is the handler for "POST /admin/ledger/adjust". If the current
+// session is authenticated as a treasurer, a manual adjustment of the ledger
+// balance of the provided mining address, account id or the pool fees is
+// booked. The amount is in DCR, a negative amount debits the balance.
+func (ui *GUI) adjustBalance(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleTreasurer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	value, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+	if err != nil {
+		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		return
+	}
+	amount, err := dcrutil.NewAmount(value)
+	if err != nil {
+		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		return
+	}
+
+	err = ui.cfg.AdjustBalance(user.UUID, remoteHost(r),
+		r.FormValue("target"), amount, r.FormValue("memo"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// downloadPendingPayout is the handler for "GET /admin/payout". If the
+// current session is authenticated as a treasurer, the payout transaction
+// awaiting an external signature is downloaded as a json file. A "404 Not
+// Found" response is returned if there is no pending payout.
+func (ui *GUI) downloadPendingPayout(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleTreasurer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	payout, err := ui.cfg.ExportPayout(user.UUID, remoteHost(r))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; "+
+		"filename=payout-%d-%s.json", payout.Height, payout.TxID))
+	sendJSONResponse(w, payout)
+}
+
+// publishPayout is the handler for "POST /admin/payout/publish". If the
+// current session is authenticated as a treasurer, the provided hex encoded
+// signed payout transaction is published provided it matches the pending
+// payout. A "400 Bad Request" response is returned if it does not.
+func (ui *GUI) publishPayout(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleTreasurer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	_, err := ui.cfg.PublishPayout(r.Context(), user.UUID, remoteHost(r),
+		r.FormValue("signedtx"))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// discardPayout is the handler for "POST /admin/payout/discard". If the
+// current session is authenticated as a treasurer, the payout transaction
+// awaiting an external signature is discarded and its payments are included
+// in the next payout.
+func (ui *GUI) discardPayout(w http.ResponseWriter, r *http.Request) {
+	user, ok := ui.adminUser(r, pool.RoleTreasurer)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err := ui.cfg.DiscardPayout(user.UUID, remoteHost(r))
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// adminLedger is the handler for "GET /admin/ledger". If the current session
+// is authenticated as an operator, it returns a json payload of the most
+// recent ledger entries. Entries are filtered by ledger account with the
+// account parameter and the number of entries is set with the limit
+// parameter, up to maxLedgerLimit.
+func (ui *GUI) adminLedger(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleOperator); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	limit := ledgerPageSize
+	if v := r.FormValue("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		limit = l
+		if limit > maxLedgerLimit {
+			limit = maxLedgerLimit
+		}
+	}
+
+	entries, err := ui.cfg.FetchLedgerEntries(r.FormValue("account"), limit)
+	if err != nil {
+		log.Errorf("unable to fetch ledger entries: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(w, entries)
+}
+
+// adminLedgerBalances is the handler for "GET /admin/ledger/balances". If the
+// current session is authenticated as an operator, it returns a json payload
+// of the balance of every ledger account, in atoms.
+func (ui *GUI) adminLedgerBalances(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleOperator); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	balances, err := ui.cfg.FetchLedgerBalances()
+	if err != nil {
+		log.Errorf("unable to fetch ledger balances: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(w, balances)
+}
+
+// adminBlockSubmissions is the handler for "GET /admin/submissions". If the
+// current session is authenticated, it returns a json payload of the most
+// recent solved block submissions along with the outcome and latency, in
+// milliseconds, of the submission to every mining node.
+func (ui *GUI) adminBlockSubmissions(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleViewer); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sendJSONResponse(w, ui.cfg.FetchBlockSubmissions())
+}
+
+// adminBlockEvents is the handler for "GET /admin/blockevents". If the
+// current session is authenticated, it returns a json payload of the block
+// notification processing counters, including the retries by error kind,
+// along with the block notifications pending processing.
+func (ui *GUI) adminBlockEvents(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleViewer); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sendJSONResponse(w, ui.cfg.FetchBlockEventStats())
+}
+
+// adminWalletStatus is the handler for "GET /admin/wallet". If the current
+// session is authenticated, it returns a json payload of the outcome of the
+// latest wallet health check along with the number of checks each kind of
+// wallet problem was detected by. A "404 Not Found" response is returned if
+// the wallet is not monitored.
+func (ui *GUI) adminWalletStatus(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleViewer); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	status := ui.cfg.FetchWalletStatus()
+	if status == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	sendJSONResponse(w, status)
+}
+
+// adminWebhookDeliveries is the handler for "GET /admin/webhooks". If the
+// current session is authenticated, it returns a json payload of the webhook
+// events pending delivery, including the failed attempts of each.
+func (ui *GUI) adminWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ui.adminUser(r, pool.RoleViewer); !ok {
+		log.Warn("Unauthorized access")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	deliveries, err := ui.cfg.FetchWebhookDeliveries()
+	if err != nil {
+		log.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(w, deliveries)
+}
@@ -0,0 +1,798 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrpool/pool"
+	"github.com/gorilla/mux"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// apiPathPrefix is the path prefix of the current version of the public
+	// JSON API.
+	apiPathPrefix = "/api/v1"
+
+	// defaultAPILimit is the number of items returned by list endpoints of
+	// the public API when no limit is provided.
+	defaultAPILimit = 50
+
+	// maxAPILimit is the maximum number of items which can be requested from
+	// list endpoints of the public API.
+	maxAPILimit = 500
+
+	// Payment statuses accepted and reported by the public API.
+	apiPaymentPending = "pending"
+	apiPaymentPaid    = "paid"
+)
+
+// The types below define the v1 schema of the public JSON API. Field names
+// and types are part of the public contract and must not be changed without
+// introducing a new version of the API.
+
+// apiError is the response body of all failed public API requests.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// apiList is the response body of all public API list endpoints. Count is
+// the total number of items available, Data holds the requested window of
+// those items.
+type apiList struct {
+	Count  int         `json:"count"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Data   interface{} `json:"data"`
+}
+
+// apiPoolStats describes the current state of the pool.
+type apiPoolStats struct {
+	Network              string  `json:"network"`
+	SoloPool             bool    `json:"solopool"`
+	PaymentMethod        string  `json:"paymentmethod"`
+	PoolFee              float64 `json:"poolfee"`
+	PoolHashRate         float64 `json:"poolhashrate"`
+	Workers              int     `json:"workers"`
+	LastWorkHeight       uint32  `json:"lastworkheight"`
+	LastPaymentHeight    uint32  `json:"lastpaymentheight"`
+	LastPaymentPaidOn    int64   `json:"lastpaymentpaidon"`
+	LastPaymentCreatedOn int64   `json:"lastpaymentcreatedon"`
+	OrphanedBlocks       int     `json:"orphanedblocks"`
+	OrphanRate           float64 `json:"orphanrate"`
+}
+
+// apiBlock describes a block mined by the pool.
+type apiBlock struct {
+	Height     uint32 `json:"height"`
+	Hash       string `json:"hash"`
+	MinedBy    string `json:"minedby"`
+	Miner      string `json:"miner"`
+	Confirmed  bool   `json:"confirmed"`
+	Status     string `json:"status"`
+	OrphanedBy string `json:"orphanedby,omitempty"`
+	CreatedOn  int64  `json:"createdon"`
+}
+
+// apiWorker describes a mining client connected to the pool.
+type apiWorker struct {
+	Miner     string  `json:"miner"`
+	HashRate  float64 `json:"hashrate"`
+	UpdatedOn int64   `json:"updatedon"`
+}
+
+// apiTrackedWorker describes a named worker of an account tracked by the
+// pool. Hashrates are in hashes per second.
+type apiTrackedWorker struct {
+	Name        string  `json:"name"`
+	Miner       string  `json:"miner"`
+	Status      string  `json:"status"`
+	StatusSince int64   `json:"statussince"`
+	HashRate    float64 `json:"hashrate"`
+	Baseline    float64 `json:"baseline"`
+	LastSeen    int64   `json:"lastseen"`
+}
+
+// apiWorkerEvent describes a status change of a tracked worker. Hashrates
+// are in hashes per second.
+type apiWorkerEvent struct {
+	Worker    string  `json:"worker"`
+	Kind      string  `json:"kind"`
+	HashRate  float64 `json:"hashrate"`
+	Baseline  float64 `json:"baseline"`
+	LastSeen  int64   `json:"lastseen"`
+	CreatedOn int64   `json:"createdon"`
+}
+
+// apiHashRate describes the combined hashrate of an account.
+type apiHashRate struct {
+	AccountID string  `json:"accountid"`
+	HashRate  float64 `json:"hashrate"`
+	Workers   int     `json:"workers"`
+}
+
+// apiBalance describes the ledger balance of an account, pending including
+// its immature rewards, and the amount paid out to it. Amounts are in atoms.
+type apiBalance struct {
+	AccountID string `json:"accountid"`
+	Pending   int64  `json:"pending"`
+	Paid      int64  `json:"paid"`
+}
+
+// apiPayment describes a payment due or made to an account. Amounts are in
+// atoms.
+type apiPayment struct {
+	Height            uint32 `json:"height"`
+	Amount            int64  `json:"amount"`
+	Status            string `json:"status"`
+	EstimatedMaturity uint32 `json:"estimatedmaturity"`
+	CreatedOn         int64  `json:"createdon"`
+	PaidOnHeight      uint32 `json:"paidonheight"`
+	TransactionID     string `json:"transactionid"`
+}
+
+// apiAccountProof is the request body of token management requests. The
+// signature must be a base64 encoded signature, by the address, of the
+// message returned by pool.AccountProofMessage for the requested action and
+// timestamp.
+type apiAccountProof struct {
+	Address   string `json:"address"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Kind      string `json:"kind,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// apiWebhook describes a webhook of an account. Secret is only set in the
+// response to the request registering the webhook.
+type apiWebhook struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	CreatedOn int64  `json:"createdon"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+// apiToken describes a token of an account. Token and URL are only set in
+// the response to the request minting the token, they cannot be recovered
+// afterwards.
+type apiToken struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	CreatedOn int64  `json:"createdon"`
+	Token     string `json:"token,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// sendAPIError writes a JSON encoded error with the provided status code.
+func sendAPIError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	sendJSONResponse(w, apiError{Error: msg})
+}
+
+// getAPIListParams parses the offset and limit parameters of public API list
+// requests, applying defaults when they are not provided.
+func getAPIListParams(r *http.Request) (offset, limit int, err error) {
+	limit = defaultAPILimit
+	if v := r.FormValue("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid offset")
+		}
+	}
+	if v := r.FormValue("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAPILimit {
+			return 0, 0, errors.New("invalid limit")
+		}
+	}
+	return offset, limit, nil
+}
+
+// apiWindow returns the bounds of the requested window of a list of count
+// items.
+func apiWindow(count, offset, limit int) (int, int) {
+	if offset > count {
+		offset = count
+	}
+	return offset, min(offset+limit, count)
+}
+
+// ratToFloat returns the float64 representation of the provided big.Rat.
+func ratToFloat(rat *big.Rat) float64 {
+	f, _ := rat.Float64()
+	return f
+}
+
+// apiAccountID returns the account id of the request, responding with a
+// "404 Not Found" and returning false if it does not reference a pool
+// account. Requests without an account id in their path are authenticated
+// with the api token provided in their Authorization header, responding with
+// a "401 Unauthorized" if the token is missing or invalid.
+func (ui *GUI) apiAccountID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	accountID, ok := mux.Vars(r)["accountID"]
+	if !ok {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			sendAPIError(w, http.StatusUnauthorized, "api token required")
+			return "", false
+		}
+
+		var err error
+		accountID, err = ui.cfg.ResolveAPIToken(token, pool.APITokenKind)
+		if err != nil {
+			log.Tracef("unable to resolve api token: %v", err)
+			sendAPIError(w, http.StatusUnauthorized, "invalid api token")
+			return "", false
+		}
+	}
+
+	if !ui.cfg.AccountExists(accountID) {
+		sendAPIError(w, http.StatusNotFound, "account not found")
+		return "", false
+	}
+	return accountID, true
+}
+
+// apiStats is the handler for "GET /api/v1/stats".
+func (ui *GUI) apiStats(w http.ResponseWriter, r *http.Request) {
+	hashData, err := ui.cfg.FetchHashData()
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch hash data")
+		return
+	}
+
+	lastPmtHeight, lastPmtPaidOn, lastPmtCreatedOn, err := ui.cfg.FetchLastPaymentInfo()
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch payment info")
+		return
+	}
+
+	work, err := ui.cfg.FetchMinedWork()
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch blocks")
+		return
+	}
+	orphaned, _, orphanRate := pool.OrphanStats(work)
+
+	poolHashRate := new(big.Rat)
+	var workers int
+	for _, data := range hashData {
+		for _, entry := range data {
+			poolHashRate.Add(poolHashRate, entry.HashRate)
+			workers++
+		}
+	}
+
+	sendJSONResponse(w, apiPoolStats{
+		Network:              ui.cfg.ActiveNet.Name,
+		SoloPool:             ui.cfg.SoloPool,
+		PaymentMethod:        ui.cfg.PaymentMethod,
+		PoolFee:              ui.poolFee(),
+		PoolHashRate:         ratToFloat(poolHashRate),
+		Workers:              workers,
+		LastWorkHeight:       ui.cfg.FetchLastWorkHeight(),
+		LastPaymentHeight:    lastPmtHeight,
+		LastPaymentPaidOn:    lastPmtPaidOn,
+		LastPaymentCreatedOn: lastPmtCreatedOn,
+		OrphanedBlocks:       orphaned,
+		OrphanRate:           orphanRate,
+	})
+}
+
+// apiBlocks is the handler for "GET /api/v1/blocks". It returns blocks mined
+// by the pool, most recent first. Blocks can be filtered by the account which
+// mined them with the account parameter.
+func (ui *GUI) apiBlocks(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := getAPIListParams(r)
+	if err != nil {
+		sendAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	work, err := ui.cfg.FetchMinedWork()
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch blocks")
+		return
+	}
+
+	accountID := r.FormValue("account")
+	blocks := make([]*apiBlock, 0, len(work))
+	for _, aw := range work {
+		if accountID != "" && aw.MinedBy != accountID {
+			continue
+		}
+		blocks = append(blocks, &apiBlock{
+			Height:     aw.Height,
+			Hash:       aw.BlockHash,
+			MinedBy:    aw.MinedBy,
+			Miner:      aw.Miner,
+			Confirmed:  aw.Confirmed,
+			Status:     aw.Status,
+			OrphanedBy: aw.OrphanedBy,
+			CreatedOn:  aw.CreatedOn,
+		})
+	}
+
+	first, last := apiWindow(len(blocks), offset, limit)
+	sendJSONResponse(w, apiList{
+		Count:  len(blocks),
+		Offset: offset,
+		Limit:  limit,
+		Data:   blocks[first:last],
+	})
+}
+
+// accountHashData returns the hash data of all connected clients of the
+// provided account.
+func (ui *GUI) accountHashData(accountID string) ([]*pool.HashData, error) {
+	hashData, err := ui.cfg.FetchHashData()
+	if err != nil {
+		return nil, err
+	}
+	return hashData[accountID], nil
+}
+
+// apiAccountWorkers is the handler for "GET /api/v1/account/{accountID}/workers".
+func (ui *GUI) apiAccountWorkers(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := ui.apiAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	hashData, err := ui.accountHashData(accountID)
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch workers")
+		return
+	}
+
+	workers := make([]*apiWorker, 0, len(hashData))
+	for _, data := range hashData {
+		workers = append(workers, &apiWorker{
+			Miner:     data.Miner,
+			HashRate:  ratToFloat(data.HashRate),
+			UpdatedOn: data.UpdatedOn,
+		})
+	}
+
+	sendJSONResponse(w, apiList{
+		Count: len(workers),
+		Limit: len(workers),
+		Data:  workers,
+	})
+}
+
+// apiAccountHashRate is the handler for
+// "GET /api/v1/account/{accountID}/hashrate".
+func (ui *GUI) apiAccountHashRate(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := ui.apiAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	hashData, err := ui.accountHashData(accountID)
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch hashrate")
+		return
+	}
+
+	hashRate := new(big.Rat)
+	for _, data := range hashData {
+		hashRate.Add(hashRate, data.HashRate)
+	}
+
+	sendJSONResponse(w, apiHashRate{
+		AccountID: accountID,
+		HashRate:  ratToFloat(hashRate),
+		Workers:   len(hashData),
+	})
+}
+
+// accountPayments returns the pending and paid payments of the provided
+// account.
+func (ui *GUI) accountPayments(accountID string) ([]*pool.Payment, []*pool.Payment, error) {
+	filter := func(pmts []*pool.Payment) []*pool.Payment {
+		filtered := make([]*pool.Payment, 0)
+		for _, pmt := range pmts {
+			if pmt.Account == accountID {
+				filtered = append(filtered, pmt)
+			}
+		}
+		return filtered
+	}
+
+	pending, err := ui.cfg.FetchPendingPayments()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paid, err := ui.cfg.FetchArchivedPayments()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return filter(pending), filter(paid), nil
+}
+
+// apiAccountBalance is the handler for
+// "GET /api/v1/account/{accountID}/balance".
+func (ui *GUI) apiAccountBalance(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := ui.apiAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	balance, err := ui.cfg.FetchAccountBalance(accountID)
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch balance")
+		return
+	}
+
+	sendJSONResponse(w, apiBalance{
+		AccountID: accountID,
+		Pending:   int64(balance.Balance),
+		Paid:      int64(balance.Paid),
+	})
+}
+
+// apiAccountPayments is the handler for
+// "GET /api/v1/account/{accountID}/payments". It returns payments of the
+// account, most recent first. Payments can be filtered with the status
+// parameter, either "pending" or "paid".
+func (ui *GUI) apiAccountPayments(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := getAPIListParams(r)
+	if err != nil {
+		sendAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := r.FormValue("status")
+	switch status {
+	case "", apiPaymentPending, apiPaymentPaid:
+	default:
+		sendAPIError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	accountID, ok := ui.apiAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	pending, paid, err := ui.accountPayments(accountID)
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch payments")
+		return
+	}
+
+	payments := make([]*apiPayment, 0, len(pending)+len(paid))
+	add := func(pmts []*pool.Payment, status string) {
+		for _, pmt := range pmts {
+			payments = append(payments, &apiPayment{
+				Height:            pmt.Height,
+				Amount:            int64(pmt.Amount),
+				Status:            status,
+				EstimatedMaturity: pmt.EstimatedMaturity,
+				CreatedOn:         pmt.CreatedOn,
+				PaidOnHeight:      pmt.PaidOnHeight,
+				TransactionID:     pmt.TransactionID,
+			})
+		}
+	}
+	if status != apiPaymentPaid {
+		add(pending, apiPaymentPending)
+	}
+	if status != apiPaymentPending {
+		add(paid, apiPaymentPaid)
+	}
+
+	sort.SliceStable(payments, func(i, j int) bool {
+		return payments[i].Height > payments[j].Height
+	})
+
+	first, last := apiWindow(len(payments), offset, limit)
+	sendJSONResponse(w, apiList{
+		Count:  len(payments),
+		Offset: offset,
+		Limit:  limit,
+		Data:   payments[first:last],
+	})
+}
+
+// apiAccountWorkerStatus is the handler for
+// "GET /api/v1/account/{accountID}/workers/status". It lists the tracked
+// workers of the account, including those which went offline.
+func (ui *GUI) apiAccountWorkerStatus(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := ui.apiAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	tracked := ui.cfg.FetchWorkers(accountID)
+	workers := make([]*apiTrackedWorker, 0, len(tracked))
+	for _, worker := range tracked {
+		workers = append(workers, &apiTrackedWorker{
+			Name:        worker.Name,
+			Miner:       worker.Miner,
+			Status:      worker.Status,
+			StatusSince: worker.StatusSince,
+			HashRate:    worker.HashRate,
+			Baseline:    worker.Baseline,
+			LastSeen:    worker.LastSeen,
+		})
+	}
+
+	sendJSONResponse(w, apiList{
+		Count: len(workers),
+		Limit: len(workers),
+		Data:  workers,
+	})
+}
+
+// apiAccountWorkerEvents is the handler for
+// "GET /api/v1/account/{accountID}/workers/events".
+func (ui *GUI) apiAccountWorkerEvents(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := getAPIListParams(r)
+	if err != nil {
+		sendAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accountID, ok := ui.apiAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	workerEvents, err := ui.cfg.FetchWorkerEvents(accountID)
+	if err != nil {
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to fetch worker events")
+		return
+	}
+
+	events := make([]*apiWorkerEvent, 0, len(workerEvents))
+	for _, event := range workerEvents {
+		events = append(events, &apiWorkerEvent{
+			Worker:    event.Worker,
+			Kind:      event.Kind,
+			HashRate:  event.HashRate,
+			Baseline:  event.Baseline,
+			LastSeen:  event.LastSeen,
+			CreatedOn: event.CreatedOn,
+		})
+	}
+
+	first, last := apiWindow(len(events), offset, limit)
+	sendJSONResponse(w, apiList{
+		Count:  len(events),
+		Offset: offset,
+		Limit:  limit,
+		Data:   events[first:last],
+	})
+}
+
+// decodeAccountProof decodes the account ownership proof of a token
+// management request, responding with a "400 Bad Request" and returning
+// false if the body is malformed.
+func decodeAccountProof(w http.ResponseWriter, r *http.Request) (*apiAccountProof, bool) {
+	var proof apiAccountProof
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&proof)
+	if err != nil || proof.Address == "" || proof.Signature == "" {
+		sendAPIError(w, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+	return &proof, true
+}
+
+// sendTokenError responds with the status code appropriate to the provided
+// token management error.
+func sendTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errs.Unauthorized):
+		sendAPIError(w, http.StatusUnauthorized, "invalid account proof")
+	case errors.Is(err, errs.ValueNotFound):
+		sendAPIError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, errs.LimitExceeded):
+		sendAPIError(w, http.StatusConflict, "limit reached")
+	case errors.Is(err, errs.Parse):
+		sendAPIError(w, http.StatusBadRequest, "invalid url")
+	default:
+		log.Error(err)
+		sendAPIError(w, http.StatusInternalServerError, "unable to process request")
+	}
+}
+
+// apiMintToken is the handler for "POST /api/v1/tokens". It mints a new
+// read-only token of the requested kind, either "api" or "watcher", for the
+// account of the address proving ownership. The response is the only time
+// the token is revealed.
+func (ui *GUI) apiMintToken(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	token, apiTkn, err := ui.cfg.MintAPIToken(proof.Address, proof.Kind,
+		proof.Signature, proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	resp := apiToken{
+		ID:        apiTkn.UUID,
+		Kind:      apiTkn.Kind,
+		CreatedOn: apiTkn.CreatedOn,
+		Token:     token,
+	}
+	if apiTkn.Kind == pool.WatcherTokenKind {
+		resp.URL = "/watch/" + token
+	}
+
+	sendJSONResponse(w, resp)
+}
+
+// apiListTokens is the handler for "POST /api/v1/tokens/list". It lists the
+// tokens of the account of the address proving ownership.
+func (ui *GUI) apiListTokens(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := ui.cfg.FetchAPITokens(proof.Address, proof.Signature,
+		proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	data := make([]*apiToken, 0, len(tokens))
+	for _, t := range tokens {
+		data = append(data, &apiToken{
+			ID:        t.UUID,
+			Kind:      t.Kind,
+			CreatedOn: t.CreatedOn,
+		})
+	}
+
+	sendJSONResponse(w, apiList{
+		Count: len(data),
+		Limit: len(data),
+		Data:  data,
+	})
+}
+
+// apiRevokeToken is the handler for "POST /api/v1/tokens/{id}/revoke". It
+// revokes the referenced token of the account of the address proving
+// ownership.
+func (ui *GUI) apiRevokeToken(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := ui.cfg.RevokeAPIToken(proof.Address, id, proof.Signature,
+		proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiRegisterWebhook is the handler for "POST /api/v1/webhooks". It
+// registers the requested URL to be notified of the events of the account of
+// the address proving ownership. The response is the only time the secret
+// signing the payloads delivered to the URL is revealed.
+func (ui *GUI) apiRegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	hook, err := ui.cfg.RegisterWebhook(proof.Address, proof.URL,
+		proof.Signature, proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	sendJSONResponse(w, apiWebhook{
+		ID:        hook.UUID,
+		URL:       hook.URL,
+		CreatedOn: hook.CreatedOn,
+		Secret:    hook.Secret,
+	})
+}
+
+// apiListWebhooks is the handler for "POST /api/v1/webhooks/list". It lists
+// the webhooks of the account of the address proving ownership.
+func (ui *GUI) apiListWebhooks(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	hooks, err := ui.cfg.FetchWebhooks(proof.Address, proof.Signature,
+		proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	data := make([]*apiWebhook, 0, len(hooks))
+	for _, hook := range hooks {
+		data = append(data, &apiWebhook{
+			ID:        hook.UUID,
+			URL:       hook.URL,
+			CreatedOn: hook.CreatedOn,
+		})
+	}
+
+	sendJSONResponse(w, apiList{
+		Count: len(data),
+		Limit: len(data),
+		Data:  data,
+	})
+}
+
+// apiDeleteWebhook is the handler for "POST /api/v1/webhooks/{id}/delete".
+// It deletes the referenced webhook of the account of the address proving
+// ownership.
+func (ui *GUI) apiDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := ui.cfg.DeleteWebhook(proof.Address, id, proof.Signature,
+		proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiForgetWorker is the handler for "POST /api/v1/workers/{name}/forget".
+// It stops tracking the referenced worker of the account of the address
+// proving ownership, so a retired worker is no longer reported offline.
+func (ui *GUI) apiForgetWorker(w http.ResponseWriter, r *http.Request) {
+	proof, ok := decodeAccountProof(w, r)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	err := ui.cfg.ForgetWorker(proof.Address, name, proof.Signature,
+		proof.Timestamp)
+	if err != nil {
+		sendTokenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
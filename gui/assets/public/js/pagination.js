@@ -28,7 +28,7 @@ if ( $('#blocks-by-account-page-select').length ) {
             var html = '';
             if (data.length > 0) {
                 $.each(data, function(_, item){
-                    html += '<tr><td><a href="' + item.blockurl + '" rel="noopener noreferrer">' + item.blockheight + '</a></td><td>' + item.confirmed + '</td><td>' + item.miner + '</td></tr>';
+                    html += '<tr><td><a href="' + item.blockurl + '" rel="noopener noreferrer">' + item.blockheight + '</a></td><td>' + item.status + '</td><td>' + item.miner + '</td></tr>';
                 });
             } else {
                 html += '<tr><td colspan="100%"><span class="no-data">No mined blocks</span></td></tr>';
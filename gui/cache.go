@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/decred/dcrd/dcrutil/v3"
@@ -17,9 +18,10 @@ import (
 // client represents a mining client. It is json annotated so it can easily be
 // encoded and sent over a websocket or pagination request.
 type client struct {
-	Miner    string `json:"miner"`
-	IP       string `json:"ip"`
-	HashRate string `json:"hashrate"`
+	ExtraNonce1 string `json:"-"`
+	Miner       string `json:"miner"`
+	IP          string `json:"ip"`
+	HashRate    string `json:"hashrate"`
 }
 
 // minedWork represents a block mined by the pool. It is json annotated so it
@@ -30,6 +32,7 @@ type minedWork struct {
 	MinedBy     string `json:"minedby"`
 	Miner       string `json:"miner"`
 	Confirmed   bool   `json:"confirmed"`
+	Status      string `json:"status"`
 	// AccountID holds the full ID (not truncated) and so should not be json encoded
 	AccountID string `json:"-"`
 }
@@ -70,6 +73,9 @@ type archivedPayment struct {
 type Cache struct {
 	blockExplorerURL      string
 	minedWork             []*minedWork
+	orphanedBlocks        int
+	resolvedBlocks        int
+	orphanRate            float64
 	minedWorkMtx          sync.RWMutex
 	rewardQuotas          []*rewardQuota
 	rewardQuotasMtx       sync.RWMutex
@@ -92,14 +98,15 @@ type Cache struct {
 // InitCache initialises and returns a cache for use in the GUI.
 func InitCache(work []*pool.AcceptedWork, quotas []*pool.Quota,
 	hashData map[string][]*pool.HashData, pendingPmts []*pool.Payment,
-	archivedPmts []*pool.Payment, blockExplorerURL string,
+	archivedPmts []*pool.Payment, balances map[string]*pool.AccountBalance,
+	blockExplorerURL string,
 	lastPmtHeight uint32, lastPmtPaidOn, lastPmtCreatedOn int64) *Cache {
 
 	cache := Cache{blockExplorerURL: blockExplorerURL}
 	cache.updateMinedWork(work)
 	cache.updateRewardQuotas(quotas)
 	cache.updateHashData(hashData)
-	cache.updatePayments(pendingPmts, archivedPmts)
+	cache.updatePayments(pendingPmts, archivedPmts, balances)
 	cache.updateLastPaymentInfo(lastPmtHeight, lastPmtPaidOn, lastPmtCreatedOn)
 	return &cache
 }
@@ -123,14 +130,29 @@ func (c *Cache) updateMinedWork(work []*pool.AcceptedWork) {
 			Miner:       w.Miner,
 			AccountID:   w.MinedBy,
 			Confirmed:   w.Confirmed,
+			Status:      w.Status,
 		})
 	}
+	orphaned, resolved, rate := pool.OrphanStats(work)
 
 	c.minedWorkMtx.Lock()
 	c.minedWork = workData
+	c.orphanedBlocks = orphaned
+	c.resolvedBlocks = resolved
+	c.orphanRate = rate
 	c.minedWorkMtx.Unlock()
 }
 
+// getOrphanRate retrieves the cached orphan rate of blocks mined by the
+// pool, formatted for display along with the number of orphaned blocks out
+// of all mined or orphaned blocks. eg. "1.5% (3 of 200)"
+func (c *Cache) getOrphanRate() string {
+	c.minedWorkMtx.RLock()
+	defer c.minedWorkMtx.RUnlock()
+	return fmt.Sprintf("%s (%d of %d)", floatToPercent(c.orphanRate),
+		c.orphanedBlocks, c.resolvedBlocks)
+}
+
 // getConfirmedMinedWork retrieves the cached list of confirmed blocks mined by
 // the pool.
 func (c *Cache) getConfirmedMinedWork(first, last int) (int, []*minedWork, error) {
@@ -239,9 +261,12 @@ func (c *Cache) updateHashData(hashData map[string][]*pool.HashData) {
 			poolHashRate = poolHashRate.Add(poolHashRate, entry.HashRate)
 			clientInfo[entry.AccountID] = append(clientInfo[entry.AccountID],
 				&client{
-					Miner:    entry.Miner,
-					IP:       entry.IP,
-					HashRate: hashString(entry.HashRate),
+					// Hash data ids are the client's extraNonce1
+					// followed by its account id.
+					ExtraNonce1: strings.TrimSuffix(entry.UUID, entry.AccountID),
+					Miner:       entry.Miner,
+					IP:          entry.IP,
+					HashRate:    hashString(entry.HashRate),
 				})
 		}
 	}
@@ -283,14 +308,21 @@ func (c *Cache) getClients() map[string][]*client {
 }
 
 // updatePayments will update the cached lists of both pending and archived
-// payments.
-func (c *Cache) updatePayments(pendingPmts []*pool.Payment, archivedPmts []*pool.Payment) {
+// payments. The pending and paid totals of accounts are their ledger balances
+// and the amounts paid out to them.
+func (c *Cache) updatePayments(pendingPmts []*pool.Payment, archivedPmts []*pool.Payment, balances map[string]*pool.AccountBalance) {
 	// Sort list so the most recently earned rewards will be shown first.
 	sort.Slice(pendingPmts, func(i, j int) bool {
 		return pendingPmts[i].Height > pendingPmts[j].Height
 	})
 
-	pendingPaymentTotals := make(map[string]dcrutil.Amount)
+	pendingPaymentTotals := make(map[string]dcrutil.Amount, len(balances))
+	archivedPaymentTotals := make(map[string]dcrutil.Amount, len(balances))
+	for account, balance := range balances {
+		pendingPaymentTotals[account] = balance.Balance
+		archivedPaymentTotals[account] = balance.Paid
+	}
+
 	pendingPayments := make(map[string][]*pendingPayment)
 	for _, p := range pendingPmts {
 		accountID := p.Account
@@ -302,10 +334,6 @@ func (c *Cache) updatePayments(pendingPmts []*pool.Payment, archivedPmts []*pool
 				EstimatedPaymentHeight: fmt.Sprint(p.EstimatedMaturity + 1),
 			},
 		)
-		if _, ok := pendingPaymentTotals[accountID]; !ok {
-			pendingPaymentTotals[accountID] = dcrutil.Amount(0)
-		}
-		pendingPaymentTotals[accountID] += p.Amount
 	}
 
 	c.pendingPaymentsMtx.Lock()
@@ -318,7 +346,6 @@ func (c *Cache) updatePayments(pendingPmts []*pool.Payment, archivedPmts []*pool
 		return archivedPmts[i].Height > archivedPmts[j].Height
 	})
 
-	archivedPaymentTotals := make(map[string]dcrutil.Amount)
 	archivedPayments := make(map[string][]*archivedPayment)
 	for _, p := range archivedPmts {
 		accountID := p.Account
@@ -332,10 +359,6 @@ func (c *Cache) updatePayments(pendingPmts []*pool.Payment, archivedPmts []*pool
 				TxURL:         txURL(c.blockExplorerURL, p.TransactionID),
 				TxID:          fmt.Sprintf("%.10s...", p.TransactionID),
 			})
-		if _, ok := archivedPaymentTotals[accountID]; !ok {
-			archivedPaymentTotals[accountID] = dcrutil.Amount(0)
-		}
-		archivedPaymentTotals[accountID] += p.Amount
 	}
 
 	c.archivedPaymentsMtx.Lock()
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/acme/autocert"
@@ -23,6 +24,7 @@ import (
 	"github.com/gorilla/sessions"
 
 	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrpool/pool"
 )
 
@@ -37,8 +39,6 @@ type Config struct {
 	GUIDir string
 	// CSRFSecret represents the frontend's CSRF secret.
 	CSRFSecret []byte
-	// AdminPass represents the admin password.
-	AdminPass string
 	// GUIListen represents the listening address the frontend is served on.
 	GUIListen string
 	// TLSCertFile represents the TLS certificate file path.
@@ -85,11 +85,132 @@ type Config struct {
 	FetchPendingPayments func() ([]*pool.Payment, error)
 	// FetchCacheChannel returns the gui cache signal channel.
 	FetchCacheChannel func() chan pool.CacheUpdateEvent
+	// MintAPIToken creates a read-only token for the account of the provided
+	// address given a signed proof of ownership.
+	MintAPIToken func(address, kind, signature string, timestamp int64) (string, *pool.APIToken, error)
+	// FetchAPITokens returns the tokens of the account of the provided
+	// address given a signed proof of ownership.
+	FetchAPITokens func(address, signature string, timestamp int64) ([]*pool.APIToken, error)
+	// RevokeAPIToken deletes a token of the account of the provided address
+	// given a signed proof of ownership.
+	RevokeAPIToken func(address, id, signature string, timestamp int64) error
+	// RegisterWebhook registers a URL notified of the events of the account
+	// of the provided address given a signed proof of ownership.
+	RegisterWebhook func(address, url, signature string, timestamp int64) (*pool.Webhook, error)
+	// FetchWebhooks returns the webhooks of the account of the provided
+	// address given a signed proof of ownership.
+	FetchWebhooks func(address, signature string, timestamp int64) ([]*pool.Webhook, error)
+	// DeleteWebhook deletes a webhook of the account of the provided address
+	// given a signed proof of ownership.
+	DeleteWebhook func(address, id, signature string, timestamp int64) error
+	// FetchWorkers returns the tracked workers of the provided account.
+	FetchWorkers func(accountID string) []*pool.Worker
+	// FetchWorkerEvents returns the worker events of the provided account,
+	// most recent first.
+	FetchWorkerEvents func(accountID string) ([]*pool.WorkerEvent, error)
+	// ForgetWorker stops tracking a worker of the account of the provided
+	// address given a signed proof of ownership.
+	ForgetWorker func(address, name, signature string, timestamp int64) error
+	// ResolveAPIToken returns the account id referenced by the provided token.
+	ResolveAPIToken func(token, kind string) (string, error)
+	// AuthenticateAdmin returns the admin user referenced by the provided
+	// credentials.
+	AuthenticateAdmin func(name, password, code, ip string) (*pool.AdminUser, error)
+	// FetchAdminUser returns the referenced admin user.
+	FetchAdminUser func(name string) (*pool.AdminUser, error)
+	// FetchAdminUsers returns all admin users.
+	FetchAdminUsers func() ([]*pool.AdminUser, error)
+	// CreateAdminUser creates an admin user on behalf of the provided actor.
+	CreateAdminUser func(actor, ip, name, password, role string) error
+	// DeleteAdminUser deletes an admin user on behalf of the provided actor.
+	DeleteAdminUser func(actor, ip, name string) error
+	// ChangeAdminPassword updates the password of an admin user.
+	ChangeAdminPassword func(name, ip, oldPass, newPass string) error
+	// GenerateAdminTOTP returns a new TOTP secret and its otpauth URL.
+	GenerateAdminTOTP func(name, issuer string) (string, string, error)
+	// EnableAdminTOTP enables a TOTP second factor for an admin user.
+	EnableAdminTOTP func(name, ip, secret, code string) error
+	// DisableAdminTOTP removes the TOTP second factor of an admin user.
+	DisableAdminTOTP func(name, ip, code string) error
+	// RecordAdminAction persists an audit log entry of an admin action.
+	RecordAdminAction func(actor, action, details, ip string) error
+	// FetchAuditEntries returns the most recent admin audit log entries.
+	FetchAuditEntries func(limit int) ([]*pool.AuditEntry, error)
+	// DisconnectClient disconnects a client by extraNonce1 or IP address on
+	// behalf of the provided actor.
+	DisconnectClient func(actor, ip, target string) error
+	// ForceClientDifficulty overrides the difficulty of a client on behalf
+	// of the provided actor.
+	ForceClientDifficulty func(actor, ip, extraNonce1 string, difficulty float64) error
+	// BanClient bans an IP address or account on behalf of the provided
+	// actor.
+	BanClient func(actor, ip, target, reason string) error
+	// UnbanClient lifts the ban of an IP address or account on behalf of the
+	// provided actor.
+	UnbanClient func(actor, ip, target string) error
+	// FetchBans returns all banned IP addresses and accounts.
+	FetchBans func() []*pool.Ban
+	// AdjustBalance books a manual adjustment of the ledger balance of an
+	// account or the pool fees on behalf of the provided actor.
+	AdjustBalance func(actor, ip, target string, amount dcrutil.Amount, memo string) error
+	// FetchLedgerEntries returns the most recent ledger entries of a ledger
+	// account, or of all ledger accounts if it is empty.
+	FetchLedgerEntries func(account string, limit int) ([]*pool.LedgerEntry, error)
+	// FetchLedgerBalances returns the balance of every ledger account.
+	FetchLedgerBalances func() (map[string]dcrutil.Amount, error)
+	// FetchAccountBalances returns the ledger balance of every ledger
+	// account along with the amount paid out to it.
+	FetchAccountBalances func() (map[string]*pool.AccountBalance, error)
+	// FetchAccountBalance returns the ledger balance of the provided account
+	// along with the amount paid out to it.
+	FetchAccountBalance func(accountID string) (*pool.AccountBalance, error)
+	// FetchPendingPayout returns the payout transaction awaiting an external
+	// signature.
+	FetchPendingPayout func() (*pool.PendingPayout, error)
+	// ExportPayout returns the payout transaction awaiting an external
+	// signature for download on behalf of the provided actor.
+	ExportPayout func(actor, ip string) (*pool.PendingPayout, error)
+	// PublishPayout publishes the signed payout transaction on behalf of the
+	// provided actor after verifying it matches the pending payout.
+	PublishPayout func(ctx context.Context, actor, ip, signedTx string) (string, error)
+	// DiscardPayout deletes the payout transaction awaiting an external
+	// signature on behalf of the provided actor.
+	DiscardPayout func(actor, ip string) error
+	// FetchBlockSubmissions returns the most recent solved block submissions
+	// along with the outcome of every submission.
+	FetchBlockSubmissions func() []*pool.BlockSubmission
+	// FetchBlockEventStats returns the block notification processing
+	// counters along with the block notifications pending processing.
+	FetchBlockEventStats func() *pool.BlockEventStats
+	// FetchWalletStatus returns the outcome of the latest wallet health
+	// check, or nil if the wallet is not monitored.
+	FetchWalletStatus func() *pool.WalletStatus
+	// FetchWebhookDeliveries returns the webhook events pending delivery.
+	FetchWebhookDeliveries func() ([]*pool.WebhookDelivery, error)
+	// ReloadConfig reloads the pool configuration, returning the names of the
+	// settings applied and of the changed settings requiring a restart.
+	ReloadConfig func() ([]string, []string, error)
+	// APIAllowedOrigins represents the origins permitted to make cross-origin
+	// requests to the public API. All origins are permitted if empty.
+	APIAllowedOrigins []string
+}
+
+// ReloadableConfig contains the GUI configuration values which can be changed
+// while the pool is running.
+type ReloadableConfig struct {
+	// Designation represents the codename of the pool.
+	Designation string
+	// PoolFee represents the fee charged to participating accounts of the pool.
+	PoolFee float64
+	// APIAllowedOrigins represents the origins permitted to make cross-origin
+	// requests to the public API.
+	APIAllowedOrigins []string
 }
 
 // GUI represents the the mining pool user interface.
 type GUI struct {
 	cfg             *Config
+	cfgMtx          sync.RWMutex
 	limiter         *pool.RateLimiter
 	templates       *template.Template
 	cookieStore     *sessions.CookieStore
@@ -119,6 +240,38 @@ type headerData struct {
 	ShowMenu    bool
 }
 
+// designation returns the codename of the pool.
+func (ui *GUI) designation() string {
+	ui.cfgMtx.RLock()
+	defer ui.cfgMtx.RUnlock()
+	return ui.cfg.Designation
+}
+
+// poolFee returns the fee charged to participating accounts of the pool.
+func (ui *GUI) poolFee() float64 {
+	ui.cfgMtx.RLock()
+	defer ui.cfgMtx.RUnlock()
+	return ui.cfg.PoolFee
+}
+
+// apiAllowedOrigins returns the origins permitted to make cross-origin
+// requests to the public API.
+func (ui *GUI) apiAllowedOrigins() []string {
+	ui.cfgMtx.RLock()
+	defer ui.cfgMtx.RUnlock()
+	return ui.cfg.APIAllowedOrigins
+}
+
+// ApplyConfig applies the provided configuration values to the user interface
+// while it is running.
+func (ui *GUI) ApplyConfig(rc *ReloadableConfig) {
+	ui.cfgMtx.Lock()
+	ui.cfg.Designation = rc.Designation
+	ui.cfg.PoolFee = rc.PoolFee
+	ui.cfg.APIAllowedOrigins = rc.APIAllowedOrigins
+	ui.cfgMtx.Unlock()
+}
+
 // route configures the http router of the user interface.
 func (ui *GUI) route() {
 	ui.router = mux.NewRouter()
@@ -131,6 +284,39 @@ func (ui *GUI) route() {
 	assetsRouter.PathPrefix("/").Handler(http.StripPrefix("/assets",
 		http.FileServer(assetsDir)))
 
+	// The public API has its own rate limiting and CORS handling, it does
+	// not use sessions or CSRF protection.
+	apiRouter := ui.router.PathPrefix(apiPathPrefix).Subrouter()
+	apiRouter.Use(ui.apiMiddleware)
+
+	apiRouter.HandleFunc("/stats", ui.apiStats).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/blocks", ui.apiBlocks).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/account/{accountID}/workers", ui.apiAccountWorkers).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/account/{accountID}/hashrate", ui.apiAccountHashRate).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/account/{accountID}/balance", ui.apiAccountBalance).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/account/{accountID}/payments", ui.apiAccountPayments).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/account/{accountID}/workers/status", ui.apiAccountWorkerStatus).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/account/{accountID}/workers/events", ui.apiAccountWorkerEvents).Methods("GET", "OPTIONS")
+
+	// Account endpoints authenticated with an api token instead of an
+	// account id.
+	apiRouter.HandleFunc("/me/workers", ui.apiAccountWorkers).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/me/hashrate", ui.apiAccountHashRate).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/me/balance", ui.apiAccountBalance).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/me/payments", ui.apiAccountPayments).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/me/workers/status", ui.apiAccountWorkerStatus).Methods("GET", "OPTIONS")
+	apiRouter.HandleFunc("/me/workers/events", ui.apiAccountWorkerEvents).Methods("GET", "OPTIONS")
+
+	// Token management endpoints are authenticated with a signed proof of
+	// account ownership.
+	apiRouter.HandleFunc("/tokens", ui.apiMintToken).Methods("POST", "OPTIONS")
+	apiRouter.HandleFunc("/tokens/list", ui.apiListTokens).Methods("POST", "OPTIONS")
+	apiRouter.HandleFunc("/tokens/{id}/revoke", ui.apiRevokeToken).Methods("POST", "OPTIONS")
+	apiRouter.HandleFunc("/webhooks", ui.apiRegisterWebhook).Methods("POST", "OPTIONS")
+	apiRouter.HandleFunc("/webhooks/list", ui.apiListWebhooks).Methods("POST", "OPTIONS")
+	apiRouter.HandleFunc("/webhooks/{id}/delete", ui.apiDeleteWebhook).Methods("POST", "OPTIONS")
+	apiRouter.HandleFunc("/workers/{name}/forget", ui.apiForgetWorker).Methods("POST", "OPTIONS")
+
 	// All other routes have rate limiting and CSRF protection applied.
 	guiRouter := ui.router.PathPrefix("/").Subrouter()
 
@@ -142,10 +328,33 @@ func (ui *GUI) route() {
 	guiRouter.HandleFunc("/", ui.homepage).Methods("GET")
 	guiRouter.HandleFunc("/account", ui.account).Methods("GET")
 	guiRouter.HandleFunc("/account", ui.isPoolAccount).Methods("HEAD")
+	guiRouter.HandleFunc("/watch/{token}", ui.watcher).Methods("GET")
 	guiRouter.HandleFunc("/admin", ui.adminPage).Methods("GET")
 	guiRouter.HandleFunc("/admin", ui.adminLogin).Methods("POST")
 	guiRouter.HandleFunc("/backup", ui.downloadDatabaseBackup).Methods("POST")
 	guiRouter.HandleFunc("/logout", ui.adminLogout).Methods("POST")
+	guiRouter.HandleFunc("/admin/users", ui.createAdminUser).Methods("POST")
+	guiRouter.HandleFunc("/admin/users/{name}/delete", ui.deleteAdminUser).Methods("POST")
+	guiRouter.HandleFunc("/admin/password", ui.changeAdminPassword).Methods("POST")
+	guiRouter.HandleFunc("/admin/totp/setup", ui.setupAdminTOTP).Methods("POST")
+	guiRouter.HandleFunc("/admin/totp/enable", ui.enableAdminTOTP).Methods("POST")
+	guiRouter.HandleFunc("/admin/totp/disable", ui.disableAdminTOTP).Methods("POST")
+	guiRouter.HandleFunc("/admin/audit", ui.adminAuditLog).Methods("GET")
+	guiRouter.HandleFunc("/admin/clients/disconnect", ui.disconnectClient).Methods("POST")
+	guiRouter.HandleFunc("/admin/clients/difficulty", ui.forceClientDifficulty).Methods("POST")
+	guiRouter.HandleFunc("/admin/bans", ui.banClient).Methods("POST")
+	guiRouter.HandleFunc("/admin/bans/unban", ui.unbanClient).Methods("POST")
+	guiRouter.HandleFunc("/admin/reload", ui.reloadConfig).Methods("POST")
+	guiRouter.HandleFunc("/admin/ledger", ui.adminLedger).Methods("GET")
+	guiRouter.HandleFunc("/admin/ledger/balances", ui.adminLedgerBalances).Methods("GET")
+	guiRouter.HandleFunc("/admin/ledger/adjust", ui.adjustBalance).Methods("POST")
+	guiRouter.HandleFunc("/admin/payout", ui.downloadPendingPayout).Methods("GET")
+	guiRouter.HandleFunc("/admin/payout/publish", ui.publishPayout).Methods("POST")
+	guiRouter.HandleFunc("/admin/payout/discard", ui.discardPayout).Methods("POST")
+	guiRouter.HandleFunc("/admin/submissions", ui.adminBlockSubmissions).Methods("GET")
+	guiRouter.HandleFunc("/admin/blockevents", ui.adminBlockEvents).Methods("GET")
+	guiRouter.HandleFunc("/admin/wallet", ui.adminWalletStatus).Methods("GET")
+	guiRouter.HandleFunc("/admin/webhooks", ui.adminWebhookDeliveries).Methods("GET")
 
 	// Paginated endpoints allow the GUI to request pages of data.
 	guiRouter.HandleFunc("/blocks", ui.paginatedBlocks).Methods("GET")
@@ -231,6 +440,7 @@ func (ui *GUI) loadTemplates() error {
 	httpTemplates := template.New("template").Funcs(template.FuncMap{
 		"upper":          strings.ToUpper,
 		"floatToPercent": floatToPercent,
+		"formatUnixTime": formatUnixTime,
 	})
 
 	// Since template.Must panics with non-nil error, it is much more
@@ -343,6 +553,12 @@ func (ui *GUI) Run(ctx context.Context) {
 		return
 	}
 
+	balances, err := ui.cfg.FetchAccountBalances()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
 	lastPmtHeight, lastPmtPaidOn, lastPmtCreatedOn, err := ui.cfg.FetchLastPaymentInfo()
 	if err != nil {
 		log.Error(err)
@@ -350,7 +566,8 @@ func (ui *GUI) Run(ctx context.Context) {
 	}
 
 	ui.cache = InitCache(work, quotas, hashData, pendingPayments, archivedPayments,
-		ui.cfg.BlockExplorerURL, lastPmtHeight, lastPmtPaidOn, lastPmtCreatedOn)
+		balances, ui.cfg.BlockExplorerURL, lastPmtHeight, lastPmtPaidOn,
+		lastPmtCreatedOn)
 
 	// Use a ticker to periodically update cached data and push updates through
 	// any established websockets
@@ -409,7 +626,14 @@ func (ui *GUI) Run(ctx context.Context) {
 						continue
 					}
 
-					ui.cache.updatePayments(pendingPayments, archivedPayments)
+					balances, err := ui.cfg.FetchAccountBalances()
+					if err != nil {
+						log.Error(err)
+						continue
+					}
+
+					ui.cache.updatePayments(pendingPayments, archivedPayments,
+						balances)
 
 					lastPmtHeight, lastPmtPaidOn, lastPmtCreatedOn, err := ui.cfg.FetchLastPaymentInfo()
 					if err != nil {
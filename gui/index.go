@@ -18,6 +18,7 @@ type indexPageData struct {
 	PoolStatsData poolStatsData
 	MinerPort     string
 	MinedWork     []*minedWork
+	OrphanRate    string
 	RewardQuotas  []*rewardQuota
 	Address       string
 	ModalError    string
@@ -53,7 +54,7 @@ func (ui *GUI) renderIndex(w http.ResponseWriter, r *http.Request, modalError st
 	data := indexPageData{
 		HeaderData: headerData{
 			CSRF:        csrf.TemplateField(r),
-			Designation: ui.cfg.Designation,
+			Designation: ui.designation(),
 			ShowMenu:    true,
 		},
 		PoolStatsData: poolStatsData{
@@ -62,11 +63,12 @@ func (ui *GUI) renderIndex(w http.ResponseWriter, r *http.Request, modalError st
 			PoolHashRate:      ui.cache.getPoolHash(),
 			PaymentMethod:     ui.cfg.PaymentMethod,
 			Network:           ui.cfg.ActiveNet.Name,
-			PoolFee:           ui.cfg.PoolFee,
+			PoolFee:           ui.poolFee(),
 			SoloPool:          ui.cfg.SoloPool,
 		},
 		RewardQuotas: rewardQuotas,
 		MinedWork:    confirmedWork,
+		OrphanRate:   ui.cache.getOrphanRate(),
 		MinerPort:    minerPort,
 		ModalError:   modalError,
 		Address:      address,
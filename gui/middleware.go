@@ -6,6 +6,7 @@ package gui
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 
@@ -67,3 +68,67 @@ func (ui *GUI) rateLimitMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// remoteHost returns the host of the remote address of the provided request.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header
+// for the provided request origin, or an empty string if the origin is not
+// permitted to make cross-origin requests to the public API.
+func (ui *GUI) allowedOrigin(origin string) string {
+	origins := ui.apiAllowedOrigins()
+	if len(origins) == 0 {
+		return "*"
+	}
+	for _, allowed := range origins {
+		if allowed == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// apiMiddleware applies CORS headers and rate limiting to public API
+// requests. Unlike the GUI routes, API requests are rate limited per IP
+// address rather than per session, and no session cookie or CSRF token is
+// required since requests which are not read-only are authenticated by
+// signed proofs of account ownership. CORS preflight requests are answered
+// directly.
+func (ui *GUI) apiMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if allowed := ui.allowedOrigin(origin); allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if allowed != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		host := remoteHost(r)
+
+		// API limiters are keyed separately from pool clients connecting
+		// from the same address.
+		if !ui.cfg.WithinLimit("api-"+host, pool.APIClient) {
+			sendAPIError(w, http.StatusTooManyRequests, "request limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
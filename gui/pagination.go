@@ -12,7 +12,6 @@ import (
 
 	"github.com/decred/dcrpool/pool"
 	"github.com/gorilla/mux"
-	"github.com/gorilla/sessions"
 )
 
 type paginationPayload struct {
@@ -221,9 +220,7 @@ func (ui *GUI) paginatedArchivedPaymentsByAccount(w http.ResponseWriter, r *http
 // well as the total count of all paid payments.
 // Returns an error if the current session is not authenticated as an admin.
 func (ui *GUI) paginatedArchivedPoolPayments(w http.ResponseWriter, r *http.Request) {
-	session := r.Context().Value(sessionKey).(*sessions.Session)
-
-	if session.Values["IsAdmin"] != true {
+	if _, ok := ui.adminUser(r, pool.RoleViewer); !ok {
 		log.Warn("Unauthorized access")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
@@ -255,9 +252,7 @@ func (ui *GUI) paginatedArchivedPoolPayments(w http.ResponseWriter, r *http.Requ
 // pool, as well as the total count of all unpaid payments.
 // Returns an error if the current session is not authenticated as an admin.
 func (ui *GUI) paginatedPendingPoolPayments(w http.ResponseWriter, r *http.Request) {
-	session := r.Context().Value(sessionKey).(*sessions.Session)
-
-	if session.Values["IsAdmin"] != true {
+	if _, ok := ui.adminUser(r, pool.RoleViewer); !ok {
 		log.Warn("Unauthorized access")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
@@ -2,7 +2,7 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package cpuminer
 
 import (
 	"bufio"
@@ -16,9 +16,26 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrpool/pool"
 )
 
+// Config describes the connection parameters of a miner.
+type Config struct {
+	// ActiveNet represents the active network being mined on.
+	ActiveNet *chaincfg.Params
+	// User represents the username of the mining account.
+	User string
+	// Address represents the address of the mining account.
+	Address string
+	// Pool represents the stratum address of the mining pool.
+	Pool string
+	// UserAgent represents the user agent identified as in subscriptions.
+	UserAgent string
+	// Stall represents whether work submissions are generated.
+	Stall bool
+}
+
 // Work represents the data received from a work notification. It comprises of
 // hex encoded block header and pool target data.
 type Work struct {
@@ -37,7 +54,7 @@ type Miner struct {
 	reader          *bufio.Reader
 	work            *Work
 	workMtx         sync.RWMutex
-	config          *config
+	config          *Config
 	req             map[uint64]string
 	reqMtx          sync.RWMutex
 	chainCh         chan struct{}
@@ -112,6 +129,8 @@ func (m *Miner) keepAlive(ctx context.Context) {
 			return
 
 		default:
+			time.Sleep(time.Second * 2)
+
 			m.connectedMtx.RLock()
 			if m.connected {
 				m.connectedMtx.RUnlock()
@@ -119,8 +138,6 @@ func (m *Miner) keepAlive(ctx context.Context) {
 			}
 			m.connectedMtx.RUnlock()
 
-			time.Sleep(time.Second * 2)
-
 			conn, err := net.Dial("tcp", m.config.Pool)
 			if err != nil {
 				log.Errorf("unable to connect to %s: %v", m.config.Pool, err)
@@ -162,6 +179,7 @@ func (m *Miner) read(ctx context.Context) {
 		m.connectedMtx.RLock()
 		if !m.connected {
 			m.connectedMtx.RUnlock()
+			time.Sleep(time.Millisecond * 100)
 			continue
 		}
 		m.connectedMtx.RUnlock()
@@ -198,7 +216,13 @@ func (m *Miner) read(ctx context.Context) {
 			log.Errorf("unable to read bytes: %v", err)
 			continue
 		}
-		m.readCh <- data
+
+		select {
+		case m.readCh <- data:
+		case <-ctx.Done():
+			m.wg.Done()
+			return
+		}
 	}
 }
 
@@ -329,7 +353,7 @@ func (m *Miner) process(ctx context.Context) {
 					log.Tracef("Difficulty is %v", difficulty)
 
 					diff := new(big.Rat).SetUint64(difficulty)
-					target := pool.DifficultyToTarget(m.config.net, diff)
+					target := pool.DifficultyToTarget(m.config.ActiveNet, diff)
 
 					m.workMtx.Lock()
 					m.work.target = target
@@ -393,8 +417,9 @@ func (m *Miner) process(ctx context.Context) {
 	}
 }
 
-// run handles the process life cycles of the miner.
-func (m *Miner) run(ctx context.Context) {
+// Run handles the process life cycles of the miner. It returns once the
+// provided context is cancelled and all miner processes have terminated.
+func (m *Miner) Run(ctx context.Context) {
 	m.wg.Add(3)
 	go m.read(ctx)
 	go m.keepAlive(ctx)
@@ -411,8 +436,9 @@ func (m *Miner) run(ctx context.Context) {
 	log.Infof("Miner terminated.")
 }
 
-// NewMiner creates a stratum mining client.
-func NewMiner(cfg *config, cancel context.CancelFunc) *Miner {
+// NewMiner creates a stratum mining client. The provided cancel func is
+// called when the miner encounters an unrecoverable error.
+func NewMiner(cfg *Config, cancel context.CancelFunc) *Miner {
 	m := &Miner{
 		config:  cfg,
 		work:    new(Work),
@@ -3,7 +3,7 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package cpuminer
 
 import (
 	"context"
@@ -158,7 +158,11 @@ func (m *CPUMiner) solveBlock(ctx context.Context, headerB []byte, target *big.R
 					m.workData.nonce = hex.EncodeToString(headerB[140:144])
 					m.workData.extraNonce2 = hex.EncodeToString(headerB[148:152])
 
-					m.updateHashes <- hashesCompleted
+					select {
+					case m.updateHashes <- hashesCompleted:
+					case <-ctx.Done():
+						return false
+					}
 					log.Infof("Solved block hash at height (%v) is (%v)",
 						header.Height, header.BlockHash().String())
 					return true
@@ -206,7 +210,12 @@ func (m *CPUMiner) solve(ctx context.Context) {
 			id := m.miner.nextID()
 			req := pool.SubmitWorkRequest(&id, worker, jobID,
 				m.workData.extraNonce2, m.workData.nTime, m.workData.nonce)
-			m.workCh <- req
+			select {
+			case m.workCh <- req:
+			case <-ctx.Done():
+				m.miner.wg.Done()
+				return
+			}
 
 			// Stall to prevent mining too quickly.
 			time.Sleep(time.Millisecond * 500)
@@ -0,0 +1,232 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/decred/slog"
+	flags "github.com/jessevdk/go-flags"
+
+	errs "github.com/decred/dcrpool/errors"
+	"github.com/decred/dcrpool/pool"
+)
+
+const (
+	// migrateDBCmd is the subcommand migrating the pool data between
+	// database backends.
+	migrateDBCmd = "migratedb"
+
+	boltBackend     = "bolt"
+	postgresBackend = "postgres"
+	sqliteBackend   = "sqlite"
+)
+
+// dbBackendConfig defines the options locating the databases of the
+// database subcommands.
+type dbBackendConfig struct {
+	DBFile        string `long:"dbfile" description:"Path to the bolt database file."`
+	SQLiteFile    string `long:"sqlitefile" description:"Path to the sqlite database file."`
+	PGHost        string `long:"postgreshost" description:"Host to establish a postgres connection."`
+	PGPort        uint32 `long:"postgresport" description:"Port to establish a postgres connection."`
+	PGUser        string `long:"postgresuser" description:"Username for postgres authentication."`
+	PGPass        string `long:"postgrespass" description:"Password for postgres authentication."`
+	PGDBName      string `long:"postgresdbname" description:"Postgres database name."`
+	PGDSN         string `long:"postgresdsn" description:"Full postgres connection string, either a URL or key/value pairs. Overrides the postgres host, port, user, password and database name."`
+	PGSSLMode     string `long:"postgressslmode" description:"Postgres ssl mode {disable, require, verify-ca, verify-full}."`
+	PGSSLRootCert string `long:"postgressslrootcert" description:"Path to the certificate authorities verifying the postgres server certificate."`
+	PGSSLCert     string `long:"postgressslcert" description:"Path to the postgres client certificate."`
+	PGSSLKey      string `long:"postgressslkey" description:"Path to the postgres client certificate key."`
+}
+
+// pgConfig returns the postgres connection details of the database
+// locations.
+func (cfg *dbBackendConfig) pgConfig() *pool.PostgresConfig {
+	return &pool.PostgresConfig{
+		Host:           cfg.PGHost,
+		Port:           cfg.PGPort,
+		User:           cfg.PGUser,
+		Pass:           cfg.PGPass,
+		DBName:         cfg.PGDBName,
+		DSN:            cfg.PGDSN,
+		SSLMode:        cfg.PGSSLMode,
+		SSLRootCert:    cleanAndExpandPath(cfg.PGSSLRootCert),
+		SSLCert:        cleanAndExpandPath(cfg.PGSSLCert),
+		SSLKey:         cleanAndExpandPath(cfg.PGSSLKey),
+		ConnectTimeout: defaultPGConnectTimeout,
+	}
+}
+
+// defaultDBBackendConfig returns the default database locations.
+func defaultDBBackendConfig() dbBackendConfig {
+	return dbBackendConfig{
+		DBFile:     defaultDBFile,
+		SQLiteFile: defaultSQLiteFile,
+		PGHost:     defaultPGHost,
+		PGPort:     defaultPGPort,
+		PGUser:     defaultPGUser,
+		PGPass:     defaultPGPass,
+		PGDBName:   defaultPGDBName,
+	}
+}
+
+// validateBackend returns an error if the provided database backend is
+// unknown.
+func validateBackend(backend string) error {
+	switch backend {
+	case boltBackend, postgresBackend, sqliteBackend:
+		return nil
+	}
+	return fmt.Errorf("unknown database backend %q", backend)
+}
+
+// backendFile returns the database file of the provided backend, which is
+// empty for backends not stored in a file.
+func backendFile(cfg *dbBackendConfig, backend string) string {
+	switch backend {
+	case boltBackend:
+		return cfg.DBFile
+	case sqliteBackend:
+		return cfg.SQLiteFile
+	}
+	return ""
+}
+
+// migrateDBConfig defines the options of the migratedb subcommand.
+type migrateDBConfig struct {
+	From      string `long:"from" description:"Source database backend {bolt, postgres, sqlite}." required:"true"`
+	To        string `long:"to" description:"Target database backend {bolt, postgres, sqlite}." required:"true"`
+	BatchSize int    `long:"batchsize" description:"Number of entities written to the target database at once."`
+	Force     bool   `long:"force" description:"Wipe the target database before migrating if it is not empty."`
+
+	dbBackendConfig
+}
+
+// openBackendDB opens the database of the provided backend. The database is
+// wiped first when purge is set.
+func openBackendDB(cfg *dbBackendConfig, backend string, purge bool) (pool.Database, error) {
+	switch backend {
+	case boltBackend:
+		if purge {
+			err := os.Remove(cfg.DBFile)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return pool.InitBoltDB(cfg.DBFile)
+
+	case postgresBackend:
+		return pool.InitPostgresDB(cfg.pgConfig(), purge)
+
+	case sqliteBackend:
+		return pool.InitSQLiteDB(cfg.SQLiteFile, purge)
+	}
+
+	return nil, fmt.Errorf("unknown database backend %q", backend)
+}
+
+// openSourceDB opens the database of the provided backend read-only, so a
+// failed migration leaves the source untouched. Returns an error if the
+// source database is not at the latest version, it must be upgraded by
+// running the pool against it first.
+func openSourceDB(cfg *dbBackendConfig, backend string) (pool.Database, error) {
+	switch backend {
+	case boltBackend:
+		return pool.OpenBoltDBReadOnly(cfg.DBFile)
+
+	case postgresBackend:
+		return pool.OpenPostgresDBReadOnly(cfg.pgConfig())
+
+	case sqliteBackend:
+		return pool.OpenSQLiteDBReadOnly(cfg.SQLiteFile)
+	}
+
+	return nil, fmt.Errorf("unknown database backend %q", backend)
+}
+
+// migrateDB copies every entity of the source database backend to the target
+// database backend and reports the per-entity counts and checksums of the
+// migrated data.
+func migrateDB(args []string) error {
+	cfg := migrateDBConfig{
+		BatchSize:       pool.DefaultMigrationBatchSize,
+		dbBackendConfig: defaultDBBackendConfig(),
+	}
+	parser := flags.NewParser(&cfg, flags.HelpFlag)
+	parser.Usage = migrateDBCmd + " [OPTIONS]"
+	_, err := parser.ParseArgs(args)
+	if err != nil {
+		var e *flags.Error
+		if errors.As(err, &e) && e.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stdout)
+			return nil
+		}
+		return err
+	}
+
+	for _, backend := range []string{cfg.From, cfg.To} {
+		err := validateBackend(backend)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.From == cfg.To {
+		return fmt.Errorf("the source and target database backends must " +
+			"differ")
+	}
+	if cfg.BatchSize <= 0 {
+		return fmt.Errorf("the batch size must be positive")
+	}
+	cfg.DBFile = cleanAndExpandPath(cfg.DBFile)
+	cfg.SQLiteFile = cleanAndExpandPath(cfg.SQLiteFile)
+
+	// Avoid creating an empty source database file.
+	srcFile := backendFile(&cfg.dbBackendConfig, cfg.From)
+	if srcFile != "" {
+		_, err := os.Stat(srcFile)
+		if err != nil {
+			return fmt.Errorf("unable to open source database: %w", err)
+		}
+	}
+
+	// The log rotator is not initialized for subcommands.
+	pool.UseLogger(slog.NewBackend(os.Stdout).Logger("POOL"))
+
+	src, err := openSourceDB(&cfg.dbBackendConfig, cfg.From)
+	if err != nil {
+		return fmt.Errorf("unable to open source database: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := openBackendDB(&cfg.dbBackendConfig, cfg.To, cfg.Force)
+	if err != nil {
+		return fmt.Errorf("unable to open target database: %w", err)
+	}
+	defer dst.Close()
+
+	summaries, err := pool.MigrateDB(src, dst, cfg.BatchSize)
+	if err != nil {
+		if !cfg.Force && errors.Is(err, errs.ValueFound) {
+			return fmt.Errorf("%w (use --force to wipe it)", err)
+		}
+		return err
+	}
+
+	kinds := make([]string, 0, len(summaries))
+	for kind := range summaries {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		summary := summaries[kind]
+		fmt.Printf("%-16s %8d  %s\n", kind, summary.Count, summary)
+	}
+	fmt.Printf("Migrated database from %s to %s.\n", cfg.From, cfg.To)
+
+	return nil
+}
@@ -11,6 +11,24 @@ import (
 	"time"
 )
 
+const (
+	// WorkSubmitted is the status of accepted work submitted to the
+	// network and not yet confirmed as mined.
+	WorkSubmitted = "submitted"
+
+	// WorkConfirmed is the status of accepted work confirmed as mined by
+	// a block built on it.
+	WorkConfirmed = "confirmed"
+
+	// WorkMatured is the status of mined work with a spendable coinbase.
+	WorkMatured = "matured"
+
+	// WorkOrphaned is the status of accepted work which is not part of the
+	// main chain, either because it was reorged out or because another
+	// block at its height was chosen.
+	WorkOrphaned = "orphaned"
+)
+
 // AcceptedWork represents an accepted work submission to the network.
 type AcceptedWork struct {
 	UUID      string `json:"uuid"`
@@ -24,6 +42,14 @@ type AcceptedWork struct {
 	// An accepted work becomes mined work once it is confirmed by incoming
 	// work as the parent block it was built on.
 	Confirmed bool `json:"confirmed"`
+
+	// Status is the lifecycle status of the accepted work, one of
+	// submitted, confirmed, matured or orphaned.
+	Status string `json:"status"`
+
+	// OrphanedBy is the hash of the main chain block which replaced the
+	// accepted work once orphaned, if known.
+	OrphanedBy string `json:"orphanedby"`
 }
 
 // heightToBigEndianBytes returns a 4-byte big endian representation of
@@ -53,5 +79,47 @@ func NewAcceptedWork(blockHash string, prevHash string, height uint32,
 		MinedBy:   minedBy,
 		Miner:     miner,
 		CreatedOn: time.Now().UnixNano(),
+		Status:    WorkSubmitted,
+	}
+}
+
+// setStatus updates the status of the accepted work. Confirmed and matured
+// work is confirmed as mined, the replacing block is only kept for orphaned
+// work.
+func (work *AcceptedWork) setStatus(status string) {
+	work.Status = status
+	work.Confirmed = status == WorkConfirmed || status == WorkMatured
+	if status != WorkOrphaned {
+		work.OrphanedBy = ""
+	}
+}
+
+// legacyWorkStatus returns the status of accepted work recorded before work
+// statuses were tracked, derived from its confirmation.
+func legacyWorkStatus(confirmed bool) string {
+	if confirmed {
+		return WorkConfirmed
+	}
+	return WorkSubmitted
+}
+
+// OrphanStats returns the number of orphaned blocks among the provided
+// accepted work, the number of blocks either mined or orphaned, and the
+// resulting orphan rate. Submitted work pending confirmation is not
+// accounted for.
+func OrphanStats(work []*AcceptedWork) (int, int, float64) {
+	var orphaned, resolved int
+	for _, w := range work {
+		switch w.Status {
+		case WorkOrphaned:
+			orphaned++
+			resolved++
+		case WorkConfirmed, WorkMatured:
+			resolved++
+		}
+	}
+	if resolved == 0 {
+		return 0, 0, 0
 	}
+	return orphaned, resolved, float64(orphaned) / float64(resolved)
 }
@@ -0,0 +1,347 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// Admin roles. Roles are ordered by privilege, each role is granted the
+// permissions of the roles before it.
+const (
+	// RoleViewer can view the admin panel.
+	RoleViewer = "viewer"
+
+	// RoleOperator can additionally manage connected clients, backups and
+	// the pool configuration.
+	RoleOperator = "operator"
+
+	// RoleTreasurer can additionally manage payments and admin users.
+	RoleTreasurer = "treasurer"
+)
+
+const (
+	// DefaultAdminUser is the name of the admin user created from the
+	// adminpass config option when no admin users exist.
+	DefaultAdminUser = "admin"
+
+	// maxLoginFailures is the number of failed login attempts from an IP
+	// address, each within the lockout duration of the previous one, after
+	// which further attempts from the address are locked out.
+	maxLoginFailures = 5
+
+	// loginLockout is the duration failed login attempts are locked out for.
+	loginLockout = time.Minute * 15
+
+	// loginSweepInterval is the minimum interval between evictions of
+	// expired login throttle entries.
+	loginSweepInterval = time.Minute
+
+	// totpPeriod is the validity period of a TOTP code.
+	totpPeriod = 30
+
+	// totpDigits is the number of digits of a TOTP code.
+	totpDigits = 6
+
+	// totpSecretSize is the number of random bytes of a TOTP secret.
+	totpSecretSize = 20
+
+	// minAdminPassLength is the minimum length of admin passwords.
+	minAdminPassLength = 8
+)
+
+// roleRanks maps admin roles to their privilege rank.
+var roleRanks = map[string]int{
+	RoleViewer:    1,
+	RoleOperator:  2,
+	RoleTreasurer: 3,
+}
+
+// ValidAdminRole returns whether the provided admin role is known.
+func ValidAdminRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAllows returns whether the provided role is granted the permissions
+// of the required role.
+func RoleAllows(role string, required string) bool {
+	rank, ok := roleRanks[role]
+	if !ok {
+		return false
+	}
+	return rank >= roleRanks[required]
+}
+
+// AdminUser represents a user of the admin panel.
+type AdminUser struct {
+	UUID         string `json:"uuid"`
+	PasswordHash string `json:"passwordhash"`
+	Role         string `json:"role"`
+	TOTPSecret   string `json:"totpsecret"`
+	CreatedOn    int64  `json:"createdon"`
+}
+
+// newAdminUser creates a new admin user with the provided name, password and
+// role. The password is stored as a bcrypt hash.
+func newAdminUser(name string, password string, role string) (*AdminUser, error) {
+	const funcName = "newAdminUser"
+	if name == "" || strings.ContainsAny(name, " \t\n/") {
+		desc := fmt.Sprintf("%s: invalid admin user name %q", funcName, name)
+		return nil, errs.PoolError(errs.Unauthorized, desc)
+	}
+	if !ValidAdminRole(role) {
+		desc := fmt.Sprintf("%s: unknown admin role %s", funcName, role)
+		return nil, errs.PoolError(errs.Unauthorized, desc)
+	}
+	hash, err := hashAdminPass(password)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminUser{
+		UUID:         name,
+		PasswordHash: hash,
+		Role:         role,
+		CreatedOn:    time.Now().UnixNano(),
+	}, nil
+}
+
+// validateAdminPass asserts the provided password is acceptable for admin
+// users.
+func validateAdminPass(password string) error {
+	const funcName = "validateAdminPass"
+	if len(password) < minAdminPassLength {
+		desc := fmt.Sprintf("%s: admin passwords must be at least %d "+
+			"characters", funcName, minAdminPassLength)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	return nil
+}
+
+// hashAdminPass returns the bcrypt hash of the provided password.
+func hashAdminPass(password string) (string, error) {
+	const funcName = "hashAdminPass"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password),
+		bcrypt.DefaultCost)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to hash password: %v", funcName, err)
+		return "", errs.PoolError(errs.CreateHash, desc)
+	}
+	return string(hash), nil
+}
+
+// checkPassword returns whether the provided password matches the admin
+// user's password.
+func (u *AdminUser) checkPassword(password string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash),
+		[]byte(password))
+	return err == nil
+}
+
+// generateTOTPSecret creates a new base32 encoded TOTP secret.
+func generateTOTPSecret() (string, error) {
+	const funcName = "generateTOTPSecret"
+	b := make([]byte, totpSecretSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to generate secret: %v", funcName, err)
+		return "", errs.PoolError(errs.CreateHash, desc)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// totpURL returns the otpauth URL of the provided secret, used to configure
+// authenticator apps.
+func totpURL(issuer, name, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(issuer),
+		url.PathEscape(name), v.Encode())
+}
+
+// totpCode returns the TOTP code (RFC 6238) of the provided secret for the
+// provided time step.
+func totpCode(secret string, step int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).
+		DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(math.Pow10(totpDigits))
+	return fmt.Sprintf("%0*d", totpDigits, code%mod), nil
+}
+
+// matchTOTP returns the time step the provided code is valid for, given the
+// provided secret at the provided time. Codes of the adjacent time steps are
+// accepted to allow for clock drift.
+func matchTOTP(secret string, code string, now time.Time) (int64, bool) {
+	step := now.Unix() / totpPeriod
+	for _, s := range []int64{step - 1, step, step + 1} {
+		expected, err := totpCode(secret, s)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// validTOTP returns whether the provided code is valid for the provided
+// secret at the provided time.
+func validTOTP(secret string, code string, now time.Time) bool {
+	_, ok := matchTOTP(secret, code, now)
+	return ok
+}
+
+var (
+	// dummyPassHash is the hash unknown admin users are checked against,
+	// so their login attempts take as long as those of existing users.
+	dummyPassHash     []byte
+	dummyPassHashOnce sync.Once
+)
+
+// checkDummyPassword compares the provided password against a dummy hash
+// of the admin password cost.
+func checkDummyPassword(password string) {
+	dummyPassHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"),
+			bcrypt.DefaultCost)
+		if err != nil {
+			log.Errorf("unable to create dummy password hash: %v", err)
+			return
+		}
+		dummyPassHash = hash
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPassHash, []byte(password))
+}
+
+// loginAttempts tracks the failed login attempts of an IP address.
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// expired returns whether the provided attempts no longer affect logins as
+// of the provided time.
+func (a *loginAttempts) expired(now time.Time) bool {
+	return !now.Before(a.lockedUntil) &&
+		now.Sub(a.lastFailure) >= loginLockout
+}
+
+// loginThrottle locks out IP addresses after repeated failed admin login
+// attempts and keeps TOTP codes from being used more than once.
+type loginThrottle struct {
+	attempts  map[string]*loginAttempts
+	totpSteps map[string]int64
+	lastSweep time.Time
+	mtx       sync.Mutex
+}
+
+// newLoginThrottle initializes a login throttle.
+func newLoginThrottle() *loginThrottle {
+	return &loginThrottle{
+		attempts:  make(map[string]*loginAttempts),
+		totpSteps: make(map[string]int64),
+	}
+}
+
+// sweep evicts expired entries, at most once every sweep interval.
+//
+// This must be called with the throttle lock held.
+func (l *loginThrottle) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < loginSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, a := range l.attempts {
+		if a.expired(now) {
+			delete(l.attempts, key)
+		}
+	}
+	step := now.Unix() / totpPeriod
+	for user, used := range l.totpSteps {
+		if used < step-1 {
+			delete(l.totpSteps, user)
+		}
+	}
+}
+
+// locked returns whether the provided IP address is locked out.
+func (l *loginThrottle) locked(ip string) bool {
+	now := time.Now()
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.sweep(now)
+	a, ok := l.attempts[ip]
+	return ok && now.Before(a.lockedUntil)
+}
+
+// failure records a failed login attempt from the provided IP address.
+func (l *loginThrottle) failure(ip string) {
+	now := time.Now()
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.sweep(now)
+	a, ok := l.attempts[ip]
+	if !ok || a.expired(now) {
+		a = &loginAttempts{}
+		l.attempts[ip] = a
+	}
+	a.failures++
+	a.lastFailure = now
+	if a.failures >= maxLoginFailures {
+		a.failures = 0
+		a.lockedUntil = now.Add(loginLockout)
+	}
+}
+
+// success clears the failed login attempts of the provided IP address.
+func (l *loginThrottle) success(ip string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	delete(l.attempts, ip)
+}
+
+// useTOTP records the provided TOTP time step as used by the referenced
+// admin user, returning false if the user already used the step or a later
+// one.
+func (l *loginThrottle) useTOTP(user string, step int64) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if used, ok := l.totpSteps[user]; ok && step <= used {
+		return false
+	}
+	l.totpSteps[user] = step
+	return true
+}
@@ -0,0 +1,283 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to six digits.
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, test := range tests {
+		code, err := totpCode(secret, test.unix/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != test.code {
+			t.Fatalf("expected code %s at %d, got %s", test.code,
+				test.unix, code)
+		}
+
+		now := time.Unix(test.unix, 0)
+		if !validTOTP(secret, test.code, now) {
+			t.Fatalf("expected code %s to be valid at %d", test.code, test.unix)
+		}
+
+		// Ensure codes of adjacent steps are accepted, others rejected.
+		if !validTOTP(secret, test.code, now.Add(time.Second*totpPeriod)) {
+			t.Fatalf("expected code %s to be valid in the next step",
+				test.code)
+		}
+		if validTOTP(secret, test.code, now.Add(time.Second*totpPeriod*3)) {
+			t.Fatalf("expected code %s to be invalid three steps later",
+				test.code)
+		}
+	}
+
+	if validTOTP("not base32!", "000000", time.Now()) {
+		t.Fatal("expected an invalid secret to be rejected")
+	}
+
+	secret2, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totpCode(secret2, time.Now().Unix()/totpPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validTOTP(secret2, code, time.Now()) {
+		t.Fatal("expected a code of a generated secret to be valid")
+	}
+}
+
+func TestAdminRoles(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		allowed  bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleOperator, false},
+		{RoleViewer, RoleTreasurer, false},
+		{RoleOperator, RoleViewer, true},
+		{RoleOperator, RoleOperator, true},
+		{RoleOperator, RoleTreasurer, false},
+		{RoleTreasurer, RoleViewer, true},
+		{RoleTreasurer, RoleOperator, true},
+		{RoleTreasurer, RoleTreasurer, true},
+		{"root", RoleViewer, false},
+	}
+
+	for _, test := range tests {
+		if RoleAllows(test.role, test.required) != test.allowed {
+			t.Fatalf("expected role %s allowed %s to be %v", test.role,
+				test.required, test.allowed)
+		}
+	}
+
+	_, err := newAdminUser("bob", "password", "root")
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	_, err = newAdminUser("bob smith", "password", RoleViewer)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	err = validateAdminPass("short")
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	l := newLoginThrottle()
+
+	for i := 0; i < maxLoginFailures-1; i++ {
+		l.failure("127.0.0.1")
+	}
+	if l.locked("127.0.0.1") {
+		t.Fatal("expected ip not to be locked before max failures")
+	}
+
+	l.failure("127.0.0.1")
+	if !l.locked("127.0.0.1") {
+		t.Fatal("expected ip to be locked after max failures")
+	}
+	if l.locked("127.0.0.2") {
+		t.Fatal("expected other ips not to be locked")
+	}
+
+	// Ensure a successful login clears recorded failures.
+	l.failure("127.0.0.2")
+	l.success("127.0.0.2")
+	if _, ok := l.attempts["127.0.0.2"]; ok {
+		t.Fatal("expected failures to be cleared on success")
+	}
+
+	// Ensure failures older than the lockout duration are not counted and
+	// expired entries are evicted.
+	for i := 0; i < maxLoginFailures-1; i++ {
+		l.failure("127.0.0.2")
+	}
+	l.mtx.Lock()
+	l.attempts["127.0.0.1"].lockedUntil = time.Now().Add(-time.Second)
+	l.attempts["127.0.0.1"].lastFailure = time.Now().Add(-loginLockout)
+	l.attempts["127.0.0.2"].lastFailure = time.Now().Add(-loginLockout)
+	l.mtx.Unlock()
+	l.failure("127.0.0.2")
+	if l.locked("127.0.0.2") {
+		t.Fatal("expected expired failures not to be counted")
+	}
+	l.mtx.Lock()
+	l.lastSweep = time.Time{}
+	l.mtx.Unlock()
+	if l.locked("127.0.0.1") {
+		t.Fatal("expected the lockout to expire")
+	}
+	if _, ok := l.attempts["127.0.0.1"]; ok || len(l.attempts) != 1 {
+		t.Fatal("expected expired entries to be evicted")
+	}
+
+	// Ensure TOTP time steps can only be used once per user.
+	if !l.useTOTP("bob", 10) || !l.useTOTP("alice", 10) {
+		t.Fatal("expected unused totp steps to be accepted")
+	}
+	if l.useTOTP("bob", 10) || l.useTOTP("bob", 9) {
+		t.Fatal("expected used totp steps to be rejected")
+	}
+	if !l.useTOTP("bob", 11) {
+		t.Fatal("expected a later totp step to be accepted")
+	}
+}
+
+func testAdminUser(t *testing.T) {
+	user, err := newAdminUser("bob", "password", RoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the password is not stored in plain text.
+	if user.PasswordHash == "password" || !user.checkPassword("password") {
+		t.Fatal("expected the password to be stored as a hash")
+	}
+	if user.checkPassword("wrong") {
+		t.Fatal("expected an incorrect password to be rejected")
+	}
+
+	// Ensure admin users can be persisted.
+	err = db.persistAdminUser(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure persisting a duplicate user returns an error.
+	err = db.persistAdminUser(user)
+	if !errors.Is(err, errs.ValueFound) {
+		t.Fatalf("expected a value found error, got %v", err)
+	}
+
+	alice, err := newAdminUser("alice", "password", RoleTreasurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAdminUser(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure admin users can be updated.
+	user.Role = RoleViewer
+	user.TOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	err = db.updateAdminUser(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err := db.fetchAdminUser("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Role != RoleViewer {
+		t.Fatalf("expected role %s, got %s", RoleViewer, fetched.Role)
+	}
+	if fetched.TOTPSecret != user.TOTPSecret {
+		t.Fatalf("expected totp secret %s, got %s", user.TOTPSecret,
+			fetched.TOTPSecret)
+	}
+	if fetched.PasswordHash != user.PasswordHash {
+		t.Fatal("expected password hashes to match")
+	}
+
+	users, err := db.listAdminUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 admin users, got %d", len(users))
+	}
+
+	// Ensure admin users can be deleted.
+	err = db.deleteAdminUser("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.fetchAdminUser("bob")
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+
+	// Ensure updating a non-existent user returns an error.
+	err = db.updateAdminUser(user)
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+}
+
+func testAuditLog(t *testing.T) {
+	actions := []string{AuditLogin, AuditBackup, AuditLogout}
+	for _, action := range actions {
+		err := db.persistAuditEntry(newAuditEntry("alice", action, "",
+			"127.0.0.1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Ensure entries are returned newest first.
+	entries, err := db.fetchAuditEntries(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].Action != AuditLogout || entries[1].Action != AuditBackup {
+		t.Fatalf("expected newest entries first, got %s and %s",
+			entries[0].Action, entries[1].Action)
+	}
+
+	// Ensure all entries are returned without a limit.
+	entries, err = db.fetchAuditEntries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(actions) {
+		t.Fatalf("expected %d audit entries, got %d", len(actions),
+			len(entries))
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// APITokenKind is the kind of token used to authenticate read-only
+	// requests to the public JSON API on behalf of an account.
+	APITokenKind = "api"
+
+	// WatcherTokenKind is the kind of token used in watcher links, opaque
+	// URLs which resolve to an account's dashboard without exposing its
+	// address.
+	WatcherTokenKind = "watcher"
+
+	// maxAPITokensPerAccount is the maximum number of tokens an account can
+	// hold at any time.
+	maxAPITokensPerAccount = 20
+
+	// maxProofAge is the maximum age of a signed account ownership proof.
+	// Proofs with timestamps further in the past or future are rejected.
+	maxProofAge = time.Minute * 10
+
+	// proofSweepInterval is the minimum interval between evictions of
+	// expired used proofs.
+	proofSweepInterval = time.Minute
+
+	// tokenSize is the number of random bytes of a token.
+	tokenSize = 32
+
+	// signedMessageMagic is prepended to messages before they are hashed
+	// and signed by Decred wallets.
+	signedMessageMagic = "Decred Signed Message:\n"
+)
+
+// APIToken represents a revocable read-only token minted by the holder of an
+// account. The token itself is never stored, only its hash.
+type APIToken struct {
+	UUID      string `json:"uuid"`
+	AccountID string `json:"accountid"`
+	Kind      string `json:"kind"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// hashAPIToken returns the hex encoded hash of the provided token, which
+// is used as the id of persisted tokens.
+func hashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// newAPIToken creates a new token of the provided kind for the referenced
+// account. It returns the token, which must be handed to the account holder
+// since it cannot be recovered, and its persistable representation.
+func newAPIToken(accountID string, kind string) (string, *APIToken, error) {
+	const funcName = "newAPIToken"
+	b := make([]byte, tokenSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to generate token: %v", funcName, err)
+		return "", nil, errs.PoolError(errs.CreateHash, desc)
+	}
+	token := hex.EncodeToString(b)
+	return token, &APIToken{
+		UUID:      hashAPIToken(token),
+		AccountID: accountID,
+		Kind:      kind,
+		CreatedOn: time.Now().UnixNano(),
+	}, nil
+}
+
+// validAPITokenKind returns whether the provided token kind is known.
+func validAPITokenKind(kind string) bool {
+	return kind == APITokenKind || kind == WatcherTokenKind
+}
+
+// AccountProofMessage returns the message an account holder must sign with
+// the account's address to prove ownership when managing tokens. Network is
+// the name of the network the pool mines on, action describes the requested
+// operation and timestamp is the current unix time in seconds. A proof is
+// only accepted once.
+func AccountProofMessage(network, action string, timestamp int64) string {
+	return fmt.Sprintf("dcrpool %s %s %d", network, action, timestamp)
+}
+
+// usedProofs tracks the account ownership proofs already accepted so they
+// cannot be replayed while their timestamp is valid.
+type usedProofs struct {
+	expiries  map[string]int64
+	lastSweep time.Time
+	mtx       sync.Mutex
+}
+
+// newUsedProofs initializes the tracking of used proofs.
+func newUsedProofs() *usedProofs {
+	return &usedProofs{
+		expiries: make(map[string]int64),
+	}
+}
+
+// use records the proof of the provided message by the provided address as
+// used, returning false if it already was.
+func (u *usedProofs) use(address, message string, timestamp int64) bool {
+	now := time.Now()
+	key := address + " " + message
+
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	if now.Sub(u.lastSweep) >= proofSweepInterval {
+		u.lastSweep = now
+		for k, expiry := range u.expiries {
+			if expiry < now.Unix() {
+				delete(u.expiries, k)
+			}
+		}
+	}
+
+	if _, ok := u.expiries[key]; ok {
+		return false
+	}
+	u.expiries[key] = timestamp + int64(maxProofAge/time.Second)
+	return true
+}
+
+// verifyMessage asserts the provided base64 encoded signature is a valid
+// signature of the message by the provided address.
+func verifyMessage(address, signature, message string, params *chaincfg.Params) error {
+	const funcName = "verifyMessage"
+
+	addr, err := dcrutil.DecodeAddress(address, params)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode address %s: %v",
+			funcName, address, err)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	// Only secp256k1 pubkey hash addresses can sign messages.
+	if _, ok := addr.(*dcrutil.AddressPubKeyHash); !ok {
+		desc := fmt.Sprintf("%s: address %s cannot sign messages",
+			funcName, address)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode signature: %v",
+			funcName, err)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, signedMessageMagic)
+	_ = wire.WriteVarString(&buf, 0, message)
+	hash := chainhash.HashB(buf.Bytes())
+
+	pubKey, wasCompressed, err := ecdsa.RecoverCompact(sig, hash)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to recover public key: %v",
+			funcName, err)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	var serializedPubKey []byte
+	if wasCompressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	} else {
+		serializedPubKey = pubKey.SerializeUncompressed()
+	}
+	pkAddr, err := dcrutil.NewAddressSecpPubKey(serializedPubKey, params)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to create address: %v", funcName, err)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	if pkAddr.AddressPubKeyHash().Address() != address {
+		desc := fmt.Sprintf("%s: signature does not match address %s",
+			funcName, address)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	return nil
+}
+
+// verifyAccountProof asserts the provided signature proves ownership of the
+// address for the provided action at the provided time on the provided
+// network, and that the proof was not used before.
+func verifyAccountProof(address, action, signature string, timestamp int64, params *chaincfg.Params, used *usedProofs) error {
+	const funcName = "verifyAccountProof"
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > maxProofAge || age < -maxProofAge {
+		desc := fmt.Sprintf("%s: proof timestamp %d is not within %v of "+
+			"the current time", funcName, timestamp, maxProofAge)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	message := AccountProofMessage(params.Name, action, timestamp)
+	err := verifyMessage(address, signature, message, params)
+	if err != nil {
+		return err
+	}
+	if !used.use(address, message, timestamp) {
+		desc := fmt.Sprintf("%s: proof for %q by address %s was already "+
+			"used", funcName, action, address)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// signMessage signs the provided message with the provided key the same way
+// Decred wallets do, returning the base64 encoded signature.
+func signMessage(key *secp256k1.PrivateKey, message string) string {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, signedMessageMagic)
+	_ = wire.WriteVarString(&buf, 0, message)
+	sig := ecdsa.SignCompact(key, chainhash.HashB(buf.Bytes()), true)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestVerifyAccountProof(t *testing.T) {
+	params := chaincfg.SimNetParams()
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkAddr, err := dcrutil.NewAddressSecpPubKey(
+		key.PubKey().SerializeCompressed(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := pkAddr.AddressPubKeyHash().Address()
+
+	used := newUsedProofs()
+	now := time.Now().Unix()
+	sig := signMessage(key, AccountProofMessage(params.Name, "list", now))
+
+	// Ensure a valid proof is accepted once.
+	err = verifyAccountProof(address, "list", sig, now, params, used)
+	if err != nil {
+		t.Fatalf("unexpected proof error: %v", err)
+	}
+	err = verifyAccountProof(address, "list", sig, now, params, used)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// Ensure a proof for a different network is rejected.
+	sig = signMessage(key, AccountProofMessage("mainnet", "list", now+1))
+	err = verifyAccountProof(address, "list", sig, now+1, params, used)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// Ensure a proof for a different action is rejected.
+	err = verifyAccountProof(address, "mint api", sig, now+1, params, used)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// Ensure a proof for a different address is rejected.
+	err = verifyAccountProof(xAddr, "list", sig, now+1, params, used)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// Ensure an expired proof is rejected.
+	then := time.Now().Add(-maxProofAge * 2).Unix()
+	sig = signMessage(key, AccountProofMessage(params.Name, "list", then))
+	err = verifyAccountProof(address, "list", sig, then, params, used)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// Ensure a malformed signature is rejected.
+	err = verifyAccountProof(address, "list", "invalid", now, params, used)
+	if !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// Ensure used proofs are evicted once their timestamp expired.
+	used.mtx.Lock()
+	for key := range used.expiries {
+		used.expiries[key] = now - 1
+	}
+	used.lastSweep = time.Time{}
+	used.mtx.Unlock()
+	if !used.use(address, "evict", now) || len(used.expiries) != 1 {
+		t.Fatalf("expected expired proofs to be evicted")
+	}
+}
+
+func testAPIToken(t *testing.T) {
+	token, apiToken, err := newAPIToken(xID, APITokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the raw token is not used as the token id.
+	if apiToken.UUID == token || apiToken.UUID != hashAPIToken(token) {
+		t.Fatalf("expected the token id to be the token hash")
+	}
+
+	// Ensure api tokens can be persisted.
+	err = db.persistAPIToken(apiToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure persisting a duplicate token returns an error.
+	err = db.persistAPIToken(apiToken)
+	if !errors.Is(err, errs.ValueFound) {
+		t.Fatalf("expected a value found error, got %v", err)
+	}
+
+	_, watcherToken, err := newAPIToken(xID, WatcherTokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAPIToken(watcherToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, yToken, err := newAPIToken(yID, APITokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAPIToken(yToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure api tokens can be fetched.
+	fetched, err := db.fetchAPIToken(hashAPIToken(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.AccountID != xID {
+		t.Fatalf("expected account id %s, got %s", xID, fetched.AccountID)
+	}
+	if fetched.Kind != APITokenKind {
+		t.Fatalf("expected token kind %s, got %s", APITokenKind, fetched.Kind)
+	}
+	if fetched.CreatedOn != apiToken.CreatedOn {
+		t.Fatalf("expected created on %d, got %d", apiToken.CreatedOn,
+			fetched.CreatedOn)
+	}
+
+	// Ensure only the tokens of the requested account are listed.
+	tokens, err := db.fetchAPITokensForAccount(xID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 tokens for account x, got %d", len(tokens))
+	}
+
+	// Ensure api tokens can be deleted.
+	err = db.deleteAPIToken(apiToken.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.fetchAPIToken(apiToken.UUID)
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+
+	tokens, err = db.fetchAPITokensForAccount(xID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 {
+		t.Fatalf("expected 1 token for account x, got %d", len(tokens))
+	}
+}
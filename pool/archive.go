@@ -0,0 +1,466 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// BackupFile is the database backup file name.
+	BackupFile = "backup.jsonl"
+
+	// archiveFormat identifies a dcrpool database archive.
+	archiveFormat = "dcrpool-archive"
+
+	// archiveVersion is the current version of the archive format.
+	archiveVersion = 1
+
+	// archiveBatchSize is the maximum number of records imported at once
+	// when restoring an archive.
+	archiveBatchSize = 1000
+)
+
+// Archive record kinds.
+const (
+	metadataRecord        = "metadata"
+	accountRecord         = "account"
+	paymentRecord         = "payment"
+	archivedPaymentRecord = "archivedpayment"
+	shareRecord           = "share"
+	acceptedWorkRecord    = "acceptedwork"
+	jobRecord             = "job"
+	hashDataRecord        = "hashdata"
+	apiTokenRecord        = "apitoken"
+	adminUserRecord       = "adminuser"
+	auditEntryRecord      = "auditentry"
+	banRecord             = "ban"
+	ledgerEntryRecord     = "ledgerentry"
+	blockEventRecord      = "blockevent"
+	webhookRecord         = "webhook"
+	webhookDeliveryRecord = "webhookdelivery"
+	workerRecord          = "worker"
+	workerEventRecord     = "workerevent"
+)
+
+// archiveHeader is the first line of a database archive.
+type archiveHeader struct {
+	Format    string `json:"format"`
+	Version   uint32 `json:"version"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// archiveRecord is a single entity of a database archive. An archive is
+// backend independent, the data of a record is the json encoding of the
+// entity.
+type archiveRecord struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// archiveMetadata represents the pool metadata of a database archive. Values
+// not set in the archived database are omitted.
+type archiveMetadata struct {
+	PoolMode             *uint32        `json:"poolmode,omitempty"`
+	CSRFSecret           []byte         `json:"csrfsecret,omitempty"`
+	LastPaymentHeight    *uint32        `json:"lastpaymentheight,omitempty"`
+	LastPaymentPaidOn    *int64         `json:"lastpaymentpaidon,omitempty"`
+	LastPaymentCreatedOn *int64         `json:"lastpaymentcreatedon,omitempty"`
+	ChainTipHash         string         `json:"chaintiphash,omitempty"`
+	ChainTipHeight       *uint32        `json:"chaintipheight,omitempty"`
+	PendingPayout        *PendingPayout `json:"pendingpayout,omitempty"`
+}
+
+// exportFunc is called for each entity streamed out of a database by
+// exportRecords. The entity is either a pointer to the entity type or its
+// json encoding as a json.RawMessage.
+type exportFunc func(kind string, entity interface{}) error
+
+// exportMetadataFunc is called by exportRecords with the pool metadata read
+// from the same snapshot as the exported entities, before any entity is
+// exported.
+type exportMetadataFunc func(meta *archiveMetadata) error
+
+// metadataReader describes the reads of the pool metadata of a database or
+// of a transaction of a database.
+type metadataReader interface {
+	fetchPoolMode() (uint32, error)
+	fetchCSRFSecret() ([]byte, error)
+	loadLastPaymentInfo() (uint32, int64, error)
+	loadLastPaymentCreatedOn() (int64, error)
+	loadChainTip() (string, uint32, error)
+	fetchPendingPayout() (*PendingPayout, error)
+}
+
+// decodeArchiveRecord deserializes the data of the provided archive record
+// into its entity type, returning the entity and its id.
+func decodeArchiveRecord(record *archiveRecord) (interface{}, string, error) {
+	const funcName = "decodeArchiveRecord"
+	var entity interface{}
+	switch record.Kind {
+	case accountRecord:
+		entity = new(Account)
+	case paymentRecord, archivedPaymentRecord:
+		entity = new(Payment)
+	case shareRecord:
+		entity = new(Share)
+	case acceptedWorkRecord:
+		entity = new(AcceptedWork)
+	case jobRecord:
+		entity = new(Job)
+	case hashDataRecord:
+		entity = new(HashData)
+	case apiTokenRecord:
+		entity = new(APIToken)
+	case adminUserRecord:
+		entity = new(AdminUser)
+	case auditEntryRecord:
+		entity = new(AuditEntry)
+	case banRecord:
+		entity = new(Ban)
+	case ledgerEntryRecord:
+		entity = new(LedgerEntry)
+	case blockEventRecord:
+		entity = new(BlockEvent)
+	case webhookRecord:
+		entity = new(Webhook)
+	case webhookDeliveryRecord:
+		entity = new(WebhookDelivery)
+	case workerRecord:
+		entity = new(Worker)
+	case workerEventRecord:
+		entity = new(WorkerEvent)
+	default:
+		desc := fmt.Sprintf("%s: unknown archive record kind %q", funcName,
+			record.Kind)
+		return nil, "", errs.DBError(errs.Decode, desc)
+	}
+
+	err := json.Unmarshal(record.Data, entity)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode %s record: %v", funcName,
+			record.Kind, err)
+		return nil, "", errs.DBError(errs.Decode, desc)
+	}
+
+	// Accepted work archived before work statuses were tracked derives its
+	// status from its confirmation.
+	if work, ok := entity.(*AcceptedWork); ok && work.Status == "" {
+		work.setStatus(legacyWorkStatus(work.Confirmed))
+	}
+
+	// All entities are identified by their uuid.
+	var id struct {
+		UUID string `json:"uuid"`
+	}
+	err = json.Unmarshal(record.Data, &id)
+	if err != nil || id.UUID == "" {
+		desc := fmt.Sprintf("%s: %s record has no uuid", funcName,
+			record.Kind)
+		return nil, "", errs.DBError(errs.Decode, desc)
+	}
+
+	return entity, id.UUID, nil
+}
+
+// fetchArchiveMetadata returns the pool metadata of the provided database.
+func fetchArchiveMetadata(db metadataReader) (*archiveMetadata, error) {
+	var meta archiveMetadata
+
+	mode, err := db.fetchPoolMode()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		meta.PoolMode = &mode
+	}
+
+	secret, err := db.fetchCSRFSecret()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		return nil, err
+	}
+	meta.CSRFSecret = secret
+
+	height, paidOn, err := db.loadLastPaymentInfo()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		meta.LastPaymentHeight = &height
+		meta.LastPaymentPaidOn = &paidOn
+	}
+
+	createdOn, err := db.loadLastPaymentCreatedOn()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		meta.LastPaymentCreatedOn = &createdOn
+	}
+
+	tipHash, tipHeight, err := db.loadChainTip()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		meta.ChainTipHash = tipHash
+		meta.ChainTipHeight = &tipHeight
+	}
+
+	payout, err := db.fetchPendingPayout()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		return nil, err
+	}
+	meta.PendingPayout = payout
+
+	return &meta, nil
+}
+
+// restoreArchiveMetadata persists the provided pool metadata to the provided
+// database.
+func restoreArchiveMetadata(db Database, meta *archiveMetadata) error {
+	if meta.PoolMode != nil {
+		err := db.persistPoolMode(*meta.PoolMode)
+		if err != nil {
+			return err
+		}
+	}
+	if len(meta.CSRFSecret) > 0 {
+		err := db.persistCSRFSecret(meta.CSRFSecret)
+		if err != nil {
+			return err
+		}
+	}
+	if meta.LastPaymentHeight != nil && meta.LastPaymentPaidOn != nil {
+		err := db.persistLastPaymentInfo(*meta.LastPaymentHeight,
+			*meta.LastPaymentPaidOn)
+		if err != nil {
+			return err
+		}
+	}
+	if meta.LastPaymentCreatedOn != nil {
+		err := db.persistLastPaymentCreatedOn(*meta.LastPaymentCreatedOn)
+		if err != nil {
+			return err
+		}
+	}
+	if meta.ChainTipHash != "" && meta.ChainTipHeight != nil {
+		err := db.persistChainTip(meta.ChainTipHash, *meta.ChainTipHeight)
+		if err != nil {
+			return err
+		}
+	}
+	if meta.PendingPayout != nil {
+		err := db.persistPendingPayout(meta.PendingPayout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeArchive streams an archive of the provided database to the provided
+// writer. The archive is a json-lines document, starting with a header
+// followed by the pool metadata and every persisted entity.
+func writeArchive(db Database, w io.Writer) error {
+	const funcName = "writeArchive"
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	err := enc.Encode(&archiveHeader{
+		Format:    archiveFormat,
+		Version:   archiveVersion,
+		CreatedOn: time.Now().UnixNano(),
+	})
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to write archive header: %v",
+			funcName, err)
+		return errs.PoolError(errs.Backup, desc)
+	}
+
+	writeRecord := func(kind string, entity interface{}) error {
+		data, ok := entity.(json.RawMessage)
+		if !ok {
+			var err error
+			data, err = json.Marshal(entity)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to encode %s record: %v",
+					funcName, kind, err)
+				return errs.PoolError(errs.Backup, desc)
+			}
+		}
+		err := enc.Encode(&archiveRecord{Kind: kind, Data: data})
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to write %s record: %v",
+				funcName, kind, err)
+			return errs.PoolError(errs.Backup, desc)
+		}
+		return nil
+	}
+
+	// The metadata and entities are read from a single snapshot so the
+	// archive is consistent, for example a payment archived while the
+	// archive is written is never exported as both pending and archived.
+	writeMeta := func(meta *archiveMetadata) error {
+		return writeRecord(metadataRecord, meta)
+	}
+	err = db.exportRecords(writeMeta, writeRecord)
+	if err != nil {
+		return err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to write archive: %v", funcName, err)
+		return errs.PoolError(errs.Backup, desc)
+	}
+
+	return nil
+}
+
+// writeArchiveFile writes an archive of the provided database to the
+// provided file path. The archive is written to a temporary file first so
+// an existing archive is only replaced by a complete one.
+func writeArchiveFile(db Database, path string) error {
+	const funcName = "writeArchiveFile"
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to create archive file: %v",
+			funcName, err)
+		return errs.PoolError(errs.Backup, desc)
+	}
+
+	err = writeArchive(db, f)
+	if err == nil {
+		err = f.Sync()
+	}
+	cErr := f.Close()
+	if err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		if errors.Is(err, errs.Backup) {
+			return err
+		}
+		desc := fmt.Sprintf("%s: unable to write archive file: %v",
+			funcName, err)
+		return errs.PoolError(errs.Backup, desc)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to replace archive file: %v",
+			funcName, err)
+		return errs.PoolError(errs.Backup, desc)
+	}
+
+	return nil
+}
+
+// serveArchive streams an archive of the provided database over the
+// provided HTTP response writer as a file download.
+func serveArchive(db Database, w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", BackupFile))
+	return writeArchive(db, w)
+}
+
+// RestoreArchive restores the archive read from the provided reader into the
+// provided database, regardless of the backend the archive was created
+// from. The database must not have been used by a pool yet.
+func RestoreArchive(db Database, r io.Reader) error {
+	const funcName = "RestoreArchive"
+
+	_, err := db.fetchCSRFSecret()
+	if err == nil {
+		desc := fmt.Sprintf("%s: unable to restore archive into a database "+
+			"already in use", funcName)
+		return errs.DBError(errs.ValueFound, desc)
+	}
+	if !errors.Is(err, errs.ValueNotFound) {
+		return err
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var header archiveHeader
+	err = dec.Decode(&header)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to read archive header: %v",
+			funcName, err)
+		return errs.DBError(errs.Decode, desc)
+	}
+	if header.Format != archiveFormat {
+		desc := fmt.Sprintf("%s: unknown archive format %q", funcName,
+			header.Format)
+		return errs.DBError(errs.Decode, desc)
+	}
+	if header.Version != archiveVersion {
+		desc := fmt.Sprintf("%s: unsupported archive version %d, "+
+			"expected %d", funcName, header.Version, archiveVersion)
+		return errs.DBError(errs.Decode, desc)
+	}
+
+	var meta *archiveMetadata
+	batch := make([]*archiveRecord, 0, archiveBatchSize)
+	for {
+		record := new(archiveRecord)
+		err := dec.Decode(record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to read archive record: %v",
+				funcName, err)
+			return errs.DBError(errs.Decode, desc)
+		}
+
+		if record.Kind == metadataRecord {
+			meta = new(archiveMetadata)
+			err := json.Unmarshal(record.Data, meta)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to decode metadata "+
+					"record: %v", funcName, err)
+				return errs.DBError(errs.Decode, desc)
+			}
+			continue
+		}
+
+		batch = append(batch, record)
+		if len(batch) == archiveBatchSize {
+			err := db.importRecords(batch)
+			if err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		err := db.importRecords(batch)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The metadata is restored last since the database is considered in use
+	// once its CSRF secret is set.
+	if meta != nil {
+		return restoreArchiveMetadata(db, meta)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// assertSameEntity ensures the provided entities have the same json encoding.
+func assertSameEntity(t *testing.T, name string, expected, actual interface{}) {
+	t.Helper()
+	expectedB, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actualB, err := json.Marshal(actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expectedB, actualB) {
+		t.Fatalf("restored %s mismatch: expected %s, got %s", name,
+			expectedB, actualB)
+	}
+}
+
+func testArchive(t *testing.T) {
+	// Populate the database with every kind of entity.
+	err := db.persistPoolMode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistCSRFSecret([]byte("csrfsecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistLastPaymentInfo(20, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistLastPaymentCreatedOn(90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := NewAccount(xAddr)
+	err = db.persistAccount(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &PaymentSource{BlockHash: "blockhash", Coinbase: "coinbase"}
+	pmt := NewPayment(xID, source, dcrutil.Amount(100), 10, 26)
+	err = db.PersistPayment(pmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paidPmt := NewPayment(yID, source, dcrutil.Amount(50), 10, 26)
+	err = db.PersistPayment(paidPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.ArchivePayment(paidPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := NewShare(xID, new(big.Rat).SetInt64(3))
+	err = db.PersistShare(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	work := NewAcceptedWork("blockhash", "prevhash", 10, xID, "cpu")
+	work.Confirmed = true
+	err = db.persistAcceptedWork(work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob("header", 10)
+	err = db.persistJob(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashData := newHashData(CPU, xID, "127.0.0.1", "8e4c0b6a",
+		new(big.Rat).SetInt64(100))
+	err = db.persistHashData(hashData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := newAPIToken(xID, APITokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAPIToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := newAdminUser("admin", "password", RoleTreasurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAdminUser(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := newAuditEntry("admin", AuditLogin, "", "127.0.0.1")
+	err = db.persistAuditEntry(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistBan(newBan("127.0.0.1", IPBanKind, "spam"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the database can be archived.
+	var archive bytes.Buffer
+	err = writeArchive(db, &archive)
+	if err != nil {
+		t.Fatalf("writeArchive error: %v", err)
+	}
+	archiveB := archive.Bytes()
+
+	// Ensure the archive can be restored into a new bolt database.
+	const restorePath = "trestore"
+	restoreDB, err := InitBoltDB(restorePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = teardownBoltDB(restoreDB, restorePath)
+		if err != nil {
+			t.Fatalf("teardown error: %v", err)
+		}
+	}()
+
+	// Ensure archives of an unknown format are rejected.
+	err = RestoreArchive(restoreDB, strings.NewReader(`{"format":"unknown"}`))
+	if !errors.Is(err, errs.Decode) {
+		t.Fatalf("expected a decode error, got %v", err)
+	}
+
+	err = RestoreArchive(restoreDB, bytes.NewReader(archiveB))
+	if err != nil {
+		t.Fatalf("RestoreArchive error: %v", err)
+	}
+
+	// Ensure every entity was restored unaltered.
+	mode, err := restoreDB.fetchPoolMode()
+	if err != nil || mode != 1 {
+		t.Fatalf("expected pool mode 1, got %d (%v)", mode, err)
+	}
+	secret, err := restoreDB.fetchCSRFSecret()
+	if err != nil || string(secret) != "csrfsecret" {
+		t.Fatalf("expected restored csrf secret, got %q (%v)", secret, err)
+	}
+	height, paidOn, err := restoreDB.loadLastPaymentInfo()
+	if err != nil || height != 20 || paidOn != 100 {
+		t.Fatalf("expected last payment info (20, 100), got (%d, %d) (%v)",
+			height, paidOn, err)
+	}
+	createdOn, err := restoreDB.loadLastPaymentCreatedOn()
+	if err != nil || createdOn != 90 {
+		t.Fatalf("expected last payment created on 90, got %d (%v)",
+			createdOn, err)
+	}
+
+	fetches := map[string]func(Database) (interface{}, error){
+		"account": func(d Database) (interface{}, error) {
+			return d.fetchAccount(account.UUID)
+		},
+		"payment": func(d Database) (interface{}, error) {
+			return d.fetchPayment(pmt.UUID)
+		},
+		"archived payments": func(d Database) (interface{}, error) {
+			return d.archivedPayments()
+		},
+		"share": func(d Database) (interface{}, error) {
+			return d.fetchShare(share.UUID)
+		},
+		"accepted work": func(d Database) (interface{}, error) {
+			return d.fetchAcceptedWork(work.UUID)
+		},
+		"job": func(d Database) (interface{}, error) {
+			return d.fetchJob(job.UUID)
+		},
+		"hash data": func(d Database) (interface{}, error) {
+			return d.fetchHashData(hashData.UUID)
+		},
+		"api token": func(d Database) (interface{}, error) {
+			return d.fetchAPIToken(token.UUID)
+		},
+		"admin user": func(d Database) (interface{}, error) {
+			return d.fetchAdminUser(user.UUID)
+		},
+		"audit entries": func(d Database) (interface{}, error) {
+			return d.fetchAuditEntries(0)
+		},
+		"bans": func(d Database) (interface{}, error) {
+			return d.listBans()
+		},
+	}
+	for name, fetch := range fetches {
+		expected, err := fetch(db)
+		if err != nil {
+			t.Fatalf("unable to fetch %s: %v", name, err)
+		}
+		actual, err := fetch(restoreDB)
+		if err != nil {
+			t.Fatalf("unable to fetch restored %s: %v", name, err)
+		}
+		assertSameEntity(t, name, expected, actual)
+	}
+
+	// Ensure an archive cannot be restored into a database in use.
+	err = RestoreArchive(restoreDB, bytes.NewReader(archiveB))
+	if !errors.Is(err, errs.ValueFound) {
+		t.Fatalf("expected a value found error, got %v", err)
+	}
+}
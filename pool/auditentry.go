@@ -0,0 +1,67 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"time"
+)
+
+// Audited admin actions.
+const (
+	AuditLogin         = "login"
+	AuditLoginFailed   = "loginfailed"
+	AuditLogout        = "logout"
+	AuditBackup        = "backup"
+	AuditCreateUser    = "createuser"
+	AuditDeleteUser    = "deleteuser"
+	AuditChangePass    = "changepassword"
+	AuditEnableTOTP    = "enabletotp"
+	AuditDisableTOTP   = "disabletotp"
+	AuditBootstrapUser = "bootstrapuser"
+	AuditDisconnect    = "disconnect"
+	AuditForceDiff     = "forcedifficulty"
+	AuditBan           = "ban"
+	AuditUnban         = "unban"
+	AuditReload        = "reload"
+	AuditAdjust        = "adjustbalance"
+	AuditExportPayout  = "exportpayout"
+	AuditPublishPayout = "publishpayout"
+	AuditDiscardPayout = "discardpayout"
+)
+
+// AuditEntry represents an action performed through the admin panel.
+type AuditEntry struct {
+	UUID      string `json:"uuid"`
+	Actor     string `json:"actor"`
+	Action    string `json:"action"`
+	Details   string `json:"details"`
+	IP        string `json:"ip"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// auditEntryID generates a unique audit entry id using the provided actor
+// and time created. Ids sort by creation time.
+func auditEntryID(actor string, createdOn int64) string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString(hex.EncodeToString(nanoToBigEndianBytes(createdOn)))
+	_, _ = buf.WriteString(actor)
+	return buf.String()
+}
+
+// newAuditEntry creates an audit entry of the provided action by the
+// provided actor.
+func newAuditEntry(actor, action, details, ip string) *AuditEntry {
+	now := time.Now().UnixNano()
+	return &AuditEntry{
+		UUID:      auditEntryID(actor, now),
+		Actor:     actor,
+		Action:    action,
+		Details:   details,
+		IP:        ip,
+		CreatedOn: now,
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"time"
+)
+
+const (
+	// IPBanKind is the kind of ban applied to an IP address.
+	IPBanKind = "ip"
+
+	// AccountBanKind is the kind of ban applied to a pool account.
+	AccountBanKind = "account"
+)
+
+// Ban represents an IP address or account banned from connecting to the
+// pool.
+type Ban struct {
+	UUID      string `json:"uuid"`
+	Kind      string `json:"kind"`
+	Reason    string `json:"reason"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// newBan creates a ban of the provided kind for the provided IP address or
+// account id.
+func newBan(id string, kind string, reason string) *Ban {
+	return &Ban{
+		UUID:      id,
+		Kind:      kind,
+		Reason:    reason,
+		CreatedOn: time.Now().UnixNano(),
+	}
+}
@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"testing"
+)
+
+func testBan(t *testing.T) {
+	ipBan := newBan("127.0.0.1", IPBanKind, "spam")
+	accountBan := newBan(xID, AccountBanKind, "")
+
+	// Ensure bans can be persisted.
+	err := db.persistBan(ipBan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistBan(accountBan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure persisting an existing ban replaces it.
+	ipBan.Reason = "abuse"
+	err = db.persistBan(ipBan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bans, err := db.listBans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans) != 2 {
+		t.Fatalf("expected 2 bans, got %d", len(bans))
+	}
+	for _, ban := range bans {
+		if ban.UUID == ipBan.UUID && ban.Reason != "abuse" {
+			t.Fatalf("expected ban reason abuse, got %s", ban.Reason)
+		}
+		if ban.UUID == accountBan.UUID && ban.Kind != AccountBanKind {
+			t.Fatalf("expected ban kind %s, got %s", AccountBanKind, ban.Kind)
+		}
+	}
+
+	// Ensure bans can be deleted.
+	err = db.deleteBan(ipBan.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bans, err = db.listBans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans) != 1 {
+		t.Fatalf("expected 1 ban, got %d", len(bans))
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// BlockConnectedEvent is the kind of block event of a connected block.
+	BlockConnectedEvent = "connected"
+
+	// BlockDisconnectedEvent is the kind of block event of a disconnected
+	// block.
+	BlockDisconnectedEvent = "disconnected"
+)
+
+// fatalChainErrors are the error kinds indicating corrupted or inconsistent
+// pool data. Block events failing to process with any of them are not
+// retried and terminate the pool instead.
+var fatalChainErrors = []errs.ErrorKind{
+	errs.StorageNotFound,
+	errs.DBUpgrade,
+	errs.Parse,
+	errs.Decode,
+	errs.HexLength,
+	errs.DivideByZero,
+	errs.ShareRatio,
+	errs.CreateHash,
+	errs.PaymentSource,
+}
+
+// BlockEvent represents a connected or disconnected block notification
+// pending processing. Block events are persisted until processed so
+// notifications failing to process are retried, including across restarts.
+type BlockEvent struct {
+	UUID        string `json:"uuid"`
+	Kind        string `json:"kind"`
+	Header      string `json:"header"`
+	Height      uint32 `json:"height"`
+	Attempts    uint32 `json:"attempts"`
+	LastError   string `json:"lasterror"`
+	NextAttempt int64  `json:"nextattempt"`
+	CreatedOn   int64  `json:"createdon"`
+}
+
+// blockEventID generates a unique id for a block event.
+func blockEventID(kind string, blockHash string, createdOn int64) string {
+	return fmt.Sprintf("%d-%s-%s", createdOn, kind, blockHash)
+}
+
+// newBlockEvent creates a block event of the provided kind for the provided
+// block header.
+func newBlockEvent(kind string, header *wire.BlockHeader) (*BlockEvent, error) {
+	const funcName = "newBlockEvent"
+	headerB, err := header.Bytes()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to serialize block header: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+
+	createdOn := time.Now().UnixNano()
+	return &BlockEvent{
+		UUID:      blockEventID(kind, header.BlockHash().String(), createdOn),
+		Kind:      kind,
+		Header:    hex.EncodeToString(headerB),
+		Height:    header.Height,
+		CreatedOn: createdOn,
+	}, nil
+}
+
+// blockHeader decodes the block header of the block event.
+func (event *BlockEvent) blockHeader() (*wire.BlockHeader, error) {
+	const funcName = "blockHeader"
+	headerB, err := hex.DecodeString(event.Header)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode block event %s header: %v",
+			funcName, event.UUID, err)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+
+	var header wire.BlockHeader
+	err = header.FromBytes(headerB)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to deserialize block event %s "+
+			"header: %v", funcName, event.UUID, err)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+	return &header, nil
+}
+
+// chainTip returns the hash and height of the chain tip once the block
+// event is processed. A connected block becomes the chain tip, the parent of
+// a disconnected block becomes the chain tip.
+func (event *BlockEvent) chainTip() (string, uint32, error) {
+	header, err := event.blockHeader()
+	if err != nil {
+		return "", 0, err
+	}
+	if event.Kind == BlockDisconnectedEvent {
+		return header.PrevBlock.String(), header.Height - 1, nil
+	}
+	return header.BlockHash().String(), header.Height, nil
+}
+
+// sortBlockEvents orders the provided block events by creation time, oldest
+// first.
+func sortBlockEvents(events []*BlockEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].CreatedOn == events[j].CreatedOn {
+			return events[i].UUID < events[j].UUID
+		}
+		return events[i].CreatedOn < events[j].CreatedOn
+	})
+}
+
+// isFatalChainError returns whether the provided error processing a block
+// event indicates corrupted or inconsistent pool data. All other errors,
+// like an unreachable mining node or wallet, are considered transient.
+func isFatalChainError(err error) bool {
+	for _, kind := range fatalChainErrors {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// chainErrorKind returns the error kind of the provided error processing a
+// block event, or "Unknown" if it has none.
+func chainErrorKind(err error) string {
+	var kind errs.ErrorKind
+	if errors.As(err, &kind) {
+		return string(kind)
+	}
+	return "Unknown"
+}
+
+// blockEventRetryDelay returns the delay before retrying a block event
+// which failed to process the provided number of times. The delay doubles
+// with every attempt, starting at the provided minimum and capped at the
+// provided maximum.
+func blockEventRetryDelay(attempts uint32, min time.Duration, max time.Duration) time.Duration {
+	delay := min
+	for i := uint32(1); i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
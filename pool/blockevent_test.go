@@ -0,0 +1,437 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func TestBlockEventRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts uint32
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, time.Second * 2},
+		{4, time.Second * 8},
+		{5, time.Second * 10},
+		{40, time.Second * 10},
+	}
+	for _, test := range tests {
+		got := blockEventRetryDelay(test.attempts, time.Second,
+			time.Second*10)
+		if got != test.want {
+			t.Fatalf("attempt %d: expected a delay of %v, got %v",
+				test.attempts, test.want, got)
+		}
+	}
+
+	fatal := []error{
+		errs.DBError(errs.Decode, "corrupted"),
+		fmt.Errorf("wrapped: %w", errs.DBError(errs.Parse, "corrupted")),
+	}
+	for _, err := range fatal {
+		if !isFatalChainError(err) {
+			t.Fatalf("expected %v to be fatal", err)
+		}
+	}
+	transient := []error{
+		errs.PoolError(errs.Disconnected, "wallet unreachable"),
+		errs.DBError(errs.FetchEntry, "connection reset"),
+		errors.New("unclassified"),
+	}
+	for _, err := range transient {
+		if isFatalChainError(err) {
+			t.Fatalf("expected %v to be transient", err)
+		}
+	}
+}
+
+func TestChainStateRetries(t *testing.T) {
+	db := NewMemoryDB()
+
+	var mtx sync.Mutex
+	var payErr error
+	payDividends := func(context.Context, uint32, bool) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return payErr
+	}
+	setPayErr := func(err error) {
+		mtx.Lock()
+		payErr = err
+		mtx.Unlock()
+	}
+	getBlock := func(context.Context, *chainhash.Hash) (*wire.MsgBlock, error) {
+		coinbase := wire.NewMsgTx()
+		coinbase.AddTxOut(wire.NewTxOut(0, []byte{}))
+		coinbase.AddTxOut(wire.NewTxOut(1, []byte{}))
+		coinbase.AddTxOut(wire.NewTxOut(100, []byte{}))
+		return &wire.MsgBlock{Transactions: []*wire.MsgTx{coinbase}}, nil
+	}
+	newChainState := func(cancel context.CancelFunc) *ChainState {
+		return NewChainState(&ChainStateConfig{
+			db:           db,
+			PayDividends: payDividends,
+			GeneratePayments: func(uint32, *PaymentSource, dcrutil.Amount, int64) error {
+				return nil
+			},
+			GetBlock: getBlock,
+			GetBlockConfirmations: func(context.Context, *chainhash.Hash) (int64, error) {
+				return -1, nil
+			},
+			GetBestBlock: func(context.Context) (*chainhash.Hash, int64, error) {
+				return nil, 0, errs.PoolError(errs.Disconnected,
+					"node unreachable")
+			},
+			RetryDelay:     time.Millisecond * 20,
+			MaxRetryDelay:  time.Millisecond * 40,
+			Cancel:         cancel,
+			SignalCache:    func(CacheUpdateEvent) {},
+			NotifyWebhooks: func(string, interface{}, map[string]interface{}) {},
+			HubWg:          new(sync.WaitGroup),
+		})
+	}
+	waitFor := func(desc string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second * 5)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", desc)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+
+	var header wire.BlockHeader
+	header.Height = 42
+	notify := func(cs *ChainState, header *wire.BlockHeader) {
+		headerB, err := header.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &blockNotification{Header: headerB, Done: make(chan bool)}
+		cs.connCh <- msg
+		<-msg.Done
+	}
+
+	// Ensure a block notification failing with a transient error is
+	// retried without terminating the pool until it is processed.
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := newChainState(cancel)
+	cs.cfg.HubWg.Add(1)
+	go cs.handleChainUpdates(ctx)
+
+	setPayErr(errs.PoolError(errs.Disconnected, "wallet unreachable"))
+	notify(cs, &header)
+	stats := cs.blockEventStats()
+	if len(stats.Pending) != 1 || stats.Pending[0].Attempts != 1 {
+		t.Fatalf("expected a pending block event after a failed attempt, "+
+			"got %+v", stats)
+	}
+	events, err := db.fetchBlockEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].LastError == "" {
+		t.Fatalf("expected a persisted failed block event, got %d events",
+			len(events))
+	}
+
+	waitFor("a retry", func() bool {
+		return cs.blockEventStats().Retries >= 2
+	})
+	setPayErr(nil)
+	waitFor("the block event to be processed", func() bool {
+		return cs.blockEventStats().Processed == 1
+	})
+	if ctx.Err() != nil {
+		t.Fatal("expected transient errors not to terminate the pool")
+	}
+	stats = cs.blockEventStats()
+	if len(stats.Pending) != 0 || stats.ByKind["Disconnected"] != stats.Retries {
+		t.Fatalf("unexpected block event stats %+v", stats)
+	}
+	events, err = db.fetchBlockEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected processed block events to be deleted, got %d",
+			len(events))
+	}
+
+	// Ensure a block notification failing to pay dividends is processed
+	// regardless once paying them failed for the maximum attempts.
+	setPayErr(errs.PoolError(errs.PublishTx, "publish failed"))
+	next := wire.BlockHeader{PrevBlock: header.BlockHash(), Height: 43}
+	notify(cs, &next)
+	waitFor("the block event to be processed", func() bool {
+		return cs.blockEventStats().Processed == 2
+	})
+	if ctx.Err() != nil {
+		t.Fatal("expected transient errors not to terminate the pool")
+	}
+	stats = cs.blockEventStats()
+	if stats.ByKind["PublishTx"] != maxPayDividendsAttempts-1 {
+		t.Fatalf("expected %d retries paying dividends, got %d",
+			maxPayDividendsAttempts-1, stats.ByKind["PublishTx"])
+	}
+
+	// Ensure a block notification failing with a fatal error terminates
+	// the pool and remains pending.
+	setPayErr(errs.DBError(errs.Decode, "corrupted payment"))
+	next = wire.BlockHeader{PrevBlock: next.BlockHash(), Height: 44}
+	notify(cs, &next)
+	if ctx.Err() == nil {
+		t.Fatal("expected a fatal error to terminate the pool")
+	}
+	cs.cfg.HubWg.Wait()
+	if cs.blockEventStats().Fatal != 1 {
+		t.Fatal("expected a fatal block event")
+	}
+
+	// Ensure the pending block notification is resumed after a restart.
+	setPayErr(nil)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	cs = newChainState(cancel)
+	cs.cfg.HubWg.Add(1)
+	go cs.handleChainUpdates(ctx)
+	waitFor("the resumed block event to be processed", func() bool {
+		return cs.blockEventStats().Processed == 1
+	})
+	events, err = db.fetchBlockEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected the resumed block event to be deleted, got %d",
+			len(events))
+	}
+	cancel()
+	cs.cfg.HubWg.Wait()
+}
+
+func TestChainStateCatchUp(t *testing.T) {
+	db := NewMemoryDB()
+
+	// The chain of the mining node.
+	var mtx sync.Mutex
+	genesis := &wire.BlockHeader{}
+	mainChain := []*wire.BlockHeader{genesis}
+	headers := map[chainhash.Hash]*wire.BlockHeader{
+		genesis.BlockHash(): genesis,
+	}
+	extend := func(n int, nonce uint32) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		for i := 0; i < n; i++ {
+			tip := mainChain[len(mainChain)-1]
+			header := &wire.BlockHeader{
+				PrevBlock: tip.BlockHash(),
+				Height:    tip.Height + 1,
+				Nonce:     nonce,
+			}
+			mainChain = append(mainChain, header)
+			headers[header.BlockHash()] = header
+		}
+	}
+	reorg := func(height uint32, n int, nonce uint32) {
+		mtx.Lock()
+		mainChain = mainChain[:height]
+		mtx.Unlock()
+		extend(n, nonce)
+	}
+	blockAt := func(height uint32) *wire.BlockHeader {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return mainChain[height]
+	}
+
+	var generated []uint32
+	newChainState := func(cancel context.CancelFunc) *ChainState {
+		return NewChainState(&ChainStateConfig{
+			db: db,
+			PayDividends: func(context.Context, uint32, bool) error {
+				return nil
+			},
+			GeneratePayments: func(height uint32, _ *PaymentSource, _ dcrutil.Amount, _ int64) error {
+				mtx.Lock()
+				generated = append(generated, height)
+				mtx.Unlock()
+				return nil
+			},
+			GetBlock: func(_ context.Context, hash *chainhash.Hash) (*wire.MsgBlock, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				coinbase := wire.NewMsgTx()
+				coinbase.AddTxOut(wire.NewTxOut(0, []byte{}))
+				coinbase.AddTxOut(wire.NewTxOut(1, []byte{}))
+				coinbase.AddTxOut(wire.NewTxOut(100, []byte{}))
+				return &wire.MsgBlock{
+					Header:       *headers[*hash],
+					Transactions: []*wire.MsgTx{coinbase},
+				}, nil
+			},
+			GetBlockConfirmations: func(context.Context, *chainhash.Hash) (int64, error) {
+				return 1, nil
+			},
+			GetBestBlock: func(context.Context) (*chainhash.Hash, int64, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				tip := mainChain[len(mainChain)-1]
+				hash := tip.BlockHash()
+				return &hash, int64(tip.Height), nil
+			},
+			GetBlockHash: func(_ context.Context, height int64) (*chainhash.Hash, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				if height >= int64(len(mainChain)) {
+					return nil, fmt.Errorf("no block at height %d", height)
+				}
+				hash := mainChain[height].BlockHash()
+				return &hash, nil
+			},
+			GetBlockHeader: func(_ context.Context, hash *chainhash.Hash) (*wire.BlockHeader, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				header, ok := headers[*hash]
+				if !ok {
+					return nil, fmt.Errorf("no block %s", hash)
+				}
+				return header, nil
+			},
+			CoinbaseMaturity: 2,
+			RetryDelay:       time.Millisecond * 20,
+			Cancel:           cancel,
+			SignalCache:      func(CacheUpdateEvent) {},
+			NotifyWebhooks:   func(string, interface{}, map[string]interface{}) {},
+			HubWg:            new(sync.WaitGroup),
+		})
+	}
+	waitFor := func(desc string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second * 5)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", desc)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+	start := func() (*ChainState, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cs := newChainState(cancel)
+		cs.cfg.HubWg.Add(1)
+		go cs.handleChainUpdates(ctx)
+		return cs, func() {
+			cancel()
+			cs.cfg.HubWg.Wait()
+		}
+	}
+	waitForTip := func(header *wire.BlockHeader) {
+		t.Helper()
+		want := header.BlockHash().String()
+		waitFor(fmt.Sprintf("chain tip #%d", header.Height), func() bool {
+			hash, height, err := db.loadChainTip()
+			return err == nil && hash == want && height == header.Height
+		})
+	}
+	notify := func(cs *ChainState, header *wire.BlockHeader) {
+		headerB, err := header.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &blockNotification{Header: headerB, Done: make(chan bool)}
+		cs.connCh <- msg
+		<-msg.Done
+	}
+
+	// Ensure the chain tip of the mining node becomes the chain tip
+	// without a persisted chain tip.
+	extend(10, 0)
+	_, stop := start()
+	waitForTip(blockAt(10))
+	stop()
+
+	// Ensure blocks connected while the pool was down are caught up on,
+	// confirming and maturing mined work and generating payments for it.
+	extend(1, 0)
+	mined := blockAt(11)
+	work := NewAcceptedWork(mined.BlockHash().String(),
+		mined.PrevBlock.String(), mined.Height, xID, CPU)
+	err := db.persistAcceptedWork(work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extend(2, 0)
+	cs, stop := start()
+	waitForTip(blockAt(13))
+	if n := cs.blockEventStats().Processed; n != 3 {
+		t.Fatalf("expected 3 caught up block events, got %d", n)
+	}
+	work, err = db.fetchAcceptedWork(work.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !work.Confirmed || work.Status != WorkMatured {
+		t.Fatalf("expected mined work to be matured by caught up blocks, "+
+			"got status %q", work.Status)
+	}
+	mtx.Lock()
+	if len(generated) != 1 || generated[0] != mined.Height {
+		t.Fatalf("expected payments generated for block #%d, got %v",
+			mined.Height, generated)
+	}
+	mtx.Unlock()
+	stop()
+
+	// Ensure a reorg while the pool was down is replayed, orphaning mined
+	// work no longer part of the main chain.
+	reorg(11, 4, 1)
+	cs, stop = start()
+	waitForTip(blockAt(14))
+	if n := cs.blockEventStats().Processed; n != 7 {
+		t.Fatalf("expected 3 disconnected and 4 connected block events, "+
+			"got %d", n)
+	}
+	work, err = db.fetchAcceptedWork(work.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacing := blockAt(11).BlockHash().String()
+	if work.Confirmed || work.Status != WorkOrphaned ||
+		work.OrphanedBy != replacing {
+		t.Fatalf("expected reorged mined work to be orphaned by %s, got "+
+			"status %q orphaned by %q", replacing, work.Status,
+			work.OrphanedBy)
+	}
+
+	// Ensure blocks missed while the pool is running are caught up on
+	// once a block notification does not extend the chain tip, and
+	// duplicate block notifications are ignored.
+	extend(2, 1)
+	notify(cs, blockAt(16))
+	waitForTip(blockAt(16))
+	notify(cs, blockAt(16))
+	if n := cs.blockEventStats().Processed; n != 9 {
+		t.Fatalf("expected 9 processed block events, got %d", n)
+	}
+
+	// Ensure a requested catch-up, like after the mining node
+	// reconnected, picks up blocks missed by the node connection.
+	extend(1, 1)
+	cs.requestCatchUp()
+	waitForTip(blockAt(17))
+	stop()
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrjson/v3"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// maxBlockSubmissions is the number of most recent block submissions kept.
+const maxBlockSubmissions = 20
+
+// WorkSubmitter defines the functionality needed by a mining node connection
+// solved blocks are submitted to.
+type WorkSubmitter interface {
+	GetWorkSubmit(context.Context, string) (bool, error)
+	Shutdown()
+}
+
+// SubmissionResult represents the outcome of submitting a solved block to a
+// mining node.
+type SubmissionResult struct {
+	Node     string `json:"node"`
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+	Latency  int64  `json:"latency"`
+}
+
+// BlockSubmission represents a solved block submitted to the mining nodes
+// along with the outcome of every submission.
+type BlockSubmission struct {
+	Block       string              `json:"block"`
+	SubmittedOn int64               `json:"submittedon"`
+	Accepted    bool                `json:"accepted"`
+	Results     []*SubmissionResult `json:"results"`
+}
+
+// submitNode represents an additional mining node solved blocks are
+// submitted to.
+type submitNode struct {
+	name string
+	conn WorkSubmitter
+}
+
+// blockSubmitter submits solved blocks to the active mining node and the
+// additional mining nodes concurrently.
+type blockSubmitter struct {
+	fetchPrimary func() (string, WorkSubmitter)
+	nodes        []*submitNode
+	history      []*BlockSubmission
+	mtx          sync.RWMutex
+}
+
+// newBlockSubmitter initializes a block submitter. The provided func returns
+// the name and connection of the active mining node.
+func newBlockSubmitter(fetchPrimary func() (string, WorkSubmitter)) *blockSubmitter {
+	return &blockSubmitter{fetchPrimary: fetchPrimary}
+}
+
+// addNode adds an additional mining node solved blocks are submitted to.
+func (s *blockSubmitter) addNode(name string, conn WorkSubmitter) {
+	s.mtx.Lock()
+	s.nodes = append(s.nodes, &submitNode{name: name, conn: conn})
+	s.mtx.Unlock()
+}
+
+// submissionBlockHash returns the hash of the block of the provided getwork
+// submission, or the submission itself if it cannot be decoded.
+func submissionBlockHash(data string) string {
+	if len(data) < wire.MaxBlockHeaderPayload*2 {
+		return data
+	}
+	headerB, err := hex.DecodeString(data[:wire.MaxBlockHeaderPayload*2])
+	if err != nil {
+		return data
+	}
+	hash, err := headerHash(headerB)
+	if err != nil {
+		return data
+	}
+	return hash.String()
+}
+
+// record adds the provided submission to the submission history.
+func (s *blockSubmitter) record(sub *BlockSubmission) {
+	s.mtx.Lock()
+	s.history = append(s.history, sub)
+	if len(s.history) > maxBlockSubmissions {
+		s.history = s.history[1:]
+	}
+	s.mtx.Unlock()
+}
+
+// submissions returns the most recent block submissions, newest first.
+func (s *blockSubmitter) submissions() []*BlockSubmission {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	subs := make([]*BlockSubmission, 0, len(s.history))
+	for i := len(s.history) - 1; i >= 0; i-- {
+		sub := *s.history[i]
+		sub.Results = make([]*SubmissionResult, len(s.history[i].Results))
+		copy(sub.Results, s.history[i].Results)
+		subs = append(subs, &sub)
+	}
+	return subs
+}
+
+// submit sends the provided getwork submission to the active mining node and
+// the additional mining nodes concurrently. It returns as soon as a mining
+// node accepts the block, the remaining results are still recorded as they
+// arrive. Once no mining node accepted the block, a mining node rejecting it
+// is reported as a rejected submission and an error is returned if no
+// mining node could be reached.
+func (s *blockSubmitter) submit(ctx context.Context, data string) (bool, error) {
+	primaryName, primary := s.fetchPrimary()
+	s.mtx.RLock()
+	nodes := append([]*submitNode{{name: primaryName, conn: primary}},
+		s.nodes...)
+	s.mtx.RUnlock()
+
+	sub := &BlockSubmission{
+		Block:       submissionBlockHash(data),
+		SubmittedOn: time.Now().UnixNano(),
+	}
+	s.record(sub)
+
+	type outcome struct {
+		accepted bool
+		err      error
+	}
+	outcomes := make(chan outcome, len(nodes))
+	for _, node := range nodes {
+		go func(node *submitNode) {
+			start := time.Now()
+			accepted, err := node.conn.GetWorkSubmit(ctx, data)
+			res := &SubmissionResult{
+				Node:     node.name,
+				Accepted: accepted && err == nil,
+				Latency:  time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				res.Error = err.Error()
+			}
+
+			s.mtx.Lock()
+			sub.Results = append(sub.Results, res)
+			sub.Accepted = sub.Accepted || res.Accepted
+			s.mtx.Unlock()
+
+			switch {
+			case err != nil:
+				log.Debugf("Block %s submission to %s failed after %dms: %v",
+					sub.Block, node.name, res.Latency, err)
+			default:
+				log.Infof("Block %s submission to %s accepted: %v (%dms)",
+					sub.Block, node.name, accepted, res.Latency)
+			}
+
+			outcomes <- outcome{accepted: res.Accepted, err: err}
+		}(node)
+	}
+
+	var rejected bool
+	var rpcErrs, connErrs []string
+	for range nodes {
+		o := <-outcomes
+		var rpcErr *dcrjson.RPCError
+		switch {
+		case o.accepted:
+			return true, nil
+		case o.err == nil:
+			rejected = true
+		case errors.As(o.err, &rpcErr):
+			rpcErrs = append(rpcErrs, o.err.Error())
+		default:
+			connErrs = append(connErrs, o.err.Error())
+		}
+	}
+
+	switch {
+	case rejected:
+		return false, nil
+
+	case len(rpcErrs) > 0:
+		desc := fmt.Sprintf("block %s rejected by the network: %s",
+			sub.Block, strings.Join(rpcErrs, "; "))
+		return false, errs.PoolError(errs.WorkRejected, desc)
+
+	default:
+		desc := fmt.Sprintf("unable to submit block %s: %s", sub.Block,
+			strings.Join(connErrs, "; "))
+		return false, errs.PoolError(errs.Disconnected, desc)
+	}
+}
+
+// shutdown shuts down the connections to the additional mining nodes.
+func (s *blockSubmitter) shutdown() {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	for _, node := range s.nodes {
+		node.conn.Shutdown()
+	}
+}
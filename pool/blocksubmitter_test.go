@@ -0,0 +1,154 @@
+package pool
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrjson/v3"
+	"github.com/decred/dcrd/rpcclient/v6"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// tWorkSubmitter is a mining node connection responding to block submissions
+// with a configurable outcome after a configurable delay.
+type tWorkSubmitter struct {
+	accepted bool
+	err      error
+	delay    time.Duration
+}
+
+func (t *tWorkSubmitter) GetWorkSubmit(ctx context.Context, _ string) (bool, error) {
+	select {
+	case <-time.After(t.delay):
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	return t.accepted, t.err
+}
+
+func (t *tWorkSubmitter) Shutdown() {}
+
+func TestBlockSubmitter(t *testing.T) {
+	var header wire.BlockHeader
+	header.Height = 42
+	headerB, err := header.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := hex.EncodeToString(headerB) + "8000000100000000000005a0"
+	blockHash := header.BlockHash().String()
+
+	rejectErr := &dcrjson.RPCError{
+		Code:    dcrjson.ErrRPCMisc,
+		Message: "block difficulty of 1 is not the expected value of 2",
+	}
+	tests := []struct {
+		name     string
+		primary  *tWorkSubmitter
+		extra    []*tWorkSubmitter
+		accepted bool
+		errKind  error
+	}{{
+		name:    "first acceptance wins",
+		primary: &tWorkSubmitter{accepted: true, delay: time.Second},
+		extra: []*tWorkSubmitter{
+			{err: rpcclient.ErrClientDisconnect},
+			{accepted: true},
+		},
+		accepted: true,
+	}, {
+		name:    "rejected by the network",
+		primary: &tWorkSubmitter{},
+		extra: []*tWorkSubmitter{
+			{err: rpcclient.ErrClientDisconnect},
+		},
+	}, {
+		name:    "rejected with an error",
+		primary: &tWorkSubmitter{err: rejectErr},
+		extra: []*tWorkSubmitter{
+			{err: rpcclient.ErrClientDisconnect},
+		},
+		errKind: errs.WorkRejected,
+	}, {
+		name:    "unreachable",
+		primary: &tWorkSubmitter{err: rpcclient.ErrClientDisconnect},
+		extra: []*tWorkSubmitter{
+			{err: rpcclient.ErrClientShutdown},
+		},
+		errKind: errs.Disconnected,
+	}}
+
+	for _, test := range tests {
+		primary := test.primary
+		s := newBlockSubmitter(func() (string, WorkSubmitter) {
+			return "primary", primary
+		})
+		for i, node := range test.extra {
+			s.addNode(string(rune('a'+i)), node)
+		}
+
+		start := time.Now()
+		accepted, err := s.submit(context.Background(), data)
+		if accepted != test.accepted {
+			t.Fatalf("%s: expected accepted %v, got %v", test.name,
+				test.accepted, accepted)
+		}
+		if test.errKind == nil && err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if test.errKind != nil && !errors.Is(err, test.errKind) {
+			t.Fatalf("%s: expected a %v error, got %v", test.name,
+				test.errKind, err)
+		}
+		if test.accepted && time.Since(start) >= time.Second {
+			t.Fatalf("%s: expected the first acceptance to return "+
+				"without waiting for slower nodes", test.name)
+		}
+
+		subs := s.submissions()
+		if len(subs) != 1 || subs[0].Block != blockHash ||
+			subs[0].Accepted != test.accepted {
+			t.Fatalf("%s: expected a recorded submission of %s", test.name,
+				blockHash)
+		}
+	}
+
+	// Ensure the results of slower nodes are recorded once they arrive.
+	s := newBlockSubmitter(func() (string, WorkSubmitter) {
+		return "primary", &tWorkSubmitter{accepted: true}
+	})
+	s.addNode("slow", &tWorkSubmitter{delay: time.Millisecond * 50})
+	_, err = s.submit(context.Background(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 200)
+	subs := s.submissions()
+	if len(subs[0].Results) != 2 {
+		t.Fatalf("expected 2 recorded results, got %d",
+			len(subs[0].Results))
+	}
+	for _, res := range subs[0].Results {
+		if res.Node == "slow" && (res.Accepted || res.Latency < 50) {
+			t.Fatalf("expected a rejection after at least 50ms, got %+v",
+				res)
+		}
+	}
+
+	// Ensure only the most recent submissions are kept.
+	for i := 0; i < maxBlockSubmissions+5; i++ {
+		_, err = s.submit(context.Background(), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(s.submissions()) != maxBlockSubmissions {
+		t.Fatalf("expected %d submissions, got %d", maxBlockSubmissions,
+			len(s.submissions()))
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// BackupManifestSuffix is the file name suffix of bolt snapshot
+	// manifests.
+	BackupManifestSuffix = ".manifest.json"
+
+	// snapshotPrefix is the file name prefix of bolt snapshots.
+	snapshotPrefix = "dcrpool-"
+
+	// snapshotTimeFormat is the layout of the creation time in the file
+	// name of bolt snapshots.
+	snapshotTimeFormat = "20060102T150405Z"
+)
+
+// BackupManifest describes a snapshot of a bolt database. It is written
+// alongside the snapshot so it can be verified before being restored.
+type BackupManifest struct {
+	File       string         `json:"file"`
+	CreatedOn  int64          `json:"createdon"`
+	DBVersion  uint32         `json:"dbversion"`
+	Compressed bool           `json:"compressed"`
+	Size       int64          `json:"size"`
+	SHA256     string         `json:"sha256"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// countBoltEntities returns the number of entities of every archived kind
+// in the provided transaction. Kinds without a bucket, which databases of
+// older versions lack, are omitted.
+func countBoltEntities(tx *bolt.Tx) (map[string]int, error) {
+	pbkt, err := fetchPoolBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(archiveBuckets))
+	for _, entry := range archiveBuckets {
+		bkt := pbkt.Bucket(entry.bucket)
+		if bkt == nil {
+			continue
+		}
+		var n int
+		c := bkt.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			// Nested buckets are not entities.
+			if v != nil {
+				n++
+			}
+		}
+		counts[entry.kind] = n
+	}
+	return counts, nil
+}
+
+// writeFileAtomic writes the content produced by the provided func to the
+// provided file path. The content is written to a temporary file first so
+// an existing file is only replaced by a complete one.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	cErr := f.Close()
+	if err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// Snapshot writes a consistent copy of the database file to the provided
+// directory while the database remains in use, along with its manifest.
+// The snapshot is gzip compressed when compress is set.
+func (db *BoltDB) Snapshot(dir string, compress bool) (*BackupManifest, error) {
+	const funcName = "Snapshot"
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to create backup directory: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+
+	now := time.Now()
+	file := snapshotPrefix + now.UTC().Format(snapshotTimeFormat) + ".kv"
+	if compress {
+		file += ".gz"
+	}
+	manifest := &BackupManifest{
+		File:       file,
+		CreatedOn:  now.UnixNano(),
+		Compressed: compress,
+	}
+
+	// The counts and the copy are taken within the same transaction, the
+	// manifest therefore describes the snapshot exactly.
+	err = db.DB.View(func(tx *bolt.Tx) error {
+		manifest.DBVersion, err = fetchDBVersion(tx)
+		if err != nil {
+			return err
+		}
+		manifest.Counts, err = countBoltEntities(tx)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, manifest.File)
+		return writeFileAtomic(path, func(w io.Writer) error {
+			hash := sha256.New()
+			out := io.MultiWriter(w, hash)
+			if compress {
+				zw := gzip.NewWriter(out)
+				_, err := tx.WriteTo(zw)
+				if err != nil {
+					return err
+				}
+				err = zw.Close()
+				if err != nil {
+					return err
+				}
+			} else {
+				_, err := tx.WriteTo(out)
+				if err != nil {
+					return err
+				}
+			}
+			manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+			return nil
+		})
+	})
+	if err != nil {
+		if errors.Is(err, errs.Backup) {
+			return nil, err
+		}
+		desc := fmt.Sprintf("%s: unable to write snapshot: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	info, err := os.Stat(filepath.Join(dir, manifest.File))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to stat snapshot: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	manifest.Size = info.Size()
+
+	mBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to encode manifest: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	manifestPath := filepath.Join(dir, manifest.File+BackupManifestSuffix)
+	err = writeFileAtomic(manifestPath, func(w io.Writer) error {
+		_, err := w.Write(mBytes)
+		return err
+	})
+	if err != nil {
+		os.Remove(filepath.Join(dir, manifest.File))
+		desc := fmt.Sprintf("%s: unable to write manifest: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+
+	return manifest, nil
+}
+
+// readBackupManifest reads the snapshot manifest at the provided path.
+func readBackupManifest(path string) (*BackupManifest, error) {
+	const funcName = "readBackupManifest"
+	mBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to read manifest: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	var manifest BackupManifest
+	err = json.Unmarshal(mBytes, &manifest)
+	if err != nil || manifest.File == "" {
+		desc := fmt.Sprintf("%s: invalid manifest %s", funcName, path)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+	return &manifest, nil
+}
+
+// ListSnapshots returns the manifests of the bolt snapshots in the provided
+// directory, newest first. Unreadable manifests are logged and skipped so a
+// single corrupt manifest does not prevent pruning the other snapshots.
+func ListSnapshots(dir string) ([]*BackupManifest, error) {
+	const funcName = "ListSnapshots"
+	paths, err := filepath.Glob(filepath.Join(dir,
+		snapshotPrefix+"*"+BackupManifestSuffix))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to list snapshots: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+
+	manifests := make([]*BackupManifest, 0, len(paths))
+	for _, path := range paths {
+		manifest, err := readBackupManifest(path)
+		if err != nil {
+			log.Warnf("skipping snapshot manifest: %v", err)
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].CreatedOn > manifests[j].CreatedOn
+	})
+	return manifests, nil
+}
+
+// retainedSnapshots returns the files of the provided snapshots, ordered
+// newest first, retained by keeping the newest snapshot of each of the
+// latest keepHourly hours and of each of the latest keepDaily days.
+func retainedSnapshots(manifests []*BackupManifest, keepHourly, keepDaily int) map[string]struct{} {
+	retained := make(map[string]struct{})
+	hours := make(map[int64]struct{})
+	days := make(map[string]struct{})
+	for _, manifest := range manifests {
+		created := time.Unix(0, manifest.CreatedOn).UTC()
+		hour := created.Truncate(time.Hour).Unix()
+		if _, ok := hours[hour]; !ok && len(hours) < keepHourly {
+			hours[hour] = struct{}{}
+			retained[manifest.File] = struct{}{}
+		}
+		day := created.Format("2006-01-02")
+		if _, ok := days[day]; !ok && len(days) < keepDaily {
+			days[day] = struct{}{}
+			retained[manifest.File] = struct{}{}
+		}
+	}
+	return retained
+}
+
+// PruneSnapshots deletes the bolt snapshots in the provided directory which
+// are not retained, keeping the newest snapshot of each of the latest
+// keepHourly hours and of each of the latest keepDaily days. Returns the
+// files of the deleted snapshots.
+func PruneSnapshots(dir string, keepHourly, keepDaily int) ([]string, error) {
+	const funcName = "PruneSnapshots"
+	manifests, err := ListSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	retained := retainedSnapshots(manifests, keepHourly, keepDaily)
+	var pruned []string
+	for _, manifest := range manifests {
+		if _, ok := retained[manifest.File]; ok {
+			continue
+		}
+		path := filepath.Join(dir, manifest.File)
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			desc := fmt.Sprintf("%s: unable to delete snapshot: %v",
+				funcName, err)
+			return pruned, errs.PoolError(errs.Backup, desc)
+		}
+		err = os.Remove(path + BackupManifestSuffix)
+		if err != nil && !os.IsNotExist(err) {
+			desc := fmt.Sprintf("%s: unable to delete manifest: %v",
+				funcName, err)
+			return pruned, errs.PoolError(errs.Backup, desc)
+		}
+		pruned = append(pruned, manifest.File)
+	}
+	return pruned, nil
+}
+
+// RestoreSnapshot restores the bolt snapshot described by the provided
+// manifest to the provided database file. The snapshot is verified against
+// its manifest and the database file must not exist. The restored database
+// is upgraded when next opened.
+func RestoreSnapshot(manifestPath string, dbFile string) (*BackupManifest, error) {
+	const funcName = "RestoreSnapshot"
+	if !strings.HasSuffix(manifestPath, BackupManifestSuffix) {
+		desc := fmt.Sprintf("%s: %s is not a snapshot manifest", funcName,
+			manifestPath)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	manifest, err := readBackupManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.DBVersion > BoltDBVersion {
+		desc := fmt.Sprintf("%s: snapshot database version %d is newer "+
+			"than the latest known version %d", funcName, manifest.DBVersion,
+			BoltDBVersion)
+		return nil, errs.DBError(errs.DBUpgrade, desc)
+	}
+	_, err = os.Stat(dbFile)
+	if err == nil {
+		desc := fmt.Sprintf("%s: database file %s already exists", funcName,
+			dbFile)
+		return nil, errs.DBError(errs.ValueFound, desc)
+	}
+
+	snapshotPath := filepath.Join(filepath.Dir(manifestPath), manifest.File)
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to open snapshot: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	defer f.Close()
+
+	// The checksum is verified before the snapshot is copied.
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to read snapshot: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != manifest.SHA256 {
+		desc := fmt.Sprintf("%s: snapshot checksum %s does not match the "+
+			"manifest checksum %s", funcName, sum, manifest.SHA256)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to read snapshot: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+
+	var r io.Reader = f
+	if manifest.Compressed {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to decompress snapshot: %v",
+				funcName, err)
+			return nil, errs.PoolError(errs.Decode, desc)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	// The database is copied to a temporary file first, which is only moved
+	// in place once verified.
+	tmpPath := dbFile + ".tmp"
+	err = writeFileAtomic(tmpPath, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to copy snapshot: %v", funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+
+	// Ensure the restored database holds the entities recorded in the
+	// manifest.
+	db, err := openBoltDB(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	var counts map[string]int
+	err = db.DB.View(func(tx *bolt.Tx) error {
+		counts, err = countBoltEntities(tx)
+		return err
+	})
+	db.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	for kind, n := range manifest.Counts {
+		if counts[kind] != n {
+			os.Remove(tmpPath)
+			desc := fmt.Sprintf("%s: snapshot has %d %s entities, the "+
+				"manifest records %d", funcName, counts[kind], kind, n)
+			return nil, errs.PoolError(errs.Decode, desc)
+		}
+	}
+
+	err = os.Rename(tmpPath, dbFile)
+	if err != nil {
+		os.Remove(tmpPath)
+		desc := fmt.Sprintf("%s: unable to move restored database: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.Backup, desc)
+	}
+
+	return manifest, nil
+}
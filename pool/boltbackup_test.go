@@ -0,0 +1,210 @@
+package pool
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func TestBoltSnapshots(t *testing.T) {
+	d, err := ioutil.TempDir("", "dcrpool_test_snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	bdb, err := InitBoltDB(filepath.Join(d, "dcrpool.kv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bdb.Close()
+
+	err = bdb.persistAccount(NewAccount(xAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bdb.persistAccount(NewAccount(yAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, compress := range []bool{false, true} {
+		dir := filepath.Join(d, "backups")
+		manifest, err := bdb.Snapshot(dir, compress)
+		if err != nil {
+			t.Fatalf("[%d] Snapshot: unexpected error: %v", i, err)
+		}
+		if manifest.DBVersion != BoltDBVersion {
+			t.Fatalf("[%d] expected snapshot version %d, got %d", i,
+				BoltDBVersion, manifest.DBVersion)
+		}
+		if manifest.Counts[accountRecord] != 2 {
+			t.Fatalf("[%d] expected 2 accounts, got %d", i,
+				manifest.Counts[accountRecord])
+		}
+
+		// Ensure the snapshot restores to a database holding its entities.
+		manifestPath := filepath.Join(dir, manifest.File+BackupManifestSuffix)
+		dbFile := filepath.Join(d, "restored.kv")
+		_, err = RestoreSnapshot(manifestPath, dbFile)
+		if err != nil {
+			t.Fatalf("[%d] RestoreSnapshot: unexpected error: %v", i, err)
+		}
+		rdb, err := InitBoltDB(dbFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = rdb.fetchAccount(NewAccount(yAddr).UUID)
+		rdb.Close()
+		if err != nil {
+			t.Fatalf("[%d] expected restored account, got %v", i, err)
+		}
+
+		// Ensure an existing database is not overwritten.
+		_, err = RestoreSnapshot(manifestPath, dbFile)
+		if !errors.Is(err, errs.ValueFound) {
+			t.Fatalf("[%d] expected a value found error, got %v", i, err)
+		}
+		os.Remove(dbFile)
+
+		// Ensure a corrupted snapshot is not restored.
+		snapshotPath := filepath.Join(dir, manifest.File)
+		f, err := os.OpenFile(snapshotPath, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write([]byte{0})
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = RestoreSnapshot(manifestPath, dbFile)
+		if !errors.Is(err, errs.Decode) {
+			t.Fatalf("[%d] expected a decode error, got %v", i, err)
+		}
+		if _, err := os.Stat(dbFile); !os.IsNotExist(err) {
+			t.Fatalf("[%d] expected no restored database, got %v", i, err)
+		}
+
+		err = os.RemoveAll(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 30, 0, 0, time.UTC)
+	var manifests []*BackupManifest
+	for i := 0; i < 72*2; i++ {
+		created := now.Add(-time.Duration(i) * 30 * time.Minute)
+		manifests = append(manifests, &BackupManifest{
+			File:      created.Format(snapshotTimeFormat),
+			CreatedOn: created.UnixNano(),
+		})
+	}
+
+	tests := []struct {
+		name       string
+		keepHourly int
+		keepDaily  int
+		retained   []time.Time
+	}{{
+		name:       "hourly only",
+		keepHourly: 2,
+		retained: []time.Time{
+			now,
+			now.Add(-time.Hour),
+		},
+	}, {
+		name:      "daily only",
+		keepDaily: 3,
+		retained: []time.Time{
+			now,
+			time.Date(2021, 3, 9, 23, 30, 0, 0, time.UTC),
+			time.Date(2021, 3, 8, 23, 30, 0, 0, time.UTC),
+		},
+	}, {
+		name:       "hourly and daily",
+		keepHourly: 3,
+		keepDaily:  2,
+		retained: []time.Time{
+			now,
+			now.Add(-time.Hour),
+			now.Add(-2 * time.Hour),
+			time.Date(2021, 3, 9, 23, 30, 0, 0, time.UTC),
+		},
+	}}
+
+	for _, test := range tests {
+		retained := retainedSnapshots(manifests, test.keepHourly,
+			test.keepDaily)
+		if len(retained) != len(test.retained) {
+			t.Fatalf("%s: expected %d retained snapshots, got %d", test.name,
+				len(test.retained), len(retained))
+		}
+		for _, created := range test.retained {
+			if _, ok := retained[created.Format(snapshotTimeFormat)]; !ok {
+				t.Fatalf("%s: expected snapshot of %v to be retained",
+					test.name, created)
+			}
+		}
+	}
+
+	// Ensure pruning deletes the snapshots and manifests not retained.
+	d, err := ioutil.TempDir("", "dcrpool_test_prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	bdb, err := InitBoltDB(filepath.Join(d, "dcrpool.kv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bdb.Close()
+
+	dir := filepath.Join(d, "backups")
+	first, err := bdb.Snapshot(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Snapshot file names have a resolution of a second.
+	time.Sleep(time.Second)
+	second, err := bdb.Snapshot(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure a corrupt manifest is skipped instead of preventing pruning.
+	corrupt := filepath.Join(dir, snapshotPrefix+"corrupt"+BackupManifestSuffix)
+	err = ioutil.WriteFile(corrupt, []byte("{"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := PruneSnapshots(dir, 1, 0)
+	if err != nil {
+		t.Fatalf("PruneSnapshots: unexpected error: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0] != first.File {
+		t.Fatalf("expected %s to be pruned, got %v", first.File, pruned)
+	}
+	manifests, err = ListSnapshots(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 || manifests[0].File != second.File {
+		t.Fatalf("expected only %s to remain, got %d snapshots",
+			second.File, len(manifests))
+	}
+	_, err = os.Stat(filepath.Join(dir, first.File))
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected pruned snapshot to be deleted, got %v", err)
+	}
+}
@@ -10,11 +10,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/http"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"time"
 
+	"github.com/decred/dcrd/dcrutil/v3"
 	bolt "go.etcd.io/bbolt"
 
 	errs "github.com/decred/dcrpool/errors"
@@ -44,6 +46,26 @@ var (
 	paymentArchiveBkt = []byte("paymentarchivebkt")
 	// hashDataBkt stores client identification and hashrate information.
 	hashDataBkt = []byte("hashdatabkt")
+	// apiTokenBkt stores the hashes of read-only account tokens.
+	apiTokenBkt = []byte("apitokenbkt")
+	// adminUserBkt stores admin panel users.
+	adminUserBkt = []byte("adminuserbkt")
+	// auditLogBkt stores the audit log of admin actions, keyed by time.
+	auditLogBkt = []byte("auditlogbkt")
+	// banBkt stores banned IP addresses and accounts.
+	banBkt = []byte("banbkt")
+	// ledgerBkt stores the entries of the accounting ledger.
+	ledgerBkt = []byte("ledgerbkt")
+	// blockEventBkt stores block notifications pending processing.
+	blockEventBkt = []byte("blockeventbkt")
+	// webhookBkt stores webhooks registered by accounts.
+	webhookBkt = []byte("webhookbkt")
+	// webhookDeliveryBkt stores webhook events pending delivery.
+	webhookDeliveryBkt = []byte("webhookdeliverybkt")
+	// workerBkt stores the workers tracked by the worker monitor.
+	workerBkt = []byte("workerbkt")
+	// workerEventBkt stores the status changes of tracked workers.
+	workerEventBkt = []byte("workereventbkt")
 	// versionK is the key of the current version of the database.
 	versionK = []byte("version")
 	// lastPaymentCreatedOn is the key of the last time a payment was
@@ -54,14 +76,20 @@ var (
 	lastPaymentPaidOn = []byte("lastpaymentpaidon")
 	// lastPaymentHeight is the key of the last payment height.
 	lastPaymentHeight = []byte("lastpaymentheight")
+	// chainTipHash is the key of the hash of the last processed chain tip.
+	chainTipHash = []byte("chaintiphash")
+	// chainTipHeight is the key of the height of the last processed chain
+	// tip.
+	chainTipHeight = []byte("chaintipheight")
+	// pendingPayoutK is the key of the payout transaction awaiting an
+	// external signature.
+	pendingPayoutK = []byte("pendingpayout")
 	// soloPool is the solo pool mode key.
 	soloPool = []byte("solopool")
 	// csrfSecret is the CSRF secret key.
 	csrfSecret = []byte("csrfsecret")
 	// PoolFeesK is the key used to track pool fee payouts.
 	PoolFeesK = "fees"
-	// BoltBackupFile is the database backup file name.
-	BoltBackupFile = "backup.kv"
 )
 
 // openBoltDB creates a connection to the provided bolt storage, the returned
@@ -136,23 +164,56 @@ func createBuckets(db *BoltDB) error {
 		if err != nil {
 			return err
 		}
-		return createNestedBucket(pbkt, hashDataBkt)
+		err = createNestedBucket(pbkt, hashDataBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, apiTokenBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, adminUserBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, auditLogBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, banBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, ledgerBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, blockEventBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, webhookBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, webhookDeliveryBkt)
+		if err != nil {
+			return err
+		}
+		err = createNestedBucket(pbkt, workerBkt)
+		if err != nil {
+			return err
+		}
+		return createNestedBucket(pbkt, workerEventBkt)
 	})
 	return err
 }
 
-// Backup saves a copy of the db to file. The file will be saved in the same
-// directory as the current db file.
+// Backup saves an archive of the db to file. The file will be saved in the
+// same directory as the current db file.
 func (db *BoltDB) Backup(backupFileName string) error {
 	backupPath := filepath.Join(filepath.Dir(db.DB.Path()), backupFileName)
-	return db.DB.View(func(tx *bolt.Tx) error {
-		err := tx.CopyFile(backupPath, 0600)
-		if err != nil {
-			desc := fmt.Sprintf("unable to backup db: %v", err)
-			return errs.PoolError(errs.Backup, desc)
-		}
-		return nil
-	})
+	return writeArchiveFile(db, backupPath)
 }
 
 // InitBoltDB handles the creation and upgrading of a bolt database.
@@ -174,6 +235,43 @@ func InitBoltDB(dbFile string) (*BoltDB, error) {
 	return db, nil
 }
 
+// OpenBoltDBReadOnly opens the provided bolt database read-only, without
+// creating or upgrading its buckets. Returns an error if the database is not
+// at the latest version.
+func OpenBoltDBReadOnly(dbFile string) (*BoltDB, error) {
+	const funcName = "OpenBoltDBReadOnly"
+	db, err := bolt.Open(dbFile, 0600, &bolt.Options{
+		Timeout:  1 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to open db file: %v", funcName, err)
+		return nil, errs.DBError(errs.DBOpen, desc)
+	}
+
+	var version uint32
+	err = db.View(func(tx *bolt.Tx) error {
+		_, err := fetchPoolBucket(tx)
+		if err != nil {
+			return err
+		}
+		version, err = fetchDBVersion(tx)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if version != BoltDBVersion {
+		db.Close()
+		desc := fmt.Sprintf("%s: database version %d is not the latest "+
+			"version %d", funcName, version, BoltDBVersion)
+		return nil, errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	return &BoltDB{db}, nil
+}
+
 // deleteEntry removes the specified key and its associated value from
 // the provided bucket.
 func deleteEntry(db *BoltDB, bucket []byte, key string) error {
@@ -239,21 +337,133 @@ func nanoToBigEndianBytes(nano int64) []byte {
 	return b
 }
 
+// boltMetadata reads the pool metadata of a bolt transaction.
+type boltMetadata struct {
+	tx *bolt.Tx
+}
+
+// fetchPoolMode retrives the pool mode. PoolMode is stored as a uint32 for
+// historical reasons. 0 indicates Public, 1 indicates Solo.
+func (m boltMetadata) fetchPoolMode() (uint32, error) {
+	pbkt, err := fetchPoolBucket(m.tx)
+	if err != nil {
+		return 0, err
+	}
+	b := pbkt.Get(soloPool)
+	if b == nil {
+		return 0, errs.DBError(errs.ValueNotFound, "no pool mode found")
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// fetchCSRFSecret retrieves the bytes used for the CSRF secret.
+func (m boltMetadata) fetchCSRFSecret() ([]byte, error) {
+	pbkt := m.tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("bucket %s not found", string(poolBkt))
+		return nil, errs.DBError(errs.StorageNotFound, desc)
+	}
+	v := pbkt.Get(csrfSecret)
+	if v == nil {
+		return nil, errs.DBError(errs.ValueNotFound, "No csrf secret found")
+	}
+
+	// Byte slices returned from Bolt are only valid during a transaction.
+	// Need to make a copy.
+	secret := make([]byte, len(v))
+	copy(secret, v)
+	return secret, nil
+}
+
+// loadLastPaymentInfo retrieves the last payment height and paidOn
+// timestamp.
+func (m boltMetadata) loadLastPaymentInfo() (uint32, int64, error) {
+	funcName := "loadLastPaymentInfo"
+	pbkt, err := fetchPoolBucket(m.tx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lastPaymentHeightB := pbkt.Get(lastPaymentHeight)
+	lastPaymentPaidOnB := pbkt.Get(lastPaymentPaidOn)
+
+	if lastPaymentHeightB == nil || lastPaymentPaidOnB == nil {
+		desc := fmt.Sprintf("%s: last payment info not initialized", funcName)
+		return 0, 0, errs.DBError(errs.ValueNotFound, desc)
+	}
+
+	height := binary.LittleEndian.Uint32(lastPaymentHeightB)
+	paidOn := int64(bigEndianBytesToNano(lastPaymentPaidOnB))
+	return height, paidOn, nil
+}
+
+// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp.
+func (m boltMetadata) loadLastPaymentCreatedOn() (int64, error) {
+	funcName := "loadLastPaymentCreatedOn"
+	pbkt, err := fetchPoolBucket(m.tx)
+	if err != nil {
+		return 0, err
+	}
+	lastPaymentCreatedOnB := pbkt.Get(lastPaymentCreatedOn)
+	if lastPaymentCreatedOnB == nil {
+		desc := fmt.Sprintf("%s: last payment created-on not initialized",
+			funcName)
+		return 0, errs.DBError(errs.ValueNotFound, desc)
+	}
+	return int64(bigEndianBytesToNano(lastPaymentCreatedOnB)), nil
+}
+
+// loadChainTip retrieves the hash and height of the last processed chain
+// tip.
+func (m boltMetadata) loadChainTip() (string, uint32, error) {
+	const funcName = "loadChainTip"
+	pbkt, err := fetchPoolBucket(m.tx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hashB := pbkt.Get(chainTipHash)
+	heightB := pbkt.Get(chainTipHeight)
+	if hashB == nil || heightB == nil {
+		desc := fmt.Sprintf("%s: chain tip not initialized", funcName)
+		return "", 0, errs.DBError(errs.ValueNotFound, desc)
+	}
+
+	return string(hashB), binary.LittleEndian.Uint32(heightB), nil
+}
+
+// fetchPendingPayout retrieves the payout transaction awaiting an external
+// signature. Returns an error if there is none.
+func (m boltMetadata) fetchPendingPayout() (*PendingPayout, error) {
+	const funcName = "fetchPendingPayout"
+	pbkt, err := fetchPoolBucket(m.tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := pbkt.Get(pendingPayoutK)
+	if v == nil {
+		desc := fmt.Sprintf("%s: no pending payout found", funcName)
+		return nil, errs.DBError(errs.ValueNotFound, desc)
+	}
+	var payout PendingPayout
+	err = json.Unmarshal(v, &payout)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to unmarshal pending payout: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+	return &payout, nil
+}
+
 // fetchPoolMode retrives the pool mode from the database. PoolMode is stored as
 // a uint32 for historical reasons. 0 indicates Public, 1 indicates Solo.
 func (db *BoltDB) fetchPoolMode() (uint32, error) {
 	var mode uint32
 	err := db.DB.View(func(tx *bolt.Tx) error {
-		pbkt, err := fetchPoolBucket(tx)
-		if err != nil {
-			return err
-		}
-		b := pbkt.Get(soloPool)
-		if b == nil {
-			return errs.DBError(errs.ValueNotFound, "no pool mode found")
-		}
-		mode = binary.LittleEndian.Uint32(b)
-		return nil
+		var err error
+		mode, err = boltMetadata{tx}.fetchPoolMode()
+		return err
 	})
 	if err != nil {
 		return 0, err
@@ -277,25 +487,11 @@ func (db *BoltDB) persistPoolMode(mode uint32) error {
 // fetchCSRFSecret retrieves the bytes used for the CSRF secret from the database.
 func (db *BoltDB) fetchCSRFSecret() ([]byte, error) {
 	var secret []byte
-
 	err := db.DB.View(func(tx *bolt.Tx) error {
-		pbkt := tx.Bucket(poolBkt)
-		if pbkt == nil {
-			desc := fmt.Sprintf("bucket %s not found", string(poolBkt))
-			return errs.DBError(errs.StorageNotFound, desc)
-		}
-		v := pbkt.Get(csrfSecret)
-		if v == nil {
-			return errs.DBError(errs.ValueNotFound, "No csrf secret found")
-		}
-
-		// Byte slices returned from Bolt are only valid during a transaction.
-		// Need to make a copy.
-		secret = make([]byte, len(v))
-		copy(secret, v)
-		return nil
+		var err error
+		secret, err = boltMetadata{tx}.fetchCSRFSecret()
+		return err
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -316,29 +512,123 @@ func (db *BoltDB) persistCSRFSecret(secret []byte) error {
 	})
 }
 
+// putLastPaymentInfo stores the last payment height and paidOn timestamp in
+// the provided transaction.
+func putLastPaymentInfo(tx *bolt.Tx, height uint32, paidOn int64) error {
+	const funcName = "persistLastPaymentInfo"
+	pbkt, err := fetchPoolBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, height)
+	err = pbkt.Put(lastPaymentHeight, b)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist last payment height: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	err = pbkt.Put(lastPaymentPaidOn, nanoToBigEndianBytes(paidOn))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist last payment "+
+			"paid on time: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	return nil
+}
+
 // persistLastPaymentInfo stores the last payment height and paidOn timestamp
 // in the database.
 func (db *BoltDB) persistLastPaymentInfo(height uint32, paidOn int64) error {
-	funcName := "persistLastPaymentInfo"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		return putLastPaymentInfo(tx, height, paidOn)
+	})
+}
+
+// loadLastPaymentInfo retrieves the last payment height and paidOn timestamp
+// from the database.
+func (db *BoltDB) loadLastPaymentInfo() (uint32, int64, error) {
+	var height uint32
+	var paidOn int64
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		var err error
+		height, paidOn, err = boltMetadata{tx}.loadLastPaymentInfo()
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return height, paidOn, nil
+}
+
+// putLastPaymentCreatedOn stores the last payment createdOn timestamp in the
+// provided transaction.
+func putLastPaymentCreatedOn(tx *bolt.Tx, createdOn int64) error {
+	const funcName = "persistLastPaymentCreatedOn"
+	pbkt, err := fetchPoolBucket(tx)
+	if err != nil {
+		return err
+	}
+	err = pbkt.Put(lastPaymentCreatedOn, nanoToBigEndianBytes(createdOn))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist last payment "+
+			"created-on time: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// persistLastPaymentCreatedOn stores the last payment createdOn timestamp in
+// the database.
+func (db *BoltDB) persistLastPaymentCreatedOn(createdOn int64) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		return putLastPaymentCreatedOn(tx, createdOn)
+	})
+}
+
+// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp from
+// the database.
+func (db *BoltDB) loadLastPaymentCreatedOn() (int64, error) {
+	var createdOn int64
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		var err error
+		createdOn, err = boltMetadata{tx}.loadLastPaymentCreatedOn()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return createdOn, nil
+}
+
+// persistChainTip stores the hash and height of the last processed chain
+// tip in the database.
+func (db *BoltDB) persistChainTip(hash string, height uint32) error {
+	const funcName = "persistChainTip"
 	return db.DB.Update(func(tx *bolt.Tx) error {
 		pbkt, err := fetchPoolBucket(tx)
 		if err != nil {
 			return err
 		}
 
-		b := make([]byte, 4)
-		binary.LittleEndian.PutUint32(b, height)
-		err = pbkt.Put(lastPaymentHeight, b)
+		err = pbkt.Put(chainTipHash, []byte(hash))
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to persist last payment height: %v",
+			desc := fmt.Sprintf("%s: unable to persist chain tip hash: %v",
 				funcName, err)
 			return errs.DBError(errs.PersistEntry, desc)
 		}
 
-		err = pbkt.Put(lastPaymentPaidOn, nanoToBigEndianBytes(paidOn))
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, height)
+		err = pbkt.Put(chainTipHeight, b)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to persist last payment "+
-				"paid on time: %v", funcName, err)
+			desc := fmt.Sprintf("%s: unable to persist chain tip height: %v",
+				funcName, err)
 			return errs.DBError(errs.PersistEntry, desc)
 		}
 
@@ -346,83 +636,83 @@ func (db *BoltDB) persistLastPaymentInfo(height uint32, paidOn int64) error {
 	})
 }
 
-// loadLastPaymentInfo retrieves the last payment height and paidOn timestamp
-// from the database.
-func (db *BoltDB) loadLastPaymentInfo() (uint32, int64, error) {
-	funcName := "loadLastPaymentInfo"
+// loadChainTip retrieves the hash and height of the last processed chain
+// tip from the database.
+func (db *BoltDB) loadChainTip() (string, uint32, error) {
+	var hash string
 	var height uint32
-	var paidOn int64
 	err := db.DB.View(func(tx *bolt.Tx) error {
-		pbkt, err := fetchPoolBucket(tx)
-		if err != nil {
-			return err
-		}
-
-		lastPaymentHeightB := pbkt.Get(lastPaymentHeight)
-		lastPaymentPaidOnB := pbkt.Get(lastPaymentPaidOn)
-
-		if lastPaymentHeightB == nil || lastPaymentPaidOnB == nil {
-			desc := fmt.Sprintf("%s: last payment info not initialized", funcName)
-			return errs.DBError(errs.ValueNotFound, desc)
-		}
-
-		height = binary.LittleEndian.Uint32(lastPaymentHeightB)
-		paidOn = int64(bigEndianBytesToNano(lastPaymentPaidOnB))
-
-		return nil
+		var err error
+		hash, height, err = boltMetadata{tx}.loadChainTip()
+		return err
 	})
-
 	if err != nil {
-		return 0, 0, err
+		return "", 0, err
 	}
 
-	return height, paidOn, nil
+	return hash, height, nil
 }
 
-// persistLastPaymentCreatedOn stores the last payment createdOn timestamp in
-// the database.
-func (db *BoltDB) persistLastPaymentCreatedOn(createdOn int64) error {
-	funcName := "persistLastPaymentCreatedOn"
+// persistPendingPayout stores the payout transaction awaiting an external
+// signature, replacing any existing one.
+func (db *BoltDB) persistPendingPayout(payout *PendingPayout) error {
+	const funcName = "persistPendingPayout"
 	return db.DB.Update(func(tx *bolt.Tx) error {
 		pbkt, err := fetchPoolBucket(tx)
 		if err != nil {
 			return err
 		}
-		err = pbkt.Put(lastPaymentCreatedOn, nanoToBigEndianBytes(createdOn))
+
+		b, err := json.Marshal(payout)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal pending payout: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = pbkt.Put(pendingPayoutK, b)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to persist last payment "+
-				"created-on time: %v", funcName, err)
+			desc := fmt.Sprintf("%s: unable to persist pending payout: %v",
+				funcName, err)
 			return errs.DBError(errs.PersistEntry, desc)
 		}
 		return nil
 	})
 }
 
-// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp from
-// the database.
-func (db *BoltDB) loadLastPaymentCreatedOn() (int64, error) {
-	funcName := "loadLastPaymentCreatedOn"
-	var createdOn int64
+// fetchPendingPayout retrieves the payout transaction awaiting an external
+// signature. Returns an error if there is none.
+func (db *BoltDB) fetchPendingPayout() (*PendingPayout, error) {
+	var payout *PendingPayout
 	err := db.DB.View(func(tx *bolt.Tx) error {
+		var err error
+		payout, err = boltMetadata{tx}.fetchPendingPayout()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+// deletePendingPayout removes the payout transaction awaiting an external
+// signature. Deleting a pending payout which does not exist is a no-op.
+func (db *BoltDB) deletePendingPayout() error {
+	const funcName = "deletePendingPayout"
+	return db.DB.Update(func(tx *bolt.Tx) error {
 		pbkt, err := fetchPoolBucket(tx)
 		if err != nil {
 			return err
 		}
-		lastPaymentCreatedOnB := pbkt.Get(lastPaymentCreatedOn)
-		if lastPaymentCreatedOnB == nil {
-			desc := fmt.Sprintf("%s: last payment created-on not initialized",
-				funcName)
-			return errs.DBError(errs.ValueNotFound, desc)
+
+		err = pbkt.Delete(pendingPayoutK)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete pending payout: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
 		}
-		createdOn = int64(bigEndianBytesToNano(lastPaymentCreatedOnB))
 		return nil
 	})
-
-	if err != nil {
-		return 0, err
-	}
-
-	return createdOn, nil
 }
 
 // Close closes the Bolt database.
@@ -476,14 +766,35 @@ func (db *BoltDB) purge() error {
 			return errs.DBError(errs.DeleteEntry, desc)
 		}
 
-		err = pbkt.Delete(versionK)
+		err = pbkt.Delete(chainTipHash)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to delete db "+
-				"version: %v", funcName, err)
+			desc := fmt.Sprintf("%s: unable to delete chain tip hash: %v",
+				funcName, err)
 			return errs.DBError(errs.DeleteEntry, desc)
 		}
 
-		// Delete all pool buckets.
+		err = pbkt.Delete(chainTipHeight)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete chain tip height: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.Delete(pendingPayoutK)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete pending payout: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.Delete(versionK)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete db "+
+				"version: %v", funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		// Delete all pool buckets.
 		err = pbkt.DeleteBucket(paymentArchiveBkt)
 		if err != nil {
 			desc := fmt.Sprintf("%s: unable to delete payment archive "+
@@ -526,21 +837,183 @@ func (db *BoltDB) purge() error {
 			return errs.DBError(errs.DeleteEntry, desc)
 		}
 
+		err = pbkt.DeleteBucket(apiTokenBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete api token bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(adminUserBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete admin user bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(auditLogBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete audit log bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(banBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete ban bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(ledgerBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete ledger bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(blockEventBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete block event bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(webhookBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete webhook bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(webhookDeliveryBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete webhook delivery "+
+				"bucket: %v", funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(workerBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete worker bucket: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		err = pbkt.DeleteBucket(workerEventBkt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to delete worker event "+
+				"bucket: %v", funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
 		return nil
 	})
 }
 
-// httpBackup streams a backup of the entire database over the provided HTTP
-// response writer.
+// httpBackup streams an archive of the entire database over the provided
+// HTTP response writer.
 func (db *BoltDB) httpBackup(w http.ResponseWriter) error {
-	err := db.DB.View(func(tx *bolt.Tx) error {
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Disposition", `attachment; filename="backup.db"`)
-		w.Header().Set("Content-Length", strconv.Itoa(int(tx.Size())))
-		_, err := tx.WriteTo(w)
-		return err
+	return serveArchive(db, w)
+}
+
+// archiveBuckets maps the archive record kinds to the buckets storing them,
+// in export order.
+var archiveBuckets = []struct {
+	kind   string
+	bucket []byte
+}{
+	{accountRecord, accountBkt},
+	{paymentRecord, paymentBkt},
+	{archivedPaymentRecord, paymentArchiveBkt},
+	{shareRecord, shareBkt},
+	{acceptedWorkRecord, workBkt},
+	{jobRecord, jobBkt},
+	{hashDataRecord, hashDataBkt},
+	{apiTokenRecord, apiTokenBkt},
+	{adminUserRecord, adminUserBkt},
+	{auditEntryRecord, auditLogBkt},
+	{banRecord, banBkt},
+	{ledgerEntryRecord, ledgerBkt},
+	{blockEventRecord, blockEventBkt},
+	{webhookRecord, webhookBkt},
+	{webhookDeliveryRecord, webhookDeliveryBkt},
+	{workerRecord, workerBkt},
+	{workerEventRecord, workerEventBkt},
+}
+
+// exportRecords streams the pool metadata, if requested, and every persisted
+// entity through the provided export funcs within a single transaction.
+func (db *BoltDB) exportRecords(metaFn exportMetadataFunc, fn exportFunc) error {
+	return db.DB.View(func(tx *bolt.Tx) error {
+		if metaFn != nil {
+			meta, err := fetchArchiveMetadata(boltMetadata{tx})
+			if err != nil {
+				return err
+			}
+			err = metaFn(meta)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range archiveBuckets {
+			bkt, err := fetchBucket(tx, entry.bucket)
+			if err != nil {
+				return err
+			}
+			err = bkt.ForEach(func(_, v []byte) error {
+				return fn(entry.kind, json.RawMessage(v))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// importRecords persists the provided archive records within a single
+// transaction. Returns an error if any of the entities already exists.
+func (db *BoltDB) importRecords(records []*archiveRecord) error {
+	const funcName = "importRecords"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		for _, record := range records {
+			var bucket []byte
+			for _, entry := range archiveBuckets {
+				if entry.kind == record.Kind {
+					bucket = entry.bucket
+					break
+				}
+			}
+			entity, id, err := decodeArchiveRecord(record)
+			if err != nil {
+				return err
+			}
+			bkt, err := fetchBucket(tx, bucket)
+			if err != nil {
+				return err
+			}
+			if bkt.Get([]byte(id)) != nil {
+				desc := fmt.Sprintf("%s: %s %s already exists", funcName,
+					record.Kind, id)
+				return errs.DBError(errs.ValueFound, desc)
+			}
+			b, err := json.Marshal(entity)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to marshal %s bytes: %v",
+					funcName, record.Kind, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			err = bkt.Put([]byte(id), b)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to persist %s: %v",
+					funcName, record.Kind, err)
+				return errs.DBError(errs.PersistEntry, desc)
+			}
+		}
+		return nil
 	})
-	return err
 }
 
 // fetchAcceptedWork fetches the accepted work referenced by the provided id.
@@ -676,8 +1149,8 @@ func (db *BoltDB) listMinedWork() ([]*AcceptedWork, error) {
 	return minedWork, nil
 }
 
-// fetchUnconfirmedWork returns all work which is not confirmed as mined with
-// height less than the provided height.
+// fetchUnconfirmedWork returns all work which is neither confirmed as mined
+// nor orphaned with height less than the provided height.
 func (db *BoltDB) fetchUnconfirmedWork(height uint32) ([]*AcceptedWork, error) {
 	toReturn := make([]*AcceptedWork, 0)
 	err := db.DB.View(func(tx *bolt.Tx) error {
@@ -701,7 +1174,7 @@ func (db *BoltDB) fetchUnconfirmedWork(height uint32) ([]*AcceptedWork, error) {
 					return err
 				}
 
-				if !work.Confirmed {
+				if !work.Confirmed && work.Status != WorkOrphaned {
 					toReturn = append(toReturn, &work)
 				}
 			}
@@ -917,21 +1390,92 @@ func (db *BoltDB) fetchPayment(id string) (*Payment, error) {
 	return &payment, err
 }
 
-// PersistPayment saves a payment to the database.
-func (db *BoltDB) PersistPayment(pmt *Payment) error {
+// putPayment saves a payment in the provided transaction. Returns an error
+// if a payment already exists with the same ID.
+func putPayment(tx *bolt.Tx, pmt *Payment) error {
 	const funcName = "PersistPayment"
+	bkt, err := fetchBucket(tx, paymentBkt)
+	if err != nil {
+		return err
+	}
+
+	// Do not persist already existing payment.
+	if bkt.Get([]byte(pmt.UUID)) != nil {
+		desc := fmt.Sprintf("%s: payment %s already exists", funcName,
+			pmt.UUID)
+		return errs.DBError(errs.ValueFound, desc)
+	}
+
+	b, err := json.Marshal(pmt)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to marshal payment bytes: %v",
+			funcName, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+	err = bkt.Put([]byte(pmt.UUID), b)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist payment bytes: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// PersistPayment saves a payment to the database. Returns an error if a
+// payment already exists with the same ID.
+func (db *BoltDB) PersistPayment(pmt *Payment) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		return putPayment(tx, pmt)
+	})
+}
+
+// persistPayments saves the provided payments of a block reward along with
+// the ledger entries booking them within a single transaction. The last
+// payment created-on time is updated and shares created before the provided
+// time are pruned in the same transaction. Returns an error if any of the
+// payments or entries already exists, in which case none of them are
+// persisted.
+func (db *BoltDB) persistPayments(payments []*Payment, entries []*LedgerEntry, lastPmtCreatedOn int64, pruneSharesBefore int64) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		for _, pmt := range payments {
+			err := putPayment(tx, pmt)
+			if err != nil {
+				return err
+			}
+		}
+		err := putLedgerEntries(tx, entries)
+		if err != nil {
+			return err
+		}
+		err = putLastPaymentCreatedOn(tx, lastPmtCreatedOn)
+		if err != nil {
+			return err
+		}
+		return deleteSharesBefore(tx, pruneSharesBefore)
+	})
+}
+
+// updatePayment persists the updated payment to the database. Updating a
+// payment which does not exist is a no-op.
+func (db *BoltDB) updatePayment(pmt *Payment) error {
+	const funcName = "updatePayment"
 	return db.DB.Update(func(tx *bolt.Tx) error {
 		bkt, err := fetchBucket(tx, paymentBkt)
 		if err != nil {
 			return err
 		}
+
+		id := []byte(pmt.UUID)
+		if bkt.Get(id) == nil {
+			return nil
+		}
 		b, err := json.Marshal(pmt)
 		if err != nil {
 			desc := fmt.Sprintf("%s: unable to marshal payment bytes: %v",
 				funcName, err)
 			return errs.DBError(errs.Parse, desc)
 		}
-		err = bkt.Put([]byte(pmt.UUID), b)
+		err = bkt.Put(id, b)
 		if err != nil {
 			desc := fmt.Sprintf("%s: unable to persist payment bytes: %v",
 				funcName, err)
@@ -941,51 +1485,91 @@ func (db *BoltDB) PersistPayment(pmt *Payment) error {
 	})
 }
 
-// updatePayment persists the updated payment to the database.
-func (db *BoltDB) updatePayment(pmt *Payment) error {
-	return db.PersistPayment(pmt)
-}
-
 // deletePayment purges the referenced payment from the database. Note that
 // archived payments cannot be deleted.
 func (db *BoltDB) deletePayment(id string) error {
 	return deleteEntry(db, paymentBkt, id)
 }
 
+// archivePayment removes the associated payment from active payments and
+// archives it in the provided transaction.
+func archivePayment(tx *bolt.Tx, pmt *Payment) error {
+	const funcName = "ArchivePayment"
+	pbkt, err := fetchBucket(tx, paymentBkt)
+	if err != nil {
+		return err
+	}
+	abkt, err := fetchBucket(tx, paymentArchiveBkt)
+	if err != nil {
+		return err
+	}
+
+	// Remove the active payment record.
+	err = pbkt.Delete([]byte(pmt.UUID))
+	if err != nil {
+		return err
+	}
+
+	// Create a new payment to add to the archive, keeping the payout
+	// details.
+	aPmt := NewPayment(pmt.Account, pmt.Source, pmt.Amount, pmt.Height,
+		pmt.EstimatedMaturity)
+	aPmt.PaidOnHeight = pmt.PaidOnHeight
+	aPmt.TransactionID = pmt.TransactionID
+	aPmtB, err := json.Marshal(aPmt)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to marshal payment bytes: %v",
+			funcName, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+
+	err = abkt.Put([]byte(aPmt.UUID), aPmtB)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to archive payment entry: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
 // ArchivePayment removes the associated payment from active payments and archives it.
 func (db *BoltDB) ArchivePayment(pmt *Payment) error {
-	const funcName = "ArchivePayment"
 	return db.DB.Update(func(tx *bolt.Tx) error {
-		pbkt, err := fetchBucket(tx, paymentBkt)
-		if err != nil {
-			return err
+		return archivePayment(tx, pmt)
+	})
+}
+
+// archivePayout archives the provided payments paid by a payout, books the
+// provided ledger entries of the payout, records the last payment info and
+// removes the pending payout within a single transaction. Returns an error
+// if any of the entries already exists, in which case nothing is persisted.
+func (db *BoltDB) archivePayout(payments []*Payment, entries []*LedgerEntry, height uint32, paidOn int64) error {
+	const funcName = "archivePayout"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		for _, pmt := range payments {
+			err := archivePayment(tx, pmt)
+			if err != nil {
+				return err
+			}
 		}
-		abkt, err := fetchBucket(tx, paymentArchiveBkt)
+		err := putLedgerEntries(tx, entries)
 		if err != nil {
 			return err
 		}
-
-		// Remove the active payment record.
-		err = pbkt.Delete([]byte(pmt.UUID))
+		err = putLastPaymentInfo(tx, height, paidOn)
 		if err != nil {
 			return err
 		}
 
-		// Create a new payment to add to the archive.
-		aPmt := NewPayment(pmt.Account, pmt.Source, pmt.Amount, pmt.Height,
-			pmt.EstimatedMaturity)
-		aPmtB, err := json.Marshal(aPmt)
+		pbkt, err := fetchPoolBucket(tx)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to marshal payment bytes: %v",
-				funcName, err)
-			return errs.DBError(errs.Parse, desc)
+			return err
 		}
-
-		err = abkt.Put([]byte(aPmt.UUID), aPmtB)
+		err = pbkt.Delete(pendingPayoutK)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to archive payment entry: %v",
+			desc := fmt.Sprintf("%s: unable to delete pending payout: %v",
 				funcName, err)
-			return errs.DBError(errs.PersistEntry, desc)
+			return errs.DBError(errs.DeleteEntry, desc)
 		}
 		return nil
 	})
@@ -1092,6 +1676,38 @@ func (db *BoltDB) pendingPaymentsForBlockHash(blockHash string) (uint32, error)
 	return count, nil
 }
 
+// archivedPaymentsForBlockHash returns the number of archived payments with
+// the provided block hash as their source.
+func (db *BoltDB) archivedPaymentsForBlockHash(blockHash string) (uint32, error) {
+	funcName := "archivedPaymentsForBlockHash"
+	var count uint32
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		abkt, err := fetchBucket(tx, paymentArchiveBkt)
+		if err != nil {
+			return err
+		}
+
+		return abkt.ForEach(func(_, v []byte) error {
+			var payment Payment
+			err := json.Unmarshal(v, &payment)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal payment: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			if payment.Source != nil &&
+				payment.Source.BlockHash == blockHash {
+				count++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // archivedPayments fetches all archived payments. List is ordered, most
 // recent comes first.
 func (db *BoltDB) archivedPayments() ([]*Payment, error) {
@@ -1228,6 +1844,50 @@ func (db *BoltDB) PersistShare(s *Share) error {
 	})
 }
 
+// aggregateShare adds the weight of the provided share to the persisted
+// share with the same ID, persisting the share if it does not exist yet.
+func (db *BoltDB) aggregateShare(s *Share) error {
+	const funcName = "aggregateShare"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, shareBkt)
+		if err != nil {
+			return err
+		}
+
+		share := &Share{
+			UUID:      s.UUID,
+			Account:   s.Account,
+			Weight:    new(big.Rat).Set(s.Weight),
+			CreatedOn: s.CreatedOn,
+		}
+		v := bkt.Get([]byte(s.UUID))
+		if v != nil {
+			var existing Share
+			err := json.Unmarshal(v, &existing)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal share: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			share.Weight.Add(share.Weight, existing.Weight)
+		}
+
+		sBytes, err := json.Marshal(share)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal share bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(s.UUID), sBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist share entry: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
 // ppsEligibleShares fetches all shares created before or at the provided time.
 func (db *BoltDB) ppsEligibleShares(max int64) ([]*Share, error) {
 	funcName := "ppsEligibleShares"
@@ -1309,36 +1969,41 @@ func (db *BoltDB) pplnsEligibleShares(min int64) ([]*Share, error) {
 // pruneShares removes shares with a createdOn time earlier than the provided
 // time.
 func (db *BoltDB) pruneShares(minNano int64) error {
-	funcName := "pruneShares"
-	minB := nanoToBigEndianBytes(minNano)
-
 	return db.DB.Update(func(tx *bolt.Tx) error {
-		bkt, err := fetchBucket(tx, shareBkt)
+		return deleteSharesBefore(tx, minNano)
+	})
+}
+
+// deleteSharesBefore removes shares with a createdOn time earlier than the
+// provided time in the provided transaction.
+func deleteSharesBefore(tx *bolt.Tx, minNano int64) error {
+	const funcName = "pruneShares"
+	minB := nanoToBigEndianBytes(minNano)
+	bkt, err := fetchBucket(tx, shareBkt)
+	if err != nil {
+		return err
+	}
+	toDelete := [][]byte{}
+	cursor := bkt.Cursor()
+	createdOnB := make([]byte, 8)
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		_, err := hex.Decode(createdOnB, k[:16])
 		if err != nil {
-			return err
+			desc := fmt.Sprintf("%s: unable to decode share created-on "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Decode, desc)
 		}
-		toDelete := [][]byte{}
-		cursor := bkt.Cursor()
-		createdOnB := make([]byte, 8)
-		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
-			_, err := hex.Decode(createdOnB, k[:16])
-			if err != nil {
-				desc := fmt.Sprintf("%s: unable to decode share created-on "+
-					"bytes: %v", funcName, err)
-				return errs.DBError(errs.Decode, desc)
-			}
-			if bytes.Compare(minB, createdOnB) > 0 {
-				toDelete = append(toDelete, k)
-			}
+		if bytes.Compare(minB, createdOnB) > 0 {
+			toDelete = append(toDelete, k)
 		}
-		for _, entry := range toDelete {
-			err := bkt.Delete(entry)
-			if err != nil {
-				return err
-			}
+	}
+	for _, entry := range toDelete {
+		err := bkt.Delete(entry)
+		if err != nil {
+			return err
 		}
-		return nil
-	})
+	}
+	return nil
 }
 
 // persistHashData saves the provided hash data to the database.
@@ -1507,3 +2172,1002 @@ func (db *BoltDB) pruneHashData(minNano int64) error {
 		return nil
 	})
 }
+
+// persistAPIToken saves the provided api token to the database.
+func (db *BoltDB) persistAPIToken(token *APIToken) error {
+	const funcName = "persistAPIToken"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, apiTokenBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing tokens.
+		if bkt.Get([]byte(token.UUID)) != nil {
+			desc := fmt.Sprintf("%s: api token %s already exists", funcName,
+				token.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		tBytes, err := json.Marshal(token)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal api token bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(token.UUID), tBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist api token: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// fetchAPIToken fetches the api token associated with the provided id.
+func (db *BoltDB) fetchAPIToken(id string) (*APIToken, error) {
+	const funcName = "fetchAPIToken"
+	var token APIToken
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, apiTokenBkt)
+		if err != nil {
+			return err
+		}
+
+		v := bkt.Get([]byte(id))
+		if v == nil {
+			desc := fmt.Sprintf("%s: no api token found for id %s",
+				funcName, id)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		err = json.Unmarshal(v, &token)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to unmarshal api token: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, err
+}
+
+// deleteAPIToken purges the referenced api token from the database.
+func (db *BoltDB) deleteAPIToken(id string) error {
+	return deleteEntry(db, apiTokenBkt, id)
+}
+
+// fetchAPITokensForAccount fetches all api tokens of the provided account.
+// List is ordered, oldest first.
+func (db *BoltDB) fetchAPITokensForAccount(accountID string) ([]*APIToken, error) {
+	const funcName = "fetchAPITokensForAccount"
+	tokens := make([]*APIToken, 0)
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, apiTokenBkt)
+		if err != nil {
+			return err
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var token APIToken
+			err = json.Unmarshal(v, &token)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal api token: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+
+			if token.AccountID == accountID {
+				tokens = append(tokens, &token)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens are keyed by their hash, order them by creation time.
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].CreatedOn < tokens[j].CreatedOn
+	})
+	return tokens, nil
+}
+
+// persistAdminUser saves the provided admin user to the database.
+func (db *BoltDB) persistAdminUser(user *AdminUser) error {
+	const funcName = "persistAdminUser"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, adminUserBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing admin users.
+		if bkt.Get([]byte(user.UUID)) != nil {
+			desc := fmt.Sprintf("%s: admin user %s already exists", funcName,
+				user.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		uBytes, err := json.Marshal(user)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal admin user bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(user.UUID), uBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist admin user: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// updateAdminUser persists the updated admin user to the database.
+func (db *BoltDB) updateAdminUser(user *AdminUser) error {
+	const funcName = "updateAdminUser"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, adminUserBkt)
+		if err != nil {
+			return err
+		}
+
+		// Assert the admin user provided exists before updating.
+		id := []byte(user.UUID)
+		if bkt.Get(id) == nil {
+			desc := fmt.Sprintf("%s: admin user %s not found",
+				funcName, user.UUID)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		uBytes, err := json.Marshal(user)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal admin user bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		err = bkt.Put(id, uBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist admin user: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// fetchAdminUser fetches the admin user with the provided name.
+func (db *BoltDB) fetchAdminUser(name string) (*AdminUser, error) {
+	const funcName = "fetchAdminUser"
+	var user AdminUser
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, adminUserBkt)
+		if err != nil {
+			return err
+		}
+
+		v := bkt.Get([]byte(name))
+		if v == nil {
+			desc := fmt.Sprintf("%s: no admin user found for name %s",
+				funcName, name)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		err = json.Unmarshal(v, &user)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to unmarshal admin user: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, err
+}
+
+// deleteAdminUser purges the referenced admin user from the database.
+func (db *BoltDB) deleteAdminUser(name string) error {
+	return deleteEntry(db, adminUserBkt, name)
+}
+
+// listAdminUsers fetches all admin users.
+func (db *BoltDB) listAdminUsers() ([]*AdminUser, error) {
+	const funcName = "listAdminUsers"
+	users := make([]*AdminUser, 0)
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, adminUserBkt)
+		if err != nil {
+			return err
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var user AdminUser
+			err = json.Unmarshal(v, &user)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal admin user: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			users = append(users, &user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// persistAuditEntry saves the provided audit entry to the database.
+func (db *BoltDB) persistAuditEntry(entry *AuditEntry) error {
+	const funcName = "persistAuditEntry"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, auditLogBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing audit entries.
+		if bkt.Get([]byte(entry.UUID)) != nil {
+			desc := fmt.Sprintf("%s: audit entry %s already exists", funcName,
+				entry.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		eBytes, err := json.Marshal(entry)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal audit entry bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(entry.UUID), eBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist audit entry: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// fetchAuditEntries fetches the most recent audit entries, newest first.
+// All entries are returned if the provided limit is not positive.
+func (db *BoltDB) fetchAuditEntries(limit int) ([]*AuditEntry, error) {
+	const funcName = "fetchAuditEntries"
+	entries := make([]*AuditEntry, 0)
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, auditLogBkt)
+		if err != nil {
+			return err
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+			var entry AuditEntry
+			err = json.Unmarshal(v, &entry)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal audit entry: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// persistBan saves the provided ban to the database. Persisting an existing
+// ban replaces it.
+func (db *BoltDB) persistBan(ban *Ban) error {
+	const funcName = "persistBan"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, banBkt)
+		if err != nil {
+			return err
+		}
+
+		bBytes, err := json.Marshal(ban)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal ban bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(ban.UUID), bBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist ban: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// deleteBan purges the referenced ban from the database.
+func (db *BoltDB) deleteBan(id string) error {
+	return deleteEntry(db, banBkt, id)
+}
+
+// listBans fetches all bans. List is ordered, oldest first.
+func (db *BoltDB) listBans() ([]*Ban, error) {
+	const funcName = "listBans"
+	bans := make([]*Ban, 0)
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, banBkt)
+		if err != nil {
+			return err
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var ban Ban
+			err = json.Unmarshal(v, &ban)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal ban: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			bans = append(bans, &ban)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Bans are keyed by the banned id, order them by creation time.
+	sort.SliceStable(bans, func(i, j int) bool {
+		return bans[i].CreatedOn < bans[j].CreatedOn
+	})
+	return bans, nil
+}
+
+// putLedgerEntries persists the provided ledger entries in the provided
+// transaction. Returns an error if any of the entries already exists.
+func putLedgerEntries(tx *bolt.Tx, entries []*LedgerEntry) error {
+	const funcName = "persistLedgerEntries"
+	bkt, err := fetchBucket(tx, ledgerBkt)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		// Ledger entries are never replaced.
+		if bkt.Get([]byte(entry.UUID)) != nil {
+			desc := fmt.Sprintf("%s: ledger entry %s already exists",
+				funcName, entry.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		eBytes, err := json.Marshal(entry)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal ledger entry "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(entry.UUID), eBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist ledger entry: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+	return nil
+}
+
+// persistLedgerEntries saves the provided ledger entries to the database
+// within a single transaction. Returns an error if any of the entries
+// already exists, in which case none of them are persisted.
+func (db *BoltDB) persistLedgerEntries(entries []*LedgerEntry) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		return putLedgerEntries(tx, entries)
+	})
+}
+
+// forEachLedgerEntry calls the provided function with every persisted ledger
+// entry.
+func (db *BoltDB) forEachLedgerEntry(funcName string, fn func(*LedgerEntry)) error {
+	return db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, ledgerBkt)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(_, v []byte) error {
+			var entry LedgerEntry
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal ledger "+
+					"entry: %v", funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			fn(&entry)
+			return nil
+		})
+	})
+}
+
+// fetchLedgerEntries fetches the most recent ledger entries debiting or
+// crediting the provided ledger account, or all entries if the account is
+// empty, newest first. All entries are returned if the provided limit is not
+// positive.
+func (db *BoltDB) fetchLedgerEntries(account string, limit int) ([]*LedgerEntry, error) {
+	const funcName = "fetchLedgerEntries"
+	entries := make([]*LedgerEntry, 0)
+	err := db.forEachLedgerEntry(funcName, func(entry *LedgerEntry) {
+		if account == "" || entry.Debit == account ||
+			entry.Credit == account {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ledger entries are keyed by reference, they are ordered by creation
+	// time once fetched.
+	sortLedgerEntries(entries)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// fetchLedgerBalances returns the balance of every ledger account with
+// ledger entries.
+func (db *BoltDB) fetchLedgerBalances() (map[string]dcrutil.Amount, error) {
+	const funcName = "fetchLedgerBalances"
+	balances := make(map[string]dcrutil.Amount)
+	err := db.forEachLedgerEntry(funcName, func(entry *LedgerEntry) {
+		balances[entry.Debit] -= entry.Amount
+		balances[entry.Credit] += entry.Amount
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// fetchAccountBalances returns the balance of the provided ledger account
+// along with the amount paid out to it, or of every ledger account with
+// ledger entries if it is empty.
+func (db *BoltDB) fetchAccountBalances(account string) (map[string]*AccountBalance, error) {
+	const funcName = "fetchAccountBalances"
+	balances := make(map[string]*AccountBalance)
+	err := db.forEachLedgerEntry(funcName, func(entry *LedgerEntry) {
+		if account == "" || entry.Debit == account ||
+			entry.Credit == account {
+			bookAccountBalance(balances, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filterAccountBalances(balances, account), nil
+}
+
+// persistBlockEvent saves the provided block event to the database.
+func (db *BoltDB) persistBlockEvent(event *BlockEvent) error {
+	const funcName = "persistBlockEvent"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, blockEventBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing block events.
+		if bkt.Get([]byte(event.UUID)) != nil {
+			desc := fmt.Sprintf("%s: block event %s already exists",
+				funcName, event.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		eBytes, err := json.Marshal(event)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal block event "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(event.UUID), eBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist block event: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// updateBlockEvent persists the updated block event to the database.
+func (db *BoltDB) updateBlockEvent(event *BlockEvent) error {
+	const funcName = "updateBlockEvent"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, blockEventBkt)
+		if err != nil {
+			return err
+		}
+
+		// Assert the block event provided exists before updating.
+		id := []byte(event.UUID)
+		if bkt.Get(id) == nil {
+			desc := fmt.Sprintf("%s: block event %s not found",
+				funcName, event.UUID)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		eBytes, err := json.Marshal(event)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal block event "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put(id, eBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist block event: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// deleteBlockEvent purges the referenced block event from the database.
+func (db *BoltDB) deleteBlockEvent(id string) error {
+	return deleteEntry(db, blockEventBkt, id)
+}
+
+// fetchBlockEvents fetches all block events pending processing. List is
+// ordered, oldest first.
+func (db *BoltDB) fetchBlockEvents() ([]*BlockEvent, error) {
+	const funcName = "fetchBlockEvents"
+	events := make([]*BlockEvent, 0)
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, blockEventBkt)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(_, v []byte) error {
+			var event BlockEvent
+			err := json.Unmarshal(v, &event)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal block "+
+					"event: %v", funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			events = append(events, &event)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortBlockEvents(events)
+	return events, nil
+}
+
+// persistWebhook saves the provided webhook to the database.
+func (db *BoltDB) persistWebhook(hook *Webhook) error {
+	const funcName = "persistWebhook"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, webhookBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing webhooks.
+		if bkt.Get([]byte(hook.UUID)) != nil {
+			desc := fmt.Sprintf("%s: webhook %s already exists", funcName,
+				hook.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		hBytes, err := json.Marshal(hook)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal webhook bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(hook.UUID), hBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist webhook: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// fetchWebhook fetches the webhook associated with the provided id.
+func (db *BoltDB) fetchWebhook(id string) (*Webhook, error) {
+	const funcName = "fetchWebhook"
+	var hook Webhook
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, webhookBkt)
+		if err != nil {
+			return err
+		}
+
+		v := bkt.Get([]byte(id))
+		if v == nil {
+			desc := fmt.Sprintf("%s: no webhook found for id %s",
+				funcName, id)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		err = json.Unmarshal(v, &hook)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to unmarshal webhook: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// deleteWebhook purges the referenced webhook from the database.
+func (db *BoltDB) deleteWebhook(id string) error {
+	return deleteEntry(db, webhookBkt, id)
+}
+
+// fetchWebhooksForAccount fetches all webhooks of the provided account.
+// List is ordered, oldest first.
+func (db *BoltDB) fetchWebhooksForAccount(accountID string) ([]*Webhook, error) {
+	const funcName = "fetchWebhooksForAccount"
+	hooks := make([]*Webhook, 0)
+
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, webhookBkt)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(_, v []byte) error {
+			var hook Webhook
+			err := json.Unmarshal(v, &hook)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal webhook: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+
+			if hook.AccountID == accountID {
+				hooks = append(hooks, &hook)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Webhooks are keyed by random ids, order them by creation time.
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].CreatedOn < hooks[j].CreatedOn
+	})
+	return hooks, nil
+}
+
+// persistWebhookDelivery saves the provided webhook delivery to the
+// database.
+func (db *BoltDB) persistWebhookDelivery(delivery *WebhookDelivery) error {
+	const funcName = "persistWebhookDelivery"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, webhookDeliveryBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing webhook deliveries.
+		if bkt.Get([]byte(delivery.UUID)) != nil {
+			desc := fmt.Sprintf("%s: webhook delivery %s already exists",
+				funcName, delivery.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		dBytes, err := json.Marshal(delivery)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal webhook delivery "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(delivery.UUID), dBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist webhook delivery: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// updateWebhookDelivery persists the updated webhook delivery to the
+// database.
+func (db *BoltDB) updateWebhookDelivery(delivery *WebhookDelivery) error {
+	const funcName = "updateWebhookDelivery"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, webhookDeliveryBkt)
+		if err != nil {
+			return err
+		}
+
+		// Assert the webhook delivery provided exists before updating.
+		id := []byte(delivery.UUID)
+		if bkt.Get(id) == nil {
+			desc := fmt.Sprintf("%s: webhook delivery %s not found",
+				funcName, delivery.UUID)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		dBytes, err := json.Marshal(delivery)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal webhook delivery "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put(id, dBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist webhook delivery: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// deleteWebhookDelivery purges the referenced webhook delivery from the
+// database.
+func (db *BoltDB) deleteWebhookDelivery(id string) error {
+	return deleteEntry(db, webhookDeliveryBkt, id)
+}
+
+// fetchWebhookDeliveries fetches all webhook deliveries pending delivery.
+// List is ordered, oldest first.
+func (db *BoltDB) fetchWebhookDeliveries() ([]*WebhookDelivery, error) {
+	const funcName = "fetchWebhookDeliveries"
+	deliveries := make([]*WebhookDelivery, 0)
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, webhookDeliveryBkt)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(_, v []byte) error {
+			var delivery WebhookDelivery
+			err := json.Unmarshal(v, &delivery)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal webhook "+
+					"delivery: %v", funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			deliveries = append(deliveries, &delivery)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortWebhookDeliveries(deliveries)
+	return deliveries, nil
+}
+
+// persistWorker saves the provided worker to the database.
+func (db *BoltDB) persistWorker(worker *Worker) error {
+	const funcName = "persistWorker"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, workerBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing workers.
+		if bkt.Get([]byte(worker.UUID)) != nil {
+			desc := fmt.Sprintf("%s: worker %s already exists", funcName,
+				worker.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		wBytes, err := json.Marshal(worker)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal worker bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(worker.UUID), wBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist worker: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// updateWorker persists the updated worker to the database.
+func (db *BoltDB) updateWorker(worker *Worker) error {
+	const funcName = "updateWorker"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, workerBkt)
+		if err != nil {
+			return err
+		}
+
+		// Assert the worker provided exists before updating.
+		id := []byte(worker.UUID)
+		if bkt.Get(id) == nil {
+			desc := fmt.Sprintf("%s: worker %s not found", funcName,
+				worker.UUID)
+			return errs.DBError(errs.ValueNotFound, desc)
+		}
+		wBytes, err := json.Marshal(worker)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal worker bytes: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put(id, wBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist worker: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// deleteWorker purges the referenced worker from the database.
+func (db *BoltDB) deleteWorker(id string) error {
+	return deleteEntry(db, workerBkt, id)
+}
+
+// fetchWorkers fetches all tracked workers. List is ordered by id.
+func (db *BoltDB) fetchWorkers() ([]*Worker, error) {
+	const funcName = "fetchWorkers"
+	workers := make([]*Worker, 0)
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, workerBkt)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(_, v []byte) error {
+			var worker Worker
+			err := json.Unmarshal(v, &worker)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal worker: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			workers = append(workers, &worker)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// persistWorkerEvent saves the provided worker event to the database.
+func (db *BoltDB) persistWorkerEvent(event *WorkerEvent) error {
+	const funcName = "persistWorkerEvent"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, workerEventBkt)
+		if err != nil {
+			return err
+		}
+
+		// Do not persist already existing worker events.
+		if bkt.Get([]byte(event.UUID)) != nil {
+			desc := fmt.Sprintf("%s: worker event %s already exists",
+				funcName, event.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		eBytes, err := json.Marshal(event)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal worker event "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		err = bkt.Put([]byte(event.UUID), eBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist worker event: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return nil
+	})
+}
+
+// fetchWorkerEventsForAccount fetches all worker events of the provided
+// account. List is ordered, most recent comes first.
+func (db *BoltDB) fetchWorkerEventsForAccount(accountID string) ([]*WorkerEvent, error) {
+	const funcName = "fetchWorkerEventsForAccount"
+	events := make([]*WorkerEvent, 0)
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, workerEventBkt)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(_, v []byte) error {
+			var event WorkerEvent
+			err := json.Unmarshal(v, &event)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal worker "+
+					"event: %v", funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			if event.AccountID == accountID {
+				events = append(events, &event)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortWorkerEvents(events)
+	return events, nil
+}
+
+// pruneWorkerEvents prunes all worker events created before the provided
+// minimum time.
+func (db *BoltDB) pruneWorkerEvents(minNano int64) error {
+	const funcName = "pruneWorkerEvents"
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt, err := fetchBucket(tx, workerEventBkt)
+		if err != nil {
+			return err
+		}
+		toDelete := [][]byte{}
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var event WorkerEvent
+			err = json.Unmarshal(v, &event)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal worker "+
+					"event: %v", funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			if minNano > event.CreatedOn {
+				toDelete = append(toDelete, k)
+			}
+		}
+		for _, entry := range toDelete {
+			err := bkt.Delete(entry)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to delete worker event: %v",
+					funcName, err)
+				return errs.DBError(errs.DeleteEntry, desc)
+			}
+		}
+		return nil
+	})
+}
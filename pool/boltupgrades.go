@@ -42,11 +42,45 @@ const (
 	// It adds a hash data bucket to the database.
 	hashDataVersion = 7
 
+	// apiTokenVersion is the eighth version of the database.
+	// It adds an api token bucket to the database.
+	apiTokenVersion = 8
+
+	// adminUserVersion is the ninth version of the database.
+	// It adds admin user and audit log buckets to the database.
+	adminUserVersion = 9
+
+	// banVersion is the tenth version of the database.
+	// It adds a ban bucket to the database.
+	banVersion = 10
+
+	// ledgerVersion is the eleventh version of the database.
+	// It adds a ledger bucket to the database and books the existing
+	// payments to the ledger.
+	ledgerVersion = 11
+
+	// blockEventVersion is the twelfth version of the database.
+	// It adds a block event bucket to the database.
+	blockEventVersion = 12
+
+	// workStatusVersion is the thirteenth version of the database.
+	// It adds a status to accepted work, derived from its confirmation for
+	// existing work.
+	workStatusVersion = 13
+
+	// webhookVersion is the fourteenth version of the database.
+	// It adds webhook and webhook delivery buckets to the database.
+	webhookVersion = 14
+
+	// workerVersion is the fifteenth version of the database.
+	// It adds worker and worker event buckets to the database.
+	workerVersion = 15
+
 	// BoltDBVersion is the latest version of the bolt database that is
 	// understood by the program. Databases with recorded versions higher than
 	// this will fail to open (meaning any upgrades prevent reverting to older
 	// software).
-	BoltDBVersion = paymentUUIDVersion
+	BoltDBVersion = workerVersion
 )
 
 // upgrades maps between old database versions and the upgrade function to
@@ -59,6 +93,14 @@ var upgrades = [...]func(tx *bolt.Tx) error{
 	shareCreatedOnVersion - 1:     shareCreatedOnUpgrade,
 	paymentUUIDVersion - 1:        paymentUUIDUpgrade,
 	hashDataVersion - 1:           hashDataUpgrade,
+	apiTokenVersion - 1:           apiTokenUpgrade,
+	adminUserVersion - 1:          adminUserUpgrade,
+	banVersion - 1:                banUpgrade,
+	ledgerVersion - 1:             ledgerUpgrade,
+	blockEventVersion - 1:         blockEventUpgrade,
+	workStatusVersion - 1:         workStatusUpgrade,
+	webhookVersion - 1:            webhookUpgrade,
+	workerVersion - 1:             workerUpgrade,
 }
 
 func fetchDBVersion(tx *bolt.Tx) (uint32, error) {
@@ -620,6 +662,104 @@ func hashDataUpgrade(tx *bolt.Tx) error {
 	return setDBVersion(tx, newVersion)
 }
 
+func apiTokenUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 7
+	const newVersion = 8
+
+	const funcName = "apiTokenUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, apiTokenBkt)
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
+func adminUserUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 8
+	const newVersion = 9
+
+	const funcName = "adminUserUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, adminUserBkt)
+	if err != nil {
+		return err
+	}
+
+	err = createNestedBucket(pbkt, auditLogBkt)
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
+func banUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 9
+	const newVersion = 10
+
+	const funcName = "banUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, banBkt)
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
 // upgradeDB checks whether any upgrades are necessary before the database is
 // ready for application usage.  If any are, they are performed.
 func upgradeDB(db *BoltDB) error {
@@ -655,3 +795,234 @@ func upgradeDB(db *BoltDB) error {
 		return nil
 	})
 }
+
+func ledgerUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 10
+	const newVersion = 11
+
+	const funcName = "ledgerUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, ledgerBkt)
+	if err != nil {
+		return err
+	}
+
+	// Book the pending and archived payments made before the ledger was
+	// introduced.
+	fetchPayments := func(bucket []byte) ([]*Payment, error) {
+		bkt := pbkt.Bucket(bucket)
+		if bkt == nil {
+			desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+				string(bucket))
+			return nil, errs.DBError(errs.StorageNotFound, desc)
+		}
+
+		pmts := make([]*Payment, 0)
+		err := bkt.ForEach(func(_, v []byte) error {
+			var pmt Payment
+			err := json.Unmarshal(v, &pmt)
+			if err != nil {
+				desc := fmt.Sprintf("%s: unable to unmarshal payment: %v",
+					funcName, err)
+				return errs.DBError(errs.Parse, desc)
+			}
+			pmts = append(pmts, &pmt)
+			return nil
+		})
+		return pmts, err
+	}
+
+	pending, err := fetchPayments(paymentBkt)
+	if err != nil {
+		return err
+	}
+	archived, err := fetchPayments(paymentArchiveBkt)
+	if err != nil {
+		return err
+	}
+
+	err = putLedgerEntries(tx, backfillLedgerEntries(pending, archived))
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
+func blockEventUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 11
+	const newVersion = 12
+
+	const funcName = "blockEventUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, blockEventBkt)
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
+func workStatusUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 12
+	const newVersion = 13
+
+	const funcName = "workStatusUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	wbkt := pbkt.Bucket(workBkt)
+	if wbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(workBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	c := wbkt.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var work AcceptedWork
+		err := json.Unmarshal(v, &work)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to unmarshal accepted work: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+
+		work.setStatus(legacyWorkStatus(work.Confirmed))
+
+		wBytes, err := json.Marshal(work)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal accepted work "+
+				"bytes: %v", funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+
+		err = wbkt.Put(k, wBytes)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist accepted work: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
+func webhookUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 13
+	const newVersion = 14
+
+	const funcName = "webhookUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, webhookBkt)
+	if err != nil {
+		return err
+	}
+	err = createNestedBucket(pbkt, webhookDeliveryBkt)
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
+
+func workerUpgrade(tx *bolt.Tx) error {
+	const oldVersion = 14
+	const newVersion = 15
+
+	const funcName = "workerUpgrade"
+
+	dbVersion, err := fetchDBVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	if dbVersion != oldVersion {
+		desc := fmt.Sprintf("%s: inappropriately called", funcName)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	pbkt := tx.Bucket(poolBkt)
+	if pbkt == nil {
+		desc := fmt.Sprintf("%s: bucket %s not found", funcName,
+			string(poolBkt))
+		return errs.DBError(errs.StorageNotFound, desc)
+	}
+
+	err = createNestedBucket(pbkt, workerBkt)
+	if err != nil {
+		return err
+	}
+	err = createNestedBucket(pbkt, workerEventBkt)
+	if err != nil {
+		return err
+	}
+
+	return setDBVersion(tx, newVersion)
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -27,6 +28,8 @@ var boltDBUpgradeTests = [...]struct {
 	{verifyV5Upgrade, "v4.db.gz"},
 	{verifyV6Upgrade, "v5.db.gz"},
 	// No upgrade test for V6, it is a backwards-compatible upgrade
+	{verifyV11Upgrade, "v10.db.gz"},
+	{verifyV13Upgrade, "v12.db.gz"},
 }
 
 func TestBoltDBUpgrades(t *testing.T) {
@@ -318,3 +321,72 @@ func verifyV6Upgrade(t *testing.T, db *BoltDB) {
 		t.Error(err)
 	}
 }
+
+func verifyV11Upgrade(t *testing.T, db *BoltDB) {
+	verifyBackfilledLedger(t, db, xID, yID)
+}
+
+// verifyBackfilledLedger ensures the ledger entries backfilled from the
+// payments of the v10.db.gz and pg_v4.sql snapshots, made before the ledger
+// was introduced, result in the expected account balances.
+func verifyBackfilledLedger(t *testing.T, db Database, x string, y string) {
+	t.Helper()
+	entries, err := db.fetchLedgerEntries("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 7 {
+		t.Fatalf("expected 7 backfilled ledger entries, got %d", len(entries))
+	}
+
+	balances, err := db.fetchAccountBalances("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]*AccountBalance{
+		x:              {Balance: 3000000000, Paid: 5000000000},
+		y:              {Balance: 2000000000, Paid: 2500000000},
+		PoolFeesK:      {Balance: 100000000},
+		LedgerCoinbase: {Balance: -12600000000},
+		LedgerPayouts:  {Balance: 7500000000},
+	}
+	if len(balances) != len(expected) {
+		t.Fatalf("expected %d account balances, got %d", len(expected),
+			len(balances))
+	}
+	for account, want := range expected {
+		got, ok := balances[account]
+		if !ok {
+			t.Fatalf("expected a balance for account %s", account)
+		}
+		if *got != *want {
+			t.Fatalf("expected balance %v and paid %v for account %s, "+
+				"got %v and %v", want.Balance, want.Paid, account,
+				got.Balance, got.Paid)
+		}
+	}
+}
+
+func verifyV13Upgrade(t *testing.T, db *BoltDB) {
+	mined := AcceptedWorkID(chainhash.Hash{1}.String(), 10)
+	submitted := AcceptedWorkID(chainhash.Hash{2}.String(), 11)
+	verifyWorkStatus(t, db, mined, WorkConfirmed)
+	verifyWorkStatus(t, db, submitted, WorkSubmitted)
+}
+
+// verifyWorkStatus ensures the accepted work of the provided id was assigned
+// the expected status from its legacy confirmed flag.
+func verifyWorkStatus(t *testing.T, db Database, id string, expected string) {
+	t.Helper()
+	work, err := db.fetchAcceptedWork(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Status != expected {
+		t.Fatalf("expected work %s to be %s, got %s", id, expected,
+			work.Status)
+	}
+	if work.Confirmed != (expected == WorkConfirmed) {
+		t.Fatalf("expected the confirmed flag of work %s to be kept", id)
+	}
+}
@@ -3,6 +3,7 @@ package pool
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,6 +19,19 @@ import (
 const (
 	// bufferSize represents the block notification buffer size.
 	bufferSize = 128
+
+	// defaultBlockEventRetryDelay is the default delay before retrying a
+	// block event which failed to process for the first time.
+	defaultBlockEventRetryDelay = time.Second * 5
+
+	// defaultBlockEventMaxRetryDelay is the default maximum delay before
+	// retrying a block event which failed to process.
+	defaultBlockEventMaxRetryDelay = time.Minute * 10
+
+	// maxPayDividendsAttempts is the number of attempts at processing a
+	// connected block event after which failing to pay mature dividends no
+	// longer fails it. The mature payments are paid by a later block.
+	maxPayDividendsAttempts = 3
 )
 
 // ChainStateConfig contains all of the configuration values which should be
@@ -37,10 +51,32 @@ type ChainStateConfig struct {
 	// GetBlockConfirmations fetches the block confirmations with the provided
 	// block hash.
 	GetBlockConfirmations func(context.Context, *chainhash.Hash) (int64, error)
+	// GetBestBlock fetches the chain tip hash and height of the mining node.
+	GetBestBlock func(context.Context) (*chainhash.Hash, int64, error)
+	// GetBlockHash fetches the hash of the main chain block at the provided
+	// height.
+	GetBlockHash func(context.Context, int64) (*chainhash.Hash, error)
+	// GetBlockHeader fetches the block header associated with the provided
+	// block hash.
+	GetBlockHeader func(context.Context, *chainhash.Hash) (*wire.BlockHeader, error)
+	// CoinbaseMaturity represents the number of blocks after which mined
+	// work has a spendable coinbase. Zero disables tracking mined work
+	// maturity.
+	CoinbaseMaturity uint16
+	// RetryDelay represents the delay before retrying a block event which
+	// failed to process for the first time, doubling with every attempt.
+	RetryDelay time.Duration
+	// MaxRetryDelay represents the maximum delay before retrying a block
+	// event which failed to process.
+	MaxRetryDelay time.Duration
 	// Cancel represents the pool's context cancellation function.
 	Cancel context.CancelFunc
 	// SignalCache sends the provided cache update event to the gui cache.
 	SignalCache func(event CacheUpdateEvent)
+	// NotifyWebhooks queues the provided event for delivery to the operator
+	// configured URLs and the webhooks of the accounts keyed in the
+	// provided account data.
+	NotifyWebhooks func(event string, data interface{}, accountData map[string]interface{})
 	// HubWg represents the hub's waitgroup.
 	HubWg *sync.WaitGroup
 }
@@ -58,16 +94,34 @@ type ChainState struct {
 	cfg            *ChainStateConfig
 	connCh         chan *blockNotification
 	discCh         chan *blockNotification
+	catchUpCh      chan struct{}
 	currentWork    string
 	currentWorkMtx sync.RWMutex
+
+	events          []*BlockEvent
+	processedEvents uint64
+	fatalEvents     uint64
+	retries         map[string]uint64
+	tipHash         string
+	tipHeight       uint32
+	hasTip          bool
+	eventsMtx       sync.RWMutex
 }
 
 // NewChainState creates a a chain state.
 func NewChainState(sCfg *ChainStateConfig) *ChainState {
+	if sCfg.RetryDelay == 0 {
+		sCfg.RetryDelay = defaultBlockEventRetryDelay
+	}
+	if sCfg.MaxRetryDelay == 0 {
+		sCfg.MaxRetryDelay = defaultBlockEventMaxRetryDelay
+	}
 	return &ChainState{
-		cfg:    sCfg,
-		connCh: make(chan *blockNotification, bufferSize),
-		discCh: make(chan *blockNotification, bufferSize),
+		cfg:       sCfg,
+		connCh:    make(chan *blockNotification, bufferSize),
+		discCh:    make(chan *blockNotification, bufferSize),
+		catchUpCh: make(chan struct{}, 1),
+		retries:   make(map[string]uint64),
 	}
 }
 
@@ -96,8 +150,9 @@ func (cs *ChainState) fetchCurrentWork() string {
 	return work
 }
 
-// pruneAcceptedWork removes all accepted work not confirmed as mined work
-// with heights less than the provided height.
+// pruneAcceptedWork resolves all accepted work neither confirmed as mined
+// work nor orphaned with heights less than the provided height. Accepted
+// work not part of the main chain is kept as orphaned.
 func (cs *ChainState) pruneAcceptedWork(ctx context.Context, height uint32) error {
 	toDelete, err := cs.cfg.db.fetchUnconfirmedWork(height)
 	if err != nil {
@@ -119,9 +174,9 @@ func (cs *ChainState) pruneAcceptedWork(ctx context.Context, height uint32) erro
 		}
 
 		// If the block has no confirmations at the current height,
-		// it is an orphan. Prune it.
+		// it is an orphan.
 		if confs <= 0 {
-			err = cs.cfg.db.deleteAcceptedWork(work.UUID)
+			err = cs.orphanWork(ctx, work)
 			if err != nil {
 				return err
 			}
@@ -131,13 +186,86 @@ func (cs *ChainState) pruneAcceptedWork(ctx context.Context, height uint32) erro
 
 		// If the block has confirmations mark the accepted work as
 		// confirmed.
-		work.Confirmed = true
+		work.setStatus(WorkConfirmed)
 		err = cs.cfg.db.updateAcceptedWork(work)
 		if err != nil {
 			return err
 		}
+		cs.notifyWork(WebhookBlockConfirmed, work)
+	}
+
+	return nil
+}
+
+// orphanWork marks the provided accepted work as orphaned, recording the
+// main chain block at its height as the block which replaced it.
+func (cs *ChainState) orphanWork(ctx context.Context, work *AcceptedWork) error {
+	work.setStatus(WorkOrphaned)
+	hash, err := cs.cfg.GetBlockHash(ctx, int64(work.Height))
+	if err != nil {
+		// The replacing block is informational, it may not be known yet
+		// while the chain reorganizes.
+		log.Debugf("unable to fetch block replacing orphaned work %s: %v",
+			work.BlockHash, err)
+	}
+	if err == nil && hash.String() != work.BlockHash {
+		work.OrphanedBy = hash.String()
+	}
+
+	err = cs.cfg.db.updateAcceptedWork(work)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Accepted work %s at height #%d orphaned", work.BlockHash,
+		work.Height)
+	cs.cfg.SignalCache(Unconfirmed)
+	cs.notifyWork(WebhookBlockOrphaned, work)
+	return nil
+}
+
+// notifyWork queues the provided webhook event of the provided accepted
+// work for the operator and the account which mined it.
+func (cs *ChainState) notifyWork(event string, work *AcceptedWork) {
+	cs.cfg.NotifyWebhooks(event, work,
+		map[string]interface{}{work.MinedBy: work})
+}
+
+// matureWork marks the mined work whose coinbase becomes spendable at the
+// provided height as matured.
+func (cs *ChainState) matureWork(ctx context.Context, height uint32) error {
+	maturity := uint32(cs.cfg.CoinbaseMaturity)
+	if maturity == 0 || height < maturity {
+		return nil
+	}
+
+	minedHeight := height - maturity
+	hash, err := cs.cfg.GetBlockHash(ctx, int64(minedHeight))
+	if err != nil {
+		return err
+	}
+	work, err := cs.cfg.db.fetchAcceptedWork(AcceptedWorkID(hash.String(),
+		minedHeight))
+	if err != nil {
+		// If the block is not mined work of the pool, ignore it.
+		if errors.Is(err, errs.ValueNotFound) {
+			return nil
+		}
+		return err
+	}
+	if work.Status != WorkConfirmed {
+		return nil
 	}
 
+	work.setStatus(WorkMatured)
+	err = cs.cfg.db.updateAcceptedWork(work)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Mined work %s at height #%d matured", work.BlockHash,
+		work.Height)
+	cs.cfg.SignalCache(Confirmed)
 	return nil
 }
 
@@ -161,8 +289,15 @@ func (cs *ChainState) prunePayments(ctx context.Context, height uint32) error {
 		}
 
 		// If the block has no confirmations at the current height,
-		// it is an orphan. Delete the payments associated with it.
+		// it is an orphan. Reverse the rewards booked for the payments
+		// associated with it and delete them. The reversal may already
+		// be booked if deleting the payment failed before.
 		if confs <= 0 {
+			err = cs.cfg.db.persistLedgerEntries(
+				[]*LedgerEntry{orphanEntry(payment)})
+			if err != nil && !errors.Is(err, errs.ValueFound) {
+				return err
+			}
 			err = cs.cfg.db.deletePayment(payment.UUID)
 			if err != nil {
 				return err
@@ -185,316 +320,636 @@ func isTreasuryActive(tx *wire.MsgTx) bool {
 	return true
 }
 
-// handleChainUpdates processes connected and disconnected block
-// notifications from the consensus daemon.
-func (cs *ChainState) handleChainUpdates(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			close(cs.discCh)
-			close(cs.connCh)
-			cs.cfg.HubWg.Done()
+// handleBlockConnected processes the provided connected block event. It
+// prunes invalidated data, pays mature dividends and confirms mined work of
+// the pool, generating payments for it in pool mining mode.
+func (cs *ChainState) handleBlockConnected(ctx context.Context, event *BlockEvent, header *wire.BlockHeader) error {
+	// Prune invalidated jobs and accepted work.
+	if header.Height > MaxReorgLimit {
+		pruneLimit := header.Height - MaxReorgLimit
+		err := cs.cfg.db.deleteJobsBeforeHeight(pruneLimit)
+		if err != nil {
+			return fmt.Errorf("unable to prune jobs to height %d: %w",
+				pruneLimit, err)
+		}
+
+		// Prune all hash data not updated in the past ten minutes.
+		// A connected client should have updated multiple times
+		// by then. Only disconnected miners would not have
+		// updated within the timeframe.
+		tenMinutesAgo := time.Now().Add(-time.Minute * 10).UnixNano()
+		err = cs.cfg.db.pruneHashData(tenMinutesAgo)
+		if err != nil {
+			return fmt.Errorf("unable to prune hash data: %w", err)
+		}
+
+		err = cs.pruneAcceptedWork(ctx, pruneLimit)
+		if err != nil {
+			return fmt.Errorf("unable to prune accepted work below "+
+				"height #%d: %w", pruneLimit, err)
+		}
+
+		err = cs.prunePayments(ctx, header.Height)
+		if err != nil {
+			return fmt.Errorf("unable to prune orphaned payments at "+
+				"height #%d: %w", header.Height, err)
+		}
+	}
+
+	block, err := cs.cfg.GetBlock(ctx, &header.PrevBlock)
+	if err != nil {
+		// Payments are sourced from coinbases, the block of confirmed
+		// mined work is required.
+		return fmt.Errorf("unable to fetch block with hash %s: %w",
+			header.PrevBlock, err)
+	}
+
+	coinbaseTx := block.Transactions[0]
+	treasuryActive := isTreasuryActive(coinbaseTx)
+
+	// Process mature payments. Failing to pay them only holds up the block
+	// events queued after this one for a limited number of attempts.
+	err = cs.cfg.PayDividends(ctx, header.Height, treasuryActive)
+	switch {
+	case err == nil:
+		// Signal the gui cache of paid dividends.
+		cs.cfg.SignalCache(DividendsPaid)
+
+	case isFatalChainError(err) || ctx.Err() != nil ||
+		event.Attempts+1 < maxPayDividendsAttempts:
+		return fmt.Errorf("unable to process payments: %w", err)
+
+	default:
+		log.Errorf("unable to process payments at height #%d, leaving "+
+			"them to a later block: %v", header.Height, err)
+	}
+
+	err = cs.matureWork(ctx, header.Height)
+	if err != nil {
+		return fmt.Errorf("unable to mature mined work at height #%d: %w",
+			header.Height, err)
+	}
+
+	// Check if the parent of the connected block is an accepted work
+	// of the pool.
+	parentHeight := header.Height - 1
+	parentHash := header.PrevBlock.String()
+	parentID := AcceptedWorkID(parentHash, parentHeight)
+	work, err := cs.cfg.db.fetchAcceptedWork(parentID)
+	if err != nil {
+		// If the parent of the connected block is not an accepted
+		// work of the the pool, ignore it.
+		if errors.Is(err, errs.ValueNotFound) {
+			log.Tracef("Block #%d (%s) is not an accepted "+
+				"work of the pool", parentHeight, parentHash)
+			return nil
+		}
+
+		return fmt.Errorf("unable to fetch accepted work for block #%d's "+
+			"parent %s: %w", header.Height, parentHash, err)
+	}
+
+	// The parent block may already be confirmed as mined by the pool, by a
+	// previous attempt of this block event interrupted before generating
+	// payments for it. Payments are generated below unless they already
+	// exist.
+	if !work.Confirmed {
+		// Update accepted work as confirmed mined.
+		work.setStatus(WorkConfirmed)
+		err = cs.cfg.db.updateAcceptedWork(work)
+		if err != nil {
+			return fmt.Errorf("unable to confirm accepted work for block "+
+				"%s: %w", parentHash, err)
+		}
+		log.Infof("Mined work %s confirmed by connected block #%d (%s)",
+			parentHash, header.Height, header.BlockHash().String())
+
+		// Signal the gui cache of the confirmed mined work.
+		cs.cfg.SignalCache(Confirmed)
+		cs.notifyWork(WebhookBlockConfirmed, work)
+	}
+
+	if cs.cfg.SoloPool {
+		return nil
+	}
+
+	count, err := cs.cfg.db.pendingPaymentsForBlockHash(parentHash)
+	if err != nil {
+		return fmt.Errorf("unable to fetch pending payments at "+
+			"height #%d: %w", parentHeight, err)
+	}
+	archived, err := cs.cfg.db.archivedPaymentsForBlockHash(parentHash)
+	if err != nil {
+		return fmt.Errorf("unable to fetch archived payments at "+
+			"height #%d: %w", parentHeight, err)
+	}
+
+	// If the parent block already has payments generated for it
+	// do not generate a new set of payments.
+	if count+archived > 0 {
+		return nil
+	}
+
+	// Generate payments for the confirmed block.
+	source := &PaymentSource{
+		BlockHash: block.BlockHash().String(),
+		Coinbase:  coinbaseTx.TxHash().String(),
+	}
+
+	// The coinbase output prior to
+	// [DCP0006](https://github.com/decred/dcps/pull/17)
+	// activation is at the third index position and at
+	// the second index position once DCP0006 is activated.
+	amt := dcrutil.Amount(coinbaseTx.TxOut[1].Value)
+	if !treasuryActive {
+		amt = dcrutil.Amount(coinbaseTx.TxOut[2].Value)
+	}
+
+	err = cs.cfg.GeneratePayments(block.Header.Height, source, amt,
+		work.CreatedOn)
+	if err != nil {
+		return fmt.Errorf("unable to generate payments for block %s: %w",
+			parentHash, err)
+	}
+
+	return nil
+}
+
+// handleBlockDisconnected processes the provided disconnected block event.
+// It unconfirms mined work of the pool confirmed by the disconnected block
+// and orphans mined work of the pool mined as the disconnected block.
+func (cs *ChainState) handleBlockDisconnected(ctx context.Context, header *wire.BlockHeader) error {
+	// Check if the disconnected block confirms a mined block, if it
+	// does unconfirm it.
+	parentHeight := header.Height - 1
+	parentHash := header.PrevBlock.String()
+	parentID := AcceptedWorkID(parentHash, parentHeight)
+	confirmedWork, err := cs.cfg.db.fetchAcceptedWork(parentID)
+	if err != nil {
+		// If the parent of the disconnected block is not an accepted
+		// work of the the pool, ignore it.
+		if !errors.Is(err, errs.ValueNotFound) {
+			return fmt.Errorf("unable to fetch accepted work for block "+
+				"#%d's parent %s: %w", header.Height, parentHash, err)
+		}
+	}
+
+	if confirmedWork != nil && confirmedWork.Confirmed {
+		confirmedWork.setStatus(WorkSubmitted)
+		err = cs.cfg.db.updateAcceptedWork(confirmedWork)
+		if err != nil {
+			return fmt.Errorf("unable to unconfirm accepted work for "+
+				"block %s: %w", parentHash, err)
+		}
+
+		log.Infof("Mined work %s unconfirmed via disconnected "+
+			"block #%d", parentHash, header.Height)
+	}
+
+	// If the disconnected block is an accepted work of the pool
+	// it is orphaned.
+	blockHash := header.BlockHash().String()
+	id := AcceptedWorkID(blockHash, header.Height)
+	work, err := cs.cfg.db.fetchAcceptedWork(id)
+	if err != nil {
+		// If the disconnected block is not an accepted
+		// work of the the pool, ignore it.
+		if errors.Is(err, errs.ValueNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to fetch accepted work for block "+
+			"#%d (%s): %w", header.Height, blockHash, err)
+	}
+
+	if work.Status == WorkOrphaned {
+		return nil
+	}
+	err = cs.orphanWork(ctx, work)
+	if err != nil {
+		return fmt.Errorf("unable to orphan mined work at "+
+			"height #%d: %w", header.Height, err)
+	}
+
+	return nil
+}
+
+// handleBlockEvent processes the provided block event.
+func (cs *ChainState) handleBlockEvent(ctx context.Context, event *BlockEvent) error {
+	header, err := event.blockHeader()
+	if err != nil {
+		return err
+	}
+
+	switch event.Kind {
+	case BlockConnectedEvent:
+		return cs.handleBlockConnected(ctx, event, header)
+	case BlockDisconnectedEvent:
+		return cs.handleBlockDisconnected(ctx, header)
+	default:
+		desc := fmt.Sprintf("unknown block event kind %q", event.Kind)
+		return errs.PoolError(errs.Decode, desc)
+	}
+}
+
+// loadBlockEvents loads the block events left pending processing by a
+// previous run of the pool, along with the last processed chain tip.
+func (cs *ChainState) loadBlockEvents() {
+	tipHash, tipHeight, err := cs.cfg.db.loadChainTip()
+	if err != nil && !errors.Is(err, errs.ValueNotFound) {
+		log.Errorf("unable to load chain tip: %v", err)
+	}
+	if err == nil {
+		cs.setChainTip(tipHash, tipHeight)
+	}
+
+	events, err := cs.cfg.db.fetchBlockEvents()
+	if err != nil {
+		log.Errorf("unable to load pending block events: %v", err)
+		return
+	}
+	if len(events) > 0 {
+		log.Infof("Resuming %d pending block events", len(events))
+	}
+
+	// The chain tip advances with every pending block event.
+	for _, event := range events {
+		hash, height, err := event.chainTip()
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		cs.setChainTip(hash, height)
+	}
+
+	cs.eventsMtx.Lock()
+	cs.events = append(events, cs.events...)
+	cs.eventsMtx.Unlock()
+}
+
+// setChainTip updates the chain tip of the queued block events.
+func (cs *ChainState) setChainTip(hash string, height uint32) {
+	cs.eventsMtx.Lock()
+	cs.tipHash = hash
+	cs.tipHeight = height
+	cs.hasTip = true
+	cs.eventsMtx.Unlock()
+}
+
+// chainTip returns the chain tip of the queued block events, if known.
+func (cs *ChainState) chainTip() (string, uint32, bool) {
+	cs.eventsMtx.RLock()
+	defer cs.eventsMtx.RUnlock()
+	return cs.tipHash, cs.tipHeight, cs.hasTip
+}
+
+// queueBlockEvent persists a block event of the provided kind for the
+// provided block notification and queues it for processing. Duplicate
+// connected block notifications are ignored. Block notifications which do
+// not extend or disconnect the chain tip indicate missed notifications, a
+// catch-up with the mining node is requested for them instead.
+func (cs *ChainState) queueBlockEvent(kind string, msg *blockNotification) {
+	var header wire.BlockHeader
+	err := header.FromBytes(msg.Header)
+	if err != nil {
+		// Errors generated parsing block notifications should not
+		// terminate the chainstate process.
+		log.Errorf("unable to create header from bytes: %v", err)
+		return
+	}
+
+	tipHash, tipHeight, hasTip := cs.chainTip()
+	if hasTip {
+		hash := header.BlockHash().String()
+		switch {
+		case kind == BlockConnectedEvent && hash == tipHash:
+			log.Debugf("Ignoring duplicate connected block #%d (%s)",
+				header.Height, hash)
 			return
 
-		case msg := <-cs.connCh:
-			var header wire.BlockHeader
-			err := header.FromBytes(msg.Header)
-			if err != nil {
-				// Errors generated parsing block notifications should not
-				// terminate the chainstate process.
-				log.Errorf("unable to create header from bytes: %v", err)
-				close(msg.Done)
-				continue
-			}
+		case kind == BlockConnectedEvent && (header.Height != tipHeight+1 ||
+			header.PrevBlock.String() != tipHash),
+			kind == BlockDisconnectedEvent && hash != tipHash:
+			log.Infof("%s block #%d (%s) does not match chain tip #%d (%s), "+
+				"catching up", kind, header.Height, hash, tipHeight, tipHash)
+			cs.requestCatchUp()
+			return
+		}
+	}
 
-			// Prune invalidated jobs and accepted work.
-			if header.Height > MaxReorgLimit {
-				pruneLimit := header.Height - MaxReorgLimit
-				err := cs.cfg.db.deleteJobsBeforeHeight(pruneLimit)
-				if err != nil {
-					// Errors generated pruning invalidated jobs indicate an
-					// underlying issue accessing the database. The chainstate
-					// process will be terminated as a result.
-					log.Errorf("unable to prune jobs to height %d: %v",
-						pruneLimit, err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-
-				// Prune all hash data not updated in the past ten minutes.
-				// A connected client should have updated multiple times
-				// by then. Only disconnected miners would not have
-				// updated within the timeframe.
-				tenMinutesAgo := time.Now().Add(-time.Minute * 10).UnixNano()
-				err = cs.cfg.db.pruneHashData(tenMinutesAgo)
-				if err != nil {
-					// Errors generated pruning invalidated hash rate
-					// indicate an underlying issue accessing the
-					// database. The chainstate process will be
-					// terminated as a result.
-					log.Errorf("unable to prune hash data: %v", err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-
-				err = cs.pruneAcceptedWork(ctx, pruneLimit)
-				if err != nil {
-					// Errors generated pruning invalidated accepted
-					// work indicate an underlying issue accessing
-					// the database. The chainstate process will be
-					// terminated as a result.
-					log.Errorf("unable to prune accepted work below "+
-						"height #%d: %v", pruneLimit, err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-
-				err = cs.prunePayments(ctx, header.Height)
-				if err != nil {
-					// Errors generated pruning invalidated payments
-					// indicate an underlying issue accessing the
-					// database. The chainstate process will be
-					// terminated as a result.
-					log.Errorf("unable to prune orphaned payments at "+
-						"height #%d: %v", header.Height, err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-			}
+	cs.queueHeader(kind, &header)
+}
 
-			block, err := cs.cfg.GetBlock(ctx, &header.PrevBlock)
-			if err != nil {
-				// Errors generated fetching blocks of confirmed mined
-				// work are curently fatal because payments are
-				// sourced from coinbases. The chainstate process will be
-				// terminated as a result.
-				log.Errorf("unable to fetch block with hash %x: %v",
-					header.PrevBlock, err)
-				close(msg.Done)
-				cs.cfg.Cancel()
-				continue
-			}
+// queueHeader persists a block event of the provided kind for the provided
+// block header and queues it for processing.
+func (cs *ChainState) queueHeader(kind string, header *wire.BlockHeader) {
+	event, err := newBlockEvent(kind, header)
+	if err != nil {
+		log.Error(err)
+		return
+	}
 
-			coinbaseTx := block.Transactions[0]
-			treasuryActive := isTreasuryActive(coinbaseTx)
+	err = cs.cfg.db.persistBlockEvent(event)
+	if err != nil {
+		// The block event is still processed, it is only not resumed
+		// after a restart.
+		log.Errorf("unable to persist %s block event for block #%d: %v",
+			kind, header.Height, err)
+	}
 
-			// Process mature payments.
-			err = cs.cfg.PayDividends(ctx, header.Height, treasuryActive)
-			if err != nil {
-				log.Errorf("unable to process payments: %v", err)
-				close(msg.Done)
-				cs.cfg.Cancel()
-				continue
-			}
+	cs.eventsMtx.Lock()
+	cs.events = append(cs.events, event)
+	cs.eventsMtx.Unlock()
 
-			// Signal the gui cache of paid dividends.
-			cs.cfg.SignalCache(DividendsPaid)
+	if kind == BlockDisconnectedEvent {
+		cs.setChainTip(header.PrevBlock.String(), header.Height-1)
+		return
+	}
+	cs.setChainTip(header.BlockHash().String(), header.Height)
+}
 
-			// Check if the parent of the connected block is an accepted work
-			// of the pool.
-			parentHeight := header.Height - 1
-			parentHash := header.PrevBlock.String()
-			parentID := AcceptedWorkID(parentHash, parentHeight)
-			work, err := cs.cfg.db.fetchAcceptedWork(parentID)
-			if err != nil {
-				// If the parent of the connected block is not an accepted
-				// work of the the pool, ignore it.
-				if errors.Is(err, errs.ValueNotFound) {
-					log.Tracef("Block #%d (%s) is not an accepted "+
-						"work of the pool", parentHeight, parentHash)
-					close(msg.Done)
-					continue
-				}
-
-				// Errors generated, except for a value not found error,
-				// looking up accepted work indicates an underlying issue
-				// accessing the database. The chainstate process will be
-				// terminated as a result.
-				log.Errorf("unable to fetch accepted work for block #%d's "+
-					"parent %s : %v", header.Height, parentHash, err)
-				close(msg.Done)
-				cs.cfg.Cancel()
-				continue
-			}
+// requestCatchUp requests a catch-up with the chain tip of the mining node.
+func (cs *ChainState) requestCatchUp() {
+	select {
+	case cs.catchUpCh <- struct{}{}:
+	default:
+		// A catch-up is already pending.
+	}
+}
 
-			// If the parent block is already confirmed as mined by the pool,
-			// ignore it.
-			if work.Confirmed {
-				close(msg.Done)
-				continue
-			}
+// catchUp queues block events for the blocks missed since the chain tip of
+// the queued block events, walking the chain of the mining node. Blocks of
+// the chain tip no longer part of the main chain are queued as disconnected,
+// main chain blocks past the fork point are queued as connected. Without a
+// chain tip, the chain tip of the mining node becomes the chain tip.
+func (cs *ChainState) catchUp(ctx context.Context) error {
+	best, bestHeight, err := cs.cfg.GetBestBlock(ctx)
+	if err != nil {
+		return err
+	}
+	resetTip := func() error {
+		cs.setChainTip(best.String(), uint32(bestHeight))
+		return cs.cfg.db.persistChainTip(best.String(), uint32(bestHeight))
+	}
 
-			// Update accepted work as confirmed mined.
-			work.Confirmed = true
-			err = cs.cfg.db.updateAcceptedWork(work)
-			if err != nil {
-				// Errors generated updating work state indicate an underlying
-				// issue accessing the database. The chainstate process will
-				// be terminated as a result.
-				log.Errorf("unable to confirm accepted work for block "+
-					"%s: %v", header.PrevBlock.String(), err)
-				close(msg.Done)
-				cs.cfg.Cancel()
-				continue
-			}
-			log.Infof("Mined work %s confirmed by connected block #%d (%s)",
-				header.PrevBlock.String(), header.Height,
-				header.BlockHash().String())
-
-			// Signal the gui cache of the confirmed mined work.
-			cs.cfg.SignalCache(Confirmed)
-
-			if !cs.cfg.SoloPool {
-				count, err := cs.cfg.db.pendingPaymentsForBlockHash(parentHash)
-				if err != nil {
-					// Errors generated looking up pending payments
-					// indicates an underlying issue accessing the database.
-					// The chainstate process will be terminated as a result.
-					log.Errorf("failed to fetch pending payments "+
-						"at height #%d: %v", parentHeight, err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-
-				// If the parent block already has payments generated for it
-				// do not generate a new set of payments.
-				if count > 0 {
-					close(msg.Done)
-					continue
-				}
-
-				// Generate payments for the confirmed block.
-				source := &PaymentSource{
-					BlockHash: block.BlockHash().String(),
-					Coinbase:  coinbaseTx.TxHash().String(),
-				}
-
-				// The coinbase output prior to
-				// [DCP0006](https://github.com/decred/dcps/pull/17)
-				// activation is at the third index position and at
-				// the second index position once DCP0006 is activated.
-				amt := dcrutil.Amount(coinbaseTx.TxOut[1].Value)
-				if !treasuryActive {
-					amt = dcrutil.Amount(coinbaseTx.TxOut[2].Value)
-				}
-
-				err = cs.cfg.GeneratePayments(block.Header.Height, source,
-					amt, work.CreatedOn)
-				if err != nil {
-					// Errors generated creating payments are fatal since it is
-					// required to distribute payments to participating miners.
-					// The chainstate process will be terminated as a result.
-					log.Error(err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-			}
+	tipHash, tipHeight, hasTip := cs.chainTip()
+	if !hasTip {
+		return resetTip()
+	}
+	if int64(tipHeight) > bestHeight {
+		return fmt.Errorf("mining node chain tip #%d is behind chain "+
+			"tip #%d", bestHeight, tipHeight)
+	}
 
-			close(msg.Done)
+	// Walk back from the chain tip to the main chain.
+	var disconnected []*wire.BlockHeader
+	for {
+		hash, err := cs.cfg.GetBlockHash(ctx, int64(tipHeight))
+		if err != nil {
+			return err
+		}
+		if hash.String() == tipHash {
+			break
+		}
+		if len(disconnected) == MaxReorgLimit {
+			log.Warnf("Chain tip forks off the main chain beyond the "+
+				"reorg limit, resuming from block #%d (%s)", bestHeight,
+				best)
+			return resetTip()
+		}
+		hash, err = chainhash.NewHashFromStr(tipHash)
+		if err != nil {
+			return err
+		}
+		header, err := cs.cfg.GetBlockHeader(ctx, hash)
+		if err != nil {
+			return err
+		}
+		disconnected = append(disconnected, header)
+		tipHash, tipHeight = header.PrevBlock.String(), header.Height-1
+	}
 
-		case msg := <-cs.discCh:
-			var header wire.BlockHeader
-			err := header.FromBytes(msg.Header)
-			if err != nil {
-				// Errors generated parsing block notifications should not
-				// terminate the chainstate process.
-				log.Errorf("unable to create header from bytes: %v", err)
-				close(msg.Done)
-				continue
-			}
+	if len(disconnected) == 0 && int64(tipHeight) == bestHeight {
+		return nil
+	}
 
-			// Check if the disconnected block confirms a mined block, if it
-			// does unconfirm it.
-			parentHeight := header.Height - 1
-			parentHash := header.PrevBlock.String()
-			parentID := AcceptedWorkID(parentHash, parentHeight)
-			confirmedWork, err := cs.cfg.db.fetchAcceptedWork(parentID)
+	log.Infof("Catching up on %d disconnected and %d connected blocks",
+		len(disconnected), bestHeight-int64(tipHeight))
+	for _, header := range disconnected {
+		cs.queueHeader(BlockDisconnectedEvent, header)
+	}
+	for height := int64(tipHeight) + 1; height <= bestHeight; height++ {
+		hash, err := cs.cfg.GetBlockHash(ctx, height)
+		if err != nil {
+			return err
+		}
+		header, err := cs.cfg.GetBlockHeader(ctx, hash)
+		if err != nil {
+			return err
+		}
+		cs.queueHeader(BlockConnectedEvent, header)
+	}
+
+	return nil
+}
+
+// nextBlockEventRetry returns the time the block event next in line is
+// retried at, if it is awaiting a retry.
+func (cs *ChainState) nextBlockEventRetry() (time.Time, bool) {
+	cs.eventsMtx.RLock()
+	defer cs.eventsMtx.RUnlock()
+	if len(cs.events) == 0 || cs.events[0].NextAttempt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, cs.events[0].NextAttempt), true
+}
+
+// processBlockEvents processes the queued block events in order. Processed
+// block events are removed from the queue. Processing stops at a block event
+// failing with a transient error, it is retried with an exponential backoff.
+// A block event failing with a fatal error terminates the pool and is
+// retried after a restart.
+func (cs *ChainState) processBlockEvents(ctx context.Context) {
+	for ctx.Err() == nil {
+		cs.eventsMtx.RLock()
+		if len(cs.events) == 0 {
+			cs.eventsMtx.RUnlock()
+			return
+		}
+		event := cs.events[0]
+		nextAttempt := event.NextAttempt
+		cs.eventsMtx.RUnlock()
+
+		now := time.Now()
+		if nextAttempt > now.UnixNano() {
+			return
+		}
+
+		err := cs.handleBlockEvent(ctx, event)
+		if err == nil {
+			err = cs.cfg.db.deleteBlockEvent(event.UUID)
 			if err != nil {
-				// Errors generated, except for a value not found error,
-				// looking up accepted work indicates an underlying issue
-				// accessing the database. The chainstate process will be
-				// terminated as a result.
-				if !errors.Is(err, errs.ValueNotFound) {
-					log.Errorf("unable to fetch accepted work for block "+
-						"#%d's parent %s: %v", header.Height, parentHash, err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-
-				// If the parent of the disconnected block is not an accepted
-				// work of the the pool, ignore it.
+				// Processing block events is idempotent, the block event
+				// is processed again after a restart.
+				log.Errorf("unable to delete processed block event %s: %v",
+					event.UUID, err)
 			}
 
-			if confirmedWork != nil {
-				confirmedWork.Confirmed = false
-				err = cs.cfg.db.updateAcceptedWork(confirmedWork)
-				if err != nil {
-					// Errors generated updating work state indicate an underlying
-					// issue accessing the database. The chainstate process will
-					// be terminated as a result.
-					log.Errorf("unable to unconfirm accepted work for block "+
-						"%s: %v", parentHash, err)
-					close(msg.Done)
-					cs.cfg.Cancel()
-					continue
-				}
-
-				log.Infof("Mined work %s unconfirmed via disconnected "+
-					"block #%d", parentHash, header.Height)
+			// Missed blocks are caught up on from the last processed
+			// chain tip after a restart.
+			hash, height, err := event.chainTip()
+			if err == nil {
+				err = cs.cfg.db.persistChainTip(hash, height)
 			}
-
-			// If the disconnected block is an accepted work of the pool
-			// ensure it is not confirmed mined.
-			blockHash := header.BlockHash().String()
-			id := AcceptedWorkID(blockHash, header.Height)
-			work, err := cs.cfg.db.fetchAcceptedWork(id)
 			if err != nil {
-				// If the disconnected block is not an accepted
-				// work of the the pool, ignore it.
-				if errors.Is(err, errs.ValueNotFound) {
-					close(msg.Done)
-					continue
-				}
-
-				// Errors generated, except for a value not found error,
-				// looking up accepted work indicates an underlying issue
-				// accessing the database. The chainstate process will be
-				// terminated as a result.
-				log.Errorf("unable to fetch accepted work for block #%d: %v",
-					header.Height, blockHash, err)
-				close(msg.Done)
-				cs.cfg.Cancel()
-				continue
+				log.Errorf("unable to persist chain tip: %v", err)
 			}
 
-			work.Confirmed = false
-			err = cs.cfg.db.updateAcceptedWork(work)
-			if err != nil {
-				// Errors generated updating work state indicate an underlying
-				// issue accessing the database. The chainstate process will
-				// be terminated as a result.
-				log.Errorf("unable to unconfirm mined work at "+
-					"height #%d: %v", err)
-				close(msg.Done)
-				cs.cfg.Cancel()
-				continue
+			cs.eventsMtx.Lock()
+			cs.events = cs.events[1:]
+			cs.processedEvents++
+			cs.eventsMtx.Unlock()
+			continue
+		}
+
+		// Block events interrupted by the pool shutting down are resumed
+		// after a restart.
+		if ctx.Err() != nil {
+			return
+		}
+
+		fatal := isFatalChainError(err)
+		cs.eventsMtx.Lock()
+		event.Attempts++
+		event.LastError = err.Error()
+		if fatal {
+			cs.fatalEvents++
+		} else {
+			delay := blockEventRetryDelay(event.Attempts,
+				cs.cfg.RetryDelay, cs.cfg.MaxRetryDelay)
+			event.NextAttempt = now.Add(delay).UnixNano()
+			cs.retries[chainErrorKind(err)]++
+		}
+		cs.eventsMtx.Unlock()
+
+		uErr := cs.cfg.db.updateBlockEvent(event)
+		if uErr != nil && !errors.Is(uErr, errs.ValueNotFound) {
+			log.Errorf("unable to update block event %s: %v", event.UUID,
+				uErr)
+		}
+
+		if fatal {
+			// Errors indicating corrupted or inconsistent pool data
+			// terminate the chainstate process.
+			log.Errorf("unable to process %s block #%d: %v", event.Kind,
+				event.Height, err)
+			cs.cfg.Cancel()
+			return
+		}
+
+		log.Warnf("unable to process %s block #%d (attempt %d), retrying "+
+			"in %v: %v", event.Kind, event.Height, event.Attempts,
+			time.Duration(event.NextAttempt-now.UnixNano()), err)
+		return
+	}
+}
+
+// BlockEventStats represents the state of block event processing.
+type BlockEventStats struct {
+	Processed uint64            `json:"processed"`
+	Fatal     uint64            `json:"fatal"`
+	Retries   uint64            `json:"retries"`
+	ByKind    map[string]uint64 `json:"retriesbykind"`
+	Pending   []*BlockEvent     `json:"pending"`
+}
+
+// blockEventStats returns the block event processing counters along with
+// the block events pending processing, oldest first. Retries are counted
+// by the error kind of the failure retried.
+func (cs *ChainState) blockEventStats() *BlockEventStats {
+	cs.eventsMtx.RLock()
+	defer cs.eventsMtx.RUnlock()
+
+	stats := &BlockEventStats{
+		Processed: cs.processedEvents,
+		Fatal:     cs.fatalEvents,
+		ByKind:    make(map[string]uint64, len(cs.retries)),
+		Pending:   make([]*BlockEvent, 0, len(cs.events)),
+	}
+	for kind, n := range cs.retries {
+		stats.ByKind[kind] = n
+		stats.Retries += n
+	}
+	for _, event := range cs.events {
+		e := *event
+		stats.Pending = append(stats.Pending, &e)
+	}
+	return stats
+}
+
+// handleChainUpdates processes connected and disconnected block
+// notifications from the consensus daemon. Block notifications are queued
+// as durable block events and processed in order, block events left
+// pending by a previous run are processed first. Blocks missed while the
+// pool or the mining node was down are caught up on at startup and when
+// requested.
+func (cs *ChainState) handleChainUpdates(ctx context.Context) {
+	var nextCatchUp time.Time
+	catchUp := func() {
+		err := cs.catchUp(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
 			}
+			log.Warnf("unable to catch up on missed blocks, retrying in "+
+				"%v: %v", cs.cfg.RetryDelay, err)
+			nextCatchUp = time.Now().Add(cs.cfg.RetryDelay)
+			return
+		}
+		nextCatchUp = time.Time{}
+	}
 
-			log.Infof("Disconnected mined work %s at height #%d",
-				blockHash, header.Height)
+	cs.loadBlockEvents()
+	catchUp()
+	cs.processBlockEvents(ctx)
 
-			// Signal the gui cache of the unconfirmed (due to a reorg)
-			// mined work.
-			cs.cfg.SignalCache(Unconfirmed)
+	for {
+		var retry <-chan time.Time
+		if next, ok := cs.nextBlockEventRetry(); ok {
+			retry = time.After(time.Until(next))
+		}
+		var catchUpRetry <-chan time.Time
+		if !nextCatchUp.IsZero() {
+			catchUpRetry = time.After(time.Until(nextCatchUp))
+		}
+
+		select {
+		case <-ctx.Done():
+			close(cs.discCh)
+			close(cs.connCh)
+			cs.cfg.HubWg.Done()
+			return
+
+		case msg := <-cs.connCh:
+			cs.queueBlockEvent(BlockConnectedEvent, msg)
+			cs.processBlockEvents(ctx)
+			close(msg.Done)
 
+		case msg := <-cs.discCh:
+			cs.queueBlockEvent(BlockDisconnectedEvent, msg)
+			cs.processBlockEvents(ctx)
 			close(msg.Done)
+
+		case <-retry:
+			cs.processBlockEvents(ctx)
+
+		case <-cs.catchUpCh:
+			catchUp()
+			cs.processBlockEvents(ctx)
+
+		case <-catchUpRetry:
+			catchUp()
+			cs.processBlockEvents(ctx)
 		}
 	}
 }
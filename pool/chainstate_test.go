@@ -65,6 +65,15 @@ func testChainState(t *testing.T) {
 		return -1, nil
 	}
 
+	getBestBlock := func(context.Context) (*chainhash.Hash, int64, error) {
+		return nil, 0, errs.PoolError(errs.Disconnected, "node unreachable")
+	}
+
+	replacingHash := chainhash.Hash{7}
+	getBlockHash := func(context.Context, int64) (*chainhash.Hash, error) {
+		return &replacingHash, nil
+	}
+
 	signalCache := func(_ CacheUpdateEvent) {
 		// Do nothing.
 	}
@@ -78,7 +87,10 @@ func testChainState(t *testing.T) {
 		GeneratePayments:      generatePayments,
 		GetBlock:              getBlock,
 		GetBlockConfirmations: getBlockConfirmations,
+		GetBestBlock:          getBestBlock,
+		GetBlockHash:          getBlockHash,
 		SignalCache:           signalCache,
+		NotifyWebhooks:        func(string, interface{}, map[string]interface{}) {},
 		Cancel:                cancel,
 		HubWg:                 new(sync.WaitGroup),
 	}
@@ -90,7 +102,7 @@ func testChainState(t *testing.T) {
 		"00000000000000001e2065a7248a9b4d3886fe3ca3128eebedddaf35fb26e58c",
 		"000000000000000007301a21efa98033e06f7eba836990394fff9f765f1556b1",
 		396692, yID, "dr3")
-	workA.Confirmed = true
+	workA.setStatus(WorkConfirmed)
 	err = db.persistAcceptedWork(workA)
 	if err != nil {
 		t.Fatal(err)
@@ -111,21 +123,38 @@ func testChainState(t *testing.T) {
 		t.Fatalf("pruneAcceptedWork error: %v", err)
 	}
 
-	// Ensure work A did not get pruned but work B did.
+	// Ensure work A did not get pruned and work B is kept as orphaned
+	// by the main chain block at its height.
 	_, err = db.fetchAcceptedWork(workA.UUID)
 	if err != nil {
 		t.Fatalf("expected a valid accepted work, got: %v", err)
 	}
-	_, err = db.fetchAcceptedWork(workB.UUID)
-	if !errors.Is(err, errs.ValueNotFound) {
-		t.Fatalf("expected value found error, got %v", err)
+	orphaned, err := db.fetchAcceptedWork(workB.UUID)
+	if err != nil {
+		t.Fatalf("expected orphaned work to be kept, got: %v", err)
+	}
+	if orphaned.Status != WorkOrphaned || orphaned.Confirmed ||
+		orphaned.OrphanedBy != replacingHash.String() {
+		t.Fatalf("expected work orphaned by %s, got status %q orphaned "+
+			"by %q", replacingHash, orphaned.Status, orphaned.OrphanedBy)
 	}
 
-	// Delete work A.
-	err = db.deleteAcceptedWork(workA.UUID)
+	// Ensure orphaned work is not resolved again.
+	unconfirmed, err := db.fetchUnconfirmedWork(workB.Height + 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if len(unconfirmed) != 0 {
+		t.Fatalf("expected no unconfirmed work, got %d", len(unconfirmed))
+	}
+
+	// Delete work A and B.
+	for _, work := range []*AcceptedWork{workA, workB} {
+		err = db.deleteAcceptedWork(work.UUID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
 
 	// Test prunePayments.
 	cs.cfg.GetBlock = func(ctx context.Context, hash *chainhash.Hash) (*wire.MsgBlock, error) {
@@ -291,6 +320,39 @@ func testChainState(t *testing.T) {
 			"after chain notifications")
 	}
 
+	// Ensure payments are generated for confirmed work without payments, as
+	// left by an attempt interrupted after confirming the work, and are not
+	// generated again once they exist, pending or archived.
+	var generated int
+	cs.cfg.GeneratePayments = func(uint32, *PaymentSource, dcrutil.Amount, int64) error {
+		generated++
+		return nil
+	}
+	minedSource := &PaymentSource{
+		BlockHash: confHeader.PrevBlock.String(),
+		Coinbase:  chainhash.Hash{0}.String(),
+	}
+	minedPayment := NewPayment(xID, minedSource, amt, 42, 58)
+	for i, setup := range []func() error{
+		func() error { return nil },
+		func() error { return db.PersistPayment(minedPayment) },
+		func() error { return db.ArchivePayment(minedPayment) },
+	} {
+		err = setup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cs.handleBlockConnected(ctx, &BlockEvent{}, &confHeader)
+		if err != nil {
+			t.Fatalf("handleBlockConnected error: %v", err)
+		}
+		if generated != 1 {
+			t.Fatalf("case %d: expected payments generated once, got %d",
+				i, generated)
+		}
+	}
+	cs.cfg.GeneratePayments = generatePayments
+
 	discConfMsg := &blockNotification{
 		Header: confHeaderB,
 		Done:   make(chan bool),
@@ -313,6 +375,10 @@ func testChainState(t *testing.T) {
 		t.Fatalf("disconnected mined work a height #%d should "+
 			"be unconfirmed", discMinedWork.Height)
 	}
+	if discMinedWork.Status != WorkOrphaned {
+		t.Fatalf("disconnected mined work at height #%d should be "+
+			"orphaned, got %q", discMinedWork.Height, discMinedWork.Status)
+	}
 
 	// Ensure a malformed disconnected block does not terminate the chain state
 	// process.
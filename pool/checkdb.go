@@ -0,0 +1,352 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"fmt"
+	"sort"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// DBViolation represents a persisted entity violating an invariant of the
+// pool accounting state.
+type DBViolation struct {
+	Check       string `json:"check"`
+	Kind        string `json:"kind"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Repairable  bool   `json:"repairable"`
+	Repaired    bool   `json:"repaired"`
+}
+
+// DBCheckReport represents the outcome of checking a database.
+type DBCheckReport struct {
+	Checks     []string       `json:"checks"`
+	Violations []*DBViolation `json:"violations"`
+}
+
+// Unrepaired returns the number of violations which were not repaired.
+func (r *DBCheckReport) Unrepaired() int {
+	var count int
+	for _, v := range r.Violations {
+		if !v.Repaired {
+			count++
+		}
+	}
+	return count
+}
+
+// dbSnapshot represents the decoded entities of a database relevant to the
+// integrity checks.
+type dbSnapshot struct {
+	accounts  map[string]struct{}
+	payments  []*Payment
+	archived  []*Payment
+	shares    []*Share
+	work      []*AcceptedWork
+	apiTokens []*APIToken
+	ledger    map[string]struct{}
+}
+
+// takeDBSnapshot decodes the entities of the provided database.
+func takeDBSnapshot(db Database) (*dbSnapshot, error) {
+	snap := &dbSnapshot{
+		accounts: make(map[string]struct{}),
+		ledger:   make(map[string]struct{}),
+	}
+	err := db.exportRecords(nil, func(kind string, entity interface{}) error {
+		record, _, err := canonicalRecord(kind, entity)
+		if err != nil {
+			return err
+		}
+		decoded, id, err := decodeArchiveRecord(record)
+		if err != nil {
+			return err
+		}
+		switch e := decoded.(type) {
+		case *Account:
+			snap.accounts[id] = struct{}{}
+		case *Payment:
+			if kind == archivedPaymentRecord {
+				snap.archived = append(snap.archived, e)
+				break
+			}
+			snap.payments = append(snap.payments, e)
+		case *Share:
+			snap.shares = append(snap.shares, e)
+		case *AcceptedWork:
+			snap.work = append(snap.work, e)
+		case *APIToken:
+			snap.apiTokens = append(snap.apiTokens, e)
+		case *LedgerEntry:
+			snap.ledger[id] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// dbCheck represents an invariant check of the pool accounting state.
+type dbCheck struct {
+	name string
+
+	// find returns the violations of the invariant.
+	find func(snap *dbSnapshot) []*DBViolation
+
+	// repair fixes the provided violation, it is nil when violations of
+	// the invariant cannot be safely repaired.
+	repair func(db Database, snap *dbSnapshot, v *DBViolation) error
+}
+
+// dbChecks is the catalogue of invariant checks run by CheckDB, in order.
+var dbChecks = []*dbCheck{
+	{
+		name: "payment-account",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			var violations []*DBViolation
+			check := func(kind string, pmts []*Payment) {
+				for _, pmt := range pmts {
+					if pmt.Account == PoolFeesK {
+						continue
+					}
+					if _, ok := snap.accounts[pmt.Account]; ok {
+						continue
+					}
+					violations = append(violations, &DBViolation{
+						Kind: kind,
+						ID:   pmt.UUID,
+						Description: fmt.Sprintf("payment references "+
+							"missing account %s", pmt.Account),
+					})
+				}
+			}
+			check(paymentRecord, snap.payments)
+			check(archivedPaymentRecord, snap.archived)
+			return violations
+		},
+	},
+	{
+		name: "payment-source",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			mined := make(map[string]struct{}, len(snap.work))
+			for _, work := range snap.work {
+				mined[work.BlockHash] = struct{}{}
+			}
+			var violations []*DBViolation
+			check := func(kind string, pmts []*Payment) {
+				for _, pmt := range pmts {
+					var desc string
+					switch {
+					case pmt.Source == nil:
+						desc = "payment has no source"
+					default:
+						if _, ok := mined[pmt.Source.BlockHash]; ok {
+							continue
+						}
+						desc = fmt.Sprintf("payment source block %s has "+
+							"no accepted work", pmt.Source.BlockHash)
+					}
+					violations = append(violations, &DBViolation{
+						Kind:        kind,
+						ID:          pmt.UUID,
+						Description: desc,
+					})
+				}
+			}
+			check(paymentRecord, snap.payments)
+			check(archivedPaymentRecord, snap.archived)
+			return violations
+		},
+	},
+	{
+		name: "archived-payment-txid",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			var violations []*DBViolation
+			for _, pmt := range snap.archived {
+				if pmt.TransactionID != "" {
+					continue
+				}
+				violations = append(violations, &DBViolation{
+					Kind:        archivedPaymentRecord,
+					ID:          pmt.UUID,
+					Description: "archived payment has no transaction id",
+				})
+			}
+			return violations
+		},
+	},
+	{
+		// Payments are marked paid before being archived, a pending
+		// payment with a transaction id was paid but not archived.
+		name: "paid-pending-payment",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			var violations []*DBViolation
+			for _, pmt := range snap.payments {
+				if pmt.TransactionID == "" {
+					continue
+				}
+				violations = append(violations, &DBViolation{
+					Kind: paymentRecord,
+					ID:   pmt.UUID,
+					Description: fmt.Sprintf("pending payment was paid "+
+						"by transaction %s", pmt.TransactionID),
+				})
+			}
+			return violations
+		},
+		repair: func(db Database, snap *dbSnapshot, v *DBViolation) error {
+			for _, pmt := range snap.payments {
+				if pmt.UUID == v.ID {
+					return db.ArchivePayment(pmt)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// Every payment of a block reward is booked to the ledger when
+		// created, the entry is derived from the pending payment. Archived
+		// payments are copies of the pending payments booked.
+		name: "payment-ledger",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			var violations []*DBViolation
+			for _, pmt := range snap.payments {
+				if _, ok := snap.ledger[rewardEntry(pmt).UUID]; ok {
+					continue
+				}
+				violations = append(violations, &DBViolation{
+					Kind:        paymentRecord,
+					ID:          pmt.UUID,
+					Description: "payment reward is not booked to the ledger",
+				})
+			}
+			return violations
+		},
+		repair: func(db Database, snap *dbSnapshot, v *DBViolation) error {
+			for _, pmt := range snap.payments {
+				if pmt.UUID == v.ID {
+					entry := rewardEntry(pmt)
+					entry.CreatedOn = pmt.CreatedOn
+					return db.persistLedgerEntries([]*LedgerEntry{entry})
+				}
+			}
+			return nil
+		},
+	},
+	{
+		name: "confirmed-work-height",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			confirmed := make(map[uint32][]*AcceptedWork)
+			for _, work := range snap.work {
+				if work.Confirmed {
+					confirmed[work.Height] = append(confirmed[work.Height],
+						work)
+				}
+			}
+			var violations []*DBViolation
+			for height, works := range confirmed {
+				if len(works) < 2 {
+					continue
+				}
+				for _, work := range works {
+					violations = append(violations, &DBViolation{
+						Kind: acceptedWorkRecord,
+						ID:   work.UUID,
+						Description: fmt.Sprintf("%d works confirmed at "+
+							"height %d", len(works), height),
+					})
+				}
+			}
+			return violations
+		},
+	},
+	{
+		name: "share-weight",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			var violations []*DBViolation
+			for _, share := range snap.shares {
+				if share.Weight != nil && share.Weight.Sign() > 0 {
+					continue
+				}
+				violations = append(violations, &DBViolation{
+					Kind:        shareRecord,
+					ID:          share.UUID,
+					Description: "share weight is not positive",
+				})
+			}
+			return violations
+		},
+	},
+	{
+		name: "api-token-account",
+		find: func(snap *dbSnapshot) []*DBViolation {
+			var violations []*DBViolation
+			for _, token := range snap.apiTokens {
+				if _, ok := snap.accounts[token.AccountID]; ok {
+					continue
+				}
+				violations = append(violations, &DBViolation{
+					Kind: apiTokenRecord,
+					ID:   token.UUID,
+					Description: fmt.Sprintf("api token references "+
+						"missing account %s", token.AccountID),
+				})
+			}
+			return violations
+		},
+		repair: func(db Database, _ *dbSnapshot, v *DBViolation) error {
+			return db.deleteAPIToken(v.ID)
+		},
+	},
+}
+
+// CheckDB runs the catalogue of invariant checks of the pool accounting state
+// against the provided database and reports the violations found, ordered by
+// check, entity kind and id. Violations which can be safely repaired are
+// repaired when repair is set.
+func CheckDB(db Database, repair bool) (*DBCheckReport, error) {
+	const funcName = "CheckDB"
+
+	snap, err := takeDBSnapshot(db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DBCheckReport{
+		Checks:     make([]string, 0, len(dbChecks)),
+		Violations: make([]*DBViolation, 0),
+	}
+	for _, check := range dbChecks {
+		report.Checks = append(report.Checks, check.name)
+
+		violations := check.find(snap)
+		sort.Slice(violations, func(i, j int) bool {
+			if violations[i].Kind != violations[j].Kind {
+				return violations[i].Kind < violations[j].Kind
+			}
+			return violations[i].ID < violations[j].ID
+		})
+		for _, v := range violations {
+			v.Check = check.name
+			v.Repairable = check.repair != nil
+			if repair && v.Repairable {
+				err := check.repair(db, snap, v)
+				if err != nil {
+					desc := fmt.Sprintf("%s: unable to repair %s %s: %v",
+						funcName, v.Kind, v.ID, err)
+					return nil, errs.DBError(errs.PersistEntry, desc)
+				}
+				v.Repaired = true
+			}
+		}
+		report.Violations = append(report.Violations, violations...)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,198 @@
+package pool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+func testCheckDB(t *testing.T) {
+	// A consistent database has no violations.
+	account := NewAccount(xAddr)
+	err := db.persistAccount(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workA := NewAcceptedWork("blocka", "prev", 10, xID, CPU)
+	workA.Confirmed = true
+	err = db.persistAcceptedWork(workA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &PaymentSource{BlockHash: workA.BlockHash, Coinbase: "coinbase"}
+	pmt := NewPayment(xID, source, dcrutil.Amount(100), 10, 26)
+	err = db.PersistPayment(pmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feePmt := NewPayment(PoolFeesK, source, dcrutil.Amount(10), 10, 26)
+	err = db.PersistPayment(feePmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistLedgerEntries([]*LedgerEntry{rewardEntry(pmt),
+		rewardEntry(feePmt)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := newAPIToken(xID, APITokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAPIToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := CheckDB(db, false)
+	if err != nil {
+		t.Fatalf("CheckDB: unexpected error: %v", err)
+	}
+	if len(report.Checks) != len(dbChecks) {
+		t.Fatalf("expected %d checks, got %d", len(dbChecks),
+			len(report.Checks))
+	}
+	if len(report.Violations) != 0 {
+		t.Fatalf("expected no violations, got %d", len(report.Violations))
+	}
+
+	// Introduce a violation of every check.
+	orphanPmt := NewPayment(yID, source, dcrutil.Amount(100), 10, 26)
+	err = db.PersistPayment(orphanPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unminedPmt := NewPayment(xID, &PaymentSource{BlockHash: "blockc"},
+		dcrutil.Amount(100), 10, 26)
+	err = db.PersistPayment(unminedPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistLedgerEntries([]*LedgerEntry{rewardEntry(orphanPmt),
+		rewardEntry(unminedPmt)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unbookedPmt := NewPayment(xID, source, dcrutil.Amount(100), 10, 26)
+	err = db.PersistPayment(unbookedPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpaidPmt := NewPayment(xID, source, dcrutil.Amount(100), 9, 25)
+	err = db.PersistPayment(unpaidPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.ArchivePayment(unpaidPmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pmt.TransactionID = "txid"
+	pmt.PaidOnHeight = 30
+	err = db.updatePayment(pmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workB := NewAcceptedWork("blockb", "prev", 10, xID, CPU)
+	workB.Confirmed = true
+	err = db.persistAcceptedWork(workB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := NewShare(xID, new(big.Rat))
+	err = db.PersistShare(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, orphanToken, err := newAPIToken(yID, APITokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistAPIToken(orphanToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type violation struct {
+		check string
+		id    string
+	}
+	expected := []violation{
+		{"payment-account", orphanPmt.UUID},
+		{"payment-source", unminedPmt.UUID},
+		{"archived-payment-txid", ""},
+		{"paid-pending-payment", pmt.UUID},
+		{"payment-ledger", unbookedPmt.UUID},
+		{"confirmed-work-height", ""},
+		{"confirmed-work-height", ""},
+		{"share-weight", share.UUID},
+		{"api-token-account", orphanToken.UUID},
+	}
+	assertViolations := func(report *DBCheckReport, repaired bool) {
+		t.Helper()
+		if len(report.Violations) != len(expected) {
+			t.Fatalf("expected %d violations, got %d: %+v", len(expected),
+				len(report.Violations), report.Violations)
+		}
+		for i, v := range report.Violations {
+			want := expected[i]
+			if v.Check != want.check || (want.id != "" && v.ID != want.id) {
+				t.Fatalf("expected violation %d to be %s of %s, got %s "+
+					"of %s", i, want.check, want.id, v.Check, v.ID)
+			}
+			if v.Repaired != (repaired && v.Repairable) {
+				t.Fatalf("expected violation %d repaired %v, got %v", i,
+					repaired && v.Repairable, v.Repaired)
+			}
+		}
+	}
+
+	report, err = CheckDB(db, false)
+	if err != nil {
+		t.Fatalf("CheckDB: unexpected error: %v", err)
+	}
+	assertViolations(report, false)
+	if report.Unrepaired() != len(expected) {
+		t.Fatalf("expected %d unrepaired violations, got %d", len(expected),
+			report.Unrepaired())
+	}
+
+	// Ensure the safe cases are repaired.
+	report, err = CheckDB(db, true)
+	if err != nil {
+		t.Fatalf("CheckDB: unexpected error: %v", err)
+	}
+	assertViolations(report, true)
+	if report.Unrepaired() != len(expected)-3 {
+		t.Fatalf("expected %d unrepaired violations, got %d",
+			len(expected)-3, report.Unrepaired())
+	}
+
+	_, err = db.fetchPayment(pmt.UUID)
+	if err == nil {
+		t.Fatal("expected paid pending payment to be archived")
+	}
+	_, err = db.fetchAPIToken(orphanToken.UUID)
+	if err == nil {
+		t.Fatal("expected orphaned api token to be deleted")
+	}
+	entries, err := db.fetchLedgerEntries(xID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var booked bool
+	for _, entry := range entries {
+		booked = booked || entry.UUID == rewardEntry(unbookedPmt).UUID
+	}
+	if !booked {
+		t.Fatal("expected unbooked payment reward to be booked")
+	}
+
+	expected = append(expected[:3], expected[5:8]...)
+	report, err = CheckDB(db, false)
+	if err != nil {
+		t.Fatalf("CheckDB: unexpected error: %v", err)
+	}
+	assertViolations(report, false)
+}
@@ -77,6 +77,9 @@ type ClientConfig struct {
 	Blake256Pad []byte
 	// NonceIterations returns the possible header nonce iterations.
 	NonceIterations float64
+	// slicer aggregates the accepted shares of accounts over time slices.
+	// Shares are persisted individually when nil.
+	slicer *shareSlicer
 	// FetchMinerDifficulty returns the difficulty information for the
 	// provided miner, if it exists.
 	FetchMinerDifficulty func(string) (*DifficultyInfo, error)
@@ -99,6 +102,13 @@ type ClientConfig struct {
 	ClientTimeout time.Duration
 	// SignalCache sends the provided cache update event to the gui cache.
 	SignalCache func(event CacheUpdateEvent)
+	// NotifyWebhooks queues the provided event for delivery to the operator
+	// configured URLs and the webhooks of the accounts keyed in the
+	// provided account data.
+	NotifyWebhooks func(event string, data interface{}, accountData map[string]interface{})
+	// RecordWorkerActivity notes the hashrate of a named worker connection
+	// which submitted shares.
+	RecordWorkerActivity func(accountID, name, miner, conn string, hashRate *big.Rat)
 	// MonitorCycle represents the time monitoring a mining client to access
 	// possible upgrades if needed.
 	MonitorCycle time.Duration
@@ -108,6 +118,9 @@ type ClientConfig struct {
 	// RollWorkCycle represents the tick interval for asserting the need for
 	// timestamp-rolled work.
 	RollWorkCycle time.Duration
+	// IsBanned returns whether the provided IP address or account id is
+	// banned from the pool.
+	IsBanned func(string) bool
 }
 
 // Client represents a client connection.
@@ -116,11 +129,13 @@ type Client struct {
 	lastWorkTime int64 // update atomically.
 
 	// These fields track the miner identification and associated
-	// difficulty info.
-	miner    string
-	id       string
-	diffInfo *DifficultyInfo
-	mtx      sync.RWMutex
+	// difficulty info. Forced difficulties are set by pool admins and are
+	// not upgraded.
+	miner      string
+	id         string
+	diffInfo   *DifficultyInfo
+	diffForced bool
+	mtx        sync.RWMutex
 
 	addr        *net.TCPAddr
 	cfg         *ClientConfig
@@ -210,6 +225,10 @@ func (c *Client) claimWeightedShare() error {
 		return errs.PoolError(errs.ClaimShare, desc)
 	}
 	weight := ShareWeights[miner]
+	if c.cfg.slicer != nil {
+		share := c.cfg.slicer.share(c.account, weight)
+		return c.cfg.db.aggregateShare(share)
+	}
 	share := NewShare(c.account, weight)
 	return c.cfg.db.PersistShare(share)
 }
@@ -260,8 +279,18 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) error {
 			return err
 		}
 
-		// Create the account if it does not already exist.
+		// Reject banned accounts.
 		account := NewAccount(address)
+		if c.cfg.IsBanned(account.UUID) {
+			err := fmt.Errorf("account %s is banned", address)
+			sErr := NewStratumError(Unknown, err)
+			resp := AuthorizeResponse(*req.ID, false, sErr)
+			c.ch <- resp
+			c.cancel()
+			return errs.PoolError(errs.Unauthorized, err.Error())
+		}
+
+		// Create the account if it does not already exist.
 		err = c.cfg.db.persistAccount(account)
 		if err != nil {
 			// Do not error if the account already exists.
@@ -336,6 +365,11 @@ func (c *Client) monitor(idx int, pair *minerIDPair, monitorCycle time.Duration,
 			// Update the miner's details and send a new mining.set_difficulty
 			// message to the client.
 			c.mtx.Lock()
+			if c.diffForced {
+				// Forced difficulties are not upgraded.
+				c.mtx.Unlock()
+				return
+			}
 			miner := pair.miners[idx]
 			newID := fmt.Sprintf("%v/%v", c.extraNonce1, miner)
 			log.Infof("upgrading %s to %s", c.id, newID)
@@ -497,6 +531,46 @@ func (c *Client) setDifficulty() {
 	c.ch <- diffNotif
 }
 
+// forceDifficulty overrides the pool client's difficulty with the provided
+// difficulty and sends it to the client along with timestamp-rolled work.
+// Forced difficulties are not upgraded by the miner monitor.
+func (c *Client) forceDifficulty(diff *big.Rat) error {
+	const funcName = "forceDifficulty"
+	if diff.Cmp(new(big.Rat).SetInt64(1)) < 0 {
+		desc := fmt.Sprintf("%s: difficulty must be at least 1, got %s",
+			funcName, diff.FloatString(3))
+		return errs.PoolError(errs.LowDifficulty, desc)
+	}
+
+	c.mtx.Lock()
+	if c.diffInfo == nil {
+		c.mtx.Unlock()
+		desc := fmt.Sprintf("%s: client %s is not subscribed", funcName,
+			c.extraNonce1)
+		return errs.PoolError(errs.ValueNotFound, desc)
+	}
+	c.diffInfo = &DifficultyInfo{
+		target:     DifficultyToTarget(c.cfg.ActiveNet, diff),
+		difficulty: diff,
+		powLimit:   c.diffInfo.powLimit,
+	}
+	c.diffForced = true
+	id := c.id
+	c.mtx.Unlock()
+
+	diffNotif := SetDifficultyNotification(new(big.Rat).Set(diff))
+	select {
+	case c.ch <- diffNotif:
+	case <-c.ctx.Done():
+		desc := fmt.Sprintf("%s: client %s disconnected", funcName, id)
+		return errs.PoolError(errs.Disconnected, desc)
+	}
+	log.Infof("forced difficulty (%s) for %s sent", diff.FloatString(3), id)
+	c.updateWork(true)
+
+	return nil
+}
+
 // handleSubmitWorkRequest processes work submission request messages received.
 func (c *Client) handleSubmitWorkRequest(ctx context.Context, req *Request, allowed bool) error {
 	if !allowed {
@@ -642,6 +716,10 @@ func (c *Client) handleSubmitWorkRequest(ctx context.Context, req *Request, allo
 	// by the mining node.
 	work := NewAcceptedWork(hash.String(), header.PrevBlock.String(),
 		header.Height, c.account, miner)
+	if c.cfg.slicer != nil {
+		// Shares claimed from now on are paid for by the next block found.
+		work.CreatedOn = c.cfg.slicer.cut()
+	}
 	err = c.cfg.db.persistAcceptedWork(work)
 	if err != nil {
 		// If the submitted accepted work already exists, ignore the
@@ -658,6 +736,8 @@ func (c *Client) handleSubmitWorkRequest(ctx context.Context, req *Request, allo
 		return err
 	}
 	log.Tracef("Work %s accepted by the network", hash.String())
+	c.cfg.NotifyWebhooks(WebhookBlockFound, work,
+		map[string]interface{}{c.account: work})
 	resp := SubmitWorkResponse(*req.ID, true, nil)
 	c.ch <- resp
 	return nil
@@ -1165,6 +1245,9 @@ func (c *Client) hashMonitor() {
 			miner := c.miner
 			c.mtx.RUnlock()
 
+			c.cfg.RecordWorkerActivity(c.account, c.name, miner,
+				c.extraNonce1, hash)
+
 			hashID := hashDataID(c.account, c.extraNonce1)
 			hashData, err := c.cfg.db.fetchHashData(hashID)
 			if err != nil {
@@ -64,9 +64,14 @@ var (
 		SignalCache: func(_ CacheUpdateEvent) {
 			// Do nothing.
 		},
-		MonitorCycle:    time.Minute,
-		MaxUpgradeTries: 5,
-		RollWorkCycle:   rollWorkCycle,
+		NotifyWebhooks:       func(string, interface{}, map[string]interface{}) {},
+		RecordWorkerActivity: func(string, string, string, string, *big.Rat) {},
+		MonitorCycle:         time.Minute,
+		MaxUpgradeTries:      5,
+		RollWorkCycle:        rollWorkCycle,
+		IsBanned: func(string) bool {
+			return false
+		},
 	}
 	userAgent = func(miner, version string) string {
 		return fmt.Sprintf("%s/%s", miner, version)
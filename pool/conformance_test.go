@@ -0,0 +1,1481 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// conformanceSetup creates an empty database for a single conformance test.
+// It returns the database along with a func releasing it, which is called
+// once the test completes.
+type conformanceSetup func(t *testing.T) (Database, func())
+
+// Conformance test accounts.
+var (
+	conformAddrX = "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"
+	conformAddrY = "Ssp7J7TUmi5iPhoQnWYNGQbeGhu6V3otJcS"
+	conformIDX   = AccountID(conformAddrX)
+	conformIDY   = AccountID(conformAddrY)
+)
+
+// conformanceTests are the tests every Database implementation must pass.
+var conformanceTests = []struct {
+	name string
+	test func(*testing.T, Database)
+}{
+	{"Metadata", conformMetadata},
+	{"Account", conformAccount},
+	{"Payment", conformPayment},
+	{"PaymentAccessors", conformPaymentAccessors},
+	{"ArchivedPayments", conformArchivedPayments},
+	{"PaymentBooking", conformPaymentBooking},
+	{"EligibleShares", conformEligibleShares},
+	{"AggregateShare", conformAggregateShare},
+	{"AcceptedWork", conformAcceptedWork},
+	{"Job", conformJob},
+	{"HashData", conformHashData},
+	{"APIToken", conformAPIToken},
+	{"AdminUser", conformAdminUser},
+	{"AuditLog", conformAuditLog},
+	{"Ban", conformBan},
+	{"Ledger", conformLedger},
+	{"BlockEvent", conformBlockEvent},
+	{"Webhook", conformWebhook},
+	{"WebhookDelivery", conformWebhookDelivery},
+	{"Worker", conformWorker},
+	{"WorkerEvent", conformWorkerEvent},
+	{"ImportRecords", conformImportRecords},
+}
+
+// runDatabaseConformance runs the database conformance tests against
+// databases created by the provided setup func, each test starting from an
+// empty database.
+//
+// The tests pin down the behaviour shared by all Database implementations:
+//   - fetching, updating or loading an entity which does not exist returns
+//     an errs.ValueNotFound error, updating a missing payment is a no-op
+//   - persisting or importing an entity which already exists returns an
+//     errs.ValueFound error, except for bans which are replaced
+//   - deleting an entity which does not exist is a no-op
+//   - aggregating a share adds its weight to the share with the same id,
+//     persisting the share if there is none
+//   - lists are ordered by their documented key, ties are ordered by id
+//   - time and height bounds are exclusive, except for ppsEligibleShares and
+//     maturePendingPayments which include entities at the bound
+func runDatabaseConformance(t *testing.T, setup conformanceSetup) {
+	for _, tc := range conformanceTests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			db, teardown := setup(t)
+			defer teardown()
+			tc.test(t, db)
+		})
+	}
+}
+
+func TestDatabaseConformance(t *testing.T) {
+	d, err := ioutil.TempDir("", "dcrpool_test_conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	var n int
+	nextPath := func(ext string) string {
+		n++
+		return filepath.Join(d, fmt.Sprintf("db%d.%s", n, ext))
+	}
+
+	t.Run("Memory", func(t *testing.T) {
+		runDatabaseConformance(t, func(t *testing.T) (Database, func()) {
+			return NewMemoryDB(), func() {}
+		})
+	})
+
+	t.Run("Bolt", func(t *testing.T) {
+		runDatabaseConformance(t, func(t *testing.T) (Database, func()) {
+			bdb, err := InitBoltDB(nextPath("db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return bdb, func() { bdb.Close() }
+		})
+	})
+
+	t.Run("SQLite", func(t *testing.T) {
+		runDatabaseConformance(t, func(t *testing.T) (Database, func()) {
+			sdb, err := InitSQLiteDB(nextPath("sqlite"), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sdb, func() { sdb.Close() }
+		})
+	})
+
+	t.Run("Postgres", func(t *testing.T) {
+		pdb, err := openPostgresDB(testPGConfig())
+		if err != nil {
+			t.Skipf("postgres unavailable: %v", err)
+		}
+		pdb.Close()
+
+		runDatabaseConformance(t, func(t *testing.T) (Database, func()) {
+			pdb, err := InitPostgresDB(testPGConfig(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return pdb, func() {
+				pdb.purge()
+				pdb.Close()
+			}
+		})
+	})
+}
+
+// conformErr asserts the provided error is of the expected kind, or nil if
+// no kind is expected.
+func conformErr(t *testing.T, op string, err error, kind errs.ErrorKind) {
+	t.Helper()
+	if kind == "" {
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", op, err)
+		}
+		return
+	}
+	if !errors.Is(err, kind) {
+		t.Fatalf("%s: expected %v error, got %v", op, kind, err)
+	}
+}
+
+// conformIDs asserts the provided ids match the expected ids, in order.
+func conformIDs(t *testing.T, op string, got []string, want ...string) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s: expected ids %q, got %q", op, want, got)
+	}
+}
+
+// conformEqual asserts the provided entities have the same json encoding.
+func conformEqual(t *testing.T, op string, got interface{}, want interface{}) {
+	t.Helper()
+	gotB, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantB, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotB) != string(wantB) {
+		t.Fatalf("%s: expected %s, got %s", op, wantB, gotB)
+	}
+}
+
+func paymentIDs(pmts []*Payment) []string {
+	ids := make([]string, 0, len(pmts))
+	for _, pmt := range pmts {
+		ids = append(ids, pmt.UUID)
+	}
+	return ids
+}
+
+func shareIDs(shares []*Share) []string {
+	ids := make([]string, 0, len(shares))
+	for _, share := range shares {
+		ids = append(ids, share.UUID)
+	}
+	return ids
+}
+
+func workIDs(work []*AcceptedWork) []string {
+	ids := make([]string, 0, len(work))
+	for _, w := range work {
+		ids = append(ids, w.UUID)
+	}
+	return ids
+}
+
+// newConformPayment creates a pending payment with a deterministic id.
+func newConformPayment(account string, height uint32, createdOn int64, estMaturity uint32, blockHash string) *Payment {
+	return &Payment{
+		UUID:              paymentID(height, createdOn, account),
+		Account:           account,
+		EstimatedMaturity: estMaturity,
+		Height:            height,
+		Amount:            100,
+		CreatedOn:         createdOn,
+		Source:            &PaymentSource{BlockHash: blockHash, Coinbase: "cb"},
+	}
+}
+
+// newConformShare creates a share with a deterministic id.
+func newConformShare(account string, createdOn int64) *Share {
+	return &Share{
+		UUID:      shareID(account, createdOn),
+		Account:   account,
+		Weight:    big.NewRat(1, 2),
+		CreatedOn: createdOn,
+	}
+}
+
+func conformMetadata(t *testing.T, db Database) {
+	_, err := db.fetchPoolMode()
+	conformErr(t, "fetchPoolMode", err, errs.ValueNotFound)
+	_, err = db.fetchCSRFSecret()
+	conformErr(t, "fetchCSRFSecret", err, errs.ValueNotFound)
+	_, _, err = db.loadLastPaymentInfo()
+	conformErr(t, "loadLastPaymentInfo", err, errs.ValueNotFound)
+	_, err = db.loadLastPaymentCreatedOn()
+	conformErr(t, "loadLastPaymentCreatedOn", err, errs.ValueNotFound)
+	_, _, err = db.loadChainTip()
+	conformErr(t, "loadChainTip", err, errs.ValueNotFound)
+	_, err = db.fetchPendingPayout()
+	conformErr(t, "fetchPendingPayout", err, errs.ValueNotFound)
+
+	// Ensure persisted metadata can be loaded and persisting it again
+	// replaces it.
+	for _, v := range []uint32{1, 0} {
+		err = db.persistPoolMode(v)
+		conformErr(t, "persistPoolMode", err, "")
+		mode, err := db.fetchPoolMode()
+		conformErr(t, "fetchPoolMode", err, "")
+		if mode != v {
+			t.Fatalf("expected pool mode %d, got %d", v, mode)
+		}
+
+		secret := []byte{byte(v), 1, 2, 3}
+		err = db.persistCSRFSecret(secret)
+		conformErr(t, "persistCSRFSecret", err, "")
+		fetched, err := db.fetchCSRFSecret()
+		conformErr(t, "fetchCSRFSecret", err, "")
+		conformEqual(t, "fetchCSRFSecret", fetched, secret)
+
+		err = db.persistLastPaymentInfo(v+10, int64(v)+100)
+		conformErr(t, "persistLastPaymentInfo", err, "")
+		height, paidOn, err := db.loadLastPaymentInfo()
+		conformErr(t, "loadLastPaymentInfo", err, "")
+		if height != v+10 || paidOn != int64(v)+100 {
+			t.Fatalf("expected last payment info (%d, %d), got (%d, %d)",
+				v+10, int64(v)+100, height, paidOn)
+		}
+
+		err = db.persistLastPaymentCreatedOn(int64(v) + 1000)
+		conformErr(t, "persistLastPaymentCreatedOn", err, "")
+		createdOn, err := db.loadLastPaymentCreatedOn()
+		conformErr(t, "loadLastPaymentCreatedOn", err, "")
+		if createdOn != int64(v)+1000 {
+			t.Fatalf("expected last payment created-on %d, got %d",
+				int64(v)+1000, createdOn)
+		}
+
+		tipHash := chainhash.Hash{byte(v)}.String()
+		err = db.persistChainTip(tipHash, v+20)
+		conformErr(t, "persistChainTip", err, "")
+		hash, height, err := db.loadChainTip()
+		conformErr(t, "loadChainTip", err, "")
+		if hash != tipHash || height != v+20 {
+			t.Fatalf("expected chain tip (%s, %d), got (%s, %d)",
+				tipHash, v+20, hash, height)
+		}
+
+		payout := &PendingPayout{
+			TxID:        chainhash.Hash{byte(v)}.String(),
+			Height:      v + 30,
+			Transaction: "0100",
+			Outputs: map[string]dcrutil.Amount{
+				conformAddrX: dcrutil.Amount(v) + 100,
+				conformAddrY: 200,
+			},
+			FeeAddress: conformAddrY,
+			Total:      dcrutil.Amount(v) + 300,
+			TxFee:      10,
+			Payments:   []string{"a", "b"},
+			CreatedOn:  int64(v) + 3000,
+		}
+		err = db.persistPendingPayout(payout)
+		conformErr(t, "persistPendingPayout", err, "")
+		fetchedPayout, err := db.fetchPendingPayout()
+		conformErr(t, "fetchPendingPayout", err, "")
+		conformEqual(t, "fetchPendingPayout", fetchedPayout, payout)
+	}
+
+	// Ensure deleting the pending payout removes it and deleting it again
+	// is a no-op.
+	err = db.deletePendingPayout()
+	conformErr(t, "deletePendingPayout", err, "")
+	_, err = db.fetchPendingPayout()
+	conformErr(t, "fetchPendingPayout", err, errs.ValueNotFound)
+	err = db.deletePendingPayout()
+	conformErr(t, "deletePendingPayout", err, "")
+}
+
+func conformAccount(t *testing.T, db Database) {
+	acc := NewAccount(conformAddrX)
+	_, err := db.fetchAccount(acc.UUID)
+	conformErr(t, "fetchAccount", err, errs.ValueNotFound)
+
+	// Ensure persisting an account sets its creation time.
+	err = db.persistAccount(acc)
+	conformErr(t, "persistAccount", err, "")
+	if acc.CreatedOn == 0 {
+		t.Fatal("expected persistAccount to set the account creation time")
+	}
+	fetched, err := db.fetchAccount(acc.UUID)
+	conformErr(t, "fetchAccount", err, "")
+	conformEqual(t, "fetchAccount", fetched, acc)
+
+	err = db.persistAccount(NewAccount(conformAddrX))
+	conformErr(t, "persistAccount", err, errs.ValueFound)
+
+	err = db.deleteAccount(acc.UUID)
+	conformErr(t, "deleteAccount", err, "")
+	_, err = db.fetchAccount(acc.UUID)
+	conformErr(t, "fetchAccount", err, errs.ValueNotFound)
+	err = db.deleteAccount(acc.UUID)
+	conformErr(t, "deleteAccount", err, "")
+}
+
+func conformPayment(t *testing.T, db Database) {
+	pmt := newConformPayment("a", 10, 100, 20, "hasha")
+	_, err := db.fetchPayment(pmt.UUID)
+	conformErr(t, "fetchPayment", err, errs.ValueNotFound)
+
+	err = db.PersistPayment(pmt)
+	conformErr(t, "PersistPayment", err, "")
+	fetched, err := db.fetchPayment(pmt.UUID)
+	conformErr(t, "fetchPayment", err, "")
+	conformEqual(t, "fetchPayment", fetched, pmt)
+
+	err = db.PersistPayment(pmt)
+	conformErr(t, "PersistPayment", err, errs.ValueFound)
+
+	pmt.TransactionID = chainhash.Hash{1}.String()
+	pmt.PaidOnHeight = 21
+	err = db.updatePayment(pmt)
+	conformErr(t, "updatePayment", err, "")
+	fetched, err = db.fetchPayment(pmt.UUID)
+	conformErr(t, "fetchPayment", err, "")
+	conformEqual(t, "fetchPayment", fetched, pmt)
+
+	// Ensure updating a payment which does not exist neither errors nor
+	// creates it.
+	missing := newConformPayment("b", 10, 100, 20, "hasha")
+	err = db.updatePayment(missing)
+	conformErr(t, "updatePayment", err, "")
+	_, err = db.fetchPayment(missing.UUID)
+	conformErr(t, "fetchPayment", err, errs.ValueNotFound)
+
+	err = db.deletePayment(pmt.UUID)
+	conformErr(t, "deletePayment", err, "")
+	_, err = db.fetchPayment(pmt.UUID)
+	conformErr(t, "fetchPayment", err, errs.ValueNotFound)
+	err = db.deletePayment(pmt.UUID)
+	conformErr(t, "deletePayment", err, "")
+}
+
+func conformPaymentAccessors(t *testing.T, db Database) {
+	// Payments a and b tie on height and creation time and are ordered by
+	// id, c is older and d is paid.
+	pmtA := newConformPayment("a", 10, 100, 20, "hasha")
+	pmtB := newConformPayment("b", 10, 100, 20, "hasha")
+	pmtC := newConformPayment("a", 9, 200, 19, "hashb")
+	pmtD := newConformPayment("a", 11, 50, 21, "hasha")
+	pmtD.PaidOnHeight = 30
+	for _, pmt := range []*Payment{pmtD, pmtB, pmtA, pmtC} {
+		err := db.PersistPayment(pmt)
+		conformErr(t, "PersistPayment", err, "")
+	}
+
+	pending, err := db.fetchPendingPayments()
+	conformErr(t, "fetchPendingPayments", err, "")
+	conformIDs(t, "fetchPendingPayments", paymentIDs(pending),
+		pmtC.UUID, pmtA.UUID, pmtB.UUID)
+
+	// Payments at height are unpaid payments past their spendable height.
+	atHeight := []struct {
+		height uint32
+		want   []string
+	}{
+		{20, nil},
+		{21, []string{pmtC.UUID}},
+		{22, []string{pmtC.UUID, pmtA.UUID, pmtB.UUID}},
+	}
+	for _, tc := range atHeight {
+		pmts, err := db.fetchPaymentsAtHeight(tc.height)
+		conformErr(t, "fetchPaymentsAtHeight", err, "")
+		conformIDs(t, "fetchPaymentsAtHeight", paymentIDs(pmts), tc.want...)
+	}
+
+	// Mature payments are unpaid payments at or past their spendable
+	// height, grouped by source block.
+	mature := []struct {
+		height uint32
+		want   map[string][]string
+	}{
+		{19, map[string][]string{}},
+		{20, map[string][]string{"hashb": {pmtC.UUID}}},
+		{21, map[string][]string{
+			"hasha": {pmtA.UUID, pmtB.UUID},
+			"hashb": {pmtC.UUID},
+		}},
+	}
+	for _, tc := range mature {
+		set, err := db.maturePendingPayments(tc.height)
+		conformErr(t, "maturePendingPayments", err, "")
+		if len(set) != len(tc.want) {
+			t.Fatalf("maturePendingPayments: expected %d sources at height "+
+				"%d, got %d", len(tc.want), tc.height, len(set))
+		}
+		for hash, want := range tc.want {
+			conformIDs(t, "maturePendingPayments", paymentIDs(set[hash]),
+				want...)
+		}
+	}
+
+	counts := map[string]uint32{"hasha": 2, "hashb": 1, "hashc": 0}
+	for hash, want := range counts {
+		count, err := db.pendingPaymentsForBlockHash(hash)
+		conformErr(t, "pendingPaymentsForBlockHash", err, "")
+		if count != want {
+			t.Fatalf("pendingPaymentsForBlockHash: expected %d payments "+
+				"for %s, got %d", want, hash, count)
+		}
+	}
+}
+
+func conformArchivedPayments(t *testing.T, db Database) {
+	archived, err := db.archivedPayments()
+	conformErr(t, "archivedPayments", err, "")
+	conformIDs(t, "archivedPayments", paymentIDs(archived))
+
+	pmtA := newConformPayment("a", 10, 100, 20, "hasha")
+	pmtB := newConformPayment("b", 10, 100, 20, "hasha")
+	pmtC := newConformPayment("a", 9, 200, 19, "hashb")
+	for _, pmt := range []*Payment{pmtA, pmtB, pmtC} {
+		err := db.PersistPayment(pmt)
+		conformErr(t, "PersistPayment", err, "")
+	}
+
+	pmtA.PaidOnHeight = 30
+	pmtA.TransactionID = "txa"
+
+	// Archived payments are recreated when archived, ensure they are
+	// ordered by height then archival time, most recent first.
+	for _, pmt := range []*Payment{pmtA, pmtC, pmtB} {
+		err := db.ArchivePayment(pmt)
+		conformErr(t, "ArchivePayment", err, "")
+		_, err = db.fetchPayment(pmt.UUID)
+		conformErr(t, "fetchPayment", err, errs.ValueNotFound)
+	}
+
+	archived, err = db.archivedPayments()
+	conformErr(t, "archivedPayments", err, "")
+	want := []*Payment{pmtB, pmtA, pmtC}
+	if len(archived) != len(want) {
+		t.Fatalf("archivedPayments: expected %d payments, got %d",
+			len(want), len(archived))
+	}
+	for i, pmt := range archived {
+		if pmt.Account != want[i].Account || pmt.Height != want[i].Height ||
+			pmt.Amount != want[i].Amount ||
+			pmt.EstimatedMaturity != want[i].EstimatedMaturity ||
+			pmt.Source.BlockHash != want[i].Source.BlockHash ||
+			pmt.PaidOnHeight != want[i].PaidOnHeight ||
+			pmt.TransactionID != want[i].TransactionID {
+			t.Fatalf("archivedPayments: expected payment %d of %s at "+
+				"height %d, got %s at height %d", i, want[i].Account,
+				want[i].Height, pmt.Account, pmt.Height)
+		}
+	}
+
+	pending, err := db.fetchPendingPayments()
+	conformErr(t, "fetchPendingPayments", err, "")
+	conformIDs(t, "fetchPendingPayments", paymentIDs(pending))
+
+	counts := map[string]uint32{"hasha": 2, "hashb": 1, "hashc": 0}
+	for hash, want := range counts {
+		count, err := db.archivedPaymentsForBlockHash(hash)
+		conformErr(t, "archivedPaymentsForBlockHash", err, "")
+		if count != want {
+			t.Fatalf("archivedPaymentsForBlockHash: expected %d payments "+
+				"for %s, got %d", want, hash, count)
+		}
+	}
+}
+
+func conformPaymentBooking(t *testing.T, db Database) {
+	pmtA := newConformPayment(conformIDX, 10, 100, 20, "hasha")
+	pmtB := newConformPayment(conformIDY, 10, 100, 20, "hasha")
+	pmtC := newConformPayment(conformIDX, 11, 200, 21, "hashb")
+
+	shareA := newConformShare(conformIDX, 100)
+	shareB := newConformShare(conformIDX, 300)
+	for _, share := range []*Share{shareA, shareB} {
+		err := db.PersistShare(share)
+		conformErr(t, "PersistShare", err, "")
+	}
+
+	// Ensure payments are persisted along with their ledger entries, the
+	// last payment created on time and the pruning of the shares they pay
+	// for, and a batch including an existing payment or entry persists none
+	// of them.
+	err := db.persistPayments([]*Payment{pmtA, pmtB},
+		[]*LedgerEntry{rewardEntry(pmtA), rewardEntry(pmtB)}, 150, 200)
+	conformErr(t, "persistPayments", err, "")
+	err = db.persistPayments([]*Payment{pmtC, pmtA},
+		[]*LedgerEntry{rewardEntry(pmtC)}, 350, 400)
+	conformErr(t, "persistPayments", err, errs.ValueFound)
+	err = db.persistPayments([]*Payment{pmtC},
+		[]*LedgerEntry{rewardEntry(pmtC), rewardEntry(pmtA)}, 350, 400)
+	conformErr(t, "persistPayments", err, errs.ValueFound)
+	createdOn, err := db.loadLastPaymentCreatedOn()
+	conformErr(t, "loadLastPaymentCreatedOn", err, "")
+	if createdOn != 150 {
+		t.Fatalf("loadLastPaymentCreatedOn: expected 150, got %d", createdOn)
+	}
+	shares, err := db.ppsEligibleShares(math.MaxInt64)
+	conformErr(t, "ppsEligibleShares", err, "")
+	conformIDs(t, "ppsEligibleShares", shareIDs(shares), shareB.UUID)
+	pending, err := db.fetchPendingPayments()
+	conformErr(t, "fetchPendingPayments", err, "")
+	conformIDs(t, "fetchPendingPayments", paymentIDs(pending), pmtB.UUID,
+		pmtA.UUID)
+	entries, err := db.fetchLedgerEntries("", 0)
+	conformErr(t, "fetchLedgerEntries", err, "")
+	if len(entries) != 2 {
+		t.Fatalf("fetchLedgerEntries: expected 2 entries, got %d",
+			len(entries))
+	}
+	err = db.persistPayments([]*Payment{pmtC},
+		[]*LedgerEntry{rewardEntry(pmtC)}, 350, 400)
+	conformErr(t, "persistPayments", err, "")
+	shares, err = db.ppsEligibleShares(math.MaxInt64)
+	conformErr(t, "ppsEligibleShares", err, "")
+	conformIDs(t, "ppsEligibleShares", shareIDs(shares))
+
+	// Ensure archiving a payout archives its payments, books its entries,
+	// records the last payment info and removes the pending payout.
+	err = db.persistPendingPayout(&PendingPayout{TxID: "txa", Height: 30,
+		Payments: []string{pmtA.UUID, pmtB.UUID}})
+	conformErr(t, "persistPendingPayout", err, "")
+	for _, pmt := range []*Payment{pmtA, pmtB} {
+		pmt.PaidOnHeight = 30
+		pmt.TransactionID = "txa"
+	}
+	payout := append(payoutEntries(conformIDX, "txa", 95, 5),
+		payoutEntries(conformIDY, "txa", 95, 5)...)
+	err = db.archivePayout([]*Payment{pmtA, pmtB}, payout, 30, 3000)
+	conformErr(t, "archivePayout", err, "")
+	pending, err = db.fetchPendingPayments()
+	conformErr(t, "fetchPendingPayments", err, "")
+	conformIDs(t, "fetchPendingPayments", paymentIDs(pending), pmtC.UUID)
+	archived, err := db.archivedPayments()
+	conformErr(t, "archivedPayments", err, "")
+	if len(archived) != 2 || archived[0].TransactionID != "txa" ||
+		archived[1].TransactionID != "txa" {
+		t.Fatalf("archivedPayments: expected 2 payments paid by txa")
+	}
+	height, paidOn, err := db.loadLastPaymentInfo()
+	conformErr(t, "loadLastPaymentInfo", err, "")
+	if height != 30 || paidOn != 3000 {
+		t.Fatalf("expected last payment info (30, 3000), got (%d, %d)",
+			height, paidOn)
+	}
+	_, err = db.fetchPendingPayout()
+	conformErr(t, "fetchPendingPayout", err, errs.ValueNotFound)
+
+	// Ensure archiving a payout whose entries are already booked archives
+	// nothing.
+	pmtC.PaidOnHeight = 31
+	pmtC.TransactionID = "txa"
+	err = db.archivePayout([]*Payment{pmtC}, payout[:1], 31, 3100)
+	conformErr(t, "archivePayout", err, errs.ValueFound)
+	pending, err = db.fetchPendingPayments()
+	conformErr(t, "fetchPendingPayments", err, "")
+	conformIDs(t, "fetchPendingPayments", paymentIDs(pending), pmtC.UUID)
+	height, _, err = db.loadLastPaymentInfo()
+	conformErr(t, "loadLastPaymentInfo", err, "")
+	if height != 30 {
+		t.Fatalf("expected last payment height 30, got %d", height)
+	}
+	entries, err = db.fetchLedgerEntries("", 0)
+	conformErr(t, "fetchLedgerEntries", err, "")
+	if len(entries) != 7 {
+		t.Fatalf("fetchLedgerEntries: expected 7 entries, got %d",
+			len(entries))
+	}
+}
+
+func conformEligibleShares(t *testing.T, db Database) {
+	shareA := newConformShare("a", 100)
+	shareB := newConformShare("b", 100)
+	shareC := newConformShare("a", 200)
+	shareD := newConformShare("a", 300)
+
+	_, err := db.fetchShare(shareA.UUID)
+	conformErr(t, "fetchShare", err, errs.ValueNotFound)
+	for _, share := range []*Share{shareC, shareA, shareD, shareB} {
+		err := db.PersistShare(share)
+		conformErr(t, "PersistShare", err, "")
+	}
+	err = db.PersistShare(shareA)
+	conformErr(t, "PersistShare", err, errs.ValueFound)
+	fetched, err := db.fetchShare(shareA.UUID)
+	conformErr(t, "fetchShare", err, "")
+	conformEqual(t, "fetchShare", fetched, shareA)
+
+	// PPS eligible shares are created at or before the provided time,
+	// oldest first.
+	pps := []struct {
+		max  int64
+		want []string
+	}{
+		{99, nil},
+		{100, []string{shareA.UUID, shareB.UUID}},
+		{200, []string{shareA.UUID, shareB.UUID, shareC.UUID}},
+		{math.MaxInt64, []string{shareA.UUID, shareB.UUID, shareC.UUID,
+			shareD.UUID}},
+	}
+	for _, tc := range pps {
+		shares, err := db.ppsEligibleShares(tc.max)
+		conformErr(t, "ppsEligibleShares", err, "")
+		conformIDs(t, "ppsEligibleShares", shareIDs(shares), tc.want...)
+	}
+
+	// PPLNS eligible shares are created after the provided time, most
+	// recent first.
+	pplns := []struct {
+		min  int64
+		want []string
+	}{
+		{300, nil},
+		{200, []string{shareD.UUID}},
+		{100, []string{shareD.UUID, shareC.UUID}},
+		{99, []string{shareD.UUID, shareC.UUID, shareB.UUID, shareA.UUID}},
+	}
+	for _, tc := range pplns {
+		shares, err := db.pplnsEligibleShares(tc.min)
+		conformErr(t, "pplnsEligibleShares", err, "")
+		conformIDs(t, "pplnsEligibleShares", shareIDs(shares), tc.want...)
+	}
+
+	// Pruning removes shares created before the provided time.
+	err = db.pruneShares(200)
+	conformErr(t, "pruneShares", err, "")
+	shares, err := db.ppsEligibleShares(math.MaxInt64)
+	conformErr(t, "ppsEligibleShares", err, "")
+	conformIDs(t, "ppsEligibleShares", shareIDs(shares), shareC.UUID,
+		shareD.UUID)
+}
+
+func conformAggregateShare(t *testing.T, db Database) {
+	weight := big.NewRat(1, 3)
+	share := &Share{
+		UUID:      shareID("a", 100),
+		Account:   "a",
+		Weight:    weight,
+		CreatedOn: 100,
+	}
+
+	// Aggregating a share which does not exist persists it.
+	err := db.aggregateShare(share)
+	conformErr(t, "aggregateShare", err, "")
+	fetched, err := db.fetchShare(share.UUID)
+	conformErr(t, "fetchShare", err, "")
+	conformEqual(t, "fetchShare", fetched, share)
+
+	// Aggregating again sums the weights exactly, without modifying the
+	// weight of the provided share.
+	for i := 0; i < 2; i++ {
+		err := db.aggregateShare(share)
+		conformErr(t, "aggregateShare", err, "")
+	}
+	if weight.Cmp(big.NewRat(1, 3)) != 0 {
+		t.Fatalf("aggregateShare: provided weight modified to %v", weight)
+	}
+	fetched, err = db.fetchShare(share.UUID)
+	conformErr(t, "fetchShare", err, "")
+	if fetched.Weight.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Fatalf("aggregateShare: expected weight 1, got %v", fetched.Weight)
+	}
+
+	// Shares of other accounts or slices are aggregated separately.
+	other := newConformShare("b", 100)
+	err = db.aggregateShare(other)
+	conformErr(t, "aggregateShare", err, "")
+	shares, err := db.ppsEligibleShares(math.MaxInt64)
+	conformErr(t, "ppsEligibleShares", err, "")
+	conformIDs(t, "ppsEligibleShares", shareIDs(shares), share.UUID,
+		other.UUID)
+}
+
+func conformAcceptedWork(t *testing.T, db Database) {
+	workA := NewAcceptedWork(chainhash.Hash{1}.String(),
+		chainhash.Hash{9}.String(), 10, conformIDX, CPU)
+	workB := NewAcceptedWork(chainhash.Hash{2}.String(),
+		chainhash.Hash{9}.String(), 10, conformIDX, CPU)
+	workC := NewAcceptedWork(chainhash.Hash{3}.String(),
+		chainhash.Hash{1}.String(), 12, conformIDX, CPU)
+	workC.setStatus(WorkConfirmed)
+	workD := NewAcceptedWork(chainhash.Hash{4}.String(),
+		chainhash.Hash{1}.String(), 11, conformIDY, CPU)
+	workE := NewAcceptedWork(chainhash.Hash{5}.String(),
+		chainhash.Hash{8}.String(), 9, conformIDY, CPU)
+	workE.setStatus(WorkOrphaned)
+	workE.OrphanedBy = chainhash.Hash{6}.String()
+
+	_, err := db.fetchAcceptedWork(workA.UUID)
+	conformErr(t, "fetchAcceptedWork", err, errs.ValueNotFound)
+	err = db.updateAcceptedWork(workA)
+	conformErr(t, "updateAcceptedWork", err, errs.ValueNotFound)
+	for _, work := range []*AcceptedWork{workC, workA, workE, workD, workB} {
+		err := db.persistAcceptedWork(work)
+		conformErr(t, "persistAcceptedWork", err, "")
+	}
+	err = db.persistAcceptedWork(workA)
+	conformErr(t, "persistAcceptedWork", err, errs.ValueFound)
+
+	// Mined work is ordered by height, most recent first.
+	mined, err := db.listMinedWork()
+	conformErr(t, "listMinedWork", err, "")
+	conformIDs(t, "listMinedWork", workIDs(mined), workC.UUID, workD.UUID,
+		workB.UUID, workA.UUID, workE.UUID)
+	fetched, err := db.fetchAcceptedWork(workE.UUID)
+	conformErr(t, "fetchAcceptedWork", err, "")
+	conformEqual(t, "fetchAcceptedWork", fetched, workE)
+
+	// Unconfirmed work is below the provided height, lowest first.
+	// Orphaned work is not unconfirmed.
+	unconfirmed := []struct {
+		height uint32
+		want   []string
+	}{
+		{10, nil},
+		{11, []string{workA.UUID, workB.UUID}},
+		{13, []string{workA.UUID, workB.UUID, workD.UUID}},
+	}
+	for _, tc := range unconfirmed {
+		work, err := db.fetchUnconfirmedWork(tc.height)
+		conformErr(t, "fetchUnconfirmedWork", err, "")
+		conformIDs(t, "fetchUnconfirmedWork", workIDs(work), tc.want...)
+	}
+
+	workA.setStatus(WorkMatured)
+	err = db.updateAcceptedWork(workA)
+	conformErr(t, "updateAcceptedWork", err, "")
+	fetched, err = db.fetchAcceptedWork(workA.UUID)
+	conformErr(t, "fetchAcceptedWork", err, "")
+	conformEqual(t, "fetchAcceptedWork", fetched, workA)
+
+	err = db.deleteAcceptedWork(workA.UUID)
+	conformErr(t, "deleteAcceptedWork", err, "")
+	_, err = db.fetchAcceptedWork(workA.UUID)
+	conformErr(t, "fetchAcceptedWork", err, errs.ValueNotFound)
+	err = db.deleteAcceptedWork(workA.UUID)
+	conformErr(t, "deleteAcceptedWork", err, "")
+}
+
+func conformJob(t *testing.T, db Database) {
+	jobs := []*Job{
+		NewJob("header10", 10),
+		NewJob("header11", 11),
+		NewJob("header12", 12),
+	}
+	_, err := db.fetchJob(jobs[0].UUID)
+	conformErr(t, "fetchJob", err, errs.ValueNotFound)
+	for _, job := range jobs {
+		err := db.persistJob(job)
+		conformErr(t, "persistJob", err, "")
+	}
+	err = db.persistJob(jobs[0])
+	conformErr(t, "persistJob", err, errs.ValueFound)
+	fetched, err := db.fetchJob(jobs[0].UUID)
+	conformErr(t, "fetchJob", err, "")
+	conformEqual(t, "fetchJob", fetched, jobs[0])
+
+	// Deleting jobs before a height keeps the jobs at that height.
+	err = db.deleteJobsBeforeHeight(11)
+	conformErr(t, "deleteJobsBeforeHeight", err, "")
+	_, err = db.fetchJob(jobs[0].UUID)
+	conformErr(t, "fetchJob", err, errs.ValueNotFound)
+	for _, job := range jobs[1:] {
+		_, err = db.fetchJob(job.UUID)
+		conformErr(t, "fetchJob", err, "")
+	}
+
+	err = db.deleteJob(jobs[1].UUID)
+	conformErr(t, "deleteJob", err, "")
+	_, err = db.fetchJob(jobs[1].UUID)
+	conformErr(t, "fetchJob", err, errs.ValueNotFound)
+	err = db.deleteJob(jobs[1].UUID)
+	conformErr(t, "deleteJob", err, "")
+}
+
+func conformHashData(t *testing.T, db Database) {
+	var data []*HashData
+	for i, updatedOn := range []int64{100, 200, 300} {
+		hashData := newHashData(CPU, conformIDX, "127.0.0.1",
+			string(rune('a'+i)), big.NewRat(int64(i+1), 1))
+		hashData.UpdatedOn = updatedOn
+		data = append(data, hashData)
+	}
+
+	_, err := db.fetchHashData(data[0].UUID)
+	conformErr(t, "fetchHashData", err, errs.ValueNotFound)
+	err = db.updateHashData(data[0])
+	conformErr(t, "updateHashData", err, errs.ValueNotFound)
+	for _, hashData := range data {
+		err := db.persistHashData(hashData)
+		conformErr(t, "persistHashData", err, "")
+	}
+	err = db.persistHashData(data[0])
+	conformErr(t, "persistHashData", err, errs.ValueFound)
+
+	data[0].HashRate = big.NewRat(7, 3)
+	err = db.updateHashData(data[0])
+	conformErr(t, "updateHashData", err, "")
+	fetched, err := db.fetchHashData(data[0].UUID)
+	conformErr(t, "fetchHashData", err, "")
+	conformEqual(t, "fetchHashData", fetched, data[0])
+
+	// Listed hash data is updated after the provided time.
+	listed := []struct {
+		min  int64
+		want []string
+	}{
+		{300, nil},
+		{200, []string{data[2].UUID}},
+		{99, []string{data[0].UUID, data[1].UUID, data[2].UUID}},
+	}
+	for _, tc := range listed {
+		set, err := db.listHashData(tc.min)
+		conformErr(t, "listHashData", err, "")
+		if len(set) != len(tc.want) {
+			t.Fatalf("listHashData: expected %d entries after %d, got %d",
+				len(tc.want), tc.min, len(set))
+		}
+		for _, id := range tc.want {
+			if set[id] == nil {
+				t.Fatalf("listHashData: expected entry %s after %d",
+					id, tc.min)
+			}
+		}
+	}
+
+	// Pruning removes hash data not updated since the provided time.
+	err = db.pruneHashData(200)
+	conformErr(t, "pruneHashData", err, "")
+	_, err = db.fetchHashData(data[0].UUID)
+	conformErr(t, "fetchHashData", err, errs.ValueNotFound)
+	_, err = db.fetchHashData(data[1].UUID)
+	conformErr(t, "fetchHashData", err, "")
+}
+
+func conformAPIToken(t *testing.T, db Database) {
+	tokens := []*APIToken{
+		{UUID: "tokena", AccountID: conformIDX, Kind: APITokenKind, CreatedOn: 300},
+		{UUID: "tokenb", AccountID: conformIDX, Kind: WatcherTokenKind, CreatedOn: 200},
+		{UUID: "tokenc", AccountID: conformIDX, Kind: APITokenKind, CreatedOn: 100},
+		{UUID: "tokend", AccountID: conformIDX, Kind: APITokenKind, CreatedOn: 100},
+		{UUID: "tokene", AccountID: conformIDY, Kind: APITokenKind, CreatedOn: 100},
+	}
+	_, err := db.fetchAPIToken(tokens[0].UUID)
+	conformErr(t, "fetchAPIToken", err, errs.ValueNotFound)
+	for _, token := range tokens {
+		err := db.persistAPIToken(token)
+		conformErr(t, "persistAPIToken", err, "")
+	}
+	err = db.persistAPIToken(tokens[0])
+	conformErr(t, "persistAPIToken", err, errs.ValueFound)
+	fetched, err := db.fetchAPIToken(tokens[1].UUID)
+	conformErr(t, "fetchAPIToken", err, "")
+	conformEqual(t, "fetchAPIToken", fetched, tokens[1])
+
+	// Account tokens are ordered by creation time, oldest first.
+	listed, err := db.fetchAPITokensForAccount(conformIDX)
+	conformErr(t, "fetchAPITokensForAccount", err, "")
+	ids := make([]string, 0, len(listed))
+	for _, token := range listed {
+		ids = append(ids, token.UUID)
+	}
+	conformIDs(t, "fetchAPITokensForAccount", ids, "tokenc", "tokend",
+		"tokenb", "tokena")
+	listed, err = db.fetchAPITokensForAccount("unknown")
+	conformErr(t, "fetchAPITokensForAccount", err, "")
+	if len(listed) != 0 {
+		t.Fatalf("fetchAPITokensForAccount: expected no tokens, got %d",
+			len(listed))
+	}
+
+	err = db.deleteAPIToken(tokens[0].UUID)
+	conformErr(t, "deleteAPIToken", err, "")
+	_, err = db.fetchAPIToken(tokens[0].UUID)
+	conformErr(t, "fetchAPIToken", err, errs.ValueNotFound)
+	err = db.deleteAPIToken(tokens[0].UUID)
+	conformErr(t, "deleteAPIToken", err, "")
+}
+
+func conformAdminUser(t *testing.T, db Database) {
+	users := []*AdminUser{
+		{UUID: "carol", PasswordHash: "hashc", Role: RoleViewer, CreatedOn: 1},
+		{UUID: "alice", PasswordHash: "hasha", Role: RoleOperator, CreatedOn: 3},
+		{UUID: "bob", PasswordHash: "hashb", Role: RoleViewer, CreatedOn: 2},
+	}
+	_, err := db.fetchAdminUser(users[0].UUID)
+	conformErr(t, "fetchAdminUser", err, errs.ValueNotFound)
+	err = db.updateAdminUser(users[0])
+	conformErr(t, "updateAdminUser", err, errs.ValueNotFound)
+	for _, user := range users {
+		err := db.persistAdminUser(user)
+		conformErr(t, "persistAdminUser", err, "")
+	}
+	err = db.persistAdminUser(users[0])
+	conformErr(t, "persistAdminUser", err, errs.ValueFound)
+
+	users[0].TOTPSecret = "secret"
+	err = db.updateAdminUser(users[0])
+	conformErr(t, "updateAdminUser", err, "")
+	fetched, err := db.fetchAdminUser(users[0].UUID)
+	conformErr(t, "fetchAdminUser", err, "")
+	conformEqual(t, "fetchAdminUser", fetched, users[0])
+
+	// Admin users are ordered by name.
+	listed, err := db.listAdminUsers()
+	conformErr(t, "listAdminUsers", err, "")
+	ids := make([]string, 0, len(listed))
+	for _, user := range listed {
+		ids = append(ids, user.UUID)
+	}
+	conformIDs(t, "listAdminUsers", ids, "alice", "bob", "carol")
+
+	err = db.deleteAdminUser(users[0].UUID)
+	conformErr(t, "deleteAdminUser", err, "")
+	_, err = db.fetchAdminUser(users[0].UUID)
+	conformErr(t, "fetchAdminUser", err, errs.ValueNotFound)
+	err = db.deleteAdminUser(users[0].UUID)
+	conformErr(t, "deleteAdminUser", err, "")
+}
+
+func conformAuditLog(t *testing.T, db Database) {
+	entries, err := db.fetchAuditEntries(0)
+	conformErr(t, "fetchAuditEntries", err, "")
+	if len(entries) != 0 {
+		t.Fatalf("fetchAuditEntries: expected no entries, got %d",
+			len(entries))
+	}
+
+	newEntry := func(actor string, createdOn int64) *AuditEntry {
+		entry := newAuditEntry(actor, AuditLogin, "", "127.0.0.1")
+		entry.UUID = auditEntryID(actor, createdOn)
+		entry.CreatedOn = createdOn
+		return entry
+	}
+	entryA := newEntry("alice", 100)
+	entryB := newEntry("bob", 100)
+	entryC := newEntry("alice", 200)
+	for _, entry := range []*AuditEntry{entryB, entryC, entryA} {
+		err := db.persistAuditEntry(entry)
+		conformErr(t, "persistAuditEntry", err, "")
+	}
+	err = db.persistAuditEntry(entryA)
+	conformErr(t, "persistAuditEntry", err, errs.ValueFound)
+
+	// Audit entries are ordered by creation time, newest first. A limit
+	// which is not positive returns all entries.
+	limits := []struct {
+		limit int
+		want  []string
+	}{
+		{-1, []string{entryC.UUID, entryB.UUID, entryA.UUID}},
+		{0, []string{entryC.UUID, entryB.UUID, entryA.UUID}},
+		{2, []string{entryC.UUID, entryB.UUID}},
+		{10, []string{entryC.UUID, entryB.UUID, entryA.UUID}},
+	}
+	for _, tc := range limits {
+		entries, err := db.fetchAuditEntries(tc.limit)
+		conformErr(t, "fetchAuditEntries", err, "")
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.UUID)
+		}
+		conformIDs(t, "fetchAuditEntries", ids, tc.want...)
+	}
+	entries, err = db.fetchAuditEntries(1)
+	conformErr(t, "fetchAuditEntries", err, "")
+	conformEqual(t, "fetchAuditEntries", entries[0], entryC)
+}
+
+func conformBan(t *testing.T, db Database) {
+	bans := []*Ban{
+		{UUID: "10.0.0.2", Kind: IPBanKind, Reason: "spam", CreatedOn: 200},
+		{UUID: "10.0.0.1", Kind: IPBanKind, Reason: "spam", CreatedOn: 200},
+		{UUID: conformIDX, Kind: AccountBanKind, Reason: "abuse", CreatedOn: 100},
+	}
+	for _, ban := range bans {
+		err := db.persistBan(ban)
+		conformErr(t, "persistBan", err, "")
+	}
+
+	listIDs := func() []string {
+		t.Helper()
+		listed, err := db.listBans()
+		conformErr(t, "listBans", err, "")
+		ids := make([]string, 0, len(listed))
+		for _, ban := range listed {
+			ids = append(ids, ban.UUID)
+		}
+		return ids
+	}
+
+	// Bans are ordered by creation time, oldest first.
+	conformIDs(t, "listBans", listIDs(), conformIDX, "10.0.0.1", "10.0.0.2")
+
+	// Persisting an existing ban replaces it.
+	replaced := &Ban{UUID: "10.0.0.2", Kind: IPBanKind, Reason: "flood",
+		CreatedOn: 50}
+	err := db.persistBan(replaced)
+	conformErr(t, "persistBan", err, "")
+	listed, err := db.listBans()
+	conformErr(t, "listBans", err, "")
+	if len(listed) != len(bans) {
+		t.Fatalf("listBans: expected %d bans, got %d", len(bans), len(listed))
+	}
+	conformEqual(t, "listBans", listed[0], replaced)
+
+	err = db.deleteBan(conformIDX)
+	conformErr(t, "deleteBan", err, "")
+	conformIDs(t, "listBans", listIDs(), "10.0.0.2", "10.0.0.1")
+	err = db.deleteBan(conformIDX)
+	conformErr(t, "deleteBan", err, "")
+}
+
+func conformLedger(t *testing.T, db Database) {
+	entries, err := db.fetchLedgerEntries("", 0)
+	conformErr(t, "fetchLedgerEntries", err, "")
+	if len(entries) != 0 {
+		t.Fatalf("fetchLedgerEntries: expected no entries, got %d",
+			len(entries))
+	}
+
+	newEntry := func(kind, ref, debit, credit string, amount dcrutil.Amount, createdOn int64) *LedgerEntry {
+		entry := newLedgerEntry(kind, ref, debit, credit, amount)
+		entry.CreatedOn = createdOn
+		return entry
+	}
+	rewardX := newEntry(LedgerShareReward, "a", LedgerCoinbase, conformIDX,
+		300, 100)
+	rewardY := newEntry(LedgerShareReward, "b", LedgerCoinbase, conformIDY,
+		200, 100)
+	fee := newEntry(LedgerPoolFee, "c", LedgerCoinbase, PoolFeesK, 10, 100)
+	payoutX := newEntry(LedgerPayout, "d", conformIDX, LedgerPayouts, 250,
+		200)
+	txFeeX := newEntry(LedgerTxFee, "d", conformIDX, LedgerTxFees, 5, 200)
+	adjustY := newEntry(LedgerAdjustment, "e", conformIDY, LedgerAdjustments,
+		20, 300)
+	adjustY.Actor = "alice"
+	adjustY.Memo = "refund"
+
+	err = db.persistLedgerEntries([]*LedgerEntry{rewardX, rewardY, fee})
+	conformErr(t, "persistLedgerEntries", err, "")
+	err = db.persistLedgerEntries([]*LedgerEntry{payoutX, txFeeX, adjustY})
+	conformErr(t, "persistLedgerEntries", err, "")
+
+	// Persisting entries is atomic, a batch including an existing entry
+	// persists none of them.
+	extra := newEntry(LedgerShareReward, "f", LedgerCoinbase, conformIDX,
+		1, 400)
+	err = db.persistLedgerEntries([]*LedgerEntry{extra, rewardX})
+	conformErr(t, "persistLedgerEntries", err, errs.ValueFound)
+
+	// Ledger entries are ordered by creation time, newest first. Entries are
+	// filtered by the ledger account they debit or credit. A limit which is
+	// not positive returns all entries.
+	fetches := []struct {
+		account string
+		limit   int
+		want    []string
+	}{
+		{"", 0, []string{adjustY.UUID, txFeeX.UUID, payoutX.UUID,
+			rewardY.UUID, rewardX.UUID, fee.UUID}},
+		{"", 2, []string{adjustY.UUID, txFeeX.UUID}},
+		{conformIDX, -1, []string{txFeeX.UUID, payoutX.UUID, rewardX.UUID}},
+		{conformIDY, 0, []string{adjustY.UUID, rewardY.UUID}},
+		{LedgerCoinbase, 1, []string{rewardY.UUID}},
+		{"unknown", 0, nil},
+	}
+	for _, tc := range fetches {
+		entries, err := db.fetchLedgerEntries(tc.account, tc.limit)
+		conformErr(t, "fetchLedgerEntries", err, "")
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.UUID)
+		}
+		conformIDs(t, "fetchLedgerEntries", ids, tc.want...)
+	}
+	entries, err = db.fetchLedgerEntries(conformIDY, 1)
+	conformErr(t, "fetchLedgerEntries", err, "")
+	conformEqual(t, "fetchLedgerEntries", entries[0], adjustY)
+
+	// Balances are the credited amounts minus the debited amounts and
+	// always sum to zero.
+	balances, err := db.fetchLedgerBalances()
+	conformErr(t, "fetchLedgerBalances", err, "")
+	conformEqual(t, "fetchLedgerBalances", balances,
+		map[string]dcrutil.Amount{
+			LedgerCoinbase:    -510,
+			conformIDX:        45,
+			conformIDY:        180,
+			PoolFeesK:         10,
+			LedgerPayouts:     250,
+			LedgerTxFees:      5,
+			LedgerAdjustments: 20,
+		})
+
+	// Account balances match the ledger balances, the amounts paid out are
+	// the payouts and payout transaction fees debited.
+	accBalances, err := db.fetchAccountBalances("")
+	conformErr(t, "fetchAccountBalances", err, "")
+	conformEqual(t, "fetchAccountBalances", accBalances,
+		map[string]*AccountBalance{
+			LedgerCoinbase:    {Balance: -510},
+			conformIDX:        {Balance: 45, Paid: 255},
+			conformIDY:        {Balance: 180},
+			PoolFeesK:         {Balance: 10},
+			LedgerPayouts:     {Balance: 250},
+			LedgerTxFees:      {Balance: 5},
+			LedgerAdjustments: {Balance: 20},
+		})
+	accBalances, err = db.fetchAccountBalances(conformIDX)
+	conformErr(t, "fetchAccountBalances", err, "")
+	conformEqual(t, "fetchAccountBalances", accBalances,
+		map[string]*AccountBalance{conformIDX: {Balance: 45, Paid: 255}})
+	accBalances, err = db.fetchAccountBalances("unknown")
+	conformErr(t, "fetchAccountBalances", err, "")
+	conformEqual(t, "fetchAccountBalances", accBalances,
+		map[string]*AccountBalance{})
+}
+
+func conformBlockEvent(t *testing.T, db Database) {
+	events := []*BlockEvent{
+		{UUID: "b", Kind: BlockConnectedEvent, Header: "00", Height: 11,
+			CreatedOn: 200},
+		{UUID: "a", Kind: BlockDisconnectedEvent, Header: "00", Height: 11,
+			CreatedOn: 200},
+		{UUID: "c", Kind: BlockConnectedEvent, Header: "00", Height: 10,
+			CreatedOn: 100},
+	}
+	for _, event := range events {
+		err := db.persistBlockEvent(event)
+		conformErr(t, "persistBlockEvent", err, "")
+	}
+	err := db.persistBlockEvent(events[0])
+	conformErr(t, "persistBlockEvent", err, errs.ValueFound)
+
+	listIDs := func() []string {
+		t.Helper()
+		listed, err := db.fetchBlockEvents()
+		conformErr(t, "fetchBlockEvents", err, "")
+		ids := make([]string, 0, len(listed))
+		for _, event := range listed {
+			ids = append(ids, event.UUID)
+		}
+		return ids
+	}
+
+	// Block events are ordered by creation time, oldest first.
+	conformIDs(t, "fetchBlockEvents", listIDs(), "c", "a", "b")
+
+	retried := *events[2]
+	retried.Attempts = 2
+	retried.LastError = "wallet unreachable"
+	retried.NextAttempt = 300
+	err = db.updateBlockEvent(&retried)
+	conformErr(t, "updateBlockEvent", err, "")
+	listed, err := db.fetchBlockEvents()
+	conformErr(t, "fetchBlockEvents", err, "")
+	conformEqual(t, "fetchBlockEvents", listed[0], &retried)
+
+	missing := &BlockEvent{UUID: "d", Kind: BlockConnectedEvent}
+	err = db.updateBlockEvent(missing)
+	conformErr(t, "updateBlockEvent", err, errs.ValueNotFound)
+
+	err = db.deleteBlockEvent("c")
+	conformErr(t, "deleteBlockEvent", err, "")
+	conformIDs(t, "fetchBlockEvents", listIDs(), "a", "b")
+	err = db.deleteBlockEvent("c")
+	conformErr(t, "deleteBlockEvent", err, "")
+}
+
+func conformWebhook(t *testing.T, db Database) {
+	hooks := []*Webhook{
+		{UUID: "hooka", AccountID: conformIDX, URL: "https://a.example",
+			Secret: "secreta", CreatedOn: 300},
+		{UUID: "hookb", AccountID: conformIDX, URL: "https://b.example",
+			Secret: "secretb", CreatedOn: 100},
+		{UUID: "hookc", AccountID: conformIDX, URL: "https://c.example",
+			Secret: "secretc", CreatedOn: 200},
+		{UUID: "hookd", AccountID: conformIDY, URL: "https://d.example",
+			Secret: "secretd", CreatedOn: 100},
+	}
+	_, err := db.fetchWebhook(hooks[0].UUID)
+	conformErr(t, "fetchWebhook", err, errs.ValueNotFound)
+	for _, hook := range hooks {
+		err := db.persistWebhook(hook)
+		conformErr(t, "persistWebhook", err, "")
+	}
+	err = db.persistWebhook(hooks[0])
+	conformErr(t, "persistWebhook", err, errs.ValueFound)
+	fetched, err := db.fetchWebhook(hooks[1].UUID)
+	conformErr(t, "fetchWebhook", err, "")
+	conformEqual(t, "fetchWebhook", fetched, hooks[1])
+
+	listIDs := func(accountID string) []string {
+		t.Helper()
+		listed, err := db.fetchWebhooksForAccount(accountID)
+		conformErr(t, "fetchWebhooksForAccount", err, "")
+		ids := make([]string, 0, len(listed))
+		for _, hook := range listed {
+			ids = append(ids, hook.UUID)
+		}
+		return ids
+	}
+
+	// Account webhooks are ordered by creation time, oldest first.
+	conformIDs(t, "fetchWebhooksForAccount", listIDs(conformIDX), "hookb",
+		"hookc", "hooka")
+	conformIDs(t, "fetchWebhooksForAccount", listIDs("unknown"))
+
+	err = db.deleteWebhook(hooks[0].UUID)
+	conformErr(t, "deleteWebhook", err, "")
+	_, err = db.fetchWebhook(hooks[0].UUID)
+	conformErr(t, "fetchWebhook", err, errs.ValueNotFound)
+	err = db.deleteWebhook(hooks[0].UUID)
+	conformErr(t, "deleteWebhook", err, "")
+}
+
+func conformWebhookDelivery(t *testing.T, db Database) {
+	deliveries := []*WebhookDelivery{
+		{UUID: "b", URL: "https://a.example", Event: WebhookBlockFound,
+			Payload: "{}", CreatedOn: 200},
+		{UUID: "a", WebhookID: "hooka", URL: "https://b.example",
+			Event: WebhookBlockFound, Payload: "{}", CreatedOn: 200},
+		{UUID: "c", URL: "https://a.example", Event: WebhookWalletProblem,
+			Payload: "{}", CreatedOn: 100},
+	}
+	for _, delivery := range deliveries {
+		err := db.persistWebhookDelivery(delivery)
+		conformErr(t, "persistWebhookDelivery", err, "")
+	}
+	err := db.persistWebhookDelivery(deliveries[0])
+	conformErr(t, "persistWebhookDelivery", err, errs.ValueFound)
+
+	listIDs := func() []string {
+		t.Helper()
+		listed, err := db.fetchWebhookDeliveries()
+		conformErr(t, "fetchWebhookDeliveries", err, "")
+		ids := make([]string, 0, len(listed))
+		for _, delivery := range listed {
+			ids = append(ids, delivery.UUID)
+		}
+		return ids
+	}
+
+	// Webhook deliveries are ordered by creation time, oldest first.
+	conformIDs(t, "fetchWebhookDeliveries", listIDs(), "c", "a", "b")
+
+	retried := *deliveries[2]
+	retried.Attempts = 2
+	retried.LastError = "connection refused"
+	retried.NextAttempt = 300
+	err = db.updateWebhookDelivery(&retried)
+	conformErr(t, "updateWebhookDelivery", err, "")
+	listed, err := db.fetchWebhookDeliveries()
+	conformErr(t, "fetchWebhookDeliveries", err, "")
+	conformEqual(t, "fetchWebhookDeliveries", listed[0], &retried)
+
+	missing := &WebhookDelivery{UUID: "d", Event: WebhookBlockFound}
+	err = db.updateWebhookDelivery(missing)
+	conformErr(t, "updateWebhookDelivery", err, errs.ValueNotFound)
+
+	err = db.deleteWebhookDelivery("c")
+	conformErr(t, "deleteWebhookDelivery", err, "")
+	conformIDs(t, "fetchWebhookDeliveries", listIDs(), "a", "b")
+	err = db.deleteWebhookDelivery("c")
+	conformErr(t, "deleteWebhookDelivery", err, "")
+}
+
+func conformWorker(t *testing.T, db Database) {
+	workers := []*Worker{
+		{UUID: conformIDX + "b", AccountID: conformIDX, Name: "b",
+			Miner: "cpu", HashRate: 1.5, Baseline: 2.25, Samples: 3,
+			Status: WorkerOnline, StatusSince: 100, LastSeen: 200,
+			CreatedOn: 100},
+		{UUID: conformIDX + "a", AccountID: conformIDX, Name: "a",
+			Miner: "cpu", Status: WorkerOffline, StatusSince: 300,
+			LastSeen: 250, CreatedOn: 50},
+		{UUID: conformIDY + "a", AccountID: conformIDY, Name: "a",
+			Miner: "cpu", Status: WorkerOnline, CreatedOn: 150},
+	}
+	for _, worker := range workers {
+		err := db.persistWorker(worker)
+		conformErr(t, "persistWorker", err, "")
+	}
+	err := db.persistWorker(workers[0])
+	conformErr(t, "persistWorker", err, errs.ValueFound)
+
+	listed, err := db.fetchWorkers()
+	conformErr(t, "fetchWorkers", err, "")
+	ids := make([]string, 0, len(listed))
+	for _, worker := range listed {
+		ids = append(ids, worker.UUID)
+	}
+
+	// Workers are ordered by id.
+	conformIDs(t, "fetchWorkers", ids, workers[2].UUID, workers[1].UUID,
+		workers[0].UUID)
+	conformEqual(t, "fetchWorkers", listed[2], workers[0])
+
+	updated := *workers[1]
+	updated.Status = WorkerLowHashRate
+	updated.HashRate = 0.5
+	updated.Baseline = 4
+	updated.Samples = 12
+	updated.LastSeen = 400
+	err = db.updateWorker(&updated)
+	conformErr(t, "updateWorker", err, "")
+	listed, err = db.fetchWorkers()
+	conformErr(t, "fetchWorkers", err, "")
+	conformEqual(t, "fetchWorkers", listed[1], &updated)
+
+	missing := &Worker{UUID: "missing", Status: WorkerOnline}
+	err = db.updateWorker(missing)
+	conformErr(t, "updateWorker", err, errs.ValueNotFound)
+
+	err = db.deleteWorker(workers[1].UUID)
+	conformErr(t, "deleteWorker", err, "")
+	listed, err = db.fetchWorkers()
+	conformErr(t, "fetchWorkers", err, "")
+	if len(listed) != 2 {
+		t.Fatalf("fetchWorkers: expected 2 workers, got %d", len(listed))
+	}
+	err = db.deleteWorker(workers[1].UUID)
+	conformErr(t, "deleteWorker", err, "")
+}
+
+func conformWorkerEvent(t *testing.T, db Database) {
+	events := []*WorkerEvent{
+		{UUID: "100-a", AccountID: conformIDX, Worker: "a",
+			Kind: WorkerOffline, HashRate: 1.5, Baseline: 2, LastSeen: 50,
+			CreatedOn: 100},
+		{UUID: "300-a", AccountID: conformIDX, Worker: "a",
+			Kind: WorkerRecovered, HashRate: 2, Baseline: 2, LastSeen: 300,
+			CreatedOn: 300},
+		{UUID: "200-b", AccountID: conformIDX, Worker: "b",
+			Kind: WorkerLowHashRate, HashRate: 0.5, Baseline: 2,
+			LastSeen: 200, CreatedOn: 200},
+		{UUID: "200-c", AccountID: conformIDY, Worker: "c",
+			Kind: WorkerOffline, CreatedOn: 200},
+	}
+	for _, event := range events {
+		err := db.persistWorkerEvent(event)
+		conformErr(t, "persistWorkerEvent", err, "")
+	}
+	err := db.persistWorkerEvent(events[0])
+	conformErr(t, "persistWorkerEvent", err, errs.ValueFound)
+
+	listIDs := func(accountID string) []string {
+		t.Helper()
+		listed, err := db.fetchWorkerEventsForAccount(accountID)
+		conformErr(t, "fetchWorkerEventsForAccount", err, "")
+		ids := make([]string, 0, len(listed))
+		for _, event := range listed {
+			ids = append(ids, event.UUID)
+		}
+		return ids
+	}
+
+	// Account worker events are ordered by creation time, newest first.
+	conformIDs(t, "fetchWorkerEventsForAccount", listIDs(conformIDX),
+		"300-a", "200-b", "100-a")
+	conformIDs(t, "fetchWorkerEventsForAccount", listIDs("unknown"))
+	listed, err := db.fetchWorkerEventsForAccount(conformIDY)
+	conformErr(t, "fetchWorkerEventsForAccount", err, "")
+	conformEqual(t, "fetchWorkerEventsForAccount", listed[0], events[3])
+
+	// Worker events created before the provided time are pruned.
+	err = db.pruneWorkerEvents(200)
+	conformErr(t, "pruneWorkerEvents", err, "")
+	conformIDs(t, "fetchWorkerEventsForAccount", listIDs(conformIDX),
+		"300-a", "200-b")
+}
+
+func conformImportRecords(t *testing.T, db Database) {
+	acc := NewAccount(conformAddrX)
+	err := db.persistAccount(acc)
+	conformErr(t, "persistAccount", err, "")
+	ban := newBan("10.0.0.1", IPBanKind, "spam")
+	err = db.persistBan(ban)
+	conformErr(t, "persistBan", err, "")
+
+	var records []*archiveRecord
+	err = db.exportRecords(nil, func(kind string, entity interface{}) error {
+		data, ok := entity.(json.RawMessage)
+		if !ok {
+			var err error
+			data, err = json.Marshal(entity)
+			if err != nil {
+				return err
+			}
+		}
+		records = append(records, &archiveRecord{
+			Kind: kind,
+			Data: append(json.RawMessage(nil), data...),
+		})
+		return nil
+	})
+	conformErr(t, "exportRecords", err, "")
+	if len(records) != 2 {
+		t.Fatalf("exportRecords: expected 2 records, got %d", len(records))
+	}
+
+	// Ensure importing any existing entity fails, including bans, and
+	// leaves the database unaltered.
+	newAcc := NewAccount(conformAddrY)
+	newAccB, err := json.Marshal(newAcc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRecord := &archiveRecord{Kind: accountRecord, Data: newAccB}
+	for _, record := range records {
+		err = db.importRecords([]*archiveRecord{newRecord, record})
+		conformErr(t, "importRecords", err, errs.ValueFound)
+		_, err = db.fetchAccount(newAcc.UUID)
+		conformErr(t, "fetchAccount", err, errs.ValueNotFound)
+	}
+
+	err = db.importRecords([]*archiveRecord{newRecord})
+	conformErr(t, "importRecords", err, "")
+	fetched, err := db.fetchAccount(newAcc.UUID)
+	conformErr(t, "fetchAccount", err, "")
+	conformEqual(t, "fetchAccount", fetched, newAcc)
+}
@@ -3,7 +3,9 @@ package pool
 import (
 	"database/sql"
 	"net/http"
+	"sync"
 
+	"github.com/decred/dcrd/dcrutil/v3"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -15,6 +17,8 @@ type Database interface {
 	purge() error
 	Backup(fileName string) error
 	Close() error
+	exportRecords(metaFn exportMetadataFunc, fn exportFunc) error
+	importRecords(records []*archiveRecord) error
 
 	// Pool metadata
 	fetchPoolMode() (uint32, error)
@@ -25,6 +29,11 @@ type Database interface {
 	loadLastPaymentInfo() (uint32, int64, error)
 	persistLastPaymentCreatedOn(createdOn int64) error
 	loadLastPaymentCreatedOn() (int64, error)
+	persistChainTip(hash string, height uint32) error
+	loadChainTip() (string, uint32, error)
+	persistPendingPayout(payout *PendingPayout) error
+	fetchPendingPayout() (*PendingPayout, error)
+	deletePendingPayout() error
 
 	// Account
 	fetchAccount(id string) (*Account, error)
@@ -34,17 +43,21 @@ type Database interface {
 	// Payment
 	fetchPayment(id string) (*Payment, error)
 	PersistPayment(payment *Payment) error
+	persistPayments(payments []*Payment, entries []*LedgerEntry, lastPmtCreatedOn int64, pruneSharesBefore int64) error
 	updatePayment(payment *Payment) error
 	deletePayment(id string) error
 	ArchivePayment(payment *Payment) error
+	archivePayout(payments []*Payment, entries []*LedgerEntry, height uint32, paidOn int64) error
 	fetchPaymentsAtHeight(height uint32) ([]*Payment, error)
 	fetchPendingPayments() ([]*Payment, error)
 	pendingPaymentsForBlockHash(blockHash string) (uint32, error)
+	archivedPaymentsForBlockHash(blockHash string) (uint32, error)
 	archivedPayments() ([]*Payment, error)
 	maturePendingPayments(height uint32) (map[string][]*Payment, error)
 
 	// Share
 	PersistShare(share *Share) error
+	aggregateShare(share *Share) error
 	fetchShare(id string) (*Share, error)
 	ppsEligibleShares(max int64) ([]*Share, error)
 	pplnsEligibleShares(min int64) ([]*Share, error)
@@ -70,6 +83,63 @@ type Database interface {
 	fetchHashData(id string) (*HashData, error)
 	listHashData(minNano int64) (map[string]*HashData, error)
 	pruneHashData(minNano int64) error
+
+	// API Token
+	persistAPIToken(token *APIToken) error
+	fetchAPIToken(id string) (*APIToken, error)
+	deleteAPIToken(id string) error
+	fetchAPITokensForAccount(accountID string) ([]*APIToken, error)
+
+	// Admin User
+	persistAdminUser(user *AdminUser) error
+	updateAdminUser(user *AdminUser) error
+	fetchAdminUser(name string) (*AdminUser, error)
+	deleteAdminUser(name string) error
+	listAdminUsers() ([]*AdminUser, error)
+
+	// Audit Log
+	persistAuditEntry(entry *AuditEntry) error
+	fetchAuditEntries(limit int) ([]*AuditEntry, error)
+
+	// Ban
+	persistBan(ban *Ban) error
+	deleteBan(id string) error
+	listBans() ([]*Ban, error)
+
+	// Ledger
+	persistLedgerEntries(entries []*LedgerEntry) error
+	fetchLedgerEntries(account string, limit int) ([]*LedgerEntry, error)
+	fetchLedgerBalances() (map[string]dcrutil.Amount, error)
+	fetchAccountBalances(account string) (map[string]*AccountBalance, error)
+
+	// Block Event
+	persistBlockEvent(event *BlockEvent) error
+	updateBlockEvent(event *BlockEvent) error
+	deleteBlockEvent(id string) error
+	fetchBlockEvents() ([]*BlockEvent, error)
+
+	// Webhook
+	persistWebhook(hook *Webhook) error
+	fetchWebhook(id string) (*Webhook, error)
+	deleteWebhook(id string) error
+	fetchWebhooksForAccount(accountID string) ([]*Webhook, error)
+
+	// Webhook Delivery
+	persistWebhookDelivery(delivery *WebhookDelivery) error
+	updateWebhookDelivery(delivery *WebhookDelivery) error
+	deleteWebhookDelivery(id string) error
+	fetchWebhookDeliveries() ([]*WebhookDelivery, error)
+
+	// Worker
+	persistWorker(worker *Worker) error
+	updateWorker(worker *Worker) error
+	deleteWorker(id string) error
+	fetchWorkers() ([]*Worker, error)
+
+	// Worker Event
+	persistWorkerEvent(event *WorkerEvent) error
+	fetchWorkerEventsForAccount(accountID string) ([]*WorkerEvent, error)
+	pruneWorkerEvents(minNano int64) error
 }
 
 // BoltDB is a wrapper around bolt.DB which implements the Database interface.
@@ -81,3 +151,19 @@ type BoltDB struct {
 type PostgresDB struct {
 	DB *sql.DB
 }
+
+// SQLiteDB is a wrapper around an embedded sqlite database which implements
+// the Database interface. The sqlite backend shares the schema, queries and
+// row decoding of the postgres backend.
+type SQLiteDB struct {
+	*PostgresDB
+}
+
+// MemoryDB is an in-memory implementation of the Database interface. It
+// persists nothing and is intended as the reference implementation of the
+// database conformance tests and for use in tests.
+type MemoryDB struct {
+	mtx      sync.RWMutex
+	meta     archiveMetadata
+	entities map[string]map[string][]byte
+}
@@ -5,11 +5,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"strconv"
 	"testing"
 	"time"
 
@@ -142,31 +140,29 @@ func Test_BoltDB_HttpBackup(t *testing.T) {
 	}
 
 	header = "Content-Disposition"
-	expected = `attachment; filename="backup.db"`
+	expected = `attachment; filename="backup.jsonl"`
 	if actual := rr.Header().Get(header); actual != expected {
 		t.Errorf("wrong %s header: expected %s, got %s",
 			header, expected, actual)
 	}
 
-	header = "Content-Length"
-	cLength, err := strconv.Atoi(rr.Header().Get(header))
+	// Check the body is an archive which can be restored.
+	restorePath := "trestore"
+	os.Remove(restorePath)
+	restoreDB, err := InitBoltDB(restorePath)
 	if err != nil {
-		t.Fatalf("could not convert %s to integer: %v", header, err)
-	}
-
-	if cLength <= 0 {
-		t.Fatalf("expected a %s greater than zero, got %d", header, cLength)
+		t.Fatal(err)
 	}
+	defer func() {
+		err = teardownBoltDB(restoreDB, restorePath)
+		if err != nil {
+			t.Fatalf("teardown error: %v", err)
+		}
+	}()
 
-	// Check reported length matches actual.
-	body, err := ioutil.ReadAll(rr.Result().Body)
+	err = RestoreArchive(restoreDB, rr.Result().Body)
 	if err != nil {
-		t.Fatalf("could not read http response body: %v", err)
-	}
-
-	if len(body) != cLength {
-		t.Fatalf("expected reported content-length to match actual body length. %v != %v",
-			cLength, len(body))
+		t.Fatalf("unable to restore http backup: %v", err)
 	}
 }
 
@@ -220,6 +216,46 @@ func Test_BoltDB_InitDB(t *testing.T) {
 		if err == nil {
 			return fmt.Errorf("expected hashDataBkt to exist already")
 		}
+		_, err = pbkt.CreateBucket(apiTokenBkt)
+		if err == nil {
+			return fmt.Errorf("expected apiTokenBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(adminUserBkt)
+		if err == nil {
+			return fmt.Errorf("expected adminUserBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(auditLogBkt)
+		if err == nil {
+			return fmt.Errorf("expected auditLogBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(banBkt)
+		if err == nil {
+			return fmt.Errorf("expected banBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(ledgerBkt)
+		if err == nil {
+			return fmt.Errorf("expected ledgerBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(blockEventBkt)
+		if err == nil {
+			return fmt.Errorf("expected blockEventBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(webhookBkt)
+		if err == nil {
+			return fmt.Errorf("expected webhookBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(webhookDeliveryBkt)
+		if err == nil {
+			return fmt.Errorf("expected webhookDeliveryBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(workerBkt)
+		if err == nil {
+			return fmt.Errorf("expected workerBkt to exist already")
+		}
+		_, err = pbkt.CreateBucket(workerEventBkt)
+		if err == nil {
+			return fmt.Errorf("expected workerEventBkt to exist already")
+		}
 		return nil
 	})
 	if err != nil {
@@ -8,9 +8,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/decred/dcrd/chaincfg/v3"
@@ -31,8 +33,11 @@ type EndpointConfig struct {
 	Blake256Pad []byte
 	// NonceIterations returns the possible header nonce iterations.
 	NonceIterations float64
+	// slicer aggregates the accepted shares of accounts over time slices.
+	// Shares are persisted individually when nil.
+	slicer *shareSlicer
 	// MaxConnectionsPerHost represents the maximum number of connections
-	// allowed per host.
+	// allowed per host. It is updated atomically.
 	MaxConnectionsPerHost uint32
 	// MaxGenTime represents the share creation target time for the pool.
 	MaxGenTime time.Duration
@@ -55,6 +60,13 @@ type EndpointConfig struct {
 	FetchHostConnections func(string) uint32
 	// SignalCache sends the provided cache update event to the gui cache.
 	SignalCache func(event CacheUpdateEvent)
+	// NotifyWebhooks queues the provided event for delivery to the operator
+	// configured URLs and the webhooks of the accounts keyed in the
+	// provided account data.
+	NotifyWebhooks func(event string, data interface{}, accountData map[string]interface{})
+	// RecordWorkerActivity notes the hashrate of a named worker connection
+	// which submitted shares.
+	RecordWorkerActivity func(accountID, name, miner, conn string, hashRate *big.Rat)
 	// MonitorCycle represents the time monitoring a mining client to access
 	// possible upgrades if needed.
 	MonitorCycle time.Duration
@@ -63,6 +75,9 @@ type EndpointConfig struct {
 	MaxUpgradeTries uint32
 	// ClientTimeout represents the read/write timeout for the client.
 	ClientTimeout time.Duration
+	// IsBanned returns whether the provided IP address or account id is
+	// banned from the pool.
+	IsBanned func(string) bool
 }
 
 // connection wraps a client connection and a done channel.
@@ -152,10 +167,17 @@ func (e *Endpoint) connect(ctx context.Context) {
 				continue
 			}
 			host := tcpAddr.IP.String()
+			if e.cfg.IsBanned(host) {
+				log.Infof("rejected connection from banned host %s", host)
+				msg.Conn.Close()
+				close(msg.Done)
+				continue
+			}
 			connCount := e.cfg.FetchHostConnections(host)
-			if connCount >= e.cfg.MaxConnectionsPerHost {
+			maxConns := atomic.LoadUint32(&e.cfg.MaxConnectionsPerHost)
+			if connCount >= maxConns {
 				log.Errorf("exceeded maximum connections allowed per"+
-					" host %d for %s", e.cfg.MaxConnectionsPerHost, host)
+					" host %d for %s", maxConns, host)
 				msg.Conn.Close()
 				close(msg.Done)
 				continue
@@ -166,6 +188,7 @@ func (e *Endpoint) connect(ctx context.Context) {
 				SoloPool:             e.cfg.SoloPool,
 				Blake256Pad:          e.cfg.Blake256Pad,
 				NonceIterations:      e.cfg.NonceIterations,
+				slicer:               e.cfg.slicer,
 				FetchMinerDifficulty: e.cfg.FetchMinerDifficulty,
 				Disconnect:           func() { e.wg.Done() },
 				RemoveClient:         e.removeClient,
@@ -176,9 +199,12 @@ func (e *Endpoint) connect(ctx context.Context) {
 				MaxGenTime:           e.cfg.MaxGenTime,
 				ClientTimeout:        e.cfg.ClientTimeout,
 				SignalCache:          e.cfg.SignalCache,
+				NotifyWebhooks:       e.cfg.NotifyWebhooks,
+				RecordWorkerActivity: e.cfg.RecordWorkerActivity,
 				MonitorCycle:         e.cfg.MonitorCycle,
 				MaxUpgradeTries:      e.cfg.MaxUpgradeTries,
 				RollWorkCycle:        rollWorkCycle,
+				IsBanned:             e.cfg.IsBanned,
 			}
 			client, err := NewClient(ctx, msg.Conn, tcpAddr, cCfg)
 			if err != nil {
@@ -238,6 +264,30 @@ func (e *Endpoint) generateHashIDs() map[string]struct{} {
 	return ids
 }
 
+// fetchClient returns the connected client with the provided extraNonce1.
+func (e *Endpoint) fetchClient(extraNonce1 string) (*Client, bool) {
+	e.clientsMtx.Lock()
+	defer e.clientsMtx.Unlock()
+	c, ok := e.clients[extraNonce1]
+	return c, ok
+}
+
+// disconnectClients terminates the connections of all clients matching the
+// provided predicate, returning the number of clients disconnected.
+func (e *Endpoint) disconnectClients(match func(c *Client) bool) int {
+	e.clientsMtx.Lock()
+	defer e.clientsMtx.Unlock()
+
+	var count int
+	for _, c := range e.clients {
+		if match(c) {
+			c.cancel()
+			count++
+		}
+	}
+	return count
+}
+
 // run handles the lifecycle of all endpoint related processes.
 // This should be run as a goroutine.
 func (e *Endpoint) run(ctx context.Context) {
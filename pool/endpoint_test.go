@@ -32,6 +32,7 @@ func testEndpoint(t *testing.T) {
 	poolDiffs := NewDifficultySet(chaincfg.SimNetParams(),
 		new(big.Rat).SetInt(powLimit), maxGenTime)
 	connections := make(map[string]uint32)
+	banned := make(map[string]bool)
 	var connectionsMtx sync.RWMutex
 	eCfg := &EndpointConfig{
 		ActiveNet:             chaincfg.SimNetParams(),
@@ -71,9 +72,16 @@ func testEndpoint(t *testing.T) {
 		SignalCache: func(_ CacheUpdateEvent) {
 			// Do nothing.
 		},
-		MonitorCycle:    time.Minute,
-		MaxUpgradeTries: 5,
-		ClientTimeout:   time.Second * 30,
+		NotifyWebhooks:       func(string, interface{}, map[string]interface{}) {},
+		RecordWorkerActivity: func(string, string, string, string, *big.Rat) {},
+		MonitorCycle:         time.Minute,
+		MaxUpgradeTries:      5,
+		ClientTimeout:        time.Second * 30,
+		IsBanned: func(id string) bool {
+			connectionsMtx.RLock()
+			defer connectionsMtx.RUnlock()
+			return banned[id]
+		},
 	}
 	endpoint, err := NewEndpoint(eCfg, "0.0.0.0:3030")
 	if err != nil {
@@ -216,6 +224,31 @@ func testEndpoint(t *testing.T) {
 			" connections, got %d", 0, host, hostConnections)
 	}
 
+	// Ensure connections from banned hosts are rejected.
+	connectionsMtx.Lock()
+	banned[host] = true
+	connectionsMtx.Unlock()
+	connE, srvE, err := makeConn(ln, serverCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer connE.Close()
+	defer srvE.Close()
+	msgE := &connection{
+		Conn: connE,
+		Done: make(chan bool),
+	}
+	endpoint.connCh <- msgE
+	<-msgE.Done
+	hostConnections = endpoint.cfg.FetchHostConnections(host)
+	if hostConnections != 0 {
+		t.Fatalf("[FetchHostConnections] expected %d connection(s) for "+
+			"banned host %s, got %d", 0, host, hostConnections)
+	}
+	connectionsMtx.Lock()
+	delete(banned, host)
+	connectionsMtx.Unlock()
+
 	// Ensure the endpoint listener can create connections.
 	ep, err := net.ResolveTCPAddr("tcp", "127.0.0.1:3030")
 	if err != nil {
@@ -0,0 +1,32 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"decred.org/dcrwallet/rpc/walletrpc"
+	"github.com/decred/dcrd/rpcclient/v6"
+)
+
+// UseConnections attaches the provided mining node, wallet and wallet
+// confirmation notification connections to the hub in place of the
+// connections established by Connect. It returns the notification handlers
+// the mining node connection is expected to invoke.
+func (h *Hub) UseConnections(node NodeConnection, wallet WalletConnection, confNotifs walletrpc.WalletService_ConfirmationNotificationsClient) *rpcclient.NotificationHandlers {
+	h.nodeConn = node
+	h.walletConn = wallet
+	h.notifClient = confNotifs
+	return h.createNotificationHandlers()
+}
+
+// MinerAddr returns the address the hub accepts mining clients on.
+func (h *Hub) MinerAddr() string {
+	return h.endpoint.listener.Addr().String()
+}
+
+// ChainTip returns the chain tip of the block notifications queued for
+// processing, if known.
+func (h *Hub) ChainTip() (string, uint32, bool) {
+	return h.chainState.chainTip()
+}
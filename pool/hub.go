@@ -15,7 +15,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -111,6 +113,9 @@ type NodeConnection interface {
 	GetWork(context.Context) (*chainjson.GetWorkResult, error)
 	GetBlockVerbose(context.Context, *chainhash.Hash, bool) (*chainjson.GetBlockVerboseResult, error)
 	GetBlock(context.Context, *chainhash.Hash) (*wire.MsgBlock, error)
+	GetBestBlock(context.Context) (*chainhash.Hash, int64, error)
+	GetBlockHash(context.Context, int64) (*chainhash.Hash, error)
+	GetBlockHeader(context.Context, *chainhash.Hash) (*wire.BlockHeader, error)
 	NotifyWork(context.Context) error
 	NotifyBlocks(context.Context) error
 	Shutdown()
@@ -124,6 +129,28 @@ type HubConfig struct {
 	DB Database
 	// NodeRPCConfig represents the mining node's RPC configuration details.
 	NodeRPCConfig *rpcclient.ConnConfig
+	// FailoverRPCConfigs represents the RPC configuration details of the
+	// mining nodes taking over when the mining node fails, in order of
+	// preference.
+	FailoverRPCConfigs []*rpcclient.ConnConfig
+	// SubmitRPCConfigs represents the RPC configuration details of the
+	// additional mining nodes solved blocks are submitted to.
+	SubmitRPCConfigs []*rpcclient.ConnConfig
+	// NodeHealthInterval represents the interval between mining node health
+	// checks.
+	NodeHealthInterval time.Duration
+	// NodeNotifyTimeout represents the time without notifications from the
+	// active mining node after which a mining node ahead of it takes over.
+	NodeNotifyTimeout time.Duration
+	// NodeMaxTipLag represents the number of blocks the active mining node
+	// may lag behind the best mining node before another one takes over.
+	NodeMaxTipLag int64
+	// BlockEventRetryDelay represents the delay before retrying a block
+	// notification which failed to process for the first time.
+	BlockEventRetryDelay time.Duration
+	// BlockEventMaxRetryDelay represents the maximum delay before retrying
+	// a block notification which failed to process.
+	BlockEventMaxRetryDelay time.Duration
 	// WalletRPCCert represents the wallet's RPC certificate.
 	WalletRPCCert string
 	// WalletTLSCert represents the wallet client's TLS certificate.
@@ -133,6 +160,12 @@ type HubConfig struct {
 	// WalletGRPCHost represents the ip:port establish a GRPC connection for
 	// the wallet.
 	WalletGRPCHost string
+	// WalletCheckInterval represents the interval between wallet health
+	// checks.
+	WalletCheckInterval time.Duration
+	// WalletMaxSyncLag represents the number of blocks the wallet may lag
+	// behind the mining node before it is considered out of sync.
+	WalletMaxSyncLag uint32
 	// PoolFee represents the fee charged to participating accounts of the pool.
 	PoolFee float64
 	// MaxGenTime represents the share creation target time for the pool.
@@ -142,13 +175,18 @@ type HubConfig struct {
 	// LastNPeriod represents the period to source shares from when using the
 	// PPLNS payment scheme.
 	LastNPeriod time.Duration
+	// ShareSlice represents the time slice accepted shares of an account
+	// are aggregated over when using the PPS payment scheme. Shares are
+	// persisted individually when zero.
+	ShareSlice time.Duration
 	// WalletPass represents the passphrase to unlock the wallet with.
 	WalletPass string
 	// SoloPool represents the solo pool mining mode.
 	SoloPool bool
 	// PoolFeeAddrs represents the pool fee addresses of the pool.
 	PoolFeeAddrs []dcrutil.Address
-	// AdminPass represents the admin password.
+	// AdminPass represents the password of the default admin user, created
+	// when no admin users exist.
 	AdminPass string
 	// NonceIterations returns the possible header nonce iterations.
 	NonceIterations float64
@@ -162,6 +200,12 @@ type HubConfig struct {
 	// CoinbaseConfTimeout is the duration to wait for coinbase confirmations
 	// when generating a payout transaction.
 	CoinbaseConfTimeout time.Duration
+	// OfflineSigning exports payout transactions for signing by an external
+	// wallet instead of signing them with the wallet passphrase.
+	OfflineSigning bool
+	// PayoutDir represents the directory unsigned payout transactions are
+	// exported to.
+	PayoutDir string
 	// MonitorCycle represents the time monitoring a mining client to access
 	// possible upgrades if needed.
 	MonitorCycle time.Duration
@@ -170,6 +214,48 @@ type HubConfig struct {
 	MaxUpgradeTries uint32
 	// ClientTimeout represents the read/write timeout for the client.
 	ClientTimeout time.Duration
+	// BackupInterval represents the interval between scheduled snapshots of
+	// a bolt database. Scheduled snapshots are disabled when zero.
+	BackupInterval time.Duration
+	// BackupDir represents the directory scheduled snapshots are written to.
+	BackupDir string
+	// BackupKeepHourly represents the number of latest hours whose newest
+	// scheduled snapshot is retained.
+	BackupKeepHourly int
+	// BackupKeepDaily represents the number of latest days whose newest
+	// scheduled snapshot is retained.
+	BackupKeepDaily int
+	// BackupCompress represents whether scheduled snapshots are gzip
+	// compressed.
+	BackupCompress bool
+	// WebhookURLs represents the operator configured URLs notified of all
+	// pool events.
+	WebhookURLs []string
+	// WebhookSecret represents the secret signing the payloads delivered to
+	// the operator configured webhook URLs.
+	WebhookSecret string
+	// WebhookMaxAttempts represents the number of attempts to deliver a
+	// webhook event before it is dropped.
+	WebhookMaxAttempts uint32
+	// WorkerOfflineTimeout represents the period a tracked worker may go
+	// without submitting shares before it is considered offline.
+	WorkerOfflineTimeout time.Duration
+	// WorkerHashRateFraction represents the fraction of its baseline
+	// hashrate a tracked worker may drop to before its hashrate is
+	// considered low, zero disables low hashrate detection.
+	WorkerHashRateFraction float64
+}
+
+// ReloadableConfig contains the hub configuration values which can be changed
+// while the pool is running.
+type ReloadableConfig struct {
+	// PoolFee represents the fee charged to participating accounts of the pool.
+	PoolFee float64
+	// PoolFeeAddrs represents the pool fee addresses of the pool.
+	PoolFeeAddrs []dcrutil.Address
+	// MaxConnectionsPerHost represents the maximum number of connections
+	// allowed per host.
+	MaxConnectionsPerHost uint32
 }
 
 // Hub maintains the set of active clients and facilitates message broadcasting
@@ -180,8 +266,12 @@ type Hub struct {
 	cfg            *HubConfig
 	limiter        *RateLimiter
 	nodeConn       NodeConnection
+	submitter      *blockSubmitter
 	walletClose    func() error
 	walletConn     WalletConnection
+	walletMonitor  *WalletMonitor
+	webhooks       *WebhookNotifier
+	workerMonitor  *WorkerMonitor
 	notifClient    walletrpc.WalletService_ConfirmationNotificationsClient
 	poolDiffs      *DifficultySet
 	paymentMgr     *PaymentMgr
@@ -193,6 +283,10 @@ type Hub struct {
 	blake256Pad    []byte
 	wg             *sync.WaitGroup
 	cacheCh        chan CacheUpdateEvent
+	loginThrottle  *loginThrottle
+	usedProofs     *usedProofs
+	bans           map[string]*Ban
+	bansMtx        sync.RWMutex
 }
 
 // SignalCache sends the provided cache update event to the gui cache.
@@ -223,14 +317,18 @@ func generateBlake256Pad() []byte {
 // NewHub initializes the mining pool hub.
 func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 	h := &Hub{
-		cfg:         hcfg,
-		limiter:     NewRateLimiter(),
-		wg:          new(sync.WaitGroup),
-		connections: make(map[string]uint32),
-		cacheCh:     make(chan CacheUpdateEvent, bufferSize),
-		cancel:      cancel,
+		cfg:           hcfg,
+		limiter:       NewRateLimiter(),
+		wg:            new(sync.WaitGroup),
+		connections:   make(map[string]uint32),
+		cacheCh:       make(chan CacheUpdateEvent, bufferSize),
+		cancel:        cancel,
+		loginThrottle: newLoginThrottle(),
+		usedProofs:    newUsedProofs(),
+		bans:          make(map[string]*Ban),
 	}
 	h.blake256Pad = generateBlake256Pad()
+	h.submitter = newBlockSubmitter(h.activeNode)
 	powLimit := new(big.Rat).SetInt(h.cfg.ActiveNet.PowLimit)
 	maxGenTime := h.cfg.MaxGenTime
 	if h.cfg.SoloPool {
@@ -242,6 +340,26 @@ func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 
 	h.poolDiffs = NewDifficultySet(h.cfg.ActiveNet, powLimit, maxGenTime)
 
+	h.webhooks = NewWebhookNotifier(&WebhookNotifierConfig{
+		db:          h.cfg.DB,
+		URLs:        h.cfg.WebhookURLs,
+		Secret:      h.cfg.WebhookSecret,
+		MaxAttempts: h.cfg.WebhookMaxAttempts,
+		HubWg:       h.wg,
+	})
+
+	var err error
+	h.workerMonitor, err = NewWorkerMonitor(&WorkerMonitorConfig{
+		db:               h.cfg.DB,
+		OfflineTimeout:   h.cfg.WorkerOfflineTimeout,
+		HashRateFraction: h.cfg.WorkerHashRateFraction,
+		NotifyWebhooks:   h.webhooks.notify,
+		HubWg:            h.wg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	pCfg := &PaymentMgrConfig{
 		db:                     h.cfg.DB,
 		ActiveNet:              h.cfg.ActiveNet,
@@ -257,9 +375,11 @@ func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 		FetchTxCreator:         func() TxCreator { return h.nodeConn },
 		FetchTxBroadcaster:     func() TxBroadcaster { return h.walletConn },
 		CoinbaseConfTimeout:    h.cfg.CoinbaseConfTimeout,
+		OfflineSigning:         h.cfg.OfflineSigning,
+		PayoutDir:              h.cfg.PayoutDir,
+		NotifyWebhooks:         h.webhooks.notify,
 	}
 
-	var err error
 	h.paymentMgr, err = NewPaymentMgr(pCfg)
 	if err != nil {
 		return nil, err
@@ -272,8 +392,15 @@ func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 		GeneratePayments:      h.paymentMgr.generatePayments,
 		GetBlock:              h.getBlock,
 		GetBlockConfirmations: h.getBlockConfirmations,
+		GetBestBlock:          h.getBestBlock,
+		GetBlockHash:          h.getBlockHash,
+		GetBlockHeader:        h.getBlockHeader,
+		CoinbaseMaturity:      h.cfg.ActiveNet.CoinbaseMaturity,
+		RetryDelay:            h.cfg.BlockEventRetryDelay,
+		MaxRetryDelay:         h.cfg.BlockEventMaxRetryDelay,
 		Cancel:                h.cancel,
 		SignalCache:           h.SignalCache,
+		NotifyWebhooks:        h.webhooks.notify,
 		HubWg:                 h.wg,
 	}
 	h.chainState = NewChainState(sCfg)
@@ -304,18 +431,47 @@ func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 			"pool mode, config=%d, database=%d", cfgMode, dbMode)
 	}
 
+	err = h.bootstrapAdminUser()
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := h.cfg.DB.listBans()
+	if err != nil {
+		return nil, err
+	}
+	for _, ban := range bans {
+		h.bans[ban.UUID] = ban
+	}
+
 	if !h.cfg.SoloPool {
 		log.Infof("Payment method is %s.", strings.ToUpper(hcfg.PaymentMethod))
 	} else {
 		log.Infof("Solo pool mode active.")
 	}
 
+	// Shares are only aggregated for PPS, the start of the PPLNS window is
+	// not known until payments are created and may fall within any slice.
+	var slicer *shareSlicer
+	if !h.cfg.SoloPool && h.cfg.ShareSlice > 0 {
+		switch h.cfg.PaymentMethod {
+		case PPS:
+			slicer = newShareSlicer(h.cfg.ShareSlice)
+			log.Infof("Aggregating shares over %v slices.", h.cfg.ShareSlice)
+		case PPLNS:
+			log.Infof("Share aggregation is not supported by %s, "+
+				"persisting shares individually.",
+				strings.ToUpper(PPLNS))
+		}
+	}
+
 	eCfg := &EndpointConfig{
 		ActiveNet:             h.cfg.ActiveNet,
 		db:                    h.cfg.DB,
 		SoloPool:              h.cfg.SoloPool,
 		Blake256Pad:           h.blake256Pad,
 		NonceIterations:       h.cfg.NonceIterations,
+		slicer:                slicer,
 		MaxConnectionsPerHost: h.cfg.MaxConnectionsPerHost,
 		HubWg:                 h.wg,
 		FetchMinerDifficulty:  h.poolDiffs.fetchMinerDifficulty,
@@ -330,6 +486,9 @@ func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 		MonitorCycle:          h.cfg.MonitorCycle,
 		MaxUpgradeTries:       h.cfg.MaxUpgradeTries,
 		ClientTimeout:         h.cfg.ClientTimeout,
+		IsBanned:              h.isBanned,
+		NotifyWebhooks:        h.webhooks.notify,
+		RecordWorkerActivity:  h.workerMonitor.record,
 	}
 
 	h.endpoint, err = NewEndpoint(eCfg, h.cfg.MinerListen)
@@ -340,29 +499,84 @@ func NewHub(cancel context.CancelFunc, hcfg *HubConfig) (*Hub, error) {
 	return h, nil
 }
 
+// connectNodes establishes connections to the mining node and the failover
+// mining nodes. Failover mining nodes which cannot be reached are skipped,
+// the first reachable mining node becomes the active one.
+func (h *Hub) connectNodes(ctx context.Context) (*NodePool, error) {
+	np := NewNodePool(&NodePoolConfig{
+		HealthInterval: h.cfg.NodeHealthInterval,
+		NotifyTimeout:  h.cfg.NodeNotifyTimeout,
+		MaxTipLag:      h.cfg.NodeMaxTipLag,
+		Handlers:       h.createNotificationHandlers(),
+		OnSwitch: func() {
+			h.refreshWork(ctx)
+			h.chainState.requestCatchUp()
+		},
+		HubWg: h.wg,
+	})
+
+	rpcCfgs := append([]*rpcclient.ConnConfig{h.cfg.NodeRPCConfig},
+		h.cfg.FailoverRPCConfigs...)
+	var connErr error
+	for _, rpcCfg := range rpcCfgs {
+		nodeConn, err := rpcclient.New(rpcCfg,
+			np.nodeHandlers(np.nodeCount()))
+		if err != nil {
+			log.Errorf("unable to connect to node %s: %v", rpcCfg.Host, err)
+			connErr = err
+			continue
+		}
+		np.addNode(rpcCfg.Host, nodeConn)
+	}
+	if np.nodeCount() == 0 {
+		return nil, connErr
+	}
+
+	err := np.Subscribe(ctx)
+	if err != nil {
+		np.Shutdown()
+		return nil, err
+	}
+
+	return np, nil
+}
+
+// refreshWork fetches work from the active mining node and dispatches it to
+// all connected pool clients. This is called once another mining node took
+// over.
+func (h *Hub) refreshWork(ctx context.Context) {
+	work, _, err := h.getWork(ctx)
+	if err != nil {
+		log.Errorf("unable to refresh work: %v", err)
+		return
+	}
+	h.chainState.setCurrentWork(work)
+	h.processWork(work)
+}
+
 // Connect establishes a connection to the mining node and a wallet connection
 // if the pool is a publicly avialable one.
 func (h *Hub) Connect(ctx context.Context) error {
 	// Establish a connection to the mining node.
-	nodeConn, err := rpcclient.New(h.cfg.NodeRPCConfig,
-		h.createNotificationHandlers())
+	nodeConn, err := h.connectNodes(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err := nodeConn.NotifyWork(ctx); err != nil {
-		nodeConn.Shutdown()
-		return fmt.Errorf("unable to subscribe for work "+
-			"notifications: %v", err)
-	}
-	if err := nodeConn.NotifyBlocks(ctx); err != nil {
-		nodeConn.Shutdown()
-		return fmt.Errorf("unable to subscribe for block "+
-			"notifications: %v", err)
-	}
-
 	h.nodeConn = nodeConn
 
+	// Establish connections to the additional mining nodes solved blocks
+	// are submitted to. Mining nodes which cannot be reached are skipped.
+	for _, rpcCfg := range h.cfg.SubmitRPCConfigs {
+		submitConn, err := rpcclient.New(rpcCfg, nil)
+		if err != nil {
+			log.Errorf("unable to connect to block submission node %s: %v",
+				rpcCfg.Host, err)
+			continue
+		}
+		h.submitter.addNode(rpcCfg.Host, submitConn)
+	}
+
 	// Establish a connection to the wallet if the pool is
 	// mining as a publicly available mining pool.
 	if !h.cfg.SoloPool {
@@ -405,6 +619,17 @@ func (h *Hub) Connect(ctx context.Context) error {
 
 		h.walletConn = walletConn
 		h.walletClose = grpc.Close
+		h.walletMonitor = NewWalletMonitor(&WalletMonitorConfig{
+			Wallet:              walletConn,
+			Account:             h.cfg.WalletAccount,
+			CheckInterval:       h.cfg.WalletCheckInterval,
+			MaxSyncLag:          h.cfg.WalletMaxSyncLag,
+			OfflineSigning:      h.cfg.OfflineSigning,
+			FetchNodeHeight:     h.nodeHeight,
+			FetchMaturePayments: h.maturePaymentsTotal,
+			NotifyWebhooks:      h.webhooks.notify,
+			HubWg:               h.wg,
+		})
 
 		confNotifs, err := walletConn.ConfirmationNotifications(ctx)
 		if err != nil {
@@ -418,13 +643,72 @@ func (h *Hub) Connect(ctx context.Context) error {
 	return nil
 }
 
-// submitWork sends solved block data to the consensus daemon for evaluation.
+// activeNode returns the name and connection of the active mining node.
+func (h *Hub) activeNode() (string, WorkSubmitter) {
+	if np, ok := h.nodeConn.(*NodePool); ok {
+		return np.ActiveNode(), np
+	}
+	name := "node"
+	if h.cfg.NodeRPCConfig != nil {
+		name = h.cfg.NodeRPCConfig.Host
+	}
+	return name, h.nodeConn
+}
+
+// submitWork sends solved block data to the consensus daemon and the
+// additional mining nodes for evaluation.
 func (h *Hub) submitWork(ctx context.Context, data *string) (bool, error) {
 	if h.nodeConn == nil {
 		return false, errs.PoolError(errs.Disconnected, "node disconnected")
 	}
 
-	return h.nodeConn.GetWorkSubmit(ctx, *data)
+	return h.submitter.submit(ctx, *data)
+}
+
+// FetchBlockSubmissions returns the most recent solved block submissions
+// along with the outcome of every submission, newest first.
+func (h *Hub) FetchBlockSubmissions() []*BlockSubmission {
+	return h.submitter.submissions()
+}
+
+// FetchBlockEventStats returns the block notification processing counters
+// along with the block notifications pending processing.
+func (h *Hub) FetchBlockEventStats() *BlockEventStats {
+	return h.chainState.blockEventStats()
+}
+
+// FetchWalletStatus returns the outcome of the latest wallet health check,
+// or nil if the wallet is not monitored.
+func (h *Hub) FetchWalletStatus() *WalletStatus {
+	if h.walletMonitor == nil {
+		return nil
+	}
+	return h.walletMonitor.Status()
+}
+
+// nodeHeight returns the chain tip height of the mining node.
+func (h *Hub) nodeHeight(ctx context.Context) (uint32, error) {
+	_, height, err := h.getBestBlock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(height), nil
+}
+
+// maturePaymentsTotal returns the total amount of the pending payments
+// mature at the provided height.
+func (h *Hub) maturePaymentsTotal(height uint32) (dcrutil.Amount, error) {
+	pmts, err := h.cfg.DB.maturePendingPayments(height)
+	if err != nil {
+		return 0, err
+	}
+	var total dcrutil.Amount
+	for _, set := range pmts {
+		for _, pmt := range set {
+			total += pmt.Amount
+		}
+	}
+	return total, nil
 }
 
 // getWork fetches available work from the consensus daemon.
@@ -511,6 +795,49 @@ func (h *Hub) getBlock(ctx context.Context, blockHash *chainhash.Hash) (*wire.Ms
 	return block, nil
 }
 
+// getBestBlock fetches the chain tip hash and height of the mining node.
+func (h *Hub) getBestBlock(ctx context.Context) (*chainhash.Hash, int64, error) {
+	if h.nodeConn == nil {
+		return nil, 0, errs.PoolError(errs.Disconnected, "node disconnected")
+	}
+	hash, height, err := h.nodeConn.GetBestBlock(ctx)
+	if err != nil {
+		desc := fmt.Sprintf("unable to fetch best block: %v", err)
+		return nil, 0, errs.PoolError(errs.GetBlock, desc)
+	}
+	return hash, height, nil
+}
+
+// getBlockHash fetches the hash of the main chain block at the provided
+// height.
+func (h *Hub) getBlockHash(ctx context.Context, height int64) (*chainhash.Hash, error) {
+	if h.nodeConn == nil {
+		return nil, errs.PoolError(errs.Disconnected, "node disconnected")
+	}
+	hash, err := h.nodeConn.GetBlockHash(ctx, height)
+	if err != nil {
+		desc := fmt.Sprintf("unable to fetch block hash at height #%d: %v",
+			height, err)
+		return nil, errs.PoolError(errs.GetBlock, desc)
+	}
+	return hash, nil
+}
+
+// getBlockHeader fetches the block header associated with the provided
+// block hash.
+func (h *Hub) getBlockHeader(ctx context.Context, blockHash *chainhash.Hash) (*wire.BlockHeader, error) {
+	if h.nodeConn == nil {
+		return nil, errs.PoolError(errs.Disconnected, "node disconnected")
+	}
+	header, err := h.nodeConn.GetBlockHeader(ctx, blockHash)
+	if err != nil {
+		desc := fmt.Sprintf("unable to fetch block header %s: %v",
+			blockHash.String(), err)
+		return nil, errs.PoolError(errs.GetBlock, desc)
+	}
+	return header, nil
+}
+
 // fetchHostConnections returns the client connection count for the
 // provided host.
 func (h *Hub) fetchHostConnections(host string) uint32 {
@@ -591,6 +918,11 @@ func (h *Hub) createNotificationHandlers() *rpcclient.NotificationHandlers {
 				Done:   make(chan bool),
 			}
 		},
+		OnClientConnected: func() {
+			// Block notifications may have been missed while the
+			// mining node was unreachable.
+			h.chainState.requestCatchUp()
+		},
 		OnWork: func(headerB []byte, target []byte, reason string) {
 			currWork := hex.EncodeToString(headerB)
 			switch reason {
@@ -634,6 +966,7 @@ func (h *Hub) shutdown() {
 	if h.nodeConn != nil {
 		h.nodeConn.Shutdown()
 	}
+	h.submitter.shutdown()
 	if h.notifClient != nil {
 		_ = h.notifClient.CloseSend()
 	}
@@ -641,15 +974,77 @@ func (h *Hub) shutdown() {
 
 // Run handles the process lifecycles of the pool hub.
 func (h *Hub) Run(ctx context.Context) {
-	h.wg.Add(2)
+	h.wg.Add(4)
 	go h.endpoint.run(ctx)
 	go h.chainState.handleChainUpdates(ctx)
+	go h.webhooks.run(ctx)
+	go h.workerMonitor.run(ctx)
+
+	// Mining node health is only monitored when failover mining nodes are
+	// available.
+	if np, ok := h.nodeConn.(*NodePool); ok && np.nodeCount() > 1 {
+		h.wg.Add(1)
+		go np.run(ctx)
+	}
+
+	// The wallet is only monitored when mining as a publicly available
+	// mining pool.
+	if h.walletMonitor != nil {
+		h.wg.Add(1)
+		go h.walletMonitor.run(ctx)
+	}
+
+	// Scheduled snapshots are only supported by bolt databases.
+	if db, ok := h.cfg.DB.(*BoltDB); ok && h.cfg.BackupInterval > 0 {
+		h.wg.Add(1)
+		go h.runBackups(ctx, db)
+	}
 
 	// Wait until all hub processes have terminated, and then shutdown.
 	h.wg.Wait()
 	h.shutdown()
 }
 
+// backup writes a snapshot of the provided bolt database and prunes the
+// snapshots no longer retained.
+func (h *Hub) backup(db *BoltDB) error {
+	manifest, err := db.Snapshot(h.cfg.BackupDir, h.cfg.BackupCompress)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Wrote database snapshot %s (%d bytes)", manifest.File,
+		manifest.Size)
+
+	pruned, err := PruneSnapshots(h.cfg.BackupDir, h.cfg.BackupKeepHourly,
+		h.cfg.BackupKeepDaily)
+	for _, file := range pruned {
+		log.Debugf("Pruned database snapshot %s", file)
+	}
+	return err
+}
+
+// runBackups writes a snapshot of the provided bolt database at the
+// configured interval until the provided context is cancelled.
+// This should be run as a goroutine.
+func (h *Hub) runBackups(ctx context.Context, db *BoltDB) {
+	ticker := time.NewTicker(h.cfg.BackupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.wg.Done()
+			return
+
+		case <-ticker.C:
+			err := h.backup(db)
+			if err != nil {
+				log.Errorf("unable to back up database: %v", err)
+			}
+		}
+	}
+}
+
 // FetchHashData returns all hash data from connected pool clients
 // which have been updated in the last five minutes.
 func (h *Hub) FetchHashData() (map[string][]*HashData, error) {
@@ -745,6 +1140,738 @@ func (h *Hub) AccountExists(accountID string) bool {
 	return true
 }
 
+// MintAPIToken creates a read-only token of the provided kind for the account
+// of the provided address. The signature must be a signature of the message
+// returned by AccountProofMessage(net, "mint <kind>", timestamp) by the
+// address, where net is the name of the active network. The returned token is
+// not stored and cannot be recovered.
+func (h *Hub) MintAPIToken(address, kind, signature string, timestamp int64) (string, *APIToken, error) {
+	const funcName = "MintAPIToken"
+	if !validAPITokenKind(kind) {
+		desc := fmt.Sprintf("%s: unknown token kind %s", funcName, kind)
+		return "", nil, errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	err := verifyAccountProof(address, "mint "+kind, signature, timestamp,
+		h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	accountID := AccountID(address)
+	_, err = h.cfg.DB.fetchAccount(accountID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tokens, err := h.cfg.DB.fetchAPITokensForAccount(accountID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tokens) >= maxAPITokensPerAccount {
+		desc := fmt.Sprintf("%s: account %s already has %d tokens", funcName,
+			accountID, len(tokens))
+		return "", nil, errs.PoolError(errs.LimitExceeded, desc)
+	}
+
+	token, apiToken, err := newAPIToken(accountID, kind)
+	if err != nil {
+		return "", nil, err
+	}
+	err = h.cfg.DB.persistAPIToken(apiToken)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, apiToken, nil
+}
+
+// FetchAPITokens returns all tokens of the account of the provided address.
+// The signature must be a signature of the message returned by
+// AccountProofMessage(net, "list", timestamp) by the address.
+func (h *Hub) FetchAPITokens(address, signature string, timestamp int64) ([]*APIToken, error) {
+	err := verifyAccountProof(address, "list", signature, timestamp,
+		h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return nil, err
+	}
+	return h.cfg.DB.fetchAPITokensForAccount(AccountID(address))
+}
+
+// RevokeAPIToken deletes the referenced token of the account of the provided
+// address. The signature must be a signature of the message returned by
+// AccountProofMessage(net, "revoke <id>", timestamp) by the address.
+func (h *Hub) RevokeAPIToken(address, id, signature string, timestamp int64) error {
+	const funcName = "RevokeAPIToken"
+	err := verifyAccountProof(address, "revoke "+id, signature, timestamp,
+		h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return err
+	}
+
+	token, err := h.cfg.DB.fetchAPIToken(id)
+	if err != nil {
+		return err
+	}
+	if token.AccountID != AccountID(address) {
+		desc := fmt.Sprintf("%s: token %s does not belong to address %s",
+			funcName, id, address)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	return h.cfg.DB.deleteAPIToken(id)
+}
+
+// RegisterWebhook registers the provided https URL to be notified of the
+// events of the account of the provided address. The signature must be a
+// signature of the message returned by AccountProofMessage(net,
+// "webhook <url>", timestamp) by the address. The returned webhook holds the
+// secret signing the payloads delivered to the URL.
+func (h *Hub) RegisterWebhook(address, hookURL, signature string, timestamp int64) (*Webhook, error) {
+	const funcName = "RegisterWebhook"
+	err := validateAccountWebhookURL(hookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyAccountProof(address, "webhook "+hookURL, signature,
+		timestamp, h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID := AccountID(address)
+	_, err = h.cfg.DB.fetchAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, err := h.cfg.DB.fetchWebhooksForAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) >= maxWebhooksPerAccount {
+		desc := fmt.Sprintf("%s: account %s already has %d webhooks",
+			funcName, accountID, len(hooks))
+		return nil, errs.PoolError(errs.LimitExceeded, desc)
+	}
+
+	hook, err := newWebhook(accountID, hookURL)
+	if err != nil {
+		return nil, err
+	}
+	err = h.cfg.DB.persistWebhook(hook)
+	if err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// FetchWebhooks returns all webhooks of the account of the provided
+// address. The signature must be a signature of the message returned by
+// AccountProofMessage(net, "listwebhooks", timestamp) by the address.
+func (h *Hub) FetchWebhooks(address, signature string, timestamp int64) ([]*Webhook, error) {
+	err := verifyAccountProof(address, "listwebhooks", signature, timestamp,
+		h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return nil, err
+	}
+	return h.cfg.DB.fetchWebhooksForAccount(AccountID(address))
+}
+
+// DeleteWebhook deletes the referenced webhook of the account of the
+// provided address. The signature must be a signature of the message
+// returned by AccountProofMessage(net, "deletewebhook <id>", timestamp) by
+// the address. Deliveries pending to the webhook are dropped.
+func (h *Hub) DeleteWebhook(address, id, signature string, timestamp int64) error {
+	const funcName = "DeleteWebhook"
+	err := verifyAccountProof(address, "deletewebhook "+id, signature,
+		timestamp, h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return err
+	}
+
+	hook, err := h.cfg.DB.fetchWebhook(id)
+	if err != nil {
+		return err
+	}
+	if hook.AccountID != AccountID(address) {
+		desc := fmt.Sprintf("%s: webhook %s does not belong to address %s",
+			funcName, id, address)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	return h.cfg.DB.deleteWebhook(id)
+}
+
+// FetchWebhookDeliveries returns the webhook events pending delivery.
+func (h *Hub) FetchWebhookDeliveries() ([]*WebhookDelivery, error) {
+	return h.cfg.DB.fetchWebhookDeliveries()
+}
+
+// FetchWorkers returns the tracked workers of the provided account, ordered
+// by name.
+func (h *Hub) FetchWorkers(accountID string) []*Worker {
+	return h.workerMonitor.accountWorkers(accountID)
+}
+
+// FetchWorkerEvents returns the worker events of the provided account, most
+// recent first.
+func (h *Hub) FetchWorkerEvents(accountID string) ([]*WorkerEvent, error) {
+	return h.cfg.DB.fetchWorkerEventsForAccount(accountID)
+}
+
+// ForgetWorker stops tracking the referenced worker of the account of the
+// provided address, retired workers are otherwise reported offline. The
+// signature must be a signature of the message returned by
+// AccountProofMessage(net, "forgetworker <name>", timestamp) by the address.
+func (h *Hub) ForgetWorker(address, name, signature string, timestamp int64) error {
+	err := verifyAccountProof(address, "forgetworker "+name, signature,
+		timestamp, h.cfg.ActiveNet, h.usedProofs)
+	if err != nil {
+		return err
+	}
+	return h.workerMonitor.forget(AccountID(address), name)
+}
+
+// ResolveAPIToken returns the account id referenced by the provided token
+// of the provided kind.
+func (h *Hub) ResolveAPIToken(token, kind string) (string, error) {
+	const funcName = "ResolveAPIToken"
+	apiToken, err := h.cfg.DB.fetchAPIToken(hashAPIToken(token))
+	if err != nil {
+		return "", err
+	}
+	if apiToken.Kind != kind {
+		desc := fmt.Sprintf("%s: expected a %s token, got %s", funcName,
+			kind, apiToken.Kind)
+		return "", errs.PoolError(errs.Unauthorized, desc)
+	}
+	return apiToken.AccountID, nil
+}
+
+// bootstrapAdminUser creates the default admin user from the configured admin
+// password if no admin users exist.
+func (h *Hub) bootstrapAdminUser() error {
+	users, err := h.cfg.DB.listAdminUsers()
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return nil
+	}
+	if h.cfg.AdminPass == "" {
+		log.Warnf("No admin users exist and no admin password is set, the " +
+			"admin panel will be inaccessible.")
+		return nil
+	}
+
+	user, err := newAdminUser(DefaultAdminUser, h.cfg.AdminPass, RoleTreasurer)
+	if err != nil {
+		return err
+	}
+	err = h.cfg.DB.persistAdminUser(user)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Created admin user %q with the %s role from the admin "+
+		"password.", DefaultAdminUser, RoleTreasurer)
+
+	return h.RecordAdminAction(DefaultAdminUser, AuditBootstrapUser,
+		"created from adminpass", "")
+}
+
+// RecordAdminAction persists an audit log entry of the provided admin
+// action.
+func (h *Hub) RecordAdminAction(actor, action, details, ip string) error {
+	return h.cfg.DB.persistAuditEntry(newAuditEntry(actor, action, details, ip))
+}
+
+// recordAdminAction persists an audit log entry of the provided admin action,
+// logging any error encountered.
+func (h *Hub) recordAdminAction(actor, action, details, ip string) {
+	err := h.RecordAdminAction(actor, action, details, ip)
+	if err != nil {
+		log.Errorf("unable to record %s admin action by %s: %v", action,
+			actor, err)
+	}
+}
+
+// FetchAuditEntries returns the most recent admin audit log entries, newest
+// first.
+func (h *Hub) FetchAuditEntries(limit int) ([]*AuditEntry, error) {
+	return h.cfg.DB.fetchAuditEntries(limit)
+}
+
+// AuthenticateAdmin asserts the provided credentials are valid for the
+// referenced admin user, returning the user. The TOTP code is only required
+// if the user has enabled a second factor and can only be used once.
+// Repeated failures lock out the IP address for a period of time.
+func (h *Hub) AuthenticateAdmin(name, password, code, ip string) (*AdminUser, error) {
+	const funcName = "AuthenticateAdmin"
+	if h.loginThrottle.locked(ip) {
+		desc := fmt.Sprintf("%s: too many failed login attempts", funcName)
+		return nil, errs.PoolError(errs.LimitExceeded, desc)
+	}
+
+	fail := func(reason string) (*AdminUser, error) {
+		h.loginThrottle.failure(ip)
+		h.recordAdminAction(name, AuditLoginFailed, reason, ip)
+		desc := fmt.Sprintf("%s: invalid credentials", funcName)
+		return nil, errs.PoolError(errs.Unauthorized, desc)
+	}
+
+	user, err := h.cfg.DB.fetchAdminUser(name)
+	if err != nil {
+		if !errors.Is(err, errs.ValueNotFound) {
+			return nil, err
+		}
+		checkDummyPassword(password)
+		return fail("unknown user")
+	}
+	if !user.checkPassword(password) {
+		return fail("invalid password")
+	}
+	if user.TOTPSecret != "" {
+		step, ok := matchTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return fail("invalid totp code")
+		}
+		if !h.loginThrottle.useTOTP(name, step) {
+			return fail("reused totp code")
+		}
+	}
+
+	h.loginThrottle.success(ip)
+	h.recordAdminAction(name, AuditLogin, "", ip)
+	return user, nil
+}
+
+// FetchAdminUser returns the referenced admin user.
+func (h *Hub) FetchAdminUser(name string) (*AdminUser, error) {
+	return h.cfg.DB.fetchAdminUser(name)
+}
+
+// FetchAdminUsers returns all admin users.
+func (h *Hub) FetchAdminUsers() ([]*AdminUser, error) {
+	return h.cfg.DB.listAdminUsers()
+}
+
+// CreateAdminUser creates an admin user with the provided name, password and
+// role on behalf of the provided actor.
+func (h *Hub) CreateAdminUser(actor, ip, name, password, role string) error {
+	err := validateAdminPass(password)
+	if err != nil {
+		return err
+	}
+	user, err := newAdminUser(name, password, role)
+	if err != nil {
+		return err
+	}
+	err = h.cfg.DB.persistAdminUser(user)
+	if err != nil {
+		return err
+	}
+	h.recordAdminAction(actor, AuditCreateUser,
+		fmt.Sprintf("%s (%s)", name, role), ip)
+	return nil
+}
+
+// DeleteAdminUser deletes the referenced admin user on behalf of the
+// provided actor. Admin users cannot delete themselves.
+func (h *Hub) DeleteAdminUser(actor, ip, name string) error {
+	const funcName = "DeleteAdminUser"
+	if actor == name {
+		desc := fmt.Sprintf("%s: admin users cannot delete themselves",
+			funcName)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	_, err := h.cfg.DB.fetchAdminUser(name)
+	if err != nil {
+		return err
+	}
+	err = h.cfg.DB.deleteAdminUser(name)
+	if err != nil {
+		return err
+	}
+	h.recordAdminAction(actor, AuditDeleteUser, name, ip)
+	return nil
+}
+
+// ChangeAdminPassword updates the password of the referenced admin user
+// given their current password.
+func (h *Hub) ChangeAdminPassword(name, ip, oldPass, newPass string) error {
+	const funcName = "ChangeAdminPassword"
+	user, err := h.cfg.DB.fetchAdminUser(name)
+	if err != nil {
+		return err
+	}
+	if !user.checkPassword(oldPass) {
+		desc := fmt.Sprintf("%s: invalid password", funcName)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	err = validateAdminPass(newPass)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash, err = hashAdminPass(newPass)
+	if err != nil {
+		return err
+	}
+	err = h.cfg.DB.updateAdminUser(user)
+	if err != nil {
+		return err
+	}
+	h.recordAdminAction(name, AuditChangePass, "", ip)
+	return nil
+}
+
+// GenerateAdminTOTP returns a new TOTP secret and its otpauth URL for the
+// referenced admin user. The secret is not persisted until confirmed with
+// EnableAdminTOTP.
+func (h *Hub) GenerateAdminTOTP(name, issuer string) (string, string, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	return secret, totpURL(issuer, name, secret), nil
+}
+
+// EnableAdminTOTP enables the provided TOTP secret as a second factor of the
+// referenced admin user, given a valid code generated from it.
+func (h *Hub) EnableAdminTOTP(name, ip, secret, code string) error {
+	const funcName = "EnableAdminTOTP"
+	if !validTOTP(secret, code, time.Now()) {
+		desc := fmt.Sprintf("%s: invalid totp code", funcName)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	user, err := h.cfg.DB.fetchAdminUser(name)
+	if err != nil {
+		return err
+	}
+	user.TOTPSecret = secret
+	err = h.cfg.DB.updateAdminUser(user)
+	if err != nil {
+		return err
+	}
+	h.recordAdminAction(name, AuditEnableTOTP, "", ip)
+	return nil
+}
+
+// DisableAdminTOTP removes the second factor of the referenced admin user,
+// given a valid code.
+func (h *Hub) DisableAdminTOTP(name, ip, code string) error {
+	const funcName = "DisableAdminTOTP"
+	user, err := h.cfg.DB.fetchAdminUser(name)
+	if err != nil {
+		return err
+	}
+	if user.TOTPSecret == "" {
+		return nil
+	}
+	step, ok := matchTOTP(user.TOTPSecret, code, time.Now())
+	if !ok || !h.loginThrottle.useTOTP(name, step) {
+		desc := fmt.Sprintf("%s: invalid totp code", funcName)
+		return errs.PoolError(errs.Unauthorized, desc)
+	}
+	user.TOTPSecret = ""
+	err = h.cfg.DB.updateAdminUser(user)
+	if err != nil {
+		return err
+	}
+	h.recordAdminAction(name, AuditDisableTOTP, "", ip)
+	return nil
+}
+
+// ApplyConfig applies the provided configuration values to the hub and its
+// subsystems while the pool is running. Existing client connections are
+// unaffected by a lower connection limit, it applies to new connections.
+func (h *Hub) ApplyConfig(rc *ReloadableConfig) {
+	h.paymentMgr.setPoolFees(rc.PoolFee, rc.PoolFeeAddrs)
+	atomic.StoreUint32(&h.endpoint.cfg.MaxConnectionsPerHost,
+		rc.MaxConnectionsPerHost)
+}
+
+// DisconnectClient terminates the connection of the client with the provided
+// extraNonce1, or of all clients connected from the provided IP address, on
+// behalf of the provided actor.
+func (h *Hub) DisconnectClient(actor, ip, target string) error {
+	const funcName = "DisconnectClient"
+	match := func(c *Client) bool { return c.extraNonce1 == target }
+	if addr := net.ParseIP(target); addr != nil {
+		match = func(c *Client) bool { return c.addr.IP.Equal(addr) }
+	}
+
+	count := h.endpoint.disconnectClients(match)
+	if count == 0 {
+		desc := fmt.Sprintf("%s: no connected clients found for %s",
+			funcName, target)
+		return errs.PoolError(errs.ValueNotFound, desc)
+	}
+
+	log.Infof("Disconnected %d client(s) for %s on behalf of %s", count,
+		target, actor)
+	h.recordAdminAction(actor, AuditDisconnect,
+		fmt.Sprintf("%s (%d clients)", target, count), ip)
+	return nil
+}
+
+// ForceClientDifficulty overrides the difficulty of the client with the
+// provided extraNonce1 on behalf of the provided actor.
+func (h *Hub) ForceClientDifficulty(actor, ip, extraNonce1 string, difficulty float64) error {
+	const funcName = "ForceClientDifficulty"
+	client, ok := h.endpoint.fetchClient(extraNonce1)
+	if !ok {
+		desc := fmt.Sprintf("%s: no connected client found for %s",
+			funcName, extraNonce1)
+		return errs.PoolError(errs.ValueNotFound, desc)
+	}
+
+	diff := new(big.Rat).SetFloat64(difficulty)
+	if diff == nil {
+		desc := fmt.Sprintf("%s: invalid difficulty %v", funcName, difficulty)
+		return errs.PoolError(errs.LowDifficulty, desc)
+	}
+
+	err := client.forceDifficulty(diff)
+	if err != nil {
+		return err
+	}
+
+	h.recordAdminAction(actor, AuditForceDiff,
+		fmt.Sprintf("%s: %s", extraNonce1, diff.FloatString(3)), ip)
+	return nil
+}
+
+// banTarget resolves the provided IP address, mining address or account id
+// to the id and kind of ban it refers to.
+func (h *Hub) banTarget(target string) (string, string, error) {
+	const funcName = "banTarget"
+	if addr := net.ParseIP(target); addr != nil {
+		return addr.String(), IPBanKind, nil
+	}
+	if _, err := dcrutil.DecodeAddress(target, h.cfg.ActiveNet); err == nil {
+		return AccountID(target), AccountBanKind, nil
+	}
+	if b, err := hex.DecodeString(target); err == nil && len(b) == 32 {
+		return target, AccountBanKind, nil
+	}
+	desc := fmt.Sprintf("%s: %s is not an IP address, mining address or "+
+		"account id", funcName, target)
+	return "", "", errs.PoolError(errs.Parse, desc)
+}
+
+// isBanned returns whether the provided IP address or account id is banned
+// from the pool.
+func (h *Hub) isBanned(id string) bool {
+	h.bansMtx.RLock()
+	_, ok := h.bans[id]
+	h.bansMtx.RUnlock()
+	return ok
+}
+
+// BanClient bans the provided IP address, mining address or account id from
+// the pool on behalf of the provided actor. Connected clients matching the
+// ban are disconnected.
+func (h *Hub) BanClient(actor, ip, target, reason string) error {
+	id, kind, err := h.banTarget(target)
+	if err != nil {
+		return err
+	}
+
+	ban := newBan(id, kind, reason)
+	err = h.cfg.DB.persistBan(ban)
+	if err != nil {
+		return err
+	}
+
+	h.bansMtx.Lock()
+	h.bans[id] = ban
+	h.bansMtx.Unlock()
+
+	match := func(c *Client) bool { return c.account == id }
+	if kind == IPBanKind {
+		match = func(c *Client) bool { return c.addr.IP.String() == id }
+	}
+	count := h.endpoint.disconnectClients(match)
+
+	log.Infof("Banned %s %s on behalf of %s, disconnected %d client(s)",
+		kind, id, actor, count)
+	h.recordAdminAction(actor, AuditBan,
+		fmt.Sprintf("%s %s: %s", kind, id, reason), ip)
+	return nil
+}
+
+// UnbanClient lifts the ban of the provided IP address, mining address or
+// account id on behalf of the provided actor.
+func (h *Hub) UnbanClient(actor, ip, target string) error {
+	const funcName = "UnbanClient"
+	id, kind, err := h.banTarget(target)
+	if err != nil {
+		return err
+	}
+
+	if !h.isBanned(id) {
+		desc := fmt.Sprintf("%s: %s %s is not banned", funcName, kind, id)
+		return errs.PoolError(errs.ValueNotFound, desc)
+	}
+
+	err = h.cfg.DB.deleteBan(id)
+	if err != nil {
+		return err
+	}
+
+	h.bansMtx.Lock()
+	delete(h.bans, id)
+	h.bansMtx.Unlock()
+
+	log.Infof("Unbanned %s %s on behalf of %s", kind, id, actor)
+	h.recordAdminAction(actor, AuditUnban, fmt.Sprintf("%s %s", kind, id), ip)
+	return nil
+}
+
+// FetchBans returns all bans, oldest first.
+func (h *Hub) FetchBans() []*Ban {
+	h.bansMtx.RLock()
+	bans := make([]*Ban, 0, len(h.bans))
+	for _, ban := range h.bans {
+		bans = append(bans, ban)
+	}
+	h.bansMtx.RUnlock()
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedOn < bans[j].CreatedOn
+	})
+	return bans
+}
+
+// AdjustBalance books a manual adjustment of the balance of the provided
+// mining address, account id or the pool fees by the provided amount on
+// behalf of the provided actor. A positive amount credits the balance, a
+// negative amount debits it. Adjustments of accounts are paid by the next
+// payouts out of the pool fees, adjustments of the pool fees only correct the
+// ledger.
+func (h *Hub) AdjustBalance(actor, ip, target string, amount dcrutil.Amount, memo string) error {
+	const funcName = "AdjustBalance"
+	if amount == 0 {
+		desc := fmt.Sprintf("%s: adjustment amount cannot be zero", funcName)
+		return errs.PoolError(errs.Parse, desc)
+	}
+	if memo == "" {
+		desc := fmt.Sprintf("%s: adjustment memo is required", funcName)
+		return errs.PoolError(errs.Parse, desc)
+	}
+
+	account := target
+	if target != PoolFeesK {
+		id, kind, err := h.banTarget(target)
+		if err != nil {
+			return err
+		}
+		if kind != AccountBanKind {
+			desc := fmt.Sprintf("%s: %s is not a mining address or "+
+				"account id", funcName, target)
+			return errs.PoolError(errs.Parse, desc)
+		}
+		_, err = h.cfg.DB.fetchAccount(id)
+		if err != nil {
+			return err
+		}
+		account = id
+	}
+
+	entry := adjustmentEntry(account, amount, actor, memo)
+	err := h.cfg.DB.persistLedgerEntries([]*LedgerEntry{entry})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Adjusted balance of %s by %v on behalf of %s", account,
+		amount, actor)
+	h.recordAdminAction(actor, AuditAdjust,
+		fmt.Sprintf("%s %v: %s", account, amount, memo), ip)
+	return nil
+}
+
+// FetchLedgerEntries returns the most recent ledger entries of the provided
+// ledger account, or of all ledger accounts if it is empty, newest first.
+func (h *Hub) FetchLedgerEntries(account string, limit int) ([]*LedgerEntry, error) {
+	return h.cfg.DB.fetchLedgerEntries(account, limit)
+}
+
+// FetchLedgerBalances returns the balance of every ledger account.
+func (h *Hub) FetchLedgerBalances() (map[string]dcrutil.Amount, error) {
+	return h.cfg.DB.fetchLedgerBalances()
+}
+
+// FetchAccountBalances returns the ledger balance of every ledger account
+// along with the amount paid out to it.
+func (h *Hub) FetchAccountBalances() (map[string]*AccountBalance, error) {
+	return h.cfg.DB.fetchAccountBalances("")
+}
+
+// FetchAccountBalance returns the ledger balance of the provided account
+// along with the amount paid out to it.
+func (h *Hub) FetchAccountBalance(accountID string) (*AccountBalance, error) {
+	balances, err := h.cfg.DB.fetchAccountBalances(accountID)
+	if err != nil {
+		return nil, err
+	}
+	balance, ok := balances[accountID]
+	if !ok {
+		return new(AccountBalance), nil
+	}
+	return balance, nil
+}
+
+// FetchPendingPayout returns the payout transaction awaiting an external
+// signature.
+func (h *Hub) FetchPendingPayout() (*PendingPayout, error) {
+	return h.paymentMgr.fetchPendingPayout()
+}
+
+// ExportPayout returns the payout transaction awaiting an external signature
+// for download on behalf of the provided actor.
+func (h *Hub) ExportPayout(actor, ip string) (*PendingPayout, error) {
+	payout, err := h.paymentMgr.fetchPendingPayout()
+	if err != nil {
+		return nil, err
+	}
+
+	h.recordAdminAction(actor, AuditExportPayout, payout.TxID, ip)
+	return payout, nil
+}
+
+// PublishPayout publishes the provided hex encoded signed payout
+// transaction on behalf of the provided actor, returning its hash. The
+// signed transaction must match the pending payout transaction.
+func (h *Hub) PublishPayout(ctx context.Context, actor, ip, signedTx string) (string, error) {
+	txid, err := h.paymentMgr.publishPayout(ctx, signedTx)
+	if err != nil {
+		return "", err
+	}
+
+	log.Infof("Published payout tx %s on behalf of %s", txid, actor)
+	h.recordAdminAction(actor, AuditPublishPayout, txid, ip)
+	h.SignalCache(DividendsPaid)
+	return txid, nil
+}
+
+// DiscardPayout deletes the payout transaction awaiting an external
+// signature on behalf of the provided actor. The payments it covers are
+// included in the next payout instead.
+func (h *Hub) DiscardPayout(actor, ip string) error {
+	payout, err := h.paymentMgr.discardPayout()
+	if err != nil {
+		return err
+	}
+
+	h.recordAdminAction(actor, AuditDiscardPayout, payout.TxID, ip)
+	return nil
+}
+
 // CSRFSecret fetches a persisted secret or generates a new one.
 func (h *Hub) CSRFSecret() ([]byte, error) {
 	secret, err := h.cfg.DB.fetchCSRFSecret()
@@ -19,6 +19,8 @@ import (
 	chainjson "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
 	"github.com/decred/dcrd/wire"
 	"google.golang.org/grpc"
+
+	errs "github.com/decred/dcrpool/errors"
 )
 
 type tWalletConnection struct {
@@ -208,6 +210,19 @@ func (t *tNodeConnection) GetBlockVerbose(context.Context, *chainhash.Hash, bool
 		Confirmations: -1,
 	}, nil
 }
+
+func (t *tNodeConnection) GetBestBlock(context.Context) (*chainhash.Hash, int64, error) {
+	return nil, 0, errors.New("chain tip unavailable")
+}
+
+func (t *tNodeConnection) GetBlockHash(context.Context, int64) (*chainhash.Hash, error) {
+	return nil, errors.New("block hash unavailable")
+}
+
+func (t *tNodeConnection) GetBlockHeader(context.Context, *chainhash.Hash) (*wire.BlockHeader, error) {
+	return nil, errors.New("block header unavailable")
+}
+
 func (t *tNodeConnection) NotifyWork(context.Context) error {
 	return nil
 }
@@ -456,6 +471,113 @@ func testHub(t *testing.T) {
 		t.Fatal("expected a non-nil csrf secref")
 	}
 
+	// Ensure accounts can be banned and unbanned by mining address.
+	err = hub.BanClient("admin", "127.0.0.1", xAddr, "testing")
+	if err != nil {
+		t.Fatalf("[BanClient] unexpected error: %v", err)
+	}
+	if !hub.isBanned(account.UUID) {
+		t.Fatalf("expected account %s to be banned", account.UUID)
+	}
+	if len(hub.FetchBans()) != 1 {
+		t.Fatalf("expected 1 ban, got %d", len(hub.FetchBans()))
+	}
+	err = hub.UnbanClient("admin", "127.0.0.1", account.UUID)
+	if err != nil {
+		t.Fatalf("[UnbanClient] unexpected error: %v", err)
+	}
+	if hub.isBanned(account.UUID) {
+		t.Fatalf("expected account %s to be unbanned", account.UUID)
+	}
+	err = hub.UnbanClient("admin", "127.0.0.1", account.UUID)
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+	err = hub.BanClient("admin", "127.0.0.1", "invalid", "")
+	if !errors.Is(err, errs.Parse) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+
+	// Ensure balances can be adjusted by mining address and for the pool
+	// fees, and the adjustments are recorded in the ledger.
+	before, err := hub.FetchLedgerBalances()
+	if err != nil {
+		t.Fatalf("[FetchLedgerBalances] unexpected error: %v", err)
+	}
+	err = hub.AdjustBalance("admin", "127.0.0.1", xAddr, 500, "refund")
+	if err != nil {
+		t.Fatalf("[AdjustBalance] unexpected error: %v", err)
+	}
+	err = hub.AdjustBalance("admin", "127.0.0.1", PoolFeesK, -200, "correction")
+	if err != nil {
+		t.Fatalf("[AdjustBalance] unexpected error: %v", err)
+	}
+	balances, err := hub.FetchLedgerBalances()
+	if err != nil {
+		t.Fatalf("[FetchLedgerBalances] unexpected error: %v", err)
+	}
+	if balances[account.UUID]-before[account.UUID] != 500 ||
+		balances[PoolFeesK]-before[PoolFeesK] != -200 ||
+		balances[LedgerAdjustments]-before[LedgerAdjustments] != -300 {
+		t.Fatalf("unexpected ledger balances %v", balances)
+	}
+	entries, err := hub.FetchLedgerEntries(account.UUID, 1)
+	if err != nil {
+		t.Fatalf("[FetchLedgerEntries] unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Kind != LedgerAdjustment ||
+		entries[0].Actor != "admin" || entries[0].Memo != "refund" {
+		t.Fatalf("unexpected ledger entries %v", entries)
+	}
+
+	// Ensure invalid adjustments are rejected.
+	err = hub.AdjustBalance("admin", "127.0.0.1", xAddr, 500, "")
+	if !errors.Is(err, errs.Parse) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	err = hub.AdjustBalance("admin", "127.0.0.1", xAddr, 0, "refund")
+	if !errors.Is(err, errs.Parse) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	err = hub.AdjustBalance("admin", "127.0.0.1", "127.0.0.1", 500, "refund")
+	if !errors.Is(err, errs.Parse) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	err = hub.AdjustBalance("admin", "127.0.0.1", yAddr, 500, "refund")
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+
+	// Ensure unknown clients cannot be acted on.
+	err = hub.ForceClientDifficulty("admin", "127.0.0.1", "unknown", 2)
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+	err = hub.DisconnectClient("admin", "127.0.0.1", "unknown")
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+
+	// Ensure reloadable config values are applied.
+	feeAddrs := []dcrutil.Address{poolFeeAddrs}
+	hub.ApplyConfig(&ReloadableConfig{
+		PoolFee:               0.05,
+		PoolFeeAddrs:          feeAddrs,
+		MaxConnectionsPerHost: 3,
+	})
+	if hub.paymentMgr.poolFee() != 0.05 {
+		t.Fatalf("expected a pool fee of 0.05, got %v",
+			hub.paymentMgr.poolFee())
+	}
+	if len(hub.paymentMgr.poolFeeAddrs()) != 1 {
+		t.Fatalf("expected 1 pool fee address, got %d",
+			len(hub.paymentMgr.poolFeeAddrs()))
+	}
+	if hub.endpoint.cfg.MaxConnectionsPerHost != 3 {
+		t.Fatalf("expected a max connections per host of 3, got %d",
+			hub.endpoint.cfg.MaxConnectionsPerHost)
+	}
+
 	cancel()
 	hub.wg.Wait()
 }
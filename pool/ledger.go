@@ -0,0 +1,259 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// Ledger entry kinds.
+const (
+	// LedgerShareReward credits an account with its share of a block reward.
+	LedgerShareReward = "sharereward"
+
+	// LedgerPoolFee credits the pool fees with their share of a block
+	// reward, including forfeited dust payments.
+	LedgerPoolFee = "poolfee"
+
+	// LedgerOrphan reverses the rewards sourced from an orphaned block.
+	LedgerOrphan = "orphan"
+
+	// LedgerPayout debits an account for the value paid out to it.
+	LedgerPayout = "payout"
+
+	// LedgerTxFee debits an account for its portion of the fee of a payout
+	// transaction.
+	LedgerTxFee = "txfee"
+
+	// LedgerAdjustment is a manual adjustment made by an admin.
+	LedgerAdjustment = "adjustment"
+
+	// LedgerSettlement settles the manual adjustments of an account paid
+	// by a payout between the pool fees and the adjustments.
+	LedgerSettlement = "settlement"
+)
+
+// Ledger accounts of the pool. The ledger accounts of participating accounts
+// are their account ids, the pool fees are booked to PoolFeesK.
+const (
+	// LedgerCoinbase is the ledger account block rewards are booked from.
+	LedgerCoinbase = "coinbase"
+
+	// LedgerPayouts is the ledger account payouts are booked to.
+	LedgerPayouts = "payouts"
+
+	// LedgerTxFees is the ledger account payout transaction fees are booked
+	// to.
+	LedgerTxFees = "txfees"
+
+	// LedgerAdjustments is the ledger account manual adjustments are booked
+	// against.
+	LedgerAdjustments = "adjustments"
+)
+
+// LedgerEntry represents an amount moved from one ledger account to another.
+// Entries are never updated or deleted, corrections are booked as new
+// entries. The balance of a ledger account is the sum of the amounts
+// credited to it minus the sum of the amounts debited from it, the balances
+// of all ledger accounts always sum to zero.
+type LedgerEntry struct {
+	UUID      string         `json:"uuid"`
+	Kind      string         `json:"kind"`
+	Debit     string         `json:"debit"`
+	Credit    string         `json:"credit"`
+	Amount    dcrutil.Amount `json:"amount"`
+	BlockHash string         `json:"blockhash"`
+	TxID      string         `json:"txid"`
+	Actor     string         `json:"actor"`
+	Memo      string         `json:"memo"`
+	CreatedOn int64          `json:"createdon"`
+}
+
+// AccountBalance represents the ledger balance of an account along with the
+// amount paid out to it.
+type AccountBalance struct {
+	// Balance is the amount owed to the account, including its immature
+	// block rewards.
+	Balance dcrutil.Amount `json:"balance"`
+	// Paid is the amount paid out to the account, including its portion of
+	// the payout transaction fees.
+	Paid dcrutil.Amount `json:"paid"`
+}
+
+// bookAccountBalance books the provided ledger entry to the balances of the
+// ledger accounts it debits and credits, the amount debited for a payout is
+// also added to the amount paid out to the debited account.
+func bookAccountBalance(balances map[string]*AccountBalance, entry *LedgerEntry) {
+	balance := func(account string) *AccountBalance {
+		b, ok := balances[account]
+		if !ok {
+			b = new(AccountBalance)
+			balances[account] = b
+		}
+		return b
+	}
+	debit := balance(entry.Debit)
+	debit.Balance -= entry.Amount
+	if entry.Kind == LedgerPayout || entry.Kind == LedgerTxFee {
+		debit.Paid += entry.Amount
+	}
+	balance(entry.Credit).Balance += entry.Amount
+}
+
+// filterAccountBalances returns the balance of the provided ledger account
+// only, or all of the provided balances if it is empty.
+func filterAccountBalances(balances map[string]*AccountBalance, account string) map[string]*AccountBalance {
+	if account == "" {
+		return balances
+	}
+	filtered := make(map[string]*AccountBalance, 1)
+	if balance, ok := balances[account]; ok {
+		filtered[account] = balance
+	}
+	return filtered
+}
+
+// sortLedgerEntries orders the provided ledger entries by creation time,
+// newest first.
+func sortLedgerEntries(entries []*LedgerEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CreatedOn != entries[j].CreatedOn {
+			return entries[i].CreatedOn > entries[j].CreatedOn
+		}
+		return entries[i].UUID > entries[j].UUID
+	})
+}
+
+// ledgerEntryID generates a unique ledger entry id using the provided kind
+// and reference. Entries booked for a payment reference the payment id, which
+// makes booking them idempotent.
+func ledgerEntryID(kind string, ref string) string {
+	return kind + ref
+}
+
+// newLedgerEntry creates a ledger entry of the provided kind moving the
+// provided amount from the debit to the credit ledger account.
+func newLedgerEntry(kind, ref, debit, credit string, amount dcrutil.Amount) *LedgerEntry {
+	return &LedgerEntry{
+		UUID:      ledgerEntryID(kind, ref),
+		Kind:      kind,
+		Debit:     debit,
+		Credit:    credit,
+		Amount:    amount,
+		CreatedOn: time.Now().UnixNano(),
+	}
+}
+
+// rewardEntry creates the ledger entry booking the provided payment of a
+// block reward.
+func rewardEntry(pmt *Payment) *LedgerEntry {
+	kind := LedgerShareReward
+	if pmt.Account == PoolFeesK {
+		kind = LedgerPoolFee
+	}
+	entry := newLedgerEntry(kind, pmt.UUID, LedgerCoinbase, pmt.Account,
+		pmt.Amount)
+	if pmt.Source != nil {
+		entry.BlockHash = pmt.Source.BlockHash
+	}
+	return entry
+}
+
+// orphanEntry creates the ledger entry reversing the provided payment of a
+// block reward sourced from an orphaned block.
+func orphanEntry(pmt *Payment) *LedgerEntry {
+	entry := newLedgerEntry(LedgerOrphan, pmt.UUID, pmt.Account,
+		LedgerCoinbase, pmt.Amount)
+	if pmt.Source != nil {
+		entry.BlockHash = pmt.Source.BlockHash
+	}
+	return entry
+}
+
+// payoutEntries creates the ledger entries booking the payout of the
+// provided amount to the provided ledger account by the provided
+// transaction, along with the portion of the transaction fee paid by the
+// account. Entries of zero amounts are omitted.
+func payoutEntries(account, txid string, paid, txFee dcrutil.Amount) []*LedgerEntry {
+	entries := make([]*LedgerEntry, 0, 2)
+	if paid > 0 {
+		entry := newLedgerEntry(LedgerPayout, txid+account, account,
+			LedgerPayouts, paid)
+		entry.TxID = txid
+		entries = append(entries, entry)
+	}
+	if txFee > 0 {
+		entry := newLedgerEntry(LedgerTxFee, txid+account, account,
+			LedgerTxFees, txFee)
+		entry.TxID = txid
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// adjustmentEntry creates the ledger entry of a manual adjustment of the
+// balance of the provided ledger account by the provided actor. A positive
+// amount credits the account, a negative amount debits it.
+func adjustmentEntry(account string, amount dcrutil.Amount, actor, memo string) *LedgerEntry {
+	now := time.Now().UnixNano()
+	ref := hex.EncodeToString(nanoToBigEndianBytes(now)) + account
+	debit, credit := LedgerAdjustments, account
+	if amount < 0 {
+		debit, credit = account, LedgerAdjustments
+		amount = -amount
+	}
+	entry := newLedgerEntry(LedgerAdjustment, ref, debit, credit, amount)
+	entry.Actor = actor
+	entry.Memo = memo
+	entry.CreatedOn = now
+	return entry
+}
+
+// settlementEntry creates the ledger entry settling the provided adjustment
+// of the provided account paid by the provided transaction. Credits are paid
+// out of the pool fees, debits are refunded to them.
+func settlementEntry(account, txid string, amount dcrutil.Amount) *LedgerEntry {
+	debit, credit := PoolFeesK, LedgerAdjustments
+	if amount < 0 {
+		debit, credit = LedgerAdjustments, PoolFeesK
+		amount = -amount
+	}
+	entry := newLedgerEntry(LedgerSettlement, txid+account, debit, credit,
+		amount)
+	entry.TxID = txid
+	return entry
+}
+
+// backfillLedgerEntries creates the ledger entries of the provided pending
+// and archived payments made before the ledger was introduced. The fees of
+// past payout transactions are not known, they are booked as part of the
+// payouts.
+func backfillLedgerEntries(pending []*Payment, archived []*Payment) []*LedgerEntry {
+	entries := make([]*LedgerEntry, 0, len(pending)+len(archived)*2)
+	for _, pmt := range pending {
+		entry := rewardEntry(pmt)
+		entry.CreatedOn = pmt.CreatedOn
+		entries = append(entries, entry)
+	}
+	for _, pmt := range archived {
+		entry := rewardEntry(pmt)
+		entry.CreatedOn = pmt.CreatedOn
+		entries = append(entries, entry)
+
+		entry = newLedgerEntry(LedgerPayout, pmt.UUID, pmt.Account,
+			LedgerPayouts, pmt.Amount)
+		entry.TxID = pmt.TransactionID
+		if pmt.Source != nil {
+			entry.BlockHash = pmt.Source.BlockHash
+		}
+		entry.CreatedOn = pmt.CreatedOn + 1
+		entries = append(entries, entry)
+	}
+	return entries
+}
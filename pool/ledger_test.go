@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func testLedger(t *testing.T) {
+	pmtX := NewPayment(xID, zeroSource, 300, 10, 20)
+	pmtY := NewPayment(yID, zeroSource, 200, 10, 20)
+	pmtFee := NewPayment(PoolFeesK, zeroSource, 10, 10, 20)
+	entries := []*LedgerEntry{rewardEntry(pmtX), rewardEntry(pmtY),
+		rewardEntry(pmtFee)}
+
+	// Ensure block rewards can be booked.
+	err := db.persistLedgerEntries(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[2].Kind != LedgerPoolFee {
+		t.Fatalf("expected a %s entry, got %s", LedgerPoolFee, entries[2].Kind)
+	}
+
+	// Ensure booking the reward of a payment twice fails.
+	err = db.persistLedgerEntries([]*LedgerEntry{rewardEntry(pmtX)})
+	if !errors.Is(err, errs.ValueFound) {
+		t.Fatalf("expected a value found error, got %v", err)
+	}
+
+	// Ensure orphaned rewards, payouts and adjustments can be booked.
+	entries = []*LedgerEntry{orphanEntry(pmtY)}
+	entries = append(entries, payoutEntries(xID, "txid", 290, 10)...)
+	entries = append(entries, payoutEntries(PoolFeesK, "txid", 10, 0)...)
+	entries = append(entries, adjustmentEntry(yID, -25, "admin", "fine"))
+	err = db.persistLedgerEntries(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balances, err := db.fetchLedgerBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]dcrutil.Amount{
+		LedgerCoinbase:    -310,
+		xID:               0,
+		yID:               -25,
+		PoolFeesK:         0,
+		LedgerPayouts:     300,
+		LedgerTxFees:      10,
+		LedgerAdjustments: 25,
+	}
+	var sum dcrutil.Amount
+	for account, balance := range balances {
+		if balance != expected[account] {
+			t.Fatalf("expected a balance of %v for %s, got %v",
+				expected[account], account, balance)
+		}
+		sum += balance
+	}
+	if sum != 0 {
+		t.Fatalf("expected balances to sum to zero, got %v", sum)
+	}
+
+	// Ensure account balances match the ledger balances and include the
+	// amounts paid out.
+	accBalances, err := db.fetchAccountBalances("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accBalances) != len(balances) {
+		t.Fatalf("expected %d account balances, got %d", len(balances),
+			len(accBalances))
+	}
+	for account, balance := range balances {
+		if accBalances[account].Balance != balance {
+			t.Fatalf("expected an account balance of %v for %s, got %v",
+				balance, account, accBalances[account].Balance)
+		}
+	}
+	if accBalances[xID].Paid != 300 || accBalances[PoolFeesK].Paid != 10 ||
+		accBalances[yID].Paid != 0 {
+		t.Fatalf("unexpected paid amounts %v, %v and %v",
+			accBalances[xID].Paid, accBalances[PoolFeesK].Paid,
+			accBalances[yID].Paid)
+	}
+
+	// Ensure the balance of a single account can be fetched.
+	accBalances, err = db.fetchAccountBalances(xID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accBalances) != 1 || accBalances[xID].Balance != 0 ||
+		accBalances[xID].Paid != 300 {
+		t.Fatalf("unexpected account balances %v", accBalances)
+	}
+
+	// Ensure entries can be fetched per ledger account.
+	fetched, err := db.fetchLedgerEntries(yID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 3 {
+		t.Fatalf("expected 3 ledger entries, got %d", len(fetched))
+	}
+	if fetched[0].Kind != LedgerAdjustment || fetched[0].Memo != "fine" {
+		t.Fatalf("expected the adjustment to be the newest entry, got %v",
+			fetched[0])
+	}
+
+	// Ensure payments made before the ledger was introduced are booked as
+	// paid once backfilled.
+	archived := NewPayment(xID, zeroSource, 100, 10, 20)
+	archived.TransactionID = "oldtxid"
+	backfill := backfillLedgerEntries(nil, []*Payment{archived})
+	if len(backfill) != 2 {
+		t.Fatalf("expected 2 backfilled entries, got %d", len(backfill))
+	}
+	if backfill[1].Kind != LedgerPayout || backfill[1].TxID != "oldtxid" ||
+		backfill[1].CreatedOn <= backfill[0].CreatedOn {
+		t.Fatalf("unexpected backfilled payout entry %v", backfill[1])
+	}
+}
@@ -15,6 +15,7 @@ import (
 const (
 	GUIClient = iota
 	PoolClient
+	APIClient
 )
 
 const (
@@ -34,6 +35,13 @@ const (
 	// multiple requests, eg. pagination, establishing a websocket, AJAX form
 	// submissions.
 	guiBurst = 7
+	// apiTokenRate is the token refill rate for the public API request
+	// bucket, per second.
+	apiTokenRate = 2
+	// apiBurst is the maximum token usage allowed per second, for public
+	// API clients. Third-party monitors are expected to poll at a modest
+	// rate, a small burst allows fetching a few resources at once.
+	apiBurst = 5
 )
 
 // RateLimiter keeps connected clients within their allocated request rates.
@@ -58,6 +66,8 @@ func (r *RateLimiter) addRequestLimiter(ip string, clientType int) (*rate.Limite
 		limiter = rate.NewLimiter(guiTokenRate, guiBurst)
 	case PoolClient:
 		limiter = rate.NewLimiter(clientTokenRate, clientBurst)
+	case APIClient:
+		limiter = rate.NewLimiter(apiTokenRate, apiBurst)
 	default:
 		return nil, fmt.Errorf("unknown client type provided: %d", clientType)
 	}
@@ -0,0 +1,1346 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// NewMemoryDB creates an empty in-memory database.
+func NewMemoryDB() *MemoryDB {
+	db := new(MemoryDB)
+	db.reset()
+	return db
+}
+
+// reset wipes all entities and metadata. The caller must hold the write lock
+// unless the database is not shared yet.
+func (db *MemoryDB) reset() {
+	db.meta = archiveMetadata{}
+	db.entities = make(map[string]map[string][]byte, len(archiveBuckets))
+	for _, entry := range archiveBuckets {
+		db.entities[entry.kind] = make(map[string][]byte)
+	}
+}
+
+// sortedIDs returns the ids of all entities of the provided kind in
+// ascending order, or descending order if reverse is set. Entities are
+// ordered by id like the keys of a bolt bucket. The caller must hold a lock.
+func (db *MemoryDB) sortedIDs(kind string, reverse bool) []string {
+	ids := make([]string, 0, len(db.entities[kind]))
+	for id := range db.entities[kind] {
+		ids = append(ids, id)
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	} else {
+		sort.Strings(ids)
+	}
+	return ids
+}
+
+// forEach decodes every entity of the provided kind in id order, calling
+// the provided function with each of them. The entity func returns a new
+// entity to decode into. The caller must hold a lock.
+func (db *MemoryDB) forEach(funcName string, kind string, reverse bool, entity func() interface{}, fn func(interface{})) error {
+	for _, id := range db.sortedIDs(kind, reverse) {
+		v := entity()
+		err := json.Unmarshal(db.entities[kind][id], v)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to unmarshal %s: %v",
+				funcName, kind, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		fn(v)
+	}
+	return nil
+}
+
+// fetch decodes the entity of the provided kind and id into the provided
+// entity. Returns an error if the entity is not found.
+func (db *MemoryDB) fetch(funcName string, kind string, id string, entity interface{}) error {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	v, ok := db.entities[kind][id]
+	if !ok {
+		desc := fmt.Sprintf("%s: no %s found for id %s", funcName, kind, id)
+		return errs.DBError(errs.ValueNotFound, desc)
+	}
+	err := json.Unmarshal(v, entity)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to unmarshal %s: %v", funcName,
+			kind, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+	return nil
+}
+
+// put stores the provided entity of the provided kind. The caller must hold
+// the write lock.
+func (db *MemoryDB) put(funcName string, kind string, id string, entity interface{}) error {
+	b, err := json.Marshal(entity)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to marshal %s bytes: %v", funcName,
+			kind, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+	db.entities[kind][id] = b
+	return nil
+}
+
+// insert stores the provided entity of the provided kind. Returns an error
+// if an entity already exists with the same id.
+func (db *MemoryDB) insert(funcName string, kind string, id string, entity interface{}) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if _, ok := db.entities[kind][id]; ok {
+		desc := fmt.Sprintf("%s: %s %s already exists", funcName, kind, id)
+		return errs.DBError(errs.ValueFound, desc)
+	}
+	return db.put(funcName, kind, id, entity)
+}
+
+// update replaces the stored entity of the provided kind. Returns an error
+// if the entity is not found.
+func (db *MemoryDB) update(funcName string, kind string, id string, entity interface{}) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if _, ok := db.entities[kind][id]; !ok {
+		desc := fmt.Sprintf("%s: %s %s not found", funcName, kind, id)
+		return errs.DBError(errs.ValueNotFound, desc)
+	}
+	return db.put(funcName, kind, id, entity)
+}
+
+// remove deletes the entity of the provided kind and id. Removing an entity
+// which does not exist is a no-op.
+func (db *MemoryDB) remove(kind string, id string) error {
+	db.mtx.Lock()
+	delete(db.entities[kind], id)
+	db.mtx.Unlock()
+	return nil
+}
+
+// httpBackup streams an archive of the entire database over the provided
+// HTTP response writer.
+func (db *MemoryDB) httpBackup(w http.ResponseWriter) error {
+	return serveArchive(db, w)
+}
+
+// purge wipes all persisted data.
+func (db *MemoryDB) purge() error {
+	db.mtx.Lock()
+	db.reset()
+	db.mtx.Unlock()
+	return nil
+}
+
+// Backup saves an archive of the db to the provided file path.
+func (db *MemoryDB) Backup(fileName string) error {
+	return writeArchiveFile(db, fileName)
+}
+
+// Close is a no-op, the contents of the database remain available.
+func (db *MemoryDB) Close() error {
+	return nil
+}
+
+// exportRecords streams the pool metadata, if requested, and every stored
+// entity through the provided export funcs.
+func (db *MemoryDB) exportRecords(metaFn exportMetadataFunc, fn exportFunc) error {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if metaFn != nil {
+		meta := db.meta
+		meta.CSRFSecret = append([]byte(nil), db.meta.CSRFSecret...)
+		err := metaFn(&meta)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range archiveBuckets {
+		for _, id := range db.sortedIDs(entry.kind, false) {
+			err := fn(entry.kind, json.RawMessage(db.entities[entry.kind][id]))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importRecords stores the provided archive records. Returns an error if any
+// of the entities already exists, in which case none of them are stored.
+func (db *MemoryDB) importRecords(records []*archiveRecord) error {
+	const funcName = "importRecords"
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	type entry struct {
+		kind string
+		id   string
+		data []byte
+	}
+	entries := make([]entry, 0, len(records))
+	seen := make(map[string]struct{}, len(records))
+	for _, record := range records {
+		entity, id, err := decodeArchiveRecord(record)
+		if err != nil {
+			return err
+		}
+		key := record.Kind + "/" + id
+		_, dup := seen[key]
+		if _, ok := db.entities[record.Kind][id]; ok || dup {
+			desc := fmt.Sprintf("%s: %s %s already exists", funcName,
+				record.Kind, id)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+		seen[key] = struct{}{}
+		b, err := json.Marshal(entity)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to marshal %s bytes: %v",
+				funcName, record.Kind, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		entries = append(entries, entry{record.Kind, id, b})
+	}
+	for _, e := range entries {
+		db.entities[e.kind][e.id] = e.data
+	}
+	return nil
+}
+
+// fetchPoolMode retrieves the pool mode. 0 indicates Public, 1 indicates
+// Solo.
+func (db *MemoryDB) fetchPoolMode() (uint32, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.meta.PoolMode == nil {
+		return 0, errs.DBError(errs.ValueNotFound, "no pool mode found")
+	}
+	return *db.meta.PoolMode, nil
+}
+
+// persistPoolMode stores the pool mode.
+func (db *MemoryDB) persistPoolMode(mode uint32) error {
+	db.mtx.Lock()
+	db.meta.PoolMode = &mode
+	db.mtx.Unlock()
+	return nil
+}
+
+// fetchCSRFSecret retrieves the bytes used for the CSRF secret.
+func (db *MemoryDB) fetchCSRFSecret() ([]byte, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if len(db.meta.CSRFSecret) == 0 {
+		return nil, errs.DBError(errs.ValueNotFound, "No csrf secret found")
+	}
+	secret := make([]byte, len(db.meta.CSRFSecret))
+	copy(secret, db.meta.CSRFSecret)
+	return secret, nil
+}
+
+// persistCSRFSecret stores the bytes used for the CSRF secret.
+func (db *MemoryDB) persistCSRFSecret(secret []byte) error {
+	db.mtx.Lock()
+	db.meta.CSRFSecret = make([]byte, len(secret))
+	copy(db.meta.CSRFSecret, secret)
+	db.mtx.Unlock()
+	return nil
+}
+
+// persistLastPaymentInfo stores the last payment height and paidOn
+// timestamp.
+func (db *MemoryDB) persistLastPaymentInfo(height uint32, paidOn int64) error {
+	db.mtx.Lock()
+	db.meta.LastPaymentHeight = &height
+	db.meta.LastPaymentPaidOn = &paidOn
+	db.mtx.Unlock()
+	return nil
+}
+
+// loadLastPaymentInfo retrieves the last payment height and paidOn
+// timestamp.
+func (db *MemoryDB) loadLastPaymentInfo() (uint32, int64, error) {
+	const funcName = "loadLastPaymentInfo"
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.meta.LastPaymentHeight == nil || db.meta.LastPaymentPaidOn == nil {
+		desc := fmt.Sprintf("%s: last payment info not initialized", funcName)
+		return 0, 0, errs.DBError(errs.ValueNotFound, desc)
+	}
+	return *db.meta.LastPaymentHeight, *db.meta.LastPaymentPaidOn, nil
+}
+
+// persistLastPaymentCreatedOn stores the last payment createdOn timestamp.
+func (db *MemoryDB) persistLastPaymentCreatedOn(createdOn int64) error {
+	db.mtx.Lock()
+	db.meta.LastPaymentCreatedOn = &createdOn
+	db.mtx.Unlock()
+	return nil
+}
+
+// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp.
+func (db *MemoryDB) loadLastPaymentCreatedOn() (int64, error) {
+	const funcName = "loadLastPaymentCreatedOn"
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.meta.LastPaymentCreatedOn == nil {
+		desc := fmt.Sprintf("%s: last payment created-on not initialized",
+			funcName)
+		return 0, errs.DBError(errs.ValueNotFound, desc)
+	}
+	return *db.meta.LastPaymentCreatedOn, nil
+}
+
+// persistChainTip stores the hash and height of the last processed chain
+// tip.
+func (db *MemoryDB) persistChainTip(hash string, height uint32) error {
+	db.mtx.Lock()
+	db.meta.ChainTipHash = hash
+	db.meta.ChainTipHeight = &height
+	db.mtx.Unlock()
+	return nil
+}
+
+// loadChainTip retrieves the hash and height of the last processed chain
+// tip.
+func (db *MemoryDB) loadChainTip() (string, uint32, error) {
+	const funcName = "loadChainTip"
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.meta.ChainTipHash == "" || db.meta.ChainTipHeight == nil {
+		desc := fmt.Sprintf("%s: chain tip not initialized", funcName)
+		return "", 0, errs.DBError(errs.ValueNotFound, desc)
+	}
+	return db.meta.ChainTipHash, *db.meta.ChainTipHeight, nil
+}
+
+// persistPendingPayout stores the payout transaction awaiting an external
+// signature, replacing any existing one.
+func (db *MemoryDB) persistPendingPayout(payout *PendingPayout) error {
+	const funcName = "persistPendingPayout"
+	b, err := json.Marshal(payout)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to marshal pending payout: %v",
+			funcName, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+	var p PendingPayout
+	err = json.Unmarshal(b, &p)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to unmarshal pending payout: %v",
+			funcName, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+
+	db.mtx.Lock()
+	db.meta.PendingPayout = &p
+	db.mtx.Unlock()
+	return nil
+}
+
+// fetchPendingPayout retrieves the payout transaction awaiting an external
+// signature. Returns an error if there is none.
+func (db *MemoryDB) fetchPendingPayout() (*PendingPayout, error) {
+	const funcName = "fetchPendingPayout"
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.meta.PendingPayout == nil {
+		desc := fmt.Sprintf("%s: no pending payout found", funcName)
+		return nil, errs.DBError(errs.ValueNotFound, desc)
+	}
+	payout := *db.meta.PendingPayout
+	payout.Payments = append([]string(nil), payout.Payments...)
+	payout.Outputs = make(map[string]dcrutil.Amount, len(payout.Outputs))
+	for addr, amt := range db.meta.PendingPayout.Outputs {
+		payout.Outputs[addr] = amt
+	}
+	return &payout, nil
+}
+
+// deletePendingPayout removes the payout transaction awaiting an external
+// signature. Deleting a pending payout which does not exist is a no-op.
+func (db *MemoryDB) deletePendingPayout() error {
+	db.mtx.Lock()
+	db.meta.PendingPayout = nil
+	db.mtx.Unlock()
+	return nil
+}
+
+// fetchAccount fetches the account referenced by the provided id. Returns
+// an error if the account is not found.
+func (db *MemoryDB) fetchAccount(id string) (*Account, error) {
+	var account Account
+	err := db.fetch("fetchAccount", accountRecord, id, &account)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// persistAccount saves the account. Before persisting the account, it sets
+// the createdOn timestamp. Returns an error if an account already exists
+// with the same ID.
+func (db *MemoryDB) persistAccount(acc *Account) error {
+	const funcName = "persistAccount"
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if _, ok := db.entities[accountRecord][acc.UUID]; ok {
+		desc := fmt.Sprintf("%s: account %s already exists", funcName,
+			acc.UUID)
+		return errs.DBError(errs.ValueFound, desc)
+	}
+	acc.CreatedOn = uint64(time.Now().Unix())
+	return db.put(funcName, accountRecord, acc.UUID, acc)
+}
+
+// deleteAccount purges the referenced account.
+func (db *MemoryDB) deleteAccount(id string) error {
+	return db.remove(accountRecord, id)
+}
+
+// fetchPayment fetches the payment referenced by the provided id. Returns an
+// error if the payment is not found.
+func (db *MemoryDB) fetchPayment(id string) (*Payment, error) {
+	var payment Payment
+	err := db.fetch("fetchPayment", paymentRecord, id, &payment)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// PersistPayment saves a payment. Returns an error if a payment already
+// exists with the same ID.
+func (db *MemoryDB) PersistPayment(pmt *Payment) error {
+	return db.insert("PersistPayment", paymentRecord, pmt.UUID, pmt)
+}
+
+// persistPayments saves the provided payments of a block reward along with
+// the ledger entries booking them atomically. The last payment created-on
+// time is updated and shares created before the provided time are pruned
+// atomically with them. Returns an error if any of the payments or entries
+// already exists, in which case none of them are persisted.
+func (db *MemoryDB) persistPayments(payments []*Payment, entries []*LedgerEntry, lastPmtCreatedOn int64, pruneSharesBefore int64) error {
+	const funcName = "persistPayments"
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	for _, pmt := range payments {
+		if _, ok := db.entities[paymentRecord][pmt.UUID]; ok {
+			desc := fmt.Sprintf("%s: payment %s already exists", funcName,
+				pmt.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+	}
+	err := db.checkLedgerEntries(funcName, entries)
+	if err != nil {
+		return err
+	}
+	for _, pmt := range payments {
+		err := db.put(funcName, paymentRecord, pmt.UUID, pmt)
+		if err != nil {
+			return err
+		}
+	}
+	err = db.putLedgerEntries(funcName, entries)
+	if err != nil {
+		return err
+	}
+	db.meta.LastPaymentCreatedOn = &lastPmtCreatedOn
+	return db.deleteSharesBefore(funcName, pruneSharesBefore)
+}
+
+// updatePayment persists the updated payment. Updating a payment which does
+// not exist is a no-op.
+func (db *MemoryDB) updatePayment(pmt *Payment) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if _, ok := db.entities[paymentRecord][pmt.UUID]; !ok {
+		return nil
+	}
+	return db.put("updatePayment", paymentRecord, pmt.UUID, pmt)
+}
+
+// deletePayment purges the referenced payment. Note that archived payments
+// cannot be deleted.
+func (db *MemoryDB) deletePayment(id string) error {
+	return db.remove(paymentRecord, id)
+}
+
+// ArchivePayment removes the associated payment from active payments and
+// archives it.
+func (db *MemoryDB) ArchivePayment(pmt *Payment) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	return db.archivePayment(pmt)
+}
+
+// archivePayout archives the provided payments paid by a payout, books the
+// provided ledger entries of the payout, records the last payment info and
+// removes the pending payout atomically. Returns an error if any of the
+// entries already exists, in which case nothing is persisted.
+func (db *MemoryDB) archivePayout(payments []*Payment, entries []*LedgerEntry, height uint32, paidOn int64) error {
+	const funcName = "archivePayout"
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	err := db.checkLedgerEntries(funcName, entries)
+	if err != nil {
+		return err
+	}
+	for _, pmt := range payments {
+		err := db.archivePayment(pmt)
+		if err != nil {
+			return err
+		}
+	}
+	err = db.putLedgerEntries(funcName, entries)
+	if err != nil {
+		return err
+	}
+	db.meta.LastPaymentHeight = &height
+	db.meta.LastPaymentPaidOn = &paidOn
+	db.meta.PendingPayout = nil
+	return nil
+}
+
+// archivePayment removes the associated payment from active payments and
+// archives it. The caller must hold the write lock.
+func (db *MemoryDB) archivePayment(pmt *Payment) error {
+	delete(db.entities[paymentRecord], pmt.UUID)
+
+	// Create a new payment to add to the archive, keeping the payout
+	// details.
+	aPmt := NewPayment(pmt.Account, pmt.Source, pmt.Amount, pmt.Height,
+		pmt.EstimatedMaturity)
+	aPmt.PaidOnHeight = pmt.PaidOnHeight
+	aPmt.TransactionID = pmt.TransactionID
+	return db.put("ArchivePayment", archivedPaymentRecord, aPmt.UUID, aPmt)
+}
+
+// filterPayments returns the payments of the provided kind matching the
+// provided filter, ordered by id.
+func (db *MemoryDB) filterPayments(funcName string, kind string, reverse bool, filter func(*Payment) bool) ([]*Payment, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	payments := make([]*Payment, 0)
+	err := db.forEach(funcName, kind, reverse,
+		func() interface{} { return new(Payment) },
+		func(v interface{}) {
+			pmt := v.(*Payment)
+			if filter(pmt) {
+				payments = append(payments, pmt)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// fetchPaymentsAtHeight returns all payments sourcing from orphaned blocks
+// at the provided height.
+func (db *MemoryDB) fetchPaymentsAtHeight(height uint32) ([]*Payment, error) {
+	return db.filterPayments("fetchPaymentsAtHeight", paymentRecord, false,
+		func(pmt *Payment) bool {
+			return pmt.PaidOnHeight == 0 && height > pmt.EstimatedMaturity+1
+		})
+}
+
+// fetchPendingPayments fetches all unpaid payments.
+func (db *MemoryDB) fetchPendingPayments() ([]*Payment, error) {
+	return db.filterPayments("fetchPendingPayments", paymentRecord, false,
+		func(pmt *Payment) bool {
+			return pmt.PaidOnHeight == 0
+		})
+}
+
+// pendingPaymentsForBlockHash returns the number of pending payments with
+// the provided block hash as their source.
+func (db *MemoryDB) pendingPaymentsForBlockHash(blockHash string) (uint32, error) {
+	pmts, err := db.filterPayments("pendingPaymentsForBlockHash",
+		paymentRecord, false, func(pmt *Payment) bool {
+			return pmt.PaidOnHeight == 0 && pmt.Source != nil &&
+				pmt.Source.BlockHash == blockHash
+		})
+	if err != nil {
+		return 0, err
+	}
+	return uint32(len(pmts)), nil
+}
+
+// archivedPaymentsForBlockHash returns the number of archived payments with
+// the provided block hash as their source.
+func (db *MemoryDB) archivedPaymentsForBlockHash(blockHash string) (uint32, error) {
+	pmts, err := db.filterPayments("archivedPaymentsForBlockHash",
+		archivedPaymentRecord, false, func(pmt *Payment) bool {
+			return pmt.Source != nil && pmt.Source.BlockHash == blockHash
+		})
+	if err != nil {
+		return 0, err
+	}
+	return uint32(len(pmts)), nil
+}
+
+// archivedPayments fetches all archived payments. List is ordered, most
+// recent comes first.
+func (db *MemoryDB) archivedPayments() ([]*Payment, error) {
+	return db.filterPayments("archivedPayments", archivedPaymentRecord, true,
+		func(*Payment) bool { return true })
+}
+
+// maturePendingPayments fetches all mature pending payments at the
+// provided height.
+func (db *MemoryDB) maturePendingPayments(height uint32) (map[string][]*Payment, error) {
+	payments, err := db.filterPayments("maturePendingPayments",
+		paymentRecord, false, func(pmt *Payment) bool {
+			return pmt.PaidOnHeight == 0 && pmt.EstimatedMaturity+1 <= height
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pmts := make(map[string][]*Payment)
+	for _, pmt := range payments {
+		pmts[pmt.Source.BlockHash] = append(pmts[pmt.Source.BlockHash], pmt)
+	}
+	return pmts, nil
+}
+
+// PersistShare saves a share. Returns an error if a share already exists
+// with the same ID.
+func (db *MemoryDB) PersistShare(share *Share) error {
+	return db.insert("PersistShare", shareRecord, share.UUID, share)
+}
+
+// aggregateShare adds the weight of the provided share to the stored share
+// with the same ID, storing the share if it does not exist yet.
+func (db *MemoryDB) aggregateShare(s *Share) error {
+	const funcName = "aggregateShare"
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	share := &Share{
+		UUID:      s.UUID,
+		Account:   s.Account,
+		Weight:    new(big.Rat).Set(s.Weight),
+		CreatedOn: s.CreatedOn,
+	}
+	if v, ok := db.entities[shareRecord][s.UUID]; ok {
+		var existing Share
+		err := json.Unmarshal(v, &existing)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to unmarshal share: %v",
+				funcName, err)
+			return errs.DBError(errs.Parse, desc)
+		}
+		share.Weight.Add(share.Weight, existing.Weight)
+	}
+	return db.put(funcName, shareRecord, s.UUID, share)
+}
+
+// fetchShare fetches the share referenced by the provided id. Returns an
+// error if the share is not found.
+func (db *MemoryDB) fetchShare(id string) (*Share, error) {
+	var share Share
+	err := db.fetch("fetchShare", shareRecord, id, &share)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// filterShares returns the shares matching the provided filter, ordered by
+// id and thereby by creation time.
+func (db *MemoryDB) filterShares(funcName string, reverse bool, filter func(*Share) bool) ([]*Share, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	shares := make([]*Share, 0)
+	err := db.forEach(funcName, shareRecord, reverse,
+		func() interface{} { return new(Share) },
+		func(v interface{}) {
+			share := v.(*Share)
+			if filter(share) {
+				shares = append(shares, share)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// ppsEligibleShares fetches all shares created before or at the provided
+// time. List is ordered, oldest first.
+func (db *MemoryDB) ppsEligibleShares(max int64) ([]*Share, error) {
+	return db.filterShares("ppsEligibleShares", false,
+		func(share *Share) bool { return share.CreatedOn <= max })
+}
+
+// pplnsEligibleShares fetches all shares created after the provided time.
+// List is ordered, most recent comes first.
+func (db *MemoryDB) pplnsEligibleShares(min int64) ([]*Share, error) {
+	return db.filterShares("pplnsEligibleShares", true,
+		func(share *Share) bool { return share.CreatedOn > min })
+}
+
+// pruneShares removes shares with a createdOn time earlier than the provided
+// time.
+func (db *MemoryDB) pruneShares(minNano int64) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	return db.deleteSharesBefore("pruneShares", minNano)
+}
+
+// deleteSharesBefore removes shares with a createdOn time earlier than the
+// provided time. The caller must hold the write lock.
+func (db *MemoryDB) deleteSharesBefore(funcName string, minNano int64) error {
+	return db.forEach(funcName, shareRecord, false,
+		func() interface{} { return new(Share) },
+		func(v interface{}) {
+			share := v.(*Share)
+			if share.CreatedOn < minNano {
+				delete(db.entities[shareRecord], share.UUID)
+			}
+		})
+}
+
+// fetchAcceptedWork fetches the accepted work referenced by the provided id.
+// Returns an error if the work is not found.
+func (db *MemoryDB) fetchAcceptedWork(id string) (*AcceptedWork, error) {
+	var work AcceptedWork
+	err := db.fetch("fetchAcceptedWork", acceptedWorkRecord, id, &work)
+	if err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+// persistAcceptedWork saves the accepted work. Returns an error if the work
+// already exists.
+func (db *MemoryDB) persistAcceptedWork(work *AcceptedWork) error {
+	return db.insert("persistAcceptedWork", acceptedWorkRecord, work.UUID, work)
+}
+
+// updateAcceptedWork persists modifications to an existing work. Returns an
+// error if the work is not found.
+func (db *MemoryDB) updateAcceptedWork(work *AcceptedWork) error {
+	return db.update("updateAcceptedWork", acceptedWorkRecord, work.UUID, work)
+}
+
+// deleteAcceptedWork removes the associated accepted work.
+func (db *MemoryDB) deleteAcceptedWork(id string) error {
+	return db.remove(acceptedWorkRecord, id)
+}
+
+// filterWork returns the accepted work matching the provided filter, ordered
+// by id and thereby by height.
+func (db *MemoryDB) filterWork(funcName string, reverse bool, filter func(*AcceptedWork) bool) ([]*AcceptedWork, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	work := make([]*AcceptedWork, 0)
+	err := db.forEach(funcName, acceptedWorkRecord, reverse,
+		func() interface{} { return new(AcceptedWork) },
+		func(v interface{}) {
+			w := v.(*AcceptedWork)
+			if filter(w) {
+				work = append(work, w)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return work, nil
+}
+
+// listMinedWork returns work data associated with all blocks mined by the
+// pool regardless of whether they are confirmed or not.
+//
+// List is ordered, most recent comes first.
+func (db *MemoryDB) listMinedWork() ([]*AcceptedWork, error) {
+	return db.filterWork("listMinedWork", true,
+		func(*AcceptedWork) bool { return true })
+}
+
+// fetchUnconfirmedWork returns all work which is neither confirmed as mined
+// nor orphaned with height less than the provided height.
+func (db *MemoryDB) fetchUnconfirmedWork(height uint32) ([]*AcceptedWork, error) {
+	return db.filterWork("fetchUnconfirmedWork", false,
+		func(work *AcceptedWork) bool {
+			return !work.Confirmed && work.Status != WorkOrphaned &&
+				work.Height < height
+		})
+}
+
+// fetchJob fetches the job referenced by the provided id. Returns an error
+// if the job is not found.
+func (db *MemoryDB) fetchJob(id string) (*Job, error) {
+	var job Job
+	err := db.fetch("fetchJob", jobRecord, id, &job)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// persistJob saves the job. Returns an error if a job already exists with
+// the same ID.
+func (db *MemoryDB) persistJob(job *Job) error {
+	return db.insert("persistJob", jobRecord, job.UUID, job)
+}
+
+// deleteJob removes the associated job.
+func (db *MemoryDB) deleteJob(id string) error {
+	return db.remove(jobRecord, id)
+}
+
+// deleteJobsBeforeHeight removes all jobs with heights less than the
+// provided height.
+func (db *MemoryDB) deleteJobsBeforeHeight(height uint32) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	return db.forEach("deleteJobsBeforeHeight", jobRecord, false,
+		func() interface{} { return new(Job) },
+		func(v interface{}) {
+			job := v.(*Job)
+			if job.Height < height {
+				delete(db.entities[jobRecord], job.UUID)
+			}
+		})
+}
+
+// persistHashData saves the provided hash data. Returns an error if the hash
+// data already exists.
+func (db *MemoryDB) persistHashData(hashData *HashData) error {
+	return db.insert("persistHashData", hashDataRecord, hashData.UUID,
+		hashData)
+}
+
+// updateHashData persists the updated hash data. Returns an error if the
+// hash data is not found.
+func (db *MemoryDB) updateHashData(hashData *HashData) error {
+	return db.update("updateHashData", hashDataRecord, hashData.UUID,
+		hashData)
+}
+
+// fetchHashData fetches the hash data associated with the provided id.
+func (db *MemoryDB) fetchHashData(id string) (*HashData, error) {
+	var data HashData
+	err := db.fetch("fetchHashData", hashDataRecord, id, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// listHashData fetches all hash data updated after the provided minimum
+// time.
+func (db *MemoryDB) listHashData(minNano int64) (map[string]*HashData, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	data := make(map[string]*HashData)
+	err := db.forEach("listHashData", hashDataRecord, false,
+		func() interface{} { return new(HashData) },
+		func(v interface{}) {
+			hashData := v.(*HashData)
+			if hashData.UpdatedOn > minNano {
+				data[hashData.UUID] = hashData
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// pruneHashData prunes all hash data that have not been updated since the
+// provided minimum time.
+func (db *MemoryDB) pruneHashData(minNano int64) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	return db.forEach("pruneHashData", hashDataRecord, false,
+		func() interface{} { return new(HashData) },
+		func(v interface{}) {
+			hashData := v.(*HashData)
+			if hashData.UpdatedOn < minNano {
+				delete(db.entities[hashDataRecord], hashData.UUID)
+			}
+		})
+}
+
+// persistAPIToken saves the provided api token. Returns an error if the
+// token already exists.
+func (db *MemoryDB) persistAPIToken(token *APIToken) error {
+	return db.insert("persistAPIToken", apiTokenRecord, token.UUID, token)
+}
+
+// fetchAPIToken fetches the api token associated with the provided id.
+func (db *MemoryDB) fetchAPIToken(id string) (*APIToken, error) {
+	var token APIToken
+	err := db.fetch("fetchAPIToken", apiTokenRecord, id, &token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// deleteAPIToken purges the referenced api token.
+func (db *MemoryDB) deleteAPIToken(id string) error {
+	return db.remove(apiTokenRecord, id)
+}
+
+// fetchAPITokensForAccount fetches all api tokens of the provided account.
+// List is ordered, oldest first.
+func (db *MemoryDB) fetchAPITokensForAccount(accountID string) ([]*APIToken, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	tokens := make([]*APIToken, 0)
+	err := db.forEach("fetchAPITokensForAccount", apiTokenRecord, false,
+		func() interface{} { return new(APIToken) },
+		func(v interface{}) {
+			token := v.(*APIToken)
+			if token.AccountID == accountID {
+				tokens = append(tokens, token)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].CreatedOn < tokens[j].CreatedOn
+	})
+	return tokens, nil
+}
+
+// persistAdminUser saves the provided admin user. Returns an error if the
+// admin user already exists.
+func (db *MemoryDB) persistAdminUser(user *AdminUser) error {
+	return db.insert("persistAdminUser", adminUserRecord, user.UUID, user)
+}
+
+// updateAdminUser persists the updated admin user. Returns an error if the
+// admin user is not found.
+func (db *MemoryDB) updateAdminUser(user *AdminUser) error {
+	return db.update("updateAdminUser", adminUserRecord, user.UUID, user)
+}
+
+// fetchAdminUser fetches the admin user with the provided name.
+func (db *MemoryDB) fetchAdminUser(name string) (*AdminUser, error) {
+	var user AdminUser
+	err := db.fetch("fetchAdminUser", adminUserRecord, name, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// deleteAdminUser purges the referenced admin user.
+func (db *MemoryDB) deleteAdminUser(name string) error {
+	return db.remove(adminUserRecord, name)
+}
+
+// listAdminUsers fetches all admin users, ordered by name.
+func (db *MemoryDB) listAdminUsers() ([]*AdminUser, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	users := make([]*AdminUser, 0)
+	err := db.forEach("listAdminUsers", adminUserRecord, false,
+		func() interface{} { return new(AdminUser) },
+		func(v interface{}) { users = append(users, v.(*AdminUser)) })
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// persistAuditEntry saves the provided audit entry. Returns an error if the
+// audit entry already exists.
+func (db *MemoryDB) persistAuditEntry(entry *AuditEntry) error {
+	return db.insert("persistAuditEntry", auditEntryRecord, entry.UUID, entry)
+}
+
+// fetchAuditEntries fetches the most recent audit entries, newest first.
+// All entries are returned if the provided limit is not positive.
+func (db *MemoryDB) fetchAuditEntries(limit int) ([]*AuditEntry, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	entries := make([]*AuditEntry, 0)
+	err := db.forEach("fetchAuditEntries", auditEntryRecord, true,
+		func() interface{} { return new(AuditEntry) },
+		func(v interface{}) {
+			if limit <= 0 || len(entries) < limit {
+				entries = append(entries, v.(*AuditEntry))
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// persistBan saves the provided ban. Persisting an existing ban replaces it.
+func (db *MemoryDB) persistBan(ban *Ban) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	return db.put("persistBan", banRecord, ban.UUID, ban)
+}
+
+// deleteBan purges the referenced ban.
+func (db *MemoryDB) deleteBan(id string) error {
+	return db.remove(banRecord, id)
+}
+
+// listBans fetches all bans. List is ordered, oldest first.
+func (db *MemoryDB) listBans() ([]*Ban, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	bans := make([]*Ban, 0)
+	err := db.forEach("listBans", banRecord, false,
+		func() interface{} { return new(Ban) },
+		func(v interface{}) { bans = append(bans, v.(*Ban)) })
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(bans, func(i, j int) bool {
+		return bans[i].CreatedOn < bans[j].CreatedOn
+	})
+	return bans, nil
+}
+
+// persistLedgerEntries saves the provided ledger entries atomically. Returns
+// an error if any of the entries already exists, in which case none of them
+// are persisted.
+func (db *MemoryDB) persistLedgerEntries(entries []*LedgerEntry) error {
+	const funcName = "persistLedgerEntries"
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	err := db.checkLedgerEntries(funcName, entries)
+	if err != nil {
+		return err
+	}
+	return db.putLedgerEntries(funcName, entries)
+}
+
+// checkLedgerEntries returns an error if any of the provided ledger entries
+// already exists. The caller must hold a lock.
+func (db *MemoryDB) checkLedgerEntries(funcName string, entries []*LedgerEntry) error {
+	for _, entry := range entries {
+		if _, ok := db.entities[ledgerEntryRecord][entry.UUID]; ok {
+			desc := fmt.Sprintf("%s: ledger entry %s already exists",
+				funcName, entry.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+	}
+	return nil
+}
+
+// putLedgerEntries stores the provided ledger entries. The caller must hold
+// the write lock.
+func (db *MemoryDB) putLedgerEntries(funcName string, entries []*LedgerEntry) error {
+	for _, entry := range entries {
+		err := db.put(funcName, ledgerEntryRecord, entry.UUID, entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachLedgerEntry calls the provided function with every ledger entry.
+// The caller must hold a lock.
+func (db *MemoryDB) forEachLedgerEntry(funcName string, fn func(*LedgerEntry)) error {
+	return db.forEach(funcName, ledgerEntryRecord, false,
+		func() interface{} { return new(LedgerEntry) },
+		func(v interface{}) { fn(v.(*LedgerEntry)) })
+}
+
+// fetchLedgerEntries fetches the most recent ledger entries debiting or
+// crediting the provided ledger account, or all entries if the account is
+// empty, newest first. All entries are returned if the provided limit is not
+// positive.
+func (db *MemoryDB) fetchLedgerEntries(account string, limit int) ([]*LedgerEntry, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	entries := make([]*LedgerEntry, 0)
+	err := db.forEachLedgerEntry("fetchLedgerEntries", func(entry *LedgerEntry) {
+		if account == "" || entry.Debit == account ||
+			entry.Credit == account {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortLedgerEntries(entries)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// fetchLedgerBalances returns the balance of every ledger account with
+// ledger entries.
+func (db *MemoryDB) fetchLedgerBalances() (map[string]dcrutil.Amount, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	balances := make(map[string]dcrutil.Amount)
+	err := db.forEachLedgerEntry("fetchLedgerBalances", func(entry *LedgerEntry) {
+		balances[entry.Debit] -= entry.Amount
+		balances[entry.Credit] += entry.Amount
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// fetchAccountBalances returns the balance of the provided ledger account
+// along with the amount paid out to it, or of every ledger account with
+// ledger entries if it is empty.
+func (db *MemoryDB) fetchAccountBalances(account string) (map[string]*AccountBalance, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	balances := make(map[string]*AccountBalance)
+	err := db.forEachLedgerEntry("fetchAccountBalances", func(entry *LedgerEntry) {
+		if account == "" || entry.Debit == account ||
+			entry.Credit == account {
+			bookAccountBalance(balances, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filterAccountBalances(balances, account), nil
+}
+
+// persistBlockEvent saves the provided block event.
+func (db *MemoryDB) persistBlockEvent(event *BlockEvent) error {
+	return db.insert("persistBlockEvent", blockEventRecord, event.UUID, event)
+}
+
+// updateBlockEvent persists the updated block event.
+func (db *MemoryDB) updateBlockEvent(event *BlockEvent) error {
+	return db.update("updateBlockEvent", blockEventRecord, event.UUID, event)
+}
+
+// deleteBlockEvent purges the referenced block event.
+func (db *MemoryDB) deleteBlockEvent(id string) error {
+	return db.remove(blockEventRecord, id)
+}
+
+// fetchBlockEvents fetches all block events pending processing. List is
+// ordered, oldest first.
+func (db *MemoryDB) fetchBlockEvents() ([]*BlockEvent, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	events := make([]*BlockEvent, 0)
+	err := db.forEach("fetchBlockEvents", blockEventRecord, false,
+		func() interface{} { return new(BlockEvent) },
+		func(v interface{}) { events = append(events, v.(*BlockEvent)) })
+	if err != nil {
+		return nil, err
+	}
+	sortBlockEvents(events)
+	return events, nil
+}
+
+// persistWebhook saves the provided webhook. Returns an error if the
+// webhook already exists.
+func (db *MemoryDB) persistWebhook(hook *Webhook) error {
+	return db.insert("persistWebhook", webhookRecord, hook.UUID, hook)
+}
+
+// fetchWebhook fetches the webhook associated with the provided id.
+func (db *MemoryDB) fetchWebhook(id string) (*Webhook, error) {
+	var hook Webhook
+	err := db.fetch("fetchWebhook", webhookRecord, id, &hook)
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// deleteWebhook purges the referenced webhook.
+func (db *MemoryDB) deleteWebhook(id string) error {
+	return db.remove(webhookRecord, id)
+}
+
+// fetchWebhooksForAccount fetches all webhooks of the provided account.
+// List is ordered, oldest first.
+func (db *MemoryDB) fetchWebhooksForAccount(accountID string) ([]*Webhook, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	hooks := make([]*Webhook, 0)
+	err := db.forEach("fetchWebhooksForAccount", webhookRecord, false,
+		func() interface{} { return new(Webhook) },
+		func(v interface{}) {
+			hook := v.(*Webhook)
+			if hook.AccountID == accountID {
+				hooks = append(hooks, hook)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].CreatedOn < hooks[j].CreatedOn
+	})
+	return hooks, nil
+}
+
+// persistWebhookDelivery saves the provided webhook delivery.
+func (db *MemoryDB) persistWebhookDelivery(delivery *WebhookDelivery) error {
+	return db.insert("persistWebhookDelivery", webhookDeliveryRecord,
+		delivery.UUID, delivery)
+}
+
+// updateWebhookDelivery persists the updated webhook delivery.
+func (db *MemoryDB) updateWebhookDelivery(delivery *WebhookDelivery) error {
+	return db.update("updateWebhookDelivery", webhookDeliveryRecord,
+		delivery.UUID, delivery)
+}
+
+// deleteWebhookDelivery purges the referenced webhook delivery.
+func (db *MemoryDB) deleteWebhookDelivery(id string) error {
+	return db.remove(webhookDeliveryRecord, id)
+}
+
+// fetchWebhookDeliveries fetches all webhook deliveries pending delivery.
+// List is ordered, oldest first.
+func (db *MemoryDB) fetchWebhookDeliveries() ([]*WebhookDelivery, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	deliveries := make([]*WebhookDelivery, 0)
+	err := db.forEach("fetchWebhookDeliveries", webhookDeliveryRecord, false,
+		func() interface{} { return new(WebhookDelivery) },
+		func(v interface{}) {
+			deliveries = append(deliveries, v.(*WebhookDelivery))
+		})
+	if err != nil {
+		return nil, err
+	}
+	sortWebhookDeliveries(deliveries)
+	return deliveries, nil
+}
+
+// persistWorker saves the provided worker. Returns an error if the worker
+// already exists.
+func (db *MemoryDB) persistWorker(worker *Worker) error {
+	return db.insert("persistWorker", workerRecord, worker.UUID, worker)
+}
+
+// updateWorker persists the updated worker.
+func (db *MemoryDB) updateWorker(worker *Worker) error {
+	return db.update("updateWorker", workerRecord, worker.UUID, worker)
+}
+
+// deleteWorker purges the referenced worker.
+func (db *MemoryDB) deleteWorker(id string) error {
+	return db.remove(workerRecord, id)
+}
+
+// fetchWorkers fetches all tracked workers. List is ordered by id.
+func (db *MemoryDB) fetchWorkers() ([]*Worker, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	workers := make([]*Worker, 0)
+	err := db.forEach("fetchWorkers", workerRecord, false,
+		func() interface{} { return new(Worker) },
+		func(v interface{}) {
+			workers = append(workers, v.(*Worker))
+		})
+	if err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// persistWorkerEvent saves the provided worker event.
+func (db *MemoryDB) persistWorkerEvent(event *WorkerEvent) error {
+	return db.insert("persistWorkerEvent", workerEventRecord, event.UUID,
+		event)
+}
+
+// fetchWorkerEventsForAccount fetches all worker events of the provided
+// account. List is ordered, most recent comes first.
+func (db *MemoryDB) fetchWorkerEventsForAccount(accountID string) ([]*WorkerEvent, error) {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	events := make([]*WorkerEvent, 0)
+	err := db.forEach("fetchWorkerEventsForAccount", workerEventRecord, false,
+		func() interface{} { return new(WorkerEvent) },
+		func(v interface{}) {
+			event := v.(*WorkerEvent)
+			if event.AccountID == accountID {
+				events = append(events, event)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	sortWorkerEvents(events)
+	return events, nil
+}
+
+// pruneWorkerEvents prunes all worker events created before the provided
+// minimum time.
+func (db *MemoryDB) pruneWorkerEvents(minNano int64) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	return db.forEach("pruneWorkerEvents", workerEventRecord, false,
+		func() interface{} { return new(WorkerEvent) },
+		func(v interface{}) {
+			event := v.(*WorkerEvent)
+			if event.CreatedOn < minNano {
+				delete(db.entities[workerEventRecord], event.UUID)
+			}
+		})
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// DefaultMigrationBatchSize is the default number of entities written to the
+// target database at once when migrating.
+const DefaultMigrationBatchSize = 1000
+
+// EntitySummary represents the number of entities of a kind persisted in a
+// database and an order independent checksum of their contents.
+type EntitySummary struct {
+	Count    uint64
+	Checksum [sha256.Size]byte
+}
+
+// String returns the hex encoding of the checksum.
+func (s *EntitySummary) String() string {
+	return hex.EncodeToString(s.Checksum[:])
+}
+
+// add accounts for the provided canonical entity encoding in the summary.
+func (s *EntitySummary) add(data []byte) {
+	sum := sha256.Sum256(data)
+	for i := range s.Checksum {
+		s.Checksum[i] ^= sum[i]
+	}
+	s.Count++
+}
+
+// canonicalRecord returns the archive record of the provided exported entity
+// along with the canonical json encoding of the entity, which is independent
+// of the backend it was read from.
+func canonicalRecord(kind string, entity interface{}) (*archiveRecord, []byte, error) {
+	const funcName = "canonicalRecord"
+	var data []byte
+	switch e := entity.(type) {
+	case json.RawMessage:
+		// Raw entity encodings are only valid for the duration of the
+		// export callback.
+		data = append([]byte(nil), e...)
+	default:
+		var err error
+		data, err = json.Marshal(entity)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to encode %s: %v", funcName,
+				kind, err)
+			return nil, nil, errs.DBError(errs.Parse, desc)
+		}
+	}
+
+	record := &archiveRecord{Kind: kind, Data: data}
+	decoded, _, err := decodeArchiveRecord(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	canonical, err := json.Marshal(decoded)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to encode %s: %v", funcName,
+			kind, err)
+		return nil, nil, errs.DBError(errs.Parse, desc)
+	}
+
+	return record, canonical, nil
+}
+
+// SummarizeDB returns the entity summaries of the provided database, keyed
+// by entity kind.
+func SummarizeDB(db Database) (map[string]*EntitySummary, error) {
+	summaries := make(map[string]*EntitySummary)
+	err := db.exportRecords(nil, func(kind string, entity interface{}) error {
+		_, canonical, err := canonicalRecord(kind, entity)
+		if err != nil {
+			return err
+		}
+		summary, ok := summaries[kind]
+		if !ok {
+			summary = new(EntitySummary)
+			summaries[kind] = summary
+		}
+		summary.add(canonical)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// isEmptyDB returns whether the provided database has no persisted entities
+// or pool metadata.
+func isEmptyDB(db Database) (bool, error) {
+	meta, err := fetchArchiveMetadata(db)
+	if err != nil {
+		return false, err
+	}
+	if meta.PoolMode != nil || len(meta.CSRFSecret) > 0 ||
+		meta.LastPaymentHeight != nil || meta.LastPaymentCreatedOn != nil ||
+		meta.ChainTipHeight != nil || meta.PendingPayout != nil {
+		return false, nil
+	}
+
+	errNotEmpty := errors.New("not empty")
+	err = db.exportRecords(nil, func(string, interface{}) error {
+		return errNotEmpty
+	})
+	if errors.Is(err, errNotEmpty) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MigrateDB copies the pool metadata and every entity of the source database
+// to the target database, writing entities in batches of the provided size.
+// The target database must be empty. Once copied, the per-entity counts and
+// checksums of both databases are compared and the summaries of the migrated
+// entities are returned.
+func MigrateDB(src Database, dst Database, batchSize int) (map[string]*EntitySummary, error) {
+	const funcName = "MigrateDB"
+
+	if batchSize <= 0 {
+		batchSize = DefaultMigrationBatchSize
+	}
+
+	empty, err := isEmptyDB(dst)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		desc := fmt.Sprintf("%s: target database is not empty", funcName)
+		return nil, errs.DBError(errs.ValueFound, desc)
+	}
+
+	// The metadata is read from the same snapshot as the entities.
+	var meta *archiveMetadata
+	readMeta := func(m *archiveMetadata) error {
+		meta = m
+		return nil
+	}
+
+	summaries := make(map[string]*EntitySummary)
+	batch := make([]*archiveRecord, 0, batchSize)
+	err = src.exportRecords(readMeta, func(kind string, entity interface{}) error {
+		record, canonical, err := canonicalRecord(kind, entity)
+		if err != nil {
+			return err
+		}
+		summary, ok := summaries[kind]
+		if !ok {
+			summary = new(EntitySummary)
+			summaries[kind] = summary
+		}
+		summary.add(canonical)
+
+		batch = append(batch, record)
+		if len(batch) < batchSize {
+			return nil
+		}
+		err = dst.importRecords(batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(batch) > 0 {
+		err = dst.importRecords(batch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The metadata is migrated last since a database with metadata is not
+	// considered empty, which allows retrying a failed migration.
+	err = restoreArchiveMetadata(dst, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the target database matches the source.
+	dstMeta, err := fetchArchiveMetadata(dst)
+	if err != nil {
+		return nil, err
+	}
+	metaB, err := json.Marshal(meta)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to encode metadata: %v", funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+	dstMetaB, err := json.Marshal(dstMeta)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to encode metadata: %v", funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+	if !bytes.Equal(metaB, dstMetaB) {
+		desc := fmt.Sprintf("%s: migrated metadata mismatch", funcName)
+		return nil, errs.DBError(errs.PersistEntry, desc)
+	}
+
+	dstSummaries, err := SummarizeDB(dst)
+	if err != nil {
+		return nil, err
+	}
+	for kind, summary := range summaries {
+		dstSummary, ok := dstSummaries[kind]
+		if !ok {
+			dstSummary = new(EntitySummary)
+		}
+		if *dstSummary != *summary {
+			desc := fmt.Sprintf("%s: migrated %s mismatch, source has "+
+				"%d (%s), target has %d (%s)", funcName, kind,
+				summary.Count, summary, dstSummary.Count, dstSummary)
+			return nil, errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+	for kind, dstSummary := range dstSummaries {
+		if _, ok := summaries[kind]; !ok {
+			desc := fmt.Sprintf("%s: target has %d unexpected %s entities",
+				funcName, dstSummary.Count, kind)
+			return nil, errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+
+	return summaries, nil
+}
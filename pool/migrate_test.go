@@ -0,0 +1,247 @@
+package pool
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	bolt "go.etcd.io/bbolt"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func testMigrateDB(t *testing.T) {
+	err := db.persistCSRFSecret([]byte("csrfsecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistLastPaymentInfo(20, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := NewAccount(xAddr)
+	err = db.persistAccount(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &PaymentSource{BlockHash: "blockhash", Coinbase: "coinbase"}
+	for i := 0; i < 3; i++ {
+		pmt := NewPayment(xID, source, dcrutil.Amount(100+i), 10, 26)
+		err = db.PersistPayment(pmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := int64(1); i <= 4; i++ {
+		err = db.PersistShare(NewShare(xID, new(big.Rat).SetInt64(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	job := NewJob("header", 10)
+	err = db.persistJob(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const targetPath = "tmigrate"
+	target, err := InitBoltDB(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = teardownBoltDB(target, targetPath)
+		if err != nil {
+			t.Fatalf("teardown error: %v", err)
+		}
+	}()
+
+	empty, err := isEmptyDB(target)
+	if err != nil || !empty {
+		t.Fatalf("expected an empty target database, got %v (%v)", empty, err)
+	}
+
+	// Ensure the database can be migrated in batches smaller than the
+	// number of entities.
+	summaries, err := MigrateDB(db, target, 2)
+	if err != nil {
+		t.Fatalf("MigrateDB error: %v", err)
+	}
+
+	expectedCounts := map[string]uint64{
+		accountRecord: 1,
+		paymentRecord: 3,
+		shareRecord:   4,
+		jobRecord:     1,
+	}
+	if len(summaries) != len(expectedCounts) {
+		t.Fatalf("expected %d migrated entity kinds, got %d",
+			len(expectedCounts), len(summaries))
+	}
+	for kind, count := range expectedCounts {
+		summary, ok := summaries[kind]
+		if !ok || summary.Count != count {
+			t.Fatalf("expected %d migrated %s entities, got %v", count,
+				kind, summary)
+		}
+	}
+
+	// Ensure the migrated database has the same summaries and metadata.
+	targetSummaries, err := SummarizeDB(target)
+	if err != nil {
+		t.Fatalf("SummarizeDB error: %v", err)
+	}
+	for kind, summary := range summaries {
+		if *targetSummaries[kind] != *summary {
+			t.Fatalf("expected %s summary %d (%s), got %d (%s)", kind,
+				summary.Count, summary, targetSummaries[kind].Count,
+				targetSummaries[kind])
+		}
+	}
+	secret, err := target.fetchCSRFSecret()
+	if err != nil || string(secret) != "csrfsecret" {
+		t.Fatalf("expected migrated csrf secret, got %q (%v)", secret, err)
+	}
+	height, paidOn, err := target.loadLastPaymentInfo()
+	if err != nil || height != 20 || paidOn != 100 {
+		t.Fatalf("expected last payment info (20, 100), got (%d, %d) (%v)",
+			height, paidOn, err)
+	}
+	srcAccount, err := db.fetchAccount(account.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migratedAccount, err := target.fetchAccount(account.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameEntity(t, "account", srcAccount, migratedAccount)
+
+	// Ensure a changed entity alters the checksum of its kind.
+	err = target.deleteJob(job.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = target.persistJob(NewJob("other", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetSummaries, err = SummarizeDB(target)
+	if err != nil {
+		t.Fatalf("SummarizeDB error: %v", err)
+	}
+	if targetSummaries[jobRecord].Count != 1 ||
+		*targetSummaries[jobRecord] == *summaries[jobRecord] {
+		t.Fatal("expected a different job checksum with the same count")
+	}
+
+	// Ensure migrating into a non-empty database fails.
+	_, err = MigrateDB(db, target, 2)
+	if !errors.Is(err, errs.ValueFound) {
+		t.Fatalf("expected a value found error, got %v", err)
+	}
+}
+
+func TestOpenDBReadOnly(t *testing.T) {
+	d, err := ioutil.TempDir("", "dcrpool_test_readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	// Ensure a bolt database at the latest version is opened read-only.
+	boltPath := filepath.Join(d, "dcrpool.kv")
+	bdb, err := InitBoltDB(boltPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb.Close()
+
+	rdb, err := OpenBoltDBReadOnly(boltPath)
+	if err != nil {
+		t.Fatalf("OpenBoltDBReadOnly: unexpected error: %v", err)
+	}
+	err = rdb.persistAccount(NewAccount(xAddr))
+	if err == nil {
+		t.Fatal("expected a read-only bolt database to refuse writes")
+	}
+	rdb.Close()
+
+	// Ensure an outdated bolt database is not opened nor upgraded.
+	bdb, err = openBoltDB(boltPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bdb.DB.Update(func(tx *bolt.Tx) error {
+		return setDBVersion(tx, BoltDBVersion-1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb.Close()
+
+	_, err = OpenBoltDBReadOnly(boltPath)
+	if !errors.Is(err, errs.DBUpgrade) {
+		t.Fatalf("expected a database version error, got %v", err)
+	}
+	bdb, err = openBoltDB(boltPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bdb.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(poolBkt).Get(versionK)
+		if binary.LittleEndian.Uint32(v) != BoltDBVersion-1 {
+			t.Fatalf("expected the bolt database to be left unchanged")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb.Close()
+
+	// Ensure a sqlite database at the latest version is opened read-only.
+	sqlitePath := filepath.Join(d, "dcrpool.sqlite")
+	sdb, err := InitSQLiteDB(sqlitePath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdb.Close()
+
+	rsdb, err := OpenSQLiteDBReadOnly(sqlitePath)
+	if err != nil {
+		t.Fatalf("OpenSQLiteDBReadOnly: unexpected error: %v", err)
+	}
+	err = rsdb.persistAccount(NewAccount(xAddr))
+	if err == nil {
+		t.Fatal("expected a read-only sqlite database to refuse writes")
+	}
+	_, err = SummarizeDB(rsdb)
+	if err != nil {
+		t.Fatalf("SummarizeDB: unexpected error: %v", err)
+	}
+	rsdb.Close()
+
+	// Ensure an outdated sqlite database is not opened nor upgraded.
+	sdb, err = InitSQLiteDB(sqlitePath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdated := strconv.FormatUint(uint64(PostgresDBVersion-1), 10)
+	_, err = sdb.DB.Exec(insertSchemaVersion, outdated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdb.Close()
+
+	_, err = OpenSQLiteDBReadOnly(sqlitePath)
+	if !errors.Is(err, errs.DBUpgrade) {
+		t.Fatalf("expected a schema version error, got %v", err)
+	}
+}
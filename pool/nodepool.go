@@ -0,0 +1,630 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	chainjson "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/dcrd/rpcclient/v6"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// defaultNodeHealthInterval is the default interval between node health
+	// checks.
+	defaultNodeHealthInterval = time.Second * 10
+
+	// defaultNodeNotifyTimeout is the default time without notifications
+	// from the active node after which a node ahead of it takes over.
+	defaultNodeNotifyTimeout = time.Minute * 2
+
+	// defaultNodeMaxTipLag is the default number of blocks the active node
+	// may lag behind the best node before another node takes over.
+	defaultNodeMaxTipLag = 2
+
+	// maxSeenBlocks is the number of most recently connected blocks kept to
+	// de-duplicate block notifications.
+	maxSeenBlocks = 64
+
+	// nodeSubscribeTimeout is the time allowed to subscribe for the
+	// notifications of a node.
+	nodeSubscribeTimeout = time.Second * 10
+)
+
+// MonitoredNodeConnection defines the functionality needed by a mining node
+// connection of a node pool.
+type MonitoredNodeConnection interface {
+	NodeConnection
+	GetBlockCount(context.Context) (int64, error)
+}
+
+// NodePoolConfig contains the configuration details of a node pool.
+type NodePoolConfig struct {
+	// HealthInterval represents the interval between node health checks.
+	// Zero uses the default interval.
+	HealthInterval time.Duration
+	// NotifyTimeout represents the time without notifications from the
+	// active node after which a node ahead of it takes over. Zero uses the
+	// default timeout.
+	NotifyTimeout time.Duration
+	// MaxTipLag represents the number of blocks the active node may lag
+	// behind the best node before another node takes over. Zero uses the
+	// default lag.
+	MaxTipLag int64
+	// Handlers represents the notification handlers of the active node.
+	Handlers *rpcclient.NotificationHandlers
+	// OnSwitch is called once another node became the active node.
+	OnSwitch func()
+	// HubWg represents the hub's waitgroup.
+	HubWg *sync.WaitGroup
+}
+
+// poolNode represents a mining node of a node pool.
+type poolNode struct {
+	lastNotif int64 // update atomically.
+
+	name       string
+	conn       MonitoredNodeConnection
+	height     int64
+	healthy    bool
+	subscribed bool
+}
+
+// NodePool maintains connections to several mining nodes. Requests and
+// notification subscriptions are served by the active node, another healthy
+// node takes over when the active node fails, stops sending notifications or
+// its chain tip falls behind.
+type NodePool struct {
+	cfg       *NodePoolConfig
+	nodes     []*poolNode
+	active    int
+	mtx       sync.RWMutex
+	switchMtx sync.Mutex
+	seen      map[chainhash.Hash]struct{}
+	seenOrder []chainhash.Hash
+	seenMtx   sync.Mutex
+}
+
+// NewNodePool initializes a node pool without nodes.
+func NewNodePool(cfg *NodePoolConfig) *NodePool {
+	if cfg.HealthInterval == 0 {
+		cfg.HealthInterval = defaultNodeHealthInterval
+	}
+	if cfg.NotifyTimeout == 0 {
+		cfg.NotifyTimeout = defaultNodeNotifyTimeout
+	}
+	if cfg.MaxTipLag == 0 {
+		cfg.MaxTipLag = defaultNodeMaxTipLag
+	}
+	return &NodePool{
+		cfg:  cfg,
+		seen: make(map[chainhash.Hash]struct{}),
+	}
+}
+
+// addNode adds the provided node connection to the pool. Nodes are
+// preferred in the order they are added, the first node added becomes the
+// active node.
+func (np *NodePool) addNode(name string, conn MonitoredNodeConnection) {
+	np.mtx.Lock()
+	np.nodes = append(np.nodes, &poolNode{
+		name:    name,
+		conn:    conn,
+		healthy: true,
+	})
+	np.mtx.Unlock()
+}
+
+// nodeCount returns the number of nodes of the pool.
+func (np *NodePool) nodeCount() int {
+	np.mtx.RLock()
+	defer np.mtx.RUnlock()
+	return len(np.nodes)
+}
+
+// activeNode returns the active node of the pool.
+func (np *NodePool) activeNode() (int, *poolNode, error) {
+	np.mtx.RLock()
+	defer np.mtx.RUnlock()
+	if len(np.nodes) == 0 {
+		return 0, nil, errs.PoolError(errs.Disconnected, "node disconnected")
+	}
+	return np.active, np.nodes[np.active], nil
+}
+
+// isActive returns whether the node at the provided index is the active
+// node.
+func (np *NodePool) isActive(idx int) bool {
+	np.mtx.RLock()
+	defer np.mtx.RUnlock()
+	return np.active == idx
+}
+
+// ActiveNode returns the name of the active node.
+func (np *NodePool) ActiveNode() string {
+	_, node, err := np.activeNode()
+	if err != nil {
+		return ""
+	}
+	return node.name
+}
+
+// markSeen records the provided connected block, returning false if it was
+// already recorded.
+func (np *NodePool) markSeen(hash chainhash.Hash) bool {
+	np.seenMtx.Lock()
+	defer np.seenMtx.Unlock()
+	if _, ok := np.seen[hash]; ok {
+		return false
+	}
+	np.seen[hash] = struct{}{}
+	np.seenOrder = append(np.seenOrder, hash)
+	if len(np.seenOrder) > maxSeenBlocks {
+		delete(np.seen, np.seenOrder[0])
+		np.seenOrder = np.seenOrder[1:]
+	}
+	return true
+}
+
+// unmarkSeen removes the provided disconnected block from the recorded
+// connected blocks so it is processed again if reconnected.
+func (np *NodePool) unmarkSeen(hash chainhash.Hash) {
+	np.seenMtx.Lock()
+	defer np.seenMtx.Unlock()
+	if _, ok := np.seen[hash]; !ok {
+		return
+	}
+	delete(np.seen, hash)
+	for i, h := range np.seenOrder {
+		if h == hash {
+			np.seenOrder = append(np.seenOrder[:i], np.seenOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// headerHash returns the hash of the provided serialized block header.
+func headerHash(headerB []byte) (chainhash.Hash, error) {
+	var header wire.BlockHeader
+	err := header.FromBytes(headerB)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	return header.BlockHash(), nil
+}
+
+// nodeHandlers returns the notification handlers of the node at the provided
+// index. Notifications of nodes other than the active node are ignored and
+// block notifications are de-duplicated across nodes.
+func (np *NodePool) nodeHandlers(idx int) *rpcclient.NotificationHandlers {
+	handlers := np.cfg.Handlers
+	notified := func() bool {
+		np.mtx.RLock()
+		node := np.nodes[idx]
+		np.mtx.RUnlock()
+		atomic.StoreInt64(&node.lastNotif, time.Now().UnixNano())
+		return np.isActive(idx)
+	}
+	return &rpcclient.NotificationHandlers{
+		OnBlockConnected: func(headerB []byte, transactions [][]byte) {
+			if !notified() {
+				return
+			}
+			hash, err := headerHash(headerB)
+			if err != nil {
+				log.Errorf("unable to decode connected block header: %v", err)
+				return
+			}
+			if !np.markSeen(hash) {
+				log.Debugf("Ignoring duplicate connected block %s", hash)
+				return
+			}
+			handlers.OnBlockConnected(headerB, transactions)
+		},
+		OnBlockDisconnected: func(headerB []byte) {
+			if !notified() {
+				return
+			}
+			hash, err := headerHash(headerB)
+			if err != nil {
+				log.Errorf("unable to decode disconnected block header: %v",
+					err)
+				return
+			}
+			np.unmarkSeen(hash)
+			handlers.OnBlockDisconnected(headerB)
+		},
+		OnWork: func(headerB []byte, target []byte, reason string) {
+			if !notified() {
+				return
+			}
+			handlers.OnWork(headerB, target, reason)
+		},
+		OnClientConnected: func() {
+			if !np.isActive(idx) || handlers.OnClientConnected == nil {
+				return
+			}
+			handlers.OnClientConnected()
+		},
+	}
+}
+
+// subscribe subscribes for work and block notifications of the provided
+// node. The subscription requests are made without holding the pool lock so
+// a hung node does not block requests and notifications of the active node.
+// The caller must hold the switch lock.
+func (np *NodePool) subscribe(ctx context.Context, node *poolNode) error {
+	np.mtx.RLock()
+	subscribed := node.subscribed
+	np.mtx.RUnlock()
+	if subscribed {
+		return nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, nodeSubscribeTimeout)
+	defer cancel()
+	err := node.conn.NotifyWork(reqCtx)
+	if err != nil {
+		return fmt.Errorf("unable to subscribe for work notifications: %v",
+			err)
+	}
+	err = node.conn.NotifyBlocks(reqCtx)
+	if err != nil {
+		return fmt.Errorf("unable to subscribe for block notifications: %v",
+			err)
+	}
+
+	np.mtx.Lock()
+	node.subscribed = true
+	np.mtx.Unlock()
+	return nil
+}
+
+// switchNode makes the first healthy node, in order of preference, among the
+// provided candidate indices the active node. It returns false if no
+// candidate could be subscribed to.
+func (np *NodePool) switchNode(ctx context.Context, candidates []int) bool {
+	np.switchMtx.Lock()
+	var switched bool
+	for _, idx := range candidates {
+		np.mtx.RLock()
+		node := np.nodes[idx]
+		np.mtx.RUnlock()
+
+		err := np.subscribe(ctx, node)
+		if err != nil {
+			log.Errorf("unable to fail over to node %s: %v", node.name, err)
+			np.mtx.Lock()
+			node.healthy = false
+			np.mtx.Unlock()
+			continue
+		}
+
+		np.mtx.Lock()
+		prev := np.nodes[np.active]
+		np.active = idx
+		np.mtx.Unlock()
+		atomic.StoreInt64(&node.lastNotif, time.Now().UnixNano())
+		log.Infof("Switched active node from %s to %s", prev.name,
+			node.name)
+		switched = true
+		break
+	}
+	np.switchMtx.Unlock()
+
+	if switched && np.cfg.OnSwitch != nil {
+		np.cfg.OnSwitch()
+	}
+	return switched
+}
+
+// Subscribe subscribes for work and block notifications of the active node.
+func (np *NodePool) Subscribe(ctx context.Context) error {
+	np.switchMtx.Lock()
+	defer np.switchMtx.Unlock()
+	_, node, err := np.activeNode()
+	if err != nil {
+		return err
+	}
+	return np.subscribe(ctx, node)
+}
+
+// failover makes another healthy node the active node after a request to
+// the active node at the provided index failed. It returns false if no
+// other node is available.
+func (np *NodePool) failover(ctx context.Context, failed int) bool {
+	np.mtx.Lock()
+	if np.active != failed {
+		// Another request already failed over.
+		np.mtx.Unlock()
+		return true
+	}
+	np.nodes[failed].healthy = false
+	var candidates []int
+	for idx, node := range np.nodes {
+		if idx != failed && node.healthy {
+			candidates = append(candidates, idx)
+		}
+	}
+	np.mtx.Unlock()
+
+	return np.switchNode(ctx, candidates)
+}
+
+// isConnectionError returns whether the provided request error indicates the
+// node could not be reached, as opposed to the node rejecting the request or
+// the request failing otherwise.
+func isConnectionError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	switch {
+	case errors.Is(err, rpcclient.ErrClientNotConnected),
+		errors.Is(err, rpcclient.ErrClientDisconnect),
+		errors.Is(err, rpcclient.ErrClientShutdown),
+		errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// do performs the provided request with the active node. The request is
+// retried once with another node if the active node could not be reached.
+func (np *NodePool) do(ctx context.Context, req func(MonitoredNodeConnection) error) error {
+	idx, node, err := np.activeNode()
+	if err != nil {
+		return err
+	}
+	err = req(node.conn)
+	if !isConnectionError(ctx, err) {
+		return err
+	}
+
+	log.Errorf("request to node %s failed: %v", node.name, err)
+	if !np.failover(ctx, idx) {
+		return err
+	}
+	_, node, rErr := np.activeNode()
+	if rErr != nil {
+		return err
+	}
+	return req(node.conn)
+}
+
+// checkHealth queries the chain tip of every node and makes another node the
+// active node if the active node is unreachable, its chain tip lags behind
+// the best node or it stopped sending notifications while another node
+// advanced. The most preferred healthy node takes over again once caught up.
+func (np *NodePool) checkHealth(ctx context.Context) {
+	np.mtx.RLock()
+	nodes := make([]*poolNode, len(np.nodes))
+	copy(nodes, np.nodes)
+	np.mtx.RUnlock()
+
+	heights := make([]int64, len(nodes))
+	healthy := make([]bool, len(nodes))
+	var best int64
+	for idx, node := range nodes {
+		reqCtx, cancel := context.WithTimeout(ctx, np.cfg.HealthInterval)
+		height, err := node.conn.GetBlockCount(reqCtx)
+		cancel()
+		if err != nil {
+			log.Debugf("Node %s is unhealthy: %v", node.name, err)
+			continue
+		}
+		heights[idx] = height
+		healthy[idx] = true
+		if height > best {
+			best = height
+		}
+	}
+
+	np.mtx.Lock()
+	for idx, node := range nodes {
+		node.healthy = healthy[idx]
+		if healthy[idx] {
+			node.height = heights[idx]
+		}
+	}
+	activeIdx := np.active
+	active := np.nodes[activeIdx]
+	activeHeight := active.height
+	np.mtx.Unlock()
+
+	silence := time.Since(time.Unix(0, atomic.LoadInt64(&active.lastNotif)))
+	stale := !healthy[activeIdx] || best-activeHeight > np.cfg.MaxTipLag ||
+		(silence > np.cfg.NotifyTimeout && activeHeight < best)
+
+	// Candidates are the healthy nodes at the best chain tip, in order of
+	// preference.
+	var candidates []int
+	for idx := range nodes {
+		if healthy[idx] && heights[idx] == best && idx != activeIdx {
+			candidates = append(candidates, idx)
+		}
+	}
+
+	switch {
+	case stale:
+		if len(candidates) == 0 {
+			log.Warnf("Active node %s is unhealthy and no other node is "+
+				"available", active.name)
+			return
+		}
+		np.switchNode(ctx, candidates)
+
+	case len(candidates) > 0 && candidates[0] < activeIdx:
+		// Fail back to a more preferred node.
+		np.switchNode(ctx, candidates[:1])
+	}
+}
+
+// run checks the health of the nodes periodically until the provided
+// context is cancelled.
+// This should be run as a goroutine.
+func (np *NodePool) run(ctx context.Context) {
+	ticker := time.NewTicker(np.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			np.cfg.HubWg.Done()
+			return
+
+		case <-ticker.C:
+			np.checkHealth(ctx)
+		}
+	}
+}
+
+// GetTxOut fetches the output referenced by the provided txHash and index
+// from the active node.
+func (np *NodePool) GetTxOut(ctx context.Context, txHash *chainhash.Hash, index uint32, mempool bool) (*chainjson.GetTxOutResult, error) {
+	var res *chainjson.GetTxOutResult
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetTxOut(ctx, txHash, index, mempool)
+		return err
+	})
+	return res, err
+}
+
+// CreateRawTransaction generates a transaction from the provided inputs and
+// payouts with the active node.
+func (np *NodePool) CreateRawTransaction(ctx context.Context, inputs []chainjson.TransactionInput, amounts map[dcrutil.Address]dcrutil.Amount, lockTime *int64, expiry *int64) (*wire.MsgTx, error) {
+	var res *wire.MsgTx
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.CreateRawTransaction(ctx, inputs, amounts, lockTime,
+			expiry)
+		return err
+	})
+	return res, err
+}
+
+// GetWorkSubmit submits solved block data to the active node.
+func (np *NodePool) GetWorkSubmit(ctx context.Context, data string) (bool, error) {
+	var accepted bool
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		accepted, err = conn.GetWorkSubmit(ctx, data)
+		return err
+	})
+	return accepted, err
+}
+
+// GetWork fetches available work from the active node.
+func (np *NodePool) GetWork(ctx context.Context) (*chainjson.GetWorkResult, error) {
+	var res *chainjson.GetWorkResult
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetWork(ctx)
+		return err
+	})
+	return res, err
+}
+
+// GetBlockVerbose fetches the verbose block associated with the provided
+// block hash from the active node.
+func (np *NodePool) GetBlockVerbose(ctx context.Context, hash *chainhash.Hash, verboseTx bool) (*chainjson.GetBlockVerboseResult, error) {
+	var res *chainjson.GetBlockVerboseResult
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetBlockVerbose(ctx, hash, verboseTx)
+		return err
+	})
+	return res, err
+}
+
+// GetBlock fetches the block associated with the provided block hash from
+// the active node.
+func (np *NodePool) GetBlock(ctx context.Context, hash *chainhash.Hash) (*wire.MsgBlock, error) {
+	var res *wire.MsgBlock
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetBlock(ctx, hash)
+		return err
+	})
+	return res, err
+}
+
+// GetBestBlock fetches the chain tip hash and height of the active node.
+func (np *NodePool) GetBestBlock(ctx context.Context) (*chainhash.Hash, int64, error) {
+	var hash *chainhash.Hash
+	var height int64
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		hash, height, err = conn.GetBestBlock(ctx)
+		return err
+	})
+	return hash, height, err
+}
+
+// GetBlockHash fetches the hash of the main chain block at the provided
+// height from the active node.
+func (np *NodePool) GetBlockHash(ctx context.Context, height int64) (*chainhash.Hash, error) {
+	var res *chainhash.Hash
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetBlockHash(ctx, height)
+		return err
+	})
+	return res, err
+}
+
+// GetBlockHeader fetches the block header associated with the provided
+// block hash from the active node.
+func (np *NodePool) GetBlockHeader(ctx context.Context, hash *chainhash.Hash) (*wire.BlockHeader, error) {
+	var res *wire.BlockHeader
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetBlockHeader(ctx, hash)
+		return err
+	})
+	return res, err
+}
+
+// GetBlockCount fetches the chain tip height of the active node.
+func (np *NodePool) GetBlockCount(ctx context.Context) (int64, error) {
+	var res int64
+	err := np.do(ctx, func(conn MonitoredNodeConnection) error {
+		var err error
+		res, err = conn.GetBlockCount(ctx)
+		return err
+	})
+	return res, err
+}
+
+// NotifyWork subscribes for work notifications of the active node.
+func (np *NodePool) NotifyWork(ctx context.Context) error {
+	return np.Subscribe(ctx)
+}
+
+// NotifyBlocks subscribes for block notifications of the active node.
+func (np *NodePool) NotifyBlocks(ctx context.Context) error {
+	return np.Subscribe(ctx)
+}
+
+// Shutdown shuts down the connections to all nodes.
+func (np *NodePool) Shutdown() {
+	np.mtx.RLock()
+	defer np.mtx.RUnlock()
+	for _, node := range np.nodes {
+		node.conn.Shutdown()
+	}
+}
@@ -0,0 +1,285 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrjson/v3"
+	chainjson "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/dcrd/rpcclient/v6"
+	"github.com/decred/dcrd/wire"
+)
+
+// tMonitoredNode is a mining node connection of a node pool with a
+// configurable chain tip and availability.
+type tMonitoredNode struct {
+	tNodeConnection
+	mtx        sync.Mutex
+	height     int64
+	down       bool
+	reject     bool
+	subscribed bool
+	hanging    chan struct{}
+}
+
+func (t *tMonitoredNode) setState(height int64, down bool) {
+	t.mtx.Lock()
+	t.height = height
+	t.down = down
+	t.mtx.Unlock()
+}
+
+func (t *tMonitoredNode) GetBlockCount(context.Context) (int64, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.down {
+		return 0, rpcclient.ErrClientDisconnect
+	}
+	return t.height, nil
+}
+
+func (t *tMonitoredNode) GetWork(ctx context.Context) (*chainjson.GetWorkResult, error) {
+	t.mtx.Lock()
+	down, reject := t.down, t.reject
+	t.mtx.Unlock()
+	if down {
+		return nil, rpcclient.ErrClientDisconnect
+	}
+	if reject {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCMisc,
+			Message: "rejected",
+		}
+	}
+	return t.tNodeConnection.GetWork(ctx)
+}
+
+func (t *tMonitoredNode) NotifyWork(ctx context.Context) error {
+	if t.hanging != nil {
+		close(t.hanging)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.down {
+		return rpcclient.ErrClientDisconnect
+	}
+	t.subscribed = true
+	return nil
+}
+
+func TestNodePool(t *testing.T) {
+	var connected, disconnected, work, switches int
+	np := NewNodePool(&NodePoolConfig{
+		NotifyTimeout: time.Minute,
+		MaxTipLag:     2,
+		Handlers: &rpcclient.NotificationHandlers{
+			OnBlockConnected: func([]byte, [][]byte) { connected++ },
+			OnBlockDisconnected: func([]byte) {
+				disconnected++
+			},
+			OnWork: func([]byte, []byte, string) { work++ },
+		},
+		OnSwitch: func() { switches++ },
+	})
+
+	nodes := []*tMonitoredNode{{height: 10}, {height: 10}, {height: 10}}
+	handlers := make([]*rpcclient.NotificationHandlers, len(nodes))
+	for i, node := range nodes {
+		handlers[i] = np.nodeHandlers(np.nodeCount())
+		np.addNode(string(rune('a'+i)), node)
+	}
+	ctx := context.Background()
+	err := np.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe: unexpected error: %v", err)
+	}
+	if !nodes[0].subscribed || nodes[1].subscribed {
+		t.Fatal("expected only the first node to be subscribed")
+	}
+
+	var header wire.BlockHeader
+	header.Height = 11
+	headerB, err := header.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure only notifications of the active node are handled and block
+	// notifications are de-duplicated.
+	handlers[1].OnWork(headerB, nil, NewParent)
+	handlers[1].OnBlockConnected(headerB, nil)
+	handlers[0].OnWork(headerB, nil, NewParent)
+	handlers[0].OnBlockConnected(headerB, nil)
+	handlers[0].OnBlockConnected(headerB, nil)
+	if connected != 1 || work != 1 {
+		t.Fatalf("expected 1 connected block and work notification, got "+
+			"%d and %d", connected, work)
+	}
+
+	// Ensure a disconnected block is handled again once reconnected.
+	handlers[0].OnBlockDisconnected(headerB)
+	handlers[0].OnBlockConnected(headerB, nil)
+	if connected != 2 || disconnected != 1 {
+		t.Fatalf("expected 2 connected and 1 disconnected blocks, got "+
+			"%d and %d", connected, disconnected)
+	}
+
+	// Ensure a healthy active node remains active.
+	np.checkHealth(ctx)
+	if np.ActiveNode() != "a" || switches != 0 {
+		t.Fatalf("expected node a to remain active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure a node lagging behind is replaced by a node at the best tip.
+	nodes[1].setState(13, false)
+	nodes[2].setState(12, false)
+	np.checkHealth(ctx)
+	if np.ActiveNode() != "b" || switches != 1 || !nodes[1].subscribed {
+		t.Fatalf("expected node b to become active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure a block notified by the previously active node is not handled
+	// twice.
+	handlers[1].OnBlockConnected(headerB, nil)
+	if connected != 2 {
+		t.Fatalf("expected 2 connected blocks, got %d", connected)
+	}
+
+	// Ensure a silent active node is replaced by a node ahead of it once
+	// the notify timeout elapsed.
+	nodes[2].setState(14, false)
+	np.checkHealth(ctx)
+	if np.ActiveNode() != "b" {
+		t.Fatalf("expected node b to remain active, got %s",
+			np.ActiveNode())
+	}
+	np.mtx.RLock()
+	np.nodes[1].lastNotif = time.Now().Add(-2 * time.Minute).UnixNano()
+	np.mtx.RUnlock()
+	np.checkHealth(ctx)
+	if np.ActiveNode() != "c" {
+		t.Fatalf("expected node c to become active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure the preferred node takes over again once caught up.
+	nodes[0].setState(14, false)
+	np.checkHealth(ctx)
+	if np.ActiveNode() != "a" || switches != 3 {
+		t.Fatalf("expected node a to become active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure requests fail over when the active node is unreachable.
+	nodes[0].setState(14, true)
+	_, err = np.GetWork(ctx)
+	if err != nil {
+		t.Fatalf("GetWork: unexpected error: %v", err)
+	}
+	if np.ActiveNode() != "b" {
+		t.Fatalf("expected node b to become active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure requests rejected by the active node do not fail over.
+	nodes[1].reject = true
+	_, err = np.GetWork(ctx)
+	var rpcErr *dcrjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected a rpc error, got %v", err)
+	}
+	if np.ActiveNode() != "b" {
+		t.Fatalf("expected node b to remain active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure an unreachable active node remains active when no other node
+	// is available.
+	nodes[1].setState(14, true)
+	nodes[2].setState(14, true)
+	np.checkHealth(ctx)
+	if np.ActiveNode() != "b" {
+		t.Fatalf("expected node b to remain active, got %s",
+			np.ActiveNode())
+	}
+
+	// Ensure subscribing to a hung node does not block the active node.
+	hung := &tMonitoredNode{height: 14, hanging: make(chan struct{})}
+	np.addNode("d", hung)
+	subCtx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+	go func() {
+		done <- np.switchNode(subCtx, []int{3})
+	}()
+	<-hung.hanging
+	if np.ActiveNode() != "b" {
+		t.Fatalf("expected node b to remain active, got %s",
+			np.ActiveNode())
+	}
+	cancel()
+	if <-done {
+		t.Fatal("expected switching to the hung node to fail")
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{{
+		name: "no error",
+		ctx:  ctx,
+		want: false,
+	}, {
+		name: "disconnected",
+		ctx:  ctx,
+		err:  fmt.Errorf("request failed: %w", rpcclient.ErrClientDisconnect),
+		want: true,
+	}, {
+		name: "request timeout",
+		ctx:  ctx,
+		err:  context.DeadlineExceeded,
+		want: true,
+	}, {
+		name: "network error",
+		ctx:  ctx,
+		err:  &net.OpError{Op: "dial", Err: errors.New("refused")},
+		want: true,
+	}, {
+		name: "rejected",
+		ctx:  ctx,
+		err:  &dcrjson.RPCError{Code: dcrjson.ErrRPCMisc, Message: "rejected"},
+		want: false,
+	}, {
+		name: "invalid response",
+		ctx:  ctx,
+		err:  errors.New("unable to decode response"),
+		want: false,
+	}, {
+		name: "cancelled",
+		ctx:  cancelled,
+		err:  rpcclient.ErrClientDisconnect,
+		want: false,
+	}}
+	for _, test := range tests {
+		got := isConnectionError(test.ctx, test.err)
+		if got != test.want {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
@@ -2,10 +2,18 @@ package pool
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"decred.org/dcrwallet/rpc/walletrpc"
@@ -98,12 +106,46 @@ type PaymentMgrConfig struct {
 	// CoinbaseConfTimeout is the duration to wait for coinbase confirmations
 	// when generating a payout transaction.
 	CoinbaseConfTimeout time.Duration
+	// OfflineSigning exports payout transactions for signing by an external
+	// wallet instead of signing them with the wallet passphrase.
+	OfflineSigning bool
+	// PayoutDir represents the directory unsigned payout transactions are
+	// exported to. Exporting payouts to files is disabled when empty.
+	PayoutDir string
+	// NotifyWebhooks queues the provided event for delivery to the operator
+	// configured URLs and the webhooks of the accounts keyed in the
+	// provided account data.
+	NotifyWebhooks func(event string, data interface{}, accountData map[string]interface{})
 }
 
 // PaymentMgr handles generating shares and paying out dividends to
 // participating accounts.
 type PaymentMgr struct {
-	cfg *PaymentMgrConfig
+	cfg       *PaymentMgrConfig
+	feeMtx    sync.RWMutex
+	payoutMtx sync.Mutex
+}
+
+// poolFee returns the fee charged to participating accounts of the pool.
+func (pm *PaymentMgr) poolFee() float64 {
+	pm.feeMtx.RLock()
+	defer pm.feeMtx.RUnlock()
+	return pm.cfg.PoolFee
+}
+
+// poolFeeAddrs returns the pool fee addresses of the pool.
+func (pm *PaymentMgr) poolFeeAddrs() []dcrutil.Address {
+	pm.feeMtx.RLock()
+	defer pm.feeMtx.RUnlock()
+	return pm.cfg.PoolFeeAddrs
+}
+
+// setPoolFees updates the pool fee and pool fee addresses of the pool.
+func (pm *PaymentMgr) setPoolFees(fee float64, addrs []dcrutil.Address) {
+	pm.feeMtx.Lock()
+	pm.cfg.PoolFee = fee
+	pm.cfg.PoolFeeAddrs = addrs
+	pm.feeMtx.Unlock()
 }
 
 // NewPaymentMgr creates a new payment manager.
@@ -283,6 +325,19 @@ func (pm *PaymentMgr) calculatePayments(ratios map[string]*big.Rat, source *Paym
 	return payments, feePayment.CreatedOn, nil
 }
 
+// persistPayments saves the provided payments of a block reward, books them
+// to the ledger, updates the last payment created on time and prunes the
+// shares created before the provided time in a single database transaction.
+// The shares paid for by the payments are therefore never paid for again.
+func (pm *PaymentMgr) persistPayments(payments []*Payment, lastPmtCreatedOn int64, pruneSharesBefore int64) error {
+	entries := make([]*LedgerEntry, 0, len(payments))
+	for _, payment := range payments {
+		entries = append(entries, rewardEntry(payment))
+	}
+	return pm.cfg.db.persistPayments(payments, entries, lastPmtCreatedOn,
+		pruneSharesBefore)
+}
+
 // PayPerShare generates a payment bundle comprised of payments to all
 // participating accounts. Payments are calculated based on work contributed
 // to the pool since the last payment batch.
@@ -293,22 +348,11 @@ func (pm *PaymentMgr) payPerShare(source *PaymentSource, amt dcrutil.Amount, hei
 	}
 	estMaturity := height + uint32(pm.cfg.ActiveNet.CoinbaseMaturity)
 	payments, lastPmtCreatedOn, err := pm.calculatePayments(percentages,
-		source, amt, pm.cfg.PoolFee, height, estMaturity)
+		source, amt, pm.poolFee(), height, estMaturity)
 	if err != nil {
 		return err
 	}
-	for _, payment := range payments {
-		err := pm.cfg.db.PersistPayment(payment)
-		if err != nil {
-			return err
-		}
-	}
-	// Update the last payment created on time and prune invalidated shares.
-	err = pm.cfg.db.persistLastPaymentCreatedOn(lastPmtCreatedOn)
-	if err != nil {
-		return err
-	}
-	return pm.cfg.db.pruneShares(workCreatedOn)
+	return pm.persistPayments(payments, lastPmtCreatedOn, workCreatedOn)
 }
 
 // payPerLastNShares generates a payment bundle comprised of payments to all
@@ -320,23 +364,12 @@ func (pm *PaymentMgr) payPerLastNShares(source *PaymentSource, amt dcrutil.Amoun
 	}
 	estMaturity := height + uint32(pm.cfg.ActiveNet.CoinbaseMaturity)
 	payments, lastPmtCreatedOn, err := pm.calculatePayments(percentages,
-		source, amt, pm.cfg.PoolFee, height, estMaturity)
-	if err != nil {
-		return err
-	}
-	for _, payment := range payments {
-		err := pm.cfg.db.PersistPayment(payment)
-		if err != nil {
-			return err
-		}
-	}
-	// Update the last payment created on time and prune invalidated shares.
-	err = pm.cfg.db.persistLastPaymentCreatedOn(lastPmtCreatedOn)
+		source, amt, pm.poolFee(), height, estMaturity)
 	if err != nil {
 		return err
 	}
 	minNano := time.Now().Add(-pm.cfg.LastNPeriod).UnixNano()
-	return pm.cfg.db.pruneShares(minNano)
+	return pm.persistPayments(payments, lastPmtCreatedOn, minNano)
 }
 
 // generatePayments creates payments for participating accounts. This should
@@ -394,12 +427,12 @@ func (pm *PaymentMgr) applyTxFees(inputs []chainjson.TransactionInput, outputs m
 	tOut dcrutil.Amount, feeAddr dcrutil.Address) (dcrutil.Amount, dcrutil.Amount, error) {
 	funcName := "applyTxFees"
 	if len(inputs) == 0 {
-		desc := fmt.Sprint("%s: cannot create a payout transaction "+
+		desc := fmt.Sprintf("%s: cannot create a payout transaction "+
 			"without a tx input", funcName)
 		return 0, 0, errs.PoolError(errs.TxIn, desc)
 	}
 	if len(outputs) == 0 {
-		desc := fmt.Sprint("%s:cannot create a payout transaction "+
+		desc := fmt.Sprintf("%s:cannot create a payout transaction "+
 			"without a tx output", funcName)
 		return 0, 0, errs.PoolError(errs.TxOut, desc)
 	}
@@ -627,9 +660,127 @@ func (pm *PaymentMgr) generatePayoutTxDetails(ctx context.Context, txC TxCreator
 	return inputs, inputTxHashes, outputs, tOut, nil
 }
 
+// payoutAdjustments applies the manual balance adjustments of accounts which
+// have not been paid yet to the provided payout outputs, returning the
+// applied adjustments keyed by account. The unpaid adjustments of an account
+// are its ledger balance minus its pending payments.
+//
+// Credits are paid out of the pool fees of the payout as long as they cover
+// them. Debits are refunded to the pool fees and reduce the payout of the
+// account, they are applied up to the amount paid to the account. Adjustments
+// which cannot be applied, or would create dust outputs, are left to later
+// payouts.
+func (pm *PaymentMgr) payoutAdjustments(outputs map[string]dcrutil.Amount, feeAddr string) (map[string]dcrutil.Amount, error) {
+	balances, err := pm.cfg.db.fetchLedgerBalances()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := pm.cfg.db.fetchPendingPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	unpaid := make(map[string]dcrutil.Amount, len(balances))
+	for account, balance := range balances {
+		switch account {
+		case PoolFeesK, LedgerCoinbase, LedgerPayouts, LedgerTxFees,
+			LedgerAdjustments:
+			continue
+		}
+		unpaid[account] = balance
+	}
+	for _, pmt := range pending {
+		if _, ok := unpaid[pmt.Account]; ok {
+			unpaid[pmt.Account] -= pmt.Amount
+		}
+	}
+
+	accounts := make([]string, 0, len(unpaid))
+	addrs := make(map[string]string, len(unpaid))
+	for account, amt := range unpaid {
+		if amt == 0 {
+			continue
+		}
+		acc, err := pm.cfg.db.fetchAccount(account)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+		addrs[account] = acc.Address
+	}
+	sort.Strings(accounts)
+
+	// The script size of the outputs is assumed to be the worst possible,
+	// which is txsizes.P2PKHOutputSize, to avoid a lower size estimation.
+	isDust := func(amt dcrutil.Amount) bool {
+		return amt > 0 && txrules.IsDustAmount(amt, txsizes.P2PKHOutputSize,
+			txrules.DefaultRelayFeePerKb)
+	}
+
+	// Debits are applied first since they fund credits.
+	applied := make(map[string]dcrutil.Amount)
+	feeOut := outputs[feeAddr]
+	for _, account := range accounts {
+		amt := unpaid[account]
+		addr := addrs[account]
+		out, ok := outputs[addr]
+		if amt > 0 || !ok {
+			continue
+		}
+		if -amt > out {
+			amt = -out
+		}
+		if isDust(out + amt) {
+			continue
+		}
+		outputs[addr] = out + amt
+		if outputs[addr] == 0 {
+			delete(outputs, addr)
+		}
+		feeOut -= amt
+		applied[account] = amt
+	}
+	for _, account := range accounts {
+		amt := unpaid[account]
+		addr := addrs[account]
+		if amt < 0 || amt > feeOut || isDust(feeOut-amt) ||
+			isDust(outputs[addr]+amt) {
+			continue
+		}
+		outputs[addr] += amt
+		feeOut -= amt
+		applied[account] = amt
+	}
+	if feeOut > 0 {
+		outputs[feeAddr] = feeOut
+	} else {
+		delete(outputs, feeAddr)
+	}
+
+	return applied, nil
+}
+
 // PayDividends pays mature mining rewards to participating accounts.
+//
+// The signed payout transaction is persisted as the pending payout before it
+// is published. When offline signing is enabled the unsigned payout
+// transaction is exported as the pending payout instead. No further payouts
+// are created while a payout is pending, a signed payout left pending by a
+// failed attempt is completed first.
 func (pm *PaymentMgr) payDividends(ctx context.Context, height uint32, treasuryActive bool) error {
 	funcName := "payDividends"
+	pm.payoutMtx.Lock()
+	defer pm.payoutMtx.Unlock()
+
+	// No further payouts are created while a payout is pending.
+	payout, err := pm.cfg.db.fetchPendingPayout()
+	if err == nil {
+		return pm.resumePayout(ctx, payout)
+	}
+	if !errors.Is(err, errs.ValueNotFound) {
+		return err
+	}
+
 	mPmts, err := pm.cfg.db.maturePendingPayments(height)
 	if err != nil {
 		return err
@@ -654,17 +805,28 @@ func (pm *PaymentMgr) payDividends(ctx context.Context, height uint32, treasuryA
 		return err
 	}
 
+	// Nothing to do if all mature payments are sourced from orphaned
+	// blocks, they are pruned once past their maturity.
+	if len(pmts) == 0 {
+		return nil
+	}
+
 	// The fee address is being picked at random from the set of pool fee
 	// addresses to make it difficult for third-parties wanting to track
 	// pool fees collected by the pool and ultimately determine the
 	// cumulative value accrued by pool operators.
-	feeAddr := pm.cfg.PoolFeeAddrs[rand.Intn(len(pm.cfg.PoolFeeAddrs))]
+	feeAddrs := pm.poolFeeAddrs()
+	feeAddr := feeAddrs[rand.Intn(len(feeAddrs))]
 
 	inputs, inputTxHashes, outputs, tOut, err :=
 		pm.generatePayoutTxDetails(ctx, txC, feeAddr, pmts, treasuryActive)
 	if err != nil {
 		return err
 	}
+	adjustments, err := pm.payoutAdjustments(outputs, feeAddr.String())
+	if err != nil {
+		return err
+	}
 
 	_, estFee, err := pm.applyTxFees(inputs, outputs, tOut, feeAddr)
 	if err != nil {
@@ -709,13 +871,24 @@ func (pm *PaymentMgr) payDividends(ctx context.Context, height uint32, treasuryA
 		return nil
 	}
 
-	// Create, sign and publish the payout transaction.
+	// Create, sign and publish the payout transaction, or export it for
+	// signing by an external wallet.
 	tx, err := txC.CreateRawTransaction(ctx, inputs, outs, nil, nil)
 	if err != nil {
 		desc := fmt.Sprintf("%s: unable to create transaction: %v",
 			funcName, err)
 		return errs.PoolError(errs.CreateTx, desc)
 	}
+	payments := make([]*Payment, 0, len(pmts))
+	for _, set := range pmts {
+		payments = append(payments, set...)
+	}
+
+	if pm.cfg.OfflineSigning {
+		return pm.exportPayout(height, tx, payments, outputs, adjustments,
+			feeAddr.String(), tOut, estFee)
+	}
+
 	txBytes, err := tx.Bytes()
 	if err != nil {
 		return err
@@ -737,52 +910,442 @@ func (pm *PaymentMgr) payDividends(ctx context.Context, height uint32, treasuryA
 		return errs.PoolError(errs.SignTx, desc)
 
 	}
+	var signedTx wire.MsgTx
+	err = signedTx.FromBytes(signedTxResp.Transaction)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to deserialize signed "+
+			"transaction: %v", funcName, err)
+		return errs.PoolError(errs.SignTx, desc)
+	}
+
+	// The signed payout is persisted as the pending payout before it is
+	// published, should completing it fail it is completed by the next
+	// payout attempt instead of paying its payments again.
+	payout, err = newPendingPayout(height, &signedTx, payments, outputs,
+		adjustments, feeAddr.String(), tOut, estFee)
+	if err != nil {
+		return err
+	}
+	err = pm.cfg.db.persistPendingPayout(payout)
+	if err != nil {
+		return err
+	}
+
+	_, err = pm.completePayout(ctx, payout, &signedTx)
+	return err
+}
+
+// resumePayout completes the provided pending payout signed by the pool
+// wallet, left pending by a failed payout attempt. Payouts awaiting an
+// external signature, or pending with offline signing, are left to
+// treasurers. The caller must hold the payout lock.
+func (pm *PaymentMgr) resumePayout(ctx context.Context, payout *PendingPayout) error {
+	if pm.cfg.OfflineSigning {
+		return nil
+	}
+	tx, err := decodeTx(payout.Transaction)
+	if err != nil {
+		return err
+	}
+	for _, in := range tx.TxIn {
+		if len(in.SignatureScript) == 0 {
+			log.Warnf("Payout tx %s is awaiting an external signature",
+				payout.TxID)
+			return nil
+		}
+	}
 
+	log.Infof("Resuming payout tx %s", payout.TxID)
+	_, err = pm.completePayout(ctx, payout, tx)
+	return err
+}
+
+// publishTx broadcasts the provided signed transaction, returning its hash.
+func (pm *PaymentMgr) publishTx(ctx context.Context, txB TxBroadcaster, signedTx []byte) (string, error) {
+	funcName := "publishTx"
 	pubTxReq := &walletrpc.PublishTransactionRequest{
-		SignedTransaction: signedTxResp.Transaction,
+		SignedTransaction: signedTx,
 	}
 	pubTxResp, err := txB.PublishTransaction(ctx, pubTxReq)
 	if err != nil {
 		desc := fmt.Sprintf("%s: unable to publish transaction: %v",
 			funcName, err)
-		return errs.PoolError(errs.PublishTx, desc)
+		return "", errs.PoolError(errs.PublishTx, desc)
 	}
 
 	txid, err := chainhash.NewHash(pubTxResp.TransactionHash)
 	if err != nil {
 		desc := fmt.Sprintf("unable to create transaction hash: %v", err)
-		return errs.PoolError(errs.CreateHash, desc)
+		return "", errs.PoolError(errs.CreateHash, desc)
 	}
-	fees := outputs[feeAddr.String()]
+	return txid.String(), nil
+}
 
-	log.Infof("paid a total of %v in tx %s, including %v in pool fees. "+
-		"Tx fee: %v", tOut, txid.String(), fees, estFee)
+// finalizePayout archives the provided payments as paid by the provided
+// transaction at the provided height and books the payout, along with the
+// provided adjustments it pays, to the ledger in a single database
+// transaction which also removes the pending payout.
+func (pm *PaymentMgr) finalizePayout(height uint32, txid string, payments []*Payment, adjustments map[string]dcrutil.Amount, outputs map[string]dcrutil.Amount, feeAddr string) error {
+	funcName := "finalizePayout"
+
+	// Mark all associated payments as paid.
+	paid := make(map[string]dcrutil.Amount)
+	for _, pmt := range payments {
+		paid[pmt.Account] += pmt.Amount
+		pmt.PaidOnHeight = height
+		pmt.TransactionID = txid
+	}
 
-	// Update all associated payments as paid and archive them.
-	for _, set := range pmts {
-		for _, pmt := range set {
-			pmt.PaidOnHeight = height
-			pmt.TransactionID = txid.String()
-			err := pm.cfg.db.updatePayment(pmt)
-			if err != nil {
-				desc := fmt.Sprintf("%s: unable to update payment: %v",
-					funcName, err)
-				return errs.PoolError(errs.PersistEntry, desc)
-			}
-			err = pm.cfg.db.ArchivePayment(pmt)
+	// Adjustments are paid out of the pool fees, or refunded to them.
+	entries := make([]*LedgerEntry, 0, len(paid)*2+len(adjustments))
+	for account, amt := range adjustments {
+		paid[account] += amt
+		paid[PoolFeesK] -= amt
+		entries = append(entries, settlementEntry(account, txid, amt))
+	}
+
+	// Book the payout to the ledger. The difference between the amount
+	// owed to an account and its transaction output is its portion of the
+	// transaction fee.
+	accountOutputs := make(map[string]dcrutil.Amount, len(paid))
+	for account, owed := range paid {
+		addr := feeAddr
+		if account != PoolFeesK {
+			acc, err := pm.cfg.db.fetchAccount(account)
 			if err != nil {
-				desc := fmt.Sprintf("%s: unable to archive payment: %v",
-					funcName, err)
-				return errs.PoolError(errs.PersistEntry, desc)
+				return err
 			}
+			addr = acc.Address
+		}
+		out := outputs[addr]
+		if account != PoolFeesK {
+			accountOutputs[account] = out
 		}
+		entries = append(entries, payoutEntries(account, txid, out,
+			owed-out)...)
+	}
+	err := pm.cfg.db.archivePayout(payments, entries, height,
+		time.Now().UnixNano())
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to archive payout: %v", funcName, err)
+		return errs.PoolError(errs.PersistEntry, desc)
 	}
 
-	// Update payments metadata.
-	err = pm.cfg.db.persistLastPaymentInfo(height, time.Now().UnixNano())
+	pm.notifyPayout(height, txid, outputs, accountOutputs)
+
+	return nil
+}
+
+// notifyPayout queues the payout published webhook event of the provided
+// payout for the operator and the accounts paid by it.
+func (pm *PaymentMgr) notifyPayout(height uint32, txid string, outputs map[string]dcrutil.Amount, accountOutputs map[string]dcrutil.Amount) {
+	var total dcrutil.Amount
+	for _, amt := range outputs {
+		total += amt
+	}
+	accountData := make(map[string]interface{}, len(accountOutputs))
+	for account, amt := range accountOutputs {
+		accountData[account] = &WebhookPayout{
+			TxID:   txid,
+			Height: height,
+			Amount: amt,
+		}
+	}
+	pm.cfg.NotifyWebhooks(WebhookPayoutPublished, &WebhookPayout{
+		TxID:    txid,
+		Height:  height,
+		Amount:  total,
+		Outputs: outputs,
+	}, accountData)
+}
+
+// payoutFile returns the path of the export file of the provided pending
+// payout.
+func (pm *PaymentMgr) payoutFile(payout *PendingPayout) string {
+	return filepath.Join(pm.cfg.PayoutDir,
+		fmt.Sprintf("payout-%d-%s.json", payout.Height, payout.TxID))
+}
+
+// writePayoutFile exports the provided pending payout to the payout
+// directory.
+func (pm *PaymentMgr) writePayoutFile(payout *PendingPayout) error {
+	b, err := json.MarshalIndent(payout, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(pm.cfg.PayoutDir, 0700)
+	if err != nil {
+		return err
+	}
+	path := pm.payoutFile(payout)
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, b, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// removePayoutFile removes the export file of the provided pending payout,
+// if any.
+func (pm *PaymentMgr) removePayoutFile(payout *PendingPayout) {
+	if pm.cfg.PayoutDir == "" {
+		return
+	}
+	err := os.Remove(pm.payoutFile(payout))
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("unable to remove payout file: %v", err)
+	}
+}
+
+// newPendingPayout creates the pending payout of the provided payout
+// transaction paying the provided payments and adjustments.
+func newPendingPayout(height uint32, tx *wire.MsgTx, payments []*Payment, outputs map[string]dcrutil.Amount, adjustments map[string]dcrutil.Amount, feeAddr string, total dcrutil.Amount, txFee dcrutil.Amount) (*PendingPayout, error) {
+	funcName := "newPendingPayout"
+	txBytes, err := tx.Bytes()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to serialize transaction: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.CreateTx, desc)
+	}
+
+	ids := make([]string, 0, len(payments))
+	for _, pmt := range payments {
+		ids = append(ids, pmt.UUID)
+	}
+	sort.Strings(ids)
+
+	payout := &PendingPayout{
+		TxID:        tx.TxHash().String(),
+		Height:      height,
+		Transaction: hex.EncodeToString(txBytes),
+		Outputs:     outputs,
+		FeeAddress:  feeAddr,
+		Total:       total,
+		TxFee:       txFee,
+		Payments:    ids,
+		CreatedOn:   time.Now().Unix(),
+		Adjustments: adjustments,
+	}
+	return payout, nil
+}
+
+// exportPayout persists the provided unsigned payout transaction as the
+// pending payout awaiting an external signature and exports it to the
+// payout directory.
+func (pm *PaymentMgr) exportPayout(height uint32, tx *wire.MsgTx, payments []*Payment, outputs map[string]dcrutil.Amount, adjustments map[string]dcrutil.Amount, feeAddr string, total dcrutil.Amount, txFee dcrutil.Amount) error {
+	payout, err := newPendingPayout(height, tx, payments, outputs,
+		adjustments, feeAddr, total, txFee)
+	if err != nil {
+		return err
+	}
+	err = pm.cfg.db.persistPendingPayout(payout)
 	if err != nil {
 		return err
 	}
 
+	// The pending payout remains available for download through the admin
+	// panel if exporting it to a file fails.
+	if pm.cfg.PayoutDir != "" {
+		err := pm.writePayoutFile(payout)
+		if err != nil {
+			log.Errorf("unable to export payout tx %s: %v", payout.TxID, err)
+		} else {
+			log.Infof("Exported payout tx %s to %s", payout.TxID,
+				pm.payoutFile(payout))
+		}
+	}
+
+	log.Infof("Payout tx %s paying a total of %v is awaiting an "+
+		"external signature. Tx fee: %v", payout.TxID, total, txFee)
+
 	return nil
 }
+
+// fetchPendingPayout returns the payout transaction awaiting an external
+// signature.
+func (pm *PaymentMgr) fetchPendingPayout() (*PendingPayout, error) {
+	return pm.cfg.db.fetchPendingPayout()
+}
+
+// payoutArchived returns whether payments archived as paid by the provided
+// transaction exist.
+func (pm *PaymentMgr) payoutArchived(txid string) (bool, error) {
+	archived, err := pm.cfg.db.archivedPayments()
+	if err != nil {
+		return false, err
+	}
+	for _, pmt := range archived {
+		if pmt.TransactionID == txid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// payoutPayments returns the payments covered by the provided pending
+// payout. No payments are returned if they are all archived as paid by the
+// payout transaction, the payout is then already complete.
+func (pm *PaymentMgr) payoutPayments(payout *PendingPayout) ([]*Payment, error) {
+	funcName := "payoutPayments"
+	payments := make([]*Payment, 0, len(payout.Payments))
+	for _, id := range payout.Payments {
+		pmt, err := pm.cfg.db.fetchPayment(id)
+		if err != nil {
+			if errors.Is(err, errs.ValueNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		payments = append(payments, pmt)
+	}
+	if len(payments) == len(payout.Payments) {
+		return payments, nil
+	}
+
+	if len(payments) == 0 {
+		paid, err := pm.payoutArchived(payout.TxID)
+		if err != nil {
+			return nil, err
+		}
+		if paid {
+			return nil, nil
+		}
+	}
+	desc := fmt.Sprintf("%s: %d of %d payments covered by payout tx %s "+
+		"are no longer pending", funcName,
+		len(payout.Payments)-len(payments), len(payout.Payments), payout.TxID)
+	return nil, errs.PoolError(errs.ValueNotFound, desc)
+}
+
+// completePayout publishes the provided signed transaction of the provided
+// pending payout unless it has already been published and archives the
+// payments it covers as paid, returning its hash. Completing a payout which
+// has already been completed only removes it. The caller must hold the
+// payout lock.
+func (pm *PaymentMgr) completePayout(ctx context.Context, payout *PendingPayout, tx *wire.MsgTx) (string, error) {
+	funcName := "completePayout"
+	payments, err := pm.payoutPayments(payout)
+	if err != nil {
+		return "", err
+	}
+	if len(payments) == 0 {
+		log.Infof("Payout tx %s has already been completed", payout.TxID)
+		err := pm.cfg.db.deletePendingPayout()
+		if err != nil {
+			return "", err
+		}
+		pm.removePayoutFile(payout)
+		return payout.TxID, nil
+	}
+
+	txid := payout.TxID
+	if !payout.Published {
+		txB := pm.cfg.FetchTxBroadcaster()
+		if txB == nil {
+			desc := fmt.Sprintf("%s: tx broadcaster cannot be nil", funcName)
+			return "", errs.PoolError(errs.Disconnected, desc)
+		}
+		txBytes, err := tx.Bytes()
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to serialize transaction: %v",
+				funcName, err)
+			return "", errs.PoolError(errs.CreateTx, desc)
+		}
+		txid, err = pm.publishTx(ctx, txB, txBytes)
+		if err != nil {
+			return "", err
+		}
+		fees := payout.Outputs[payout.FeeAddress]
+
+		log.Infof("paid a total of %v in tx %s, including %v in pool fees. "+
+			"Tx fee: %v", payout.Total, txid, fees, payout.TxFee)
+
+		// Record the payout as published so it is not published again
+		// should archiving its payments fail. Archiving is attempted
+		// regardless since it removes the pending payout as well.
+		payout.Published = true
+		err = pm.cfg.db.persistPendingPayout(payout)
+		if err != nil {
+			log.Errorf("unable to record payout tx %s as published: %v",
+				txid, err)
+		}
+	}
+
+	err = pm.finalizePayout(payout.Height, txid, payments, payout.Adjustments,
+		payout.Outputs, payout.FeeAddress)
+	if err != nil {
+		return "", err
+	}
+	pm.removePayoutFile(payout)
+
+	return txid, nil
+}
+
+// publishPayout verifies the provided hex encoded transaction is the signed
+// pending payout transaction and publishes it, returning its hash. The
+// payments covered by the payout are archived as paid once it is published.
+// Publishing the signed transaction of a payout which has already been
+// completed returns its hash.
+func (pm *PaymentMgr) publishPayout(ctx context.Context, signedTx string) (string, error) {
+	pm.payoutMtx.Lock()
+	defer pm.payoutMtx.Unlock()
+
+	tx, err := decodeTx(strings.TrimSpace(signedTx))
+	if err != nil {
+		return "", err
+	}
+
+	payout, err := pm.cfg.db.fetchPendingPayout()
+	if err != nil {
+		if !errors.Is(err, errs.ValueNotFound) {
+			return "", err
+		}
+
+		txid := tx.TxHash().String()
+		paid, aErr := pm.payoutArchived(txid)
+		if aErr != nil {
+			return "", aErr
+		}
+		if !paid {
+			return "", err
+		}
+		log.Infof("Payout tx %s has already been completed", txid)
+		return txid, nil
+	}
+
+	err = payout.verifySignedTx(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return pm.completePayout(ctx, payout, tx)
+}
+
+// discardPayout deletes the pending payout transaction, the payments it
+// covers are included in the next payout instead. A payout which has
+// already been published cannot be discarded.
+func (pm *PaymentMgr) discardPayout() (*PendingPayout, error) {
+	funcName := "discardPayout"
+	pm.payoutMtx.Lock()
+	defer pm.payoutMtx.Unlock()
+
+	payout, err := pm.cfg.db.fetchPendingPayout()
+	if err != nil {
+		return nil, err
+	}
+	if payout.Published {
+		desc := fmt.Sprintf("%s: payout tx %s has already been published",
+			funcName, payout.TxID)
+		return nil, errs.PoolError(errs.ValueFound, desc)
+	}
+	err = pm.cfg.db.deletePendingPayout()
+	if err != nil {
+		return nil, err
+	}
+	pm.removePayoutFile(payout)
+
+	log.Infof("Discarded pending payout tx %s", payout.TxID)
+
+	return payout, nil
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"testing"
 	"time"
 
@@ -165,6 +166,7 @@ func createPaymentMgr(paymentMethod string) (*PaymentMgr, error) {
 		FetchTxCreator:        fetchTxCreator,
 		FetchTxBroadcaster:    fetchTxBroadcaster,
 		PoolFeeAddrs:          []dcrutil.Address{poolFeeAddrs},
+		NotifyWebhooks:        func(string, interface{}, map[string]interface{}) {},
 	}
 	return NewPaymentMgr(pCfg)
 }
@@ -934,18 +936,34 @@ func testPaymentMgrPayment(t *testing.T) {
 		t.Fatalf("expected a generate payout tx details error, got %v", err)
 	}
 
-	// Ensure dividend payment returns an error if applying tx fees fails.
+	// Ensure applying tx fees fails for payout tx details without payments
+	// to pay out.
 	mgr.cfg.FetchTxCreator = func() TxCreator {
 		return &txCreatorImpl{}
 	}
+	inputs, _, outputs, tOut, err := mgr.generatePayoutTxDetails(ctx,
+		mgr.cfg.FetchTxCreator(), poolFeeAddrs,
+		make(map[string][]*Payment), treasuryActive)
+	if err != nil {
+		cancel()
+		t.Fatalf("unexpected payout tx details error: %v", err)
+	}
+	_, _, err = mgr.applyTxFees(inputs, outputs, tOut, poolFeeAddrs)
+	if !errors.Is(err, errs.TxIn) {
+		cancel()
+		t.Fatalf("expected an apply tx fee error, got %v", err)
+	}
+
+	// Ensure dividend payment does nothing if all mature payments are
+	// sourced from orphaned blocks.
 	mgr.cfg.GetBlockConfirmations = func(ctx context.Context, bh *chainhash.Hash) (int64, error) {
 		return -1, nil
 	}
 
 	err = mgr.payDividends(ctx, estMaturity+1, treasuryActive)
-	if !errors.Is(err, errs.TxIn) {
+	if err != nil {
 		cancel()
-		t.Fatalf("expected an apply tx fee error, got %v", err)
+		t.Fatalf("expected no payout for orphaned payments, got %v", err)
 	}
 
 	// Ensure dividend payment returns an error if confirming a coinbase fails.
@@ -1097,7 +1115,7 @@ func testPaymentMgrPayment(t *testing.T) {
 
 	// Ensure dividend payment returns an error if the payout transaction
 	// cannot be published.
-	txBytes := []byte("01000000018e17619f0d627c2769ee3f957582691aea59c2" +
+	txBytes, _ := hex.DecodeString("01000000018e17619f0d627c2769ee3f957582691aea59c2" +
 		"e79cc45b8ba1f08485dd88d75c0300000001ffffffff017a64e43703000000" +
 		"00001976a914978fa305bd66f63f0de847338bb56ff65fa8e27288ac000000" +
 		"000000000001f46ce43703000000846c0700030000006b483045022100d668" +
@@ -1124,6 +1142,18 @@ func testPaymentMgrPayment(t *testing.T) {
 		t.Fatalf("expected a publish error, got %v", err)
 	}
 
+	// Ensure the signed payout transaction failing to publish is left
+	// pending, it is published again by the next payout attempt.
+	payout, err := db.fetchPendingPayout()
+	if err != nil {
+		cancel()
+		t.Fatalf("unable to fetch pending payout: %v", err)
+	}
+	if payout.Published {
+		cancel()
+		t.Fatal("expected the pending payout not to be published")
+	}
+
 	// Ensure paying dividend payment succeeds with valid inputs.
 	txHash, _ := hex.DecodeString("013264da8cc53f70022dc2b5654ebefc9ecfed24ea18dfcfc9adca5642d4fe66")
 	mgr.cfg.FetchTxBroadcaster = func() TxBroadcaster {
@@ -1141,14 +1171,50 @@ func testPaymentMgrPayment(t *testing.T) {
 		}
 	}
 
+	owed := make(map[string]dcrutil.Amount)
+	pending, err := db.fetchPendingPayments()
+	if err != nil {
+		cancel()
+		t.Fatalf("unable to fetch pending payments: %v", err)
+	}
+	for _, pmt := range pending {
+		owed[pmt.Account] += pmt.Amount
+	}
+
 	err = mgr.payDividends(ctx, estMaturity+1, treasuryActive)
 	if err != nil {
 		cancel()
 		t.Fatalf("unexpected dividend payment error, got %v", err)
 	}
+	_, err = db.fetchPendingPayout()
+	if !errors.Is(err, errs.ValueNotFound) {
+		cancel()
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
 
 	cancel()
 
+	// Ensure the payout and the transaction fee booked to the ledger for
+	// each paid account add up to the amount owed to it.
+	txid, _ := chainhash.NewHash(txHash)
+	entries, err := db.fetchLedgerEntries("", 0)
+	if err != nil {
+		t.Fatalf("unable to fetch ledger entries: %v", err)
+	}
+	booked := make(map[string]dcrutil.Amount)
+	for _, entry := range entries {
+		if entry.TxID != txid.String() {
+			continue
+		}
+		if entry.Kind != LedgerPayout && entry.Kind != LedgerTxFee {
+			t.Fatalf("unexpected %s ledger entry for payout tx", entry.Kind)
+		}
+		booked[entry.Debit] += entry.Amount
+	}
+	if !reflect.DeepEqual(booked, owed) {
+		t.Fatalf("expected booked payouts %v, got %v", owed, booked)
+	}
+
 	// Reset backed up values to their defaults.
 	err = db.persistLastPaymentInfo(0, 0)
 	if err != nil {
@@ -1160,6 +1226,266 @@ func testPaymentMgrPayment(t *testing.T) {
 	}
 }
 
+func testPaymentMgrPublish(t *testing.T) {
+	accountX := NewAccount(xAddr)
+	err := db.persistAccount(accountX)
+	if err != nil {
+		t.Fatalf("failed to insert account: %v", err)
+	}
+
+	mgr, err := createPaymentMgr(PPS)
+	if err != nil {
+		t.Fatalf("[createPaymentMgr] unexpected error: %v", err)
+	}
+
+	amt, _ := dcrutil.NewAmount(5)
+	pmtA := NewPayment(xID, zeroSource, amt, 10, 26)
+	pmtB := NewPayment(yID, zeroSource, amt, 10, 26)
+	for _, pmt := range []*Payment{pmtA, pmtB} {
+		err = db.PersistPayment(pmt)
+		if err != nil {
+			t.Fatalf("unable to persist payment: %v", err)
+		}
+	}
+
+	unsigned := wire.NewMsgTx()
+	unsigned.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+		wire.TxTreeRegular), int64(amt*2), nil))
+	unsigned.AddTxOut(wire.NewTxOut(int64(amt)-100, []byte{0x76, 0xa9}))
+	unsigned.AddTxOut(wire.NewTxOut(int64(amt)-100, []byte{0x76, 0xaa}))
+	txBytes, err := unsigned.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize transaction: %v", err)
+	}
+	payout := &PendingPayout{
+		TxID:        unsigned.TxHash().String(),
+		Height:      30,
+		Transaction: hex.EncodeToString(txBytes),
+		Outputs: map[string]dcrutil.Amount{
+			xAddr: amt - 100,
+			yAddr: amt - 100,
+		},
+		Total:     amt*2 - 200,
+		TxFee:     200,
+		Payments:  []string{pmtA.UUID, pmtB.UUID},
+		CreatedOn: time.Now().Unix(),
+	}
+	err = db.persistPendingPayout(payout)
+	if err != nil {
+		t.Fatalf("unable to persist pending payout: %v", err)
+	}
+
+	signed := unsigned.Copy()
+	signed.TxIn[0].SignatureScript = []byte{0x01}
+	signedBytes, err := signed.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize transaction: %v", err)
+	}
+	signedTx := hex.EncodeToString(signedBytes)
+
+	var published int
+	mgr.cfg.FetchTxBroadcaster = func() TxBroadcaster {
+		return &txBroadcasterImpl{
+			publishTransaction: func(ctx context.Context, req *walletrpc.PublishTransactionRequest, options ...grpc.CallOption) (*walletrpc.PublishTransactionResponse, error) {
+				published++
+				txHash := signed.TxHash()
+				return &walletrpc.PublishTransactionResponse{
+					TransactionHash: txHash[:],
+				}, nil
+			},
+		}
+	}
+	ctx := context.Background()
+
+	// Ensure a payout which is published but cannot be archived is
+	// recorded as published and cannot be discarded. The account of the
+	// second payment does not exist yet.
+	_, err = mgr.publishPayout(ctx, signedTx)
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+	if published != 1 {
+		t.Fatalf("expected the payout to be published once, got %d",
+			published)
+	}
+	pending, err := db.fetchPendingPayout()
+	if err != nil {
+		t.Fatalf("unable to fetch pending payout: %v", err)
+	}
+	if !pending.Published {
+		t.Fatal("expected the pending payout to be recorded as published")
+	}
+	_, err = mgr.discardPayout()
+	if !errors.Is(err, errs.ValueFound) {
+		t.Fatalf("expected a value found error, got %v", err)
+	}
+
+	// Ensure publishing the payout again completes it without publishing
+	// it again.
+	accountY := NewAccount(yAddr)
+	err = db.persistAccount(accountY)
+	if err != nil {
+		t.Fatalf("failed to insert account: %v", err)
+	}
+	txid, err := mgr.publishPayout(ctx, signedTx)
+	if err != nil {
+		t.Fatalf("unexpected publish payout error: %v", err)
+	}
+	if txid != payout.TxID {
+		t.Fatalf("expected payout tx %s, got %s", payout.TxID, txid)
+	}
+	if published != 1 {
+		t.Fatalf("expected the payout to be published once, got %d",
+			published)
+	}
+	_, err = db.fetchPendingPayout()
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+	pmts, err := db.fetchPendingPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch pending payments: %v", err)
+	}
+	if len(pmts) != 0 {
+		t.Fatalf("expected no pending payments, got %d", len(pmts))
+	}
+
+	// Ensure publishing a completed payout again returns its hash.
+	txid, err = mgr.publishPayout(ctx, signedTx)
+	if err != nil {
+		t.Fatalf("unexpected publish payout error: %v", err)
+	}
+	if txid != payout.TxID || published != 1 {
+		t.Fatalf("expected payout tx %s published once, got %s "+
+			"published %d times", payout.TxID, txid, published)
+	}
+
+	// Ensure a pending payout whose payments are already archived as paid
+	// by it is removed without publishing it again.
+	payout.Published = false
+	err = db.persistPendingPayout(payout)
+	if err != nil {
+		t.Fatalf("unable to persist pending payout: %v", err)
+	}
+	txid, err = mgr.publishPayout(ctx, signedTx)
+	if err != nil {
+		t.Fatalf("unexpected publish payout error: %v", err)
+	}
+	if txid != payout.TxID || published != 1 {
+		t.Fatalf("expected payout tx %s published once, got %s "+
+			"published %d times", payout.TxID, txid, published)
+	}
+	_, err = db.fetchPendingPayout()
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+
+	// Ensure publishing an unknown transaction without a pending payout
+	// fails.
+	signed.TxIn[0].PreviousOutPoint.Index = 1
+	signedBytes, err = signed.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize transaction: %v", err)
+	}
+	_, err = mgr.publishPayout(ctx, hex.EncodeToString(signedBytes))
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+}
+
+func testPaymentMgrAdjustments(t *testing.T) {
+	for _, addr := range []string{xAddr, yAddr} {
+		err := db.persistAccount(NewAccount(addr))
+		if err != nil {
+			t.Fatalf("failed to insert account: %v", err)
+		}
+	}
+
+	mgr, err := createPaymentMgr(PPS)
+	if err != nil {
+		t.Fatalf("[createPaymentMgr] unexpected error: %v", err)
+	}
+
+	const coin = dcrutil.Amount(1e8)
+	pmtX := NewPayment(xID, zeroSource, 10*coin, 10, 26)
+	pmtFee := NewPayment(PoolFeesK, zeroSource, 2*coin, 10, 26)
+	pmtY := NewPayment(yID, zeroSource, coin, 20, 36)
+	payments := []*Payment{pmtX, pmtFee, pmtY}
+	err = db.persistPayments(payments, []*LedgerEntry{rewardEntry(pmtX),
+		rewardEntry(pmtFee), rewardEntry(pmtY)}, 0, 0)
+	if err != nil {
+		t.Fatalf("unable to persist payments: %v", err)
+	}
+	err = db.persistLedgerEntries([]*LedgerEntry{
+		adjustmentEntry(xID, -coin, "admin", "refund"),
+		adjustmentEntry(yID, coin/2, "admin", "bonus"),
+		adjustmentEntry(PoolFeesK, coin, "admin", "correction"),
+	})
+	if err != nil {
+		t.Fatalf("unable to persist adjustments: %v", err)
+	}
+
+	// Ensure unpaid adjustments are applied to the payout, debits refunding
+	// and credits paid out of the pool fees. The immature payment of the
+	// second account is not part of its adjustments.
+	feeAddr := poolFeeAddrs.String()
+	outputs := map[string]dcrutil.Amount{
+		xAddr:   10 * coin,
+		feeAddr: 2 * coin,
+	}
+	adjustments, err := mgr.payoutAdjustments(outputs, feeAddr)
+	if err != nil {
+		t.Fatalf("unexpected payout adjustments error: %v", err)
+	}
+	expected := map[string]dcrutil.Amount{xID: -coin, yID: coin / 2}
+	if !reflect.DeepEqual(adjustments, expected) {
+		t.Fatalf("expected adjustments %v, got %v", expected, adjustments)
+	}
+	expected = map[string]dcrutil.Amount{
+		xAddr:   9 * coin,
+		yAddr:   coin / 2,
+		feeAddr: 2*coin + coin/2,
+	}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Fatalf("expected outputs %v, got %v", expected, outputs)
+	}
+
+	// Ensure the paid adjustments are settled by the payout, leaving only
+	// the immature payment and the pool fees correction.
+	err = mgr.finalizePayout(30, "txid", payments[:2], adjustments, outputs,
+		feeAddr)
+	if err != nil {
+		t.Fatalf("unexpected finalize payout error: %v", err)
+	}
+	balances, err := db.fetchLedgerBalances()
+	if err != nil {
+		t.Fatalf("unable to fetch ledger balances: %v", err)
+	}
+	if balances[xID] != 0 || balances[yID] != coin ||
+		balances[PoolFeesK] != coin || balances[LedgerAdjustments] != -coin {
+		t.Fatalf("unexpected ledger balances %v", balances)
+	}
+
+	// Ensure settled adjustments are not applied again and credits not
+	// covered by the pool fees are left to later payouts.
+	err = db.persistLedgerEntries([]*LedgerEntry{
+		adjustmentEntry(yID, 5*coin, "admin", "bonus"),
+	})
+	if err != nil {
+		t.Fatalf("unable to persist adjustment: %v", err)
+	}
+	outputs = map[string]dcrutil.Amount{feeAddr: 2 * coin}
+	adjustments, err = mgr.payoutAdjustments(outputs, feeAddr)
+	if err != nil {
+		t.Fatalf("unexpected payout adjustments error: %v", err)
+	}
+	if len(adjustments) != 0 || len(outputs) != 1 ||
+		outputs[feeAddr] != 2*coin {
+		t.Fatalf("expected no adjustments, got %v with outputs %v",
+			adjustments, outputs)
+	}
+}
+
 func testPaymentMgrDust(t *testing.T) {
 	mgr, err := createPaymentMgr(PPLNS)
 	if err != nil {
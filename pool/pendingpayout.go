@@ -0,0 +1,130 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// PendingPayout represents a payout transaction which has not been
+// completed yet, either unsigned and awaiting an external signature before it
+// can be published or signed by the pool wallet and awaiting publishing.
+type PendingPayout struct {
+	// TxID is the hash of the unsigned transaction. Signatures are not
+	// committed to by the transaction hash, the signed transaction has the
+	// same hash.
+	TxID        string                    `json:"txid"`
+	Height      uint32                    `json:"height"`
+	Transaction string                    `json:"transaction"`
+	Outputs     map[string]dcrutil.Amount `json:"outputs"`
+	FeeAddress  string                    `json:"feeaddress"`
+	Total       dcrutil.Amount            `json:"total"`
+	TxFee       dcrutil.Amount            `json:"txfee"`
+	Payments    []string                  `json:"payments"`
+	CreatedOn   int64                     `json:"createdon"`
+
+	// Adjustments are the manual balance adjustments paid by the payout,
+	// keyed by account.
+	Adjustments map[string]dcrutil.Amount `json:"adjustments,omitempty"`
+
+	// Published is set once the signed transaction has been published, the
+	// payout is then completed without publishing it again.
+	Published bool `json:"published,omitempty"`
+}
+
+// decodeTx deserializes the provided hex encoded transaction.
+func decodeTx(txHex string) (*wire.MsgTx, error) {
+	const funcName = "decodeTx"
+	b, err := hex.DecodeString(txHex)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode transaction hex: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+	var tx wire.MsgTx
+	err = tx.FromBytes(b)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to deserialize transaction: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.Decode, desc)
+	}
+	return &tx, nil
+}
+
+// verifySignedTx ensures the provided signed transaction spends the inputs
+// and pays the outputs planned by the pending payout, and that all of its
+// inputs are signed.
+func (p *PendingPayout) verifySignedTx(signed *wire.MsgTx) error {
+	const funcName = "verifySignedTx"
+	unsigned, err := decodeTx(p.Transaction)
+	if err != nil {
+		return err
+	}
+
+	if len(signed.TxIn) != len(unsigned.TxIn) {
+		desc := fmt.Sprintf("%s: signed transaction has %d inputs, "+
+			"expected %d", funcName, len(signed.TxIn), len(unsigned.TxIn))
+		return errs.PoolError(errs.TxIn, desc)
+	}
+	for i, in := range signed.TxIn {
+		prevOut := unsigned.TxIn[i].PreviousOutPoint
+		if in.PreviousOutPoint != prevOut {
+			desc := fmt.Sprintf("%s: signed transaction input %d spends "+
+				"%v, expected %v", funcName, i, in.PreviousOutPoint, prevOut)
+			return errs.PoolError(errs.TxIn, desc)
+		}
+		if len(in.SignatureScript) == 0 {
+			desc := fmt.Sprintf("%s: signed transaction input %d is not "+
+				"signed", funcName, i)
+			return errs.PoolError(errs.SignTx, desc)
+		}
+	}
+
+	if len(signed.TxOut) != len(p.Outputs) ||
+		len(signed.TxOut) != len(unsigned.TxOut) {
+		desc := fmt.Sprintf("%s: signed transaction has %d outputs, "+
+			"expected %d", funcName, len(signed.TxOut), len(p.Outputs))
+		return errs.PoolError(errs.TxOut, desc)
+	}
+	var total dcrutil.Amount
+	for _, amt := range p.Outputs {
+		total += amt
+	}
+	var signedTotal dcrutil.Amount
+	for i, out := range signed.TxOut {
+		planned := unsigned.TxOut[i]
+		if out.Value != planned.Value || out.Version != planned.Version ||
+			!bytes.Equal(out.PkScript, planned.PkScript) {
+			desc := fmt.Sprintf("%s: signed transaction output %d pays "+
+				"%v to script %x, expected %v to script %x", funcName, i,
+				dcrutil.Amount(out.Value), out.PkScript,
+				dcrutil.Amount(planned.Value), planned.PkScript)
+			return errs.PoolError(errs.TxOut, desc)
+		}
+		signedTotal += dcrutil.Amount(out.Value)
+	}
+	if signedTotal != total {
+		desc := fmt.Sprintf("%s: signed transaction pays a total of %v, "+
+			"expected %v", funcName, signedTotal, total)
+		return errs.PoolError(errs.TxOut, desc)
+	}
+
+	// The transaction hash commits to the remaining fields of the
+	// transaction like the lock time and expiry.
+	if signed.TxHash().String() != p.TxID {
+		desc := fmt.Sprintf("%s: signed transaction %v does not match "+
+			"pending payout transaction %s", funcName, signed.TxHash(), p.TxID)
+		return errs.PoolError(errs.TxOut, desc)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func TestPendingPayoutVerifySignedTx(t *testing.T) {
+	unsigned := wire.NewMsgTx()
+	unsigned.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+		wire.TxTreeRegular), 500, nil))
+	unsigned.AddTxOut(wire.NewTxOut(300, []byte{0x76, 0xa9}))
+	unsigned.AddTxOut(wire.NewTxOut(190, []byte{0x76, 0xaa}))
+	txBytes, err := unsigned.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize transaction: %v", err)
+	}
+
+	payout := &PendingPayout{
+		TxID:        unsigned.TxHash().String(),
+		Transaction: hex.EncodeToString(txBytes),
+		Outputs: map[string]dcrutil.Amount{
+			"a": 300,
+			"b": 190,
+		},
+		Total: 490,
+		TxFee: 10,
+	}
+
+	// signed returns a signed copy of the unsigned transaction.
+	signed := func() *wire.MsgTx {
+		tx := unsigned.Copy()
+		tx.TxIn[0].SignatureScript = []byte{0x01}
+		return tx
+	}
+
+	// Ensure the signed transaction matching the planned payout is valid.
+	err = payout.verifySignedTx(signed())
+	if err != nil {
+		t.Fatalf("unexpected verification error: %v", err)
+	}
+
+	// Ensure an unsigned input is rejected.
+	err = payout.verifySignedTx(unsigned)
+	if !errors.Is(err, errs.SignTx) {
+		t.Fatalf("expected a sign tx error, got %v", err)
+	}
+
+	// Ensure a different input is rejected.
+	tx := signed()
+	tx.TxIn[0].PreviousOutPoint.Index = 1
+	err = payout.verifySignedTx(tx)
+	if !errors.Is(err, errs.TxIn) {
+		t.Fatalf("expected a tx input error, got %v", err)
+	}
+
+	// Ensure an additional input is rejected.
+	tx = signed()
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0,
+		wire.TxTreeRegular), 500, []byte{0x01}))
+	err = payout.verifySignedTx(tx)
+	if !errors.Is(err, errs.TxIn) {
+		t.Fatalf("expected a tx input error, got %v", err)
+	}
+
+	// Ensure a changed output amount is rejected.
+	tx = signed()
+	tx.TxOut[1].Value = 200
+	err = payout.verifySignedTx(tx)
+	if !errors.Is(err, errs.TxOut) {
+		t.Fatalf("expected a tx output error, got %v", err)
+	}
+
+	// Ensure a changed output script is rejected.
+	tx = signed()
+	tx.TxOut[0].PkScript = []byte{0x76, 0xab}
+	err = payout.verifySignedTx(tx)
+	if !errors.Is(err, errs.TxOut) {
+		t.Fatalf("expected a tx output error, got %v", err)
+	}
+
+	// Ensure an additional output is rejected.
+	tx = signed()
+	tx.AddTxOut(wire.NewTxOut(5, []byte{0x76, 0xac}))
+	err = payout.verifySignedTx(tx)
+	if !errors.Is(err, errs.TxOut) {
+		t.Fatalf("expected a tx output error, got %v", err)
+	}
+
+	// Ensure a changed expiry is rejected.
+	tx = signed()
+	tx.Expiry = 100
+	err = payout.verifySignedTx(tx)
+	if !errors.Is(err, errs.TxOut) {
+		t.Fatalf("expected a tx output error, got %v", err)
+	}
+}
@@ -11,6 +11,8 @@ import (
 var (
 	// testDB represents the database used in testing.
 	testDB = "testdb"
+	// testSQLiteDB represents the sqlite database used in testing.
+	testSQLiteDB = "testsqlitedb"
 	// Account X address.
 	xAddr = "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"
 	// Account X id.
@@ -27,14 +29,36 @@ var (
 	db Database
 )
 
+// Postgres test database connection details.
+const (
+	pgHost   = "127.0.0.1"
+	pgPort   = uint32(5432)
+	pgUser   = "dcrpooluser"
+	pgPass   = "12345"
+	pgDBName = "dcrpooltestdb"
+)
+
+// testPGConfig returns the connection details of the postgres test
+// database.
+func testPGConfig() *PostgresConfig {
+	return &PostgresConfig{
+		Host:   pgHost,
+		Port:   pgPort,
+		User:   pgUser,
+		Pass:   pgPass,
+		DBName: pgDBName,
+	}
+}
+
 func setupPostgresDB() (*PostgresDB, error) {
-	pgHost := "127.0.0.1"
-	pgPort := uint32(5432)
-	pgUser := "dcrpooluser"
-	pgPass := "12345"
-	pgDBName := "dcrpooltestdb"
 	purgeDB := false
-	return InitPostgresDB(pgHost, pgPort, pgUser, pgPass, pgDBName, purgeDB)
+	return InitPostgresDB(testPGConfig(), purgeDB)
+}
+
+// setupSQLiteDB initializes a sqlite database.
+func setupSQLiteDB() (*SQLiteDB, error) {
+	purgeDB := true
+	return InitSQLiteDB(testSQLiteDB, purgeDB)
 }
 
 // setupBoltDB initializes a bolt database.
@@ -71,7 +95,7 @@ func teardownBoltDB(db *BoltDB, dbPath string) error {
 func TestPool(t *testing.T) {
 
 	// All sub-tests to run. All of these tests will be run with a postgres
-	// database and a bolt database.
+	// database, a sqlite database and a bolt database.
 	tests := map[string]func(*testing.T){
 		"testCSRFSecret":             testCSRFSecret,
 		"testLastPaymentInfo":        testLastPaymentInfo,
@@ -85,6 +109,7 @@ func TestPool(t *testing.T) {
 		"testPPSEligibleShares":      testPPSEligibleShares,
 		"testPPLNSEligibleShares":    testPPLNSEligibleShares,
 		"testPruneShares":            testPruneShares,
+		"testShareAggregation":       testShareAggregation,
 		"testPayment":                testPayment,
 		"testPaymentAccessors":       testPaymentAccessors,
 		"testEndpoint":               testEndpoint,
@@ -97,9 +122,19 @@ func TestPool(t *testing.T) {
 		"testPaymentMgrPPLNS":        testPaymentMgrPPLNS,
 		"testPaymentMgrMaturity":     testPaymentMgrMaturity,
 		"testPaymentMgrPayment":      testPaymentMgrPayment,
+		"testPaymentMgrPublish":      testPaymentMgrPublish,
+		"testPaymentMgrAdjustments":  testPaymentMgrAdjustments,
 		"testPaymentMgrDust":         testPaymentMgrDust,
 		"testChainState":             testChainState,
 		"testHub":                    testHub,
+		"testAPIToken":               testAPIToken,
+		"testAdminUser":              testAdminUser,
+		"testAuditLog":               testAuditLog,
+		"testBan":                    testBan,
+		"testArchive":                testArchive,
+		"testMigrateDB":              testMigrateDB,
+		"testCheckDB":                testCheckDB,
+		"testLedger":                 testLedger,
 	}
 
 	// Run all tests with bolt DB.
@@ -121,6 +156,28 @@ func TestPool(t *testing.T) {
 		boltDB.Close()
 	}
 
+	// Run all tests with sqlite DB.
+	for testName, test := range tests {
+		sqliteDB, err := setupSQLiteDB()
+		if err != nil {
+			t.Fatalf("setupSQLiteDB error: %v", err)
+		}
+
+		db = sqliteDB
+
+		t.Run(testName+"_SQLite", test)
+
+		err = sqliteDB.purge()
+		if err != nil {
+			t.Fatalf("sqlite teardown error: %v", err)
+		}
+
+		sqliteDB.Close()
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(testSQLiteDB + suffix)
+	}
+
 	// Run all tests with postgres DB.
 	for testName, test := range tests {
 		postgresDB, err := setupPostgresDB()
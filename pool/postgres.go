@@ -5,10 +5,13 @@
 package pool
 
 import (
+	"context"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"net/http"
 	"time"
@@ -16,90 +19,113 @@ import (
 	"github.com/decred/dcrd/dcrutil/v3"
 
 	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
 
 	errs "github.com/decred/dcrpool/errors"
 )
 
-// InitPostgresDB connects to the specified database and creates all tables
-// required by dcrpool.
-func InitPostgresDB(host string, port uint32, user, pass, dbName string, purgeDB bool) (*PostgresDB, error) {
-	const funcName = "InitPostgresDB"
+// isUniqueViolation returns whether the provided error is a unique constraint
+// violation reported by the postgres or the sqlite driver.
+func isUniqueViolation(err error) bool {
+	var pqError *pq.Error
+	if errors.As(err, &pqError) {
+		return pqError.Code.Name() == "unique_violation"
+	}
+	var sqliteError sqlite3.Error
+	if errors.As(err, &sqliteError) {
+		return sqliteError.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteError.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
+
+// openPostgresDB connects to the specified database. An error is returned
+// right away if the connection details are invalid or the database is
+// unreachable.
+func openPostgresDB(cfg *PostgresConfig) (*PostgresDB, error) {
+	const funcName = "openPostgresDB"
 
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
-		"password=%s dbname=%s sslmode=disable",
-		host, port, user, pass, dbName)
+	err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	psqlInfo, err := cfg.dsn()
+	if err != nil {
+		return nil, err
+	}
 
 	db, err := sql.Open("postgres", psqlInfo)
 	if err != nil {
 		desc := fmt.Sprintf("%s: unable to open postgres: %v", funcName, err)
 		return nil, errs.DBError(errs.DBOpen, desc)
 	}
-
-	pdb := &PostgresDB{db}
-	if purgeDB {
-		err := pdb.purge()
-		if err != nil {
-			return nil, err
-		}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
 	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	// Send a Ping() to validate the db connection. This is because the Open()
 	// func does not actually create a connection to the database, it just
 	// validates the provided arguments.
-	err = db.Ping()
+	ctx := context.Background()
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+	err = db.PingContext(ctx)
 	if err != nil {
+		db.Close()
 		desc := fmt.Sprintf("%s: unable to connect to postgres: %v", funcName, err)
 		return nil, errs.DBError(errs.DBOpen, desc)
 	}
 
-	makeErr := func(table string, err error) error {
-		desc := fmt.Sprintf("%s: unable to create %s table: %v",
-			funcName, table, err)
-		return errs.DBError(errs.CreateStorage, desc)
-	}
-
-	// Create all of the tables required by dcrpool.
-	_, err = db.Exec(createTableMetadata)
-	if err != nil {
-		return nil, makeErr("metadata", err)
-	}
-
-	_, err = db.Exec(createTableAccounts)
-	if err != nil {
-		return nil, makeErr("accounts", err)
-	}
+	return &PostgresDB{db}, nil
+}
 
-	_, err = db.Exec(createTablePayments)
+// InitPostgresDB connects to the specified database and creates or upgrades
+// all tables required by dcrpool.
+func InitPostgresDB(cfg *PostgresConfig, purgeDB bool) (*PostgresDB, error) {
+	pdb, err := openPostgresDB(cfg)
 	if err != nil {
-		return nil, makeErr("payments", err)
+		return nil, err
 	}
 
-	_, err = db.Exec(createTableArchivedPayments)
-	if err != nil {
-		return nil, makeErr("archived payments", err)
+	if purgeDB {
+		err := pdb.purge()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	_, err = db.Exec(createTableJobs)
+	// Create or upgrade the tables required by dcrpool.
+	err = upgradePostgresDB(pdb)
 	if err != nil {
-		return nil, makeErr("jobs", err)
+		return nil, err
 	}
 
-	_, err = db.Exec(createTableShares)
-	if err != nil {
-		return nil, makeErr("shares", err)
-	}
+	return pdb, nil
+}
 
-	_, err = db.Exec(createTableAcceptedWork)
+// OpenPostgresDBReadOnly connects to the specified database with read-only
+// sessions, without creating or upgrading its tables. Returns an error if
+// the schema is not at the latest version.
+func OpenPostgresDBReadOnly(cfg *PostgresConfig) (*PostgresDB, error) {
+	roCfg := *cfg
+	roCfg.ReadOnly = true
+	pdb, err := openPostgresDB(&roCfg)
 	if err != nil {
-		return nil, makeErr("accepted work", err)
+		return nil, err
 	}
 
-	_, err = db.Exec(createTableHashData)
+	err = checkSQLSchemaVersion(pdb.DB, postgresDialect)
 	if err != nil {
-		return nil, makeErr("hashrate", err)
+		pdb.Close()
+		return nil, err
 	}
 
-	return &PostgresDB{db}, nil
+	return pdb, nil
 }
 
 // Close closes the postgres database connection.
@@ -126,27 +152,36 @@ func (db *PostgresDB) purge() error {
 	return nil
 }
 
+// scanPayment deserializes the current SQL row into a Payment.
+func scanPayment(rows *sql.Rows) (*Payment, error) {
+	const funcName = "scanPayment"
+	var uuid, account, transactionID, sourceBlockHash, sourceCoinbase string
+	var estimatedMaturity, height, paidOnHeight uint32
+	var amount, createdon int64
+	err := rows.Scan(&uuid, &account, &estimatedMaturity,
+		&height, &amount, &createdon, &paidOnHeight, &transactionID,
+		&sourceBlockHash, &sourceCoinbase)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan payment entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &Payment{uuid, account, estimatedMaturity,
+		height, dcrutil.Amount(amount), createdon, paidOnHeight, transactionID,
+		&PaymentSource{sourceBlockHash, sourceCoinbase}}, nil
+}
+
 // decodePaymentRows deserializes the provided SQL rows into a slice of Payment
 // structs.
 func decodePaymentRows(rows *sql.Rows) ([]*Payment, error) {
 	const funcName = "decodePaymentRows"
 	var toReturn []*Payment
 	for rows.Next() {
-		var uuid, account, transactionID, sourceBlockHash, sourceCoinbase string
-		var estimatedMaturity, height, paidOnHeight uint32
-		var amount, createdon int64
-		err := rows.Scan(&uuid, &account, &estimatedMaturity,
-			&height, &amount, &createdon, &paidOnHeight, &transactionID,
-			&sourceBlockHash, &sourceCoinbase)
+		payment, err := scanPayment(rows)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to scan payment entry: %v",
-				funcName, err)
-			return nil, errs.DBError(errs.Decode, desc)
+			return nil, err
 		}
-
-		payment := &Payment{uuid, account, estimatedMaturity,
-			height, dcrutil.Amount(amount), createdon, paidOnHeight, transactionID,
-			&PaymentSource{sourceBlockHash, sourceCoinbase}}
 		toReturn = append(toReturn, payment)
 	}
 
@@ -160,26 +195,35 @@ func decodePaymentRows(rows *sql.Rows) ([]*Payment, error) {
 	return toReturn, nil
 }
 
+// scanWork deserializes the current SQL row into an AcceptedWork.
+func scanWork(rows *sql.Rows) (*AcceptedWork, error) {
+	const funcName = "scanWork"
+	var uuid, blockhash, prevhash, minedby, miner, status, orphanedBy string
+	var confirmed bool
+	var height uint32
+	var createdOn int64
+	err := rows.Scan(&uuid, &blockhash, &prevhash, &height,
+		&minedby, &miner, &createdOn, &confirmed, &status, &orphanedBy)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan work entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &AcceptedWork{uuid, blockhash, prevhash, height,
+		minedby, miner, createdOn, confirmed, status, orphanedBy}, nil
+}
+
 // decodeWorkRows deserializes the provided SQL rows into a slice of
 // AcceptedWork structs.
 func decodeWorkRows(rows *sql.Rows) ([]*AcceptedWork, error) {
 	const funcName = "decodeWorkRows"
 	var toReturn []*AcceptedWork
 	for rows.Next() {
-		var uuid, blockhash, prevhash, minedby, miner string
-		var confirmed bool
-		var height uint32
-		var createdOn int64
-		err := rows.Scan(&uuid, &blockhash, &prevhash, &height,
-			&minedby, &miner, &createdOn, &confirmed)
+		work, err := scanWork(rows)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to scan work entry: %v",
-				funcName, err)
-			return nil, errs.DBError(errs.Decode, desc)
+			return nil, err
 		}
-
-		work := &AcceptedWork{uuid, blockhash, prevhash, height,
-			minedby, miner, createdOn, confirmed}
 		toReturn = append(toReturn, work)
 	}
 
@@ -193,27 +237,38 @@ func decodeWorkRows(rows *sql.Rows) ([]*AcceptedWork, error) {
 	return toReturn, nil
 }
 
+// scanShare deserializes the current SQL row into a Share.
+func scanShare(rows *sql.Rows) (*Share, error) {
+	const funcName = "scanShare"
+	var uuid, account, weight string
+	var createdon int64
+	err := rows.Scan(&uuid, &account, &weight, &createdon)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan share entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	weightRat, ok := new(big.Rat).SetString(weight)
+	if !ok {
+		desc := fmt.Sprintf("%s: unable to decode big.Rat string %s",
+			funcName, weight)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+
+	return &Share{uuid, account, weightRat, createdon}, nil
+}
+
 // decodeShareRows deserializes the provided SQL rows into a slice of Share
 // structs.
 func decodeShareRows(rows *sql.Rows) ([]*Share, error) {
 	const funcName = "decodeShareRows"
 	var toReturn []*Share
 	for rows.Next() {
-		var uuid, account, weight string
-		var createdon int64
-		err := rows.Scan(&uuid, &account, &weight, &createdon)
+		share, err := scanShare(rows)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to scan share entry: %v",
-				funcName, err)
-			return nil, errs.DBError(errs.Decode, desc)
-		}
-
-		weightRat, ok := new(big.Rat).SetString(weight)
-		if !ok {
-			desc := fmt.Sprintf("unable to decode big.Rat string: %v", err)
-			return nil, errs.DBError(errs.Parse, desc)
+			return nil, err
 		}
-		share := &Share{uuid, account, weightRat, createdon}
 		toReturn = append(toReturn, share)
 	}
 
@@ -227,6 +282,29 @@ func decodeShareRows(rows *sql.Rows) ([]*Share, error) {
 	return toReturn, nil
 }
 
+// scanHashData deserializes the current SQL row into a HashData.
+func scanHashData(rows *sql.Rows) (*HashData, error) {
+	const funcName = "scanHashData"
+	var uuid, accountID, miner, ip, hashRate string
+	var updatedOn int64
+	err := rows.Scan(&uuid, &accountID, &miner, &ip,
+		&hashRate, &updatedOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan hash data entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	hashRat, ok := new(big.Rat).SetString(hashRate)
+	if !ok {
+		desc := fmt.Sprintf("%s: unable to decode big.Rat string %s",
+			funcName, hashRate)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+
+	return &HashData{uuid, accountID, miner, ip, hashRat, updatedOn}, nil
+}
+
 // decodeHashDataRows deserializes the provided SQL rows into a slice of
 // HashData structs.
 func decodeHashDataRows(rows *sql.Rows) (map[string]*HashData, error) {
@@ -234,24 +312,10 @@ func decodeHashDataRows(rows *sql.Rows) (map[string]*HashData, error) {
 
 	toReturn := make(map[string]*HashData)
 	for rows.Next() {
-		var uuid, accountID, miner, ip, hashRate string
-		var updatedOn int64
-		err := rows.Scan(&uuid, &accountID, &miner, &ip,
-			&hashRate, &updatedOn)
+		hashData, err := scanHashData(rows)
 		if err != nil {
-			desc := fmt.Sprintf("%s: unable to scan hash data entry: %v",
-				funcName, err)
-			return nil, errs.DBError(errs.Decode, desc)
-		}
-
-		hashRat, ok := new(big.Rat).SetString(hashRate)
-		if !ok {
-			desc := fmt.Sprintf("%s: unable to decode big.Rat string: %v",
-				funcName, err)
-			return nil, errs.DBError(errs.Parse, desc)
+			return nil, err
 		}
-
-		hashData := &HashData{uuid, accountID, miner, ip, hashRat, updatedOn}
 		toReturn[hashData.UUID] = hashData
 	}
 
@@ -265,151 +329,376 @@ func decodeHashDataRows(rows *sql.Rows) (map[string]*HashData, error) {
 	return toReturn, nil
 }
 
-// httpBackup is not implemented for postgres database.
-func (db *PostgresDB) httpBackup(w http.ResponseWriter) error {
-	return errors.New("httpBackup is not implemented for postgres database")
-}
+// scanAPIToken deserializes the current SQL row into an APIToken.
+func scanAPIToken(rows *sql.Rows) (*APIToken, error) {
+	const funcName = "scanAPIToken"
+	var uuid, accountID, kind string
+	var createdOn int64
+	err := rows.Scan(&uuid, &accountID, &kind, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan api token entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
 
-// Backup is not implemented for postgres database.
-func (db *PostgresDB) Backup(fileName string) error {
-	return errors.New("Backup is not implemented for postgres database")
+	return &APIToken{uuid, accountID, kind, createdOn}, nil
 }
 
-// fetchPoolMode retrives the pool mode from the database. PoolMode is stored as
-// a uint32 for historical reasons. 0 indicates Public, 1 indicates Solo.
-func (db *PostgresDB) fetchPoolMode() (uint32, error) {
-	const funcName = "fetchPoolMode"
-	var poolmode uint32
-	err := db.DB.QueryRow(selectPoolMode).Scan(&poolmode)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no value found for poolmode", funcName)
-			return 0, errs.DBError(errs.ValueNotFound, desc)
+// decodeAPITokenRows deserializes the provided SQL rows into a slice of
+// APIToken structs.
+func decodeAPITokenRows(rows *sql.Rows) ([]*APIToken, error) {
+	const funcName = "decodeAPITokenRows"
+
+	var toReturn []*APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
 		}
+		toReturn = append(toReturn, token)
+	}
 
-		desc := fmt.Sprintf("%s: unable to fetch pool mode: %v", funcName, err)
-		return 0, errs.DBError(errs.FetchEntry, desc)
+	err := rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode api tokens: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
-	return poolmode, nil
+
+	return toReturn, nil
 }
 
-// persistPoolMode stores the pool mode in the database. PoolMode is stored as a
-// uint32 for historical reasons. 0 indicates Public, 1 indicates Solo.
-func (db *PostgresDB) persistPoolMode(mode uint32) error {
-	const funcName = "persistPoolMode"
-	_, err := db.DB.Exec(insertPoolMode, mode)
+// scanAdminUser deserializes the current SQL row into an AdminUser.
+func scanAdminUser(rows *sql.Rows) (*AdminUser, error) {
+	const funcName = "scanAdminUser"
+	var uuid, passwordHash, role, totpSecret string
+	var createdOn int64
+	err := rows.Scan(&uuid, &passwordHash, &role, &totpSecret, &createdOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to persist pool mode: %v",
+		desc := fmt.Sprintf("%s: unable to scan admin user entry: %v",
 			funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
-	return nil
+
+	return &AdminUser{uuid, passwordHash, role, totpSecret, createdOn}, nil
 }
 
-// fetchCSRFSecret retrieves the bytes used for the CSRF secret from the database.
-func (db *PostgresDB) fetchCSRFSecret() ([]byte, error) {
-	const funcName = "fetchCSRFSecret"
-	var secret string
-	err := db.DB.QueryRow(selectCSRFSecret).Scan(&secret)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no value found for csrfsecret", funcName)
-			return nil, errs.DBError(errs.ValueNotFound, desc)
-		}
+// decodeAdminUserRows deserializes the provided SQL rows into a slice of
+// AdminUser structs.
+func decodeAdminUserRows(rows *sql.Rows) ([]*AdminUser, error) {
+	const funcName = "decodeAdminUserRows"
 
-		desc := fmt.Sprintf("%s: unable to fetch CSRF secret: %v",
-			funcName, err)
-		return nil, errs.DBError(errs.FetchEntry, desc)
+	var toReturn []*AdminUser
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		toReturn = append(toReturn, user)
 	}
 
-	decoded, err := hex.DecodeString(secret)
+	err := rows.Err()
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to decode csrf secret: %v",
+		desc := fmt.Sprintf("%s: unable to decode admin users: %v",
 			funcName, err)
-		return nil, errs.DBError(errs.Parse, desc)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	return decoded, nil
+	return toReturn, nil
 }
 
-// persistCSRFSecret stores the bytes used for the CSRF secret in the database.
-func (db *PostgresDB) persistCSRFSecret(secret []byte) error {
-	const funcName = "persistCSRFSecret"
-	_, err := db.DB.Exec(insertCSRFSecret, hex.EncodeToString(secret))
+// scanBan deserializes the current SQL row into a Ban.
+func scanBan(rows *sql.Rows) (*Ban, error) {
+	const funcName = "scanBan"
+	var uuid, kind, reason string
+	var createdOn int64
+	err := rows.Scan(&uuid, &kind, &reason, &createdOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to persist CSRF secrets: %v",
+		desc := fmt.Sprintf("%s: unable to scan ban entry: %v",
 			funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
-	return err
+
+	return &Ban{uuid, kind, reason, createdOn}, nil
 }
 
-// persistLastPaymentInfo stores the last payment height and paidOn timestamp
-// in the database.
-func (db *PostgresDB) persistLastPaymentInfo(height uint32, paidOn int64) error {
-	const funcName = "persistLastPaymentInfo"
-	tx, err := db.DB.Begin()
-	if err != nil {
-		return err
-	}
+// decodeBanRows deserializes the provided SQL rows into a slice of Ban
+// structs.
+func decodeBanRows(rows *sql.Rows) ([]*Ban, error) {
+	const funcName = "decodeBanRows"
 
-	_, err = tx.Exec(insertLastPaymentHeight, height)
-	if err != nil {
-		rErr := tx.Rollback()
-		if rErr != nil {
-			desc := fmt.Sprintf("%s: unable to rollback persisting last "+
-				"payment height tx: %v, initial error: %v", funcName,
-				rErr, err)
-			return errs.DBError(errs.PersistEntry, desc)
+	var toReturn []*Ban
+	for rows.Next() {
+		ban, err := scanBan(rows)
+		if err != nil {
+			return nil, err
 		}
-
-		desc := fmt.Sprintf("%s: unable to persist last payment height: %v",
-			funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+		toReturn = append(toReturn, ban)
 	}
 
-	_, err = tx.Exec(insertLastPaymentPaidOn, paidOn)
+	err := rows.Err()
 	if err != nil {
-		rErr := tx.Rollback()
-		if rErr != nil {
-			desc := fmt.Sprintf("%s: unable to rollback persist last payment "+
-				"paid on time tx: %v, initial error: %v", funcName,
-				rErr, err)
-			return errs.DBError(errs.PersistEntry, desc)
-		}
+		desc := fmt.Sprintf("%s: unable to decode bans: %v", funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
 
-		desc := fmt.Sprintf("%s: unable to persist last payment paid on "+
-			"time: %v", funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+	return toReturn, nil
+}
+
+// scanAuditEntry deserializes the current SQL row into an AuditEntry.
+func scanAuditEntry(rows *sql.Rows) (*AuditEntry, error) {
+	const funcName = "scanAuditEntry"
+	var uuid, actor, action, details, ip string
+	var createdOn int64
+	err := rows.Scan(&uuid, &actor, &action, &details, &ip, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan audit entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	return tx.Commit()
+	return &AuditEntry{uuid, actor, action, details, ip, createdOn}, nil
 }
 
-// loadLastPaymentInfo retrieves the last payment height and paidOn timestamp
-// from the database.
-func (db *PostgresDB) loadLastPaymentInfo() (uint32, int64, error) {
-	const funcName = "loadLastPaymentInfo"
+// decodeAuditEntryRows deserializes the provided SQL rows into a slice of
+// AuditEntry structs.
+func decodeAuditEntryRows(rows *sql.Rows) ([]*AuditEntry, error) {
+	const funcName = "decodeAuditEntryRows"
 
-	var height uint32
-	err := db.DB.QueryRow(selectLastPaymentHeight).Scan(&height)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no value found for lastpaymentheight",
-				funcName)
-			return 0, 0, errs.DBError(errs.ValueNotFound, desc)
+	var toReturn []*AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
 		}
+		toReturn = append(toReturn, entry)
+	}
 
-		desc := fmt.Sprintf("%s: unable to load last payment height: %v",
+	err := rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode audit entries: %v",
 			funcName, err)
-		return 0, 0, errs.DBError(errs.FetchEntry, desc)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	var paidOn int64
-	err = db.DB.QueryRow(selectLastPaymentPaidOn).Scan(&paidOn)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no value found for lastpaymentpaidon",
+	return toReturn, nil
+}
+
+// scanLedgerEntry deserializes the current SQL row into a LedgerEntry.
+func scanLedgerEntry(rows *sql.Rows) (*LedgerEntry, error) {
+	const funcName = "scanLedgerEntry"
+	var uuid, kind, debit, credit, blockHash, txID, actor, memo string
+	var amount, createdOn int64
+	err := rows.Scan(&uuid, &kind, &debit, &credit, &amount, &blockHash,
+		&txID, &actor, &memo, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan ledger entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &LedgerEntry{uuid, kind, debit, credit, dcrutil.Amount(amount),
+		blockHash, txID, actor, memo, createdOn}, nil
+}
+
+// scanBlockEvent deserializes the current SQL row into a BlockEvent.
+func scanBlockEvent(rows *sql.Rows) (*BlockEvent, error) {
+	const funcName = "scanBlockEvent"
+	var uuid, kind, header, lastError string
+	var height, attempts uint32
+	var nextAttempt, createdOn int64
+	err := rows.Scan(&uuid, &kind, &header, &height, &attempts, &lastError,
+		&nextAttempt, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan block event: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &BlockEvent{uuid, kind, header, height, attempts, lastError,
+		nextAttempt, createdOn}, nil
+}
+
+// scanWebhook deserializes the current SQL row into a Webhook.
+func scanWebhook(rows *sql.Rows) (*Webhook, error) {
+	const funcName = "scanWebhook"
+	var uuid, accountID, url, secret string
+	var createdOn int64
+	err := rows.Scan(&uuid, &accountID, &url, &secret, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan webhook: %v", funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &Webhook{uuid, accountID, url, secret, createdOn}, nil
+}
+
+// scanWebhookDelivery deserializes the current SQL row into a
+// WebhookDelivery.
+func scanWebhookDelivery(rows *sql.Rows) (*WebhookDelivery, error) {
+	const funcName = "scanWebhookDelivery"
+	var uuid, webhookID, url, event, payload, lastError string
+	var attempts uint32
+	var nextAttempt, createdOn int64
+	err := rows.Scan(&uuid, &webhookID, &url, &event, &payload, &attempts,
+		&lastError, &nextAttempt, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan webhook delivery: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &WebhookDelivery{uuid, webhookID, url, event, payload, attempts,
+		lastError, nextAttempt, createdOn}, nil
+}
+
+// scanWorker deserializes the current SQL row into a Worker.
+func scanWorker(rows *sql.Rows) (*Worker, error) {
+	const funcName = "scanWorker"
+	var uuid, accountID, name, miner, status string
+	var hashRate, baseline float64
+	var samples uint32
+	var statusSince, lastSeen, createdOn int64
+	err := rows.Scan(&uuid, &accountID, &name, &miner, &hashRate, &baseline,
+		&samples, &status, &statusSince, &lastSeen, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan worker: %v", funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &Worker{uuid, accountID, name, miner, hashRate, baseline, samples,
+		status, statusSince, lastSeen, createdOn}, nil
+}
+
+// scanWorkerEvent deserializes the current SQL row into a WorkerEvent.
+func scanWorkerEvent(rows *sql.Rows) (*WorkerEvent, error) {
+	const funcName = "scanWorkerEvent"
+	var uuid, accountID, worker, kind string
+	var hashRate, baseline float64
+	var lastSeen, createdOn int64
+	err := rows.Scan(&uuid, &accountID, &worker, &kind, &hashRate, &baseline,
+		&lastSeen, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan worker event: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &WorkerEvent{uuid, accountID, worker, kind, hashRate, baseline,
+		lastSeen, createdOn}, nil
+}
+
+// scanAccount deserializes the current SQL row into an Account.
+func scanAccount(rows *sql.Rows) (*Account, error) {
+	const funcName = "scanAccount"
+	var uuid, address string
+	var createdOn uint64
+	err := rows.Scan(&uuid, &address, &createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan account entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &Account{uuid, address, createdOn}, nil
+}
+
+// scanJob deserializes the current SQL row into a Job.
+func scanJob(rows *sql.Rows) (*Job, error) {
+	const funcName = "scanJob"
+	var uuid, header string
+	var height uint32
+	err := rows.Scan(&uuid, &header, &height)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to scan job entry: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return &Job{uuid, height, header}, nil
+}
+
+// sqlQuerier describes the queries of a database or of a transaction of a
+// database.
+type sqlQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// pgMetadata reads the pool metadata of a database or of a transaction of a
+// database.
+type pgMetadata struct {
+	q sqlQuerier
+}
+
+// fetchPoolMode retrives the pool mode. PoolMode is stored as a uint32 for
+// historical reasons. 0 indicates Public, 1 indicates Solo.
+func (m pgMetadata) fetchPoolMode() (uint32, error) {
+	const funcName = "fetchPoolMode"
+	var poolmode uint32
+	err := m.q.QueryRow(selectPoolMode).Scan(&poolmode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no value found for poolmode", funcName)
+			return 0, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch pool mode: %v", funcName, err)
+		return 0, errs.DBError(errs.FetchEntry, desc)
+	}
+	return poolmode, nil
+}
+
+// fetchCSRFSecret retrieves the bytes used for the CSRF secret.
+func (m pgMetadata) fetchCSRFSecret() ([]byte, error) {
+	const funcName = "fetchCSRFSecret"
+	var secret string
+	err := m.q.QueryRow(selectCSRFSecret).Scan(&secret)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no value found for csrfsecret", funcName)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch CSRF secret: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	decoded, err := hex.DecodeString(secret)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode csrf secret: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+
+	return decoded, nil
+}
+
+// loadLastPaymentInfo retrieves the last payment height and paidOn
+// timestamp.
+func (m pgMetadata) loadLastPaymentInfo() (uint32, int64, error) {
+	const funcName = "loadLastPaymentInfo"
+
+	var height uint32
+	err := m.q.QueryRow(selectLastPaymentHeight).Scan(&height)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no value found for lastpaymentheight",
+				funcName)
+			return 0, 0, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to load last payment height: %v",
+			funcName, err)
+		return 0, 0, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	var paidOn int64
+	err = m.q.QueryRow(selectLastPaymentPaidOn).Scan(&paidOn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no value found for lastpaymentpaidon",
 				funcName)
 			return 0, 0, errs.DBError(errs.ValueNotFound, desc)
 		}
@@ -422,25 +711,11 @@ func (db *PostgresDB) loadLastPaymentInfo() (uint32, int64, error) {
 	return height, paidOn, nil
 }
 
-// persistLastPaymentCreatedOn stores the last payment createdOn timestamp in
-// the database.
-func (db *PostgresDB) persistLastPaymentCreatedOn(createdOn int64) error {
-	const funcName = "persistLastPaymentCreatedOn"
-	_, err := db.DB.Exec(insertLastPaymentCreatedOn, createdOn)
-	if err != nil {
-		desc := fmt.Sprintf("%s: unable to persist last payment created "+
-			"on time: %v", funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
-	}
-	return nil
-}
-
-// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp from
-// the database.
-func (db *PostgresDB) loadLastPaymentCreatedOn() (int64, error) {
+// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp.
+func (m pgMetadata) loadLastPaymentCreatedOn() (int64, error) {
 	const funcName = "loadLastPaymentCreatedOn"
 	var createdOn int64
-	err := db.DB.QueryRow(selectLastPaymentCreatedOn).Scan(&createdOn)
+	err := m.q.QueryRow(selectLastPaymentCreatedOn).Scan(&createdOn)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			desc := fmt.Sprintf("%s: no value found for lastpaymentcreatedon",
@@ -455,650 +730,2158 @@ func (db *PostgresDB) loadLastPaymentCreatedOn() (int64, error) {
 	return createdOn, nil
 }
 
-// persistAccount saves the account to the database. Before persisting the
-// account, it sets the createdOn timestamp. Returns an error if an account
-// already exists with the same ID.
-func (db *PostgresDB) persistAccount(acc *Account) error {
-	const funcName = "persistAccount"
-	_, err := db.DB.Exec(insertAccount, acc.UUID, acc.Address, uint64(time.Now().Unix()))
+// loadChainTip retrieves the hash and height of the last processed chain
+// tip.
+func (m pgMetadata) loadChainTip() (string, uint32, error) {
+	const funcName = "loadChainTip"
+
+	var hash string
+	err := m.q.QueryRow(selectChainTipHash).Scan(&hash)
 	if err != nil {
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				desc := fmt.Sprintf("%s: account %s already exists", funcName,
-					acc.UUID)
-				return errs.DBError(errs.ValueFound, desc)
-			}
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no value found for chaintiphash",
+				funcName)
+			return "", 0, errs.DBError(errs.ValueNotFound, desc)
 		}
 
-		desc := fmt.Sprintf("%s: unable to persist account: %v", funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+		desc := fmt.Sprintf("%s: unable to load chain tip hash: %v",
+			funcName, err)
+		return "", 0, errs.DBError(errs.FetchEntry, desc)
 	}
-	return nil
+
+	var height uint32
+	err = m.q.QueryRow(selectChainTipHeight).Scan(&height)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no value found for chaintipheight",
+				funcName)
+			return "", 0, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to load chain tip height: %v",
+			funcName, err)
+		return "", 0, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return hash, height, nil
 }
 
-// fetchAccount fetches the account referenced by the provided id. Returns
-// an error if the account is not found.
-func (db *PostgresDB) fetchAccount(id string) (*Account, error) {
-	const funcName = "fetchAccount"
-	var uuid, address string
-	var createdOn uint64
-	err := db.DB.QueryRow(selectAccount, id).Scan(&uuid, &address, &createdOn)
+// fetchPendingPayout retrieves the payout transaction awaiting an external
+// signature. Returns an error if there is none.
+func (m pgMetadata) fetchPendingPayout() (*PendingPayout, error) {
+	const funcName = "fetchPendingPayout"
+	var v string
+	err := m.q.QueryRow(selectPendingPayout).Scan(&v)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no account found for id %s", funcName, id)
+			desc := fmt.Sprintf("%s: no pending payout found", funcName)
 			return nil, errs.DBError(errs.ValueNotFound, desc)
 		}
 
-		desc := fmt.Sprintf("%s: unable to fetch account with id (%s): %v",
-			funcName, id, err)
+		desc := fmt.Sprintf("%s: unable to fetch pending payout: %v",
+			funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
-	return &Account{uuid, address, createdOn}, nil
-}
 
-// deleteAccount purges the referenced account from the database.
-func (db *PostgresDB) deleteAccount(id string) error {
-	const funcName = "deleteAccount"
-	_, err := db.DB.Exec(deleteAccount, id)
+	var payout PendingPayout
+	err = json.Unmarshal([]byte(v), &payout)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to delete account with id (%s): %v",
-			funcName, id, err)
-		return errs.DBError(errs.DeleteEntry, desc)
+		desc := fmt.Sprintf("%s: unable to unmarshal pending payout: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
 	}
-	return nil
+	return &payout, nil
 }
 
-// fetchPayment fetches the payment referenced by the provided id. Returns an
-// error if the payment is not found.
-func (db *PostgresDB) fetchPayment(id string) (*Payment, error) {
-	const funcName = "fetchPayment"
-	var uuid, account, transactionID, sourceBlockHash, sourceCoinbase string
-	var estimatedMaturity, height, paidOnHeight uint32
-	var amount, createdOn int64
-
-	err := db.DB.QueryRow(selectPayment, id).Scan(&uuid, &account, &estimatedMaturity,
-		&height, &amount, &createdOn, &paidOnHeight, &transactionID,
-		&sourceBlockHash, &sourceCoinbase)
+// exportRows streams the rows of the provided query through the provided
+// export func as records of the provided kind.
+func exportRows(q sqlQuerier, fn exportFunc, kind string, query string, scan func(*sql.Rows) (interface{}, error), args ...interface{}) error {
+	const funcName = "exportRows"
+	rows, err := q.Query(query, args...)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no payment found for id %s", funcName, id)
-			return nil, errs.DBError(errs.ValueNotFound, desc)
+		desc := fmt.Sprintf("%s: unable to fetch %s entries: %v", funcName,
+			kind, err)
+		return errs.DBError(errs.FetchEntry, desc)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entity, err := scan(rows)
+		if err != nil {
+			return err
 		}
+		err = fn(kind, entity)
+		if err != nil {
+			return err
+		}
+	}
 
-		desc := fmt.Sprintf("%s: unable to fetch payment with id (%s): %v",
-			funcName, id, err)
-		return nil, errs.DBError(errs.FetchEntry, desc)
+	err = rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode %s entries: %v", funcName,
+			kind, err)
+		return errs.DBError(errs.Decode, desc)
 	}
-	return &Payment{uuid, account, estimatedMaturity, height,
-		dcrutil.Amount(amount), createdOn, paidOnHeight, transactionID,
-		&PaymentSource{sourceBlockHash, sourceCoinbase}}, nil
+
+	return nil
 }
 
-// PersistPayment saves a payment to the database.
-func (db *PostgresDB) PersistPayment(p *Payment) error {
-	const funcName = "PersistPayment"
-	_, err := db.DB.Exec(insertPayment,
-		p.UUID, p.Account, p.EstimatedMaturity, p.Height, p.Amount, p.CreatedOn,
-		p.PaidOnHeight, p.TransactionID, p.Source.BlockHash, p.Source.Coinbase)
+// exportRecords streams the pool metadata, if requested, and every persisted
+// entity through the provided export funcs. All of them are read within a
+// single read-only transaction so the export is a consistent snapshot.
+func (db *PostgresDB) exportRecords(metaFn exportMetadataFunc, fn exportFunc) error {
+	const funcName = "exportRecords"
+	tx, err := db.DB.BeginTx(context.Background(), &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
 	if err != nil {
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				desc := fmt.Sprintf("%s: payment %s already exists", funcName,
-					p.UUID)
-				return errs.DBError(errs.ValueFound, desc)
-			}
+		desc := fmt.Sprintf("%s: unable to begin export tx: %v", funcName, err)
+		return errs.DBError(errs.FetchEntry, desc)
+	}
+
+	// The transaction only reads, it is rolled back once done.
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if metaFn != nil {
+		meta, err := fetchArchiveMetadata(pgMetadata{tx})
+		if err != nil {
+			return err
 		}
+		err = metaFn(meta)
+		if err != nil {
+			return err
+		}
+	}
 
-		desc := fmt.Sprintf("%s: unable to persist payment: %v", funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+	exports := []struct {
+		kind  string
+		query string
+		scan  func(*sql.Rows) (interface{}, error)
+	}{
+		{accountRecord, selectAccounts, func(r *sql.Rows) (interface{}, error) { return scanAccount(r) }},
+		{paymentRecord, selectPayments, func(r *sql.Rows) (interface{}, error) { return scanPayment(r) }},
+		{archivedPaymentRecord, selectArchivedPayments, func(r *sql.Rows) (interface{}, error) { return scanPayment(r) }},
+		{shareRecord, selectShares, func(r *sql.Rows) (interface{}, error) { return scanShare(r) }},
+		{acceptedWorkRecord, selectMinedWork, func(r *sql.Rows) (interface{}, error) { return scanWork(r) }},
+		{jobRecord, selectJobs, func(r *sql.Rows) (interface{}, error) { return scanJob(r) }},
+		{hashDataRecord, selectAllHashData, func(r *sql.Rows) (interface{}, error) { return scanHashData(r) }},
+		{apiTokenRecord, selectAPITokens, func(r *sql.Rows) (interface{}, error) { return scanAPIToken(r) }},
+		{adminUserRecord, listAdminUsers, func(r *sql.Rows) (interface{}, error) { return scanAdminUser(r) }},
+		{banRecord, listBans, func(r *sql.Rows) (interface{}, error) { return scanBan(r) }},
+		{blockEventRecord, selectBlockEvents, func(r *sql.Rows) (interface{}, error) { return scanBlockEvent(r) }},
+		{webhookRecord, selectWebhooks, func(r *sql.Rows) (interface{}, error) { return scanWebhook(r) }},
+		{webhookDeliveryRecord, selectWebhookDeliveries, func(r *sql.Rows) (interface{}, error) { return scanWebhookDelivery(r) }},
+		{workerRecord, selectWorkers, func(r *sql.Rows) (interface{}, error) { return scanWorker(r) }},
+		{workerEventRecord, selectWorkerEvents, func(r *sql.Rows) (interface{}, error) { return scanWorkerEvent(r) }},
+	}
+
+	for _, export := range exports {
+		err := exportRows(tx, fn, export.kind, export.query, export.scan)
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+
+	err = exportRows(tx, fn, auditEntryRecord, selectAuditEntries,
+		func(r *sql.Rows) (interface{}, error) { return scanAuditEntry(r) },
+		int64(math.MaxInt64))
+	if err != nil {
+		return err
+	}
+
+	return exportRows(tx, fn, ledgerEntryRecord, selectLedgerEntries,
+		func(r *sql.Rows) (interface{}, error) { return scanLedgerEntry(r) },
+		"", int64(math.MaxInt64))
 }
 
-// updatePayment persists the updated payment to the database.
-func (db *PostgresDB) updatePayment(p *Payment) error {
-	const funcName = "updatePayment"
-	_, err := db.DB.Exec(updatePayment,
-		p.UUID, p.Account, p.EstimatedMaturity, p.Height, p.Amount, p.CreatedOn,
-		p.PaidOnHeight, p.TransactionID, p.Source.BlockHash, p.Source.Coinbase)
+// importRecords persists the provided archive records within a single
+// transaction. Returns an error if any of the entities already exists.
+func (db *PostgresDB) importRecords(records []*archiveRecord) error {
+	const funcName = "importRecords"
+
+	tx, err := db.DB.Begin()
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to update payment with id (%s): %v",
-			funcName, p.UUID, err)
+		desc := fmt.Sprintf("%s: unable to begin import tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	for _, record := range records {
+		entity, id, err := decodeArchiveRecord(record)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		switch e := entity.(type) {
+		case *Account:
+			_, err = tx.Exec(insertAccount, e.UUID, e.Address, e.CreatedOn)
+		case *Payment:
+			query := insertPayment
+			if record.Kind == archivedPaymentRecord {
+				query = insertArchivedPayment
+			}
+			var source PaymentSource
+			if e.Source != nil {
+				source = *e.Source
+			}
+			_, err = tx.Exec(query, e.UUID, e.Account, e.EstimatedMaturity,
+				e.Height, int64(e.Amount), e.CreatedOn, e.PaidOnHeight,
+				e.TransactionID, source.BlockHash, source.Coinbase)
+		case *Share:
+			_, err = tx.Exec(insertShare, e.UUID, e.Account,
+				e.Weight.RatString(), e.CreatedOn)
+		case *AcceptedWork:
+			_, err = tx.Exec(insertAcceptedWork, e.UUID, e.BlockHash,
+				e.PrevHash, e.Height, e.MinedBy, e.Miner, e.CreatedOn,
+				e.Confirmed, e.Status, e.OrphanedBy)
+		case *Job:
+			_, err = tx.Exec(insertJob, e.UUID, e.Height, e.Header)
+		case *HashData:
+			_, err = tx.Exec(insertHashData, e.UUID, e.AccountID, e.Miner,
+				e.IP, e.HashRate.RatString(), e.UpdatedOn)
+		case *APIToken:
+			_, err = tx.Exec(insertAPIToken, e.UUID, e.AccountID, e.Kind,
+				e.CreatedOn)
+		case *AdminUser:
+			_, err = tx.Exec(insertAdminUser, e.UUID, e.PasswordHash, e.Role,
+				e.TOTPSecret, e.CreatedOn)
+		case *AuditEntry:
+			_, err = tx.Exec(insertAuditEntry, e.UUID, e.Actor, e.Action,
+				e.Details, e.IP, e.CreatedOn)
+		case *Ban:
+			_, err = tx.Exec(importBan, e.UUID, e.Kind, e.Reason, e.CreatedOn)
+		case *LedgerEntry:
+			_, err = tx.Exec(insertLedgerEntry, e.UUID, e.Kind, e.Debit,
+				e.Credit, int64(e.Amount), e.BlockHash, e.TxID, e.Actor,
+				e.Memo, e.CreatedOn)
+		case *BlockEvent:
+			_, err = tx.Exec(insertBlockEvent, e.UUID, e.Kind, e.Header,
+				e.Height, e.Attempts, e.LastError, e.NextAttempt, e.CreatedOn)
+		case *Webhook:
+			_, err = tx.Exec(insertWebhook, e.UUID, e.AccountID, e.URL,
+				e.Secret, e.CreatedOn)
+		case *WebhookDelivery:
+			_, err = tx.Exec(insertWebhookDelivery, e.UUID, e.WebhookID, e.URL,
+				e.Event, e.Payload, e.Attempts, e.LastError, e.NextAttempt,
+				e.CreatedOn)
+		case *Worker:
+			_, err = tx.Exec(insertWorker, e.UUID, e.AccountID, e.Name,
+				e.Miner, e.HashRate, e.Baseline, e.Samples, e.Status,
+				e.StatusSince, e.LastSeen, e.CreatedOn)
+		case *WorkerEvent:
+			_, err = tx.Exec(insertWorkerEvent, e.UUID, e.AccountID, e.Worker,
+				e.Kind, e.HashRate, e.Baseline, e.LastSeen, e.CreatedOn)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			if isUniqueViolation(err) {
+				desc := fmt.Sprintf("%s: %s %s already exists", funcName,
+					record.Kind, id)
+				return errs.DBError(errs.ValueFound, desc)
+			}
+			desc := fmt.Sprintf("%s: unable to persist %s %s: %v", funcName,
+				record.Kind, id, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit import tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// httpBackup streams an archive of the entire database over the provided
+// HTTP response writer.
+func (db *PostgresDB) httpBackup(w http.ResponseWriter) error {
+	return serveArchive(db, w)
+}
+
+// Backup saves an archive of the db to the provided file path.
+func (db *PostgresDB) Backup(fileName string) error {
+	return writeArchiveFile(db, fileName)
+}
+
+// fetchPoolMode retrives the pool mode from the database. PoolMode is stored as
+// a uint32 for historical reasons. 0 indicates Public, 1 indicates Solo.
+func (db *PostgresDB) fetchPoolMode() (uint32, error) {
+	return pgMetadata{db.DB}.fetchPoolMode()
+}
+
+// persistPoolMode stores the pool mode in the database. PoolMode is stored as a
+// uint32 for historical reasons. 0 indicates Public, 1 indicates Solo.
+func (db *PostgresDB) persistPoolMode(mode uint32) error {
+	const funcName = "persistPoolMode"
+	_, err := db.DB.Exec(insertPoolMode, mode)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist pool mode: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// fetchCSRFSecret retrieves the bytes used for the CSRF secret from the database.
+func (db *PostgresDB) fetchCSRFSecret() ([]byte, error) {
+	return pgMetadata{db.DB}.fetchCSRFSecret()
+}
+
+// persistCSRFSecret stores the bytes used for the CSRF secret in the database.
+func (db *PostgresDB) persistCSRFSecret(secret []byte) error {
+	const funcName = "persistCSRFSecret"
+	_, err := db.DB.Exec(insertCSRFSecret, hex.EncodeToString(secret))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist CSRF secrets: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return err
+}
+
+// persistLastPaymentInfo stores the last payment height and paidOn timestamp
+// in the database.
+func (db *PostgresDB) persistLastPaymentInfo(height uint32, paidOn int64) error {
+	const funcName = "persistLastPaymentInfo"
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(insertLastPaymentHeight, height)
+	if err != nil {
+		rErr := tx.Rollback()
+		if rErr != nil {
+			desc := fmt.Sprintf("%s: unable to rollback persisting last "+
+				"payment height tx: %v, initial error: %v", funcName,
+				rErr, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist last payment height: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	_, err = tx.Exec(insertLastPaymentPaidOn, paidOn)
+	if err != nil {
+		rErr := tx.Rollback()
+		if rErr != nil {
+			desc := fmt.Sprintf("%s: unable to rollback persist last payment "+
+				"paid on time tx: %v, initial error: %v", funcName,
+				rErr, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist last payment paid on "+
+			"time: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	return tx.Commit()
+}
+
+// loadLastPaymentInfo retrieves the last payment height and paidOn timestamp
+// from the database.
+func (db *PostgresDB) loadLastPaymentInfo() (uint32, int64, error) {
+	return pgMetadata{db.DB}.loadLastPaymentInfo()
+}
+
+// persistLastPaymentCreatedOn stores the last payment createdOn timestamp in
+// the database.
+func (db *PostgresDB) persistLastPaymentCreatedOn(createdOn int64) error {
+	const funcName = "persistLastPaymentCreatedOn"
+	_, err := db.DB.Exec(insertLastPaymentCreatedOn, createdOn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist last payment created "+
+			"on time: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// loadLastPaymentCreatedOn retrieves the last payment createdOn timestamp from
+// the database.
+func (db *PostgresDB) loadLastPaymentCreatedOn() (int64, error) {
+	return pgMetadata{db.DB}.loadLastPaymentCreatedOn()
+}
+
+// persistChainTip stores the hash and height of the last processed chain
+// tip in the database.
+func (db *PostgresDB) persistChainTip(hash string, height uint32) error {
+	const funcName = "persistChainTip"
+	tx, err := db.DB.Begin()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin chain tip tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	_, err = tx.Exec(insertChainTipHash, hash)
+	if err != nil {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: unable to persist chain tip hash: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	_, err = tx.Exec(insertChainTipHeight, height)
+	if err != nil {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: unable to persist chain tip height: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit chain tip tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// loadChainTip retrieves the hash and height of the last processed chain
+// tip from the database.
+func (db *PostgresDB) loadChainTip() (string, uint32, error) {
+	return pgMetadata{db.DB}.loadChainTip()
+}
+
+// persistPendingPayout stores the payout transaction awaiting an external
+// signature, replacing any existing one.
+func (db *PostgresDB) persistPendingPayout(payout *PendingPayout) error {
+	const funcName = "persistPendingPayout"
+	b, err := json.Marshal(payout)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to marshal pending payout: %v",
+			funcName, err)
+		return errs.DBError(errs.Parse, desc)
+	}
+	_, err = db.DB.Exec(insertPendingPayout, string(b))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist pending payout: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// fetchPendingPayout retrieves the payout transaction awaiting an external
+// signature. Returns an error if there is none.
+func (db *PostgresDB) fetchPendingPayout() (*PendingPayout, error) {
+	return pgMetadata{db.DB}.fetchPendingPayout()
+}
+
+// deletePendingPayout removes the payout transaction awaiting an external
+// signature. Deleting a pending payout which does not exist is a no-op.
+func (db *PostgresDB) deletePendingPayout() error {
+	const funcName = "deletePendingPayout"
+	_, err := db.DB.Exec(deletePendingPayout)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete pending payout: %v",
+			funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// persistAccount saves the account to the database. Before persisting the
+// account, it sets the createdOn timestamp. Returns an error if an account
+// already exists with the same ID.
+func (db *PostgresDB) persistAccount(acc *Account) error {
+	const funcName = "persistAccount"
+	acc.CreatedOn = uint64(time.Now().Unix())
+	_, err := db.DB.Exec(insertAccount, acc.UUID, acc.Address, acc.CreatedOn)
+	if err != nil {
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: account %s already exists", funcName,
+				acc.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist account: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// fetchAccount fetches the account referenced by the provided id. Returns
+// an error if the account is not found.
+func (db *PostgresDB) fetchAccount(id string) (*Account, error) {
+	const funcName = "fetchAccount"
+	var uuid, address string
+	var createdOn uint64
+	err := db.DB.QueryRow(selectAccount, id).Scan(&uuid, &address, &createdOn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no account found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch account with id (%s): %v",
+			funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+	return &Account{uuid, address, createdOn}, nil
+}
+
+// deleteAccount purges the referenced account from the database.
+func (db *PostgresDB) deleteAccount(id string) error {
+	const funcName = "deleteAccount"
+	_, err := db.DB.Exec(deleteAccount, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete account with id (%s): %v",
+			funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// fetchPayment fetches the payment referenced by the provided id. Returns an
+// error if the payment is not found.
+func (db *PostgresDB) fetchPayment(id string) (*Payment, error) {
+	const funcName = "fetchPayment"
+	var uuid, account, transactionID, sourceBlockHash, sourceCoinbase string
+	var estimatedMaturity, height, paidOnHeight uint32
+	var amount, createdOn int64
+
+	err := db.DB.QueryRow(selectPayment, id).Scan(&uuid, &account, &estimatedMaturity,
+		&height, &amount, &createdOn, &paidOnHeight, &transactionID,
+		&sourceBlockHash, &sourceCoinbase)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no payment found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch payment with id (%s): %v",
+			funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+	return &Payment{uuid, account, estimatedMaturity, height,
+		dcrutil.Amount(amount), createdOn, paidOnHeight, transactionID,
+		&PaymentSource{sourceBlockHash, sourceCoinbase}}, nil
+}
+
+// PersistPayment saves a payment to the database.
+func (db *PostgresDB) PersistPayment(p *Payment) error {
+	const funcName = "PersistPayment"
+	_, err := db.DB.Exec(insertPayment,
+		p.UUID, p.Account, p.EstimatedMaturity, p.Height, p.Amount, p.CreatedOn,
+		p.PaidOnHeight, p.TransactionID, p.Source.BlockHash, p.Source.Coinbase)
+	if err != nil {
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: payment %s already exists", funcName,
+				p.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist payment: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// persistPayments saves the provided payments of a block reward along with
+// the ledger entries booking them within a single transaction. The last
+// payment created-on time is updated and shares created before the provided
+// time are pruned in the same transaction. Returns an error if any of the
+// payments or entries already exists, in which case none of them are
+// persisted.
+func (db *PostgresDB) persistPayments(payments []*Payment, entries []*LedgerEntry, lastPmtCreatedOn int64, pruneSharesBefore int64) error {
+	const funcName = "persistPayments"
+	tx, err := db.DB.Begin()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin payments tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	for _, p := range payments {
+		_, err := tx.Exec(insertPayment,
+			p.UUID, p.Account, p.EstimatedMaturity, p.Height, p.Amount,
+			p.CreatedOn, p.PaidOnHeight, p.TransactionID,
+			p.Source.BlockHash, p.Source.Coinbase)
+		if err != nil {
+			_ = tx.Rollback()
+			if isUniqueViolation(err) {
+				desc := fmt.Sprintf("%s: payment %s already exists",
+					funcName, p.UUID)
+				return errs.DBError(errs.ValueFound, desc)
+			}
+			desc := fmt.Sprintf("%s: unable to persist payment: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+
+	err = insertLedgerEntries(tx, funcName, entries)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(insertLastPaymentCreatedOn, lastPmtCreatedOn)
+	if err != nil {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: unable to persist last payment created "+
+			"on time: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	_, err = tx.Exec(deleteShareCreatedBefore, pruneSharesBefore)
+	if err != nil {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: unable to prune shares: %v", funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit payments tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// updatePayment persists the updated payment to the database. Updating a
+// payment which does not exist is a no-op.
+func (db *PostgresDB) updatePayment(p *Payment) error {
+	const funcName = "updatePayment"
+	_, err := db.DB.Exec(updatePayment,
+		p.UUID, p.Account, p.EstimatedMaturity, p.Height, p.Amount, p.CreatedOn,
+		p.PaidOnHeight, p.TransactionID, p.Source.BlockHash, p.Source.Coinbase)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to update payment with id (%s): %v",
+			funcName, p.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// deletePayment purges the referenced payment from the database. Note that
+// archived payments cannot be deleted.
+func (db *PostgresDB) deletePayment(id string) error {
+	const funcName = "deletePayment"
+	_, err := db.DB.Exec(deletePayment, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete payment with id (%s): %v",
+			funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// ArchivePayment removes the associated payment from active payments and archives it.
+func (db *PostgresDB) ArchivePayment(p *Payment) error {
+	const funcName = "ArchivePayment"
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin payment archive tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	_, err = tx.Exec(deletePayment, p.UUID)
+	if err != nil {
+		rErr := tx.Rollback()
+		if rErr != nil {
+			desc := fmt.Sprintf("%s: unable to rollback payment deletion "+
+				"tx %v, initial error: %v", funcName, rErr, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to delete payment: %v", funcName, rErr)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+
+	aPmt := NewPayment(p.Account, p.Source, p.Amount, p.Height,
+		p.EstimatedMaturity)
+	aPmt.PaidOnHeight = p.PaidOnHeight
+	aPmt.TransactionID = p.TransactionID
+
+	_, err = tx.Exec(insertArchivedPayment,
+		aPmt.UUID, aPmt.Account, aPmt.EstimatedMaturity, aPmt.Height, aPmt.Amount,
+		aPmt.CreatedOn, aPmt.PaidOnHeight, aPmt.TransactionID, aPmt.Source.BlockHash,
+		p.Source.Coinbase)
+	if err != nil {
+		rErr := tx.Rollback()
+		if rErr != nil {
+			desc := fmt.Sprintf("%s: unable to rollback archived payment "+
+				"tx: %v, initial error: %v", funcName, rErr, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to archive payment: %v", funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit archived payment tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// archivePayout archives the provided payments paid by a payout, books the
+// provided ledger entries of the payout, records the last payment info and
+// removes the pending payout within a single transaction. Returns an error
+// if any of the entries already exists, in which case nothing is persisted.
+func (db *PostgresDB) archivePayout(payments []*Payment, entries []*LedgerEntry, height uint32, paidOn int64) error {
+	const funcName = "archivePayout"
+	tx, err := db.DB.Begin()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin payout tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	for _, p := range payments {
+		_, err := tx.Exec(deletePayment, p.UUID)
+		if err != nil {
+			_ = tx.Rollback()
+			desc := fmt.Sprintf("%s: unable to delete payment: %v",
+				funcName, err)
+			return errs.DBError(errs.DeleteEntry, desc)
+		}
+
+		aPmt := NewPayment(p.Account, p.Source, p.Amount, p.Height,
+			p.EstimatedMaturity)
+		aPmt.PaidOnHeight = p.PaidOnHeight
+		aPmt.TransactionID = p.TransactionID
+		_, err = tx.Exec(insertArchivedPayment,
+			aPmt.UUID, aPmt.Account, aPmt.EstimatedMaturity, aPmt.Height,
+			aPmt.Amount, aPmt.CreatedOn, aPmt.PaidOnHeight,
+			aPmt.TransactionID, aPmt.Source.BlockHash, aPmt.Source.Coinbase)
+		if err != nil {
+			_ = tx.Rollback()
+			desc := fmt.Sprintf("%s: unable to archive payment: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+	}
+
+	err = insertLedgerEntries(tx, funcName, entries)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(insertLastPaymentHeight, height)
+	if err == nil {
+		_, err = tx.Exec(insertLastPaymentPaidOn, paidOn)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: unable to persist last payment info: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	_, err = tx.Exec(deletePendingPayout)
+	if err != nil {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: unable to delete pending payout: %v",
+			funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit payout tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// fetchPaymentsAtHeight returns all payments sourcing from orphaned blocks at
+// the provided height.
+func (db *PostgresDB) fetchPaymentsAtHeight(height uint32) ([]*Payment, error) {
+	const funcName = "fetchPaymentsAtHeight"
+	rows, err := db.DB.Query(selectPaymentsAtHeight, height)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch payments at height %d: %v",
+			funcName, height, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodePaymentRows(rows)
+}
+
+// fetchPendingPayments fetches all unpaid payments.
+func (db *PostgresDB) fetchPendingPayments() ([]*Payment, error) {
+	const funcName = "fetchPendingPayments"
+	rows, err := db.DB.Query(selectPendingPayments)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch pending payments: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodePaymentRows(rows)
+}
+
+// pendingPaymentsForBlockHash returns the number of pending payments with the
+// provided block hash as their source.
+func (db *PostgresDB) pendingPaymentsForBlockHash(blockHash string) (uint32, error) {
+	const funcName = "pendingPaymentsForBlockHash"
+	var count uint32
+	err := db.DB.QueryRow(countPaymentsAtBlockHash, blockHash).Scan(&count)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch pending payments for "+
+			"blockhash (%s): %v", funcName, blockHash, err)
+		return 0, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return count, nil
+}
+
+// archivedPaymentsForBlockHash returns the number of archived payments with
+// the provided block hash as their source.
+func (db *PostgresDB) archivedPaymentsForBlockHash(blockHash string) (uint32, error) {
+	const funcName = "archivedPaymentsForBlockHash"
+	var count uint32
+	err := db.DB.QueryRow(countArchivedPaymentsAtBlockHash, blockHash).
+		Scan(&count)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch archived payments for "+
+			"blockhash (%s): %v", funcName, blockHash, err)
+		return 0, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return count, nil
+}
+
+// archivedPayments fetches all archived payments. List is ordered, most
+// recent comes first.
+func (db *PostgresDB) archivedPayments() ([]*Payment, error) {
+	const funcName = "archivedPayments"
+	rows, err := db.DB.Query(selectArchivedPayments)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch archived payments: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodePaymentRows(rows)
+}
+
+// maturePendingPayments fetches all mature pending payments at the
+// provided height.
+func (db *PostgresDB) maturePendingPayments(height uint32) (map[string][]*Payment, error) {
+	const funcName = "maturePendingPayments"
+	rows, err := db.DB.Query(selectMaturePendingPayments, height)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch mature pending payments: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	payments, err := decodePaymentRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	pmts := make(map[string][]*Payment)
+	for _, pmt := range payments {
+		set, ok := pmts[pmt.Source.BlockHash]
+		if !ok {
+			set = make([]*Payment, 0)
+		}
+		set = append(set, pmt)
+		pmts[pmt.Source.BlockHash] = set
+	}
+
+	return pmts, nil
+}
+
+// fetchShare fetches the share referenced by the provided id. Returns an error
+// if the share is not found.
+func (db *PostgresDB) fetchShare(id string) (*Share, error) {
+	const funcName = "fetchShare"
+	var uuid, account, weight string
+	var createdOn int64
+	err := db.DB.QueryRow(selectShare, id).Scan(&uuid, &account, &weight, &createdOn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no share found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch share with id (%s): %v",
+			funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	weightRat, ok := new(big.Rat).SetString(weight)
+	if !ok {
+		desc := fmt.Sprintf("%s: unable to decode big.Rat string: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+
+	return &Share{uuid, account, weightRat, createdOn}, nil
+}
+
+// PersistShare saves a share to the database. Returns an error if a share
+// already exists with the same ID.
+func (db *PostgresDB) PersistShare(share *Share) error {
+	const funcName = "PersistShare"
+
+	_, err := db.DB.Exec(insertShare, share.UUID, share.Account,
+		share.Weight.RatString(), share.CreatedOn)
+	if err != nil {
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: share %s already exists", funcName,
+				share.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist share: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// aggregateShare adds the weight of the provided share to the persisted
+// share with the same ID, persisting the share if it does not exist yet.
+func (db *PostgresDB) aggregateShare(share *Share) error {
+	err := db.aggregateShareTx(share)
+	if isUniqueViolation(err) {
+		// A concurrent aggregation persisted the share first, the retry
+		// adds to it instead.
+		err = db.aggregateShareTx(share)
+	}
+	if err != nil {
+		var dbErr errs.Error
+		if errors.As(err, &dbErr) {
+			return err
+		}
+
+		desc := fmt.Sprintf("aggregateShare: unable to aggregate share: %v",
+			err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// aggregateShareTx adds the weight of the provided share to the persisted
+// share with the same ID in a single transaction.
+func (db *PostgresDB) aggregateShareTx(share *Share) error {
+	const funcName = "aggregateShare"
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	var weight string
+	err = tx.QueryRow(selectShareWeightForUpdate, share.UUID).Scan(&weight)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.Exec(insertShare, share.UUID, share.Account,
+			share.Weight.RatString(), share.CreatedOn)
+
+	case err == nil:
+		sum, ok := new(big.Rat).SetString(weight)
+		if !ok {
+			_ = tx.Rollback()
+			desc := fmt.Sprintf("%s: unable to decode weight string: %v",
+				funcName, weight)
+			return errs.DBError(errs.Parse, desc)
+		}
+		sum.Add(sum, share.Weight)
+		_, err = tx.Exec(updateShareWeight, share.UUID, sum.RatString())
+	}
+	if err != nil {
+		rErr := tx.Rollback()
+		if rErr != nil {
+			desc := fmt.Sprintf("%s: unable to rollback aggregate share "+
+				"tx: %v, initial error: %v", funcName, rErr, err)
+			return errs.DBError(errs.PersistEntry, desc)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ppsEligibleShares fetches all shares created before or at the provided time.
+func (db *PostgresDB) ppsEligibleShares(max int64) ([]*Share, error) {
+	const funcName = "ppsEligibleShares"
+	rows, err := db.DB.Query(selectSharesOnOrBeforeTime, max)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch PPS eligible shares: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodeShareRows(rows)
+}
+
+// pplnsEligibleShares fetches all shares created after the provided time.
+func (db *PostgresDB) pplnsEligibleShares(min int64) ([]*Share, error) {
+	const funcName = "pplnsEligibleShares"
+	rows, err := db.DB.Query(selectSharesAfterTime, min)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch PPLNS eligible shares: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodeShareRows(rows)
+}
+
+// pruneShares removes shares with a createdOn time earlier than the provided
+// time.
+func (db *PostgresDB) pruneShares(minNano int64) error {
+	const funcName = "pruneShares"
+	_, err := db.DB.Exec(deleteShareCreatedBefore, minNano)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to prune shares: %v", funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// fetchAcceptedWork fetches the accepted work referenced by the provided id.
+// Returns an error if the work is not found.
+func (db *PostgresDB) fetchAcceptedWork(id string) (*AcceptedWork, error) {
+	const funcName = "fetchAcceptedWork"
+
+	var uuid, blockhash, prevhash, minedby, miner, status, orphanedBy string
+	var confirmed bool
+	var height uint32
+	var createdOn int64
+	err := db.DB.QueryRow(selectAcceptedWork, id).Scan(&uuid, &blockhash, &prevhash, &height,
+		&minedby, &miner, &createdOn, &confirmed, &status, &orphanedBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no work found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch accepted work with id "+
+			"(%s): %v", funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return &AcceptedWork{uuid, blockhash, prevhash, height,
+		minedby, miner, createdOn, confirmed, status, orphanedBy}, nil
+}
+
+// persistAcceptedWork saves the accepted work to the database.
+func (db *PostgresDB) persistAcceptedWork(work *AcceptedWork) error {
+	const funcName = "persistAcceptedWork"
+
+	_, err := db.DB.Exec(insertAcceptedWork, work.UUID, work.BlockHash, work.PrevHash,
+		work.Height, work.MinedBy, work.Miner, work.CreatedOn, work.Confirmed,
+		work.Status, work.OrphanedBy)
+	if err != nil {
+
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: work %s already exists", funcName,
+				work.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist accepted work: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// updateAcceptedWork persists modifications to an existing work. Returns an
+// error if the work is not found.
+func (db *PostgresDB) updateAcceptedWork(work *AcceptedWork) error {
+	const funcName = "updateAcceptedWork"
+
+	result, err := db.DB.Exec(updateAcceptedWork,
+		work.UUID, work.BlockHash, work.PrevHash,
+		work.Height, work.MinedBy, work.Miner, work.CreatedOn, work.Confirmed,
+		work.Status, work.OrphanedBy)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to update accepted work with id "+
+			"(%s): %v", funcName, work.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	if rowsAffected == 0 {
+		desc := fmt.Sprintf("%s: work %s not found", funcName, work.UUID)
+		return errs.DBError(errs.ValueNotFound, desc)
+	}
+
+	return nil
+}
+
+// deleteAcceptedWork removes the associated accepted work from the database.
+func (db *PostgresDB) deleteAcceptedWork(id string) error {
+	const funcName = "deleteAcceptedWork"
+	_, err := db.DB.Exec(deleteAcceptedWork, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete account work with id "+
+			"(%s): %v", funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// listMinedWork returns work data associated with all blocks mined by the pool
+// regardless of whether they are confirmed or not.
+//
+// List is ordered, most recent comes first.
+func (db *PostgresDB) listMinedWork() ([]*AcceptedWork, error) {
+	const funcName = "listMinedWork"
+	rows, err := db.DB.Query(selectMinedWork)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch mined work: %v", funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodeWorkRows(rows)
+}
+
+// fetchUnconfirmedWork returns all work which is neither confirmed as mined
+// nor orphaned with height less than the provided height.
+func (db *PostgresDB) fetchUnconfirmedWork(height uint32) ([]*AcceptedWork, error) {
+	const funcName = "fetchUnconfirmedWork"
+	rows, err := db.DB.Query(selectUnconfirmedWork, height)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch unconfirmed work: %v", funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return decodeWorkRows(rows)
+}
+
+// fetchJob fetches the job referenced by the provided id. Returns an error if
+// the job is not found.
+func (db *PostgresDB) fetchJob(id string) (*Job, error) {
+	const funcName = "fetchJob"
+	var uuid, header string
+	var height uint32
+	err := db.DB.QueryRow(selectJob, id).Scan(&uuid, &header, &height)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no job found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch job with id (%s): %v",
+			funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+	return &Job{uuid, height, header}, nil
+}
+
+// persistJob saves the job to the database. Returns an error if an account
+// already exists with the same ID.
+func (db *PostgresDB) persistJob(job *Job) error {
+	const funcName = "persistJob"
+
+	_, err := db.DB.Exec(insertJob, job.UUID, job.Height, job.Header)
+	if err != nil {
+
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: job %s already exists", funcName,
+				job.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist job: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// deleteJob removes the associated job from the database.
+func (db *PostgresDB) deleteJob(id string) error {
+	const funcName = "deleteJob"
+	_, err := db.DB.Exec(deleteJob, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete job entry with id "+
+			"(%s): %v", funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// deleteJobsBeforeHeight removes all jobs with heights less than the provided
+// height.
+func (db *PostgresDB) deleteJobsBeforeHeight(height uint32) error {
+	const funcName = "deleteJobsBeforeHeight"
+	_, err := db.DB.Exec(deleteJobBeforeHeight, height)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete jobs before height %d: %v",
+			funcName, height, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return err
+}
+
+// persistHashData saves the provided hash data to the database.
+func (db *PostgresDB) persistHashData(hashData *HashData) error {
+	const funcName = "persistHashData"
+
+	_, err := db.DB.Exec(insertHashData, hashData.UUID, hashData.AccountID,
+		hashData.Miner, hashData.IP, hashData.HashRate.RatString(),
+		hashData.UpdatedOn)
+	if err != nil {
+
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: hash rate %s already exists", funcName,
+				hashData.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist hash rate: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// updateHashData persists the updated hash data to the database.
+func (db *PostgresDB) updateHashData(hashData *HashData) error {
+	const funcName = "updateHashData"
+
+	result, err := db.DB.Exec(updateHashData,
+		hashData.UUID, hashData.AccountID, hashData.Miner,
+		hashData.IP, hashData.HashRate.RatString(), hashData.UpdatedOn)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to update accepted hash data with id "+
+			"(%s): %v", funcName, hashData.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	if rowsAffected == 0 {
+		desc := fmt.Sprintf("%s: hash data %s not found", funcName, hashData.UUID)
+		return errs.DBError(errs.ValueNotFound, desc)
+	}
+
+	return nil
+}
+
+// fetchHashData fetches the hash data associated with the provided id.
+func (db *PostgresDB) fetchHashData(id string) (*HashData, error) {
+	const funcName = "fetchHashData"
+	var uuid, accountID, miner, ip, hashRate string
+	var updatedOn int64
+	err := db.DB.QueryRow(selectHashData, id).Scan(&uuid, &accountID, &miner,
+		&ip, &hashRate, &updatedOn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no hash data found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch hash data with id (%s): %v",
+			funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	hashRat, ok := new(big.Rat).SetString(hashRate)
+	if !ok {
+		desc := fmt.Sprintf("%s: unable to decode big.Rat string: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Parse, desc)
+	}
+
+	return &HashData{uuid, accountID, miner, ip, hashRat, updatedOn}, nil
+}
+
+// listHashData fetches all hash data updated after the provided minimum time.
+func (db *PostgresDB) listHashData(minNano int64) (map[string]*HashData, error) {
+	const funcName = "listHashData"
+	rows, err := db.DB.Query(listHashData, minNano)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to list hash data: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	hashData, err := decodeHashDataRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashData, nil
+}
+
+// pruneHashData prunes all hash data that have not been updated since
+// the provided minimum time.
+func (db *PostgresDB) pruneHashData(minNano int64) error {
+	const funcName = "pruneHashData"
+
+	_, err := db.DB.Exec(pruneHashData, minNano)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to prune hash data: %v", funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+
+	return nil
+}
+
+// persistAPIToken saves the provided api token to the database.
+func (db *PostgresDB) persistAPIToken(token *APIToken) error {
+	const funcName = "persistAPIToken"
+
+	_, err := db.DB.Exec(insertAPIToken, token.UUID, token.AccountID,
+		token.Kind, token.CreatedOn)
+	if err != nil {
+
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: api token %s already exists", funcName,
+				token.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist api token: %v", funcName, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 	return nil
 }
 
-// deletePayment purges the referenced payment from the database. Note that
-// archived payments cannot be deleted.
-func (db *PostgresDB) deletePayment(id string) error {
-	const funcName = "deletePayment"
-	_, err := db.DB.Exec(deletePayment, id)
+// fetchAPIToken fetches the api token associated with the provided id.
+func (db *PostgresDB) fetchAPIToken(id string) (*APIToken, error) {
+	const funcName = "fetchAPIToken"
+	var uuid, accountID, kind string
+	var createdOn int64
+	err := db.DB.QueryRow(selectAPIToken, id).Scan(&uuid, &accountID, &kind,
+		&createdOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to delete payment with id (%s): %v",
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no api token found for id %s", funcName, id)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch api token with id (%s): %v",
+			funcName, id, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	return &APIToken{uuid, accountID, kind, createdOn}, nil
+}
+
+// deleteAPIToken purges the referenced api token from the database.
+func (db *PostgresDB) deleteAPIToken(id string) error {
+	const funcName = "deleteAPIToken"
+	_, err := db.DB.Exec(deleteAPIToken, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete api token with id (%s): %v",
 			funcName, id, err)
 		return errs.DBError(errs.DeleteEntry, desc)
 	}
 	return nil
 }
 
-// ArchivePayment removes the associated payment from active payments and archives it.
-func (db *PostgresDB) ArchivePayment(p *Payment) error {
-	const funcName = "ArchivePayment"
-
-	tx, err := db.DB.Begin()
+// fetchAPITokensForAccount fetches all api tokens of the provided account.
+// List is ordered, oldest first.
+func (db *PostgresDB) fetchAPITokensForAccount(accountID string) ([]*APIToken, error) {
+	const funcName = "fetchAPITokensForAccount"
+	rows, err := db.DB.Query(selectAPITokensForAccount, accountID)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to begin payment archive tx: %v",
+		desc := fmt.Sprintf("%s: unable to fetch api tokens: %v",
 			funcName, err)
-		return errs.DBError(errs.PersistEntry, desc)
+		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
 
-	_, err = tx.Exec(deletePayment, p.UUID)
+	tokens, err := decodeAPITokenRows(rows)
 	if err != nil {
-		rErr := tx.Rollback()
-		if rErr != nil {
-			desc := fmt.Sprintf("%s: unable to rollback payment deletion "+
-				"tx %v, initial error: %v", funcName, rErr, err)
-			return errs.DBError(errs.PersistEntry, desc)
-		}
-
-		desc := fmt.Sprintf("%s: unable to delete payment: %v", funcName, rErr)
-		return errs.DBError(errs.DeleteEntry, desc)
+		return nil, err
 	}
 
-	aPmt := NewPayment(p.Account, p.Source, p.Amount, p.Height,
-		p.EstimatedMaturity)
+	return tokens, nil
+}
 
-	_, err = tx.Exec(insertArchivedPayment,
-		aPmt.UUID, aPmt.Account, aPmt.EstimatedMaturity, aPmt.Height, aPmt.Amount,
-		aPmt.CreatedOn, aPmt.PaidOnHeight, aPmt.TransactionID, aPmt.Source.BlockHash,
-		p.Source.Coinbase)
+// persistAdminUser saves the provided admin user to the database.
+func (db *PostgresDB) persistAdminUser(user *AdminUser) error {
+	const funcName = "persistAdminUser"
+
+	_, err := db.DB.Exec(insertAdminUser, user.UUID, user.PasswordHash,
+		user.Role, user.TOTPSecret, user.CreatedOn)
 	if err != nil {
-		rErr := tx.Rollback()
-		if rErr != nil {
-			desc := fmt.Sprintf("%s: unable to rollback archived payment "+
-				"tx: %v, initial error: %v", funcName, rErr, err)
-			return errs.DBError(errs.PersistEntry, desc)
+
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: admin user %s already exists",
+				funcName, user.UUID)
+			return errs.DBError(errs.ValueFound, desc)
 		}
 
-		desc := fmt.Sprintf("%s: unable to archive payment: %v", funcName, err)
-		return errs.DBError(errs.DeleteEntry, desc)
+		desc := fmt.Sprintf("%s: unable to persist admin user: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
 	}
+	return nil
+}
 
-	err = tx.Commit()
+// updateAdminUser persists the updated admin user to the database.
+func (db *PostgresDB) updateAdminUser(user *AdminUser) error {
+	const funcName = "updateAdminUser"
+
+	result, err := db.DB.Exec(updateAdminUser, user.UUID, user.PasswordHash,
+		user.Role, user.TOTPSecret, user.CreatedOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to commit archived payment tx: %v",
-			funcName, err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to update admin user with id "+
+			"(%s): %v", funcName, user.UUID, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
+
+	if rowsAffected == 0 {
+		desc := fmt.Sprintf("%s: admin user %s not found", funcName, user.UUID)
+		return errs.DBError(errs.ValueNotFound, desc)
+	}
+
 	return nil
 }
 
-// fetchPaymentsAtHeight returns all payments sourcing from orphaned blocks at
-// the provided height.
-func (db *PostgresDB) fetchPaymentsAtHeight(height uint32) ([]*Payment, error) {
-	const funcName = "fetchPaymentsAtHeight"
-	rows, err := db.DB.Query(selectPaymentsAtHeight, height)
+// fetchAdminUser fetches the admin user with the provided name.
+func (db *PostgresDB) fetchAdminUser(name string) (*AdminUser, error) {
+	const funcName = "fetchAdminUser"
+	var uuid, passwordHash, role, totpSecret string
+	var createdOn int64
+	err := db.DB.QueryRow(selectAdminUser, name).Scan(&uuid, &passwordHash,
+		&role, &totpSecret, &createdOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch payments at height %d: %v",
-			funcName, height, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			desc := fmt.Sprintf("%s: no admin user found for name %s",
+				funcName, name)
+			return nil, errs.DBError(errs.ValueNotFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to fetch admin user with name "+
+			"(%s): %v", funcName, name, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
 
-	return decodePaymentRows(rows)
+	return &AdminUser{uuid, passwordHash, role, totpSecret, createdOn}, nil
 }
 
-// fetchPendingPayments fetches all unpaid payments.
-func (db *PostgresDB) fetchPendingPayments() ([]*Payment, error) {
-	const funcName = "fetchPendingPayments"
-	rows, err := db.DB.Query(selectPendingPayments)
+// deleteAdminUser purges the referenced admin user from the database.
+func (db *PostgresDB) deleteAdminUser(name string) error {
+	const funcName = "deleteAdminUser"
+	_, err := db.DB.Exec(deleteAdminUser, name)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch pending payments: %v",
+		desc := fmt.Sprintf("%s: unable to delete admin user with name "+
+			"(%s): %v", funcName, name, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// listAdminUsers fetches all admin users.
+func (db *PostgresDB) listAdminUsers() ([]*AdminUser, error) {
+	const funcName = "listAdminUsers"
+	rows, err := db.DB.Query(listAdminUsers)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to list admin users: %v",
 			funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
 
-	return decodePaymentRows(rows)
-}
-
-// pendingPaymentsForBlockHash returns the number of pending payments with the
-// provided block hash as their source.
-func (db *PostgresDB) pendingPaymentsForBlockHash(blockHash string) (uint32, error) {
-	const funcName = "pendingPaymentsForBlockHash"
-	var count uint32
-	err := db.DB.QueryRow(countPaymentsAtBlockHash, blockHash).Scan(&count)
+	users, err := decodeAdminUserRows(rows)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch pending payments for "+
-			"blockhash (%s): %v", funcName, blockHash, err)
-		return 0, errs.DBError(errs.FetchEntry, desc)
+		return nil, err
 	}
 
-	return count, nil
+	return users, nil
 }
 
-// archivedPayments fetches all archived payments. List is ordered, most
-// recent comes first.
-func (db *PostgresDB) archivedPayments() ([]*Payment, error) {
-	const funcName = "archivedPayments"
-	rows, err := db.DB.Query(selectArchivedPayments)
+// persistAuditEntry saves the provided audit entry to the database.
+func (db *PostgresDB) persistAuditEntry(entry *AuditEntry) error {
+	const funcName = "persistAuditEntry"
+
+	_, err := db.DB.Exec(insertAuditEntry, entry.UUID, entry.Actor,
+		entry.Action, entry.Details, entry.IP, entry.CreatedOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch archived payments: %v",
+
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: audit entry %s already exists",
+				funcName, entry.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist audit entry: %v",
 			funcName, err)
-		return nil, errs.DBError(errs.FetchEntry, desc)
+		return errs.DBError(errs.PersistEntry, desc)
 	}
-
-	return decodePaymentRows(rows)
+	return nil
 }
 
-// maturePendingPayments fetches all mature pending payments at the
-// provided height.
-func (db *PostgresDB) maturePendingPayments(height uint32) (map[string][]*Payment, error) {
-	const funcName = "maturePendingPayments"
-	rows, err := db.DB.Query(selectMaturePendingPayments, height)
+// fetchAuditEntries fetches the most recent audit entries, newest first.
+// All entries are returned if the provided limit is not positive.
+func (db *PostgresDB) fetchAuditEntries(limit int) ([]*AuditEntry, error) {
+	const funcName = "fetchAuditEntries"
+	n := int64(limit)
+	if n <= 0 {
+		n = math.MaxInt64
+	}
+	rows, err := db.DB.Query(selectAuditEntries, n)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch mature pending payments: %v",
+		desc := fmt.Sprintf("%s: unable to fetch audit entries: %v",
 			funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
 
-	payments, err := decodePaymentRows(rows)
+	entries, err := decodeAuditEntryRows(rows)
 	if err != nil {
 		return nil, err
 	}
 
-	pmts := make(map[string][]*Payment)
-	for _, pmt := range payments {
-		set, ok := pmts[pmt.Source.BlockHash]
-		if !ok {
-			set = make([]*Payment, 0)
-		}
-		set = append(set, pmt)
-		pmts[pmt.Source.BlockHash] = set
-	}
-
-	return pmts, nil
+	return entries, nil
 }
 
-// fetchShare fetches the share referenced by the provided id. Returns an error
-// if the share is not found.
-func (db *PostgresDB) fetchShare(id string) (*Share, error) {
-	const funcName = "fetchShare"
-	var uuid, account, weight string
-	var createdOn int64
-	err := db.DB.QueryRow(selectShare, id).Scan(&uuid, &account, &weight, &createdOn)
+// persistBan saves the provided ban to the database. Persisting an existing
+// ban replaces it.
+func (db *PostgresDB) persistBan(ban *Ban) error {
+	const funcName = "persistBan"
+	_, err := db.DB.Exec(insertBan, ban.UUID, ban.Kind, ban.Reason,
+		ban.CreatedOn)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no share found for id %s", funcName, id)
-			return nil, errs.DBError(errs.ValueNotFound, desc)
-		}
+		desc := fmt.Sprintf("%s: unable to persist ban: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
 
-		desc := fmt.Sprintf("%s: unable to fetch share with id (%s): %v",
+// deleteBan purges the referenced ban from the database.
+func (db *PostgresDB) deleteBan(id string) error {
+	const funcName = "deleteBan"
+	_, err := db.DB.Exec(deleteBan, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete ban with id (%s): %v",
 			funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// listBans fetches all bans. List is ordered, oldest first.
+func (db *PostgresDB) listBans() ([]*Ban, error) {
+	const funcName = "listBans"
+	rows, err := db.DB.Query(listBans)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to list bans: %v", funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
 
-	weightRat, ok := new(big.Rat).SetString(weight)
-	if !ok {
-		desc := fmt.Sprintf("%s: unable to decode big.Rat string: %v",
-			funcName, err)
-		return nil, errs.DBError(errs.Parse, desc)
+	bans, err := decodeBanRows(rows)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Share{uuid, account, weightRat, createdOn}, nil
+	return bans, nil
 }
 
-// PersistShare saves a share to the database. Returns an error if a share
-// already exists with the same ID.
-func (db *PostgresDB) PersistShare(share *Share) error {
-	const funcName = "PersistShare"
-
-	_, err := db.DB.Exec(insertShare, share.UUID, share.Account,
-		share.Weight.RatString(), share.CreatedOn)
-	if err != nil {
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				desc := fmt.Sprintf("%s: share %s already exists", funcName,
-					share.UUID)
+// insertLedgerEntries saves the provided ledger entries in the provided
+// transaction. Returns an error if any of the entries already exists.
+func insertLedgerEntries(tx *sql.Tx, funcName string, entries []*LedgerEntry) error {
+	for _, e := range entries {
+		_, err := tx.Exec(insertLedgerEntry, e.UUID, e.Kind, e.Debit,
+			e.Credit, int64(e.Amount), e.BlockHash, e.TxID, e.Actor, e.Memo,
+			e.CreatedOn)
+		if err != nil {
+			if isUniqueViolation(err) {
+				desc := fmt.Sprintf("%s: ledger entry %s already exists",
+					funcName, e.UUID)
 				return errs.DBError(errs.ValueFound, desc)
 			}
+			desc := fmt.Sprintf("%s: unable to persist ledger entry: %v",
+				funcName, err)
+			return errs.DBError(errs.PersistEntry, desc)
 		}
+	}
+	return nil
+}
 
-		desc := fmt.Sprintf("%s: unable to persist share: %v", funcName, err)
+// persistLedgerEntries saves the provided ledger entries to the database
+// within a single transaction. Returns an error if any of the entries
+// already exists, in which case none of them are persisted.
+func (db *PostgresDB) persistLedgerEntries(entries []*LedgerEntry) error {
+	const funcName = "persistLedgerEntries"
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin ledger tx: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	err = insertLedgerEntries(tx, funcName, entries)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit ledger tx: %v",
+			funcName, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 	return nil
 }
 
-// ppsEligibleShares fetches all shares created before or at the provided time.
-func (db *PostgresDB) ppsEligibleShares(max int64) ([]*Share, error) {
-	const funcName = "ppsEligibleShares"
-	rows, err := db.DB.Query(selectSharesOnOrBeforeTime, max)
+// fetchLedgerEntries fetches the most recent ledger entries debiting or
+// crediting the provided ledger account, or all entries if the account is
+// empty, newest first. All entries are returned if the provided limit is not
+// positive.
+func (db *PostgresDB) fetchLedgerEntries(account string, limit int) ([]*LedgerEntry, error) {
+	const funcName = "fetchLedgerEntries"
+	n := int64(limit)
+	if n <= 0 {
+		n = math.MaxInt64
+	}
+	rows, err := db.DB.Query(selectLedgerEntries, account, n)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch PPS eligible shares: %v",
+		desc := fmt.Sprintf("%s: unable to fetch ledger entries: %v",
 			funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
+	defer rows.Close()
 
-	return decodeShareRows(rows)
-}
+	entries := make([]*LedgerEntry, 0)
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
 
-// pplnsEligibleShares fetches all shares created after the provided time.
-func (db *PostgresDB) pplnsEligibleShares(min int64) ([]*Share, error) {
-	const funcName = "pplnsEligibleShares"
-	rows, err := db.DB.Query(selectSharesAfterTime, min)
+	err = rows.Err()
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch PPLNS eligible shares: %v",
+		desc := fmt.Sprintf("%s: unable to decode ledger entries: %v",
 			funcName, err)
-		return nil, errs.DBError(errs.FetchEntry, desc)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	return decodeShareRows(rows)
+	return entries, nil
 }
 
-// pruneShares removes shares with a createdOn time earlier than the provided
-// time.
-func (db *PostgresDB) pruneShares(minNano int64) error {
-	const funcName = "pruneShares"
-	_, err := db.DB.Exec(deleteShareCreatedBefore, minNano)
+// fetchLedgerBalances returns the balance of every ledger account with
+// ledger entries.
+func (db *PostgresDB) fetchLedgerBalances() (map[string]dcrutil.Amount, error) {
+	const funcName = "fetchLedgerBalances"
+	rows, err := db.DB.Query(selectLedgerBalances)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to prune shares: %v", funcName, err)
-		return errs.DBError(errs.DeleteEntry, desc)
+		desc := fmt.Sprintf("%s: unable to fetch ledger balances: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
-	return nil
-}
+	defer rows.Close()
 
-// fetchAcceptedWork fetches the accepted work referenced by the provided id.
-// Returns an error if the work is not found.
-func (db *PostgresDB) fetchAcceptedWork(id string) (*AcceptedWork, error) {
-	const funcName = "fetchAcceptedWork"
+	balances := make(map[string]dcrutil.Amount)
+	for rows.Next() {
+		var account string
+		var balance int64
+		err := rows.Scan(&account, &balance)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to scan ledger balance: %v",
+				funcName, err)
+			return nil, errs.DBError(errs.Decode, desc)
+		}
+		balances[account] = dcrutil.Amount(balance)
+	}
 
-	var uuid, blockhash, prevhash, minedby, miner string
-	var confirmed bool
-	var height uint32
-	var createdOn int64
-	err := db.DB.QueryRow(selectAcceptedWork, id).Scan(&uuid, &blockhash, &prevhash, &height,
-		&minedby, &miner, &createdOn, &confirmed)
+	err = rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode ledger balances: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return balances, nil
+}
+
+// fetchAccountBalances returns the balance of the provided ledger account
+// along with the amount paid out to it, or of every ledger account with
+// ledger entries if it is empty.
+func (db *PostgresDB) fetchAccountBalances(account string) (map[string]*AccountBalance, error) {
+	const funcName = "fetchAccountBalances"
+	rows, err := db.DB.Query(selectAccountBalances, account)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no work found for id %s", funcName, id)
-			return nil, errs.DBError(errs.ValueNotFound, desc)
+		desc := fmt.Sprintf("%s: unable to fetch account balances: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]*AccountBalance)
+	for rows.Next() {
+		var acc string
+		var balance, paid int64
+		err := rows.Scan(&acc, &balance, &paid)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to scan account balance: %v",
+				funcName, err)
+			return nil, errs.DBError(errs.Decode, desc)
 		}
+		balances[acc] = &AccountBalance{
+			Balance: dcrutil.Amount(balance),
+			Paid:    dcrutil.Amount(paid),
+		}
+	}
 
-		desc := fmt.Sprintf("%s: unable to fetch accepted work with id "+
-			"(%s): %v", funcName, id, err)
-		return nil, errs.DBError(errs.FetchEntry, desc)
+	err = rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode account balances: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	return &AcceptedWork{uuid, blockhash, prevhash, height,
-		minedby, miner, createdOn, confirmed}, nil
+	return balances, nil
 }
 
-// persistAcceptedWork saves the accepted work to the database.
-func (db *PostgresDB) persistAcceptedWork(work *AcceptedWork) error {
-	const funcName = "persistAcceptedWork"
+// persistBlockEvent saves the provided block event to the database.
+func (db *PostgresDB) persistBlockEvent(event *BlockEvent) error {
+	const funcName = "persistBlockEvent"
 
-	_, err := db.DB.Exec(insertAcceptedWork, work.UUID, work.BlockHash, work.PrevHash,
-		work.Height, work.MinedBy, work.Miner, work.CreatedOn, work.Confirmed)
+	_, err := db.DB.Exec(insertBlockEvent, event.UUID, event.Kind,
+		event.Header, event.Height, event.Attempts, event.LastError,
+		event.NextAttempt, event.CreatedOn)
 	if err != nil {
-
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				desc := fmt.Sprintf("%s: work %s already exists", funcName,
-					work.UUID)
-				return errs.DBError(errs.ValueFound, desc)
-			}
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: block event %s already exists",
+				funcName, event.UUID)
+			return errs.DBError(errs.ValueFound, desc)
 		}
 
-		desc := fmt.Sprintf("%s: unable to persist accepted work: %v",
+		desc := fmt.Sprintf("%s: unable to persist block event: %v",
 			funcName, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 	return nil
 }
 
-// updateAcceptedWork persists modifications to an existing work. Returns an
-// error if the work is not found.
-func (db *PostgresDB) updateAcceptedWork(work *AcceptedWork) error {
-	const funcName = "updateAcceptedWork"
+// updateBlockEvent persists the updated block event to the database.
+func (db *PostgresDB) updateBlockEvent(event *BlockEvent) error {
+	const funcName = "updateBlockEvent"
 
-	result, err := db.DB.Exec(updateAcceptedWork,
-		work.UUID, work.BlockHash, work.PrevHash,
-		work.Height, work.MinedBy, work.Miner, work.CreatedOn, work.Confirmed)
+	result, err := db.DB.Exec(updateBlockEvent, event.UUID, event.Kind,
+		event.Header, event.Height, event.Attempts, event.LastError,
+		event.NextAttempt, event.CreatedOn)
 	if err != nil {
-		return err
+		desc := fmt.Sprintf("%s: unable to update block event with id "+
+			"(%s): %v", funcName, event.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to update accepted work with id "+
-			"(%s): %v", funcName, work.UUID, err)
+		desc := fmt.Sprintf("%s: unable to update block event with id "+
+			"(%s): %v", funcName, event.UUID, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 
 	if rowsAffected == 0 {
-		desc := fmt.Sprintf("%s: work %s not found", funcName, work.UUID)
+		desc := fmt.Sprintf("%s: block event %s not found", funcName,
+			event.UUID)
 		return errs.DBError(errs.ValueNotFound, desc)
 	}
 
 	return nil
 }
 
-// deleteAcceptedWork removes the associated accepted work from the database.
-func (db *PostgresDB) deleteAcceptedWork(id string) error {
-	const funcName = "deleteAcceptedWork"
-	_, err := db.DB.Exec(deleteAcceptedWork, id)
+// deleteBlockEvent purges the referenced block event from the database.
+func (db *PostgresDB) deleteBlockEvent(id string) error {
+	const funcName = "deleteBlockEvent"
+	_, err := db.DB.Exec(deleteBlockEvent, id)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to delete account work with id "+
+		desc := fmt.Sprintf("%s: unable to delete block event with id "+
 			"(%s): %v", funcName, id, err)
 		return errs.DBError(errs.DeleteEntry, desc)
 	}
 	return nil
 }
 
-// listMinedWork returns work data associated with all blocks mined by the pool
-// regardless of whether they are confirmed or not.
-//
-// List is ordered, most recent comes first.
-func (db *PostgresDB) listMinedWork() ([]*AcceptedWork, error) {
-	const funcName = "listMinedWork"
-	rows, err := db.DB.Query(selectMinedWork)
+// fetchBlockEvents fetches all block events pending processing. List is
+// ordered, oldest first.
+func (db *PostgresDB) fetchBlockEvents() ([]*BlockEvent, error) {
+	const funcName = "fetchBlockEvents"
+	rows, err := db.DB.Query(selectBlockEvents)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch mined work: %v", funcName, err)
+		desc := fmt.Sprintf("%s: unable to fetch block events: %v",
+			funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
+	defer rows.Close()
 
-	return decodeWorkRows(rows)
-}
+	events := make([]*BlockEvent, 0)
+	for rows.Next() {
+		event, err := scanBlockEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
 
-// fetchUnconfirmedWork returns all work which is not confirmed as mined with
-// height less than the provided height.
-func (db *PostgresDB) fetchUnconfirmedWork(height uint32) ([]*AcceptedWork, error) {
-	const funcName = "fetchUnconfirmedWork"
-	rows, err := db.DB.Query(selectUnconfirmedWork, height)
+	err = rows.Err()
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to fetch unconfirmed work: %v", funcName, err)
-		return nil, errs.DBError(errs.FetchEntry, desc)
+		desc := fmt.Sprintf("%s: unable to decode block events: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	return decodeWorkRows(rows)
+	return events, nil
 }
 
-// fetchJob fetches the job referenced by the provided id. Returns an error if
-// the job is not found.
-func (db *PostgresDB) fetchJob(id string) (*Job, error) {
-	const funcName = "fetchJob"
-	var uuid, header string
-	var height uint32
-	err := db.DB.QueryRow(selectJob, id).Scan(&uuid, &header, &height)
+// persistWebhook saves the provided webhook to the database.
+func (db *PostgresDB) persistWebhook(hook *Webhook) error {
+	const funcName = "persistWebhook"
+
+	_, err := db.DB.Exec(insertWebhook, hook.UUID, hook.AccountID, hook.URL,
+		hook.Secret, hook.CreatedOn)
+	if err != nil {
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: webhook %s already exists", funcName,
+				hook.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist webhook: %v", funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// fetchWebhook fetches the webhook associated with the provided id.
+func (db *PostgresDB) fetchWebhook(id string) (*Webhook, error) {
+	const funcName = "fetchWebhook"
+	var uuid, accountID, url, secret string
+	var createdOn int64
+	err := db.DB.QueryRow(selectWebhook, id).Scan(&uuid, &accountID, &url,
+		&secret, &createdOn)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no job found for id %s", funcName, id)
+			desc := fmt.Sprintf("%s: no webhook found for id %s", funcName, id)
 			return nil, errs.DBError(errs.ValueNotFound, desc)
 		}
 
-		desc := fmt.Sprintf("%s: unable to fetch job with id (%s): %v",
+		desc := fmt.Sprintf("%s: unable to fetch webhook with id (%s): %v",
 			funcName, id, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
-	return &Job{uuid, height, header}, nil
+
+	return &Webhook{uuid, accountID, url, secret, createdOn}, nil
 }
 
-// persistJob saves the job to the database. Returns an error if an account
-// already exists with the same ID.
-func (db *PostgresDB) persistJob(job *Job) error {
-	const funcName = "persistJob"
+// deleteWebhook purges the referenced webhook from the database.
+func (db *PostgresDB) deleteWebhook(id string) error {
+	const funcName = "deleteWebhook"
+	_, err := db.DB.Exec(deleteWebhook, id)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to delete webhook with id (%s): %v",
+			funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
 
-	_, err := db.DB.Exec(insertJob, job.UUID, job.Height, job.Header)
+// fetchWebhooksForAccount fetches all webhooks of the provided account.
+// List is ordered, oldest first.
+func (db *PostgresDB) fetchWebhooksForAccount(accountID string) ([]*Webhook, error) {
+	const funcName = "fetchWebhooksForAccount"
+	rows, err := db.DB.Query(selectWebhooksForAccount, accountID)
 	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch webhooks: %v", funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+	defer rows.Close()
 
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				desc := fmt.Sprintf("%s: job %s already exists", funcName,
-					job.UUID)
-				return errs.DBError(errs.ValueFound, desc)
-			}
+	hooks := make([]*Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
 		}
+		hooks = append(hooks, hook)
+	}
 
-		desc := fmt.Sprintf("%s: unable to persist job: %v", funcName, err)
+	err = rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode webhooks: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return hooks, nil
+}
+
+// persistWebhookDelivery saves the provided webhook delivery to the
+// database.
+func (db *PostgresDB) persistWebhookDelivery(delivery *WebhookDelivery) error {
+	const funcName = "persistWebhookDelivery"
+
+	_, err := db.DB.Exec(insertWebhookDelivery, delivery.UUID,
+		delivery.WebhookID, delivery.URL, delivery.Event, delivery.Payload,
+		delivery.Attempts, delivery.LastError, delivery.NextAttempt,
+		delivery.CreatedOn)
+	if err != nil {
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: webhook delivery %s already exists",
+				funcName, delivery.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist webhook delivery: %v",
+			funcName, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 	return nil
 }
 
-// deleteJob removes the associated job from the database.
-func (db *PostgresDB) deleteJob(id string) error {
-	const funcName = "deleteJob"
-	_, err := db.DB.Exec(deleteJob, id)
+// updateWebhookDelivery persists the updated webhook delivery to the
+// database.
+func (db *PostgresDB) updateWebhookDelivery(delivery *WebhookDelivery) error {
+	const funcName = "updateWebhookDelivery"
+
+	result, err := db.DB.Exec(updateWebhookDelivery, delivery.UUID,
+		delivery.WebhookID, delivery.URL, delivery.Event, delivery.Payload,
+		delivery.Attempts, delivery.LastError, delivery.NextAttempt,
+		delivery.CreatedOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to delete job entry with id "+
-			"(%s): %v", funcName, id, err)
-		return errs.DBError(errs.DeleteEntry, desc)
+		desc := fmt.Sprintf("%s: unable to update webhook delivery with id "+
+			"(%s): %v", funcName, delivery.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to update webhook delivery with id "+
+			"(%s): %v", funcName, delivery.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+
+	if rowsAffected == 0 {
+		desc := fmt.Sprintf("%s: webhook delivery %s not found", funcName,
+			delivery.UUID)
+		return errs.DBError(errs.ValueNotFound, desc)
 	}
+
 	return nil
 }
 
-// deleteJobsBeforeHeight removes all jobs with heights less than the provided
-// height.
-func (db *PostgresDB) deleteJobsBeforeHeight(height uint32) error {
-	const funcName = "deleteJobsBeforeHeight"
-	_, err := db.DB.Exec(deleteJobBeforeHeight, height)
+// deleteWebhookDelivery purges the referenced webhook delivery from the
+// database.
+func (db *PostgresDB) deleteWebhookDelivery(id string) error {
+	const funcName = "deleteWebhookDelivery"
+	_, err := db.DB.Exec(deleteWebhookDelivery, id)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to delete jobs before height %d: %v",
-			funcName, height, err)
+		desc := fmt.Sprintf("%s: unable to delete webhook delivery with id "+
+			"(%s): %v", funcName, id, err)
 		return errs.DBError(errs.DeleteEntry, desc)
 	}
-	return err
+	return nil
 }
 
-// persistHashData saves the provided hash data to the database.
-func (db *PostgresDB) persistHashData(hashData *HashData) error {
-	const funcName = "persistHashData"
+// fetchWebhookDeliveries fetches all webhook deliveries pending delivery.
+// List is ordered, oldest first.
+func (db *PostgresDB) fetchWebhookDeliveries() ([]*WebhookDelivery, error) {
+	const funcName = "fetchWebhookDeliveries"
+	rows, err := db.DB.Query(selectWebhookDeliveries)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch webhook deliveries: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.FetchEntry, desc)
+	}
+	defer rows.Close()
 
-	_, err := db.DB.Exec(insertHashData, hashData.UUID, hashData.AccountID,
-		hashData.Miner, hashData.IP, hashData.HashRate.RatString(),
-		hashData.UpdatedOn)
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	err = rows.Err()
 	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode webhook deliveries: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
 
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				desc := fmt.Sprintf("%s: hash rate %s already exists", funcName,
-					hashData.UUID)
-				return errs.DBError(errs.ValueFound, desc)
-			}
+	return deliveries, nil
+}
+
+// persistWorker saves the provided worker to the database.
+func (db *PostgresDB) persistWorker(worker *Worker) error {
+	const funcName = "persistWorker"
+
+	_, err := db.DB.Exec(insertWorker, worker.UUID, worker.AccountID,
+		worker.Name, worker.Miner, worker.HashRate, worker.Baseline,
+		worker.Samples, worker.Status, worker.StatusSince, worker.LastSeen,
+		worker.CreatedOn)
+	if err != nil {
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: worker %s already exists", funcName,
+				worker.UUID)
+			return errs.DBError(errs.ValueFound, desc)
 		}
 
-		desc := fmt.Sprintf("%s: unable to persist hash rate: %v", funcName, err)
+		desc := fmt.Sprintf("%s: unable to persist worker: %v", funcName, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 	return nil
 }
 
-// updateHashData persists the updated hash data to the database.
-func (db *PostgresDB) updateHashData(hashData *HashData) error {
-	const funcName = "updateHashData"
+// updateWorker persists the updated worker to the database.
+func (db *PostgresDB) updateWorker(worker *Worker) error {
+	const funcName = "updateWorker"
 
-	result, err := db.DB.Exec(updateHashData,
-		hashData.UUID, hashData.AccountID, hashData.Miner,
-		hashData.IP, hashData.HashRate.RatString(), hashData.UpdatedOn)
+	result, err := db.DB.Exec(updateWorker, worker.UUID, worker.AccountID,
+		worker.Name, worker.Miner, worker.HashRate, worker.Baseline,
+		worker.Samples, worker.Status, worker.StatusSince, worker.LastSeen,
+		worker.CreatedOn)
 	if err != nil {
-		return err
+		desc := fmt.Sprintf("%s: unable to update worker with id (%s): %v",
+			funcName, worker.UUID, err)
+		return errs.DBError(errs.PersistEntry, desc)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to update accepted hash data with id "+
-			"(%s): %v", funcName, hashData.UUID, err)
+		desc := fmt.Sprintf("%s: unable to update worker with id (%s): %v",
+			funcName, worker.UUID, err)
 		return errs.DBError(errs.PersistEntry, desc)
 	}
 
 	if rowsAffected == 0 {
-		desc := fmt.Sprintf("%s: hash data %s not found", funcName, hashData.UUID)
+		desc := fmt.Sprintf("%s: worker %s not found", funcName, worker.UUID)
 		return errs.DBError(errs.ValueNotFound, desc)
 	}
 
 	return nil
 }
 
-// fetchHashData fetches the hash data associated with the provided id.
-func (db *PostgresDB) fetchHashData(id string) (*HashData, error) {
-	const funcName = "fetchHashData"
-	var uuid, accountID, miner, ip, hashRate string
-	var updatedOn int64
-	err := db.DB.QueryRow(selectHashData, id).Scan(&uuid, &accountID, &miner,
-		&ip, &hashRate, &updatedOn)
+// deleteWorker purges the referenced worker from the database.
+func (db *PostgresDB) deleteWorker(id string) error {
+	const funcName = "deleteWorker"
+	_, err := db.DB.Exec(deleteWorker, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			desc := fmt.Sprintf("%s: no hash data found for id %s", funcName, id)
-			return nil, errs.DBError(errs.ValueNotFound, desc)
-		}
-
-		desc := fmt.Sprintf("%s: unable to fetch hash data with id (%s): %v",
+		desc := fmt.Sprintf("%s: unable to delete worker with id (%s): %v",
 			funcName, id, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+	return nil
+}
+
+// fetchWorkers fetches all tracked workers. List is ordered by id.
+func (db *PostgresDB) fetchWorkers() ([]*Worker, error) {
+	const funcName = "fetchWorkers"
+	rows, err := db.DB.Query(selectWorkers)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch workers: %v", funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
+	defer rows.Close()
 
-	hashRat, ok := new(big.Rat).SetString(hashRate)
-	if !ok {
-		desc := fmt.Sprintf("%s: unable to decode big.Rat string: %v",
-			funcName, err)
-		return nil, errs.DBError(errs.Parse, desc)
+	workers := make([]*Worker, 0)
+	for rows.Next() {
+		worker, err := scanWorker(rows)
+		if err != nil {
+			return nil, err
+		}
+		workers = append(workers, worker)
 	}
 
-	return &HashData{uuid, accountID, miner, ip, hashRat, updatedOn}, nil
+	err = rows.Err()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to decode workers: %v", funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
+	}
+
+	return workers, nil
 }
 
-// listHashData fetches all hash data updated after the provided minimum time.
-func (db *PostgresDB) listHashData(minNano int64) (map[string]*HashData, error) {
-	const funcName = "listHashData"
-	rows, err := db.DB.Query(listHashData, minNano)
+// persistWorkerEvent saves the provided worker event to the database.
+func (db *PostgresDB) persistWorkerEvent(event *WorkerEvent) error {
+	const funcName = "persistWorkerEvent"
+
+	_, err := db.DB.Exec(insertWorkerEvent, event.UUID, event.AccountID,
+		event.Worker, event.Kind, event.HashRate, event.Baseline,
+		event.LastSeen, event.CreatedOn)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to list hash data: %v",
+		if isUniqueViolation(err) {
+			desc := fmt.Sprintf("%s: worker event %s already exists",
+				funcName, event.UUID)
+			return errs.DBError(errs.ValueFound, desc)
+		}
+
+		desc := fmt.Sprintf("%s: unable to persist worker event: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// fetchWorkerEventsForAccount fetches all worker events of the provided
+// account. List is ordered, most recent comes first.
+func (db *PostgresDB) fetchWorkerEventsForAccount(accountID string) ([]*WorkerEvent, error) {
+	const funcName = "fetchWorkerEventsForAccount"
+	rows, err := db.DB.Query(selectWorkerEventsForAccount, accountID)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to fetch worker events: %v",
 			funcName, err)
 		return nil, errs.DBError(errs.FetchEntry, desc)
 	}
+	defer rows.Close()
 
-	hashData, err := decodeHashDataRows(rows)
+	events := make([]*WorkerEvent, 0)
+	for rows.Next() {
+		event, err := scanWorkerEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	err = rows.Err()
 	if err != nil {
-		return nil, err
+		desc := fmt.Sprintf("%s: unable to decode worker events: %v",
+			funcName, err)
+		return nil, errs.DBError(errs.Decode, desc)
 	}
 
-	return hashData, nil
+	return events, nil
 }
 
-// pruneHashData prunes all hash data that have not been updated since
-// the provided minimum time.
-func (db *PostgresDB) pruneHashData(minNano int64) error {
-	const funcName = "pruneHashData"
+// pruneWorkerEvents prunes all worker events created before the provided
+// minimum time.
+func (db *PostgresDB) pruneWorkerEvents(minNano int64) error {
+	const funcName = "pruneWorkerEvents"
 
-	_, err := db.DB.Exec(pruneHashData, minNano)
+	_, err := db.DB.Exec(pruneWorkerEvents, minNano)
 	if err != nil {
-		desc := fmt.Sprintf("%s: unable to prune hash data: %v", funcName, err)
+		desc := fmt.Sprintf("%s: unable to prune worker events: %v",
+			funcName, err)
 		return errs.DBError(errs.DeleteEntry, desc)
 	}
 
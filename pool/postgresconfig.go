@@ -0,0 +1,195 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// Postgres ssl modes supported by the postgres driver.
+const (
+	PGSSLDisable    = "disable"
+	PGSSLRequire    = "require"
+	PGSSLVerifyCA   = "verify-ca"
+	PGSSLVerifyFull = "verify-full"
+)
+
+// PostgresConfig contains the connection details of a postgres database.
+type PostgresConfig struct {
+	// Host represents the host of the database server.
+	Host string
+	// Port represents the port of the database server.
+	Port uint32
+	// User represents the username for authentication.
+	User string
+	// Pass represents the password for authentication.
+	Pass string
+	// DBName represents the name of the database.
+	DBName string
+	// DSN represents a full connection string, either a URL or key/value
+	// pairs. The host, port, user, password and database name are ignored
+	// when set.
+	DSN string
+	// SSLMode represents the ssl mode of the connection, one of disable,
+	// require, verify-ca or verify-full. The ssl mode defaults to disable
+	// unless set by the DSN.
+	SSLMode string
+	// SSLRootCert represents the path to the certificate authorities
+	// verifying the server certificate.
+	SSLRootCert string
+	// SSLCert represents the path to the client certificate.
+	SSLCert string
+	// SSLKey represents the path to the client certificate key.
+	SSLKey string
+	// MaxOpenConns represents the maximum number of open connections, zero
+	// means unlimited.
+	MaxOpenConns int
+	// MaxIdleConns represents the maximum number of idle connections, zero
+	// uses the default of the sql package.
+	MaxIdleConns int
+	// ConnMaxLifetime represents the maximum time a connection is reused,
+	// zero means connections are reused forever.
+	ConnMaxLifetime time.Duration
+	// StatementTimeout represents the time after which the server aborts a
+	// statement, zero means no timeout.
+	StatementTimeout time.Duration
+	// ConnectTimeout represents the time allowed to establish a connection,
+	// zero means no timeout.
+	ConnectTimeout time.Duration
+	// ReadOnly represents whether the sessions only allow read-only
+	// transactions.
+	ReadOnly bool
+}
+
+// quoteDSNValue quotes the provided value of a key/value connection string.
+func quoteDSNValue(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(value) + "'"
+}
+
+// validate ensures the connection details are valid, including the ssl
+// certificates and keys.
+func (cfg *PostgresConfig) validate() error {
+	const funcName = "validate"
+	switch cfg.SSLMode {
+	case "", PGSSLDisable, PGSSLRequire, PGSSLVerifyCA, PGSSLVerifyFull:
+	default:
+		desc := fmt.Sprintf("%s: unsupported ssl mode %q", funcName,
+			cfg.SSLMode)
+		return errs.DBError(errs.DBOpen, desc)
+	}
+	if cfg.MaxOpenConns < 0 || cfg.MaxIdleConns < 0 ||
+		cfg.ConnMaxLifetime < 0 || cfg.StatementTimeout < 0 ||
+		cfg.ConnectTimeout < 0 {
+		desc := fmt.Sprintf("%s: connection limits and timeouts may not "+
+			"be negative", funcName)
+		return errs.DBError(errs.DBOpen, desc)
+	}
+
+	hasSSLFiles := cfg.SSLRootCert != "" || cfg.SSLCert != "" ||
+		cfg.SSLKey != ""
+	if cfg.SSLMode == PGSSLDisable && hasSSLFiles {
+		desc := fmt.Sprintf("%s: ssl certificates may not be provided "+
+			"when ssl is disabled", funcName)
+		return errs.DBError(errs.DBOpen, desc)
+	}
+
+	if cfg.SSLRootCert != "" {
+		pem, err := ioutil.ReadFile(cfg.SSLRootCert)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to read ssl root certificate: "+
+				"%v", funcName, err)
+			return errs.DBError(errs.DBOpen, desc)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			desc := fmt.Sprintf("%s: no certificates found in ssl root "+
+				"certificate %s", funcName, cfg.SSLRootCert)
+			return errs.DBError(errs.DBOpen, desc)
+		}
+	}
+
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		desc := fmt.Sprintf("%s: the ssl client certificate and key must "+
+			"be provided together", funcName)
+		return errs.DBError(errs.DBOpen, desc)
+	}
+	if cfg.SSLCert != "" {
+		_, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to load ssl client "+
+				"certificate: %v", funcName, err)
+			return errs.DBError(errs.DBOpen, desc)
+		}
+	}
+
+	return nil
+}
+
+// dsn returns the key/value connection string of the connection details.
+// Options set explicitly take precedence over the options of the DSN.
+func (cfg *PostgresConfig) dsn() (string, error) {
+	const funcName = "dsn"
+	var opts []string
+	sslMode := cfg.SSLMode
+	switch {
+	case strings.HasPrefix(cfg.DSN, "postgres://"),
+		strings.HasPrefix(cfg.DSN, "postgresql://"):
+		dsn, err := pq.ParseURL(cfg.DSN)
+		if err != nil {
+			desc := fmt.Sprintf("%s: invalid postgres dsn: %v", funcName, err)
+			return "", errs.DBError(errs.DBOpen, desc)
+		}
+		opts = append(opts, dsn)
+
+	case cfg.DSN != "":
+		opts = append(opts, cfg.DSN)
+
+	default:
+		opts = append(opts,
+			"host="+quoteDSNValue(cfg.Host),
+			fmt.Sprintf("port=%d", cfg.Port),
+			"user="+quoteDSNValue(cfg.User),
+			"password="+quoteDSNValue(cfg.Pass),
+			"dbname="+quoteDSNValue(cfg.DBName))
+		if sslMode == "" {
+			sslMode = PGSSLDisable
+		}
+	}
+
+	if sslMode != "" {
+		opts = append(opts, "sslmode="+sslMode)
+	}
+	if cfg.SSLRootCert != "" {
+		opts = append(opts, "sslrootcert="+quoteDSNValue(cfg.SSLRootCert))
+	}
+	if cfg.SSLCert != "" {
+		opts = append(opts, "sslcert="+quoteDSNValue(cfg.SSLCert),
+			"sslkey="+quoteDSNValue(cfg.SSLKey))
+	}
+	if cfg.ConnectTimeout > 0 {
+		// The connect timeout has a resolution of a second.
+		secs := int64((cfg.ConnectTimeout + time.Second - 1) / time.Second)
+		opts = append(opts, fmt.Sprintf("connect_timeout=%d", secs))
+	}
+	if cfg.StatementTimeout > 0 {
+		// Unknown options are set as run-time parameters of the session.
+		opts = append(opts, fmt.Sprintf("statement_timeout=%d",
+			cfg.StatementTimeout.Milliseconds()))
+	}
+	if cfg.ReadOnly {
+		opts = append(opts, "default_transaction_read_only=on")
+	}
+
+	return strings.Join(opts, " "), nil
+}
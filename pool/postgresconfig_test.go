@@ -0,0 +1,172 @@
+package pool
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// writeTestCert writes a self-signed certificate and its key to the provided
+// directory, returning their paths.
+func writeTestCert(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dcrpool"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template,
+		&key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestPostgresConfig(t *testing.T) {
+	d, err := ioutil.TempDir("", "dcrpool_test_pgconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	certPath, keyPath := writeTestCert(t, d)
+	invalidPath := filepath.Join(d, "invalid.crt")
+	err = ioutil.WriteFile(invalidPath, []byte("invalid"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dsnTests := []struct {
+		name string
+		cfg  PostgresConfig
+		dsn  string
+	}{{
+		name: "connection details",
+		cfg: PostgresConfig{Host: "127.0.0.1", Port: 5432, User: "user",
+			Pass: `it's \ secret`, DBName: "dcrpool"},
+		dsn: `host='127.0.0.1' port=5432 user='user' ` +
+			`password='it\'s \\ secret' dbname='dcrpool' sslmode=disable`,
+	}, {
+		name: "tls and timeouts",
+		cfg: PostgresConfig{Host: "db", Port: 5432, User: "user",
+			DBName: "dcrpool", SSLMode: PGSSLVerifyFull, SSLRootCert: certPath,
+			ConnectTimeout:   1500 * time.Millisecond,
+			StatementTimeout: 30 * time.Second},
+		dsn: `host='db' port=5432 user='user' password='' dbname='dcrpool' ` +
+			`sslmode=verify-full sslrootcert='` + certPath + `' ` +
+			`connect_timeout=2 statement_timeout=30000`,
+	}, {
+		name: "client certificate",
+		cfg: PostgresConfig{DSN: "host=db", SSLMode: PGSSLRequire,
+			SSLCert: certPath, SSLKey: keyPath},
+		dsn: `host=db sslmode=require sslcert='` + certPath + `' ` +
+			`sslkey='` + keyPath + `'`,
+	}, {
+		name: "key/value dsn",
+		cfg: PostgresConfig{Host: "ignored", DSN: "host=db dbname=dcrpool",
+			SSLMode: PGSSLRequire},
+		dsn: "host=db dbname=dcrpool sslmode=require",
+	}, {
+		name: "url dsn",
+		cfg:  PostgresConfig{DSN: "postgres://user@db:5433/dcrpool"},
+		dsn:  "dbname=dcrpool host=db port=5433 user=user",
+	}, {
+		name: "read-only",
+		cfg:  PostgresConfig{DSN: "host=db", ReadOnly: true},
+		dsn:  "host=db default_transaction_read_only=on",
+	}}
+	for _, test := range dsnTests {
+		err := test.cfg.validate()
+		if err != nil {
+			t.Fatalf("%s: validate: unexpected error: %v", test.name, err)
+		}
+		dsn, err := test.cfg.dsn()
+		if err != nil {
+			t.Fatalf("%s: dsn: unexpected error: %v", test.name, err)
+		}
+		if dsn != test.dsn {
+			t.Fatalf("%s: expected dsn %q, got %q", test.name, test.dsn, dsn)
+		}
+	}
+
+	invalidTests := []struct {
+		name string
+		cfg  PostgresConfig
+	}{{
+		name: "unknown ssl mode",
+		cfg:  PostgresConfig{SSLMode: "prefer"},
+	}, {
+		name: "negative limit",
+		cfg:  PostgresConfig{MaxOpenConns: -1},
+	}, {
+		name: "certificate without ssl",
+		cfg:  PostgresConfig{SSLMode: PGSSLDisable, SSLRootCert: certPath},
+	}, {
+		name: "missing root certificate",
+		cfg: PostgresConfig{SSLMode: PGSSLVerifyCA,
+			SSLRootCert: filepath.Join(d, "missing.crt")},
+	}, {
+		name: "invalid root certificate",
+		cfg:  PostgresConfig{SSLMode: PGSSLVerifyCA, SSLRootCert: invalidPath},
+	}, {
+		name: "client certificate without key",
+		cfg:  PostgresConfig{SSLMode: PGSSLRequire, SSLCert: certPath},
+	}, {
+		name: "mismatched client key",
+		cfg: PostgresConfig{SSLMode: PGSSLRequire, SSLCert: certPath,
+			SSLKey: invalidPath},
+	}, {
+		name: "invalid url dsn",
+		cfg:  PostgresConfig{DSN: "postgres://db:port/dcrpool"},
+	}}
+	for _, test := range invalidTests {
+		_, err := openPostgresDB(&test.cfg)
+		if !errors.Is(err, errs.DBOpen) {
+			t.Fatalf("%s: expected a db open error, got %v", test.name, err)
+		}
+	}
+
+	// Ensure an unreachable database fails right away.
+	_, err = openPostgresDB(&PostgresConfig{Host: "127.0.0.1", Port: 1,
+		User: pgUser, DBName: pgDBName, ConnectTimeout: time.Second})
+	if !errors.Is(err, errs.DBOpen) {
+		t.Fatalf("expected a db open error, got %v", err)
+	}
+}
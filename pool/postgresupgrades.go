@@ -0,0 +1,351 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// pgInitialVersion is the first version of the postgres schema. It
+	// creates the metadata, account, payment, archived payment, job, share,
+	// accepted work and hash data tables. Databases created before the
+	// schema was versioned are at this version.
+	pgInitialVersion = 1
+
+	// pgAPITokenVersion is the second version of the postgres schema.
+	// It adds the api tokens table.
+	pgAPITokenVersion = 2
+
+	// pgAdminUserVersion is the third version of the postgres schema.
+	// It adds the admin users and audit log tables.
+	pgAdminUserVersion = 3
+
+	// pgBanVersion is the fourth version of the postgres schema.
+	// It adds the bans table.
+	pgBanVersion = 4
+
+	// pgLedgerVersion is the fifth version of the postgres schema.
+	// It adds the ledger table and books the existing payments to the
+	// ledger.
+	pgLedgerVersion = 5
+
+	// pgBlockEventVersion is the sixth version of the postgres schema.
+	// It adds the block events table.
+	pgBlockEventVersion = 6
+
+	// pgWorkStatusVersion is the seventh version of the postgres schema.
+	// It adds the status and orphaned by columns to the accepted work
+	// table, deriving the status of existing work from its confirmation.
+	pgWorkStatusVersion = 7
+
+	// pgWebhookVersion is the eighth version of the postgres schema.
+	// It adds the webhooks and webhook deliveries tables.
+	pgWebhookVersion = 8
+
+	// pgWorkerVersion is the ninth version of the postgres schema.
+	// It adds the workers and worker events tables.
+	pgWorkerVersion = 9
+
+	// PostgresDBVersion is the latest version of the postgres schema, which
+	// the sqlite backend shares, that is understood by the program.
+	// Databases with recorded versions higher than this will fail to open
+	// (meaning any upgrades prevent reverting to older software).
+	PostgresDBVersion = pgWorkerVersion
+
+	// pgUpgradeLockID identifies the advisory lock serializing schema
+	// upgrades of pools sharing a database.
+	pgUpgradeLockID = 0x6463727030
+)
+
+// sqlDialect represents the backend specific queries used to upgrade the
+// schema of a sql database.
+type sqlDialect struct {
+	// name is the name of the backend.
+	name string
+
+	// metadataExists returns whether the metadata table exists.
+	metadataExists string
+
+	// lockUpgrades, when set, serializes schema upgrades of pools sharing the
+	// database for the duration of the upgrade transaction.
+	lockUpgrades string
+}
+
+var postgresDialect = &sqlDialect{
+	name:           "postgres",
+	metadataExists: selectMetadataExists,
+	lockUpgrades:   lockSchemaUpgrades,
+}
+
+// pgUpgrades maps between old postgres schema versions and the upgrade
+// function to upgrade the schema to the next version.
+var pgUpgrades = [...]func(tx *sql.Tx) error{
+	pgInitialVersion - 1:    pgInitialUpgrade,
+	pgAPITokenVersion - 1:   pgAPITokenUpgrade,
+	pgAdminUserVersion - 1:  pgAdminUserUpgrade,
+	pgBanVersion - 1:        pgBanUpgrade,
+	pgLedgerVersion - 1:     pgLedgerUpgrade,
+	pgBlockEventVersion - 1: pgBlockEventUpgrade,
+	pgWorkStatusVersion - 1: pgWorkStatusUpgrade,
+	pgWebhookVersion - 1:    pgWebhookUpgrade,
+	pgWorkerVersion - 1:     pgWorkerUpgrade,
+}
+
+// fetchSchemaVersion returns the schema version of the database.
+func fetchSchemaVersion(tx *sql.Tx, dialect *sqlDialect) (uint32, error) {
+	const funcName = "fetchSchemaVersion"
+
+	var exists bool
+	err := tx.QueryRow(dialect.metadataExists).Scan(&exists)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to check metadata table: %v",
+			funcName, err)
+		return 0, errs.DBError(errs.FetchEntry, desc)
+	}
+	if !exists {
+		// The schema has not been created yet.
+		return 0, nil
+	}
+
+	var version string
+	err = tx.QueryRow(selectSchemaVersion).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The schema predates versioning.
+			return pgInitialVersion, nil
+		}
+		desc := fmt.Sprintf("%s: unable to fetch schema version: %v",
+			funcName, err)
+		return 0, errs.DBError(errs.FetchEntry, desc)
+	}
+
+	v, err := strconv.ParseUint(version, 10, 32)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to parse schema version: %v",
+			funcName, err)
+		return 0, errs.DBError(errs.Parse, desc)
+	}
+
+	return uint32(v), nil
+}
+
+// setSchemaVersion persists the provided schema version.
+func setSchemaVersion(tx *sql.Tx, version uint32) error {
+	const funcName = "setSchemaVersion"
+	_, err := tx.Exec(insertSchemaVersion, strconv.FormatUint(uint64(version), 10))
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to persist schema version: %v",
+			funcName, err)
+		return errs.DBError(errs.PersistEntry, desc)
+	}
+	return nil
+}
+
+// execUpgrade executes the provided schema upgrade statements.
+func execUpgrade(tx *sql.Tx, funcName string, stmts ...string) error {
+	for _, stmt := range stmts {
+		_, err := tx.Exec(stmt)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to upgrade schema: %v",
+				funcName, err)
+			return errs.DBError(errs.DBUpgrade, desc)
+		}
+	}
+	return nil
+}
+
+func pgInitialUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgInitialUpgrade", createTableMetadata,
+		createTableAccounts, createTablePayments, createTableArchivedPayments,
+		createTableJobs, createTableShares, createTableAcceptedWork,
+		createTableHashData)
+}
+
+// The tables added by the following upgrades were created without a schema
+// version before, the upgrades are therefore idempotent.
+
+func pgAPITokenUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgAPITokenUpgrade", createTableAPITokens)
+}
+
+func pgAdminUserUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgAdminUserUpgrade", createTableAdminUsers,
+		createTableAuditLog)
+}
+
+func pgBanUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgBanUpgrade", createTableBans)
+}
+
+func pgLedgerUpgrade(tx *sql.Tx) error {
+	const funcName = "pgLedgerUpgrade"
+
+	err := execUpgrade(tx, funcName, createTableLedger)
+	if err != nil {
+		return err
+	}
+
+	// Book the pending and archived payments made before the ledger was
+	// introduced.
+	fetchPayments := func(query string) ([]*Payment, error) {
+		rows, err := tx.Query(query)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to fetch payments: %v",
+				funcName, err)
+			return nil, errs.DBError(errs.DBUpgrade, desc)
+		}
+		defer rows.Close()
+		return decodePaymentRows(rows)
+	}
+
+	pending, err := fetchPayments(selectPayments)
+	if err != nil {
+		return err
+	}
+	archived, err := fetchPayments(selectArchivedPayments)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range backfillLedgerEntries(pending, archived) {
+		_, err := tx.Exec(insertLedgerEntry, e.UUID, e.Kind, e.Debit,
+			e.Credit, int64(e.Amount), e.BlockHash, e.TxID, e.Actor, e.Memo,
+			e.CreatedOn)
+		if err != nil {
+			desc := fmt.Sprintf("%s: unable to persist ledger entry: %v",
+				funcName, err)
+			return errs.DBError(errs.DBUpgrade, desc)
+		}
+	}
+	return nil
+}
+
+func pgBlockEventUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgBlockEventUpgrade", createTableBlockEvents)
+}
+
+func pgWorkStatusUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgWorkStatusUpgrade", addAcceptedWorkStatus,
+		addAcceptedWorkOrphanedBy, updateConfirmedWorkStatus)
+}
+
+func pgWebhookUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgWebhookUpgrade", createTableWebhooks,
+		createTableWebhookDeliveries)
+}
+
+func pgWorkerUpgrade(tx *sql.Tx) error {
+	return execUpgrade(tx, "pgWorkerUpgrade", createTableWorkers,
+		createTableWorkerEvents)
+}
+
+// upgradeSQLDB upgrades the schema of the provided sql database to the latest
+// version. All necessary upgrades are executed in order in a single
+// transaction so a failed upgrade leaves the schema unchanged.
+func upgradeSQLDB(db *sql.DB, dialect *sqlDialect) error {
+	const funcName = "upgradeSQLDB"
+
+	tx, err := db.Begin()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin upgrade tx: %v",
+			funcName, err)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	if dialect.lockUpgrades != "" {
+		_, err = tx.Exec(dialect.lockUpgrades, pgUpgradeLockID)
+		if err != nil {
+			_ = tx.Rollback()
+			desc := fmt.Sprintf("%s: unable to lock schema upgrades: %v",
+				funcName, err)
+			return errs.DBError(errs.DBUpgrade, desc)
+		}
+	}
+
+	version, err := fetchSchemaVersion(tx, dialect)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if version > PostgresDBVersion {
+		_ = tx.Rollback()
+		desc := fmt.Sprintf("%s: schema version %d is newer than the "+
+			"latest known version %d", funcName, version, PostgresDBVersion)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	if version == PostgresDBVersion {
+		// No upgrades necessary.
+		_ = tx.Rollback()
+		return nil
+	}
+
+	if version > 0 {
+		log.Infof("Upgrading %s database from version %d to %d",
+			dialect.name, version, PostgresDBVersion)
+	}
+
+	// Execute all necessary upgrades in order.
+	for _, upgrade := range pgUpgrades[version:] {
+		err := upgrade(tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	err = setSchemaVersion(tx, PostgresDBVersion)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to commit upgrade tx: %v",
+			funcName, err)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	return nil
+}
+
+// checkSQLSchemaVersion returns an error if the schema of the provided sql
+// database is not at the latest version. The schema is left unchanged.
+func checkSQLSchemaVersion(db *sql.DB, dialect *sqlDialect) error {
+	const funcName = "checkSQLSchemaVersion"
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to begin tx: %v", funcName, err)
+		return errs.DBError(errs.FetchEntry, desc)
+	}
+	version, err := fetchSchemaVersion(tx, dialect)
+	_ = tx.Rollback()
+	if err != nil {
+		return err
+	}
+
+	if version != PostgresDBVersion {
+		desc := fmt.Sprintf("%s: %s schema version %d is not the latest "+
+			"version %d", funcName, dialect.name, version, PostgresDBVersion)
+		return errs.DBError(errs.DBUpgrade, desc)
+	}
+
+	return nil
+}
+
+// upgradePostgresDB upgrades the postgres schema to the latest version.
+func upgradePostgresDB(db *PostgresDB) error {
+	return upgradeSQLDB(db.DB, postgresDialect)
+}
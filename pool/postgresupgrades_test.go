@@ -0,0 +1,280 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+var postgresDBUpgradeTests = [...]struct {
+	verify   func(*testing.T, *PostgresDB)
+	filename string // in testdata directory
+}{
+	{verifyPgInitialUpgrade, "pg_v1.sql"},
+	{verifyPgAPITokenUpgrade, "pg_v2.sql"},
+	{verifyPgLedgerUpgrade, "pg_v4.sql"},
+	{verifyPgWorkStatusUpgrade, "pg_v6.sql"},
+}
+
+// openPostgresTestDB connects to an empty postgres test database without
+// creating the schema.
+func openPostgresTestDB() (*PostgresDB, error) {
+	pdb, err := openPostgresDB(testPGConfig())
+	if err != nil {
+		return nil, err
+	}
+	err = pdb.purge()
+	if err != nil {
+		pdb.Close()
+		return nil, err
+	}
+	return pdb, nil
+}
+
+func TestPostgresDBUpgrades(t *testing.T) {
+	pdb, err := openPostgresDB(testPGConfig())
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	pdb.Close()
+
+	for i, test := range postgresDBUpgradeTests {
+		test := test
+		name := fmt.Sprintf("test%d", i)
+		t.Run(name, func(t *testing.T) {
+			snapshot, err := ioutil.ReadFile(filepath.Join("testdata",
+				test.filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pdb, err := openPostgresTestDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				pdb.purge()
+				pdb.Close()
+			}()
+			_, err = pdb.DB.Exec(string(snapshot))
+			if err != nil {
+				t.Fatalf("unable to load snapshot: %v", err)
+			}
+			err = upgradePostgresDB(pdb)
+			if err != nil {
+				t.Fatalf("Upgrade failed: %v", err)
+			}
+			verifyPgSchemaVersion(t, pdb, postgresDialect, PostgresDBVersion)
+			test.verify(t, pdb)
+
+			// Ensure upgrading an up to date schema is a no-op.
+			err = upgradePostgresDB(pdb)
+			if err != nil {
+				t.Fatalf("Repeated upgrade failed: %v", err)
+			}
+			verifyPgSchemaVersion(t, pdb, postgresDialect, PostgresDBVersion)
+		})
+	}
+
+	t.Run("fresh", func(t *testing.T) {
+		pdb, err := openPostgresTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			pdb.purge()
+			pdb.Close()
+		}()
+		err = upgradePostgresDB(pdb)
+		if err != nil {
+			t.Fatalf("Upgrade failed: %v", err)
+		}
+		verifyPgSchemaVersion(t, pdb, postgresDialect, PostgresDBVersion)
+		verifyPgLatestTables(t, pdb)
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		pdb, err := openPostgresTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			pdb.purge()
+			pdb.Close()
+		}()
+		err = upgradePostgresDB(pdb)
+		if err != nil {
+			t.Fatalf("Upgrade failed: %v", err)
+		}
+		_, err = pdb.DB.Exec(insertSchemaVersion,
+			fmt.Sprint(PostgresDBVersion+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Ensure a schema newer than understood is rejected.
+		err = upgradePostgresDB(pdb)
+		if !errors.Is(err, errs.DBUpgrade) {
+			t.Fatalf("expected a db upgrade error, got %v", err)
+		}
+	})
+}
+
+func TestSQLiteDBUpgrades(t *testing.T) {
+	t.Parallel()
+
+	d, err := ioutil.TempDir("", "dcrpool_test_sqlite_upgrades")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	// The sqlite backend shares the postgres schema snapshots.
+	for i, test := range postgresDBUpgradeTests {
+		test := test
+		name := fmt.Sprintf("test%d", i)
+		t.Run(name, func(t *testing.T) {
+			snapshot, err := ioutil.ReadFile(filepath.Join("testdata",
+				test.filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sdb, err := InitSQLiteDB(filepath.Join(d, name+".db"), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sdb.Close()
+			err = sdb.purge()
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = sdb.DB.Exec(string(snapshot))
+			if err != nil {
+				t.Fatalf("unable to load snapshot: %v", err)
+			}
+			err = upgradeSQLDB(sdb.DB, sqliteDialect)
+			if err != nil {
+				t.Fatalf("Upgrade failed: %v", err)
+			}
+			verifyPgSchemaVersion(t, sdb.PostgresDB, sqliteDialect,
+				PostgresDBVersion)
+			test.verify(t, sdb.PostgresDB)
+		})
+	}
+}
+
+// verifyPgSchemaVersion ensures the recorded schema version of the provided
+// database matches the expected version.
+func verifyPgSchemaVersion(t *testing.T, pdb *PostgresDB, dialect *sqlDialect, expected uint32) {
+	t.Helper()
+	tx, err := pdb.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	version, err := fetchSchemaVersion(tx, dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != expected {
+		t.Fatalf("expected schema version %d, got %d", expected, version)
+	}
+}
+
+// verifyPgLatestTables ensures the tables added by every upgrade are usable.
+func verifyPgLatestTables(t *testing.T, pdb *PostgresDB) {
+	t.Helper()
+	_, token, err := newAPIToken(xID, APITokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pdb.persistAPIToken(token)
+	if err != nil {
+		t.Fatalf("unable to persist api token: %v", err)
+	}
+	user, err := newAdminUser("admin", "password", RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pdb.persistAdminUser(user)
+	if err != nil {
+		t.Fatalf("unable to persist admin user: %v", err)
+	}
+	err = pdb.persistAuditEntry(newAuditEntry("admin", AuditLogin, "",
+		"127.0.0.1"))
+	if err != nil {
+		t.Fatalf("unable to persist audit entry: %v", err)
+	}
+	err = pdb.persistBan(newBan("127.0.0.1", IPBanKind, "spam"))
+	if err != nil {
+		t.Fatalf("unable to persist ban: %v", err)
+	}
+	work := NewAcceptedWork("blockhash", "prevhash", 10, xID, CPU)
+	work.setStatus(WorkOrphaned)
+	work.OrphanedBy = "orphanedby"
+	err = pdb.persistAcceptedWork(work)
+	if err != nil {
+		t.Fatalf("unable to persist accepted work: %v", err)
+	}
+	fetched, err := pdb.fetchAcceptedWork(work.UUID)
+	if err != nil || fetched.Status != WorkOrphaned ||
+		fetched.OrphanedBy != work.OrphanedBy {
+		t.Fatalf("expected orphaned work, got %v (%v)", fetched, err)
+	}
+}
+
+func verifyPgInitialUpgrade(t *testing.T, pdb *PostgresDB) {
+	// Ensure the existing entities are unaltered.
+	mode, err := pdb.fetchPoolMode()
+	if err != nil || mode != 0 {
+		t.Fatalf("expected pool mode 0, got %d (%v)", mode, err)
+	}
+	secret, err := pdb.fetchCSRFSecret()
+	if err != nil || string(secret) != "csrfsecret1" {
+		t.Fatalf("expected csrf secret, got %q (%v)", secret, err)
+	}
+	account, err := pdb.fetchAccount("accountx")
+	if err != nil || account.Address != xAddr {
+		t.Fatalf("expected account %s, got %v (%v)", xAddr, account, err)
+	}
+	pmt, err := pdb.fetchPayment("paymenta")
+	if err != nil || pmt.Amount != 5000000000 || pmt.Height != 10 {
+		t.Fatalf("expected payment of height 10, got %v (%v)", pmt, err)
+	}
+	archived, err := pdb.archivedPayments()
+	if err != nil || len(archived) != 1 || archived[0].TransactionID != "txid" {
+		t.Fatalf("expected one archived payment, got %v (%v)", archived, err)
+	}
+	share, err := pdb.fetchShare("sharea")
+	if err != nil || share.Account != "accountx" {
+		t.Fatalf("expected share of accountx, got %v (%v)", share, err)
+	}
+
+	verifyPgLatestTables(t, pdb)
+}
+
+func verifyPgAPITokenUpgrade(t *testing.T, pdb *PostgresDB) {
+	// Ensure the existing api token is unaltered.
+	token, err := pdb.fetchAPIToken("tokena")
+	if err != nil || token.AccountID != "accountx" ||
+		token.Kind != APITokenKind {
+		t.Fatalf("expected api token of accountx, got %v (%v)", token, err)
+	}
+
+	verifyPgLatestTables(t, pdb)
+}
+
+func verifyPgLedgerUpgrade(t *testing.T, pdb *PostgresDB) {
+	verifyBackfilledLedger(t, pdb, "accountx", "accounty")
+	verifyPgLatestTables(t, pdb)
+}
+
+func verifyPgWorkStatusUpgrade(t *testing.T, pdb *PostgresDB) {
+	verifyWorkStatus(t, pdb, "worka", WorkConfirmed)
+	verifyWorkStatus(t, pdb, "workb", WorkSubmitted)
+	verifyPgLatestTables(t, pdb)
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"math/big"
+	"sync"
 	"time"
 )
 
@@ -51,3 +52,61 @@ func NewShare(account string, weight *big.Rat) *Share {
 		CreatedOn: now,
 	}
 }
+
+// newSliceShare creates a share with the provided account and weight which
+// aggregates all shares of the account created within the same time slice
+// of the provided duration. Slices are split at the provided cutoff, shares
+// created after it are aggregated separately from the shares created before
+// it. The share is timestamped at the start of the slice, or right after the
+// cutoff when split.
+func newSliceShare(account string, weight *big.Rat, createdOn int64, slice time.Duration, cutoff int64) *Share {
+	sliceNano := int64(slice)
+	start := createdOn - createdOn%sliceNano
+	if cutoff >= start && cutoff < createdOn {
+		start = cutoff + 1
+	}
+	return &Share{
+		UUID:      shareID(account, start),
+		Account:   account,
+		Weight:    weight,
+		CreatedOn: start,
+	}
+}
+
+// shareSlicer aggregates the shares claimed by accounts over time slices.
+// Slices are split at the time blocks are found, which makes aggregated
+// shares paid for by PPS exactly like individually persisted shares.
+type shareSlicer struct {
+	slice  time.Duration
+	cutoff int64
+	mtx    sync.Mutex
+}
+
+// newShareSlicer creates a share slicer aggregating shares over slices of
+// the provided duration. Blocks found before the slicer was created are not
+// known to it, the current slices are split at creation.
+func newShareSlicer(slice time.Duration) *shareSlicer {
+	return &shareSlicer{
+		slice:  slice,
+		cutoff: time.Now().UnixNano(),
+	}
+}
+
+// share creates a share with the provided account and weight which
+// aggregates all shares of the account claimed within the current slice.
+func (s *shareSlicer) share(account string, weight *big.Rat) *Share {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return newSliceShare(account, weight, time.Now().UnixNano(), s.slice,
+		s.cutoff)
+}
+
+// cut splits the current slices of all accounts and returns the cutoff time.
+// Shares claimed after the cutoff are not aggregated with shares claimed
+// before it.
+func (s *shareSlicer) cut() int64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.cutoff = time.Now().UnixNano()
+	return s.cutoff
+}
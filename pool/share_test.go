@@ -288,3 +288,131 @@ func testPruneShares(t *testing.T) {
 		t.Fatalf("expected value not found error, got %v", err)
 	}
 }
+
+func testShareAggregation(t *testing.T) {
+	mgr, err := createPaymentMgr(PPS)
+	if err != nil {
+		t.Fatalf("[createPaymentMgr] unexpected error: %v", err)
+	}
+
+	// Create shares for accounts x and y spread over six one second slices,
+	// with uneven weights and share rates.
+	slice := time.Second
+	start := time.Now().Truncate(slice).Add(-time.Minute).UnixNano()
+	type rawShare struct {
+		account   string
+		weight    *big.Rat
+		createdOn int64
+	}
+	var raw []rawShare
+	for i := int64(0); i < 30; i++ {
+		raw = append(raw, rawShare{xID, big.NewRat(1, 3),
+			start + i*int64(200*time.Millisecond)})
+		if i%3 == 0 {
+			raw = append(raw, rawShare{yID, big.NewRat(2, 7),
+				start + i*int64(200*time.Millisecond) + 1})
+		}
+	}
+
+	// Blocks are found within the second and fourth slices, off the slice
+	// edges.
+	cutoffs := []int64{
+		start + int64(1300*time.Millisecond),
+		start + int64(3500*time.Millisecond),
+	}
+
+	// percentages returns the PPS percentages due each account for each
+	// block found.
+	percentages := func() []map[string]*big.Rat {
+		pps := make([]map[string]*big.Rat, 0, len(cutoffs))
+		for _, cutoff := range cutoffs {
+			p, err := mgr.PPSSharePercentages(cutoff)
+			if err != nil {
+				t.Fatalf("PPSSharePercentages: unexpected error: %v", err)
+			}
+			pps = append(pps, p)
+		}
+		return pps
+	}
+
+	for _, s := range raw {
+		err := persistShare(db, s.account, s.weight, s.createdOn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	rawPPS := percentages()
+
+	err = db.pruneShares(time.Now().Add(time.Hour).UnixNano())
+	if err != nil {
+		t.Fatalf("pruneShares: unexpected error: %v", err)
+	}
+
+	// Aggregate the shares the way the share slicer does, splitting slices
+	// at the latest block found before each share.
+	for _, s := range raw {
+		var cutoff int64
+		for _, c := range cutoffs {
+			if c < s.createdOn {
+				cutoff = c
+			}
+		}
+		share := newSliceShare(s.account, s.weight, s.createdOn, slice,
+			cutoff)
+		err := db.aggregateShare(share)
+		if err != nil {
+			t.Fatalf("aggregateShare: unexpected error: %v", err)
+		}
+	}
+
+	// Ensure each account has a single share per slice, or two for the
+	// slices split by a block found.
+	shares, err := db.ppsEligibleShares(time.Now().UnixNano())
+	if err != nil {
+		t.Fatalf("ppsEligibleShares: unexpected error: %v", err)
+	}
+	if len(shares) != 16 {
+		t.Fatalf("expected 16 aggregated shares, got %d", len(shares))
+	}
+	for _, share := range shares {
+		if (share.CreatedOn-start)%int64(slice) != 0 &&
+			share.CreatedOn != cutoffs[0]+1 &&
+			share.CreatedOn != cutoffs[1]+1 {
+			t.Fatalf("expected share created at the start of a slice or "+
+				"right after a block found, got %d", share.CreatedOn)
+		}
+	}
+
+	// Ensure the aggregated shares are paid exactly like the individual
+	// shares by each block found.
+	aggPPS := percentages()
+	for i := range cutoffs {
+		if len(aggPPS[i]) != 2 || len(rawPPS[i]) != len(aggPPS[i]) {
+			t.Fatalf("block %d: expected percentages for accounts x and "+
+				"y, got %d and %d", i, len(rawPPS[i]), len(aggPPS[i]))
+		}
+		for account, percentage := range rawPPS[i] {
+			if aggPPS[i][account] == nil ||
+				aggPPS[i][account].Cmp(percentage) != 0 {
+				t.Fatalf("block %d: expected percentage %v for account "+
+					"%s, got %v", i, percentage, account,
+					aggPPS[i][account])
+			}
+		}
+	}
+
+	err = db.pruneShares(time.Now().Add(time.Hour).UnixNano())
+	if err != nil {
+		t.Fatalf("pruneShares: unexpected error: %v", err)
+	}
+
+	// Ensure the share slicer splits the current slices when cut.
+	slicer := newShareSlicer(time.Hour)
+	before := slicer.share(xID, big.NewRat(1, 1))
+	cutoff := slicer.cut()
+	after := slicer.share(xID, big.NewRat(1, 1))
+	if before.CreatedOn > cutoff || after.CreatedOn != cutoff+1 {
+		t.Fatalf("expected shares created around cutoff %d, got %d and %d",
+			cutoff, before.CreatedOn, after.CreatedOn)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/decred/dcrpool/pool"
+)
+
+const (
+	// simnetAddrX and simnetAddrY are the addresses of the harness miners.
+	simnetAddrX = "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"
+	simnetAddrY = "Ssp7J7TUmi5iPhoQnWYNGQbeGhu6V3otJcS"
+)
+
+// decodeAddr decodes the provided simnet address.
+func decodeAddr(t *testing.T, addr string) dcrutil.Address {
+	t.Helper()
+	decoded, err := dcrutil.DecodeAddress(addr, chaincfg.SimNetParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// TestSimnetPayout ensures rewards of blocks mined by the pool are paid
+// out to the participating accounts once the blocks mature.
+func TestSimnetPayout(t *testing.T) {
+	for _, method := range []string{pool.PPS, pool.PPLNS} {
+		method := method
+		t.Run(method, func(t *testing.T) {
+			testSimnetPayout(t, method)
+		})
+	}
+}
+
+func testSimnetPayout(t *testing.T, paymentMethod string) {
+	h := newSimnetHarness(t, paymentMethod)
+	xID, yID := pool.AccountID(simnetAddrX), pool.AccountID(simnetAddrY)
+	h.startMiner(simnetAddrX, "x")
+	h.startMiner(simnetAddrY, "y")
+	h.waitForShares(xID, yID)
+
+	// Ensure a block mined by the pool is confirmed by the next block and
+	// payments are generated for the participating accounts.
+	work := h.mineBlock()
+	minedBlock := h.dcrd.blockAt(work.Height)
+	if minedBlock.BlockHash().String() != work.BlockHash {
+		t.Fatalf("expected mined block %s at height #%d, got %s",
+			work.BlockHash, work.Height, minedBlock.BlockHash())
+	}
+	h.dcrd.generate(1)
+	h.waitForChainTip()
+	h.waitForWork(work.BlockHash, pool.WorkConfirmed)
+
+	maturity := work.Height + uint32(h.net.CoinbaseMaturity)
+	pending, err := h.hub.FetchPendingPayments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total dcrutil.Amount
+	accounts := make(map[string]bool)
+	for _, pmt := range pending {
+		if pmt.EstimatedMaturity != maturity ||
+			pmt.Source.BlockHash != work.BlockHash {
+			t.Fatalf("unexpected payment %+v", pmt)
+		}
+		accounts[pmt.Account] = true
+		total += pmt.Amount
+	}
+	if !accounts[xID] || !accounts[yID] || !accounts[pool.PoolFeesK] {
+		t.Fatalf("expected payments for both miners and the pool fees, "+
+			"got %d payments", len(pending))
+	}
+	if total != simnetCoinbaseValue {
+		t.Fatalf("expected payments totalling %v, got %v",
+			simnetCoinbaseValue, total)
+	}
+
+	// Ensure a reorg replacing the confirming block reconfirms the mined
+	// work without generating payments twice.
+	h.dcrd.reorg(1, 1)
+	h.waitForChainTip()
+	h.waitForWork(work.BlockHash, pool.WorkConfirmed)
+	reorged, err := h.hub.FetchPendingPayments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reorged) != len(pending) {
+		t.Fatalf("expected %d payments after the reorg, got %d",
+			len(pending), len(reorged))
+	}
+
+	// Ensure the mined work matures and its rewards are paid out once its
+	// coinbase is spendable.
+	h.dcrd.generate(int(maturity + 1 - h.dcrd.height()))
+	h.waitForChainTip()
+	h.waitForWork(work.BlockHash, pool.WorkMatured)
+	var payout *wire.MsgTx
+	h.waitFor("payout", func() (bool, error) {
+		txs := h.wallet.publishedTxs()
+		if len(txs) > 0 {
+			payout = txs[0]
+		}
+		return len(txs) > 0, nil
+	})
+	if len(h.wallet.publishedTxs()) != 1 {
+		t.Fatalf("expected a single payout")
+	}
+	coinbase := minedBlock.Transactions[0].TxHash()
+	if len(payout.TxIn) != 1 ||
+		payout.TxIn[0].PreviousOutPoint.Hash != coinbase ||
+		payout.TxIn[0].PreviousOutPoint.Index != 1 {
+		t.Fatalf("expected the payout to spend coinbase %s", coinbase)
+	}
+
+	var paid int64
+	for _, out := range payout.TxOut {
+		paid += out.Value
+	}
+	fees := paysTo(payout, decodeAddr(t, simnetFeeAddr))
+	if fees != int64(simnetCoinbaseValue)/10 {
+		t.Fatalf("expected pool fees of %v, got %v", simnetCoinbaseValue/10,
+			dcrutil.Amount(fees))
+	}
+	if paysTo(payout, decodeAddr(t, simnetAddrX)) == 0 ||
+		paysTo(payout, decodeAddr(t, simnetAddrY)) == 0 {
+		t.Fatalf("expected the payout to pay both miners")
+	}
+	if paid >= int64(simnetCoinbaseValue) || paid+5e5 < int64(simnetCoinbaseValue) {
+		t.Fatalf("expected the payout to pay %v minus the tx fee, got %v",
+			simnetCoinbaseValue, dcrutil.Amount(paid))
+	}
+
+	txid := payout.TxHash().String()
+	h.waitFor("archived payments", func() (bool, error) {
+		archived, err := h.hub.FetchArchivedPayments()
+		if err != nil {
+			return false, err
+		}
+		for _, pmt := range archived {
+			if pmt.TransactionID != txid {
+				return false, nil
+			}
+		}
+		return len(archived) == len(pending), nil
+	})
+	pending, err = h.hub.FetchPendingPayments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending payments, got %d", len(pending))
+	}
+
+	// Ensure the payout is mined.
+	h.dcrd.generate(1)
+	h.waitForChainTip()
+	_, err = h.dcrd.GetTxOut(context.Background(), &coinbase, 1, false)
+	if err == nil {
+		t.Fatalf("expected the coinbase to be spent by the payout")
+	}
+}
+
+// TestSimnetReorg ensures blocks mined by the pool which are reorganized
+// out of the chain are orphaned and their rewards are never paid out.
+func TestSimnetReorg(t *testing.T) {
+	h := newSimnetHarness(t, pool.PPLNS)
+	xID := pool.AccountID(simnetAddrX)
+	h.startMiner(simnetAddrX, "x")
+	h.waitForShares(xID)
+
+	work := h.mineBlock()
+	h.dcrd.generate(1)
+	h.waitForChainTip()
+	h.waitForWork(work.BlockHash, pool.WorkConfirmed)
+	balances, err := h.hub.FetchLedgerBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[xID] <= 0 {
+		t.Fatalf("expected rewards booked for account %s", xID)
+	}
+
+	// Ensure mined work reorganized out of the chain is orphaned by the
+	// block replacing it.
+	replaced := h.dcrd.reorg(2, 3)
+	h.waitForChainTip()
+	orphaned := h.waitForWork(work.BlockHash, pool.WorkOrphaned)
+	if orphaned.OrphanedBy != replaced[0].BlockHash().String() {
+		t.Fatalf("expected work orphaned by %s, got %q",
+			replaced[0].BlockHash(), orphaned.OrphanedBy)
+	}
+
+	// Ensure the payments of orphaned work are pruned and their rewards
+	// reversed once they would have matured.
+	maturity := work.Height + uint32(h.net.CoinbaseMaturity)
+	h.dcrd.generate(int(maturity + 2 - h.dcrd.height()))
+	h.waitForChainTip()
+	pending, err := h.hub.FetchPendingPayments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending payments, got %d", len(pending))
+	}
+	if len(h.wallet.publishedTxs()) != 0 {
+		t.Fatalf("expected no payouts for orphaned work")
+	}
+	balances, err = h.hub.FetchLedgerBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[xID] != 0 {
+		t.Fatalf("expected the rewards of account %s reversed, got %v",
+			xID, balances[xID])
+	}
+}
@@ -0,0 +1,914 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"decred.org/dcrwallet/rpc/walletrpc"
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	chainjson "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/dcrd/rpcclient/v6"
+	"github.com/decred/dcrd/wire"
+	"google.golang.org/grpc"
+
+	"github.com/decred/dcrpool/internal/cpuminer"
+	"github.com/decred/dcrpool/pool"
+)
+
+const (
+	// simnetWalletAddr is the address of the pool wallet coinbases pay to.
+	simnetWalletAddr = "Ssj6Sd54j11JM8qpenCwfwnKD73dsjm68ru"
+
+	// simnetFeeAddr is the pool fee address of the harness pool.
+	simnetFeeAddr = "SsnbEmxCVXskgTHXvf3rEa17NA39qQuGHwQ"
+
+	// simnetCoinbaseValue is the value of the coinbase output of every
+	// block mined on the harness chain.
+	simnetCoinbaseValue = dcrutil.Amount(30e8)
+
+	// simnetWaitTimeout is the maximum time the harness waits for the
+	// pool to reach an expected state.
+	simnetWaitTimeout = time.Second * 60
+
+	// simnetMaxBlockEventAttempts is the number of times the pool may fail
+	// to process a block notification before the harness fails.
+	simnetMaxBlockEventAttempts = 5
+)
+
+// p2pkhScript returns the pay-to-pubkey-hash script paying to the provided
+// address.
+func p2pkhScript(addr dcrutil.Address) []byte {
+	script := []byte{0x76, 0xa9, 0x14} // OP_DUP OP_HASH160 OP_DATA_20
+	script = append(script, addr.Hash160()[:]...)
+	return append(script, 0x88, 0xac) // OP_EQUALVERIFY OP_CHECKSIG
+}
+
+// getworkPad returns the blake256 padding appended to block headers in
+// getwork data.
+func getworkPad() []byte {
+	pad := make([]byte, 192-wire.MaxBlockHeaderPayload)
+	pad[0] = 0x80
+	pad[len(pad)-9] |= 0x01
+	binary.BigEndian.PutUint64(pad[len(pad)-8:], wire.MaxBlockHeaderPayload*8)
+	return pad
+}
+
+// simnetDcrd is a scripted simnet mining node. It maintains a main chain of
+// blocks extended by solved blocks submitted by the pool and by blocks it
+// generates itself, and delivers work and block notifications to the pool
+// in order the way the websocket notifications of dcrd are delivered.
+//
+// Solved blocks are only accepted while the script expects them, see
+// acceptBlocks.
+type simnetDcrd struct {
+	net      *chaincfg.Params
+	payTo    []byte
+	handlers *rpcclient.NotificationHandlers
+	notifCh  chan func()
+	blocks   map[chainhash.Hash]*wire.MsgBlock
+	chain    []*wire.MsgBlock
+	mempool  []*wire.MsgTx
+	spent    map[wire.OutPoint]struct{}
+	accept   int
+	nonce    uint32
+	tipCh    chan struct{}
+	mtx      sync.Mutex
+}
+
+// newSimnetDcrd creates a scripted mining node with a chain of the simnet
+// genesis block extended by the provided number of blocks.
+func newSimnetDcrd(net *chaincfg.Params, payTo dcrutil.Address, blocks int) *simnetDcrd {
+	genesis := net.GenesisBlock
+	d := &simnetDcrd{
+		net:     net,
+		payTo:   p2pkhScript(payTo),
+		notifCh: make(chan func(), 1024),
+		blocks:  map[chainhash.Hash]*wire.MsgBlock{genesis.BlockHash(): genesis},
+		chain:   []*wire.MsgBlock{genesis},
+		spent:   make(map[wire.OutPoint]struct{}),
+		tipCh:   make(chan struct{}),
+	}
+	d.generate(blocks)
+
+	// The pool starts from the chain tip, the initial blocks are not
+	// notified.
+	for len(d.notifCh) > 0 {
+		<-d.notifCh
+	}
+	return d
+}
+
+// run delivers queued notifications to the notification handlers of the
+// pool until the provided context is cancelled. It must be run as a
+// goroutine.
+func (d *simnetDcrd) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notify := <-d.notifCh:
+			notify()
+		}
+	}
+}
+
+// tip returns the chain tip. The caller must hold the lock.
+func (d *simnetDcrd) tip() *wire.MsgBlock {
+	return d.chain[len(d.chain)-1]
+}
+
+// height returns the height of the chain tip.
+func (d *simnetDcrd) height() uint32 {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.tip().Header.Height
+}
+
+// blockAt returns the main chain block at the provided height.
+func (d *simnetDcrd) blockAt(height uint32) *wire.MsgBlock {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if int(height) >= len(d.chain) {
+		return nil
+	}
+	return d.chain[height]
+}
+
+// template returns the header of the next block extending the chain tip.
+// The caller must hold the lock.
+func (d *simnetDcrd) template() wire.BlockHeader {
+	tip := d.tip()
+	return wire.BlockHeader{
+		Version:   9,
+		PrevBlock: tip.BlockHash(),
+		VoteBits:  1,
+		Bits:      d.net.PowLimitBits,
+		Height:    tip.Header.Height + 1,
+		Timestamp: time.Unix(time.Now().Unix(), 0),
+	}
+}
+
+// workData returns the getwork data of the next block extending the chain
+// tip. The caller must hold the lock.
+func (d *simnetDcrd) workData() ([]byte, error) {
+	header := d.template()
+	headerB, err := header.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(headerB, getworkPad()...), nil
+}
+
+// coinbase returns a coinbase transaction for a block at the provided
+// height paying to the pool wallet. The coinbases of blocks at the same
+// height are told apart by the provided nonce.
+func (d *simnetDcrd) coinbase(height uint32, nonce uint32) *wire.MsgTx {
+	sigScript := make([]byte, 9)
+	binary.LittleEndian.PutUint32(sigScript[0:4], height)
+	binary.LittleEndian.PutUint32(sigScript[4:8], nonce)
+
+	tx := wire.NewMsgTx()
+	tx.Version = wire.TxVersionTreasury
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular),
+		ValueIn:         int64(simnetCoinbaseValue),
+		SignatureScript: sigScript,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, []byte{0x6a})) // OP_RETURN
+	tx.AddTxOut(wire.NewTxOut(int64(simnetCoinbaseValue), d.payTo))
+	return tx
+}
+
+// connect extends the main chain with a block of the provided header,
+// mining the transactions in the mempool, and queues the block and work
+// notifications for it. The caller must hold the lock.
+func (d *simnetDcrd) connect(header wire.BlockHeader) (*wire.MsgBlock, error) {
+	d.nonce++
+	block := &wire.MsgBlock{Header: header}
+	block.Transactions = append([]*wire.MsgTx{d.coinbase(header.Height,
+		d.nonce)}, d.mempool...)
+	for _, tx := range d.mempool {
+		for _, in := range tx.TxIn {
+			d.spent[in.PreviousOutPoint] = struct{}{}
+		}
+	}
+	d.mempool = nil
+
+	hash := block.BlockHash()
+	d.blocks[hash] = block
+	d.chain = append(d.chain, block)
+	close(d.tipCh)
+	d.tipCh = make(chan struct{})
+
+	headerB, err := block.Header.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	work, err := d.workData()
+	if err != nil {
+		return nil, err
+	}
+	d.notifCh <- func() {
+		d.handlers.OnBlockConnected(headerB, nil)
+		d.handlers.OnWork(work, nil, pool.NewParent)
+	}
+	return block, nil
+}
+
+// disconnect removes the chain tip from the main chain, returning its
+// transactions to the mempool, and queues the block notification for it.
+// The caller must hold the lock.
+func (d *simnetDcrd) disconnect() error {
+	block := d.tip()
+	d.chain = d.chain[:len(d.chain)-1]
+	for _, tx := range block.Transactions[1:] {
+		for _, in := range tx.TxIn {
+			delete(d.spent, in.PreviousOutPoint)
+		}
+	}
+	d.mempool = append(block.Transactions[1:], d.mempool...)
+	close(d.tipCh)
+	d.tipCh = make(chan struct{})
+
+	headerB, err := block.Header.Bytes()
+	if err != nil {
+		return err
+	}
+	d.notifCh <- func() {
+		d.handlers.OnBlockDisconnected(headerB)
+	}
+	return nil
+}
+
+// generate extends the main chain with the provided number of blocks mined
+// by the mining node itself, returning them.
+func (d *simnetDcrd) generate(n int) []*wire.MsgBlock {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	blocks := make([]*wire.MsgBlock, 0, n)
+	for i := 0; i < n; i++ {
+		header := d.template()
+		header.Nonce = d.nonce
+		block, err := d.connect(header)
+		if err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// reorg replaces the provided number of blocks at the chain tip with the
+// provided number of blocks mined by the mining node itself, returning
+// them.
+func (d *simnetDcrd) reorg(depth int, n int) []*wire.MsgBlock {
+	d.mtx.Lock()
+	for i := 0; i < depth; i++ {
+		err := d.disconnect()
+		if err != nil {
+			panic(err)
+		}
+	}
+	d.mtx.Unlock()
+	return d.generate(n)
+}
+
+// acceptBlocks sets the number of solved blocks submitted by the pool the
+// mining node accepts, further solved blocks are rejected.
+func (d *simnetDcrd) acceptBlocks(n int) {
+	d.mtx.Lock()
+	d.accept = n
+	d.mtx.Unlock()
+}
+
+// tipChanged returns a channel closed when the chain tip changes.
+func (d *simnetDcrd) tipChanged() <-chan struct{} {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.tipCh
+}
+
+// findTx returns the main chain transaction with the provided hash along
+// with the height of its block.
+func (d *simnetDcrd) findTx(hash *chainhash.Hash) (*wire.MsgTx, uint32, bool) {
+	for _, block := range d.chain {
+		for _, tx := range block.Transactions {
+			if tx.TxHash() == *hash {
+				return tx, block.Header.Height, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// confirmations returns the confirmations of the main chain block at the
+// provided height.
+func (d *simnetDcrd) confirmations(height uint32) int64 {
+	return int64(d.tip().Header.Height) - int64(height) + 1
+}
+
+// sendTx adds the provided transaction to the mempool after ensuring it
+// spends mature unspent outputs and does not create value.
+func (d *simnetDcrd) sendTx(tx *wire.MsgTx) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var in, out int64
+	for _, txIn := range tx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+		if _, ok := d.spent[prevOut]; ok {
+			return fmt.Errorf("output %v already spent", prevOut)
+		}
+		prevTx, height, ok := d.findTx(&prevOut.Hash)
+		if !ok || int(prevOut.Index) >= len(prevTx.TxOut) {
+			return fmt.Errorf("output %v not found", prevOut)
+		}
+		if d.confirmations(height) < int64(d.net.CoinbaseMaturity)+1 {
+			return fmt.Errorf("output %v is immature", prevOut)
+		}
+		in += prevTx.TxOut[prevOut.Index].Value
+	}
+	for _, txOut := range tx.TxOut {
+		out += txOut.Value
+	}
+	if out > in {
+		return fmt.Errorf("outputs (%d) exceed inputs (%d)", out, in)
+	}
+	d.mempool = append(d.mempool, tx)
+	return nil
+}
+
+// GetTxOut returns the unspent main chain transaction output referenced
+// by the provided transaction hash and output index.
+func (d *simnetDcrd) GetTxOut(_ context.Context, txHash *chainhash.Hash, index uint32, _ bool) (*chainjson.GetTxOutResult, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	tx, height, ok := d.findTx(txHash)
+	if !ok || int(index) >= len(tx.TxOut) {
+		return nil, fmt.Errorf("output %s:%d not found", txHash, index)
+	}
+	if _, ok := d.spent[*wire.NewOutPoint(txHash, index,
+		wire.TxTreeRegular)]; ok {
+		return nil, fmt.Errorf("output %s:%d already spent", txHash, index)
+	}
+	return &chainjson.GetTxOutResult{
+		BestBlock:     d.tip().BlockHash().String(),
+		Confirmations: d.confirmations(height),
+		Value:         dcrutil.Amount(tx.TxOut[index].Value).ToCoin(),
+		Coinbase:      tx == d.chain[height].Transactions[0],
+	}, nil
+}
+
+// CreateRawTransaction creates an unsigned transaction spending the
+// provided inputs to the provided outputs.
+func (d *simnetDcrd) CreateRawTransaction(_ context.Context, inputs []chainjson.TransactionInput, amounts map[dcrutil.Address]dcrutil.Amount, _ *int64, _ *int64) (*wire.MsgTx, error) {
+	tx := wire.NewMsgTx()
+	for _, in := range inputs {
+		hash, err := chainhash.NewHashFromStr(in.Txid)
+		if err != nil {
+			return nil, err
+		}
+		amt, err := dcrutil.NewAmount(in.Amount)
+		if err != nil {
+			return nil, err
+		}
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, in.Vout, in.Tree),
+			int64(amt), nil))
+	}
+
+	addrs := make([]dcrutil.Address, 0, len(amounts))
+	for addr := range amounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].String() < addrs[j].String()
+	})
+	for _, addr := range addrs {
+		tx.AddTxOut(wire.NewTxOut(int64(amounts[addr]), p2pkhScript(addr)))
+	}
+	return tx, nil
+}
+
+// GetWorkSubmit connects the solved block of the provided getwork data if
+// it extends the chain tip, satisfies its target difficulty and a solved
+// block is expected.
+func (d *simnetDcrd) GetWorkSubmit(_ context.Context, data string) (bool, error) {
+	dataB, err := hex.DecodeString(data)
+	if err != nil {
+		return false, err
+	}
+	if len(dataB) < wire.MaxBlockHeaderPayload {
+		return false, fmt.Errorf("invalid getwork data length %d",
+			len(dataB))
+	}
+	var header wire.BlockHeader
+	err = header.FromBytes(dataB[:wire.MaxBlockHeaderPayload])
+	if err != nil {
+		return false, err
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if header.PrevBlock != d.tip().BlockHash() || d.accept == 0 {
+		return false, nil
+	}
+	hash := header.BlockHash()
+	target := standalone.CompactToBig(header.Bits)
+	if standalone.HashToBig(&hash).Cmp(target) > 0 {
+		return false, nil
+	}
+	_, err = d.connect(header)
+	if err != nil {
+		return false, err
+	}
+	d.accept--
+	return true, nil
+}
+
+// GetWork returns the getwork data of the next block extending the chain
+// tip.
+func (d *simnetDcrd) GetWork(context.Context) (*chainjson.GetWorkResult, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	work, err := d.workData()
+	if err != nil {
+		return nil, err
+	}
+	target := standalone.CompactToBig(d.net.PowLimitBits).Bytes()
+	return &chainjson.GetWorkResult{
+		Data:   hex.EncodeToString(work),
+		Target: hex.EncodeToString(target),
+	}, nil
+}
+
+// GetBlockVerbose returns the provided block along with its confirmations,
+// blocks not part of the main chain have no confirmations.
+func (d *simnetDcrd) GetBlockVerbose(_ context.Context, blockHash *chainhash.Hash, _ bool) (*chainjson.GetBlockVerboseResult, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	block, ok := d.blocks[*blockHash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", blockHash)
+	}
+	height := block.Header.Height
+	confs := int64(-1)
+	if int(height) < len(d.chain) && d.chain[height] == block {
+		confs = d.confirmations(height)
+	}
+	return &chainjson.GetBlockVerboseResult{
+		Hash:          blockHash.String(),
+		Confirmations: confs,
+		Height:        int64(height),
+	}, nil
+}
+
+// GetBlock returns the block with the provided hash.
+func (d *simnetDcrd) GetBlock(_ context.Context, blockHash *chainhash.Hash) (*wire.MsgBlock, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	block, ok := d.blocks[*blockHash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", blockHash)
+	}
+	return block, nil
+}
+
+// GetBestBlock returns the hash and height of the chain tip.
+func (d *simnetDcrd) GetBestBlock(context.Context) (*chainhash.Hash, int64, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	tip := d.tip()
+	hash := tip.BlockHash()
+	return &hash, int64(tip.Header.Height), nil
+}
+
+// GetBlockHash returns the hash of the main chain block at the provided
+// height.
+func (d *simnetDcrd) GetBlockHash(_ context.Context, height int64) (*chainhash.Hash, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if height < 0 || height >= int64(len(d.chain)) {
+		return nil, fmt.Errorf("no block at height %d", height)
+	}
+	hash := d.chain[height].BlockHash()
+	return &hash, nil
+}
+
+// GetBlockHeader returns the header of the block with the provided hash.
+func (d *simnetDcrd) GetBlockHeader(_ context.Context, blockHash *chainhash.Hash) (*wire.BlockHeader, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	block, ok := d.blocks[*blockHash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", blockHash)
+	}
+	header := block.Header
+	return &header, nil
+}
+
+// NotifyWork is a no-op, work notifications are always delivered.
+func (d *simnetDcrd) NotifyWork(context.Context) error {
+	return nil
+}
+
+// NotifyBlocks is a no-op, block notifications are always delivered.
+func (d *simnetDcrd) NotifyBlocks(context.Context) error {
+	return nil
+}
+
+// Shutdown is a no-op, notifications stop when the harness stops.
+func (d *simnetDcrd) Shutdown() {}
+
+// simnetWallet is a pool wallet backed by a scripted mining node. It signs
+// transactions with placeholder signatures and publishes them to the
+// mempool of the mining node.
+type simnetWallet struct {
+	dcrd      *simnetDcrd
+	published []*wire.MsgTx
+	mtx       sync.Mutex
+}
+
+// SignTransaction signs the inputs of the provided transaction.
+func (w *simnetWallet) SignTransaction(_ context.Context, req *walletrpc.SignTransactionRequest, _ ...grpc.CallOption) (*walletrpc.SignTransactionResponse, error) {
+	tx := wire.NewMsgTx()
+	err := tx.FromBytes(req.SerializedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	for _, in := range tx.TxIn {
+		in.SignatureScript = []byte{0x51} // OP_TRUE
+	}
+	txB, err := tx.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &walletrpc.SignTransactionResponse{Transaction: txB}, nil
+}
+
+// PublishTransaction broadcasts the provided signed transaction to the
+// mining node.
+func (w *simnetWallet) PublishTransaction(_ context.Context, req *walletrpc.PublishTransactionRequest, _ ...grpc.CallOption) (*walletrpc.PublishTransactionResponse, error) {
+	tx := wire.NewMsgTx()
+	err := tx.FromBytes(req.SignedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	for _, in := range tx.TxIn {
+		if len(in.SignatureScript) == 0 {
+			return nil, errors.New("transaction is not signed")
+		}
+	}
+	err = w.dcrd.sendTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mtx.Lock()
+	w.published = append(w.published, tx)
+	w.mtx.Unlock()
+
+	hash := tx.TxHash()
+	return &walletrpc.PublishTransactionResponse{
+		TransactionHash: hash[:],
+	}, nil
+}
+
+// publishedTxs returns the transactions published by the wallet.
+func (w *simnetWallet) publishedTxs() []*wire.MsgTx {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return append([]*wire.MsgTx(nil), w.published...)
+}
+
+// simnetConfNotifs streams transaction confirmation notifications of the
+// pool wallet. A notification is streamed right after a request and on
+// every chain tip change thereafter.
+type simnetConfNotifs struct {
+	grpc.ClientStream
+	dcrd     *simnetDcrd
+	hashes   [][]byte
+	notified bool
+	closed   chan struct{}
+	once     sync.Once
+	mtx      sync.Mutex
+}
+
+// Send requests confirmation notifications for the provided transactions.
+func (s *simnetConfNotifs) Send(req *walletrpc.ConfirmationNotificationsRequest) error {
+	s.mtx.Lock()
+	s.hashes = req.TxHashes
+	s.notified = false
+	s.mtx.Unlock()
+	return nil
+}
+
+// Recv returns the confirmations of the requested transactions.
+func (s *simnetConfNotifs) Recv() (*walletrpc.ConfirmationNotificationsResponse, error) {
+	tipChanged := s.dcrd.tipChanged()
+	s.mtx.Lock()
+	notified := s.notified
+	s.notified = true
+	hashes := s.hashes
+	s.mtx.Unlock()
+
+	if notified {
+		select {
+		case <-tipChanged:
+		case <-s.closed:
+			return nil, io.EOF
+		}
+	}
+
+	s.dcrd.mtx.Lock()
+	defer s.dcrd.mtx.Unlock()
+	resp := new(walletrpc.ConfirmationNotificationsResponse)
+	for _, hashB := range hashes {
+		hash, err := chainhash.NewHash(hashB)
+		if err != nil {
+			return nil, err
+		}
+		confs := &walletrpc.ConfirmationNotificationsResponse_TransactionConfirmations{
+			TxHash:        hashB,
+			Confirmations: -1,
+		}
+		if _, height, ok := s.dcrd.findTx(hash); ok {
+			blockHash := s.dcrd.chain[height].BlockHash()
+			confs.Confirmations = int32(s.dcrd.confirmations(height))
+			confs.BlockHash = blockHash[:]
+			confs.BlockHeight = int32(height)
+		}
+		resp.Confirmations = append(resp.Confirmations, confs)
+	}
+	return resp, nil
+}
+
+// CloseSend terminates the stream.
+func (s *simnetConfNotifs) CloseSend() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// simnetHarness runs a mining pool against a scripted mining node and pool
+// wallet, mined by in-process CPU miners.
+type simnetHarness struct {
+	t       *testing.T
+	net     *chaincfg.Params
+	dcrd    *simnetDcrd
+	wallet  *simnetWallet
+	hub     *pool.Hub
+	cancel  context.CancelFunc
+	dcrdWg  sync.WaitGroup
+	hubWg   sync.WaitGroup
+	miners  []context.CancelFunc
+	minerWg sync.WaitGroup
+}
+
+// newSimnetHarness starts a mining pool paying rewards with the provided
+// payment method.
+func newSimnetHarness(t *testing.T, paymentMethod string) *simnetHarness {
+	t.Helper()
+	net := chaincfg.SimNetParams()
+	walletAddr, err := dcrutil.DecodeAddress(simnetWalletAddr, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeAddr, err := dcrutil.DecodeAddress(simnetFeeAddr, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	powLimitF, _ := new(big.Float).SetInt(net.PowLimit).Float64()
+	iterations := math.Pow(2, 256-math.Floor(math.Log2(powLimitF)))
+	ctx, cancel := context.WithCancel(context.Background())
+	hub, err := pool.NewHub(cancel, &pool.HubConfig{
+		ActiveNet:               net,
+		DB:                      pool.NewMemoryDB(),
+		PoolFee:                 0.1,
+		MaxGenTime:              time.Second * 2,
+		PaymentMethod:           paymentMethod,
+		LastNPeriod:             time.Minute * 10,
+		PoolFeeAddrs:            []dcrutil.Address{feeAddr},
+		NonceIterations:         iterations,
+		MinerListen:             "127.0.0.1:0",
+		MaxConnectionsPerHost:   10,
+		CoinbaseConfTimeout:     time.Minute,
+		MonitorCycle:            time.Minute,
+		MaxUpgradeTries:         5,
+		ClientTimeout:           time.Minute,
+		BlockEventRetryDelay:    time.Millisecond * 100,
+		BlockEventMaxRetryDelay: time.Second,
+	})
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+
+	h := &simnetHarness{
+		t:      t,
+		net:    net,
+		dcrd:   newSimnetDcrd(net, walletAddr, pool.MaxReorgLimit),
+		hub:    hub,
+		cancel: cancel,
+	}
+	h.wallet = &simnetWallet{dcrd: h.dcrd}
+	h.dcrd.handlers = hub.UseConnections(h.dcrd, h.wallet,
+		&simnetConfNotifs{dcrd: h.dcrd, closed: make(chan struct{})})
+	err = hub.FetchWork(ctx)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+
+	dcrdCtx, dcrdCancel := context.WithCancel(ctx)
+	h.dcrdWg.Add(1)
+	go func() {
+		h.dcrd.run(dcrdCtx)
+		h.dcrdWg.Done()
+	}()
+	h.hubWg.Add(1)
+	go func() {
+		hub.Run(ctx)
+		h.hubWg.Done()
+	}()
+	h.cancel = func() {
+		dcrdCancel()
+		h.dcrdWg.Wait()
+		cancel()
+	}
+	t.Cleanup(h.stop)
+	return h
+}
+
+// startMiner connects an in-process CPU miner mining to the provided
+// address to the pool.
+func (h *simnetHarness) startMiner(address string, user string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	miner := cpuminer.NewMiner(&cpuminer.Config{
+		ActiveNet: h.net,
+		User:      user,
+		Address:   address,
+		Pool:      h.hub.MinerAddr(),
+		UserAgent: pool.CPUID,
+	}, cancel)
+	h.miners = append(h.miners, cancel)
+	h.minerWg.Add(1)
+	go func() {
+		miner.Run(ctx)
+		h.minerWg.Done()
+	}()
+}
+
+// stop terminates the miners, the mining node notifications and the pool,
+// in that order.
+func (h *simnetHarness) stop() {
+	for _, cancel := range h.miners {
+		cancel()
+	}
+	h.miners = nil
+	h.minerWg.Wait()
+	h.cancel()
+	h.hubWg.Wait()
+}
+
+// waitFor waits until the provided condition holds, failing the test if it
+// does not within the wait timeout.
+func (h *simnetHarness) waitFor(desc string, cond func() (bool, error)) {
+	h.t.Helper()
+	deadline := time.Now().Add(simnetWaitTimeout)
+	for {
+		ok, err := cond()
+		if err != nil {
+			h.t.Fatalf("%s: %v", desc, err)
+		}
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+}
+
+// waitForShares waits until every provided account has shares counted
+// towards its reward.
+func (h *simnetHarness) waitForShares(accountIDs ...string) {
+	h.t.Helper()
+	h.waitFor("shares", func() (bool, error) {
+		quotas, err := h.hub.FetchWorkQuotas()
+		if err != nil {
+			return false, err
+		}
+		found := make(map[string]bool, len(quotas))
+		for _, quota := range quotas {
+			found[quota.AccountID] = true
+		}
+		for _, id := range accountIDs {
+			if !found[id] {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// mineBlock waits until the pool mines a block extending the chain tip and
+// the block is accepted as mined work, returning it.
+func (h *simnetHarness) mineBlock() *pool.AcceptedWork {
+	h.t.Helper()
+	height := h.dcrd.height() + 1
+	h.dcrd.acceptBlocks(1)
+	var mined *pool.AcceptedWork
+	h.waitFor(fmt.Sprintf("mined block #%d", height), func() (bool, error) {
+		work, err := h.hub.FetchMinedWork()
+		if err != nil {
+			return false, err
+		}
+		for _, w := range work {
+			if w.Height == height {
+				mined = w
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return mined
+}
+
+// waitForWork waits until the mined work of the provided block has the
+// provided status, returning it.
+func (h *simnetHarness) waitForWork(blockHash string, status string) *pool.AcceptedWork {
+	h.t.Helper()
+	var mined *pool.AcceptedWork
+	desc := fmt.Sprintf("%s mined work %s", status, blockHash)
+	h.waitFor(desc, func() (bool, error) {
+		work, err := h.hub.FetchMinedWork()
+		if err != nil {
+			return false, err
+		}
+		for _, w := range work {
+			if w.BlockHash == blockHash && w.Status == status {
+				mined = w
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return mined
+}
+
+// waitForChainTip waits until the pool processed the blocks up to the
+// chain tip of the mining node.
+func (h *simnetHarness) waitForChainTip() {
+	h.t.Helper()
+	h.waitFor("chain tip", func() (bool, error) {
+		stats := h.hub.FetchBlockEventStats()
+		if stats.Fatal > 0 {
+			return false, errors.New("fatal block event")
+		}
+		for _, event := range stats.Pending {
+			if event.Attempts >= simnetMaxBlockEventAttempts {
+				return false, fmt.Errorf("%s block #%d failed %d times: %s",
+					event.Kind, event.Height, event.Attempts, event.LastError)
+			}
+		}
+		hash, _, ok := h.hub.ChainTip()
+		tip, _, err := h.dcrd.GetBestBlock(context.Background())
+		if err != nil {
+			return false, err
+		}
+		return ok && hash == tip.String() && len(stats.Pending) == 0, nil
+	})
+}
+
+// paysTo returns the value of the outputs of the provided transaction
+// paying to the provided address.
+func paysTo(tx *wire.MsgTx, addr dcrutil.Address) int64 {
+	script := p2pkhScript(addr)
+	var value int64
+	for _, out := range tx.TxOut {
+		if bytes.Equal(out.PkScript, script) {
+			value += out.Value
+		}
+	}
+	return value
+}
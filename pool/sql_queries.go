@@ -4,6 +4,9 @@
 
 package pool
 
+// The table definitions are executed by the postgres upgrades of the schema
+// version which introduced them and must not be changed afterwards, changes
+// to existing tables are made by new upgrades instead.
 const (
 	createTableMetadata = `
 	CREATE TABLE IF NOT EXISTS metadata (
@@ -83,6 +86,129 @@ const (
 		updatedon INT8    NOT NULL
 	);`
 
+	createTableAPITokens = `
+	CREATE TABLE IF NOT EXISTS apitokens (
+		uuid      TEXT PRIMARY KEY,
+		accountid TEXT NOT NULL,
+		kind      TEXT NOT NULL,
+		createdon INT8 NOT NULL
+	);`
+
+	createTableAdminUsers = `
+	CREATE TABLE IF NOT EXISTS adminusers (
+		uuid         TEXT PRIMARY KEY,
+		passwordhash TEXT NOT NULL,
+		role         TEXT NOT NULL,
+		totpsecret   TEXT NOT NULL,
+		createdon    INT8 NOT NULL
+	);`
+
+	createTableAuditLog = `
+	CREATE TABLE IF NOT EXISTS auditlog (
+		uuid      TEXT PRIMARY KEY,
+		actor     TEXT NOT NULL,
+		action    TEXT NOT NULL,
+		details   TEXT NOT NULL,
+		ip        TEXT NOT NULL,
+		createdon INT8 NOT NULL
+	);`
+
+	createTableBans = `
+	CREATE TABLE IF NOT EXISTS bans (
+		uuid      TEXT PRIMARY KEY,
+		kind      TEXT NOT NULL,
+		reason    TEXT NOT NULL,
+		createdon INT8 NOT NULL
+	);`
+
+	createTableLedger = `
+	CREATE TABLE IF NOT EXISTS ledger (
+		uuid      TEXT PRIMARY KEY,
+		kind      TEXT NOT NULL,
+		debit     TEXT NOT NULL,
+		credit    TEXT NOT NULL,
+		amount    INT8 NOT NULL,
+		blockhash TEXT NOT NULL,
+		txid      TEXT NOT NULL,
+		actor     TEXT NOT NULL,
+		memo      TEXT NOT NULL,
+		createdon INT8 NOT NULL
+	);`
+
+	createTableBlockEvents = `
+	CREATE TABLE IF NOT EXISTS blockevents (
+		uuid        TEXT PRIMARY KEY,
+		kind        TEXT NOT NULL,
+		header      TEXT NOT NULL,
+		height      INT8 NOT NULL,
+		attempts    INT8 NOT NULL,
+		lasterror   TEXT NOT NULL,
+		nextattempt INT8 NOT NULL,
+		createdon   INT8 NOT NULL
+	);`
+
+	addAcceptedWorkStatus = `
+	ALTER TABLE acceptedwork
+	ADD COLUMN status TEXT NOT NULL DEFAULT 'submitted';`
+
+	addAcceptedWorkOrphanedBy = `
+	ALTER TABLE acceptedwork
+	ADD COLUMN orphanedby TEXT NOT NULL DEFAULT '';`
+
+	updateConfirmedWorkStatus = `
+	UPDATE acceptedwork
+	SET status='confirmed'
+	WHERE confirmed=true;`
+
+	createTableWebhooks = `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		uuid      TEXT PRIMARY KEY,
+		accountid TEXT NOT NULL,
+		url       TEXT NOT NULL,
+		secret    TEXT NOT NULL,
+		createdon INT8 NOT NULL
+	);`
+
+	createTableWebhookDeliveries = `
+	CREATE TABLE IF NOT EXISTS webhookdeliveries (
+		uuid        TEXT PRIMARY KEY,
+		webhookid   TEXT NOT NULL,
+		url         TEXT NOT NULL,
+		event       TEXT NOT NULL,
+		payload     TEXT NOT NULL,
+		attempts    INT8 NOT NULL,
+		lasterror   TEXT NOT NULL,
+		nextattempt INT8 NOT NULL,
+		createdon   INT8 NOT NULL
+	);`
+
+	createTableWorkers = `
+	CREATE TABLE IF NOT EXISTS workers (
+		uuid        TEXT PRIMARY KEY,
+		accountid   TEXT NOT NULL,
+		name        TEXT NOT NULL,
+		miner       TEXT NOT NULL,
+		hashrate    FLOAT8 NOT NULL,
+		baseline    FLOAT8 NOT NULL,
+		samples     INT8 NOT NULL,
+		status      TEXT NOT NULL,
+		statussince INT8 NOT NULL,
+		lastseen    INT8 NOT NULL,
+		createdon   INT8 NOT NULL
+	);`
+
+	createTableWorkerEvents = `
+	CREATE TABLE IF NOT EXISTS workerevents (
+		uuid      TEXT PRIMARY KEY,
+		accountid TEXT NOT NULL,
+		worker    TEXT NOT NULL,
+		kind      TEXT NOT NULL,
+		hashrate  FLOAT8 NOT NULL,
+		baseline  FLOAT8 NOT NULL,
+		lastseen  INT8 NOT NULL,
+		createdon INT8 NOT NULL
+	);`
+
 	purgeDB = `DROP TABLE IF EXISTS 
 		acceptedwork, 
 		accounts, 
@@ -91,7 +217,64 @@ const (
 		metadata, 
 		payments, 
 		shares,
-		hashdata;`
+		hashdata,
+		apitokens,
+		adminusers,
+		auditlog,
+		bans,
+		ledger,
+		blockevents,
+		webhooks,
+		webhookdeliveries,
+		workers,
+		workerevents;`
+
+	purgeSQLiteDB = `
+	DROP TABLE IF EXISTS acceptedwork;
+	DROP TABLE IF EXISTS accounts;
+	DROP TABLE IF EXISTS archivedpayments;
+	DROP TABLE IF EXISTS jobs;
+	DROP TABLE IF EXISTS metadata;
+	DROP TABLE IF EXISTS payments;
+	DROP TABLE IF EXISTS shares;
+	DROP TABLE IF EXISTS hashdata;
+	DROP TABLE IF EXISTS apitokens;
+	DROP TABLE IF EXISTS adminusers;
+	DROP TABLE IF EXISTS auditlog;
+	DROP TABLE IF EXISTS bans;
+	DROP TABLE IF EXISTS ledger;
+	DROP TABLE IF EXISTS blockevents;
+	DROP TABLE IF EXISTS webhooks;
+	DROP TABLE IF EXISTS webhookdeliveries;
+	DROP TABLE IF EXISTS workers;
+	DROP TABLE IF EXISTS workerevents;`
+
+	selectMetadataExists = `
+	SELECT EXISTS (
+		SELECT 1
+		FROM information_schema.tables
+		WHERE table_schema=current_schema() AND table_name='metadata'
+	);`
+
+	selectSQLiteMetadataExists = `
+	SELECT EXISTS (
+		SELECT 1
+		FROM sqlite_master
+		WHERE type='table' AND name='metadata'
+	);`
+
+	lockSchemaUpgrades = `SELECT pg_advisory_xact_lock($1::bigint);`
+
+	selectSchemaVersion = `
+	SELECT value
+	FROM metadata
+	WHERE key='version';`
+
+	insertSchemaVersion = `
+	INSERT INTO metadata(key, value)
+	VALUES ('version', $1)
+	ON CONFLICT (key)
+	DO UPDATE SET value=$1;`
 
 	selectPoolMode = `
 	SELECT value
@@ -137,6 +320,43 @@ const (
 	ON CONFLICT (key)
 	DO UPDATE SET value=$1;`
 
+	selectChainTipHash = `
+	SELECT value
+	FROM metadata
+	WHERE key='chaintiphash';`
+
+	insertChainTipHash = `
+	INSERT INTO metadata(key, value)
+	VALUES ('chaintiphash', $1)
+	ON CONFLICT (key)
+	DO UPDATE SET value=$1;`
+
+	selectChainTipHeight = `
+	SELECT value
+	FROM metadata
+	WHERE key='chaintipheight';`
+
+	insertChainTipHeight = `
+	INSERT INTO metadata(key, value)
+	VALUES ('chaintipheight', $1)
+	ON CONFLICT (key)
+	DO UPDATE SET value=$1;`
+
+	selectPendingPayout = `
+	SELECT value
+	FROM metadata
+	WHERE key='pendingpayout';`
+
+	insertPendingPayout = `
+	INSERT INTO metadata(key, value)
+	VALUES ('pendingpayout', $1)
+	ON CONFLICT (key)
+	DO UPDATE SET value=$1;`
+
+	deletePendingPayout = `
+	DELETE FROM metadata
+	WHERE key='pendingpayout';`
+
 	selectLastPaymentCreatedOn = `
 	SELECT value
 	FROM metadata
@@ -161,6 +381,11 @@ const (
 
 	deleteAccount = `DELETE FROM accounts WHERE uuid=$1;`
 
+	selectAccounts = `
+	SELECT
+		uuid, address, createdon
+	FROM accounts;`
+
 	insertPayment = `
 	INSERT INTO payments(
 		uuid,
@@ -222,6 +447,20 @@ const (
 	)
 	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10);`
 
+	selectPayments = `
+	SELECT
+		uuid,
+		account,
+		estimatedmaturity,
+		height,
+		amount,
+		createdon,
+		paidonheight,
+		transactionid,
+		sourceblockhash,
+		sourcecoinbase
+	FROM payments;`
+
 	selectPaymentsAtHeight = `
 	SELECT
 		uuid,
@@ -236,7 +475,8 @@ const (
 		sourcecoinbase
 	FROM payments
 	WHERE paidonheight=0
-	AND $1>(estimatedmaturity+1);`
+	AND $1>(estimatedmaturity+1)
+	ORDER BY height, createdon, uuid;`
 
 	selectPendingPayments = `
 	SELECT
@@ -251,7 +491,8 @@ const (
 		sourceblockhash,
 		sourcecoinbase
 	FROM payments
-	WHERE paidonheight=0;`
+	WHERE paidonheight=0
+	ORDER BY height, createdon, uuid;`
 
 	countPaymentsAtBlockHash = `
 	SELECT count(1)
@@ -259,6 +500,11 @@ const (
 	WHERE paidonheight=0
 	AND sourceblockhash=$1;`
 
+	countArchivedPaymentsAtBlockHash = `
+	SELECT count(1)
+	FROM archivedpayments
+	WHERE sourceblockhash=$1;`
+
 	selectArchivedPayments = `
 	SELECT
 		uuid,
@@ -272,7 +518,7 @@ const (
 		sourceblockhash,
 		sourcecoinbase
 	FROM archivedpayments
-	ORDER BY height DESC;`
+	ORDER BY height DESC, createdon DESC, uuid DESC;`
 
 	selectMaturePendingPayments = `
 	SELECT
@@ -288,7 +534,8 @@ const (
 		sourcecoinbase
 	FROM payments
 	WHERE paidonheight=0
-	AND (estimatedmaturity+1)<=$1;`
+	AND (estimatedmaturity+1)<=$1
+	ORDER BY height, createdon, uuid;`
 
 	selectShare = `
 	SELECT
@@ -302,17 +549,32 @@ const (
 	)
 	VALUES ($1,$2,$3,$4);`
 
+	selectShareWeightForUpdate = `
+	SELECT weight
+	FROM shares
+	WHERE uuid=$1
+	FOR UPDATE;`
+
+	updateShareWeight = `UPDATE shares SET weight=$2 WHERE uuid=$1;`
+
 	selectSharesOnOrBeforeTime = `
 	SELECT
 		uuid, account, weight, createdon
 	FROM shares
-	WHERE createdon <= $1`
+	WHERE createdon <= $1
+	ORDER BY createdon, uuid;`
 
 	selectSharesAfterTime = `
 	SELECT
 		uuid, account, weight, createdon
 	FROM shares
-	WHERE createdon > $1`
+	WHERE createdon > $1
+	ORDER BY createdon DESC, uuid DESC;`
+
+	selectShares = `
+	SELECT
+		uuid, account, weight, createdon
+	FROM shares;`
 
 	deleteShareCreatedBefore = `DELETE FROM shares WHERE createdon < $1`
 
@@ -325,7 +587,9 @@ const (
 		minedby,
 		miner,
 		createdon,
-		confirmed
+		confirmed,
+		status,
+		orphanedby
 	FROM acceptedwork
 	WHERE uuid=$1;`
 
@@ -338,8 +602,10 @@ const (
 		minedby,
 		miner,
 		createdon,
-		confirmed
-	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8);`
+		confirmed,
+		status,
+		orphanedby
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10);`
 
 	updateAcceptedWork = `
 	UPDATE acceptedwork
@@ -350,7 +616,9 @@ const (
 		minedby=$5,
 		miner=$6,
 		createdon=$7,
-		confirmed=$8
+		confirmed=$8,
+		status=$9,
+		orphanedby=$10
 		WHERE uuid=$1;`
 
 	deleteAcceptedWork = `DELETE FROM acceptedwork WHERE uuid=$1;`
@@ -364,9 +632,11 @@ const (
 		minedby,
 		miner,
 		createdon,
-		confirmed
+		confirmed,
+		status,
+		orphanedby
 	FROM acceptedwork
-	ORDER BY height DESC;`
+	ORDER BY height DESC, uuid DESC;`
 
 	selectUnconfirmedWork = `
 	SELECT
@@ -377,13 +647,19 @@ const (
 		minedby,
 		miner,
 		createdon,
-		confirmed
+		confirmed,
+		status,
+		orphanedby
 	FROM acceptedwork
 	WHERE $1>height
-	AND confirmed=false;`
+	AND confirmed=false
+	AND status<>'orphaned'
+	ORDER BY height, uuid;`
 
 	selectJob = `SELECT uuid, header, height FROM jobs WHERE uuid=$1;`
 
+	selectJobs = `SELECT uuid, header, height FROM jobs;`
+
 	insertJob = `INSERT INTO jobs(uuid, height, header) VALUES ($1,$2,$3);`
 
 	deleteJob = `DELETE FROM jobs WHERE uuid=$1;`
@@ -410,6 +686,15 @@ const (
 		FROM hashdata 
 		WHERE updatedon > $1;`
 
+	selectAllHashData = `SELECT 
+		uuid, 
+		accountid, 
+		miner, 
+		ip, 
+		hashrate, 
+		updatedon 
+		FROM hashdata;`
+
 	pruneHashData = `DELETE FROM hashdata WHERE updatedon < $1;`
 
 	insertHashData = `INSERT INTO hashdata(
@@ -429,4 +714,358 @@ const (
 			hashrate=$5,
 			updatedon=$6
 			WHERE uuid=$1;`
+
+	selectAPIToken = `SELECT uuid, accountid, kind, createdon FROM apitokens WHERE uuid=$1;`
+
+	selectAPITokensForAccount = `SELECT 
+		uuid, 
+		accountid, 
+		kind, 
+		createdon 
+		FROM apitokens 
+		WHERE accountid=$1 
+		ORDER BY createdon, uuid;`
+
+	selectAPITokens = `SELECT 
+		uuid, 
+		accountid, 
+		kind, 
+		createdon 
+		FROM apitokens;`
+
+	insertAPIToken = `INSERT INTO apitokens(
+		uuid, 
+		accountid, 
+		kind, 
+		createdon) VALUES ($1,$2,$3,$4);`
+
+	deleteAPIToken = `DELETE FROM apitokens WHERE uuid=$1;`
+
+	selectAdminUser = `SELECT 
+		uuid, 
+		passwordhash, 
+		role, 
+		totpsecret, 
+		createdon 
+		FROM adminusers 
+		WHERE uuid=$1;`
+
+	listAdminUsers = `SELECT 
+		uuid, 
+		passwordhash, 
+		role, 
+		totpsecret, 
+		createdon 
+		FROM adminusers 
+		ORDER BY uuid;`
+
+	insertAdminUser = `INSERT INTO adminusers(
+		uuid, 
+		passwordhash, 
+		role, 
+		totpsecret, 
+		createdon) VALUES ($1,$2,$3,$4,$5);`
+
+	updateAdminUser = `
+		UPDATE adminusers
+		SET
+			passwordhash=$2,
+			role=$3,
+			totpsecret=$4,
+			createdon=$5
+			WHERE uuid=$1;`
+
+	deleteAdminUser = `DELETE FROM adminusers WHERE uuid=$1;`
+
+	insertAuditEntry = `INSERT INTO auditlog(
+		uuid, 
+		actor, 
+		action, 
+		details, 
+		ip, 
+		createdon) VALUES ($1,$2,$3,$4,$5,$6);`
+
+	selectAuditEntries = `SELECT 
+		uuid, 
+		actor, 
+		action, 
+		details, 
+		ip, 
+		createdon 
+		FROM auditlog 
+		ORDER BY createdon DESC, uuid DESC 
+		LIMIT $1;`
+
+	insertBan = `INSERT INTO bans(
+		uuid, 
+		kind, 
+		reason, 
+		createdon) VALUES ($1,$2,$3,$4)
+		ON CONFLICT (uuid)
+		DO UPDATE SET kind=$2, reason=$3, createdon=$4;`
+
+	importBan = `INSERT INTO bans(
+		uuid, 
+		kind, 
+		reason, 
+		createdon) VALUES ($1,$2,$3,$4);`
+
+	deleteBan = `DELETE FROM bans WHERE uuid=$1;`
+
+	listBans = `SELECT 
+		uuid, 
+		kind, 
+		reason, 
+		createdon 
+		FROM bans 
+		ORDER BY createdon, uuid;`
+
+	insertLedgerEntry = `INSERT INTO ledger(
+		uuid, 
+		kind, 
+		debit, 
+		credit, 
+		amount, 
+		blockhash, 
+		txid, 
+		actor, 
+		memo, 
+		createdon) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10);`
+
+	selectLedgerEntries = `SELECT 
+		uuid, 
+		kind, 
+		debit, 
+		credit, 
+		amount, 
+		blockhash, 
+		txid, 
+		actor, 
+		memo, 
+		createdon 
+		FROM ledger 
+		WHERE $1='' OR debit=$1 OR credit=$1 
+		ORDER BY createdon DESC, uuid DESC 
+		LIMIT $2;`
+
+	selectLedgerBalances = `SELECT 
+		account, 
+		CAST(SUM(amount) AS BIGINT) 
+		FROM (
+			SELECT credit AS account, amount FROM ledger 
+			UNION ALL 
+			SELECT debit AS account, -amount FROM ledger
+		) AS postings 
+		GROUP BY account;`
+
+	selectAccountBalances = `SELECT 
+		account, 
+		CAST(SUM(CASE WHEN posting = 'credit' THEN amount ELSE -amount END) 
+			AS BIGINT), 
+		CAST(SUM(CASE WHEN posting = 'debit' AND kind IN ('payout', 'txfee') 
+			THEN amount ELSE 0 END) AS BIGINT) 
+		FROM (
+			SELECT credit AS account, kind, 'credit' AS posting, 
+				SUM(amount) AS amount 
+				FROM ledger WHERE $1 = '' OR credit = $1 
+				GROUP BY credit, kind 
+			UNION ALL 
+			SELECT debit AS account, kind, 'debit' AS posting, 
+				SUM(amount) AS amount 
+				FROM ledger WHERE $1 = '' OR debit = $1 
+				GROUP BY debit, kind 
+		) AS postings 
+		GROUP BY account;`
+
+	insertBlockEvent = `INSERT INTO blockevents(
+		uuid, 
+		kind, 
+		header, 
+		height, 
+		attempts, 
+		lasterror, 
+		nextattempt, 
+		createdon) VALUES ($1,$2,$3,$4,$5,$6,$7,$8);`
+
+	updateBlockEvent = `
+		UPDATE blockevents
+		SET
+			kind=$2,
+			header=$3,
+			height=$4,
+			attempts=$5,
+			lasterror=$6,
+			nextattempt=$7,
+			createdon=$8
+			WHERE uuid=$1;`
+
+	deleteBlockEvent = `DELETE FROM blockevents WHERE uuid=$1;`
+
+	selectBlockEvents = `SELECT 
+		uuid, 
+		kind, 
+		header, 
+		height, 
+		attempts, 
+		lasterror, 
+		nextattempt, 
+		createdon 
+		FROM blockevents 
+		ORDER BY createdon, uuid;`
+
+	insertWebhook = `INSERT INTO webhooks(
+		uuid, 
+		accountid, 
+		url, 
+		secret, 
+		createdon) VALUES ($1,$2,$3,$4,$5);`
+
+	selectWebhook = `SELECT 
+		uuid, 
+		accountid, 
+		url, 
+		secret, 
+		createdon 
+		FROM webhooks 
+		WHERE uuid=$1;`
+
+	deleteWebhook = `DELETE FROM webhooks WHERE uuid=$1;`
+
+	selectWebhooksForAccount = `SELECT 
+		uuid, 
+		accountid, 
+		url, 
+		secret, 
+		createdon 
+		FROM webhooks 
+		WHERE accountid=$1 
+		ORDER BY createdon, uuid;`
+
+	selectWebhooks = `SELECT 
+		uuid, 
+		accountid, 
+		url, 
+		secret, 
+		createdon 
+		FROM webhooks;`
+
+	insertWebhookDelivery = `INSERT INTO webhookdeliveries(
+		uuid, 
+		webhookid, 
+		url, 
+		event, 
+		payload, 
+		attempts, 
+		lasterror, 
+		nextattempt, 
+		createdon) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9);`
+
+	updateWebhookDelivery = `
+		UPDATE webhookdeliveries
+		SET
+			webhookid=$2,
+			url=$3,
+			event=$4,
+			payload=$5,
+			attempts=$6,
+			lasterror=$7,
+			nextattempt=$8,
+			createdon=$9
+			WHERE uuid=$1;`
+
+	deleteWebhookDelivery = `DELETE FROM webhookdeliveries WHERE uuid=$1;`
+
+	selectWebhookDeliveries = `SELECT 
+		uuid, 
+		webhookid, 
+		url, 
+		event, 
+		payload, 
+		attempts, 
+		lasterror, 
+		nextattempt, 
+		createdon 
+		FROM webhookdeliveries 
+		ORDER BY createdon, uuid;`
+
+	insertWorker = `INSERT INTO workers(
+		uuid, 
+		accountid, 
+		name, 
+		miner, 
+		hashrate, 
+		baseline, 
+		samples, 
+		status, 
+		statussince, 
+		lastseen, 
+		createdon) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11);`
+
+	updateWorker = `
+		UPDATE workers
+		SET
+			accountid=$2,
+			name=$3,
+			miner=$4,
+			hashrate=$5,
+			baseline=$6,
+			samples=$7,
+			status=$8,
+			statussince=$9,
+			lastseen=$10,
+			createdon=$11
+			WHERE uuid=$1;`
+
+	deleteWorker = `DELETE FROM workers WHERE uuid=$1;`
+
+	selectWorkers = `SELECT 
+		uuid, 
+		accountid, 
+		name, 
+		miner, 
+		hashrate, 
+		baseline, 
+		samples, 
+		status, 
+		statussince, 
+		lastseen, 
+		createdon 
+		FROM workers 
+		ORDER BY uuid;`
+
+	insertWorkerEvent = `INSERT INTO workerevents(
+		uuid, 
+		accountid, 
+		worker, 
+		kind, 
+		hashrate, 
+		baseline, 
+		lastseen, 
+		createdon) VALUES ($1,$2,$3,$4,$5,$6,$7,$8);`
+
+	selectWorkerEventsForAccount = `SELECT 
+		uuid, 
+		accountid, 
+		worker, 
+		kind, 
+		hashrate, 
+		baseline, 
+		lastseen, 
+		createdon 
+		FROM workerevents 
+		WHERE accountid=$1 
+		ORDER BY createdon DESC, uuid DESC;`
+
+	selectWorkerEvents = `SELECT 
+		uuid, 
+		accountid, 
+		worker, 
+		kind, 
+		hashrate, 
+		baseline, 
+		lastseen, 
+		createdon 
+		FROM workerevents;`
+
+	pruneWorkerEvents = `DELETE FROM workerevents WHERE createdon < $1;`
 )
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+
+	"github.com/mattn/go-sqlite3"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// sqliteDriverName is the name of the registered sqlite driver which accepts
+// the postgres queries.
+const sqliteDriverName = "dcrpool_sqlite3"
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteDriver{})
+}
+
+// pgParam matches the numbered parameters of postgres queries.
+var pgParam = regexp.MustCompile(`\$(\d+)`)
+
+// pgForUpdate matches the row locking clauses of postgres queries.
+var pgForUpdate = regexp.MustCompile(`\s+FOR UPDATE`)
+
+// rebindQuery rewrites the numbered parameters of the provided postgres query
+// to sqlite numbered parameters. Sqlite numbers $-prefixed parameters by
+// order of appearance, which differs from their postgres number when a query
+// does not reference its parameters in order.
+//
+// Row locking clauses are dropped since sqlite does not support them. They
+// are not needed either, writing transactions are serialized by the
+// immediate transaction locking of the database.
+func rebindQuery(query string) string {
+	query = pgForUpdate.ReplaceAllString(query, "")
+	return pgParam.ReplaceAllString(query, "?$1")
+}
+
+// sqliteDriver is a sqlite driver which rebinds the parameters of the
+// postgres queries.
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+// Open returns a new connection to the sqlite database.
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+// sqliteConn is a sqlite connection which rebinds the parameters of the
+// postgres queries.
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+// Prepare returns a prepared statement of the provided query.
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.SQLiteConn.Prepare(rebindQuery(query))
+}
+
+// PrepareContext returns a prepared statement of the provided query.
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.SQLiteConn.PrepareContext(ctx, rebindQuery(query))
+}
+
+// ExecContext executes the provided query.
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.SQLiteConn.ExecContext(ctx, rebindQuery(query), args)
+}
+
+// QueryContext executes the provided query and returns its rows.
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.SQLiteConn.QueryContext(ctx, rebindQuery(query), args)
+}
+
+var sqliteDialect = &sqlDialect{
+	name:           "sqlite",
+	metadataExists: selectSQLiteMetadataExists,
+}
+
+// InitSQLiteDB opens the sqlite database at the provided path, creating it if
+// necessary, and creates or upgrades all tables required by dcrpool.
+func InitSQLiteDB(dbFile string, purgeDB bool) (*SQLiteDB, error) {
+	const funcName = "InitSQLiteDB"
+
+	// Writers wait on each other instead of failing immediately and
+	// transactions take the write lock upfront, which avoids deadlocks
+	// between transactions upgrading their locks.
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=10000&_journal_mode=WAL"+
+		"&_txlock=immediate", dbFile)
+	db, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to open sqlite: %v", funcName, err)
+		return nil, errs.DBError(errs.DBOpen, desc)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to open sqlite: %v", funcName, err)
+		return nil, errs.DBError(errs.DBOpen, desc)
+	}
+
+	sdb := &SQLiteDB{&PostgresDB{db}}
+	if purgeDB {
+		err := sdb.purge()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create or upgrade the tables required by dcrpool.
+	err = upgradeSQLDB(db, sqliteDialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdb, nil
+}
+
+// OpenSQLiteDBReadOnly opens the sqlite database at the provided path
+// read-only, without creating or upgrading its tables. Returns an error if
+// the schema is not at the latest version.
+func OpenSQLiteDBReadOnly(dbFile string) (*SQLiteDB, error) {
+	const funcName = "OpenSQLiteDBReadOnly"
+
+	// Read transactions are deferred since a read-only connection cannot
+	// take the write lock.
+	dsn := fmt.Sprintf("file:%s?mode=ro&_busy_timeout=10000"+
+		"&_txlock=deferred", dbFile)
+	db, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to open sqlite: %v", funcName, err)
+		return nil, errs.DBError(errs.DBOpen, desc)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		desc := fmt.Sprintf("%s: unable to open sqlite: %v", funcName, err)
+		return nil, errs.DBError(errs.DBOpen, desc)
+	}
+
+	err = checkSQLSchemaVersion(db, sqliteDialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteDB{&PostgresDB{db}}, nil
+}
+
+// purge wipes all persisted data. This is intended for use with testnet and
+// simnet testing purposes only.
+func (db *SQLiteDB) purge() error {
+	funcName := "purge"
+	_, err := db.DB.Exec(purgeSQLiteDB)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to purge db: %v", funcName, err)
+		return errs.DBError(errs.DeleteEntry, desc)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+-- Postgres schema snapshot at pgAPITokenVersion. This file should not be
+-- updated for schema changes.
+
+CREATE TABLE metadata (
+	key      TEXT PRIMARY KEY,
+	value    TEXT NOT NULL
+);
+
+CREATE TABLE accounts (
+	uuid      TEXT PRIMARY KEY,
+	address   TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE payments (
+	uuid              TEXT PRIMARY KEY,
+	account           TEXT NOT NULL,
+	estimatedmaturity INT8 NOT NULL,
+	height            INT8 NOT NULL,
+	amount            INT8 NOT NULL,
+	createdon         INT8 NOT NULL,
+	paidonheight      INT8 NOT NULL,
+	transactionid     TEXT NOT NULL,
+	sourceblockhash   TEXT NOT NULL,
+	sourcecoinbase    TEXT NOT NULL
+);
+
+CREATE TABLE archivedpayments (
+	uuid              TEXT PRIMARY KEY,
+	account           TEXT NOT NULL,
+	estimatedmaturity INT8 NOT NULL,
+	height            INT8 NOT NULL,
+	amount            INT8 NOT NULL,
+	createdon         INT8 NOT NULL,
+	paidonheight      INT8 NOT NULL,
+	transactionid     TEXT NOT NULL,
+	sourceblockhash   TEXT NOT NULL,
+	sourcecoinbase    TEXT NOT NULL
+);
+
+CREATE TABLE jobs (
+	uuid   TEXT PRIMARY KEY,
+	height INT8 NOT NULL,
+	header TEXT NOT NULL
+);
+
+CREATE TABLE shares (
+	uuid      TEXT PRIMARY KEY,
+	account   TEXT NOT NULL,
+	weight    TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE acceptedwork (
+	uuid      TEXT    PRIMARY KEY,
+	blockhash TEXT    NOT NULL,
+	prevhash  TEXT    NOT NULL,
+	height    INT8    NOT NULL,
+	minedby   TEXT    NOT NULL,
+	miner     TEXT    NOT NULL,
+	createdon INT8    NOT NULL,
+	confirmed BOOLEAN NOT NULL
+);
+
+CREATE TABLE hashdata (
+	uuid      TEXT    PRIMARY KEY,
+	accountid TEXT    NOT NULL,
+	miner     TEXT    NOT NULL,
+	ip        TEXT    NOT NULL,
+	hashrate  TEXT    NOT NULL,
+	updatedon INT8    NOT NULL
+);
+
+CREATE TABLE apitokens (
+	uuid      TEXT PRIMARY KEY,
+	accountid TEXT NOT NULL,
+	kind      TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+INSERT INTO metadata(key, value) VALUES
+	('version', '2'),
+	('poolmode', '0');
+
+INSERT INTO accounts(uuid, address, createdon) VALUES
+	('accountx', 'SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc', 1600000000);
+
+INSERT INTO apitokens(uuid, accountid, kind, createdon) VALUES
+	('tokena', 'accountx', 'api', 1600000000);
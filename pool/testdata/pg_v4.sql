@@ -0,0 +1,130 @@
+-- Postgres schema snapshot at pgBanVersion, before the ledger was introduced.
+-- This file should not be updated for schema changes.
+
+CREATE TABLE metadata (
+	key      TEXT PRIMARY KEY,
+	value    TEXT NOT NULL
+);
+
+CREATE TABLE accounts (
+	uuid      TEXT PRIMARY KEY,
+	address   TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE payments (
+	uuid              TEXT PRIMARY KEY,
+	account           TEXT NOT NULL,
+	estimatedmaturity INT8 NOT NULL,
+	height            INT8 NOT NULL,
+	amount            INT8 NOT NULL,
+	createdon         INT8 NOT NULL,
+	paidonheight      INT8 NOT NULL,
+	transactionid     TEXT NOT NULL,
+	sourceblockhash   TEXT NOT NULL,
+	sourcecoinbase    TEXT NOT NULL
+);
+
+CREATE TABLE archivedpayments (
+	uuid              TEXT PRIMARY KEY,
+	account           TEXT NOT NULL,
+	estimatedmaturity INT8 NOT NULL,
+	height            INT8 NOT NULL,
+	amount            INT8 NOT NULL,
+	createdon         INT8 NOT NULL,
+	paidonheight      INT8 NOT NULL,
+	transactionid     TEXT NOT NULL,
+	sourceblockhash   TEXT NOT NULL,
+	sourcecoinbase    TEXT NOT NULL
+);
+
+CREATE TABLE jobs (
+	uuid   TEXT PRIMARY KEY,
+	height INT8 NOT NULL,
+	header TEXT NOT NULL
+);
+
+CREATE TABLE shares (
+	uuid      TEXT PRIMARY KEY,
+	account   TEXT NOT NULL,
+	weight    TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE acceptedwork (
+	uuid      TEXT    PRIMARY KEY,
+	blockhash TEXT    NOT NULL,
+	prevhash  TEXT    NOT NULL,
+	height    INT8    NOT NULL,
+	minedby   TEXT    NOT NULL,
+	miner     TEXT    NOT NULL,
+	createdon INT8    NOT NULL,
+	confirmed BOOLEAN NOT NULL
+);
+
+CREATE TABLE hashdata (
+	uuid      TEXT    PRIMARY KEY,
+	accountid TEXT    NOT NULL,
+	miner     TEXT    NOT NULL,
+	ip        TEXT    NOT NULL,
+	hashrate  TEXT    NOT NULL,
+	updatedon INT8    NOT NULL
+);
+
+CREATE TABLE apitokens (
+	uuid      TEXT PRIMARY KEY,
+	accountid TEXT NOT NULL,
+	kind      TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE adminusers (
+	uuid         TEXT PRIMARY KEY,
+	passwordhash TEXT NOT NULL,
+	role         TEXT NOT NULL,
+	totpsecret   TEXT NOT NULL,
+	createdon    INT8 NOT NULL
+);
+
+CREATE TABLE auditlog (
+	uuid      TEXT PRIMARY KEY,
+	actor     TEXT NOT NULL,
+	action    TEXT NOT NULL,
+	details   TEXT NOT NULL,
+	ip        TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE bans (
+	uuid      TEXT PRIMARY KEY,
+	kind      TEXT NOT NULL,
+	reason    TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+INSERT INTO metadata(key, value) VALUES
+	('version', '4'),
+	('poolmode', '0');
+
+INSERT INTO accounts(uuid, address, createdon) VALUES
+	('accountx', 'SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc', 1600000000),
+	('accounty', 'Ssp7J7TUmi5iPhoQnWYNGQbeGhu6V3otJcS', 1600000000);
+
+INSERT INTO payments VALUES
+	('paymenta', 'accountx', 26, 10, 3000000000, 1600000003, 0, '',
+	'0000000000000000000000000000000000000000000000000000000000000001',
+	'0000000000000000000000000000000000000000000000000000000000000000'),
+	('paymentb', 'accounty', 26, 10, 2000000000, 1600000003, 0, '',
+	'0000000000000000000000000000000000000000000000000000000000000001',
+	'0000000000000000000000000000000000000000000000000000000000000000'),
+	('paymentc', 'fees', 26, 10, 100000000, 1600000003, 0, '',
+	'0000000000000000000000000000000000000000000000000000000000000001',
+	'0000000000000000000000000000000000000000000000000000000000000000');
+
+INSERT INTO archivedpayments VALUES
+	('paymentd', 'accountx', 25, 9, 5000000000, 1600000001, 30, 'txid',
+	'0000000000000000000000000000000000000000000000000000000000000002',
+	'0000000000000000000000000000000000000000000000000000000000000000'),
+	('paymente', 'accounty', 25, 9, 2500000000, 1600000001, 30, 'txid',
+	'0000000000000000000000000000000000000000000000000000000000000002',
+	'0000000000000000000000000000000000000000000000000000000000000000');
@@ -0,0 +1,140 @@
+-- Postgres schema snapshot at pgBlockEventVersion, before the accepted work
+-- status was introduced. This file should not be updated for schema changes.
+
+CREATE TABLE metadata (
+	key      TEXT PRIMARY KEY,
+	value    TEXT NOT NULL
+);
+
+CREATE TABLE accounts (
+	uuid      TEXT PRIMARY KEY,
+	address   TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE payments (
+	uuid              TEXT PRIMARY KEY,
+	account           TEXT NOT NULL,
+	estimatedmaturity INT8 NOT NULL,
+	height            INT8 NOT NULL,
+	amount            INT8 NOT NULL,
+	createdon         INT8 NOT NULL,
+	paidonheight      INT8 NOT NULL,
+	transactionid     TEXT NOT NULL,
+	sourceblockhash   TEXT NOT NULL,
+	sourcecoinbase    TEXT NOT NULL
+);
+
+CREATE TABLE archivedpayments (
+	uuid              TEXT PRIMARY KEY,
+	account           TEXT NOT NULL,
+	estimatedmaturity INT8 NOT NULL,
+	height            INT8 NOT NULL,
+	amount            INT8 NOT NULL,
+	createdon         INT8 NOT NULL,
+	paidonheight      INT8 NOT NULL,
+	transactionid     TEXT NOT NULL,
+	sourceblockhash   TEXT NOT NULL,
+	sourcecoinbase    TEXT NOT NULL
+);
+
+CREATE TABLE jobs (
+	uuid   TEXT PRIMARY KEY,
+	height INT8 NOT NULL,
+	header TEXT NOT NULL
+);
+
+CREATE TABLE shares (
+	uuid      TEXT PRIMARY KEY,
+	account   TEXT NOT NULL,
+	weight    TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE acceptedwork (
+	uuid      TEXT    PRIMARY KEY,
+	blockhash TEXT    NOT NULL,
+	prevhash  TEXT    NOT NULL,
+	height    INT8    NOT NULL,
+	minedby   TEXT    NOT NULL,
+	miner     TEXT    NOT NULL,
+	createdon INT8    NOT NULL,
+	confirmed BOOLEAN NOT NULL
+);
+
+CREATE TABLE hashdata (
+	uuid      TEXT    PRIMARY KEY,
+	accountid TEXT    NOT NULL,
+	miner     TEXT    NOT NULL,
+	ip        TEXT    NOT NULL,
+	hashrate  TEXT    NOT NULL,
+	updatedon INT8    NOT NULL
+);
+
+CREATE TABLE apitokens (
+	uuid      TEXT PRIMARY KEY,
+	accountid TEXT NOT NULL,
+	kind      TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE adminusers (
+	uuid         TEXT PRIMARY KEY,
+	passwordhash TEXT NOT NULL,
+	role         TEXT NOT NULL,
+	totpsecret   TEXT NOT NULL,
+	createdon    INT8 NOT NULL
+);
+
+CREATE TABLE auditlog (
+	uuid      TEXT PRIMARY KEY,
+	actor     TEXT NOT NULL,
+	action    TEXT NOT NULL,
+	details   TEXT NOT NULL,
+	ip        TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE bans (
+	uuid      TEXT PRIMARY KEY,
+	kind      TEXT NOT NULL,
+	reason    TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE ledger (
+	uuid      TEXT PRIMARY KEY,
+	kind      TEXT NOT NULL,
+	debit     TEXT NOT NULL,
+	credit    TEXT NOT NULL,
+	amount    INT8 NOT NULL,
+	blockhash TEXT NOT NULL,
+	txid      TEXT NOT NULL,
+	actor     TEXT NOT NULL,
+	memo      TEXT NOT NULL,
+	createdon INT8 NOT NULL
+);
+
+CREATE TABLE blockevents (
+	uuid        TEXT PRIMARY KEY,
+	kind        TEXT NOT NULL,
+	header      TEXT NOT NULL,
+	height      INT8 NOT NULL,
+	attempts    INT8 NOT NULL,
+	lasterror   TEXT NOT NULL,
+	nextattempt INT8 NOT NULL,
+	createdon   INT8 NOT NULL
+);
+
+INSERT INTO metadata(key, value) VALUES
+	('version', '6'),
+	('poolmode', '0');
+
+INSERT INTO accounts(uuid, address, createdon) VALUES
+	('accountx', 'SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc', 1600000000);
+
+INSERT INTO acceptedwork VALUES
+	('worka', 'blockhasha', 'prevhasha', 10, 'accountx', 'cpu', 1600000000,
+	TRUE),
+	('workb', 'blockhashb', 'blockhasha', 11, 'accountx', 'cpu', 1600000001,
+	FALSE);
@@ -0,0 +1,125 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// This file should compiled from the commit the file was introduced, otherwise
+// it may not compile due to API changes, or may not create the database with
+// the correct old version.  This file should not be updated for API changes.
+
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrpool/pool"
+)
+
+const dbname = "v10.db"
+
+func main() {
+	err := setup()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "setup: %v\n", err)
+		os.Exit(1)
+	}
+	err = compress()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compress: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func setup() error {
+	xID := pool.AccountID("SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc")
+	yID := pool.AccountID("Ssp7J7TUmi5iPhoQnWYNGQbeGhu6V3otJcS")
+
+	db, err := pool.InitBoltDB(dbname)
+	if err != nil {
+		return err
+	}
+
+	pendingSource := &pool.PaymentSource{
+		BlockHash: chainhash.Hash{1}.String(),
+		Coinbase:  chainhash.Hash{0}.String(),
+	}
+	paidSource := &pool.PaymentSource{
+		BlockHash: chainhash.Hash{2}.String(),
+		Coinbase:  chainhash.Hash{0}.String(),
+	}
+
+	height := uint32(10)
+	estMaturity := uint32(26)
+
+	// Pending payments of accounts X and Y and the pool fees.
+	pending := []struct {
+		account string
+		amount  float64
+	}{
+		{xID, 30},
+		{yID, 20},
+		{pool.PoolFeesK, 1},
+	}
+	for _, p := range pending {
+		amt, err := dcrutil.NewAmount(p.amount)
+		if err != nil {
+			return err
+		}
+		pmt := pool.NewPayment(p.account, pendingSource, amt, height,
+			estMaturity)
+		err = db.PersistPayment(pmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Archived payments of accounts X and Y paid out by the same
+	// transaction.
+	archived := []struct {
+		account string
+		amount  float64
+	}{
+		{xID, 50},
+		{yID, 25},
+	}
+	for _, p := range archived {
+		amt, err := dcrutil.NewAmount(p.amount)
+		if err != nil {
+			return err
+		}
+		pmt := pool.NewPayment(p.account, paidSource, amt, height-1,
+			estMaturity-1)
+		pmt.PaidOnHeight = estMaturity + 4
+		pmt.TransactionID = chainhash.Hash{3}.String()
+		err = db.ArchivePayment(pmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Close()
+}
+
+func compress() error {
+	db, err := os.Open(dbname)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dbname)
+	defer db.Close()
+	dbgz, err := os.Create(dbname + ".gz")
+	if err != nil {
+		return err
+	}
+	defer dbgz.Close()
+	gz := gzip.NewWriter(dbgz)
+	_, err = io.Copy(gz, db)
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
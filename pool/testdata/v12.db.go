@@ -0,0 +1,93 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// This file should compiled from the commit the file was introduced, otherwise
+// it may not compile due to API changes, or may not create the database with
+// the correct old version.  This file should not be updated for API changes.
+
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrpool/pool"
+	bolt "go.etcd.io/bbolt"
+)
+
+const dbname = "v12.db"
+
+func main() {
+	err := setup()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "setup: %v\n", err)
+		os.Exit(1)
+	}
+	err = compress()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compress: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func setup() error {
+	xID := pool.AccountID("SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc")
+
+	db, err := pool.InitBoltDB(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Confirmed mined work and work submitted but not yet confirmed, the
+	// accepted work persistence methods are not exported.
+	mined := pool.NewAcceptedWork(chainhash.Hash{1}.String(),
+		chainhash.Hash{0}.String(), 10, xID, pool.CPU)
+	mined.Confirmed = true
+	submitted := pool.NewAcceptedWork(chainhash.Hash{2}.String(),
+		chainhash.Hash{1}.String(), 11, xID, pool.CPU)
+
+	err = db.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte("poolbkt")).Bucket([]byte("workbkt"))
+		for _, work := range []*pool.AcceptedWork{mined, submitted} {
+			workBytes, err := json.Marshal(work)
+			if err != nil {
+				return err
+			}
+			err = bkt.Put([]byte(work.UUID), workBytes)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.Close()
+}
+
+func compress() error {
+	db, err := os.Open(dbname)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dbname)
+	defer db.Close()
+	dbgz, err := os.Create(dbname + ".gz")
+	if err != nil {
+		return err
+	}
+	defer dbgz.Close()
+	gz := gzip.NewWriter(dbgz)
+	_, err = io.Copy(gz, db)
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"decred.org/dcrwallet/rpc/walletrpc"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"google.golang.org/grpc"
+)
+
+const (
+	// defaultWalletCheckInterval is the default interval between wallet
+	// health checks.
+	defaultWalletCheckInterval = time.Minute
+
+	// defaultWalletMaxSyncLag is the default number of blocks the wallet may
+	// lag behind the mining node before it is considered out of sync.
+	defaultWalletMaxSyncLag = 2
+)
+
+const (
+	// WalletUnreachable is the kind of wallet problem of a wallet which
+	// cannot be reached.
+	WalletUnreachable = "unreachable"
+
+	// WalletOutOfSync is the kind of wallet problem of a wallet lagging
+	// behind the mining node.
+	WalletOutOfSync = "outofsync"
+
+	// WalletUnderfunded is the kind of wallet problem of a wallet account
+	// whose spendable balance does not cover the mature pending payments.
+	WalletUnderfunded = "underfunded"
+
+	// WalletLocked is the kind of wallet problem of an individually
+	// encrypted wallet account which is locked.
+	WalletLocked = "locked"
+)
+
+// MonitoredWalletConnection defines the functionality needed by a wallet
+// connection to monitor its health.
+type MonitoredWalletConnection interface {
+	Balance(context.Context, *walletrpc.BalanceRequest, ...grpc.CallOption) (*walletrpc.BalanceResponse, error)
+	BestBlock(context.Context, *walletrpc.BestBlockRequest, ...grpc.CallOption) (*walletrpc.BestBlockResponse, error)
+	Accounts(context.Context, *walletrpc.AccountsRequest, ...grpc.CallOption) (*walletrpc.AccountsResponse, error)
+}
+
+// WalletMonitorConfig contains the configuration details of a wallet
+// monitor.
+type WalletMonitorConfig struct {
+	// Wallet represents the monitored wallet connection.
+	Wallet MonitoredWalletConnection
+	// Account represents the wallet account payouts are made from.
+	Account uint32
+	// CheckInterval represents the interval between wallet health checks.
+	// Zero uses the default interval.
+	CheckInterval time.Duration
+	// MaxSyncLag represents the number of blocks the wallet may lag behind
+	// the mining node before it is considered out of sync. Zero uses the
+	// default lag.
+	MaxSyncLag uint32
+	// OfflineSigning represents whether payouts are signed by an external
+	// wallet, the unlock state of the wallet account is not checked then.
+	OfflineSigning bool
+	// FetchNodeHeight returns the chain tip height of the mining node.
+	FetchNodeHeight func(context.Context) (uint32, error)
+	// FetchMaturePayments returns the total amount of the pending payments
+	// mature at the provided height.
+	FetchMaturePayments func(height uint32) (dcrutil.Amount, error)
+	// NotifyWebhooks queues the provided event for delivery to the operator
+	// configured URLs and the webhooks of the accounts keyed in the
+	// provided account data.
+	NotifyWebhooks func(event string, data interface{}, accountData map[string]interface{})
+	// HubWg represents the hub's waitgroup.
+	HubWg *sync.WaitGroup
+}
+
+// WalletProblem represents a wallet problem detected by the wallet monitor.
+type WalletProblem struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Since       int64  `json:"since"`
+}
+
+// WalletStatus represents the outcome of the latest wallet health check
+// along with the number of checks performed and the number of checks each
+// kind of problem was detected by.
+type WalletStatus struct {
+	LastCheck        int64             `json:"lastcheck"`
+	WalletHeight     uint32            `json:"walletheight"`
+	NodeHeight       uint32            `json:"nodeheight"`
+	Spendable        dcrutil.Amount    `json:"spendable"`
+	MaturePayments   dcrutil.Amount    `json:"maturepayments"`
+	AccountEncrypted bool              `json:"accountencrypted"`
+	AccountUnlocked  bool              `json:"accountunlocked"`
+	Problems         []*WalletProblem  `json:"problems"`
+	Checks           uint64            `json:"checks"`
+	ProblemCounts    map[string]uint64 `json:"problemcounts"`
+}
+
+// WalletMonitor periodically checks the connectivity, sync height, spendable
+// balance and unlock state of the pool wallet, so problems are surfaced
+// before a payout fails.
+type WalletMonitor struct {
+	cfg    *WalletMonitorConfig
+	status WalletStatus
+	mtx    sync.RWMutex
+}
+
+// NewWalletMonitor initializes a wallet monitor.
+func NewWalletMonitor(cfg *WalletMonitorConfig) *WalletMonitor {
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = defaultWalletCheckInterval
+	}
+	if cfg.MaxSyncLag == 0 {
+		cfg.MaxSyncLag = defaultWalletMaxSyncLag
+	}
+	return &WalletMonitor{
+		cfg: cfg,
+		status: WalletStatus{
+			ProblemCounts: make(map[string]uint64),
+		},
+	}
+}
+
+// inspect queries the wallet and the mining node, returning the resulting
+// wallet status and the problems detected.
+func (wm *WalletMonitor) inspect(ctx context.Context) (*WalletStatus, []*WalletProblem) {
+	status := new(WalletStatus)
+
+	balance, err := wm.cfg.Wallet.Balance(ctx, &walletrpc.BalanceRequest{
+		AccountNumber:         wm.cfg.Account,
+		RequiredConfirmations: 1,
+	})
+	if err != nil {
+		return status, []*WalletProblem{{
+			Kind:        WalletUnreachable,
+			Description: fmt.Sprintf("unable to fetch wallet balance: %v", err),
+		}}
+	}
+	status.Spendable = dcrutil.Amount(balance.Spendable)
+
+	best, err := wm.cfg.Wallet.BestBlock(ctx, &walletrpc.BestBlockRequest{})
+	if err != nil {
+		return status, []*WalletProblem{{
+			Kind: WalletUnreachable,
+			Description: fmt.Sprintf("unable to fetch wallet best "+
+				"block: %v", err),
+		}}
+	}
+	status.WalletHeight = best.Height
+
+	accounts, err := wm.cfg.Wallet.Accounts(ctx, &walletrpc.AccountsRequest{})
+	if err != nil {
+		return status, []*WalletProblem{{
+			Kind: WalletUnreachable,
+			Description: fmt.Sprintf("unable to fetch wallet "+
+				"accounts: %v", err),
+		}}
+	}
+	for _, acc := range accounts.Accounts {
+		if acc.AccountNumber == wm.cfg.Account {
+			status.AccountEncrypted = acc.AccountEncrypted
+			status.AccountUnlocked = acc.AccountUnlocked
+			break
+		}
+	}
+
+	var problems []*WalletProblem
+
+	// Individually encrypted accounts are not unlocked by the wallet
+	// passphrase payouts are signed with.
+	if !wm.cfg.OfflineSigning && status.AccountEncrypted &&
+		!status.AccountUnlocked {
+		problems = append(problems, &WalletProblem{
+			Kind: WalletLocked,
+			Description: fmt.Sprintf("wallet account %d is locked",
+				wm.cfg.Account),
+		})
+	}
+
+	// The sync height and balance checks need the chain tip of the mining
+	// node, they are skipped when it cannot be reached.
+	nodeHeight, err := wm.cfg.FetchNodeHeight(ctx)
+	if err != nil {
+		log.Debugf("unable to fetch node height for wallet check: %v", err)
+		return status, problems
+	}
+	status.NodeHeight = nodeHeight
+
+	if nodeHeight > status.WalletHeight+wm.cfg.MaxSyncLag {
+		problems = append(problems, &WalletProblem{
+			Kind: WalletOutOfSync,
+			Description: fmt.Sprintf("wallet is at height %d, %d blocks "+
+				"behind the mining node", status.WalletHeight,
+				nodeHeight-status.WalletHeight),
+		})
+	}
+
+	mature, err := wm.cfg.FetchMaturePayments(nodeHeight)
+	if err != nil {
+		log.Errorf("unable to fetch mature payments for wallet check: %v", err)
+		return status, problems
+	}
+	status.MaturePayments = mature
+
+	if status.Spendable < mature {
+		problems = append(problems, &WalletProblem{
+			Kind: WalletUnderfunded,
+			Description: fmt.Sprintf("spendable balance of %v does not "+
+				"cover %v of mature pending payments", status.Spendable,
+				mature),
+		})
+	}
+
+	return status, problems
+}
+
+// check performs a wallet health check and records its outcome. Detected
+// and resolved problems are logged once, detected problems are also sent to
+// the operator configured webhooks.
+func (wm *WalletMonitor) check(ctx context.Context) {
+	status, problems := wm.inspect(ctx)
+	now := time.Now().Unix()
+
+	var detected []*WalletProblem
+	wm.mtx.Lock()
+
+	previous := make(map[string]*WalletProblem, len(wm.status.Problems))
+	for _, problem := range wm.status.Problems {
+		previous[problem.Kind] = problem
+	}
+
+	current := make(map[string]struct{}, len(problems))
+	for _, problem := range problems {
+		current[problem.Kind] = struct{}{}
+		wm.status.ProblemCounts[problem.Kind]++
+		if prev, ok := previous[problem.Kind]; ok {
+			problem.Since = prev.Since
+			continue
+		}
+		problem.Since = now
+		log.Warnf("Wallet problem detected: %s", problem.Description)
+		p := *problem
+		detected = append(detected, &p)
+	}
+	for kind := range previous {
+		if _, ok := current[kind]; !ok {
+			log.Infof("Wallet problem resolved: %s", kind)
+		}
+	}
+
+	status.LastCheck = now
+	status.Problems = problems
+	status.Checks = wm.status.Checks + 1
+	status.ProblemCounts = wm.status.ProblemCounts
+	wm.status = *status
+	wm.mtx.Unlock()
+
+	for _, problem := range detected {
+		wm.cfg.NotifyWebhooks(WebhookWalletProblem, problem, nil)
+	}
+}
+
+// Status returns the outcome of the latest wallet health check.
+func (wm *WalletMonitor) Status() *WalletStatus {
+	wm.mtx.RLock()
+	defer wm.mtx.RUnlock()
+
+	status := wm.status
+	status.Problems = make([]*WalletProblem, 0, len(wm.status.Problems))
+	for _, problem := range wm.status.Problems {
+		p := *problem
+		status.Problems = append(status.Problems, &p)
+	}
+	status.ProblemCounts = make(map[string]uint64, len(wm.status.ProblemCounts))
+	for kind, count := range wm.status.ProblemCounts {
+		status.ProblemCounts[kind] = count
+	}
+	return &status
+}
+
+// run checks the wallet health at the configured interval until the
+// provided context is cancelled.
+// This should be run as a goroutine.
+func (wm *WalletMonitor) run(ctx context.Context) {
+	wm.check(ctx)
+
+	ticker := time.NewTicker(wm.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wm.cfg.HubWg.Done()
+			return
+
+		case <-ticker.C:
+			wm.check(ctx)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"decred.org/dcrwallet/rpc/walletrpc"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"google.golang.org/grpc"
+)
+
+// tMonitoredWallet is a wallet connection of a wallet monitor with a
+// configurable height, balance, lock state and availability.
+type tMonitoredWallet struct {
+	height    uint32
+	spendable int64
+	encrypted bool
+	unlocked  bool
+	down      bool
+}
+
+func (t *tMonitoredWallet) Balance(context.Context, *walletrpc.BalanceRequest, ...grpc.CallOption) (*walletrpc.BalanceResponse, error) {
+	if t.down {
+		return nil, errors.New("wallet unreachable")
+	}
+	return &walletrpc.BalanceResponse{Spendable: t.spendable}, nil
+}
+
+func (t *tMonitoredWallet) BestBlock(context.Context, *walletrpc.BestBlockRequest, ...grpc.CallOption) (*walletrpc.BestBlockResponse, error) {
+	return &walletrpc.BestBlockResponse{Height: t.height}, nil
+}
+
+func (t *tMonitoredWallet) Accounts(context.Context, *walletrpc.AccountsRequest, ...grpc.CallOption) (*walletrpc.AccountsResponse, error) {
+	return &walletrpc.AccountsResponse{
+		Accounts: []*walletrpc.AccountsResponse_Account{{
+			AccountNumber:    1,
+			AccountEncrypted: t.encrypted,
+			AccountUnlocked:  t.unlocked,
+		}},
+	}, nil
+}
+
+func TestWalletMonitor(t *testing.T) {
+	ctx := context.Background()
+	wallet := &tMonitoredWallet{height: 100, spendable: 500}
+	nodeHeight := uint32(100)
+	mature := dcrutil.Amount(400)
+	var notified []string
+	wm := NewWalletMonitor(&WalletMonitorConfig{
+		Wallet:  wallet,
+		Account: 1,
+		FetchNodeHeight: func(context.Context) (uint32, error) {
+			return nodeHeight, nil
+		},
+		FetchMaturePayments: func(height uint32) (dcrutil.Amount, error) {
+			if height != nodeHeight {
+				t.Fatalf("expected mature payments at height %d, got %d",
+					nodeHeight, height)
+			}
+			return mature, nil
+		},
+		NotifyWebhooks: func(event string, data interface{}, _ map[string]interface{}) {
+			if event != WebhookWalletProblem {
+				t.Fatalf("expected a %s event, got %s", WebhookWalletProblem,
+					event)
+			}
+			notified = append(notified, data.(*WalletProblem).Kind)
+		},
+	})
+
+	// problemKinds returns the kinds of wallet problems of the latest check.
+	problemKinds := func() map[string]int64 {
+		kinds := make(map[string]int64)
+		for _, problem := range wm.Status().Problems {
+			kinds[problem.Kind] = problem.Since
+		}
+		return kinds
+	}
+
+	// Ensure a healthy wallet has no problems.
+	wm.check(ctx)
+	status := wm.Status()
+	if len(status.Problems) != 0 {
+		t.Fatalf("expected no wallet problems, got %d", len(status.Problems))
+	}
+	if status.Checks != 1 || status.Spendable != 500 ||
+		status.MaturePayments != 400 || status.WalletHeight != 100 {
+		t.Fatalf("unexpected wallet status %+v", status)
+	}
+
+	// Ensure an unreachable wallet is reported.
+	wallet.down = true
+	wm.check(ctx)
+	kinds := problemKinds()
+	if _, ok := kinds[WalletUnreachable]; !ok || len(kinds) != 1 {
+		t.Fatalf("expected only an unreachable problem, got %v", kinds)
+	}
+
+	// Ensure a lagging wallet, an insufficient spendable balance and a locked
+	// account are reported, and that the unreachable problem is resolved.
+	wallet.down = false
+	wallet.encrypted = true
+	nodeHeight = 103
+	mature = 600
+	wm.check(ctx)
+	kinds = problemKinds()
+	for _, kind := range []string{WalletOutOfSync, WalletUnderfunded,
+		WalletLocked} {
+		if _, ok := kinds[kind]; !ok {
+			t.Fatalf("expected a %s problem, got %v", kind, kinds)
+		}
+	}
+	if len(kinds) != 3 {
+		t.Fatalf("expected 3 wallet problems, got %v", kinds)
+	}
+	if len(notified) != 4 {
+		t.Fatalf("expected 4 wallet problem notifications, got %v", notified)
+	}
+
+	// Ensure a persisting problem keeps the time it was first detected and
+	// is counted by every check detecting it.
+	since := kinds[WalletUnderfunded]
+	wm.check(ctx)
+	kinds = problemKinds()
+	if kinds[WalletUnderfunded] != since {
+		t.Fatalf("expected problem detected at %d, got %d", since,
+			kinds[WalletUnderfunded])
+	}
+	if len(notified) != 4 {
+		t.Fatalf("expected persisting problems not to be notified again, "+
+			"got %v", notified)
+	}
+	status = wm.Status()
+	if status.ProblemCounts[WalletUnderfunded] != 2 ||
+		status.ProblemCounts[WalletUnreachable] != 1 || status.Checks != 4 {
+		t.Fatalf("unexpected wallet problem counts %v after %d checks",
+			status.ProblemCounts, status.Checks)
+	}
+
+	// Ensure locked accounts are not reported when payouts are signed
+	// offline, and that problems are resolved once fixed.
+	wm.cfg.OfflineSigning = true
+	wallet.height = 103
+	wallet.spendable = 600
+	wm.check(ctx)
+	kinds = problemKinds()
+	if len(kinds) != 0 {
+		t.Fatalf("expected no wallet problems, got %v", kinds)
+	}
+}
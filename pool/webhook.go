@@ -0,0 +1,722 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// WebhookBlockFound is the webhook event of work accepted by the
+	// network.
+	WebhookBlockFound = "blockfound"
+
+	// WebhookBlockConfirmed is the webhook event of mined work confirmed by
+	// a connected block.
+	WebhookBlockConfirmed = "blockconfirmed"
+
+	// WebhookBlockOrphaned is the webhook event of accepted work orphaned
+	// by the chain.
+	WebhookBlockOrphaned = "blockorphaned"
+
+	// WebhookPayoutPublished is the webhook event of a published payout
+	// transaction.
+	WebhookPayoutPublished = "payoutpublished"
+
+	// WebhookWalletProblem is the webhook event of a newly detected wallet
+	// problem.
+	WebhookWalletProblem = "walletproblem"
+
+	// WebhookWorkerOffline is the webhook event of a tracked worker which
+	// stopped submitting shares.
+	WebhookWorkerOffline = "workeroffline"
+
+	// WebhookWorkerLowHashRate is the webhook event of a tracked worker
+	// whose hashrate dropped below the configured fraction of its baseline.
+	WebhookWorkerLowHashRate = "workerlowhashrate"
+
+	// WebhookWorkerRecovered is the webhook event of an offline or low
+	// hashrate worker back to its baseline hashrate.
+	WebhookWorkerRecovered = "workerrecovered"
+)
+
+const (
+	// WebhookEventHeader is the request header carrying the event of a
+	// webhook delivery.
+	WebhookEventHeader = "X-Dcrpool-Event"
+
+	// WebhookDeliveryHeader is the request header carrying the id of a
+	// webhook delivery, which is the same for every attempt.
+	WebhookDeliveryHeader = "X-Dcrpool-Delivery"
+
+	// WebhookSignatureHeader is the request header carrying the hex encoded
+	// HMAC-SHA256 of the request body, prefixed by "sha256=".
+	WebhookSignatureHeader = "X-Dcrpool-Signature"
+)
+
+const (
+	// maxWebhooksPerAccount is the maximum number of webhooks an account
+	// can register at any time.
+	maxWebhooksPerAccount = 5
+
+	// defaultWebhookMaxAttempts is the default number of attempts to
+	// deliver a webhook event before it is dropped.
+	defaultWebhookMaxAttempts = 10
+
+	// webhookRetryDelay is the delay before retrying a webhook delivery
+	// which failed for the first time, doubling with every attempt.
+	webhookRetryDelay = time.Second * 10
+
+	// webhookMaxRetryDelay is the maximum delay before retrying a webhook
+	// delivery.
+	webhookMaxRetryDelay = time.Hour
+
+	// webhookPollInterval is the interval at which webhook deliveries due
+	// for a retry are checked for.
+	webhookPollInterval = time.Second * 5
+
+	// webhookTimeout is the timeout of a webhook delivery request.
+	webhookTimeout = time.Second * 10
+
+	// webhookSecretSize is the number of random bytes of a webhook secret.
+	webhookSecretSize = 32
+
+	// maxWebhookHosts is the maximum number of hosts account webhook
+	// deliveries are concurrently attempted to. Deliveries to a host are
+	// attempted one at a time, and deliveries to the operator configured
+	// URLs do not count towards the limit.
+	maxWebhookHosts = 16
+
+	// maxPendingWebhookDeliveries is the maximum number of deliveries
+	// pending to a webhook registered by an account, further events are
+	// dropped until pending deliveries complete.
+	maxPendingWebhookDeliveries = 100
+)
+
+// nonPublicNets are the address ranges account webhooks may not be
+// delivered to, keeping accounts from reaching the pool's own services and
+// network.
+var nonPublicNets = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"64:ff9b::/96",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	}
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}()
+
+// isPublicIP returns whether the provided IP is a publicly routable
+// address.
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, ipNet := range nonPublicNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicDialControl refuses connections to addresses which are not
+// publicly routable. It is checked against the resolved address of every
+// connection so hostnames resolving to internal addresses, including after
+// being validated, are refused as well.
+func publicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s",
+			address)
+	}
+	return nil
+}
+
+// newAccountWebhookClient returns the http client delivering to the
+// webhooks registered by accounts. It only connects to public addresses,
+// bypassing any configured proxy, and does not follow redirects.
+func newAccountWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: publicDialControl,
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 1,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Webhook represents a URL registered by the holder of an account to be
+// notified of the events of the account. The secret signs the payloads
+// delivered to the URL.
+type Webhook struct {
+	UUID      string `json:"uuid"`
+	AccountID string `json:"accountid"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// WebhookDelivery represents a webhook event pending delivery to a URL.
+// Deliveries are persisted until delivered so failed deliveries are
+// retried, including across restarts. The webhook id is empty for
+// deliveries to the operator configured URLs.
+type WebhookDelivery struct {
+	UUID        string `json:"uuid"`
+	WebhookID   string `json:"webhookid"`
+	URL         string `json:"url"`
+	Event       string `json:"event"`
+	Payload     string `json:"payload"`
+	Attempts    uint32 `json:"attempts"`
+	LastError   string `json:"lasterror"`
+	NextAttempt int64  `json:"nextattempt"`
+	CreatedOn   int64  `json:"createdon"`
+}
+
+// WebhookPayload is the JSON body of a webhook delivery. The account id is
+// only set for deliveries to webhooks registered by an account.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	AccountID string      `json:"accountid,omitempty"`
+	CreatedOn int64       `json:"createdon"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookPayout is the data of a payout published webhook event. The
+// amount is the total paid out and the outputs are only set for the
+// operator, account webhooks are sent the amount paid to the account.
+type WebhookPayout struct {
+	TxID    string                    `json:"txid"`
+	Height  uint32                    `json:"height"`
+	Amount  dcrutil.Amount            `json:"amount"`
+	Outputs map[string]dcrutil.Amount `json:"outputs,omitempty"`
+}
+
+// randomHex returns the hex encoding of the provided number of random
+// bytes.
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newWebhook creates a webhook for the provided URL of the referenced
+// account along with the secret signing its payloads.
+func newWebhook(accountID string, hookURL string) (*Webhook, error) {
+	const funcName = "newWebhook"
+	id, err := randomHex(16)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to generate webhook id: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.CreateHash, desc)
+	}
+	secret, err := randomHex(webhookSecretSize)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to generate webhook secret: %v",
+			funcName, err)
+		return nil, errs.PoolError(errs.CreateHash, desc)
+	}
+	return &Webhook{
+		UUID:      id,
+		AccountID: accountID,
+		URL:       hookURL,
+		Secret:    secret,
+		CreatedOn: time.Now().UnixNano(),
+	}, nil
+}
+
+// ValidateWebhookURL asserts the provided URL is an absolute http or https
+// URL.
+func ValidateWebhookURL(hookURL string) error {
+	const funcName = "ValidateWebhookURL"
+	u, err := url.Parse(hookURL)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to parse webhook url: %v",
+			funcName, err)
+		return errs.PoolError(errs.Parse, desc)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		desc := fmt.Sprintf("%s: webhook url %q is not an absolute http "+
+			"or https url", funcName, hookURL)
+		return errs.PoolError(errs.Parse, desc)
+	}
+	return nil
+}
+
+// validateAccountWebhookURL asserts the provided URL registered by an
+// account is an absolute https URL which does not address a non-public IP.
+// Hostnames are checked when connecting to them.
+func validateAccountWebhookURL(hookURL string) error {
+	const funcName = "validateAccountWebhookURL"
+	err := ValidateWebhookURL(hookURL)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(hookURL)
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to parse webhook url: %v",
+			funcName, err)
+		return errs.PoolError(errs.Parse, desc)
+	}
+	if u.Scheme != "https" {
+		desc := fmt.Sprintf("%s: webhook url %q is not an https url",
+			funcName, hookURL)
+		return errs.PoolError(errs.Parse, desc)
+	}
+	host := u.Hostname()
+	ip := net.ParseIP(host)
+	if (ip != nil && !isPublicIP(ip)) || strings.EqualFold(host, "localhost") {
+		desc := fmt.Sprintf("%s: webhook url %q does not address a "+
+			"public host", funcName, hookURL)
+		return errs.PoolError(errs.Parse, desc)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value of the provided
+// webhook payload signed with the provided secret.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sortWebhookDeliveries orders the provided webhook deliveries by creation
+// time, oldest first.
+func sortWebhookDeliveries(deliveries []*WebhookDelivery) {
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		if deliveries[i].CreatedOn == deliveries[j].CreatedOn {
+			return deliveries[i].UUID < deliveries[j].UUID
+		}
+		return deliveries[i].CreatedOn < deliveries[j].CreatedOn
+	})
+}
+
+// WebhookNotifierConfig contains the configuration details of a webhook
+// notifier.
+type WebhookNotifierConfig struct {
+	// db represents the pool database.
+	db Database
+	// URLs represents the operator configured URLs notified of all events.
+	URLs []string
+	// Secret represents the secret signing the payloads delivered to the
+	// operator configured URLs.
+	Secret string
+	// MaxAttempts represents the number of attempts to deliver a webhook
+	// event before it is dropped. Zero uses the default.
+	MaxAttempts uint32
+	// RetryDelay represents the delay before retrying a webhook delivery
+	// which failed for the first time. Zero uses the default.
+	RetryDelay time.Duration
+	// MaxRetryDelay represents the maximum delay before retrying a webhook
+	// delivery. Zero uses the default.
+	MaxRetryDelay time.Duration
+	// HubWg represents the hub's waitgroup.
+	HubWg *sync.WaitGroup
+}
+
+// WebhookNotifier queues pool events for delivery to the operator
+// configured URLs and the webhooks registered by accounts, and delivers
+// them.
+type WebhookNotifier struct {
+	seq uint64 // update atomically.
+
+	cfg *WebhookNotifierConfig
+
+	// client delivers to the operator configured URLs, accountClient to the
+	// webhooks registered by accounts.
+	client        *http.Client
+	accountClient *http.Client
+	wakeCh        chan struct{}
+
+	// queues are the delivery queues being processed, keyed by host.
+	queues      map[string]struct{}
+	activeHosts int
+	queueMtx    sync.Mutex
+	queueWg     sync.WaitGroup
+}
+
+// NewWebhookNotifier initializes a webhook notifier.
+func NewWebhookNotifier(cfg *WebhookNotifierConfig) *WebhookNotifier {
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = webhookRetryDelay
+	}
+	if cfg.MaxRetryDelay == 0 {
+		cfg.MaxRetryDelay = webhookMaxRetryDelay
+	}
+	return &WebhookNotifier{
+		cfg:           cfg,
+		client:        &http.Client{Timeout: webhookTimeout},
+		accountClient: newAccountWebhookClient(),
+		wakeCh:        make(chan struct{}, 1),
+		queues:        make(map[string]struct{}),
+	}
+}
+
+// newDelivery creates a delivery of the provided event data to the
+// provided URL.
+func (wn *WebhookNotifier) newDelivery(event, webhookID, hookURL, accountID string, data interface{}, createdOn int64) (*WebhookDelivery, error) {
+	const funcName = "newDelivery"
+	id := fmt.Sprintf("%d-%d", createdOn, atomic.AddUint64(&wn.seq, 1))
+	payload, err := json.Marshal(&WebhookPayload{
+		ID:        id,
+		Event:     event,
+		AccountID: accountID,
+		CreatedOn: createdOn,
+		Data:      data,
+	})
+	if err != nil {
+		desc := fmt.Sprintf("%s: unable to marshal %s payload: %v",
+			funcName, event, err)
+		return nil, errs.PoolError(errs.Parse, desc)
+	}
+	return &WebhookDelivery{
+		UUID:      id,
+		WebhookID: webhookID,
+		URL:       hookURL,
+		Event:     event,
+		Payload:   string(payload),
+		CreatedOn: createdOn,
+	}, nil
+}
+
+// notify queues the provided event for delivery. The operator configured
+// URLs are sent the provided data, the webhooks of every account keyed in
+// the provided account data are sent the data of the account. Operator
+// configured URLs are not notified when the data is nil.
+func (wn *WebhookNotifier) notify(event string, data interface{}, accountData map[string]interface{}) {
+	now := time.Now().UnixNano()
+	var deliveries []*WebhookDelivery
+	if data != nil {
+		for _, hookURL := range wn.cfg.URLs {
+			delivery, err := wn.newDelivery(event, "", hookURL, "", data, now)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	var pending map[string]int
+	for accountID, accData := range accountData {
+		if accountID == "" {
+			continue
+		}
+		hooks, err := wn.cfg.db.fetchWebhooksForAccount(accountID)
+		if err != nil {
+			log.Errorf("unable to fetch webhooks of account %s: %v",
+				accountID, err)
+			continue
+		}
+		if len(hooks) > 0 && pending == nil {
+			pending, err = wn.pendingDeliveries()
+			if err != nil {
+				log.Errorf("unable to count pending webhook deliveries: %v",
+					err)
+				continue
+			}
+		}
+		for _, hook := range hooks {
+			if pending[hook.UUID] >= maxPendingWebhookDeliveries {
+				log.Warnf("Dropping %s webhook delivery to %s, %d "+
+					"deliveries are already pending", event, hook.URL,
+					pending[hook.UUID])
+				continue
+			}
+			pending[hook.UUID]++
+			delivery, err := wn.newDelivery(event, hook.UUID, hook.URL,
+				accountID, accData, now)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	for _, delivery := range deliveries {
+		err := wn.cfg.db.persistWebhookDelivery(delivery)
+		if err != nil {
+			log.Errorf("unable to queue %s webhook delivery to %s: %v",
+				event, delivery.URL, err)
+		}
+	}
+
+	select {
+	case wn.wakeCh <- struct{}{}:
+	default:
+		// A delivery round is already pending.
+	}
+}
+
+// pendingDeliveries returns the number of pending deliveries of each
+// webhook registered by an account.
+func (wn *WebhookNotifier) pendingDeliveries() (map[string]int, error) {
+	deliveries, err := wn.cfg.db.fetchWebhookDeliveries()
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]int)
+	for _, delivery := range deliveries {
+		if delivery.WebhookID != "" {
+			pending[delivery.WebhookID]++
+		}
+	}
+	return pending, nil
+}
+
+// deliver posts the payload of the provided delivery to its URL. A
+// ValueNotFound error is returned if the webhook of the delivery no longer
+// exists.
+func (wn *WebhookNotifier) deliver(ctx context.Context, delivery *WebhookDelivery) error {
+	secret := wn.cfg.Secret
+	client := wn.client
+	if delivery.WebhookID != "" {
+		hook, err := wn.cfg.db.fetchWebhook(delivery.WebhookID)
+		if err != nil {
+			return err
+		}
+		secret = hook.Secret
+		client = wn.accountClient
+	}
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.UUID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// deliveryQueue returns the key of the queue of the provided delivery.
+// Deliveries are queued per host, the operator configured URLs separately
+// from the webhooks registered by accounts.
+func deliveryQueue(delivery *WebhookDelivery) string {
+	host := delivery.URL
+	u, err := url.Parse(delivery.URL)
+	if err == nil {
+		host = u.Host
+	}
+	if delivery.WebhookID == "" {
+		return "operator " + host
+	}
+	return "account " + host
+}
+
+// processDeliveries attempts the webhook deliveries which are due. The due
+// deliveries of each host are attempted in order by a goroutine of their
+// own, hosts whose previous deliveries are still being attempted are
+// skipped until the next round. At most maxWebhookHosts account hosts are
+// attempted concurrently, operator deliveries are not limited so they are
+// not delayed by unresponsive account webhooks.
+func (wn *WebhookNotifier) processDeliveries(ctx context.Context) {
+	deliveries, err := wn.cfg.db.fetchWebhookDeliveries()
+	if err != nil {
+		log.Errorf("unable to fetch webhook deliveries: %v", err)
+		return
+	}
+
+	now := time.Now().UnixNano()
+	queues := make(map[string][]*WebhookDelivery)
+	var keys []string
+	for _, delivery := range deliveries {
+		if delivery.NextAttempt > now {
+			continue
+		}
+		key := deliveryQueue(delivery)
+		if _, ok := queues[key]; !ok {
+			keys = append(keys, key)
+		}
+		queues[key] = append(queues[key], delivery)
+	}
+
+	for _, key := range keys {
+		queue := queues[key]
+		operator := queue[0].WebhookID == ""
+
+		wn.queueMtx.Lock()
+		_, busy := wn.queues[key]
+		if busy || (!operator && wn.activeHosts >= maxWebhookHosts) {
+			wn.queueMtx.Unlock()
+			continue
+		}
+		wn.queues[key] = struct{}{}
+		if !operator {
+			wn.activeHosts++
+		}
+		wn.queueMtx.Unlock()
+
+		wn.queueWg.Add(1)
+		go func(key string, queue []*WebhookDelivery, operator bool) {
+			defer wn.queueWg.Done()
+			for _, delivery := range queue {
+				if ctx.Err() != nil {
+					break
+				}
+				wn.attempt(ctx, delivery)
+			}
+
+			wn.queueMtx.Lock()
+			delete(wn.queues, key)
+			if !operator {
+				wn.activeHosts--
+			}
+			wn.queueMtx.Unlock()
+		}(key, queue, operator)
+	}
+}
+
+// attempt attempts the provided delivery. Failed deliveries are rescheduled
+// with a growing delay until the maximum number of attempts is reached,
+// they are dropped afterwards.
+func (wn *WebhookNotifier) attempt(ctx context.Context, delivery *WebhookDelivery) {
+	err := wn.deliver(ctx, delivery)
+	if err == nil || errors.Is(err, errs.ValueNotFound) {
+		if err != nil {
+			log.Debugf("Dropping %s webhook delivery %s of a removed "+
+				"webhook", delivery.Event, delivery.UUID)
+		}
+		err = wn.cfg.db.deleteWebhookDelivery(delivery.UUID)
+		if err != nil {
+			log.Errorf("unable to remove webhook delivery: %v", err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= wn.cfg.MaxAttempts {
+		log.Errorf("Dropping %s webhook delivery %s to %s after %d "+
+			"attempts: %v", delivery.Event, delivery.UUID, delivery.URL,
+			delivery.Attempts, err)
+		err = wn.cfg.db.deleteWebhookDelivery(delivery.UUID)
+		if err != nil {
+			log.Errorf("unable to remove webhook delivery: %v", err)
+		}
+		return
+	}
+
+	delay := blockEventRetryDelay(delivery.Attempts, wn.cfg.RetryDelay,
+		wn.cfg.MaxRetryDelay)
+	delivery.NextAttempt = time.Now().Add(delay).UnixNano()
+	log.Debugf("Unable to deliver %s webhook delivery %s to %s, "+
+		"retrying in %v: %v", delivery.Event, delivery.UUID, delivery.URL,
+		delay, err)
+	err = wn.cfg.db.updateWebhookDelivery(delivery)
+	if err != nil {
+		log.Errorf("unable to update webhook delivery: %v", err)
+	}
+}
+
+// run delivers queued webhook events until the provided context is
+// cancelled.
+// This should be run as a goroutine.
+func (wn *WebhookNotifier) run(ctx context.Context) {
+	wn.processDeliveries(ctx)
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wn.queueWg.Wait()
+			wn.cfg.HubWg.Done()
+			return
+
+		case <-wn.wakeCh:
+			wn.processDeliveries(ctx)
+
+		case <-ticker.C:
+			wn.processDeliveries(ctx)
+		}
+	}
+}
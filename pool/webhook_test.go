@@ -0,0 +1,334 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+// tWebhookRequest is a webhook delivery request received by a test server.
+type tWebhookRequest struct {
+	path      string
+	event     string
+	delivery  string
+	signature string
+	payload   WebhookPayload
+	body      []byte
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/hook", true},
+		{"http://127.0.0.1:8080", true},
+		{"ftp://example.com", false},
+		{"/hook", false},
+		{"https://", false},
+		{"://example.com", false},
+	}
+	for _, test := range tests {
+		err := ValidateWebhookURL(test.url)
+		if test.valid && err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.url, err)
+		}
+		if !test.valid && !errors.Is(err, errs.Parse) {
+			t.Fatalf("%s: expected a parse error, got %v", test.url, err)
+		}
+	}
+}
+
+func TestValidateAccountWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/hook", true},
+		{"https://93.184.216.34:8443/hook", true},
+		{"http://example.com/hook", false},
+		{"https://localhost/hook", false},
+		{"https://127.0.0.1:8080", false},
+		{"https://10.0.0.1", false},
+		{"https://169.254.169.254/latest", false},
+		{"https://[::1]/hook", false},
+		{"https://[::ffff:192.168.1.1]/hook", false},
+		{"ftp://example.com", false},
+	}
+	for _, test := range tests {
+		err := validateAccountWebhookURL(test.url)
+		if test.valid && err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.url, err)
+		}
+		if !test.valid && !errors.Is(err, errs.Parse) {
+			t.Fatalf("%s: expected a parse error, got %v", test.url, err)
+		}
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+
+	var mtx sync.Mutex
+	var requests []*tWebhookRequest
+	failing := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("unable to read request body: %v", err)
+		}
+		req := &tWebhookRequest{
+			path:      r.URL.Path,
+			event:     r.Header.Get(WebhookEventHeader),
+			delivery:  r.Header.Get(WebhookDeliveryHeader),
+			signature: r.Header.Get(WebhookSignatureHeader),
+			body:      body,
+		}
+		err = json.Unmarshal(body, &req.payload)
+		if err != nil {
+			t.Errorf("unable to decode payload: %v", err)
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		requests = append(requests, req)
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	// received returns the requests received since the last call, keyed by
+	// path.
+	received := func() map[string]*tWebhookRequest {
+		mtx.Lock()
+		defer mtx.Unlock()
+		reqs := make(map[string]*tWebhookRequest, len(requests))
+		for _, req := range requests {
+			reqs[req.path] = req
+		}
+		requests = nil
+		return reqs
+	}
+
+	hook, err := newWebhook(xID, srv.URL+"/account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistWebhook(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wn := NewWebhookNotifier(&WebhookNotifierConfig{
+		db:          db,
+		URLs:        []string{srv.URL + "/operator"},
+		Secret:      "operatorsecret",
+		MaxAttempts: 2,
+	})
+
+	// process attempts the due deliveries and waits for the attempts to
+	// complete.
+	process := func() {
+		wn.processDeliveries(ctx)
+		wn.queueWg.Wait()
+	}
+
+	// Ensure account webhooks are not delivered to non-public addresses.
+	wn.notify(WebhookBlockFound, nil,
+		map[string]interface{}{xID: &AcceptedWork{MinedBy: xID}})
+	process()
+	if len(received()) != 0 {
+		t.Fatalf("expected no webhook requests to a non-public address")
+	}
+	deliveries, err := db.fetchWebhookDeliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 ||
+		!strings.Contains(deliveries[0].LastError, "non-public") {
+		t.Fatalf("expected a refused delivery, got %+v", deliveries)
+	}
+	err = db.deleteWebhookDelivery(deliveries[0].UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deliver account webhooks to the test server from here on.
+	wn.accountClient = srv.Client()
+
+	// Ensure the operator is sent the event data and the account webhook
+	// the data of the account, each signed with its own secret.
+	wn.notify(WebhookPayoutPublished, &WebhookPayout{Amount: 300},
+		map[string]interface{}{
+			xID: &WebhookPayout{Amount: 100},
+			yID: &WebhookPayout{Amount: 200},
+		})
+	process()
+	reqs := received()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 webhook requests, got %d", len(reqs))
+	}
+	for path, secret := range map[string]string{
+		"/operator": "operatorsecret",
+		"/account":  hook.Secret,
+	} {
+		req, ok := reqs[path]
+		if !ok {
+			t.Fatalf("expected a request to %s", path)
+		}
+		if req.event != WebhookPayoutPublished ||
+			req.payload.Event != WebhookPayoutPublished {
+			t.Fatalf("%s: unexpected event %s", path, req.event)
+		}
+		if req.delivery == "" || req.delivery != req.payload.ID {
+			t.Fatalf("%s: expected delivery id %s, got %s", path,
+				req.payload.ID, req.delivery)
+		}
+		if req.signature != SignWebhookPayload(secret, req.body) {
+			t.Fatalf("%s: invalid payload signature %s", path, req.signature)
+		}
+	}
+	opData := reqs["/operator"].payload.Data.(map[string]interface{})
+	accData := reqs["/account"].payload.Data.(map[string]interface{})
+	if opData["amount"] != float64(300) || accData["amount"] != float64(100) {
+		t.Fatalf("unexpected payout amounts %v and %v", opData["amount"],
+			accData["amount"])
+	}
+	if reqs["/operator"].payload.AccountID != "" ||
+		reqs["/account"].payload.AccountID != xID {
+		t.Fatalf("unexpected payload account ids")
+	}
+	deliveries, err = db.fetchWebhookDeliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no pending deliveries, got %d", len(deliveries))
+	}
+
+	// Ensure operator only events are not sent to account webhooks.
+	wn.notify(WebhookWalletProblem, &WalletProblem{Kind: WalletLocked}, nil)
+	process()
+	reqs = received()
+	if _, ok := reqs["/operator"]; !ok || len(reqs) != 1 {
+		t.Fatalf("expected a single operator request, got %d", len(reqs))
+	}
+
+	// Ensure failed deliveries are rescheduled and not retried before due.
+	mtx.Lock()
+	failing = true
+	mtx.Unlock()
+	wn.notify(WebhookBlockFound, &AcceptedWork{MinedBy: xID},
+		map[string]interface{}{xID: &AcceptedWork{MinedBy: xID}})
+	process()
+	if len(received()) != 2 {
+		t.Fatalf("expected 2 failed webhook requests")
+	}
+	deliveries, err = db.fetchWebhookDeliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("expected 2 pending deliveries, got %d", len(deliveries))
+	}
+	for _, delivery := range deliveries {
+		if delivery.Attempts != 1 || delivery.LastError == "" ||
+			delivery.NextAttempt <= time.Now().UnixNano() {
+			t.Fatalf("unexpected failed delivery %+v", delivery)
+		}
+	}
+	process()
+	if len(received()) != 0 {
+		t.Fatalf("expected deliveries not to be retried before due")
+	}
+
+	// Ensure deliveries of removed webhooks are dropped and deliveries
+	// failing the maximum number of attempts are dropped.
+	err = db.deleteWebhook(hook.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, delivery := range deliveries {
+		delivery.NextAttempt = 0
+		err = db.updateWebhookDelivery(delivery)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	process()
+	reqs = received()
+	if _, ok := reqs["/operator"]; !ok || len(reqs) != 1 {
+		t.Fatalf("expected a single operator request, got %d", len(reqs))
+	}
+	deliveries, err = db.fetchWebhookDeliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no pending deliveries, got %d", len(deliveries))
+	}
+
+	// Ensure an unresponsive account webhook does not delay deliveries to
+	// the operator configured URLs.
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	hook, err = newWebhook(xID, hanging.URL+"/account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.persistWebhook(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wn.notify(WebhookBlockFound, &AcceptedWork{MinedBy: xID},
+		map[string]interface{}{xID: &AcceptedWork{MinedBy: xID}})
+	wn.processDeliveries(ctx)
+	deadline := time.After(time.Second * 5)
+	for len(received()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected the operator delivery not to be delayed")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+	close(release)
+	wn.queueWg.Wait()
+
+	// Ensure the deliveries pending to an account webhook are capped.
+	deliveries, err = db.fetchWebhookDeliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, delivery := range deliveries {
+		err = db.deleteWebhookDelivery(delivery.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i <= maxPendingWebhookDeliveries; i++ {
+		wn.notify(WebhookWorkerOffline, nil,
+			map[string]interface{}{xID: &WorkerEvent{AccountID: xID}})
+	}
+	deliveries, err = db.fetchWebhookDeliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != maxPendingWebhookDeliveries {
+		t.Fatalf("expected %d pending deliveries, got %d",
+			maxPendingWebhookDeliveries, len(deliveries))
+	}
+}
@@ -0,0 +1,518 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+const (
+	// defaultWorkerOfflineTimeout is the default period a tracked worker
+	// may go without submitting shares before it is considered offline.
+	defaultWorkerOfflineTimeout = time.Minute * 10
+
+	// defaultWorkerCheckInterval is the default interval between worker
+	// checks.
+	defaultWorkerCheckInterval = time.Minute
+
+	// workerRateWeight is the weight of the latest hashrate reported by a
+	// worker in its smoothed hashrate.
+	workerRateWeight = 0.25
+
+	// workerBaselineWeight is the weight of the smoothed hashrate of a
+	// worker in its baseline hashrate at each check.
+	workerBaselineWeight = 0.05
+
+	// workerBaselineSamples is the number of checks contributing to the
+	// baseline hashrate of a worker before it is compared against.
+	workerBaselineSamples = 10
+
+	// workerEventRetention is the period worker events are kept for. Workers
+	// offline for longer are no longer tracked.
+	workerEventRetention = time.Hour * 24 * 30
+
+	// maxWorkersPerAccount is the maximum number of workers tracked for an
+	// account at any time. Workers of an account beyond the limit are not
+	// tracked until tracked workers are forgotten or expire.
+	maxWorkersPerAccount = 100
+)
+
+const (
+	// WorkerOnline is the status of a tracked worker submitting shares at
+	// its baseline hashrate.
+	WorkerOnline = "online"
+
+	// WorkerOffline is the status, and the event kind, of a tracked worker
+	// which stopped submitting shares.
+	WorkerOffline = "offline"
+
+	// WorkerLowHashRate is the status, and the event kind, of a tracked
+	// worker whose hashrate dropped below the configured fraction of its
+	// baseline.
+	WorkerLowHashRate = "lowhashrate"
+
+	// WorkerRecovered is the event kind of an offline or low hashrate
+	// worker back online.
+	WorkerRecovered = "recovered"
+)
+
+// Worker represents a named mining client of an account expected to keep
+// submitting shares. Hashrates are in hashes per second.
+type Worker struct {
+	UUID        string  `json:"uuid"`
+	AccountID   string  `json:"accountid"`
+	Name        string  `json:"name"`
+	Miner       string  `json:"miner"`
+	HashRate    float64 `json:"hashrate"`
+	Baseline    float64 `json:"baseline"`
+	Samples     uint32  `json:"samples"`
+	Status      string  `json:"status"`
+	StatusSince int64   `json:"statussince"`
+	LastSeen    int64   `json:"lastseen"`
+	CreatedOn   int64   `json:"createdon"`
+}
+
+// WorkerEvent represents a status change of a tracked worker.
+type WorkerEvent struct {
+	UUID      string  `json:"uuid"`
+	AccountID string  `json:"accountid"`
+	Worker    string  `json:"worker"`
+	Kind      string  `json:"kind"`
+	HashRate  float64 `json:"hashrate"`
+	Baseline  float64 `json:"baseline"`
+	LastSeen  int64   `json:"lastseen"`
+	CreatedOn int64   `json:"createdon"`
+}
+
+// workerID generates a unique worker id.
+func workerID(accountID string, name string) string {
+	return accountID + name
+}
+
+// newWorkerEvent creates an event of the provided kind for the provided
+// worker.
+func newWorkerEvent(worker *Worker, kind string, createdOn int64) *WorkerEvent {
+	return &WorkerEvent{
+		UUID:      fmt.Sprintf("%d-%s", createdOn, worker.UUID),
+		AccountID: worker.AccountID,
+		Worker:    worker.Name,
+		Kind:      kind,
+		HashRate:  worker.HashRate,
+		Baseline:  worker.Baseline,
+		LastSeen:  worker.LastSeen,
+		CreatedOn: createdOn,
+	}
+}
+
+// sortWorkerEvents orders the provided worker events, newest first.
+func sortWorkerEvents(events []*WorkerEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].CreatedOn == events[j].CreatedOn {
+			return events[i].UUID > events[j].UUID
+		}
+		return events[i].CreatedOn > events[j].CreatedOn
+	})
+}
+
+// WorkerMonitorConfig contains the configuration details of a worker
+// monitor.
+type WorkerMonitorConfig struct {
+	// db represents the pool database.
+	db Database
+	// OfflineTimeout represents the period a tracked worker may go without
+	// submitting shares before it is considered offline. Zero uses the
+	// default timeout.
+	OfflineTimeout time.Duration
+	// HashRateFraction represents the fraction of its baseline hashrate a
+	// tracked worker may drop to before its hashrate is considered low.
+	// Zero disables low hashrate detection.
+	HashRateFraction float64
+	// CheckInterval represents the interval between worker checks. Zero
+	// uses the default interval.
+	CheckInterval time.Duration
+	// NotifyWebhooks queues the provided event for delivery to the operator
+	// configured URLs and the webhooks of the accounts keyed in the
+	// provided account data.
+	NotifyWebhooks func(event string, data interface{}, accountData map[string]interface{})
+	// HubWg represents the hub's waitgroup.
+	HubWg *sync.WaitGroup
+}
+
+// workerSample is the latest hashrate reported by a connection of a
+// worker.
+type workerSample struct {
+	hashRate  float64
+	updatedOn int64
+}
+
+// WorkerMonitor tracks the named workers of accounts, recording an event
+// when a worker stops submitting shares or its hashrate drops below the
+// configured fraction of its baseline, and when it recovers.
+type WorkerMonitor struct {
+	cfg     *WorkerMonitorConfig
+	workers map[string]*Worker
+	samples map[string]map[string]*workerSample
+	pending map[string]struct{}
+	counts  map[string]int
+	mtx     sync.Mutex
+
+	// startedOn is the time the monitor was created, workers are not
+	// reported offline before they had a full timeout to report after a
+	// restart.
+	startedOn int64
+
+	// writeMtx serializes database writes of tracked workers so they are
+	// not performed with the monitor lock held.
+	writeMtx sync.Mutex
+}
+
+// NewWorkerMonitor initializes a worker monitor tracking the workers
+// persisted by the database.
+func NewWorkerMonitor(cfg *WorkerMonitorConfig) (*WorkerMonitor, error) {
+	if cfg.OfflineTimeout == 0 {
+		cfg.OfflineTimeout = defaultWorkerOfflineTimeout
+	}
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = defaultWorkerCheckInterval
+	}
+	workers, err := cfg.db.fetchWorkers()
+	if err != nil {
+		return nil, err
+	}
+	wm := &WorkerMonitor{
+		cfg:     cfg,
+		workers: make(map[string]*Worker, len(workers)),
+		samples: make(map[string]map[string]*workerSample),
+		pending: make(map[string]struct{}),
+		counts:  make(map[string]int),
+
+		startedOn: time.Now().UnixNano(),
+	}
+	for _, worker := range workers {
+		wm.workers[worker.UUID] = worker
+		wm.counts[worker.AccountID]++
+	}
+	return wm, nil
+}
+
+// untrack stops tracking the referenced worker.
+//
+// This must be called with the monitor lock held.
+func (wm *WorkerMonitor) untrack(id string) {
+	worker, ok := wm.workers[id]
+	if !ok {
+		return
+	}
+	wm.counts[worker.AccountID]--
+	if wm.counts[worker.AccountID] <= 0 {
+		delete(wm.counts, worker.AccountID)
+	}
+	delete(wm.workers, id)
+	delete(wm.samples, id)
+	delete(wm.pending, id)
+}
+
+// record notes the hashrate reported by the referenced connection of a
+// worker which submitted shares, tracking the worker if it is new and the
+// account is below its tracked workers limit.
+func (wm *WorkerMonitor) record(accountID, name, miner, conn string, hashRate *big.Rat) {
+	now := time.Now().UnixNano()
+	rate, _ := hashRate.Float64()
+	id := workerID(accountID, name)
+
+	wm.mtx.Lock()
+	defer wm.mtx.Unlock()
+
+	worker, ok := wm.workers[id]
+	if !ok {
+		if wm.counts[accountID] >= maxWorkersPerAccount {
+			log.Debugf("Not tracking worker %s of account %s, the account "+
+				"already has %d tracked workers", name, accountID,
+				maxWorkersPerAccount)
+			return
+		}
+		worker = &Worker{
+			UUID:        id,
+			AccountID:   accountID,
+			Name:        name,
+			HashRate:    rate,
+			Status:      WorkerOnline,
+			StatusSince: now,
+			CreatedOn:   now,
+		}
+		wm.workers[id] = worker
+		wm.pending[id] = struct{}{}
+		wm.counts[accountID]++
+	}
+	worker.Miner = miner
+	worker.LastSeen = now
+
+	samples, ok := wm.samples[id]
+	if !ok {
+		samples = make(map[string]*workerSample)
+		wm.samples[id] = samples
+	}
+	samples[conn] = &workerSample{hashRate: rate, updatedOn: now}
+}
+
+// evaluate updates the hashrates and the status of the provided worker as of
+// the provided time, returning the kind of event the status change results
+// in if any.
+//
+// This must be called with the monitor lock held.
+func (wm *WorkerMonitor) evaluate(worker *Worker, now int64) string {
+	timeout := wm.cfg.OfflineTimeout.Nanoseconds()
+
+	// Combine the hashrates of the worker connections which recently
+	// submitted shares.
+	var rate float64
+	var active bool
+	for conn, sample := range wm.samples[worker.UUID] {
+		if now-sample.updatedOn > timeout {
+			delete(wm.samples[worker.UUID], conn)
+			continue
+		}
+		rate += sample.hashRate
+		active = true
+	}
+	if len(wm.samples[worker.UUID]) == 0 {
+		delete(wm.samples, worker.UUID)
+	}
+
+	status := WorkerOnline
+	switch {
+	case now-worker.LastSeen > timeout && now-wm.startedOn > timeout:
+		status = WorkerOffline
+
+	case !active:
+		// Workers seen before a restart keep their status until they
+		// report again or time out, the pool being down does not count
+		// towards the timeout.
+		return ""
+
+	default:
+		worker.HashRate += (rate - worker.HashRate) * workerRateWeight
+		if wm.cfg.HashRateFraction > 0 &&
+			worker.Samples >= workerBaselineSamples &&
+			worker.HashRate < worker.Baseline*wm.cfg.HashRateFraction {
+			status = WorkerLowHashRate
+			break
+		}
+
+		// Only healthy hashrates contribute to the baseline, a sustained
+		// drop would otherwise become the new baseline.
+		if worker.Samples == 0 {
+			worker.Baseline = worker.HashRate
+		} else {
+			worker.Baseline += (worker.HashRate - worker.Baseline) *
+				workerBaselineWeight
+		}
+		worker.Samples++
+	}
+
+	if status == worker.Status {
+		return ""
+	}
+	worker.Status = status
+	worker.StatusSince = now
+	if status == WorkerOnline {
+		return WorkerRecovered
+	}
+	return status
+}
+
+// check evaluates all tracked workers, persisting their state along with
+// the events of their status changes. Events are also sent to the
+// configured webhooks. Workers offline for longer than the event retention
+// are no longer tracked.
+func (wm *WorkerMonitor) check() {
+	now := time.Now().UnixNano()
+	expiry := now - workerEventRetention.Nanoseconds()
+
+	var events []*WorkerEvent
+	var expired []string
+	var dirty []Worker
+	wm.mtx.Lock()
+	for id, worker := range wm.workers {
+		if worker.Status == WorkerOffline && worker.LastSeen < expiry {
+			if _, ok := wm.pending[id]; !ok {
+				expired = append(expired, id)
+			}
+			wm.untrack(id)
+			log.Infof("Worker %s of account %s expired", worker.Name,
+				worker.AccountID)
+			continue
+		}
+
+		kind := wm.evaluate(worker, now)
+		if kind != "" {
+			events = append(events, newWorkerEvent(worker, kind, now))
+			switch kind {
+			case WorkerRecovered:
+				log.Infof("Worker %s of account %s recovered", worker.Name,
+					worker.AccountID)
+			default:
+				log.Warnf("Worker %s of account %s is %s", worker.Name,
+					worker.AccountID, kind)
+			}
+		}
+
+		// Offline workers only change state when their status does.
+		if kind == "" && worker.Status == WorkerOffline {
+			continue
+		}
+
+		dirty = append(dirty, *worker)
+	}
+	wm.mtx.Unlock()
+
+	wm.writeMtx.Lock()
+	for _, id := range expired {
+		err := wm.cfg.db.deleteWorker(id)
+		if err != nil && !errors.Is(err, errs.ValueNotFound) {
+			log.Errorf("unable to delete worker %s: %v", id, err)
+		}
+	}
+	for i := range dirty {
+		wm.write(&dirty[i])
+	}
+	wm.writeMtx.Unlock()
+
+	for _, event := range events {
+		err := wm.cfg.db.persistWorkerEvent(event)
+		if err != nil {
+			log.Errorf("unable to persist worker event %s: %v",
+				event.UUID, err)
+		}
+
+		var webhookEvent string
+		switch event.Kind {
+		case WorkerOffline:
+			webhookEvent = WebhookWorkerOffline
+		case WorkerLowHashRate:
+			webhookEvent = WebhookWorkerLowHashRate
+		default:
+			webhookEvent = WebhookWorkerRecovered
+		}
+		wm.cfg.NotifyWebhooks(webhookEvent, event,
+			map[string]interface{}{event.AccountID: event})
+	}
+
+	err := wm.cfg.db.pruneWorkerEvents(expiry)
+	if err != nil {
+		log.Errorf("unable to prune worker events: %v", err)
+	}
+}
+
+// write persists the provided copy of a tracked worker, skipping workers no
+// longer tracked.
+//
+// This must be called with the write lock held.
+func (wm *WorkerMonitor) write(worker *Worker) {
+	id := worker.UUID
+	wm.mtx.Lock()
+	_, tracked := wm.workers[id]
+	_, pending := wm.pending[id]
+	wm.mtx.Unlock()
+	if !tracked {
+		return
+	}
+
+	if !pending {
+		err := wm.cfg.db.updateWorker(worker)
+		if err != nil {
+			log.Errorf("unable to update worker %s: %v", id, err)
+		}
+		return
+	}
+
+	err := wm.cfg.db.persistWorker(worker)
+	if err != nil {
+		log.Errorf("unable to persist worker %s: %v", id, err)
+		return
+	}
+	wm.mtx.Lock()
+	delete(wm.pending, id)
+	wm.mtx.Unlock()
+}
+
+// accountWorkers returns the tracked workers of the provided account, ordered by
+// name.
+func (wm *WorkerMonitor) accountWorkers(accountID string) []*Worker {
+	wm.mtx.Lock()
+	defer wm.mtx.Unlock()
+
+	workers := make([]*Worker, 0)
+	for _, worker := range wm.workers {
+		if worker.AccountID == accountID {
+			w := *worker
+			workers = append(workers, &w)
+		}
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].Name < workers[j].Name
+	})
+	return workers
+}
+
+// forget stops tracking the referenced worker of the provided account.
+// Returns a ValueNotFound error if the worker is not tracked.
+func (wm *WorkerMonitor) forget(accountID string, name string) error {
+	const funcName = "forget"
+	id := workerID(accountID, name)
+
+	wm.writeMtx.Lock()
+	defer wm.writeMtx.Unlock()
+
+	wm.mtx.Lock()
+	_, tracked := wm.workers[id]
+	_, pending := wm.pending[id]
+	wm.mtx.Unlock()
+	if !tracked {
+		desc := fmt.Sprintf("%s: worker %s of account %s is not tracked",
+			funcName, name, accountID)
+		return errs.PoolError(errs.ValueNotFound, desc)
+	}
+
+	// Workers not persisted yet have nothing to delete.
+	if !pending {
+		err := wm.cfg.db.deleteWorker(id)
+		if err != nil && !errors.Is(err, errs.ValueNotFound) {
+			return err
+		}
+	}
+
+	wm.mtx.Lock()
+	wm.untrack(id)
+	wm.mtx.Unlock()
+	return nil
+}
+
+// run checks the tracked workers at the configured interval until the
+// provided context is cancelled.
+// This should be run as a goroutine.
+func (wm *WorkerMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(wm.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wm.cfg.HubWg.Done()
+			return
+
+		case <-ticker.C:
+			wm.check()
+		}
+	}
+}
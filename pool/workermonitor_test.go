@@ -0,0 +1,221 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	errs "github.com/decred/dcrpool/errors"
+)
+
+func TestWorkerMonitor(t *testing.T) {
+	db := NewMemoryDB()
+	var notified []string
+	cfg := &WorkerMonitorConfig{
+		db:               db,
+		OfflineTimeout:   time.Minute * 10,
+		HashRateFraction: 0.5,
+		NotifyWebhooks: func(event string, data interface{}, accountData map[string]interface{}) {
+			if accountData[xID] != data {
+				t.Fatalf("expected the %s event to be sent to account %s",
+					event, xID)
+			}
+			notified = append(notified, event)
+		},
+	}
+	wm, err := NewWorkerMonitor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkEvents performs a worker check and asserts the webhook events it
+	// results in.
+	checkEvents := func(events ...string) {
+		t.Helper()
+		notified = nil
+		wm.check()
+		if len(notified) != len(events) {
+			t.Fatalf("expected events %v, got %v", events, notified)
+		}
+		for i := range events {
+			if notified[i] != events[i] {
+				t.Fatalf("expected events %v, got %v", events, notified)
+			}
+		}
+	}
+
+	// status returns the persisted worker of the test account.
+	status := func() *Worker {
+		t.Helper()
+		workers, err := db.fetchWorkers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(workers) != 1 {
+			t.Fatalf("expected a single worker, got %d", len(workers))
+		}
+		return workers[0]
+	}
+
+	// age moves the activity of the tracked workers and the start of the
+	// monitor back by the provided duration.
+	age := func(d time.Duration) {
+		wm.mtx.Lock()
+		defer wm.mtx.Unlock()
+		wm.startedOn -= d.Nanoseconds()
+		for id, worker := range wm.workers {
+			worker.LastSeen -= d.Nanoseconds()
+			for _, sample := range wm.samples[id] {
+				sample.updatedOn -= d.Nanoseconds()
+			}
+		}
+	}
+
+	// Ensure workers are tracked once they submit shares and build their
+	// baseline hashrate from the reports of their connections.
+	for i := 0; i < workerBaselineSamples*5; i++ {
+		wm.record(xID, "rig", "cpu", "a", big.NewRat(60, 1))
+		wm.record(xID, "rig", "cpu", "b", big.NewRat(40, 1))
+		checkEvents()
+	}
+	worker := status()
+	if worker.Name != "rig" || worker.Status != WorkerOnline ||
+		worker.Samples != workerBaselineSamples*5 {
+		t.Fatalf("unexpected worker %+v", worker)
+	}
+	if worker.Baseline < 95 || worker.Baseline > 100 {
+		t.Fatalf("expected a baseline hashrate of 100, got %f",
+			worker.Baseline)
+	}
+
+	// Ensure a sustained drop of the hashrate is reported once and does not
+	// lower the baseline.
+	age(time.Minute * 11)
+	for i := 0; i < 3; i++ {
+		wm.record(xID, "rig", "cpu", "a", big.NewRat(20, 1))
+		checkEvents()
+	}
+	wm.record(xID, "rig", "cpu", "a", big.NewRat(20, 1))
+	checkEvents(WebhookWorkerLowHashRate)
+	wm.record(xID, "rig", "cpu", "a", big.NewRat(20, 1))
+	checkEvents()
+	worker = status()
+	if worker.Status != WorkerLowHashRate || worker.Baseline < 90 {
+		t.Fatalf("unexpected low hashrate worker %+v", worker)
+	}
+
+	// Ensure a worker back to its baseline hashrate is reported recovered.
+	for len(notified) == 0 {
+		wm.record(xID, "rig", "cpu", "a", big.NewRat(100, 1))
+		wm.check()
+	}
+	if notified[0] != WebhookWorkerRecovered || status().Status != WorkerOnline {
+		t.Fatalf("expected the worker to recover, got %v", notified)
+	}
+
+	// Ensure a worker which stopped submitting shares is reported offline
+	// once.
+	age(time.Minute * 9)
+	checkEvents()
+	age(time.Minute * 2)
+	checkEvents(WebhookWorkerOffline)
+	checkEvents()
+	if status().Status != WorkerOffline {
+		t.Fatalf("expected the worker to be offline")
+	}
+
+	// Ensure tracked workers are restored by a new monitor and are not
+	// reported offline before a full timeout elapsed since the restart.
+	worker = status()
+	worker.Status = WorkerOnline
+	worker.LastSeen = time.Now().Add(-time.Hour).UnixNano()
+	err = db.updateWorker(worker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm, err = NewWorkerMonitor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEvents()
+	wm.mtx.Lock()
+	wm.startedOn -= (time.Minute * 9).Nanoseconds()
+	wm.mtx.Unlock()
+	checkEvents()
+	age(time.Minute * 2)
+	checkEvents(WebhookWorkerOffline)
+	wm.record(xID, "rig", "cpu", "a", big.NewRat(100, 1))
+	checkEvents(WebhookWorkerRecovered)
+
+	events, err := db.fetchWorkerEventsForAccount(xID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []string{WorkerRecovered, WorkerOffline, WorkerOffline,
+		WorkerRecovered, WorkerLowHashRate}
+	if len(events) != len(kinds) {
+		t.Fatalf("expected %d worker events, got %d", len(kinds), len(events))
+	}
+	for i, event := range events {
+		if event.Kind != kinds[i] || event.Worker != "rig" {
+			t.Fatalf("unexpected worker event %+v", event)
+		}
+	}
+
+	// Ensure forgotten workers are no longer tracked.
+	if len(wm.accountWorkers(xID)) != 1 || len(wm.accountWorkers(yID)) != 0 {
+		t.Fatalf("expected a single worker for account %s", xID)
+	}
+	err = wm.forget(xID, "rig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wm.accountWorkers(xID)) != 0 {
+		t.Fatalf("expected no workers for account %s", xID)
+	}
+	workers, err := db.fetchWorkers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workers) != 0 {
+		t.Fatalf("expected no persisted workers, got %d", len(workers))
+	}
+	err = wm.forget(xID, "rig")
+	if !errors.Is(err, errs.ValueNotFound) {
+		t.Fatalf("expected a value not found error, got %v", err)
+	}
+
+	// Ensure the number of workers tracked per account is capped.
+	for i := 0; i <= maxWorkersPerAccount; i++ {
+		wm.record(xID, fmt.Sprintf("rig%d", i), "cpu", "a", big.NewRat(100, 1))
+	}
+	if len(wm.accountWorkers(xID)) != maxWorkersPerAccount {
+		t.Fatalf("expected %d workers for account %s",
+			maxWorkersPerAccount, xID)
+	}
+	checkEvents()
+
+	// Ensure workers offline for longer than the event retention are no
+	// longer tracked, making room for new workers.
+	age(time.Minute * 11)
+	notified = nil
+	wm.check()
+	age(workerEventRetention)
+	checkEvents()
+	if len(wm.accountWorkers(xID)) != 0 {
+		t.Fatalf("expected expired workers to no longer be tracked")
+	}
+	workers, err = db.fetchWorkers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workers) != 0 {
+		t.Fatalf("expected no persisted workers, got %d", len(workers))
+	}
+	wm.record(xID, "rig", "cpu", "a", big.NewRat(100, 1))
+	if len(wm.accountWorkers(xID)) != 1 {
+		t.Fatalf("expected a single worker for account %s", xID)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/decred/dcrpool/gui"
+	"github.com/decred/dcrpool/pool"
+)
+
+// reloadableSettings are the config options, by long name, which are applied
+// while the pool is running when the config is reloaded. Changes to any other
+// option require a restart.
+var reloadableSettings = map[string]struct{}{
+	"poolfee":           {},
+	"poolfeeaddrs":      {},
+	"maxconnperhost":    {},
+	"debuglevel":        {},
+	"designation":       {},
+	"apiallowedorigins": {},
+}
+
+// changedSettings returns the long names of the config options which differ
+// between the provided configs.
+func changedSettings(prev, next *config) []string {
+	prevV := reflect.ValueOf(prev).Elem()
+	nextV := reflect.ValueOf(next).Elem()
+	t := prevV.Type()
+
+	var changed []string
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("long")
+		if name == "" {
+			continue
+		}
+		if !reflect.DeepEqual(prevV.Field(i).Interface(),
+			nextV.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+
+	return changed
+}
+
+// reloadConfig parses and validates the config again and applies the options
+// which can be changed while the pool is running. It returns the names of the
+// changed options applied and of the changed options which require a restart
+// to take effect. Nothing is applied if the config is invalid.
+func (p *miningPool) reloadConfig() ([]string, []string, error) {
+	p.cfgMtx.Lock()
+	defer p.cfgMtx.Unlock()
+
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var applied, restart []string
+	for _, name := range changedSettings(p.cfg, cfg) {
+		if _, ok := reloadableSettings[name]; ok {
+			applied = append(applied, name)
+			continue
+		}
+		restart = append(restart, name)
+	}
+
+	// The debug levels have already been validated while loading the config.
+	err = parseAndSetDebugLevels(cfg.DebugLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.hub.ApplyConfig(&pool.ReloadableConfig{
+		PoolFee:               cfg.PoolFee,
+		PoolFeeAddrs:          cfg.poolFeeAddrs,
+		MaxConnectionsPerHost: cfg.MaxConnectionsPerHost,
+	})
+	p.gui.ApplyConfig(&gui.ReloadableConfig{
+		Designation:       cfg.Designation,
+		PoolFee:           cfg.PoolFee,
+		APIAllowedOrigins: cfg.APIAllowedOrigins,
+	})
+
+	// Only track the applied options so options requiring a restart keep
+	// being reported until the pool is restarted.
+	p.cfg.PoolFee = cfg.PoolFee
+	p.cfg.PoolFeeAddrs = cfg.PoolFeeAddrs
+	p.cfg.poolFeeAddrs = cfg.poolFeeAddrs
+	p.cfg.MaxConnectionsPerHost = cfg.MaxConnectionsPerHost
+	p.cfg.DebugLevel = cfg.DebugLevel
+	p.cfg.Designation = cfg.Designation
+	p.cfg.APIAllowedOrigins = cfg.APIAllowedOrigins
+
+	mpLog.Infof("Reloaded config, applied changes: [%s]",
+		strings.Join(applied, ", "))
+	if len(restart) > 0 {
+		mpLog.Warnf("Changes to the following settings require a "+
+			"restart to take effect: [%s]", strings.Join(restart, ", "))
+	}
+
+	return applied, restart, nil
+}